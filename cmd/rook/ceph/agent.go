@@ -25,6 +25,7 @@ import (
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util/exec"
 	"github.com/rook/rook/pkg/util/flags"
+	"github.com/rook/rook/pkg/util/metrics"
 	"github.com/spf13/cobra"
 )
 
@@ -34,7 +35,10 @@ var agentCmd = &cobra.Command{
 	Hidden: true,
 }
 
+var agentMetricsPort int
+
 func init() {
+	agentCmd.Flags().IntVar(&agentMetricsPort, "metrics-port", 0, "port to serve the prometheus metrics of the volume attach and detach operations on (0 disables the metrics)")
 	flags.SetFlagsFromEnv(agentCmd.Flags(), rook.RookEnvVarPrefix)
 	agentCmd.RunE = startAgent
 }
@@ -59,6 +63,8 @@ func startAgent(cmd *cobra.Command, args []string) error {
 		RookClientset:         rookClientset,
 	}
 
+	metrics.StartServer(agentMetricsPort)
+
 	agent := agent.New(context)
 	err = agent.Run()
 	if err != nil {
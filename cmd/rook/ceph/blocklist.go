@@ -0,0 +1,166 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var blocklistCmd = &cobra.Command{
+	Use:   "blocklist",
+	Short: "Manages the blocklist of the rbd and cephfs clients of a cluster",
+}
+
+var blocklistListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the blocked client addresses",
+	Args:  cobra.NoArgs,
+}
+
+var blocklistAddCmd = &cobra.Command{
+	Use:   "add ADDR",
+	Short: "Blocks the io of the clients at an address, or of all the clients of a node ip",
+	Args:  cobra.ExactArgs(1),
+}
+
+var blocklistRemoveCmd = &cobra.Command{
+	Use:   "remove [ADDR]",
+	Short: "Allows the io of the clients at an address again, or removes the expired entries",
+	Args:  cobra.MaximumNArgs(1),
+}
+
+var (
+	blocklistNamespace string
+	blocklistOutput    string
+	blocklistExpire    time.Duration
+	blocklistExpired   bool
+)
+
+func init() {
+	for _, command := range []*cobra.Command{blocklistListCmd, blocklistAddCmd, blocklistRemoveCmd} {
+		command.Flags().StringVar(&blocklistNamespace, "namespace", "rook-ceph", "namespace of the cluster")
+		command.Flags().StringVar(&cfg.dataDir, "config-dir", "/var/lib/rook", "directory of the cluster config generated by the operator")
+		flags.SetFlagsFromEnv(command.Flags(), rook.RookEnvVarPrefix)
+		blocklistCmd.AddCommand(command)
+	}
+	blocklistListCmd.Flags().StringVarP(&blocklistOutput, "output", "o", outputTable, "output format (table or json)")
+	blocklistAddCmd.Flags().DurationVar(&blocklistExpire, "expire", time.Hour, "duration of the block")
+	blocklistRemoveCmd.Flags().BoolVar(&blocklistExpired, "expired", false, "remove all the expired entries instead of an address")
+
+	blocklistListCmd.RunE = listBlocklist
+	blocklistAddCmd.RunE = addToBlocklist
+	blocklistRemoveCmd.RunE = removeFromBlocklist
+}
+
+func listBlocklist(cmd *cobra.Command, args []string) error {
+	if blocklistOutput != outputTable && blocklistOutput != outputJSON {
+		return fmt.Errorf("invalid output format %q. must be %s or %s", blocklistOutput, outputTable, outputJSON)
+	}
+
+	entries, err := client.ListBlocklist(createContext(), blocklistNamespace)
+	if err != nil {
+		return err
+	}
+	return printBlocklist(os.Stdout, entries, time.Now())
+}
+
+func addToBlocklist(cmd *cobra.Command, args []string) error {
+	if blocklistExpire <= 0 {
+		return fmt.Errorf("invalid expire %s. must be positive", blocklistExpire)
+	}
+
+	addr := blocklistAddr(args[0])
+	if err := client.AddToBlocklist(createContext(), blocklistNamespace, addr, blocklistExpire); err != nil {
+		return err
+	}
+	fmt.Printf("blocked %s for %s\n", addr, blocklistExpire)
+	return nil
+}
+
+func removeFromBlocklist(cmd *cobra.Command, args []string) error {
+	if blocklistExpired == (len(args) == 1) {
+		return fmt.Errorf("either an address or --expired is required")
+	}
+
+	context := createContext()
+	var addrs []string
+	if blocklistExpired {
+		entries, err := client.ListBlocklist(context, blocklistNamespace)
+		if err != nil {
+			return err
+		}
+		addrs = expiredAddrs(entries, time.Now())
+	} else {
+		addrs = []string{blocklistAddr(args[0])}
+	}
+
+	for _, addr := range addrs {
+		if err := client.RemoveFromBlocklist(context, blocklistNamespace, addr); err != nil {
+			return err
+		}
+		fmt.Printf("removed %s\n", addr)
+	}
+	return nil
+}
+
+// blocklistAddr returns the address blocking all the clients of a node if the address is an ip
+func blocklistAddr(addr string) string {
+	if net.ParseIP(addr) != nil {
+		return client.NodeBlocklistAddr(addr)
+	}
+	return addr
+}
+
+func expiredAddrs(entries []client.BlocklistEntry, now time.Time) []string {
+	var addrs []string
+	for _, e := range entries {
+		if e.Expired(now) {
+			addrs = append(addrs, e.Addr)
+		}
+	}
+	return addrs
+}
+
+// printBlocklist writes the entries as json or as a table with their expiration
+func printBlocklist(out io.Writer, entries []client.BlocklistEntry, now time.Time) error {
+	if blocklistOutput == outputJSON {
+		b, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDR\tUNTIL\tEXPIRED")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%t\n", e.Addr, e.Until, e.Expired(now))
+	}
+	return w.Flush()
+}
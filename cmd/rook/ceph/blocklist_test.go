@@ -0,0 +1,53 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintBlocklist(t *testing.T) {
+	entries := []client.BlocklistEntry{
+		{Addr: "10.0.0.1:0/0", Until: "2018-12-01 10:00:00.000000"},
+		{Addr: "10.0.0.2:0/3710147553", Until: "2118-12-01 10:00:00.000000"},
+	}
+	now := time.Now()
+
+	blocklistOutput = outputTable
+	var out bytes.Buffer
+	assert.Nil(t, printBlocklist(&out, entries, now))
+	assert.Equal(t, "ADDR                   UNTIL                       EXPIRED\n"+
+		"10.0.0.1:0/0           2018-12-01 10:00:00.000000  true\n"+
+		"10.0.0.2:0/3710147553  2118-12-01 10:00:00.000000  false\n", out.String())
+
+	blocklistOutput = outputJSON
+	out.Reset()
+	assert.Nil(t, printBlocklist(&out, entries, now))
+	assert.Contains(t, out.String(), `"addr": "10.0.0.1:0/0"`)
+
+	assert.Equal(t, []string{"10.0.0.1:0/0"}, expiredAddrs(entries, now))
+}
+
+func TestBlocklistAddr(t *testing.T) {
+	assert.Equal(t, "10.0.0.1:0/0", blocklistAddr("10.0.0.1"))
+	assert.Equal(t, "10.0.0.2:0/3710147553", blocklistAddr("10.0.0.2:0/3710147553"))
+}
@@ -67,6 +67,15 @@ func AddCommands(command *cobra.Command) {
 	command.AddCommand(rgwCmd)
 	command.AddCommand(mdsCmd)
 	command.AddCommand(configCmd)
+	command.AddCommand(exporterCmd)
+	command.AddCommand(rbdCmd)
+	command.AddCommand(blocklistCmd)
+	command.AddCommand(mirrorCmd)
+	command.AddCommand(planCmd)
+	command.AddCommand(importCmd)
+	command.AddCommand(objectCredentialsCmd)
+	command.AddCommand(metadataBackupCmd)
+	command.AddCommand(preflightCmd)
 }
 
 func createContext() *clusterd.Context {
@@ -41,13 +41,15 @@ var configCmd = &cobra.Command{
 }
 
 var (
-	configKeyring  string
-	configUsername string
+	configKeyring       string
+	configUsername      string
+	configMonDNSSRVName string
 )
 
 func init() {
 	configCmd.Flags().StringVar(&configKeyring, "keyring", "", "the daemon keyring")
 	configCmd.Flags().StringVar(&configUsername, "username", "", "the daemon username")
+	configCmd.Flags().StringVar(&configMonDNSSRVName, "mon-dns-srv-name", "", "the name of the SRV records of the mons, used instead of the mon endpoints if set")
 	addCephFlags(configCmd)
 
 	flags.SetFlagsFromEnv(configCmd.Flags(), rook.RookEnvVarPrefix)
@@ -72,6 +74,7 @@ func initConfig(cmd *cobra.Command, args []string) error {
 
 	clusterInfo.Monitors = mondaemon.ParseMonEndpoints(cfg.monEndpoints)
 	clusterInfo.Name = "ceph"
+	clusterInfo.MonDNSSRVName = configMonDNSSRVName
 	context := createContext()
 
 	keyringPath := "/etc/ceph/keyring"
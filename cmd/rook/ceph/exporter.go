@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/daemon/ceph/exporter"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var exporterCmd = &cobra.Command{
+	Use:    "exporter",
+	Short:  "Exports the perf counters of the ceph daemons of the node",
+	Hidden: true,
+}
+
+var exporterPort int
+
+func init() {
+	exporterCmd.Flags().IntVar(&exporterPort, "port", exporter.DefaultPort, "port to serve the prometheus metrics of the perf counters on")
+	addCephFlags(exporterCmd)
+
+	flags.SetFlagsFromEnv(exporterCmd.Flags(), rook.RookEnvVarPrefix)
+
+	exporterCmd.RunE = startExporter
+}
+
+func startExporter(cmd *cobra.Command, args []string) error {
+	required := []string{"cluster-name"}
+	if err := flags.VerifyRequiredFlags(exporterCmd, required); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+
+	rook.LogStartupInfo(exporterCmd.Flags())
+
+	// the admin sockets of the daemons are under their data dir on the node
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(exporter.New(createContext(), cfg.dataDir))
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	logger.Infof("serving the perf counters of cluster %s on port %d", clusterInfo.Name, exporterPort)
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", exporterPort), mux); err != nil {
+		rook.TerminateFatal(fmt.Errorf("failed to serve the perf counters. %+v", err))
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/rook/rook/cmd/rook/rook"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// the command runs on an admin node of the existing cluster, with its config and admin keyring in /etc/ceph
+const (
+	importClusterName = "ceph"
+	importConfigDir   = "/etc"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Prints the manifests with which the operator takes over an existing ceph cluster",
+	Args:  cobra.NoArgs,
+}
+
+var (
+	importNamespace       string
+	importCephImage       string
+	importDataDirHostPath string
+)
+
+// clusterImport is the inventory of an existing cluster taken over by the operator
+type clusterImport struct {
+	status      client.CephStatus
+	monSecret   string
+	adminSecret string
+	osds        []client.OSDMetadata
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importNamespace, "namespace", "rook-ceph", "namespace of the cluster to create")
+	importCmd.Flags().StringVar(&importCephImage, "ceph-image", "ceph/ceph:v13", "ceph image of the daemons, matching the version of the existing cluster")
+	importCmd.Flags().StringVar(&importDataDirHostPath, "data-dir-host-path", "/var/lib/rook", "path on the hosts where the daemons keep their config and data")
+	flags.SetFlagsFromEnv(importCmd.Flags(), rook.RookEnvVarPrefix)
+
+	importCmd.RunE = importCluster
+}
+
+func importCluster(cmd *cobra.Command, args []string) error {
+	context := createContext()
+	context.ConfigDir = importConfigDir
+
+	var inventory clusterImport
+	var err error
+	if inventory.status, err = client.Status(context, importClusterName); err != nil {
+		return fmt.Errorf("failed to get the status of the cluster. %+v", err)
+	}
+	if inventory.monSecret, err = client.AuthGetKey(context, importClusterName, "mon."); err != nil {
+		return err
+	}
+	if inventory.adminSecret, err = client.AuthGetKey(context, importClusterName, client.AdminUsername); err != nil {
+		return err
+	}
+	if inventory.osds, err = client.GetOSDMetadata(context, importClusterName); err != nil {
+		return err
+	}
+
+	manifests, warnings, err := generateImport(inventory)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		logger.Warning(w)
+	}
+	_, err = io.Copy(os.Stdout, bytes.NewReader(manifests))
+	return err
+}
+
+// generateImport returns the manifests of the mon secret and endpoints and of the CephCluster that takes over the
+// cluster, with the warnings about the osds that will not be adopted by the operator
+func generateImport(inventory clusterImport) ([]byte, []string, error) {
+	clusterInfo := &cephconfig.ClusterInfo{
+		FSID:          inventory.status.FSID,
+		MonitorSecret: inventory.monSecret,
+		AdminSecret:   inventory.adminSecret,
+		Monitors:      map[string]*cephconfig.MonInfo{},
+	}
+	for _, m := range inventory.status.MonMap.Mons {
+		// the address of a mon is reported with its nonce, as in 10.0.0.1:6789/0
+		endpoint := strings.Split(m.Address, "/")[0]
+		clusterInfo.Monitors[m.Name] = &cephconfig.MonInfo{Name: m.Name, Endpoint: endpoint}
+	}
+	secret, configMap, err := mon.ImportResources(importNamespace, clusterInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+	configMap.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+
+	monCount := len(clusterInfo.Monitors)
+	if monCount < mon.DefaultMonCount {
+		monCount = mon.DefaultMonCount
+	}
+	if monCount > mon.MaxMonCount {
+		monCount = mon.MaxMonCount
+	}
+	nodes, warnings := importNodes(inventory.osds)
+
+	cluster := &cephv1.CephCluster{
+		TypeMeta: metav1.TypeMeta{APIVersion: "ceph.rook.io/v1", Kind: "CephCluster"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      importNamespace,
+			Namespace: importNamespace,
+		},
+		Spec: cephv1.ClusterSpec{
+			CephVersion:     cephv1.CephVersionSpec{Image: importCephImage},
+			DataDirHostPath: importDataDirHostPath,
+			Mon:             cephv1.MonSpec{Count: monCount},
+			// the daemons and clients outside of kubernetes must reach the rook mons once the imported mons are retired
+			Network: rookalpha.NetworkSpec{HostNetwork: true},
+			Storage: rookalpha.StorageScopeSpec{Nodes: nodes},
+		},
+	}
+
+	var manifests bytes.Buffer
+	for _, obj := range []interface{}{secret, configMap, cluster} {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate the manifests. %+v", err)
+		}
+		manifests.WriteString("---\n")
+		manifests.Write(b)
+	}
+	return manifests.Bytes(), warnings, nil
+}
+
+// importNodes returns the storage nodes of the cluster with the devices of their bluestore osds. The nodes must have
+// the hostname of the osds as kubernetes node name.
+func importNodes(osds []client.OSDMetadata) ([]rookalpha.Node, []string) {
+	var warnings []string
+	devices := map[string]map[string]bool{}
+	for _, osd := range osds {
+		if osd.ObjectStore != "bluestore" {
+			warnings = append(warnings, fmt.Sprintf("%s osd %d on host %s cannot be adopted and must be migrated to bluestore", osd.ObjectStore, osd.ID, osd.Hostname))
+			continue
+		}
+		if _, ok := devices[osd.Hostname]; !ok {
+			devices[osd.Hostname] = map[string]bool{}
+		}
+
+		var names []string
+		if osd.Devices != "" {
+			names = strings.Split(osd.Devices, ",")
+		} else if osd.BlockPartPath != "" && !strings.HasPrefix(path.Base(osd.BlockPartPath), "dm-") {
+			names = []string{path.Base(osd.BlockPartPath)}
+		} else {
+			// the osd is still adopted from the ceph-volume volumes found on the node
+			warnings = append(warnings, fmt.Sprintf("unknown device of osd %d on host %s. add the device to the node in the CephCluster", osd.ID, osd.Hostname))
+		}
+		for _, name := range names {
+			devices[osd.Hostname][name] = true
+		}
+	}
+
+	var hostnames []string
+	for hostname := range devices {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+
+	var nodes []rookalpha.Node
+	for _, hostname := range hostnames {
+		var names []string
+		for name := range devices[hostname] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		node := rookalpha.Node{Name: hostname}
+		for _, name := range names {
+			node.Devices = append(node.Devices, rookalpha.Device{Name: name})
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, warnings
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+)
+
+func TestGenerateImport(t *testing.T) {
+	importNamespace = "rook-ceph"
+	importCephImage = "ceph/ceph:v13"
+	importDataDirHostPath = "/var/lib/rook"
+
+	var inventory clusterImport
+	inventory.status.FSID = "4bfe8b72-5e69-4330-b6c0-4d914db8ab89"
+	inventory.status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "host1", Rank: 0, Address: "10.0.0.1:6789/0"},
+		{Name: "host2", Rank: 1, Address: "10.0.0.2:6789/0"},
+	}
+	inventory.monSecret = "monsecret"
+	inventory.adminSecret = "adminsecret"
+	inventory.osds = []client.OSDMetadata{
+		{ID: 0, Hostname: "host2", ObjectStore: "bluestore", Devices: "sdb"},
+		{ID: 1, Hostname: "host1", ObjectStore: "bluestore", Devices: "sdc,nvme0n1"},
+		{ID: 2, Hostname: "host1", ObjectStore: "bluestore", Devices: "sdb,nvme0n1"},
+		{ID: 3, Hostname: "host1", ObjectStore: "filestore", Devices: "sdd"},
+		{ID: 4, Hostname: "host3", ObjectStore: "bluestore", BlockPartPath: "/dev/dm-0"},
+	}
+
+	manifests, warnings, err := generateImport(inventory)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(warnings))
+
+	cluster, err := parseClusterManifest(manifests)
+	assert.Nil(t, err)
+	assert.Equal(t, "rook-ceph", cluster.Namespace)
+	assert.Equal(t, cephv1.CephVersionSpec{Image: "ceph/ceph:v13"}, cluster.Spec.CephVersion)
+	assert.Equal(t, mon.DefaultMonCount, cluster.Spec.Mon.Count)
+	assert.True(t, cluster.Spec.Network.HostNetwork)
+	nodes := cluster.Spec.Storage.Nodes
+	assert.Equal(t, 3, len(nodes))
+	assert.Equal(t, "host1", nodes[0].Name)
+	assert.Equal(t, 3, len(nodes[0].Devices))
+	assert.Equal(t, "nvme0n1", nodes[0].Devices[0].Name)
+	assert.Equal(t, "sdb", nodes[1].Devices[0].Name)
+	assert.Equal(t, "host3", nodes[2].Name)
+	assert.Equal(t, 0, len(nodes[2].Devices))
+
+	docs := strings.Split(string(manifests), "---\n")
+	assert.Equal(t, 4, len(docs))
+	var configMap v1.ConfigMap
+	assert.Nil(t, yaml.Unmarshal([]byte(docs[2]), &configMap))
+	assert.Equal(t, "host1,host2", configMap.Data[mon.ImportedMonsKey])
+	assert.Contains(t, configMap.Data[mon.EndpointDataKey], "host1=10.0.0.1:6789")
+}
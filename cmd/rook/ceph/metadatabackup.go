@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/operator/ceph/backup"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var metadataBackupCmd = &cobra.Command{
+	Use:    "metadata-backup",
+	Short:  "Writes the archive of a metadata backup to a directory and deletes the archives beyond the retention",
+	Hidden: true,
+}
+
+var (
+	metadataBackupArchive   string
+	metadataBackupDir       string
+	metadataBackupName      string
+	metadataBackupRetention int
+)
+
+func init() {
+	metadataBackupCmd.Flags().StringVar(&metadataBackupArchive, "archive", "", "path of the archive to write")
+	metadataBackupCmd.Flags().StringVar(&metadataBackupDir, "backup-dir", "", "directory keeping the archives")
+	metadataBackupCmd.Flags().StringVar(&metadataBackupName, "backup-name", "", "name of the CephMetadataBackup")
+	metadataBackupCmd.Flags().IntVar(&metadataBackupRetention, "retention", backup.DefaultRetention, "number of archives of the backup to keep")
+	flags.SetFlagsFromEnv(metadataBackupCmd.Flags(), rook.RookEnvVarPrefix)
+
+	metadataBackupCmd.RunE = storeMetadataBackup
+}
+
+func storeMetadataBackup(cmd *cobra.Command, args []string) error {
+	required := []string{"archive", "backup-dir", "backup-name"}
+	if err := flags.VerifyRequiredFlags(metadataBackupCmd, required); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+
+	rook.LogStartupInfo(metadataBackupCmd.Flags())
+
+	archive, err := ioutil.ReadFile(metadataBackupArchive)
+	if err != nil {
+		rook.TerminateFatal(fmt.Errorf("failed to read archive %s. %+v", metadataBackupArchive, err))
+	}
+	store := &backup.DirStore{Dir: metadataBackupDir}
+	if err := store.Put(path.Base(metadataBackupArchive), archive); err != nil {
+		rook.TerminateFatal(err)
+	}
+	if err := backup.Prune(store, metadataBackupName, metadataBackupRetention); err != nil {
+		rook.TerminateFatal(err)
+	}
+
+	logger.Infof("wrote backup %s to %s", path.Base(metadataBackupArchive), metadataBackupDir)
+	return nil
+}
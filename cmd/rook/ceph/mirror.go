@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"fmt"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Manages the mirroring of the pools and filesystems of a cluster with the peer clusters",
+}
+
+var mirrorTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Creates the bootstrap token a peer cluster imports to mirror a pool or a filesystem with this cluster",
+	Args:  cobra.NoArgs,
+}
+
+var (
+	mirrorNamespace  string
+	mirrorPool       string
+	mirrorFilesystem string
+	mirrorClient     string
+	mirrorSiteName   string
+)
+
+func init() {
+	mirrorTokenCmd.Flags().StringVar(&mirrorNamespace, "namespace", "rook-ceph", "namespace of the cluster")
+	mirrorTokenCmd.Flags().StringVar(&mirrorPool, "pool", "", "block pool mirrored with the peer cluster")
+	mirrorTokenCmd.Flags().StringVar(&mirrorFilesystem, "filesystem", "", "filesystem the peer cluster mirrors its snapshots to")
+	mirrorTokenCmd.Flags().StringVar(&mirrorClient, "client", "client.mirror-remote", "ceph user of the peer cluster created for a filesystem")
+	mirrorTokenCmd.Flags().StringVar(&mirrorSiteName, "site-name", "", "name of this cluster for the peers of a filesystem")
+	mirrorTokenCmd.Flags().StringVar(&cfg.dataDir, "config-dir", "/var/lib/rook", "directory of the cluster config generated by the operator")
+	flags.SetFlagsFromEnv(mirrorTokenCmd.Flags(), rook.RookEnvVarPrefix)
+	mirrorCmd.AddCommand(mirrorTokenCmd)
+
+	mirrorTokenCmd.RunE = createMirrorToken
+}
+
+func createMirrorToken(cmd *cobra.Command, args []string) error {
+	if err := validateMirrorTokenFlags(); err != nil {
+		return err
+	}
+
+	var token string
+	var err error
+	if mirrorPool != "" {
+		token, err = client.CreatePoolPeerBootstrapToken(createContext(), mirrorNamespace, mirrorPool)
+	} else {
+		token, err = client.CreateFilesystemPeerBootstrapToken(createContext(), mirrorNamespace, mirrorFilesystem, mirrorClient, mirrorSiteName)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
+
+func validateMirrorTokenFlags() error {
+	if (mirrorPool == "") == (mirrorFilesystem == "") {
+		return fmt.Errorf("either --pool or --filesystem is required")
+	}
+	if mirrorFilesystem != "" && mirrorSiteName == "" {
+		return fmt.Errorf("--site-name is required for a filesystem")
+	}
+	return nil
+}
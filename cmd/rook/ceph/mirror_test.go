@@ -0,0 +1,40 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMirrorTokenFlags(t *testing.T) {
+	defer func() { mirrorPool, mirrorFilesystem, mirrorSiteName = "", "", "" }()
+
+	// either a pool or a filesystem
+	assert.NotNil(t, validateMirrorTokenFlags())
+	mirrorPool = "replicapool"
+	assert.Nil(t, validateMirrorTokenFlags())
+	mirrorFilesystem = "myfs"
+	assert.NotNil(t, validateMirrorTokenFlags())
+
+	// the filesystems require the site name
+	mirrorPool = ""
+	assert.NotNil(t, validateMirrorTokenFlags())
+	mirrorSiteName = "site-a"
+	assert.Nil(t, validateMirrorTokenFlags())
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	objectuser "github.com/rook/rook/pkg/operator/ceph/object/user"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var objectCredentialsCmd = &cobra.Command{
+	Use:   "object-credentials",
+	Short: "Prints the credential bundle of an object store user, for the clients outside of the cluster",
+	Args:  cobra.NoArgs,
+}
+
+var (
+	objectCredentialsNamespace string
+	objectCredentialsStore     string
+	objectCredentialsUser      string
+	objectCredentialsFormat    string
+	objectCredentialsEndpoint  string
+	objectCredentialsRegion    string
+	objectCredentialsOutputDir string
+)
+
+func init() {
+	objectCredentialsCmd.Flags().StringVar(&objectCredentialsNamespace, "namespace", "rook-ceph", "namespace of the cluster")
+	objectCredentialsCmd.Flags().StringVar(&objectCredentialsStore, "store", "", "name of the object store")
+	objectCredentialsCmd.Flags().StringVar(&objectCredentialsUser, "user", "", "name of the CephObjectStoreUser")
+	objectCredentialsCmd.Flags().StringVar(&objectCredentialsFormat, "format", objectuser.BundleS3A,
+		fmt.Sprintf("format of the bundle (%s, %s or %s)", objectuser.BundleS3A, objectuser.BundleVelero, objectuser.BundleMinio))
+	objectCredentialsCmd.Flags().StringVar(&objectCredentialsEndpoint, "endpoint", "", "url of the store reachable by the clients (default is the service of the store)")
+	objectCredentialsCmd.Flags().StringVar(&objectCredentialsRegion, "region", objectuser.DefaultRegion, "region expected by the clients")
+	objectCredentialsCmd.Flags().StringVar(&objectCredentialsOutputDir, "output-dir", "", "directory to write the files of the bundle instead of printing them")
+	flags.SetFlagsFromEnv(objectCredentialsCmd.Flags(), rook.RookEnvVarPrefix)
+
+	objectCredentialsCmd.RunE = printObjectCredentials
+}
+
+func printObjectCredentials(cmd *cobra.Command, args []string) error {
+	if err := flags.VerifyRequiredFlags(objectCredentialsCmd, []string{"store", "user"}); err != nil {
+		return err
+	}
+
+	clientset, _, rookClientset, err := rook.GetClientset()
+	if err != nil {
+		return fmt.Errorf("failed to get k8s client. %+v", err)
+	}
+	context := createContext()
+	context.Clientset = clientset
+	context.RookClientset = rookClientset
+
+	name := objectuser.SecretName(objectCredentialsStore, objectCredentialsUser)
+	secret, err := clientset.CoreV1().Secrets(objectCredentialsNamespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the secret %s of user %s. %+v", name, objectCredentialsUser, err)
+	}
+
+	creds := objectuser.Credentials{
+		AccessKey: string(secret.Data["AccessKey"]),
+		SecretKey: string(secret.Data["SecretKey"]),
+		Endpoint:  objectCredentialsEndpoint,
+		Region:    objectCredentialsRegion,
+	}
+	if _, ok := secret.Data[objectuser.BundleAccessKeyName]; ok {
+		// the bundles of the user have the keys of its subuser with a restricted access
+		creds.AccessKey = string(secret.Data[objectuser.BundleAccessKeyName])
+		creds.SecretKey = string(secret.Data[objectuser.BundleSecretKeyName])
+	}
+	if creds.Endpoint == "" {
+		if creds.Endpoint, err = object.GetStoreEndpoint(context, objectCredentialsNamespace, objectCredentialsStore); err != nil {
+			return err
+		}
+	}
+	if strings.HasPrefix(creds.Endpoint, "https://") {
+		if creds.CACert, err = object.GetStoreCACert(context, objectCredentialsNamespace, objectCredentialsStore); err != nil {
+			return err
+		}
+	}
+
+	files, err := objectuser.Bundle(objectCredentialsFormat, creds)
+	if err != nil {
+		return err
+	}
+	if objectCredentialsOutputDir != "" {
+		return writeBundle(objectCredentialsOutputDir, files)
+	}
+	printBundle(os.Stdout, files)
+	return nil
+}
+
+// writeBundle writes the files of a bundle in a directory, readable only by the owner since they have the keys
+func writeBundle(dir string, files map[string]string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s. %+v", dir, err)
+	}
+	for name, content := range files {
+		filePath := path.Join(dir, name)
+		if err := ioutil.WriteFile(filePath, []byte(content), 0600); err != nil {
+			return fmt.Errorf("failed to write %s. %+v", filePath, err)
+		}
+		fmt.Printf("wrote %s\n", filePath)
+	}
+	return nil
+}
+
+// printBundle writes the files of a bundle sorted by name, each after a header with its name
+func printBundle(out io.Writer, files map[string]string) {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "==> %s <==\n%s", name, files[name])
+	}
+}
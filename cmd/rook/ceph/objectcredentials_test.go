@@ -0,0 +1,30 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintBundle(t *testing.T) {
+	var out bytes.Buffer
+	printBundle(&out, map[string]string{"velero-config.yaml": "region: us-east-1\n", "cloud": "[default]\n"})
+	assert.Equal(t, "==> cloud <==\n[default]\n\n==> velero-config.yaml <==\nregion: us-east-1\n", out.String())
+}
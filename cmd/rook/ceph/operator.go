@@ -23,14 +23,20 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
 	"github.com/rook/rook/pkg/operator/ceph"
+	"github.com/rook/rook/pkg/operator/ceph/api"
+	"github.com/rook/rook/pkg/operator/ceph/capacity"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/provisioner"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util/flags"
+	"github.com/rook/rook/pkg/util/metrics"
 	"github.com/spf13/cobra"
 )
 
 const containerName = "rook-ceph-operator"
 
+var operatorMetricsPort int
+
 var operatorCmd = &cobra.Command{
 	Use:   "operator",
 	Short: "Runs the Ceph operator for orchestrating and managing Ceph storage in a Kubernetes cluster",
@@ -42,6 +48,18 @@ https://github.com/rook/rook`,
 func init() {
 	operatorCmd.Flags().DurationVar(&mon.HealthCheckInterval, "mon-healthcheck-interval", mon.HealthCheckInterval, "mon health check interval (duration)")
 	operatorCmd.Flags().DurationVar(&mon.MonOutTimeout, "mon-out-timeout", mon.MonOutTimeout, "mon out timeout (duration)")
+	operatorCmd.Flags().StringVar(&provisioner.OrphanedImagesPolicy, "orphaned-images-policy", provisioner.OrphanedImagesPolicy, "what to do with provisioned images not used by any volume (Ignore, Report or Delete)")
+	operatorCmd.Flags().DurationVar(&provisioner.OrphanedImagesCheckInterval, "orphaned-images-check-interval", provisioner.OrphanedImagesCheckInterval, "interval between the checks for orphaned images (duration)")
+	operatorCmd.Flags().DurationVar(&provisioner.OrphanedImagesGracePeriod, "orphaned-images-grace-period", provisioner.OrphanedImagesGracePeriod, "how long an image must be orphaned before it is deleted (duration)")
+	operatorCmd.Flags().StringVar(&provisioner.StorageQuotaPolicy, "storage-quota-policy", provisioner.StorageQuotaPolicy, "what to do when a namespace claims more ceph volumes than its storage quota annotation (Ignore, Alert or Block)")
+	operatorCmd.Flags().DurationVar(&provisioner.StorageQuotaCheckInterval, "storage-quota-check-interval", provisioner.StorageQuotaCheckInterval, "interval between the checks of the storage quotas of the namespaces (duration)")
+	operatorCmd.Flags().StringVar(&capacity.GuardrailPolicy, "capacity-guardrail-policy", capacity.GuardrailPolicy, "what to do when creating a pool, filesystem or volume would push the raw utilization of a cluster above the fullness threshold (Ignore, Alert or Block)")
+	operatorCmd.Flags().IntVar(&capacity.FullnessThresholdPercent, "capacity-fullness-threshold", capacity.FullnessThresholdPercent, "percentage of the raw capacity of a cluster above which the capacity guardrail policy applies")
+	operatorCmd.Flags().DurationVar(&provisioner.ImageTrashExpiry, "image-trash-expiry", provisioner.ImageTrashExpiry, "how long the images of deleted volumes are kept in the trash before they are purged (duration). images are deleted immediately if not set")
+	operatorCmd.Flags().IntVar(&api.Port, "admin-api-port", api.Port, "port to serve the authenticated admin api of the operator on (0 disables the api)")
+	operatorCmd.Flags().StringVar(&api.TokenSecretName, "admin-api-token-secret", api.TokenSecretName, "name of the secret in the namespace of the operator with the bearer token of the admin api, created with a random token if missing")
+	operatorCmd.Flags().StringVar(&api.TLSSecretName, "admin-api-tls-secret", api.TLSSecretName, "name of the tls secret in the namespace of the operator with the certificate of the admin api. the api only listens on localhost if not set")
+	operatorCmd.Flags().IntVar(&operatorMetricsPort, "metrics-port", 0, "port to serve the prometheus metrics of the volume provisioner and of the usage of the clusters on (0 disables the metrics)")
 	flags.SetFlagsFromEnv(operatorCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetLoggingFlags(operatorCmd.Flags())
 	operatorCmd.RunE = startOperator
@@ -81,6 +99,8 @@ func startOperator(cmd *cobra.Command, args []string) error {
 		rook.TerminateFatal(fmt.Errorf("failed to get container image. %+v\n", err))
 	}
 
+	metrics.StartServer(operatorMetricsPort)
+
 	op := operator.New(context, volumeAttachment, rookImage, pod.Spec.ServiceAccountName)
 	err = op.Run()
 	if err != nil {
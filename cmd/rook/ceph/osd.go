@@ -65,6 +65,10 @@ var osdStartCmd = &cobra.Command{
 	Short:  "Starts the osd daemon", // OSDs that were provisioned by ceph-volume
 	Hidden: true,
 }
+var osdZapCmd = &cobra.Command{
+	Use:   "zap",
+	Short: "Wipes the ceph signatures, lvm metadata and partition tables of a device previously used by an osd",
+}
 var (
 	osdDataDeviceFilter string
 	ownerRefID          string
@@ -76,6 +80,10 @@ var (
 	osdStringID         string
 	osdUUID             string
 	osdIsDevice         bool
+	osdResources        osddaemon.OSDResources
+	zapDevice           string
+	zapDryRun           bool
+	zapForce            bool
 )
 
 func addOSDFlags(command *cobra.Command) {
@@ -89,10 +97,15 @@ func addOSDFlags(command *cobra.Command) {
 	provisionCmd.Flags().StringVar(&cfg.metadataDevice, "metadata-device", "", "device to use for metadata (e.g. a high performance SSD/NVMe device)")
 	provisionCmd.Flags().BoolVar(&cfg.forceFormat, "force-format", false,
 		"true to force the format of any specified devices, even if they already have a filesystem.  BE CAREFUL!")
+	provisionCmd.Flags().StringVar(&cfg.storeConfig.ResumePolicy, "resume-policy", osdcfg.ResumePolicyResume,
+		"what to do with the devices left partially prepared by an interrupted provisioning (resume, clean or skip)")
 
 	// flags for generating the osd config
 	osdConfigCmd.Flags().IntVar(&osdID, "osd-id", -1, "osd id for which to generate config")
 	osdConfigCmd.Flags().BoolVar(&osdIsDevice, "is-device", false, "whether the osd is a device")
+	osdConfigCmd.Flags().Uint64Var(&osdResources.MemoryBytes, "osd-memory-limit", 0, "memory limit (bytes) of the osd to size the settings of the tuning profile")
+	osdConfigCmd.Flags().IntVar(&osdResources.CPUs, "osd-cpu-limit", 0, "cpu limit (cores) of the osd to size the settings of the tuning profile")
+	osdConfigCmd.Flags().IntVar(&osdResources.HugePagesMB, "osd-hugepages", 0, "size (MB) of the hugepages available to the osd")
 
 	// flag for copying the rook binaries for use by a ceph container
 	copyBinariesCmd.Flags().StringVar(&copyBinariesPath, "path", "", "Copy the rook binaries to this path for use by a ceph container")
@@ -106,12 +119,18 @@ func addOSDFlags(command *cobra.Command) {
 	osdStartCmd.Flags().StringVar(&osdUUID, "osd-uuid", "", "the osd UUID")
 	osdStartCmd.Flags().StringVar(&osdStoreType, "osd-store-type", "", "whether the osd is bluestore or filestore")
 
+	// flags for wiping a device previously used by an osd
+	osdZapCmd.Flags().StringVar(&zapDevice, "device", "", "the device to wipe (e.g. /dev/sdb)")
+	osdZapCmd.Flags().BoolVar(&zapDryRun, "dry-run", false, "only list what would be destroyed")
+	osdZapCmd.Flags().BoolVar(&zapForce, "force", false, "wipe the device even if it holds data that was not created for an osd. BE CAREFUL!")
+
 	// add the subcommands to the parent osd command
 	osdCmd.AddCommand(osdConfigCmd)
 	osdCmd.AddCommand(copyBinariesCmd)
 	osdCmd.AddCommand(provisionCmd)
 	osdCmd.AddCommand(filestoreDeviceCmd)
 	osdCmd.AddCommand(osdStartCmd)
+	osdCmd.AddCommand(osdZapCmd)
 }
 
 func addOSDConfigFlags(command *cobra.Command) {
@@ -126,6 +145,13 @@ func addOSDConfigFlags(command *cobra.Command) {
 	command.Flags().StringVar(&cfg.storeConfig.StoreType, "osd-store", "", "type of backing OSD store to use (bluestore or filestore)")
 	command.Flags().IntVar(&cfg.storeConfig.OSDsPerDevice, "osds-per-device", 1, "the number of OSDs per device")
 	command.Flags().BoolVar(&cfg.storeConfig.EncryptedDevice, "encrypted-device", false, "whether to encrypt the OSD with dmcrypt")
+	command.Flags().StringVar(&cfg.storeConfig.CacheDevice, "osd-cache-device", "", "device to use as a dm-cache for the OSD data devices (e.g. a high performance SSD/NVMe device)")
+	command.Flags().IntVar(&cfg.storeConfig.CacheSizeMB, "osd-cache-size", osdcfg.CacheDefaultSizeMB, "size (MB) of the cache of each OSD on the cache device")
+	command.Flags().StringVar(&cfg.storeConfig.CacheMode, "osd-cache-mode", osdcfg.CacheModeWritethrough, "cache mode of the OSDs (writethrough or writeback)")
+	command.Flags().StringVar(&cfg.storeConfig.Tuning, "osd-tuning", "", "tuning profile of the OSDs (latency, throughput or balanced)")
+	command.Flags().StringVar(&cfg.storeConfig.MclockProfile, "osd-mclock-profile", "", "mclock profile of the OSDs (highClientOps, balanced or highRecoveryOps)")
+	command.Flags().BoolVar(&cfg.storeConfig.Benchmark, "osd-benchmark-devices", false, "whether to benchmark the new devices before provisioning them")
+	command.Flags().IntVar(&cfg.storeConfig.WeightRampUpStep, "osd-weight-ramp-up-step", 0, "percentage of their crush weight the new OSDs gain at each step of their ramp up (0 to add them with their full weight)")
 }
 
 func init() {
@@ -137,12 +163,29 @@ func init() {
 	flags.SetFlagsFromEnv(provisionCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(filestoreDeviceCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(osdStartCmd.Flags(), rook.RookEnvVarPrefix)
+	flags.SetFlagsFromEnv(osdZapCmd.Flags(), rook.RookEnvVarPrefix)
 
 	osdConfigCmd.RunE = writeOSDConfig
 	copyBinariesCmd.RunE = copyRookBinaries
 	provisionCmd.RunE = prepareOSD
 	filestoreDeviceCmd.RunE = runFilestoreDeviceOSD
 	osdStartCmd.RunE = startOSD
+	osdZapCmd.RunE = zapOSDDevice
+}
+
+// Wipe a device previously used by an osd
+func zapOSDDevice(cmd *cobra.Command, args []string) error {
+	if err := flags.VerifyRequiredFlags(osdZapCmd, []string{"device"}); err != nil {
+		return err
+	}
+
+	commonOSDInit(osdZapCmd)
+
+	context := createContext()
+	if _, err := osddaemon.ZapDevice(context, zapDevice, zapDryRun, zapForce); err != nil {
+		rook.TerminateFatal(fmt.Errorf("failed to zap device %s. %+v", zapDevice, err))
+	}
+	return nil
 }
 
 // Start the osd daemon if provisioned by ceph-volume
@@ -219,7 +262,7 @@ func writeOSDConfig(cmd *cobra.Command, args []string) error {
 	crushLocation := strings.Join(locArgs, " ")
 	kv := k8sutil.NewConfigMapKVStore(clusterInfo.Name, clientset, metav1.OwnerReference{})
 
-	if err := osddaemon.WriteConfigFile(context, &clusterInfo, kv, osdID, osdIsDevice, cfg.storeConfig, cfg.nodeName, crushLocation); err != nil {
+	if err := osddaemon.WriteConfigFile(context, &clusterInfo, kv, osdID, osdIsDevice, cfg.storeConfig, osdResources, cfg.nodeName, crushLocation); err != nil {
 		rook.TerminateFatal(fmt.Errorf("failed to write osd config file. %+v", err))
 	}
 	return nil
@@ -247,6 +290,10 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := osddaemon.ValidateResumePolicy(cfg.storeConfig.ResumePolicy); err != nil {
+		return err
+	}
+
 	var dataDevices []osddaemon.DesiredDevice
 	if osdDataDeviceFilter != "" {
 		if cfg.devices != "" {
@@ -308,9 +355,11 @@ func commonOSDInit(cmd *cobra.Command) {
 	clusterInfo.Monitors = mondaemon.ParseMonEndpoints(cfg.monEndpoints)
 }
 
-// Parse the devices, which are comma separated. A colon indicates a non-default number of osds per device.
-// For example, one osd will be created on each of sda and sdb, with 5 osds on the nvme01 device.
-//   sda,sdb,nvme01:5
+// Parse the devices, which are comma separated. A colon indicates a non-default number of osds per device, and a
+// second colon the store type of the osds of the device if it differs from the store type of the node.
+// For example, one osd will be created on each of sda and sdb, with 5 osds on the nvme01 device and one
+// filestore osd on sdc.
+//   sda,sdb,nvme01:5,sdc:1:filestore
 func parseDevices(devices string) ([]osddaemon.DesiredDevice, error) {
 	var result []osddaemon.DesiredDevice
 	parsed := strings.Split(devices, ",")
@@ -327,6 +376,12 @@ func parseDevices(devices string) ([]osddaemon.DesiredDevice, error) {
 			}
 			d.OSDsPerDevice = count
 		}
+		if len(parts) > 2 {
+			if err := osdcfg.ValidateStoreType(parts[2]); err != nil {
+				return nil, fmt.Errorf("error parsing store type from devices (%s). %+v", devices, err)
+			}
+			d.StoreType = parts[2]
+		}
 		result = append(result, d)
 	}
 
@@ -17,8 +17,10 @@ limitations under the License.
 package ceph
 
 import (
+	"bytes"
 	"testing"
 
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -48,4 +50,43 @@ func TestParseDesiredDevices(t *testing.T) {
 	result, err = parseDevices(devices)
 	assert.Nil(t, result)
 	assert.NotNil(t, err)
+
+	// the store type of a device overrides the store type of the node
+	devices = "sda,sdb:1:filestore"
+	result, err = parseDevices(devices)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "", result[0].StoreType)
+	assert.Equal(t, "filestore", result[1].StoreType)
+	assert.Equal(t, 1, result[1].OSDsPerDevice)
+
+	// unknown store types are not allowed
+	devices = "sda:1:zfs"
+	result, err = parseDevices(devices)
+	assert.Nil(t, result)
+	assert.NotNil(t, err)
+}
+
+func TestPrintOSDs(t *testing.T) {
+	osds := []oposd.OSDSummary{
+		{ID: 0, Node: "node1", Device: "/dev/sdb", Class: "hdd", Up: true, In: true, SizeKB: 1048576, UsedKB: 524288, AvailKB: 524288, Utilization: 50},
+		{ID: 1, Node: "node2", Class: "ssd", In: false},
+	}
+
+	osdListOutput = outputTable
+	var out bytes.Buffer
+	assert.Nil(t, printOSDs(&out, osds, false))
+	assert.Equal(t, "ID  NODE   DEVICE    CLASS  STATUS\n"+
+		"0   node1  /dev/sdb  hdd    up,in\n"+
+		"1   node2            ssd    down,out\n", out.String())
+
+	out.Reset()
+	assert.Nil(t, printOSDs(&out, osds, true))
+	assert.Contains(t, out.String(), "%USE")
+	assert.Contains(t, out.String(), "1.00 GiB")
+
+	osdListOutput = outputJSON
+	out.Reset()
+	assert.Nil(t, printOSDs(&out, osds, true))
+	assert.Contains(t, out.String(), `"device": "/dev/sdb"`)
 }
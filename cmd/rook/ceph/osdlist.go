@@ -0,0 +1,134 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/util/display"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+var osdListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the osds of a cluster with their node, device, class and status",
+}
+
+var osdDFCmd = &cobra.Command{
+	Use:   "df",
+	Short: "Shows the utilization of the osds of a cluster",
+}
+
+var (
+	osdListNamespace string
+	osdListOutput    string
+)
+
+func init() {
+	for _, command := range []*cobra.Command{osdListCmd, osdDFCmd} {
+		command.Flags().StringVar(&osdListNamespace, "namespace", "rook-ceph", "namespace of the cluster")
+		command.Flags().StringVarP(&osdListOutput, "output", "o", outputTable, "output format (table or json)")
+		command.Flags().StringVar(&cfg.dataDir, "config-dir", "/var/lib/rook", "directory of the cluster config generated by the operator")
+		flags.SetFlagsFromEnv(command.Flags(), rook.RookEnvVarPrefix)
+		osdCmd.AddCommand(command)
+	}
+
+	osdListCmd.RunE = listOSDs
+	osdDFCmd.RunE = showOSDUsage
+}
+
+func listOSDs(cmd *cobra.Command, args []string) error {
+	osds, err := getOSDSummaries()
+	if err != nil {
+		return err
+	}
+	return printOSDs(os.Stdout, osds, false)
+}
+
+func showOSDUsage(cmd *cobra.Command, args []string) error {
+	osds, err := getOSDSummaries()
+	if err != nil {
+		return err
+	}
+	return printOSDs(os.Stdout, osds, true)
+}
+
+func getOSDSummaries() ([]oposd.OSDSummary, error) {
+	if osdListOutput != outputTable && osdListOutput != outputJSON {
+		return nil, fmt.Errorf("invalid output format %q. must be %s or %s", osdListOutput, outputTable, outputJSON)
+	}
+
+	clientset, _, _, err := rook.GetClientset()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init k8s client. %+v", err)
+	}
+	context := createContext()
+	context.Clientset = clientset
+
+	return oposd.ListOSDs(context, osdListNamespace)
+}
+
+// printOSDs writes the osds as json or as a table with either the placement and status or the utilization columns
+func printOSDs(out io.Writer, osds []oposd.OSDSummary, usage bool) error {
+	if osdListOutput == outputJSON {
+		b, err := json.MarshalIndent(osds, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if usage {
+		fmt.Fprintf(w, "ID\tNODE\tCLASS\tWEIGHT\tSIZE\tUSED\tAVAIL\t%%USE\tPGS\n")
+		for _, o := range osds {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%.5f\t%s\t%s\t%s\t%.2f\t%d\n", o.ID, o.Node, o.Class, o.CrushWeight,
+				display.BytesToString(o.SizeKB*1024), display.BytesToString(o.UsedKB*1024),
+				display.BytesToString(o.AvailKB*1024), o.Utilization, o.PGs)
+		}
+	} else {
+		fmt.Fprintln(w, "ID\tNODE\tDEVICE\tCLASS\tSTATUS")
+		for _, o := range osds {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", o.ID, o.Node, o.Device, o.Class, osdStatus(o))
+		}
+	}
+	return w.Flush()
+}
+
+func osdStatus(o oposd.OSDSummary) string {
+	status := "down"
+	if o.Up {
+		status = "up"
+	}
+	if o.In {
+		return status + ",in"
+	}
+	return status + ",out"
+}
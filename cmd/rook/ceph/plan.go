@@ -0,0 +1,183 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/rook/rook/cmd/rook/rook"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/discover"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Prints the nodes and devices that would get the mons and osds of a cluster manifest, without creating anything",
+	Args:  cobra.NoArgs,
+}
+
+var (
+	planClusterCRD string
+	planOutput     string
+)
+
+// clusterPlan is the placement of the daemons of a cluster manifest
+type clusterPlan struct {
+	Mons     map[string]string `json:"mons"`
+	MonError string            `json:"monError,omitempty"`
+	OSDs     []osd.NodePlan    `json:"osds"`
+}
+
+func init() {
+	planCmd.Flags().StringVar(&planClusterCRD, "cluster-crd", "", "manifest with the CephCluster to evaluate")
+	planCmd.Flags().StringVarP(&planOutput, "output", "o", outputTable, "output format (table or json)")
+	flags.SetFlagsFromEnv(planCmd.Flags(), rook.RookEnvVarPrefix)
+
+	planCmd.RunE = planCluster
+}
+
+func planCluster(cmd *cobra.Command, args []string) error {
+	if planClusterCRD == "" {
+		return fmt.Errorf("--cluster-crd is required")
+	}
+	if planOutput != outputTable && planOutput != outputJSON {
+		return fmt.Errorf("invalid output format %q. must be %s or %s", planOutput, outputTable, outputJSON)
+	}
+	manifest, err := ioutil.ReadFile(planClusterCRD)
+	if err != nil {
+		return fmt.Errorf("failed to read %s. %+v", planClusterCRD, err)
+	}
+	cluster, err := parseClusterManifest(manifest)
+	if err != nil {
+		return err
+	}
+
+	clientset, _, _, err := rook.GetClientset()
+	if err != nil {
+		return fmt.Errorf("failed to get k8s client. %+v", err)
+	}
+	context := createContext()
+	context.Clientset = clientset
+
+	// the discover daemons run in the namespace of the operator
+	inventory, err := discover.ListDevices(context, os.Getenv(k8sutil.PodNamespaceEnvVar), "" /* all nodes */)
+	if err != nil {
+		return fmt.Errorf("failed to get the devices found by the discover daemons. %+v", err)
+	}
+
+	spec := cluster.Spec
+	if spec.Mon.Count <= 0 {
+		spec.Mon.Count = mon.DefaultMonCount
+	}
+	if spec.Mon.Count > mon.MaxMonCount {
+		spec.Mon.Count = mon.MaxMonCount
+	}
+
+	plan := clusterPlan{Mons: map[string]string{}}
+	mons := mon.New(context, cluster.Namespace, spec.DataDirHostPath, "", spec.CephVersion, spec.Mon, cephv1.GetMonPlacement(spec.Placement),
+		spec.Network.HostNetwork, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
+	monNodes, err := mons.Plan()
+	if err != nil {
+		// the placement of the osds is still useful to fix the spec
+		plan.MonError = err.Error()
+	}
+	for name, node := range monNodes {
+		plan.Mons[name] = node.Name
+	}
+
+	osds := osd.New(context, cluster.Namespace, "", spec.CephVersion, spec.Storage, spec.DataDirHostPath, cephv1.GetOSDPlacement(spec.Placement),
+		spec.Network.HostNetwork, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, 0, spec.NodeExclusion, metav1.OwnerReference{})
+	plan.OSDs, err = osds.Plan(inventory)
+	if err != nil {
+		return fmt.Errorf("failed to plan the osds. %+v", err)
+	}
+
+	return printPlan(os.Stdout, plan)
+}
+
+// parseClusterManifest returns the CephCluster of a manifest that may contain other resources
+func parseClusterManifest(manifest []byte) (*cephv1.CephCluster, error) {
+	for _, doc := range bytes.Split(manifest, []byte("\n---")) {
+		var meta metav1.TypeMeta
+		if err := yaml.Unmarshal(doc, &meta); err != nil {
+			return nil, fmt.Errorf("failed to parse the manifest. %+v", err)
+		}
+		if meta.Kind != "CephCluster" {
+			continue
+		}
+		var cluster cephv1.CephCluster
+		if err := yaml.Unmarshal(doc, &cluster); err != nil {
+			return nil, fmt.Errorf("failed to parse the CephCluster. %+v", err)
+		}
+		return &cluster, nil
+	}
+	return nil, fmt.Errorf("no CephCluster found in the manifest")
+}
+
+// printPlan writes the plan as json, or as tables of the mons and of the storage of the osds
+func printPlan(out io.Writer, plan clusterPlan) error {
+	if planOutput == outputJSON {
+		b, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MON\tNODE")
+	var names []string
+	for name := range plan.Mons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, plan.Mons[name])
+	}
+	if plan.MonError != "" {
+		fmt.Fprintf(w, "error: %s\n", plan.MonError)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "OSD NODE\tDEVICES\tDIRECTORIES\tSKIPPED DEVICES")
+	for _, node := range plan.OSDs {
+		var skipped []string
+		for device, reason := range node.SkippedDevices {
+			skipped = append(skipped, fmt.Sprintf("%s (%s)", device, reason))
+		}
+		sort.Strings(skipped)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", node.Name, strings.Join(node.Devices, ","), strings.Join(node.Directories, ","), strings.Join(skipped, ", "))
+	}
+	return w.Flush()
+}
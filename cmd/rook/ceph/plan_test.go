@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseClusterManifest(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Namespace
+metadata:
+  name: rook-ceph
+---
+apiVersion: ceph.rook.io/v1
+kind: CephCluster
+metadata:
+  name: rook-ceph
+  namespace: rook-ceph
+spec:
+  mon:
+    count: 3
+  storage:
+    useAllNodes: true
+    deviceFilter: ^sd.
+`
+	cluster, err := parseClusterManifest([]byte(manifest))
+	assert.Nil(t, err)
+	assert.Equal(t, "rook-ceph", cluster.Namespace)
+	assert.Equal(t, 3, cluster.Spec.Mon.Count)
+	assert.True(t, cluster.Spec.Storage.UseAllNodes)
+	assert.Equal(t, "^sd.", cluster.Spec.Storage.DeviceFilter)
+
+	_, err = parseClusterManifest([]byte("apiVersion: v1\nkind: Namespace\n"))
+	assert.NotNil(t, err)
+}
+
+func TestPrintPlan(t *testing.T) {
+	plan := clusterPlan{
+		Mons: map[string]string{"b": "node1", "a": "node0"},
+		OSDs: []osd.NodePlan{{Name: "node0", Devices: []string{"sdb", "sdc"}, SkippedDevices: map[string]string{"sda": "in use"}}},
+	}
+
+	planOutput = outputTable
+	var out bytes.Buffer
+	assert.Nil(t, printPlan(&out, plan))
+	assert.Equal(t, "MON  NODE\n"+
+		"a    node0\n"+
+		"b    node1\n"+
+		"\n"+
+		"OSD NODE  DEVICES  DIRECTORIES  SKIPPED DEVICES\n"+
+		"node0     sdb,sdc               sda (in use)\n", out.String())
+
+	planOutput = outputJSON
+	out.Reset()
+	assert.Nil(t, printPlan(&out, plan))
+	assert.Contains(t, out.String(), `"a": "node0"`)
+}
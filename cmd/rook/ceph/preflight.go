@@ -0,0 +1,148 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/daemon/ceph/preflight"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var preflightCmd = &cobra.Command{
+	Use:   "preflight",
+	Short: "Checks the prerequisites of a node before the ceph daemons are configured on it",
+	Args:  cobra.NoArgs,
+}
+
+var (
+	preflightNodeName      string
+	preflightDataDir       string
+	preflightDevices       string
+	preflightKernelModules []string
+	preflightSysctls       []string
+	preflightCheckHostname bool
+	preflightOutput        string
+)
+
+func init() {
+	var sysctls []string
+	for name, min := range preflight.DefaultSysctls {
+		sysctls = append(sysctls, fmt.Sprintf("%s=%d", name, min))
+	}
+	sort.Strings(sysctls)
+
+	preflightCmd.Flags().StringVar(&preflightNodeName, "node-name", os.Getenv("HOSTNAME"), "the host name of the node, which must resolve")
+	preflightCmd.Flags().StringVar(&preflightDataDir, "data-dir", k8sutil.DataDir, "the directory of the ceph config and data (dataDirHostPath), which must be writable")
+	preflightCmd.Flags().StringVar(&preflightDevices, "data-devices", "", "comma separated list of devices that must be visible")
+	preflightCmd.Flags().StringSliceVar(&preflightKernelModules, "kernel-modules", preflight.DefaultKernelModules, "kernel modules that must be loaded or available to be loaded")
+	preflightCmd.Flags().StringSliceVar(&preflightSysctls, "sysctls", sysctls, "recommended minimum values of the kernel settings (name=min), a lower value is a warning")
+	preflightCmd.Flags().BoolVar(&preflightCheckHostname, "check-hostname", true, "whether the node name must resolve, only needed when the daemons run on the host network")
+	preflightCmd.Flags().StringVarP(&preflightOutput, "output", "o", outputTable, "output format (table or json)")
+	flags.SetFlagsFromEnv(preflightCmd.Flags(), rook.RookEnvVarPrefix)
+
+	preflightCmd.RunE = runPreflight
+}
+
+func runPreflight(cmd *cobra.Command, args []string) error {
+	if preflightOutput != outputTable && preflightOutput != outputJSON {
+		return fmt.Errorf("invalid output format %q. must be %s or %s", preflightOutput, outputTable, outputJSON)
+	}
+	config := preflight.Config{NodeName: preflightNodeName, DataDir: preflightDataDir, KernelModules: preflightKernelModules, CheckHostname: preflightCheckHostname}
+	if preflightDevices != "" {
+		// the devices have the format of the provisioning of the osds
+		devices, err := parseDevices(preflightDevices)
+		if err != nil {
+			return err
+		}
+		for _, device := range devices {
+			config.Devices = append(config.Devices, device.Name)
+		}
+	}
+	sysctls, err := parseSysctls(preflightSysctls)
+	if err != nil {
+		return err
+	}
+	config.Sysctls = sysctls
+
+	report := preflight.Run(config)
+	if err := printPreflightReport(os.Stdout, report); err != nil {
+		return err
+	}
+	if !report.Passed {
+		// fail the init container running the checks so the daemons are not configured on the node
+		return fmt.Errorf("preflight checks of node %s failed", report.Node)
+	}
+	return nil
+}
+
+// parseSysctls parses the minimum values of the kernel settings, for example kernel.pid_max=4194303
+func parseSysctls(values []string) (map[string]int64, error) {
+	sysctls := map[string]int64{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid sysctl %q. must be name=min", value)
+		}
+		min, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum of sysctl %q. %+v", value, err)
+		}
+		sysctls[parts[0]] = min
+	}
+	return sysctls, nil
+}
+
+// printPreflightReport writes the report as json, or as a table of the checks
+func printPreflightReport(out io.Writer, report *preflight.Report) error {
+	if preflightOutput == outputJSON {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tRESULT\tMESSAGE")
+	for _, check := range report.Checks {
+		result := "PASS"
+		if check.Warning {
+			result = "WARN"
+		} else if !check.Passed {
+			result = "FAIL"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", check.Name, result, check.Message)
+	}
+	result := "PASS"
+	if !report.Passed {
+		result = "FAIL"
+	}
+	fmt.Fprintf(w, "\nnode %s: %s\n", report.Node, result)
+	return w.Flush()
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rook/rook/pkg/daemon/ceph/preflight"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSysctls(t *testing.T) {
+	sysctls, err := parseSysctls([]string{"kernel.pid_max=4194303", "fs.aio-max-nr=1048576"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int64{"kernel.pid_max": 4194303, "fs.aio-max-nr": 1048576}, sysctls)
+
+	_, err = parseSysctls([]string{"kernel.pid_max"})
+	assert.NotNil(t, err)
+	_, err = parseSysctls([]string{"kernel.pid_max=max"})
+	assert.NotNil(t, err)
+}
+
+func TestPrintPreflightReport(t *testing.T) {
+	report := &preflight.Report{Node: "node1", Passed: false, Checks: []preflight.Check{
+		{Name: "kernel module rbd", Passed: true, Message: "loaded"},
+		{Name: "time sync", Passed: false, Message: "the clock is not synchronized"},
+	}}
+
+	preflightOutput = outputTable
+	var out bytes.Buffer
+	assert.Nil(t, printPreflightReport(&out, report))
+	assert.Contains(t, out.String(), "kernel module rbd  PASS    loaded")
+	assert.Contains(t, out.String(), "time sync          FAIL    the clock is not synchronized")
+	assert.Contains(t, out.String(), "node node1: FAIL")
+
+	preflightOutput = outputJSON
+	out.Reset()
+	assert.Nil(t, printPreflightReport(&out, report))
+	assert.Contains(t, out.String(), `"passed": false`)
+	preflightOutput = outputTable
+}
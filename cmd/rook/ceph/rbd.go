@@ -0,0 +1,194 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/util/display"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var rbdCmd = &cobra.Command{
+	Use:   "rbd",
+	Short: "Manages the rbd images of the pools of a cluster",
+}
+
+var rbdCreateCmd = &cobra.Command{
+	Use:   "create IMAGE",
+	Short: "Creates an rbd image",
+	Args:  cobra.ExactArgs(1),
+}
+
+var rbdListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the rbd images of a pool",
+	Args:  cobra.NoArgs,
+}
+
+var rbdDeleteCmd = &cobra.Command{
+	Use:   "delete IMAGE",
+	Short: "Deletes an rbd image",
+	Args:  cobra.ExactArgs(1),
+}
+
+var rbdResizeCmd = &cobra.Command{
+	Use:   "resize IMAGE",
+	Short: "Grows an rbd image to a new size",
+	Args:  cobra.ExactArgs(1),
+}
+
+var (
+	rbdNamespace string
+	rbdPool      string
+	rbdDataPool  string
+	rbdSize      string
+	rbdOutput    string
+)
+
+func init() {
+	for _, command := range []*cobra.Command{rbdCreateCmd, rbdListCmd, rbdDeleteCmd, rbdResizeCmd} {
+		command.Flags().StringVar(&rbdNamespace, "namespace", "rook-ceph", "namespace of the cluster")
+		command.Flags().StringVar(&rbdPool, "pool", "replicapool", "pool of the images")
+		command.Flags().StringVar(&cfg.dataDir, "config-dir", "/var/lib/rook", "directory of the cluster config generated by the operator")
+		flags.SetFlagsFromEnv(command.Flags(), rook.RookEnvVarPrefix)
+		rbdCmd.AddCommand(command)
+	}
+	for _, command := range []*cobra.Command{rbdCreateCmd, rbdResizeCmd} {
+		command.Flags().StringVar(&rbdSize, "size", "", "size of the image, such as 10Gi (rounded up to the next MiB)")
+	}
+	rbdCreateCmd.Flags().StringVar(&rbdDataPool, "data-pool", "", "erasure coded pool storing the data of the image (optional)")
+	rbdListCmd.Flags().StringVarP(&rbdOutput, "output", "o", outputTable, "output format (table or json)")
+
+	rbdCreateCmd.RunE = createImage
+	rbdListCmd.RunE = listImages
+	rbdDeleteCmd.RunE = deleteImage
+	rbdResizeCmd.RunE = resizeImage
+}
+
+func createImage(cmd *cobra.Command, args []string) error {
+	size, err := parseImageSize(rbdSize)
+	if err != nil {
+		return err
+	}
+
+	image, err := client.CreateImage(createContext(), rbdNamespace, args[0], rbdPool, rbdDataPool, size)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created image %s/%s of %s\n", rbdPool, image.Name, display.BytesToString(image.Size))
+	return nil
+}
+
+func listImages(cmd *cobra.Command, args []string) error {
+	if rbdOutput != outputTable && rbdOutput != outputJSON {
+		return fmt.Errorf("invalid output format %q. must be %s or %s", rbdOutput, outputTable, outputJSON)
+	}
+
+	images, err := client.ListImages(createContext(), rbdNamespace, rbdPool)
+	if err != nil {
+		return err
+	}
+	return printImages(os.Stdout, images)
+}
+
+func deleteImage(cmd *cobra.Command, args []string) error {
+	if err := client.DeleteImage(createContext(), rbdNamespace, args[0], rbdPool); err != nil {
+		return err
+	}
+	fmt.Printf("deleted image %s/%s\n", rbdPool, args[0])
+	return nil
+}
+
+func resizeImage(cmd *cobra.Command, args []string) error {
+	size, err := parseImageSize(rbdSize)
+	if err != nil {
+		return err
+	}
+
+	// the images are never shrunk, which would lose the data at the end of the image
+	context := createContext()
+	images, err := client.ListImages(context, rbdNamespace, rbdPool)
+	if err != nil {
+		return err
+	}
+	current := findImage(images, args[0])
+	if current == nil {
+		return fmt.Errorf("image %s not found in pool %s", args[0], rbdPool)
+	}
+	if size < current.Size {
+		return fmt.Errorf("cannot shrink image %s from %s to %s", args[0], display.BytesToString(current.Size), display.BytesToString(size))
+	}
+
+	image, err := client.ExpandImage(context, rbdNamespace, args[0], rbdPool, size)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("resized image %s/%s to %s\n", rbdPool, image.Name, display.BytesToString(image.Size))
+	return nil
+}
+
+// parseImageSize parses the size of an image as a kubernetes quantity
+func parseImageSize(size string) (uint64, error) {
+	if size == "" {
+		return 0, fmt.Errorf("the size of the image is required")
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q. %+v", size, err)
+	}
+	if quantity.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid size %q. must be positive", size)
+	}
+	return uint64(quantity.Value()), nil
+}
+
+func findImage(images []client.CephBlockImage, name string) *client.CephBlockImage {
+	for i := range images {
+		if images[i].Name == name {
+			return &images[i]
+		}
+	}
+	return nil
+}
+
+// printImages writes the images as json or as a table with their size
+func printImages(out io.Writer, images []client.CephBlockImage) error {
+	if rbdOutput == outputJSON {
+		b, err := json.MarshalIndent(images, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tFORMAT")
+	for _, image := range images {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", image.Name, display.BytesToString(image.Size), image.Format)
+	}
+	return w.Flush()
+}
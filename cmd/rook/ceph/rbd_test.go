@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImageSize(t *testing.T) {
+	size, err := parseImageSize("10Gi")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(10*1024*1024*1024), size)
+
+	size, err = parseImageSize("500M")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(500000000), size)
+
+	for _, invalid := range []string{"", "ten", "-1Gi", "0"} {
+		_, err = parseImageSize(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestPrintImages(t *testing.T) {
+	images := []client.CephBlockImage{
+		{Name: "image1", Size: 1073741824, Format: 2},
+		{Name: "image2", Size: 1048576, Format: 2},
+	}
+
+	rbdOutput = outputTable
+	var out bytes.Buffer
+	assert.Nil(t, printImages(&out, images))
+	assert.Equal(t, "NAME    SIZE      FORMAT\n"+
+		"image1  1.00 GiB  2\n"+
+		"image2  1.00 MiB  2\n", out.String())
+
+	rbdOutput = outputJSON
+	out.Reset()
+	assert.Nil(t, printImages(&out, images))
+	assert.Contains(t, out.String(), `"image": "image1"`)
+
+	assert.Equal(t, "image2", findImage(images, "image2").Name)
+	assert.Nil(t, findImage(images, "image3"))
+}
@@ -37,6 +37,7 @@ var (
 	rgwName       string
 	rgwKeyring    string
 	rgwHost       string
+	rgwDNSName    string
 	rgwCert       string
 	rgwPort       int
 	rgwSecurePort int
@@ -46,6 +47,7 @@ func init() {
 	rgwCmd.Flags().StringVar(&rgwName, "rgw-name", "", "name of the object store")
 	rgwCmd.Flags().StringVar(&rgwKeyring, "rgw-keyring", "", "the rgw keyring")
 	rgwCmd.Flags().StringVar(&rgwHost, "rgw-host", os.Getenv("HOSTNAME"), "RGW host name. Becomes the only accepted hostname if the rgw dns name property is unset. Defaults to the pod hostname")
+	rgwCmd.Flags().StringVar(&rgwDNSName, "rgw-dns-name", "", "the domain of the virtual hosted style requests")
 	rgwCmd.Flags().StringVar(&rgwCert, "rgw-cert", "", "path to the ssl certificate in pem format")
 	rgwCmd.Flags().IntVar(&rgwPort, "rgw-port", 0, "rgw port (http)")
 	rgwCmd.Flags().IntVar(&rgwSecurePort, "rgw-secure-port", 0, "rgw secure port number (https)")
@@ -80,6 +82,7 @@ func initRGW(cmd *cobra.Command, args []string) error {
 		Name:            rgwName,
 		Keyring:         rgwKeyring,
 		Host:            rgwHost,
+		DNSName:         rgwDNSName,
 		Port:            rgwPort,
 		SecurePort:      rgwSecurePort,
 		CertificatePath: rgwCert,
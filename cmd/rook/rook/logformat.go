@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package rook
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+)
+
+const (
+	// LogFormatText is the default format of the logs, one line of text per entry
+	LogFormatText = "text"
+	// LogFormatJSON is the format of the logs with one json object per entry
+	LogFormatJSON = "json"
+)
+
+// LogFields are the fields of the daemon added to each entry of the structured logs
+type LogFields struct {
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Daemon    string `json:"daemon,omitempty"`
+	Node      string `json:"node,omitempty"`
+}
+
+type jsonEntry struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Package string `json:"package"`
+	Message string `json:"message"`
+	LogFields
+}
+
+// JSONFormatter writes the log entries as json objects, one per line
+type JSONFormatter struct {
+	w      *bufio.Writer
+	fields LogFields
+}
+
+// NewJSONFormatter creates a formatter adding the fields to each entry written to w
+func NewJSONFormatter(w io.Writer, fields LogFields) *JSONFormatter {
+	return &JSONFormatter{w: bufio.NewWriter(w), fields: fields}
+}
+
+// Format writes a log entry. The capnslog global lock serializes the calls.
+func (f *JSONFormatter) Format(pkg string, l capnslog.LogLevel, depth int, entries ...interface{}) {
+	entry := jsonEntry{
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+		Level:     l.String(),
+		Package:   pkg,
+		Message:   strings.TrimSuffix(fmt.Sprint(entries...), "\n"),
+		LogFields: f.fields,
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		// the fields are all strings, only keep the message readable if it ever happens
+		b = []byte(fmt.Sprintf(`{"level":"ERROR","message":%q}`, err.Error()))
+	}
+	f.w.Write(b)
+	f.w.WriteByte('\n')
+	f.Flush()
+}
+
+// Flush writes the buffered entries
+func (f *JSONFormatter) Flush() {
+	f.w.Flush()
+}
+
+// setLogFormat sets the formatter of all the loggers
+func setLogFormat(format string, fields LogFields) error {
+	switch format {
+	case "", LogFormatText:
+		// keep the default formatter of capnslog
+		return nil
+	case LogFormatJSON:
+		capnslog.SetFormatter(NewJSONFormatter(os.Stderr, fields))
+		return nil
+	}
+	return fmt.Errorf("unknown log format %s (valid values: %s,%s)", format, LogFormatText, LogFormatJSON)
+}
+
+// logFieldsFromEnv gets the fields of the daemon from the env variables set by the operator in the pods
+func logFieldsFromEnv(daemon string) LogFields {
+	fields := LogFields{
+		Cluster:   os.Getenv("ROOK_CLUSTER_NAME"),
+		Namespace: os.Getenv(k8sutil.PodNamespaceEnvVar),
+		Daemon:    daemon,
+		Node:      os.Getenv(k8sutil.NodeNameEnvVar),
+	}
+	if fields.Namespace == "" {
+		// the ceph daemons run in the namespace of their cluster
+		fields.Namespace = fields.Cluster
+	}
+	return fields
+}
+
+// daemonName is the path of the running command, such as "ceph operator" or "ceph osd"
+func daemonName() string {
+	cmd, _, err := RootCmd.Find(os.Args[1:])
+	if err != nil || cmd == RootCmd {
+		return ""
+	}
+	return strings.TrimPrefix(cmd.CommandPath(), RootCmd.Name()+" ")
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package rook
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewJSONFormatter(&buf, LogFields{Cluster: "rook-ceph", Namespace: "rook-ceph", Daemon: "ceph osd"})
+	f.Format("cephosd", capnslog.INFO, 0, "osd.1 is \"up\"\n")
+	f.Format("cephosd", capnslog.WARNING, 0, "failed ", 2, " times")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Equal(t, 2, len(lines))
+
+	var entry map[string]string
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, "cephosd", entry["package"])
+	assert.Equal(t, `osd.1 is "up"`, entry["message"])
+	assert.Equal(t, "rook-ceph", entry["cluster"])
+	assert.Equal(t, "rook-ceph", entry["namespace"])
+	assert.Equal(t, "ceph osd", entry["daemon"])
+	assert.NotEmpty(t, entry["time"])
+	// the empty fields are omitted
+	_, ok := entry["node"]
+	assert.False(t, ok)
+
+	entry = nil
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "WARNING", entry["level"])
+	assert.Equal(t, "failed 2 times", entry["message"])
+}
+
+func TestSetLogFormat(t *testing.T) {
+	assert.Nil(t, setLogFormat("", LogFields{}))
+	assert.Nil(t, setLogFormat(LogFormatText, LogFields{}))
+	assert.NotNil(t, setLogFormat("xml", LogFields{}))
+}
+
+func TestLogFieldsFromEnv(t *testing.T) {
+	os.Setenv("ROOK_CLUSTER_NAME", "rook-ceph")
+	os.Setenv("NODE_NAME", "node1")
+	defer os.Unsetenv("ROOK_CLUSTER_NAME")
+	defer os.Unsetenv("NODE_NAME")
+
+	// the daemons of the cluster run in the namespace of the cluster
+	fields := logFieldsFromEnv("ceph mon")
+	assert.Equal(t, LogFields{Cluster: "rook-ceph", Namespace: "rook-ceph", Daemon: "ceph mon", Node: "node1"}, fields)
+
+	os.Setenv("POD_NAMESPACE", "rook-ceph-system")
+	defer os.Unsetenv("POD_NAMESPACE")
+	fields = logFieldsFromEnv("ceph agent")
+	assert.Equal(t, "rook-ceph-system", fields.Namespace)
+}
@@ -44,6 +44,7 @@ var RootCmd = &cobra.Command{
 
 var (
 	logLevelRaw string
+	logFormat   string
 	Cfg         = &Config{}
 	logger      = capnslog.NewPackageLogger("github.com/rook/rook", "rookcmd")
 )
@@ -58,6 +59,7 @@ type Config struct {
 //  3) command line parameter
 func init() {
 	RootCmd.PersistentFlags().StringVar(&logLevelRaw, "log-level", "INFO", "logging level for logging/tracing output (valid values: CRITICAL,ERROR,WARNING,NOTICE,INFO,DEBUG,TRACE)")
+	RootCmd.PersistentFlags().StringVar(&logFormat, "log-format", LogFormatText, "format of the logging output (valid values: text,json)")
 
 	// load the environment variables
 	flags.SetFlagsFromEnv(RootCmd.Flags(), RookEnvVarPrefix)
@@ -72,6 +74,11 @@ func SetLogLevel() {
 	}
 	Cfg.LogLevel = ll
 	capnslog.SetGlobalLogLevel(Cfg.LogLevel)
+
+	// structured logs for the log collectors, with the fields identifying the daemon
+	if err := setLogFormat(logFormat, logFieldsFromEnv(daemonName())); err != nil {
+		logger.Warningf("failed to set log format. %+v", err)
+	}
 }
 
 func LogStartupInfo(cmdFlags *pflag.FlagSet) {
@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/rpc"
+
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+	"github.com/spf13/cobra"
+	k8smount "k8s.io/kubernetes/pkg/util/mount"
+)
+
+var (
+	expandFSCmd = &cobra.Command{
+		Use:   "expandfs",
+		Short: "Expands the filesystem of the pod volume",
+		RunE:  handleExpandFS,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(expandFSCmd)
+}
+
+// handleExpandFS is called by the kubelet after the provisioner has grown the backing image.
+// Arguments: <json options> <device path> <device mount path> <new size> <old size>
+func handleExpandFS(cmd *cobra.Command, args []string) error {
+	if len(args) < 4 {
+		return fmt.Errorf("Rook: expandfs requires the options, device path, mount path and new size. Got %v", args)
+	}
+
+	client, err := getRPCClient()
+	if err != nil {
+		return fmt.Errorf("Rook: Error getting RPC client: %v", err)
+	}
+
+	var opts = &flexvolume.AttachOptions{}
+	if err = json.Unmarshal([]byte(args[0]), opts); err != nil {
+		return fmt.Errorf("Rook: Could not parse options for expanding %s. Got %v", args[0], err)
+	}
+	mountDir := args[2]
+	newSize := args[3]
+
+	log(client, fmt.Sprintf("expanding filesystem at mount dir %s to %s bytes", mountDir, newSize), false)
+
+	mounter := getMounter()
+	if opts.FsType == cephFS {
+		return expandCephFS(client, mounter, mountDir, newSize)
+	}
+
+	devicePath, _, err := k8smount.GetDeviceNameFromMount(mounter.Interface, mountDir)
+	if err != nil || devicePath == "" {
+		log(client, fmt.Sprintf("failed to find the device mounted at %s: %v", mountDir, err), true)
+		return fmt.Errorf("Rook: failed to find the device mounted at %s: %v", mountDir, err)
+	}
+
+	var output []byte
+	if opts.FsType == "xfs" {
+		// xfs can only be grown while it is mounted
+		output, err = mounter.Exec.Run("xfs_growfs", "-d", mountDir)
+	} else {
+		output, err = mounter.Exec.Run("resize2fs", devicePath)
+	}
+	if err != nil {
+		log(client, fmt.Sprintf("failed to expand filesystem on %s: %v. output: %s", devicePath, err, string(output)), true)
+		return fmt.Errorf("Rook: failed to expand filesystem on %s: %v", devicePath, err)
+	}
+
+	log(client, fmt.Sprintf("filesystem on %s mounted at %s has been expanded", devicePath, mountDir), false)
+	return nil
+}
+
+// expandCephFS raises the quota of the cephfs directory mounted at mountDir
+func expandCephFS(client *rpc.Client, mounter *k8smount.SafeFormatAndMount, mountDir, newSize string) error {
+	output, err := mounter.Exec.Run("setfattr", "-n", "ceph.quota.max_bytes", "-v", newSize, mountDir)
+	if err != nil {
+		log(client, fmt.Sprintf("failed to set quota on cephfs mount %s: %v. output: %s", mountDir, err, string(output)), true)
+		return fmt.Errorf("Rook: failed to set quota on cephfs mount %s: %v", mountDir, err)
+	}
+
+	log(client, fmt.Sprintf("quota of cephfs mount %s set to %s bytes", mountDir, newSize), false)
+	return nil
+}
@@ -36,13 +36,29 @@ func init() {
 	RootCmd.AddCommand(initCmd)
 }
 
+// driverCapabilities extends the capabilities known by the vendored flexvolume package with
+// the ones supported by newer versions of the kubelet.
+type driverCapabilities struct {
+	flexvolume.DriverCapabilities
+	// RequiresFSResize tells the kubelet to call expandfs after the volume has been expanded
+	RequiresFSResize bool `json:"requiresFSResize"`
+}
+
+type driverStatus struct {
+	Status       string              `json:"status"`
+	Capabilities *driverCapabilities `json:",omitempty"`
+}
+
 func initPlugin(cmd *cobra.Command, args []string) error {
-	status := flexvolume.DriverStatus{
+	status := driverStatus{
 		Status: flexvolume.StatusSuccess,
-		Capabilities: &flexvolume.DriverCapabilities{
-			Attach: false,
-			// Required for cephfs (ReadWriteMany)
-			SELinuxRelabel: false,
+		Capabilities: &driverCapabilities{
+			DriverCapabilities: flexvolume.DriverCapabilities{
+				Attach: false,
+				// Required for cephfs (ReadWriteMany)
+				SELinuxRelabel: false,
+			},
+			RequiresFSResize: true,
 		},
 	}
 	if err := json.NewEncoder(os.Stdout).Encode(&status); err != nil {
@@ -232,6 +232,10 @@ func mountCephFS(client *rpc.Client, opts *flexvolume.AttachOptions) error {
 		}
 	}
 
+	if opts.Mounter == flexvolume.MounterFuse {
+		return mountCephFSWithFuse(client, opts, clientAccessInfo, path)
+	}
+
 	options := []string{
 		fmt.Sprintf("name=%s", clientAccessInfo.UserName),
 		fmt.Sprintf("secret=%s", clientAccessInfo.SecretKey),
@@ -295,3 +299,24 @@ func mountCephFS(client *rpc.Client, opts *flexvolume.AttachOptions) error {
 
 	return err
 }
+
+// mountCephFSWithFuse calls the agent to mount the filesystem with ceph-fuse, when the kernel of the node cannot mount it
+func mountCephFSWithFuse(client *rpc.Client, opts *flexvolume.AttachOptions, clientAccessInfo flexvolume.ClientAccessInfo, path string) error {
+	log(client, fmt.Sprintf("mounting ceph filesystem %s on %s with ceph-fuse", opts.FsName, opts.MountDir), false)
+	fuseOpts := flexvolume.FuseMountOptions{
+		FsName:       opts.FsName,
+		Path:         path,
+		MountDir:     opts.MountDir,
+		RW:           opts.RW,
+		MonAddresses: clientAccessInfo.MonAddresses,
+		UserName:     clientAccessInfo.UserName,
+		SecretKey:    clientAccessInfo.SecretKey,
+	}
+	if err := client.Call("Controller.MountFuse", fuseOpts, nil); err != nil {
+		errorMsg := fmt.Sprintf("Attach filesystem %s with ceph-fuse failed: %v", opts.FsName, err)
+		log(client, errorMsg, true)
+		return fmt.Errorf("Rook: %v", errorMsg)
+	}
+	log(client, fmt.Sprintf("ceph filesystem %s has been mounted with ceph-fuse", opts.FsName), false)
+	return nil
+}
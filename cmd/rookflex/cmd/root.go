@@ -33,6 +33,8 @@ import (
 
 const (
 	cephFS = "ceph"
+	// the type of the filesystems mounted with ceph-fuse
+	cephFuseFS = "fuse.ceph-fuse"
 )
 
 // RootCmd the rookflex volume plugin cobra root command
@@ -51,11 +51,12 @@ func handleUnmount(cmd *cobra.Command, args []string) error {
 
 	mounter := getMounter()
 
-	// Check if it's a cephfs
-	command := exec.Command("df", "--type", cephFS, mountDir)
-	err = command.Run()
-	if err == nil {
-		return unmountCephFS(client, mounter, mountDir)
+	// Check if it's a cephfs, mounted by the kernel or with ceph-fuse
+	for _, fsType := range []string{cephFS, cephFuseFS} {
+		command := exec.Command("df", "--type", fsType, mountDir)
+		if err = command.Run(); err == nil {
+			return unmountCephFS(client, mounter, mountDir)
+		}
 	}
 
 	var opts = &flexvolume.AttachOptions{
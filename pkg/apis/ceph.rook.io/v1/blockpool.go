@@ -18,16 +18,22 @@ package v1
 import "github.com/rook/rook/pkg/daemon/ceph/model"
 
 func (p *PoolSpec) ToModel(name string) *model.Pool {
-	pool := &model.Pool{Name: name, FailureDomain: p.FailureDomain, CrushRoot: p.CrushRoot}
+	pool := &model.Pool{Name: name, FailureDomain: p.FailureDomain, CrushRoot: p.CrushRoot, CrushRule: p.CrushRule}
 	r := p.Replication()
 	if r != nil {
 		pool.ReplicatedConfig.Size = r.Size
+		if r.HybridStorage != nil {
+			pool.ReplicatedConfig.PrimaryDeviceClass = r.HybridStorage.PrimaryDeviceClass
+			pool.ReplicatedConfig.SecondaryDeviceClass = r.HybridStorage.SecondaryDeviceClass
+		}
 		pool.Type = model.Replicated
 	} else {
 		ec := p.ErasureCode()
 		if ec != nil {
 			pool.ErasureCodedConfig.CodingChunkCount = ec.CodingChunks
 			pool.ErasureCodedConfig.DataChunkCount = ec.DataChunks
+			pool.ErasureCodedConfig.Plugin = ec.Plugin
+			pool.ErasureCodedConfig.Technique = ec.Technique
 			pool.Type = model.ErasureCoded
 		}
 	}
@@ -41,6 +47,11 @@ func (p *PoolSpec) Replication() *ReplicatedSpec {
 	return nil
 }
 
+// RequiresSafeReplicaSize returns whether the pool must have at least 3 replicas, which is the default
+func (r *ReplicatedSpec) RequiresSafeReplicaSize() bool {
+	return r.RequireSafeReplicaSize == nil || *r.RequireSafeReplicaSize
+}
+
 func (p *PoolSpec) ErasureCode() *ErasureCodedSpec {
 	ec := &p.ErasureCoded
 	if ec.CodingChunks > 0 || ec.DataChunks > 0 {
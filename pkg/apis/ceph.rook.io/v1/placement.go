@@ -24,6 +24,7 @@ const (
 	PlacementKeyMon       = "mon"
 	PlacementKeyOSD       = "osd"
 	PlacementKeyRBDMirror = "rbdmirror"
+	PlacementKeyExporter  = "exporter"
 )
 
 // GetMgrPlacement returns the placement for the MGR service
@@ -45,3 +46,8 @@ func GetOSDPlacement(p rook.PlacementSpec) rook.Placement {
 func GetRBDMirrorPlacement(p rook.PlacementSpec) rook.Placement {
 	return p.All().Merge(p[PlacementKeyRBDMirror])
 }
+
+// GetExporterPlacement returns the placement for the exporters
+func GetExporterPlacement(p rook.PlacementSpec) rook.Placement {
+	return p.All().Merge(p[PlacementKeyExporter])
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	rook "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+)
+
+const (
+	PodExtensionKeyMgr       = "mgr"
+	PodExtensionKeyMon       = "mon"
+	PodExtensionKeyOSD       = "osd"
+	PodExtensionKeyRBDMirror = "rbdmirror"
+	PodExtensionKeyExporter  = "exporter"
+)
+
+// GetMgrPodExtension returns the pod extension for the MGR service
+func GetMgrPodExtension(p rook.PodExtensionSpec) rook.PodExtension {
+	return p.All().Merge(p[PodExtensionKeyMgr])
+}
+
+// GetMonPodExtension returns the pod extension for the MON service
+func GetMonPodExtension(p rook.PodExtensionSpec) rook.PodExtension {
+	return p.All().Merge(p[PodExtensionKeyMon])
+}
+
+// GetOSDPodExtension returns the pod extension for the OSD service
+func GetOSDPodExtension(p rook.PodExtensionSpec) rook.PodExtension {
+	return p.All().Merge(p[PodExtensionKeyOSD])
+}
+
+// GetRBDMirrorPodExtension returns the pod extension for the RBD mirrors
+func GetRBDMirrorPodExtension(p rook.PodExtensionSpec) rook.PodExtension {
+	return p.All().Merge(p[PodExtensionKeyRBDMirror])
+}
+
+// GetExporterPodExtension returns the pod extension for the exporters
+func GetExporterPodExtension(p rook.PodExtensionSpec) rook.PodExtension {
+	return p.All().Merge(p[PodExtensionKeyExporter])
+}
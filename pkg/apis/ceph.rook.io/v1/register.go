@@ -57,12 +57,30 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&CephClusterList{},
 		&CephBlockPool{},
 		&CephBlockPoolList{},
+		&CephBlockPoolRadosNamespace{},
+		&CephBlockPoolRadosNamespaceList{},
+		&CephCrushRule{},
+		&CephCrushRuleList{},
 		&CephFilesystem{},
 		&CephFilesystemList{},
 		&CephObjectStore{},
 		&CephObjectStoreList{},
 		&CephObjectStoreUser{},
 		&CephObjectStoreUserList{},
+		&CephObjectBucket{},
+		&CephObjectBucketList{},
+		&CephCOSIDriver{},
+		&CephCOSIDriverList{},
+		&CephBackupSnapshot{},
+		&CephBackupSnapshotList{},
+		&CephVolumeGroup{},
+		&CephVolumeGroupList{},
+		&CephMetadataBackup{},
+		&CephMetadataBackupList{},
+		&CephSMBGateway{},
+		&CephSMBGatewayList{},
+		&CephMirroringStatus{},
+		&CephMirroringStatusList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
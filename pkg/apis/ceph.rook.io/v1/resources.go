@@ -25,6 +25,7 @@ const (
 	ResourcesKeyMon       = "mon"
 	ResourcesKeyOSD       = "osd"
 	ResourcesKeyRBDMirror = "rbdmirror"
+	ResourcesKeyExporter  = "exporter"
 )
 
 // GetMgrResources returns the placement for the MGR service
@@ -46,3 +47,8 @@ func GetOSDResources(p rook.ResourceSpec) v1.ResourceRequirements {
 func GetRBDMirrorResources(p rook.ResourceSpec) v1.ResourceRequirements {
 	return p[ResourcesKeyRBDMirror]
 }
+
+// GetExporterResources returns the resources for the exporters
+func GetExporterResources(p rook.ResourceSpec) v1.ResourceRequirements {
+	return p[ResourcesKeyExporter]
+}
@@ -63,6 +63,9 @@ type ClusterSpec struct {
 	// Resources set resource requests and limits
 	Resources rook.ResourceSpec `json:"resources,omitempty"`
 
+	// The user-defined init containers, sidecars and volumes added to the pods of the daemons
+	PodExtensions rook.PodExtensionSpec `json:"podExtensions,omitempty"`
+
 	// The path on the host where config and data can be persisted.
 	DataDirHostPath string `json:"dataDirHostPath,omitempty"`
 
@@ -74,6 +77,138 @@ type ClusterSpec struct {
 
 	// Dashboard settings
 	Dashboard DashboardSpec `json:"dashboard,omitempty"`
+
+	// The opt-in to the telemetry reports of the mgr module, starting with nautilus
+	Telemetry TelemetrySpec `json:"telemetry,omitempty"`
+
+	// Whether to skip the health checks before updating the daemons during an upgrade of the ceph version
+	SkipUpgradeChecks bool `json:"skipUpgradeChecks,omitempty"`
+
+	// Whether to continue the upgrade when the cluster is still not healthy after the timeout of the upgrade checks
+	ContinueUpgradeAfterChecksEvenIfNotHealthy bool `json:"continueUpgradeAfterChecksEvenIfNotHealthy,omitempty"`
+
+	// The settings of the health checks before updating the daemons during an upgrade
+	UpgradeChecks UpgradeChecksSpec `json:"upgradeChecks,omitempty"`
+
+	// The settings of the health checks of the mon, mgr and osd daemons
+	HealthCheck HealthCheckSpec `json:"healthCheck,omitempty"`
+
+	// The number of seconds to wait for an osd to be up after its deployment is updated. When the timeout expires,
+	// the operator continues with the other osds and reports the osd in the status of the cluster.
+	WaitTimeoutForHealthyOSD int `json:"waitTimeoutForHealthyOSD,omitempty"`
+
+	// The nodes excluded from the placement of new osds, whose osds are marked out after a grace period
+	NodeExclusion NodeExclusionSpec `json:"nodeExclusion,omitempty"`
+
+	// The exporter of the perf counters of the ceph daemons running on each node
+	Exporter ExporterSpec `json:"exporter,omitempty"`
+
+	// Whether the mon containers may be privileged and the daemons may run on the host network. The mgr, rgw and mds
+	// pods are never privileged, and the osds always need privileged access to their devices. Default is true.
+	Privileged *bool `json:"privileged,omitempty"`
+
+	// The number of seconds between the periodic resyncs of the cluster, which recreate the deleted resources and revert
+	// the manual edits of the daemon deployments. The resync is disabled if not set.
+	ReconcileInterval int `json:"reconcileInterval,omitempty"`
+
+	// Whether the osds that are down and out for longer than removeOSDsAfterSeconds are purged from the cluster and
+	// their deployment deleted, once ceph reports that they are safe to destroy
+	RemoveOSDsIfOutAndSafeToRemove bool `json:"removeOSDsIfOutAndSafeToRemove,omitempty"`
+
+	// The number of seconds an osd must be down and out before it is removed. Default is 86400 (one day).
+	RemoveOSDsAfterSeconds int `json:"removeOSDsAfterSeconds,omitempty"`
+
+	// Whether the operator stops orchestrating the cluster, so the admins can repair it manually. The noout and
+	// nobackfill flags are set until maintenance mode is disabled, after which the updates of the cluster are orchestrated.
+	MaintenanceMode bool `json:"maintenanceMode,omitempty"`
+
+	// The webhooks notified of the health transitions, the down osds and the upgrades of the cluster
+	Notifications NotificationsSpec `json:"notifications,omitempty"`
+
+	// The automatic repair of the inconsistent placement groups found by the scrubs
+	PGAutoRepair PGAutoRepairSpec `json:"pgAutoRepair,omitempty"`
+}
+
+// PGAutoRepairSpec represents the repair of the inconsistent placement groups by the operator
+type PGAutoRepairSpec struct {
+	// Whether the operator repairs the inconsistent pgs
+	Enabled bool `json:"enabled,omitempty"`
+
+	// The largest number of scrub errors of the cluster for which the pgs are repaired. More errors are left to the
+	// admins since they may reveal a failing disk. Default is 10.
+	MaxScrubErrors int `json:"maxScrubErrors,omitempty"`
+
+	// The number of seconds between two checks of the inconsistent pgs. Default is 600.
+	IntervalSeconds int `json:"intervalSeconds,omitempty"`
+}
+
+// NotificationsSpec represents the webhooks notified of the events of the cluster
+type NotificationsSpec struct {
+	Webhooks []WebhookSpec `json:"webhooks,omitempty"`
+}
+
+// WebhookSpec represents a webhook the events of the cluster are posted to
+type WebhookSpec struct {
+	Name string `json:"name"`
+	// The url the events are posted to
+	URL string `json:"url,omitempty"`
+	// The secret with the url in its "url" key, since the url of a webhook is often a credential
+	SecretName string `json:"secretName,omitempty"`
+	// The format of the payload: generic (default) or slack
+	Format string `json:"format,omitempty"`
+	// The events posted to the webhook: health, osdDown or upgrade. All the events are posted if empty.
+	Events []string `json:"events,omitempty"`
+}
+
+// IsPrivileged returns whether the mon containers may be privileged and the daemons may run on the host network
+func (s *ClusterSpec) IsPrivileged() bool {
+	return s.Privileged == nil || *s.Privileged
+}
+
+// NodeExclusionSpec represents the taints and labels excluding the nodes from the placement of the osds
+type NodeExclusionSpec struct {
+	// The keys of the taints excluding a node, such as node.kubernetes.io/out-of-service
+	Taints []string `json:"taints,omitempty"`
+
+	// The labels excluding a node, in the form key or key=value
+	Labels []string `json:"labels,omitempty"`
+
+	// The number of seconds a node must be excluded before its osds are marked out
+	MarkOutAfterSeconds int `json:"markOutAfterSeconds,omitempty"`
+}
+
+// HealthCheckSpec represents the health checks of the ceph daemons
+type HealthCheckSpec struct {
+	// The liveness probes of the daemons, keyed by the type of daemon: mon, mgr or osd
+	LivenessProbe map[string]ProbeSpec `json:"livenessProbe,omitempty"`
+}
+
+// ProbeSpec represents the settings of the probe checking the admin socket of a ceph daemon
+type ProbeSpec struct {
+	// Whether to disable the probe
+	Disabled bool `json:"disabled,omitempty"`
+
+	// The number of seconds after the start of the daemon before the probe is initiated. The osds may need a long
+	// delay to replay their journal or check their store after a dirty shutdown.
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+
+	// The number of seconds between each probe
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+
+	// The number of seconds after which the probe times out
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+
+	// The number of consecutive failures before the daemon is restarted
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// UpgradeChecksSpec represents the health checks before updating the daemons during an upgrade
+type UpgradeChecksSpec struct {
+	// The ceph health checks, such as PG_DEGRADED or POOL_NEARFULL, that do not block the upgrade
+	IgnoredHealthChecks []string `json:"ignoredHealthChecks,omitempty"`
+
+	// The number of seconds to wait for the cluster to be healthy before each type of daemon is updated
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
 // VersionSpec represents the settings for the Ceph version that Rook is orchestrating.
@@ -98,25 +233,112 @@ type DashboardSpec struct {
 	Port int `json:"port,omitempty"`
 	// Whether SSL should be used
 	SSL *bool `json:"ssl,omitempty"`
+	// The ingress routing a host to the dashboard service
+	Ingress IngressSpec `json:"ingress,omitempty"`
+}
+
+// TelemetrySpec represents the settings of the reports sent by the telemetry mgr module
+type TelemetrySpec struct {
+	// Whether to opt in to the telemetry reports
+	Enabled bool `json:"enabled,omitempty"`
+	// The channels of the reports: basic, crash, device and ident. The default channels of ceph are sent if empty.
+	Channels []string `json:"channels,omitempty"`
+	// The contact email sent in the ident channel
+	Contact string `json:"contact,omitempty"`
+	// The description of the cluster sent in the ident channel
+	Description string `json:"description,omitempty"`
+	// The organization sent in the ident channel
+	Organization string `json:"organization,omitempty"`
+	// The proxy to send the reports through
+	Proxy string `json:"proxy,omitempty"`
 }
 
 type ClusterStatus struct {
 	State   ClusterState `json:"state,omitempty"`
 	Message string       `json:"message,omitempty"`
+	// The ids of the osds that were not up before the timeout after their last update
+	StuckOSDs []int `json:"stuckOSDs,omitempty"`
+	// The capacity of the cluster, collected periodically
+	Usage *ClusterUsage `json:"usage,omitempty"`
+	// The conditions of the cluster that need the attention of the admin, checked periodically
+	Conditions []ClusterCondition `json:"conditions,omitempty"`
+	// The osd flags set by the operator, such as noout, with the operations holding them. The admin holds the flags
+	// that were already set when the operator set them, which are not unset by the operator.
+	OSDFlags map[string][]string `json:"osdFlags,omitempty"`
+	// The spec orchestrated before the maintenance mode was enabled, whose changes are orchestrated once it is disabled
+	SpecBeforeMaintenance *ClusterSpec `json:"specBeforeMaintenance,omitempty"`
+}
+
+// ClusterCondition represents a condition of a cluster that needs the attention of the admin
+type ClusterCondition struct {
+	Type    ClusterConditionType `json:"type"`
+	Status  v1.ConditionStatus   `json:"status"`
+	Reason  string               `json:"reason,omitempty"`
+	Message string               `json:"message,omitempty"`
+	// The nodes concerned by the condition
+	Nodes []string `json:"nodes,omitempty"`
+	// The last time the condition changed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+type ClusterConditionType string
+
+const (
+	// ClusterConditionClockSkew is set when the clocks of the mons are not synchronized
+	ClusterConditionClockSkew ClusterConditionType = "ClockSkew"
+)
+
+// ClusterUsage represents the raw capacity of the cluster reported by ceph df
+type ClusterUsage struct {
+	TotalBytes     uint64 `json:"totalBytes"`
+	UsedBytes      uint64 `json:"usedBytes"`
+	AvailableBytes uint64 `json:"availableBytes"`
+	Objects        uint64 `json:"objects"`
+	// The time of the collection of the usage
+	LastUpdated metav1.Time `json:"lastUpdated"`
 }
 
 type ClusterState string
 
 const (
-	ClusterStateCreating ClusterState = "Creating"
-	ClusterStateCreated  ClusterState = "Created"
-	ClusterStateUpdating ClusterState = "Updating"
-	ClusterStateError    ClusterState = "Error"
+	ClusterStateCreating    ClusterState = "Creating"
+	ClusterStateCreated     ClusterState = "Created"
+	ClusterStateUpdating    ClusterState = "Updating"
+	ClusterStateError       ClusterState = "Error"
+	ClusterStateMaintenance ClusterState = "Maintenance"
 )
 
+// ExporterSpec represents the settings of the exporter of the perf counters read from the admin sockets of the daemons
+type ExporterSpec struct {
+	// Whether the exporter runs on the nodes of the cluster
+	Enabled bool `json:"enabled,omitempty"`
+	// The port of the prometheus metrics of the exporter (9926 by default)
+	Port int `json:"port,omitempty"`
+}
+
 type MonSpec struct {
 	Count                int  `json:"count"`
 	AllowMultiplePerNode bool `json:"allowMultiplePerNode"`
+	// The number of seconds an osd can be down before the mons mark it out (mon_osd_down_out_interval)
+	DownOutInterval int `json:"downOutInterval,omitempty"`
+	// The smallest crush unit type whose osds are not marked out automatically when all of them are down
+	// (mon_osd_down_out_subtree_limit)
+	DownOutSubtreeLimit string `json:"downOutSubtreeLimit,omitempty"`
+	// The label of the nodes with the failure domain across which the mons are spread
+	// (failure-domain.beta.kubernetes.io/zone by default)
+	ZoneLabel string `json:"zoneLabel,omitempty"`
+	// Whether each mon must run in a different failure domain
+	StrictZoneSpread bool `json:"strictZoneSpread,omitempty"`
+	// A chrony sidecar that synchronizes the clock of the nodes of the mons, for the hosts whose ntp cannot be fixed
+	Chrony *ChronySpec `json:"chrony,omitempty"`
+}
+
+// ChronySpec represents the chrony sidecar of the mons
+type ChronySpec struct {
+	// The image with chronyd
+	Image string `json:"image"`
+	// The ntp servers to synchronize with. The pool.ntp.org pool is used if empty.
+	Servers []string `json:"servers,omitempty"`
 }
 
 type RBDMirroringSpec struct {
@@ -130,7 +352,8 @@ type RBDMirroringSpec struct {
 type CephBlockPool struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              PoolSpec `json:"spec"`
+	Spec              PoolSpec   `json:"spec"`
+	Status            PoolStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -154,14 +377,90 @@ type PoolSpec struct {
 
 	// The erasure code settings
 	ErasureCoded ErasureCodedSpec `json:"erasureCoded"`
+
+	// The crush rule of the pool, such as a rule defined by a CephCrushRule, instead of the rule created for the pool
+	CrushRule string `json:"crushRule,omitempty"`
+
+	// The mirroring of the rbd images of the pool to the peer clusters
+	Mirroring *MirroringSpec `json:"mirroring,omitempty"`
+
+	// The size of the data expected in the pool, such as 100Gi, projected on the raw utilization of the cluster by the
+	// capacity guardrail before creating the pool. If not set, the pool is counted as empty.
+	ExpectedSize string `json:"expectedSize,omitempty"`
+}
+
+// MirroringSpec represents the mirroring of a block pool or a filesystem to the peer clusters
+type MirroringSpec struct {
+	// The mirroring mode of a block pool: image, where the mirroring is enabled for each image, or pool. The default is
+	// image. Not used by the filesystems.
+	Mode string `json:"mode,omitempty"`
+
+	// The peer clusters imported from their bootstrap tokens
+	Peers MirroringPeerSpec `json:"peers,omitempty"`
+}
+
+// MirroringPeerSpec represents the peer clusters of a block pool or a filesystem
+type MirroringPeerSpec struct {
+	// The names of the secrets with a bootstrap token created by a peer cluster in their "token" key
+	SecretNames []string `json:"secretNames,omitempty"`
 }
 
 // ReplicationSpec represents the spec for replication in a pool
 type ReplicatedSpec struct {
 	// Number of copies per object in a replicated storage pool, including the object itself (required for replicated pool type)
 	Size uint `json:"size"`
+
+	// Whether a size of at least 3 is required. Set to false to allow pools with a size of 1 or 2. Default is true.
+	RequireSafeReplicaSize *bool `json:"requireSafeReplicaSize,omitempty"`
+
+	// Place the primary replica and the other replicas on different device classes
+	HybridStorage *HybridStorageSpec `json:"hybridStorage,omitempty"`
 }
 
+// HybridStorageSpec represents the device classes of the replicas of a hybrid pool
+type HybridStorageSpec struct {
+	// The device class of the primary replica, which serves the reads, such as ssd
+	PrimaryDeviceClass string `json:"primaryDeviceClass"`
+
+	// The device class of the other replicas, such as hdd
+	SecondaryDeviceClass string `json:"secondaryDeviceClass"`
+}
+
+// PoolStatus represents the status of a pool
+type PoolStatus struct {
+	Conditions []PoolCondition `json:"conditions,omitempty"`
+	// The usage of the pool, collected periodically
+	Usage *PoolUsage `json:"usage,omitempty"`
+}
+
+// PoolUsage represents the usage of a pool reported by ceph df
+type PoolUsage struct {
+	// The bytes stored in the pool, before replication or erasure coding
+	UsedBytes uint64 `json:"usedBytes"`
+	// The bytes that can still be stored in the pool before the first osd is full
+	MaxAvailableBytes uint64 `json:"maxAvailableBytes"`
+	Objects           uint64 `json:"objects"`
+	// The time of the collection of the usage
+	LastUpdated metav1.Time `json:"lastUpdated"`
+}
+
+// PoolCondition represents a condition of a pool that needs the attention of the admin
+type PoolCondition struct {
+	Type    PoolConditionType  `json:"type"`
+	Status  v1.ConditionStatus `json:"status"`
+	Reason  string             `json:"reason,omitempty"`
+	Message string             `json:"message,omitempty"`
+}
+
+type PoolConditionType string
+
+const (
+	// PoolConditionUnsafeReplicaSize is set when the pool has less than 3 replicas
+	PoolConditionUnsafeReplicaSize PoolConditionType = "UnsafeReplicaSize"
+	// PoolConditionCapacityExceeded is set when the creation of the pool is blocked by the capacity guardrail
+	PoolConditionCapacityExceeded PoolConditionType = "CapacityExceeded"
+)
+
 // ErasureCodeSpec represents the spec for erasure code in a pool
 type ErasureCodedSpec struct {
 	// Number of coding chunks per object in an erasure coded storage pool (required for erasure-coded pool type)
@@ -172,6 +471,107 @@ type ErasureCodedSpec struct {
 
 	// The algorithm for erasure coding
 	Algorithm string `json:"algorithm"`
+
+	// The erasure code plugin: jerasure, isa, lrc, shec or clay. If not specified, the plugin of the default profile is used.
+	Plugin string `json:"plugin,omitempty"`
+
+	// The technique of the erasure code plugin, such as reed_sol_van or cauchy_good
+	Technique string `json:"technique,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephBlockPoolRadosNamespace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              RadosNamespaceSpec    `json:"spec"`
+	Status            *RadosNamespaceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephBlockPoolRadosNamespaceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephBlockPoolRadosNamespace `json:"items"`
+}
+
+// RadosNamespaceSpec represents the spec of a RADOS namespace of a block pool, named after the resource
+type RadosNamespaceSpec struct {
+	// The name of the CephBlockPool where the namespace is created
+	BlockPoolName string `json:"blockPoolName"`
+}
+
+// RadosNamespaceStatus represents the status of a RADOS namespace
+type RadosNamespaceStatus struct {
+	Phase   string `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+	// The ceph user restricted to the namespace, to be the mountUser of the storage classes
+	User string `json:"user,omitempty"`
+	// The secret with the key of the user, to be the mountSecret of the storage classes
+	SecretName string `json:"secretName,omitempty"`
+}
+
+const (
+	RadosNamespacePhaseReady  = "Ready"
+	RadosNamespacePhaseFailed = "Failed"
+)
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephCrushRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              CrushRuleSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephCrushRuleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephCrushRule `json:"items"`
+}
+
+// CrushRuleSpec represents the spec of a crush rule
+type CrushRuleSpec struct {
+	// The type of the pools using the rule: replicated (default) or erasure
+	Type string `json:"type,omitempty"`
+
+	// The root of the crush hierarchy the osds are taken from (default is "default")
+	Root string `json:"root,omitempty"`
+
+	// The type of the buckets across which the replicas or chunks are spread (default is "host")
+	FailureDomain string `json:"failureDomain,omitempty"`
+
+	// The class of the devices of the osds taken by the rule, such as hdd or ssd
+	DeviceClass string `json:"deviceClass,omitempty"`
+
+	// The steps of the rule. If specified, the root, failure domain and device class are ignored.
+	Steps []CrushRuleStep `json:"steps,omitempty"`
+}
+
+// CrushRuleStep represents a step of a crush rule
+type CrushRuleStep struct {
+	// The operation of the step: take, choose, chooseleaf or emit
+	Op string `json:"op"`
+
+	// The bucket taken by a take step
+	Item string `json:"item,omitempty"`
+
+	// The class of the devices taken by a take step
+	DeviceClass string `json:"deviceClass,omitempty"`
+
+	// The number of buckets selected by a choose or chooseleaf step. 0 selects as many buckets as the size of
+	// the pool and a negative number selects that many buckets less than the size of the pool.
+	Num int `json:"num,omitempty"`
+
+	// The type of the buckets selected by a choose or chooseleaf step
+	Type string `json:"type,omitempty"`
 }
 
 // +genclient
@@ -202,6 +602,9 @@ type FilesystemSpec struct {
 
 	// The mds pod info
 	MetadataServer MetadataServerSpec `json:"metadataServer"`
+
+	// The snapshot mirroring of the filesystem to the peer clusters
+	Mirroring *MirroringSpec `json:"mirroring,omitempty"`
 }
 
 type MetadataServerSpec struct {
@@ -217,6 +620,12 @@ type MetadataServerSpec struct {
 
 	// The resource requirements for the rgw pods
 	Resources v1.ResourceRequirements `json:"resources"`
+
+	// The liveness probe checking the admin socket of the mds daemons
+	LivenessProbe ProbeSpec `json:"livenessProbe,omitempty"`
+
+	// The user-defined init containers, sidecars and volumes added to the mds pods
+	PodExtension rook.PodExtension `json:"podExtension,omitempty"`
 }
 
 // +genclient
@@ -247,6 +656,12 @@ type ObjectStoreSpec struct {
 
 	// The rgw pod info
 	Gateway GatewaySpec `json:"gateway"`
+
+	// The DNS names the object store is served under
+	Hosting HostingSpec `json:"hosting,omitempty"`
+
+	// The placement targets and storage classes the buckets and objects are tiered on
+	PlacementTargets []PlacementTargetSpec `json:"placementTargets,omitempty"`
 }
 
 // +genclient
@@ -256,7 +671,8 @@ type ObjectStoreSpec struct {
 type CephObjectStoreUser struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              ObjectStoreUserSpec `json:"spec"`
+	Spec              ObjectStoreUserSpec   `json:"spec"`
+	Status            ObjectStoreUserStatus `json:"status,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -273,6 +689,424 @@ type ObjectStoreUserSpec struct {
 	Store string `json:"store,omitempty"`
 	//The display name for the ceph users
 	DisplayName string `json:"displayName,omitempty"`
+	// The bundles of the credentials of the user with the endpoint of the store, written to the secret of the user
+	CredentialBundles CredentialBundleSpec `json:"credentialBundles,omitempty"`
+}
+
+// CredentialBundleSpec represents the configuration files of the S3 clients generated for an object store user
+type CredentialBundleSpec struct {
+	// The formats of the bundles: s3a (Hadoop), velero and minio
+	Formats []string `json:"formats,omitempty"`
+	// The endpoint of the store for the clients, such as the host of an ingress. The default is the url of the rgw service.
+	Endpoint string `json:"endpoint,omitempty"`
+	// The region of the store expected by the clients. The default is us-east-1.
+	Region string `json:"region,omitempty"`
+	// The access of the keys of the bundles: full (the default) for the keys of the user, or read, write or readwrite
+	// for the keys of a subuser restricted to this access
+	Access string `json:"access,omitempty"`
+}
+
+// ObjectStoreUserStatus represents the status of an object store user
+type ObjectStoreUserStatus struct {
+	// The usage of the object store by the user, collected periodically from the usage log of rgw
+	Usage *ObjectUserUsage `json:"usage,omitempty"`
+}
+
+// ObjectUserUsage represents the total usage of the object store by a user
+type ObjectUserUsage struct {
+	BytesSent     uint64 `json:"bytesSent"`
+	BytesReceived uint64 `json:"bytesReceived"`
+	Ops           uint64 `json:"ops"`
+	SuccessfulOps uint64 `json:"successfulOps"`
+	// The time of the collection of the usage
+	LastUpdated metav1.Time `json:"lastUpdated"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephObjectBucket struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              ObjectBucketSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephObjectBucketList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephObjectBucket `json:"items"`
+}
+
+// ObjectBucketSpec represents the spec of a bucket of an object store
+type ObjectBucketSpec struct {
+	// The store the bucket is created in
+	Store string `json:"store"`
+
+	// The object store user owning the bucket. The bucket is managed with the credentials of the user.
+	Owner string `json:"owner"`
+
+	// The name of the bucket. If not set, the name of the resource is used.
+	BucketName string `json:"bucketName,omitempty"`
+
+	// The lifecycle rules expiring or transitioning the objects of the bucket
+	Lifecycle []BucketLifecycleRule `json:"lifecycle,omitempty"`
+
+	// The quota of the bucket
+	Quota BucketQuotaSpec `json:"quota,omitempty"`
+
+	// The policy document of the bucket in JSON
+	Policy string `json:"policy,omitempty"`
+}
+
+// BucketQuotaSpec represents the quota of a bucket
+type BucketQuotaSpec struct {
+	// The maximum number of objects of the bucket. 0 is no limit.
+	MaxObjects int64 `json:"maxObjects,omitempty"`
+
+	// The maximum size of the objects of the bucket, such as 10Gi. If not set, there is no limit.
+	MaxSize string `json:"maxSize,omitempty"`
+}
+
+// BucketLifecycleRule represents a lifecycle rule of a bucket
+type BucketLifecycleRule struct {
+	// The id of the rule
+	ID string `json:"id"`
+
+	// The prefix of the keys of the objects the rule applies to. If not set, the rule applies to all the objects.
+	Prefix string `json:"prefix,omitempty"`
+
+	// Whether the rule is disabled
+	Disabled bool `json:"disabled,omitempty"`
+
+	// The number of days after their creation the objects expire
+	ExpirationDays int `json:"expirationDays,omitempty"`
+
+	// The number of days after they became noncurrent the versions of the objects expire
+	NoncurrentVersionExpirationDays int `json:"noncurrentVersionExpirationDays,omitempty"`
+
+	// The number of days after their start the incomplete multipart uploads are aborted
+	AbortIncompleteMultipartUploadDays int `json:"abortIncompleteMultipartUploadDays,omitempty"`
+
+	// The transitions of the objects to other storage classes
+	Transitions []BucketLifecycleTransition `json:"transitions,omitempty"`
+}
+
+// BucketLifecycleTransition represents the transition of the objects to a storage class
+type BucketLifecycleTransition struct {
+	// The number of days after their creation the objects are transitioned
+	Days int `json:"days"`
+
+	// The storage class the objects are transitioned to
+	StorageClass string `json:"storageClass"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephCOSIDriver struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              COSIDriverSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephCOSIDriverList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephCOSIDriver `json:"items"`
+}
+
+// COSIDriverSpec represents the spec of the driver of the Container Object Storage Interface for the object stores
+type COSIDriverSpec struct {
+	// The image of the ceph COSI driver
+	Image string `json:"image,omitempty"`
+
+	// The image of the objectstorage provisioner sidecar
+	ObjectProvisionerImage string `json:"objectProvisionerImage,omitempty"`
+
+	// Auto (default) to deploy the driver when the cluster has an object store, Force to always deploy it,
+	// or Never to remove it
+	DeploymentStrategy string `json:"deploymentStrategy,omitempty"`
+
+	// The affinity to place the driver pod (default is to place on any available node)
+	Placement rook.Placement `json:"placement,omitempty"`
+
+	// The resource requirements for the driver containers
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephBackupSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              BackupSnapshotSpec   `json:"spec"`
+	Status            BackupSnapshotStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephBackupSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephBackupSnapshot `json:"items"`
+}
+
+// BackupSnapshotSpec represents the block volumes of a namespace snapshotted together for a backup
+type BackupSnapshotSpec struct {
+	// The names of the pvcs of the namespace to snapshot
+	PVCs []string `json:"pvcs,omitempty"`
+
+	// The labels of the pvcs of the namespace to snapshot, in addition to the pvcs listed by name
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Whether the filesystems of the mounted volumes are frozen by the agents while the snapshots are taken
+	Freeze bool `json:"freeze,omitempty"`
+
+	// The maximum number of seconds the filesystems are frozen. The default is 30.
+	FreezeTimeoutSeconds int `json:"freezeTimeoutSeconds,omitempty"`
+}
+
+// BackupSnapshotStatus represents the progress of a backup snapshot
+type BackupSnapshotStatus struct {
+	// Pending, Freezing, Snapshotting, Thawing, Completed or Failed
+	Phase string `json:"phase,omitempty"`
+
+	// The reason of the failure of the snapshot
+	Message string `json:"message,omitempty"`
+
+	// The volumes of the snapshot
+	Volumes []BackupSnapshotVolume `json:"volumes,omitempty"`
+
+	// The time the snapshots of the images were taken
+	SnapshotTime *metav1.Time `json:"snapshotTime,omitempty"`
+}
+
+// BackupSnapshotVolume represents the snapshot of the image of a pvc
+type BackupSnapshotVolume struct {
+	PVC              string `json:"pvc"`
+	ClusterNamespace string `json:"clusterNamespace"`
+	Pool             string `json:"pool"`
+	Image            string `json:"image"`
+
+	// The name of the snapshot of the image
+	Snapshot string `json:"snapshot,omitempty"`
+
+	// The mounts of the volume frozen by the agents
+	Mounts []BackupSnapshotMount `json:"mounts,omitempty"`
+}
+
+// BackupSnapshotMount represents the mount of a volume in a pod
+type BackupSnapshotMount struct {
+	Node     string `json:"node"`
+	MountDir string `json:"mountDir"`
+	Frozen   bool   `json:"frozen,omitempty"`
+	// The time the agent thaws the mount at the latest, even if it restarted
+	FrozenUntil *metav1.Time `json:"frozenUntil,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephVolumeGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              VolumeGroupSpec   `json:"spec"`
+	Status            VolumeGroupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephVolumeGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephVolumeGroup `json:"items"`
+}
+
+// VolumeGroupSpec represents the block volumes of a namespace in an rbd group, snapshotted atomically
+type VolumeGroupSpec struct {
+	// The names of the pvcs of the namespace in the group
+	PVCs []string `json:"pvcs,omitempty"`
+
+	// The labels of the pvcs of the namespace in the group, in addition to the pvcs listed by name
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// The names of the snapshots of the group. A snapshot is taken when it is added, and deleted when it is removed.
+	Snapshots []string `json:"snapshots,omitempty"`
+
+	// The clones of the volumes of the group from its snapshots, created as new pvcs
+	Clones []VolumeGroupClone `json:"clones,omitempty"`
+
+	// The snapshot the volumes of the group are rolled back to. The volumes must not be mounted.
+	RestoreSnapshot string `json:"restoreSnapshot,omitempty"`
+}
+
+// VolumeGroupClone represents the clones of the volumes of a group from a snapshot of the group
+type VolumeGroupClone struct {
+	// The name of the clone, appended to the names of the pvcs of the group for the names of the cloned pvcs
+	Name string `json:"name"`
+
+	// The snapshot of the group the volumes are cloned from
+	Snapshot string `json:"snapshot"`
+}
+
+// VolumeGroupStatus represents the rbd group of a volume group
+type VolumeGroupStatus struct {
+	// The cluster and the pool of the rbd group
+	ClusterNamespace string `json:"clusterNamespace,omitempty"`
+	Pool             string `json:"pool,omitempty"`
+
+	// The volumes in the group
+	Volumes []VolumeGroupImage `json:"volumes,omitempty"`
+
+	// The snapshots of the group
+	Snapshots []VolumeGroupSnapshot `json:"snapshots,omitempty"`
+
+	// The names of the clones created
+	Clones []string `json:"clones,omitempty"`
+
+	// The last snapshot the volumes were rolled back to
+	RestoredSnapshot string `json:"restoredSnapshot,omitempty"`
+
+	// The reason of the last failure to reconcile the group
+	Message string `json:"message,omitempty"`
+}
+
+// VolumeGroupImage represents the image of a pvc in an rbd group
+type VolumeGroupImage struct {
+	PVC   string `json:"pvc"`
+	Pool  string `json:"pool"`
+	Image string `json:"image"`
+}
+
+// VolumeGroupSnapshot represents a snapshot of an rbd group
+type VolumeGroupSnapshot struct {
+	Name string      `json:"name"`
+	Time metav1.Time `json:"time"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephMetadataBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              MetadataBackupSpec   `json:"spec"`
+	Status            MetadataBackupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephMetadataBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephMetadataBackup `json:"items"`
+}
+
+// MetadataBackupSpec represents the periodic backups of the metadata of a cluster, from which the cluster can be
+// reconstructed after its control plane was destroyed
+type MetadataBackupSpec struct {
+	// The number of minutes between two backups. The default is 1440 (a day).
+	IntervalMinutes int `json:"intervalMinutes,omitempty"`
+
+	// The number of backups kept, the older backups are deleted. The default is 7.
+	Retention int `json:"retention,omitempty"`
+
+	// The name of the pvc of the namespace the backups are written to
+	PVC string `json:"pvc,omitempty"`
+
+	// The bucket the backups are uploaded to
+	S3 *MetadataBackupS3Spec `json:"s3,omitempty"`
+
+	// Whether the keys of the cluster are backed up, in plaintext. The keyring of the daemons and clients and the mon and
+	// admin keys of the mon secret are left out of the backups by default.
+	IncludeKeys bool `json:"includeKeys,omitempty"`
+}
+
+// MetadataBackupS3Spec represents a bucket of an s3 endpoint, which should be outside of the cluster
+type MetadataBackupS3Spec struct {
+	// The http or https url of the s3 endpoint
+	Endpoint string `json:"endpoint"`
+
+	// The name of the bucket
+	Bucket string `json:"bucket"`
+
+	// The prefix of the keys of the backups in the bucket
+	Prefix string `json:"prefix,omitempty"`
+
+	// The name of the secret of the namespace with the AccessKey and the SecretKey of the bucket
+	SecretName string `json:"secretName"`
+}
+
+// MetadataBackupStatus represents the last backup of the metadata of a cluster
+type MetadataBackupStatus struct {
+	// The name of the archive of the last backup
+	LastBackup string `json:"lastBackup,omitempty"`
+
+	// The time of the last backup
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+
+	// The reason of the failure of the last backup
+	Message string `json:"message,omitempty"`
+}
+
+// HostingSpec represents the DNS names of an object store
+type HostingSpec struct {
+	// The DNS names of the object store. The buckets are the subdomains of the names with the virtual hosted style requests.
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// The ingress routing the DNS names and their subdomains to the rgw service
+	Ingress IngressSpec `json:"ingress,omitempty"`
+}
+
+// PlacementTargetSpec represents a placement target of an object store. The buckets are created in a placement target,
+// and their objects are stored in the pool of the STANDARD storage class unless the request selects another class.
+type PlacementTargetSpec struct {
+	// The name of the placement target. The "default-placement" target always exists and stores its STANDARD class in
+	// the data pool of the object store.
+	Name string `json:"name"`
+
+	// The pool of the STANDARD storage class. Required, except for the "default-placement" target where it must be unset.
+	DataPool *PoolSpec `json:"dataPool,omitempty"`
+
+	// The storage classes of the placement target in addition to STANDARD
+	StorageClasses []StorageClassSpec `json:"storageClasses,omitempty"`
+}
+
+// StorageClassSpec represents an S3 storage class of a placement target
+type StorageClassSpec struct {
+	// The name of the storage class as requested by the S3 clients, such as COLD
+	Name string `json:"name"`
+
+	// The pool storing the objects of the storage class
+	DataPool PoolSpec `json:"dataPool"`
+}
+
+// IngressSpec represents the settings of an ingress generated by the operator
+type IngressSpec struct {
+	// Whether the ingress is created
+	Enabled bool `json:"enabled,omitempty"`
+
+	// The host name of the ingress
+	Host string `json:"host,omitempty"`
+
+	// The class of the ingress controller serving the ingress
+	Class string `json:"class,omitempty"`
+
+	// The annotations of the ingress, for example to select the ingress controller
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// The name of the secret with the tls certificate of the hosts of the ingress
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
 }
 
 type GatewaySpec struct {
@@ -296,4 +1130,219 @@ type GatewaySpec struct {
 
 	// The resource requirements for the rgw pods
 	Resources v1.ResourceRequirements `json:"resources"`
+
+	// The user-defined init containers, sidecars and volumes added to the rgw pods
+	PodExtension rook.PodExtension `json:"podExtension,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephSMBGateway struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              SMBGatewaySpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephSMBGatewayList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephSMBGateway `json:"items"`
+}
+
+// SMBGatewaySpec represents a samba gateway exporting directories of the filesystems as SMB shares for the
+// windows clients
+type SMBGatewaySpec struct {
+	// The image of samba, which must include the ceph vfs module
+	Image string `json:"image"`
+
+	// The netbios name of the gateway, at most 15 characters. The default is the name of the gateway.
+	NetbiosName string `json:"netbiosName,omitempty"`
+
+	// The shares exported by the gateway
+	Shares []SMBShareSpec `json:"shares"`
+
+	// How the clients are authenticated
+	Security SMBSecuritySpec `json:"security,omitempty"`
+
+	// The type of the service of the gateway: ClusterIP (default), NodePort or LoadBalancer
+	ServiceType v1.ServiceType `json:"serviceType,omitempty"`
+
+	// The affinity to place the gateway pod (default is to place on any available node)
+	Placement rook.Placement `json:"placement,omitempty"`
+
+	// The resource requirements for the gateway container
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// The user-defined init containers, sidecars and volumes added to the gateway pod
+	PodExtension rook.PodExtension `json:"podExtension,omitempty"`
+}
+
+// SMBShareSpec represents a directory of a filesystem exported as a share
+type SMBShareSpec struct {
+	// The name of the share
+	Name string `json:"name"`
+
+	// The name of the CephFilesystem of the share
+	Filesystem string `json:"filesystem"`
+
+	// The directory of the filesystem exported by the share. The default is the root of the filesystem.
+	Path string `json:"path,omitempty"`
+
+	// Whether the clients can only read the share
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// The users and groups (prefixed with @) allowed to access the share. All the authenticated users are allowed if empty.
+	ValidUsers []string `json:"validUsers,omitempty"`
+}
+
+// SMBSecuritySpec represents the authentication of the clients of a samba gateway
+type SMBSecuritySpec struct {
+	// user (default) to authenticate the local users of the gateway, or ads to join an Active Directory domain
+	Mode string `json:"mode,omitempty"`
+
+	// The workgroup, or the netbios name of the domain with ads. The default is WORKGROUP.
+	Workgroup string `json:"workgroup,omitempty"`
+
+	// The kerberos realm of the Active Directory domain, such as EXAMPLE.COM. Required with ads.
+	Realm string `json:"realm,omitempty"`
+
+	// The name of the secret with the username and password of an account allowed to join the domain. Required with ads.
+	JoinSecretName string `json:"joinSecretName,omitempty"`
+
+	// The name of the secret whose keys are the names of the local users and whose values are their passwords.
+	// Required with user.
+	UsersSecretName string `json:"usersSecretName,omitempty"`
+
+	// The range of the unix ids the users and groups of the domain are mapped to. The default is 10000-999999.
+	IDMapRange string `json:"idmapRange,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephMirroringStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              MirroringStatusSpec `json:"spec"`
+	Status            MirroringHealth     `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephMirroringStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephMirroringStatus `json:"items"`
+}
+
+// MirroringStatusSpec represents the pools and filesystems whose mirroring to the peer clusters is checked
+type MirroringStatusSpec struct {
+	// The block pools mirrored by rbd-mirror. The default is all the block pools with mirroring enabled.
+	Pools []string `json:"pools,omitempty"`
+
+	// The filesystems mirrored by cephfs-mirror
+	Filesystems []string `json:"filesystems,omitempty"`
+
+	// The number of seconds between two checks. The default is 60.
+	IntervalSeconds int `json:"intervalSeconds,omitempty"`
+
+	// The number of journal entries an image can be behind its primary image before it is lagging. Not checked if 0.
+	MaxEntriesBehind int64 `json:"maxEntriesBehind,omitempty"`
+
+	// The number of seconds since the last update of the status of an image before it is lagging. Not checked if 0.
+	MaxSyncLagSeconds int `json:"maxSyncLagSeconds,omitempty"`
+}
+
+// MirroringHealth represents the health of the mirroring of the pools and filesystems to the peer clusters
+type MirroringHealth struct {
+	// OK, WARNING or ERROR, the worst health of the pools and filesystems
+	Health string `json:"health,omitempty"`
+
+	// Whether all the pools and filesystems are healthy, so the peer clusters are ready to take over
+	Ready bool `json:"ready"`
+
+	// The time of the last check
+	LastChecked *metav1.Time `json:"lastChecked,omitempty"`
+
+	// The mirroring of the block pools
+	Pools []PoolMirroringStatus `json:"pools,omitempty"`
+
+	// The mirroring of the filesystems
+	Filesystems []FilesystemMirroringStatus `json:"filesystems,omitempty"`
+}
+
+// PoolMirroringStatus represents the mirroring of the images of a block pool
+type PoolMirroringStatus struct {
+	Name string `json:"name"`
+
+	// The mirroring mode of the pool: disabled, pool or image
+	Mode string `json:"mode,omitempty"`
+
+	// OK, WARNING or ERROR
+	Health string `json:"health"`
+
+	// The reason of the health of the pool
+	Message string `json:"message,omitempty"`
+
+	// The peer clusters of the pool
+	Peers []MirroringPeerStatus `json:"peers,omitempty"`
+
+	// The number of images by mirroring state, such as up+replaying
+	States map[string]int `json:"states,omitempty"`
+
+	// The largest number of journal entries an image is behind its primary image
+	MaxEntriesBehind int64 `json:"maxEntriesBehind"`
+
+	// The images which are not healthy or lagging
+	UnhealthyImages []ImageMirroringStatus `json:"unhealthyImages,omitempty"`
+}
+
+// MirroringPeerStatus represents a peer cluster of a pool or filesystem
+type MirroringPeerStatus struct {
+	UUID        string `json:"uuid"`
+	SiteName    string `json:"siteName,omitempty"`
+	ClusterName string `json:"clusterName,omitempty"`
+	ClientName  string `json:"clientName,omitempty"`
+
+	// The remote filesystem of a filesystem peer
+	Filesystem string `json:"filesystem,omitempty"`
+
+	// The number of directories of a filesystem peer which failed to synchronize
+	FailureCount int `json:"failureCount,omitempty"`
+
+	// The number of directories of a filesystem peer which recovered from a failure
+	RecoveryCount int `json:"recoveryCount,omitempty"`
+}
+
+// ImageMirroringStatus represents the mirroring of an image
+type ImageMirroringStatus struct {
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+	LastUpdate  string `json:"lastUpdate,omitempty"`
+}
+
+// FilesystemMirroringStatus represents the snapshot mirroring of the directories of a filesystem
+type FilesystemMirroringStatus struct {
+	Name string `json:"name"`
+
+	// OK, WARNING or ERROR
+	Health string `json:"health"`
+
+	// The reason of the health of the filesystem
+	Message string `json:"message,omitempty"`
+
+	// The number of cephfs-mirror daemons mirroring the filesystem
+	Daemons int `json:"daemons"`
+
+	// The number of directories mirrored
+	Directories int `json:"directories"`
+
+	// The peer clusters of the filesystem
+	Peers []MirroringPeerStatus `json:"peers,omitempty"`
 }
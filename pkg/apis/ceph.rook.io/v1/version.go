@@ -19,11 +19,13 @@ const (
 	Luminous             = "luminous"
 	Mimic                = "mimic"
 	Nautilus             = "nautilus"
+	Octopus              = "octopus"
+	Pacific              = "pacific"
 	DefaultLuminousImage = "ceph/ceph:v12.2.9-20181026"
 )
 
 func VersionAtLeast(version, minimumVersion string) bool {
-	orderedVersions := []string{Luminous, Mimic, Nautilus}
+	orderedVersions := []string{Luminous, Mimic, Nautilus, Octopus, Pacific}
 	found := false
 	for _, v := range orderedVersions {
 		if v == minimumVersion {
@@ -32,6 +32,10 @@ func TestVersionAtLeast(t *testing.T) {
 	assert.True(t, VersionAtLeast(Nautilus, Luminous))
 	assert.True(t, VersionAtLeast(Nautilus, Mimic))
 	assert.True(t, VersionAtLeast(Nautilus, Nautilus))
+	assert.False(t, VersionAtLeast(Nautilus, Octopus))
+	assert.True(t, VersionAtLeast(Octopus, Nautilus))
+	assert.False(t, VersionAtLeast(Octopus, Pacific))
+	assert.True(t, VersionAtLeast(Pacific, Octopus))
 
 	// Invalid combinations
 	assert.False(t, VersionAtLeast(Mimic, "foo"))
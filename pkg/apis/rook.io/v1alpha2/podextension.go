@@ -0,0 +1,47 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"k8s.io/api/core/v1"
+)
+
+func (p PodExtensionSpec) All() PodExtension {
+	return p[PlacementKeyAll]
+}
+
+// ApplyToPodSpec adds the containers and volumes of the extension to a pod spec. The volume mounts are added to the
+// containers of the daemon, so they must be generated before.
+func (e PodExtension) ApplyToPodSpec(t *v1.PodSpec) {
+	for i := range t.Containers {
+		t.Containers[i].VolumeMounts = append(t.Containers[i].VolumeMounts, e.VolumeMounts...)
+	}
+	t.InitContainers = append(t.InitContainers, e.InitContainers...)
+	t.Containers = append(t.Containers, e.Sidecars...)
+	t.Volumes = append(t.Volumes, e.Volumes...)
+}
+
+// Merge returns a PodExtension with the containers and volumes of the original PodExtension followed by the ones of
+// the supplied one
+func (e PodExtension) Merge(with PodExtension) PodExtension {
+	var ret PodExtension
+	ret.InitContainers = append(append(ret.InitContainers, e.InitContainers...), with.InitContainers...)
+	ret.Sidecars = append(append(ret.Sidecars, e.Sidecars...), with.Sidecars...)
+	ret.Volumes = append(append(ret.Volumes, e.Volumes...), with.Volumes...)
+	ret.VolumeMounts = append(append(ret.VolumeMounts, e.VolumeMounts...), with.VolumeMounts...)
+	return ret
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2016 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+)
+
+func TestPodExtension_ApplyToPodSpec(t *testing.T) {
+	ext := PodExtension{
+		InitContainers: []v1.Container{{Name: "scan"}},
+		Sidecars:       []v1.Container{{Name: "backup", VolumeMounts: []v1.VolumeMount{{Name: "backup", MountPath: "/backup"}}}},
+		Volumes:        []v1.Volume{{Name: "backup"}},
+		VolumeMounts:   []v1.VolumeMount{{Name: "backup", MountPath: "/var/lib/backup"}},
+	}
+	spec := &v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "config-init"}},
+		Containers:     []v1.Container{{Name: "daemon", VolumeMounts: []v1.VolumeMount{{Name: "data", MountPath: "/data"}}}},
+		Volumes:        []v1.Volume{{Name: "data"}},
+	}
+	ext.ApplyToPodSpec(spec)
+
+	expected := &v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "config-init"}, {Name: "scan"}},
+		Containers: []v1.Container{
+			{Name: "daemon", VolumeMounts: []v1.VolumeMount{{Name: "data", MountPath: "/data"}, {Name: "backup", MountPath: "/var/lib/backup"}}},
+			// the mounts of the sidecars are not changed
+			{Name: "backup", VolumeMounts: []v1.VolumeMount{{Name: "backup", MountPath: "/backup"}}},
+		},
+		Volumes: []v1.Volume{{Name: "data"}, {Name: "backup"}},
+	}
+	assert.Equal(t, expected, spec)
+
+	// an empty extension does not change the pod spec
+	spec = &v1.PodSpec{Containers: []v1.Container{{Name: "daemon"}}}
+	PodExtension{}.ApplyToPodSpec(spec)
+	assert.Equal(t, &v1.PodSpec{Containers: []v1.Container{{Name: "daemon"}}}, spec)
+}
+
+func TestPodExtension_Merge(t *testing.T) {
+	all := PodExtension{Sidecars: []v1.Container{{Name: "scanner"}}, Volumes: []v1.Volume{{Name: "a"}}}
+	osd := PodExtension{Sidecars: []v1.Container{{Name: "backup"}}, InitContainers: []v1.Container{{Name: "init"}}}
+	spec := PodExtensionSpec{"all": all, "osd": osd}
+
+	merged := spec.All().Merge(spec["osd"])
+	assert.Equal(t, []v1.Container{{Name: "scanner"}, {Name: "backup"}}, merged.Sidecars)
+	assert.Equal(t, []v1.Container{{Name: "init"}}, merged.InitContainers)
+	assert.Equal(t, []v1.Volume{{Name: "a"}}, merged.Volumes)
+	assert.Equal(t, 0, len(merged.VolumeMounts))
+
+	// the original extensions are not modified
+	assert.Equal(t, 1, len(spec.All().Sidecars))
+	assert.Equal(t, 1, len(spec["osd"].Sidecars))
+}
@@ -81,6 +81,20 @@ type Placement struct {
 
 type ResourceSpec map[string]v1.ResourceRequirements
 
+type PodExtensionSpec map[string]PodExtension
+
+// PodExtension is the user-defined containers and volumes added to the pods of a daemon
+type PodExtension struct {
+	// Init containers started after the init containers of the daemon
+	InitContainers []v1.Container `json:"initContainers,omitempty"`
+	// Containers running next to the daemon, like backup agents or compliance scanners
+	Sidecars []v1.Container `json:"sidecars,omitempty"`
+	// Volumes added to the pods, available to the containers above
+	Volumes []v1.Volume `json:"volumes,omitempty"`
+	// Volume mounts added to the containers of the daemon
+	VolumeMounts []v1.VolumeMount `json:"volumeMounts,omitempty"`
+}
+
 type NetworkSpec struct {
 	metav1.TypeMeta `json:",inline"`
 
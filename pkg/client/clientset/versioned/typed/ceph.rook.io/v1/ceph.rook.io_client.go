@@ -27,11 +27,20 @@ import (
 
 type CephV1Interface interface {
 	RESTClient() rest.Interface
+	CephBackupSnapshotsGetter
 	CephBlockPoolsGetter
+	CephBlockPoolRadosNamespacesGetter
+	CephCOSIDriversGetter
 	CephClustersGetter
+	CephCrushRulesGetter
 	CephFilesystemsGetter
+	CephMetadataBackupsGetter
+	CephMirroringStatusesGetter
+	CephObjectBucketsGetter
 	CephObjectStoresGetter
 	CephObjectStoreUsersGetter
+	CephSMBGatewaysGetter
+	CephVolumeGroupsGetter
 }
 
 // CephV1Client is used to interact with features provided by the ceph.rook.io group.
@@ -39,18 +48,46 @@ type CephV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *CephV1Client) CephBackupSnapshots(namespace string) CephBackupSnapshotInterface {
+	return newCephBackupSnapshots(c, namespace)
+}
+
 func (c *CephV1Client) CephBlockPools(namespace string) CephBlockPoolInterface {
 	return newCephBlockPools(c, namespace)
 }
 
+func (c *CephV1Client) CephBlockPoolRadosNamespaces(namespace string) CephBlockPoolRadosNamespaceInterface {
+	return newCephBlockPoolRadosNamespaces(c, namespace)
+}
+
+func (c *CephV1Client) CephCOSIDrivers(namespace string) CephCOSIDriverInterface {
+	return newCephCOSIDrivers(c, namespace)
+}
+
 func (c *CephV1Client) CephClusters(namespace string) CephClusterInterface {
 	return newCephClusters(c, namespace)
 }
 
+func (c *CephV1Client) CephCrushRules(namespace string) CephCrushRuleInterface {
+	return newCephCrushRules(c, namespace)
+}
+
 func (c *CephV1Client) CephFilesystems(namespace string) CephFilesystemInterface {
 	return newCephFilesystems(c, namespace)
 }
 
+func (c *CephV1Client) CephMetadataBackups(namespace string) CephMetadataBackupInterface {
+	return newCephMetadataBackups(c, namespace)
+}
+
+func (c *CephV1Client) CephMirroringStatuses(namespace string) CephMirroringStatusInterface {
+	return newCephMirroringStatuses(c, namespace)
+}
+
+func (c *CephV1Client) CephObjectBuckets(namespace string) CephObjectBucketInterface {
+	return newCephObjectBuckets(c, namespace)
+}
+
 func (c *CephV1Client) CephObjectStores(namespace string) CephObjectStoreInterface {
 	return newCephObjectStores(c, namespace)
 }
@@ -59,6 +96,14 @@ func (c *CephV1Client) CephObjectStoreUsers(namespace string) CephObjectStoreUse
 	return newCephObjectStoreUsers(c, namespace)
 }
 
+func (c *CephV1Client) CephSMBGateways(namespace string) CephSMBGatewayInterface {
+	return newCephSMBGateways(c, namespace)
+}
+
+func (c *CephV1Client) CephVolumeGroups(namespace string) CephVolumeGroupInterface {
+	return newCephVolumeGroups(c, namespace)
+}
+
 // NewForConfig creates a new CephV1Client for the given config.
 func NewForConfig(c *rest.Config) (*CephV1Client, error) {
 	config := *c
@@ -28,18 +28,46 @@ type FakeCephV1 struct {
 	*testing.Fake
 }
 
+func (c *FakeCephV1) CephBackupSnapshots(namespace string) v1.CephBackupSnapshotInterface {
+	return &FakeCephBackupSnapshots{c, namespace}
+}
+
 func (c *FakeCephV1) CephBlockPools(namespace string) v1.CephBlockPoolInterface {
 	return &FakeCephBlockPools{c, namespace}
 }
 
+func (c *FakeCephV1) CephBlockPoolRadosNamespaces(namespace string) v1.CephBlockPoolRadosNamespaceInterface {
+	return &FakeCephBlockPoolRadosNamespaces{c, namespace}
+}
+
+func (c *FakeCephV1) CephCOSIDrivers(namespace string) v1.CephCOSIDriverInterface {
+	return &FakeCephCOSIDrivers{c, namespace}
+}
+
 func (c *FakeCephV1) CephClusters(namespace string) v1.CephClusterInterface {
 	return &FakeCephClusters{c, namespace}
 }
 
+func (c *FakeCephV1) CephCrushRules(namespace string) v1.CephCrushRuleInterface {
+	return &FakeCephCrushRules{c, namespace}
+}
+
 func (c *FakeCephV1) CephFilesystems(namespace string) v1.CephFilesystemInterface {
 	return &FakeCephFilesystems{c, namespace}
 }
 
+func (c *FakeCephV1) CephMetadataBackups(namespace string) v1.CephMetadataBackupInterface {
+	return &FakeCephMetadataBackups{c, namespace}
+}
+
+func (c *FakeCephV1) CephMirroringStatuses(namespace string) v1.CephMirroringStatusInterface {
+	return &FakeCephMirroringStatuses{c, namespace}
+}
+
+func (c *FakeCephV1) CephObjectBuckets(namespace string) v1.CephObjectBucketInterface {
+	return &FakeCephObjectBuckets{c, namespace}
+}
+
 func (c *FakeCephV1) CephObjectStores(namespace string) v1.CephObjectStoreInterface {
 	return &FakeCephObjectStores{c, namespace}
 }
@@ -48,6 +76,14 @@ func (c *FakeCephV1) CephObjectStoreUsers(namespace string) v1.CephObjectStoreUs
 	return &FakeCephObjectStoreUsers{c, namespace}
 }
 
+func (c *FakeCephV1) CephSMBGateways(namespace string) v1.CephSMBGatewayInterface {
+	return &FakeCephSMBGateways{c, namespace}
+}
+
+func (c *FakeCephV1) CephVolumeGroups(namespace string) v1.CephVolumeGroupInterface {
+	return &FakeCephVolumeGroups{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeCephV1) RESTClient() rest.Interface {
@@ -18,12 +18,30 @@ limitations under the License.
 
 package v1
 
+type CephBackupSnapshotExpansion interface{}
+
 type CephBlockPoolExpansion interface{}
 
+type CephBlockPoolRadosNamespaceExpansion interface{}
+
+type CephCOSIDriverExpansion interface{}
+
 type CephClusterExpansion interface{}
 
+type CephCrushRuleExpansion interface{}
+
 type CephFilesystemExpansion interface{}
 
+type CephMetadataBackupExpansion interface{}
+
+type CephMirroringStatusExpansion interface{}
+
+type CephObjectBucketExpansion interface{}
+
 type CephObjectStoreExpansion interface{}
 
 type CephObjectStoreUserExpansion interface{}
+
+type CephSMBGatewayExpansion interface{}
+
+type CephVolumeGroupExpansion interface{}
@@ -24,16 +24,34 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// CephBackupSnapshots returns a CephBackupSnapshotInformer.
+	CephBackupSnapshots() CephBackupSnapshotInformer
 	// CephBlockPools returns a CephBlockPoolInformer.
 	CephBlockPools() CephBlockPoolInformer
+	// CephBlockPoolRadosNamespaces returns a CephBlockPoolRadosNamespaceInformer.
+	CephBlockPoolRadosNamespaces() CephBlockPoolRadosNamespaceInformer
+	// CephCOSIDrivers returns a CephCOSIDriverInformer.
+	CephCOSIDrivers() CephCOSIDriverInformer
 	// CephClusters returns a CephClusterInformer.
 	CephClusters() CephClusterInformer
+	// CephCrushRules returns a CephCrushRuleInformer.
+	CephCrushRules() CephCrushRuleInformer
 	// CephFilesystems returns a CephFilesystemInformer.
 	CephFilesystems() CephFilesystemInformer
+	// CephMetadataBackups returns a CephMetadataBackupInformer.
+	CephMetadataBackups() CephMetadataBackupInformer
+	// CephMirroringStatuses returns a CephMirroringStatusInformer.
+	CephMirroringStatuses() CephMirroringStatusInformer
+	// CephObjectBuckets returns a CephObjectBucketInformer.
+	CephObjectBuckets() CephObjectBucketInformer
 	// CephObjectStores returns a CephObjectStoreInformer.
 	CephObjectStores() CephObjectStoreInformer
 	// CephObjectStoreUsers returns a CephObjectStoreUserInformer.
 	CephObjectStoreUsers() CephObjectStoreUserInformer
+	// CephSMBGateways returns a CephSMBGatewayInformer.
+	CephSMBGateways() CephSMBGatewayInformer
+	// CephVolumeGroups returns a CephVolumeGroupInformer.
+	CephVolumeGroups() CephVolumeGroupInformer
 }
 
 type version struct {
@@ -47,21 +65,56 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// CephBackupSnapshots returns a CephBackupSnapshotInformer.
+func (v *version) CephBackupSnapshots() CephBackupSnapshotInformer {
+	return &cephBackupSnapshotInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CephBlockPools returns a CephBlockPoolInformer.
 func (v *version) CephBlockPools() CephBlockPoolInformer {
 	return &cephBlockPoolInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// CephBlockPoolRadosNamespaces returns a CephBlockPoolRadosNamespaceInformer.
+func (v *version) CephBlockPoolRadosNamespaces() CephBlockPoolRadosNamespaceInformer {
+	return &cephBlockPoolRadosNamespaceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// CephCOSIDrivers returns a CephCOSIDriverInformer.
+func (v *version) CephCOSIDrivers() CephCOSIDriverInformer {
+	return &cephCOSIDriverInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CephClusters returns a CephClusterInformer.
 func (v *version) CephClusters() CephClusterInformer {
 	return &cephClusterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// CephCrushRules returns a CephCrushRuleInformer.
+func (v *version) CephCrushRules() CephCrushRuleInformer {
+	return &cephCrushRuleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CephFilesystems returns a CephFilesystemInformer.
 func (v *version) CephFilesystems() CephFilesystemInformer {
 	return &cephFilesystemInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// CephMetadataBackups returns a CephMetadataBackupInformer.
+func (v *version) CephMetadataBackups() CephMetadataBackupInformer {
+	return &cephMetadataBackupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// CephMirroringStatuses returns a CephMirroringStatusInformer.
+func (v *version) CephMirroringStatuses() CephMirroringStatusInformer {
+	return &cephMirroringStatusInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// CephObjectBuckets returns a CephObjectBucketInformer.
+func (v *version) CephObjectBuckets() CephObjectBucketInformer {
+	return &cephObjectBucketInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CephObjectStores returns a CephObjectStoreInformer.
 func (v *version) CephObjectStores() CephObjectStoreInformer {
 	return &cephObjectStoreInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -71,3 +124,13 @@ func (v *version) CephObjectStores() CephObjectStoreInformer {
 func (v *version) CephObjectStoreUsers() CephObjectStoreUserInformer {
 	return &cephObjectStoreUserInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// CephSMBGateways returns a CephSMBGatewayInformer.
+func (v *version) CephSMBGateways() CephSMBGatewayInformer {
+	return &cephSMBGatewayInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// CephVolumeGroups returns a CephVolumeGroupInformer.
+func (v *version) CephVolumeGroups() CephVolumeGroupInformer {
+	return &cephVolumeGroupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -64,16 +64,34 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Cassandra().V1alpha1().Clusters().Informer()}, nil
 
 		// Group=ceph.rook.io, Version=v1
+	case v1.SchemeGroupVersion.WithResource("cephbackupsnapshots"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephBackupSnapshots().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephblockpools"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephBlockPools().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephblockpoolradosnamespaces"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephBlockPoolRadosNamespaces().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephcosidrivers"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephCOSIDrivers().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephclusters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephClusters().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephcrushrules"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephCrushRules().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephfilesystems"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephFilesystems().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephmetadatabackups"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephMetadataBackups().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephmirroringstatuses"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephMirroringStatuses().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephobjectbuckets"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephObjectBuckets().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephobjectstores"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephObjectStores().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("cephobjectstoreusers"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephObjectStoreUsers().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephsmbgateways"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephSMBGateways().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("cephvolumegroups"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Ceph().V1().CephVolumeGroups().Informer()}, nil
 
 		// Group=ceph.rook.io, Version=v1beta1
 	case v1beta1.SchemeGroupVersion.WithResource("clusters"):
@@ -18,6 +18,14 @@ limitations under the License.
 
 package v1
 
+// CephBackupSnapshotListerExpansion allows custom methods to be added to
+// CephBackupSnapshotLister.
+type CephBackupSnapshotListerExpansion interface{}
+
+// CephBackupSnapshotNamespaceListerExpansion allows custom methods to be added to
+// CephBackupSnapshotNamespaceLister.
+type CephBackupSnapshotNamespaceListerExpansion interface{}
+
 // CephBlockPoolListerExpansion allows custom methods to be added to
 // CephBlockPoolLister.
 type CephBlockPoolListerExpansion interface{}
@@ -26,6 +34,22 @@ type CephBlockPoolListerExpansion interface{}
 // CephBlockPoolNamespaceLister.
 type CephBlockPoolNamespaceListerExpansion interface{}
 
+// CephBlockPoolRadosNamespaceListerExpansion allows custom methods to be added to
+// CephBlockPoolRadosNamespaceLister.
+type CephBlockPoolRadosNamespaceListerExpansion interface{}
+
+// CephBlockPoolRadosNamespaceNamespaceListerExpansion allows custom methods to be added to
+// CephBlockPoolRadosNamespaceNamespaceLister.
+type CephBlockPoolRadosNamespaceNamespaceListerExpansion interface{}
+
+// CephCOSIDriverListerExpansion allows custom methods to be added to
+// CephCOSIDriverLister.
+type CephCOSIDriverListerExpansion interface{}
+
+// CephCOSIDriverNamespaceListerExpansion allows custom methods to be added to
+// CephCOSIDriverNamespaceLister.
+type CephCOSIDriverNamespaceListerExpansion interface{}
+
 // CephClusterListerExpansion allows custom methods to be added to
 // CephClusterLister.
 type CephClusterListerExpansion interface{}
@@ -34,6 +58,14 @@ type CephClusterListerExpansion interface{}
 // CephClusterNamespaceLister.
 type CephClusterNamespaceListerExpansion interface{}
 
+// CephCrushRuleListerExpansion allows custom methods to be added to
+// CephCrushRuleLister.
+type CephCrushRuleListerExpansion interface{}
+
+// CephCrushRuleNamespaceListerExpansion allows custom methods to be added to
+// CephCrushRuleNamespaceLister.
+type CephCrushRuleNamespaceListerExpansion interface{}
+
 // CephFilesystemListerExpansion allows custom methods to be added to
 // CephFilesystemLister.
 type CephFilesystemListerExpansion interface{}
@@ -42,6 +74,30 @@ type CephFilesystemListerExpansion interface{}
 // CephFilesystemNamespaceLister.
 type CephFilesystemNamespaceListerExpansion interface{}
 
+// CephMetadataBackupListerExpansion allows custom methods to be added to
+// CephMetadataBackupLister.
+type CephMetadataBackupListerExpansion interface{}
+
+// CephMetadataBackupNamespaceListerExpansion allows custom methods to be added to
+// CephMetadataBackupNamespaceLister.
+type CephMetadataBackupNamespaceListerExpansion interface{}
+
+// CephMirroringStatusListerExpansion allows custom methods to be added to
+// CephMirroringStatusLister.
+type CephMirroringStatusListerExpansion interface{}
+
+// CephMirroringStatusNamespaceListerExpansion allows custom methods to be added to
+// CephMirroringStatusNamespaceLister.
+type CephMirroringStatusNamespaceListerExpansion interface{}
+
+// CephObjectBucketListerExpansion allows custom methods to be added to
+// CephObjectBucketLister.
+type CephObjectBucketListerExpansion interface{}
+
+// CephObjectBucketNamespaceListerExpansion allows custom methods to be added to
+// CephObjectBucketNamespaceLister.
+type CephObjectBucketNamespaceListerExpansion interface{}
+
 // CephObjectStoreListerExpansion allows custom methods to be added to
 // CephObjectStoreLister.
 type CephObjectStoreListerExpansion interface{}
@@ -57,3 +113,19 @@ type CephObjectStoreUserListerExpansion interface{}
 // CephObjectStoreUserNamespaceListerExpansion allows custom methods to be added to
 // CephObjectStoreUserNamespaceLister.
 type CephObjectStoreUserNamespaceListerExpansion interface{}
+
+// CephSMBGatewayListerExpansion allows custom methods to be added to
+// CephSMBGatewayLister.
+type CephSMBGatewayListerExpansion interface{}
+
+// CephSMBGatewayNamespaceListerExpansion allows custom methods to be added to
+// CephSMBGatewayNamespaceLister.
+type CephSMBGatewayNamespaceListerExpansion interface{}
+
+// CephVolumeGroupListerExpansion allows custom methods to be added to
+// CephVolumeGroupLister.
+type CephVolumeGroupListerExpansion interface{}
+
+// CephVolumeGroupNamespaceListerExpansion allows custom methods to be added to
+// CephVolumeGroupNamespaceLister.
+type CephVolumeGroupNamespaceListerExpansion interface{}
@@ -27,11 +27,14 @@ import (
 
 	"github.com/coreos/pkg/capnslog"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/capabilities"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/cluster"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/manager/ceph"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/freeze"
 	"github.com/rook/rook/pkg/operator/ceph/agent"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/api/core/v1"
 )
 
@@ -58,6 +61,7 @@ func (a *Agent) Run() error {
 	if err != nil {
 		return fmt.Errorf("failed to create volume manager: %+v", err)
 	}
+	a.publishCapabilities()
 
 	mountSecurityMode := os.Getenv(agent.AgentMountSecurityModeEnv)
 	// Don't check if it is not empty because the operator always sets it on the DaemonSet
@@ -114,6 +118,10 @@ func (a *Agent) Run() error {
 		volumeAttachmentController)
 	stopChan := make(chan struct{})
 	clusterController.StartWatch(v1.NamespaceAll, stopChan)
+
+	// freeze the filesystems of the volumes mounted on the node during the backup snapshots
+	freezeController := freeze.NewFreezeController(a.context)
+	freezeController.StartWatch(v1.NamespaceAll, stopChan)
 	go periodicallyRefreshFlexDrivers(driverName, stopChan)
 
 	sigc := make(chan os.Signal, 1)
@@ -129,6 +137,20 @@ func (a *Agent) Run() error {
 	}
 }
 
+// publishCapabilities annotates the node with the ceph clients supported by its kernel, for the provisioner to create
+// volumes that the node can mount. The volumes are created as before if the capabilities cannot be published.
+func (a *Agent) publishCapabilities() {
+	nodeName := os.Getenv(k8sutil.NodeNameEnvVar)
+	caps, err := capabilities.Probe(a.context, nodeName)
+	if err != nil {
+		logger.Warningf("failed to probe the capabilities of node %s. %+v", nodeName, err)
+		return
+	}
+	if err := capabilities.Publish(a.context.Clientset, nodeName, caps); err != nil {
+		logger.Warningf("failed to publish the capabilities of node %s. %+v", nodeName, err)
+	}
+}
+
 // In 1.11 and newer there is a timing issue loading flex drivers.
 // See https://github.com/rook/rook/issues/1501 and https://github.com/kubernetes/kubernetes/issues/60694
 // With this loop we constantly make sure the flex drivers are all loaded.
@@ -0,0 +1,220 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package capabilities to probe the ceph clients supported by the kernel of the nodes.
+package capabilities
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/sys"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/pkg/util/version"
+)
+
+const (
+	// Annotation is the annotation of the nodes with the ceph clients supported by their kernel, as probed by the agent
+	Annotation = "ceph.rook.io/client-capabilities"
+
+	cephKernelModule = "ceph"
+	cephFuseTool     = "ceph-fuse"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "agent-capabilities")
+
+var (
+	// the features of the rbd images that the kernel can map, since kernel 4.11
+	rbdSupportedFeaturesPath = "/sys/bus/rbd/supported_features"
+	procFilesystemsPath      = "/proc/filesystems"
+	fuseDevicePath           = "/dev/fuse"
+	lookPath                 = exec.LookPath
+)
+
+// rbdFeatureBits are the bits of the rbd image features in the features supported by the kernel
+var rbdFeatureBits = []struct {
+	name string
+	bit  uint64
+}{
+	{"layering", 1 << 0},
+	{"striping", 1 << 1},
+	{"exclusive-lock", 1 << 2},
+	{"object-map", 1 << 3},
+	{"fast-diff", 1 << 4},
+	{"deep-flatten", 1 << 5},
+	{"journaling", 1 << 6},
+}
+
+// rbdFeatureKernelVersions are the first kernel versions mapping the rbd images with each feature, for the kernels
+// which do not list their supported features in sysfs
+var rbdFeatureKernelVersions = []struct {
+	name    string
+	version string
+}{
+	{"layering", "3.10"},
+	{"striping", "3.10"},
+	{"exclusive-lock", "4.9"},
+}
+
+// NodeCapabilities are the ceph clients supported by the kernel of a node
+type NodeCapabilities struct {
+	KernelVersion string `json:"kernelVersion"`
+	// The rbd image features the kernel can map
+	RBDFeatures []string `json:"rbdFeatures"`
+	// Whether the kernel can mount the ceph filesystems
+	CephFSKernel bool `json:"cephfsKernel"`
+	// Whether the agent can mount the ceph filesystems with ceph-fuse
+	CephFSFuse bool `json:"cephfsFuse"`
+}
+
+// SupportsRBDFeature returns whether the kernel can map the rbd images with the feature
+func (c *NodeCapabilities) SupportsRBDFeature(feature string) bool {
+	for _, f := range c.RBDFeatures {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Probe returns the ceph clients supported by the node of the agent. The rbd kernel module must already be loaded.
+func Probe(context *clusterd.Context, nodeName string) (*NodeCapabilities, error) {
+	node, err := context.Clientset.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s. %+v", nodeName, err)
+	}
+
+	caps := &NodeCapabilities{KernelVersion: node.Status.NodeInfo.KernelVersion}
+	caps.RBDFeatures = probeRBDFeatures(caps.KernelVersion)
+	caps.CephFSKernel = probeCephFSKernel(context)
+	caps.CephFSFuse = probeCephFSFuse()
+	return caps, nil
+}
+
+// probeRBDFeatures returns the rbd image features listed by the kernel in sysfs, or the features known to be
+// supported by the kernel version for the older kernels
+func probeRBDFeatures(kernelVersion string) []string {
+	features := []string{}
+	if content, err := ioutil.ReadFile(rbdSupportedFeaturesPath); err == nil {
+		mask, err := strconv.ParseUint(strings.TrimSpace(string(content)), 0, 64)
+		if err == nil {
+			for _, f := range rbdFeatureBits {
+				if mask&f.bit != 0 {
+					features = append(features, f.name)
+				}
+			}
+			return features
+		}
+		logger.Warningf("failed to parse the rbd features %q supported by the kernel. %+v", string(content), err)
+	}
+
+	parsed, err := version.ParseGeneric(kernelVersion)
+	if err != nil {
+		logger.Warningf("failed to parse kernel version %q, assuming the rbd images can only be mapped with the layering feature. %+v", kernelVersion, err)
+		return []string{"layering"}
+	}
+	for _, f := range rbdFeatureKernelVersions {
+		if parsed.AtLeast(version.MustParseGeneric(f.version)) {
+			features = append(features, f.name)
+		}
+	}
+	return features
+}
+
+// probeCephFSKernel returns whether the ceph kernel module is builtin or can be loaded
+func probeCephFSKernel(context *clusterd.Context) bool {
+	if content, err := ioutil.ReadFile(procFilesystemsPath); err == nil {
+		for _, line := range strings.Split(string(content), "\n") {
+			if strings.TrimSpace(strings.TrimPrefix(line, "nodev")) == cephKernelModule {
+				return true
+			}
+		}
+	}
+	if err := sys.LoadKernelModule(cephKernelModule, nil, context.Executor); err != nil {
+		logger.Infof("the ceph filesystems cannot be mounted by the kernel. %+v", err)
+		return false
+	}
+	return true
+}
+
+// probeCephFSFuse returns whether the agent can mount the ceph filesystems with ceph-fuse
+func probeCephFSFuse() bool {
+	if _, err := os.Stat(fuseDevicePath); err != nil {
+		logger.Infof("the ceph filesystems cannot be mounted with fuse. %+v", err)
+		return false
+	}
+	if _, err := lookPath(cephFuseTool); err != nil {
+		logger.Infof("the ceph filesystems cannot be mounted with fuse. %+v", err)
+		return false
+	}
+	return true
+}
+
+// Publish annotates the node with its capabilities, for the provisioner to create volumes the node can mount
+func Publish(clientset kubernetes.Interface, nodeName string, caps *NodeCapabilities) error {
+	value, err := json.Marshal(caps)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the capabilities of node %s. %+v", nodeName, err)
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{Annotation: string(value)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the annotation of node %s. %+v", nodeName, err)
+	}
+
+	if _, err := clientset.CoreV1().Nodes().Patch(nodeName, types.MergePatchType, patch); err != nil {
+		return fmt.Errorf("failed to annotate node %s with its capabilities. %+v", nodeName, err)
+	}
+	logger.Infof("node %s supports the rbd image features %v, kernel cephfs: %t, fuse cephfs: %t",
+		nodeName, caps.RBDFeatures, caps.CephFSKernel, caps.CephFSFuse)
+	return nil
+}
+
+// GetNodeCapabilities returns the capabilities published by the agents, by node name. The nodes without an agent,
+// or with an agent which does not publish the capabilities, are not returned.
+func GetNodeCapabilities(clientset kubernetes.Interface) (map[string]*NodeCapabilities, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes. %+v", err)
+	}
+
+	result := map[string]*NodeCapabilities{}
+	for _, node := range nodes.Items {
+		value, ok := node.Annotations[Annotation]
+		if !ok {
+			continue
+		}
+		caps := &NodeCapabilities{}
+		if err := json.Unmarshal([]byte(value), caps); err != nil {
+			logger.Warningf("ignoring the invalid capabilities of node %s. %+v", node.Name, err)
+			continue
+		}
+		result[node.Name] = caps
+	}
+	return result, nil
+}
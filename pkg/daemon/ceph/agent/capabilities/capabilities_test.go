@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capabilities
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestProbe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capabilities")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	rbdSupportedFeaturesPath = filepath.Join(dir, "supported_features")
+	procFilesystemsPath = filepath.Join(dir, "filesystems")
+	fuseDevicePath = filepath.Join(dir, "fuse")
+	lookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}, Status: v1.NodeStatus{NodeInfo: v1.NodeSystemInfo{KernelVersion: "4.9.0-8-amd64"}}}
+	modprobeErr := errors.New("module ceph not found")
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			assert.Equal(t, "modprobe", command)
+			assert.Equal(t, []string{"ceph"}, args)
+			return modprobeErr
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(node), Executor: executor}
+
+	// the features are derived from the kernel version without sysfs, and neither cephfs client is available
+	caps, err := Probe(context, "node1")
+	assert.Nil(t, err)
+	assert.Equal(t, "4.9.0-8-amd64", caps.KernelVersion)
+	assert.Equal(t, []string{"layering", "striping", "exclusive-lock"}, caps.RBDFeatures)
+	assert.False(t, caps.CephFSKernel)
+	assert.False(t, caps.CephFSFuse)
+
+	// the features listed by the kernel, the ceph module loaded by modprobe and fuse
+	assert.Nil(t, ioutil.WriteFile(rbdSupportedFeaturesPath, []byte("0x3d\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(fuseDevicePath, []byte{}, 0644))
+	modprobeErr = nil
+	caps, err = Probe(context, "node1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"layering", "exclusive-lock", "object-map", "fast-diff", "deep-flatten"}, caps.RBDFeatures)
+	assert.True(t, caps.CephFSKernel)
+	assert.True(t, caps.CephFSFuse)
+	assert.True(t, caps.SupportsRBDFeature("fast-diff"))
+	assert.False(t, caps.SupportsRBDFeature("journaling"))
+
+	// the builtin ceph module is found without modprobe
+	assert.Nil(t, ioutil.WriteFile(procFilesystemsPath, []byte("nodev\tsysfs\n\text4\nnodev\tceph\n"), 0644))
+	modprobeErr = errors.New("modprobe should not be called")
+	assert.True(t, probeCephFSKernel(context))
+
+	// without the ceph-fuse tool
+	lookPath = func(file string) (string, error) { return "", errors.New("not found") }
+	assert.False(t, probeCephFSFuse())
+
+	_, err = Probe(context, "node2")
+	assert.NotNil(t, err)
+}
+
+func TestProbeRBDFeaturesFromKernelVersion(t *testing.T) {
+	rbdSupportedFeaturesPath = "/does/not/exist"
+	assert.Equal(t, []string{"layering", "striping"}, probeRBDFeatures("4.4.0-141-generic"))
+	assert.Equal(t, []string{}, probeRBDFeatures("3.2.0"))
+	assert.Equal(t, []string{"layering"}, probeRBDFeatures("invalid"))
+}
+
+func TestPublishNodeCapabilities(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node3", Annotations: map[string]string{Annotation: "invalid"}}},
+	)
+
+	caps := &NodeCapabilities{KernelVersion: "4.15.0", RBDFeatures: []string{"layering", "exclusive-lock"}, CephFSKernel: true}
+	assert.Nil(t, Publish(clientset, "node1", caps))
+
+	// only the node with valid capabilities is returned
+	nodes, err := GetNodeCapabilities(clientset)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]*NodeCapabilities{"node1": caps}, nodes)
+}
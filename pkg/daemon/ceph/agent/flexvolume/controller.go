@@ -19,6 +19,7 @@ package flexvolume
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -32,6 +33,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/cluster"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/metrics"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -51,8 +53,27 @@ const (
 	// PoolKey key for image name option.
 	ImageKey = "image"
 	// PoolKey key for data pool name option.
-	DataBlockPoolKey      = "dataBlockPool"
+	DataBlockPoolKey = "dataBlockPool"
+	// FsNameKey key for filesystem name option.
+	FsNameKey = "fsName"
+	// PathKey key for the path within the filesystem option.
+	PathKey = "path"
+	// MountUserKey key for the ceph user mapping the image option.
+	MountUserKey = "mountUser"
+	// MountSecretKey key for the name of the secret in the cluster namespace with the key of the mount user option.
+	MountSecretKey = "mountSecret"
+	// MounterKey key for the client mounting the filesystem option.
+	MounterKey = "mounter"
+	// MounterKernel mounts the filesystem with the kernel client.
+	MounterKernel = "kernel"
+	// MounterFuse mounts the filesystem with ceph-fuse in the agent. The mounts are lost when the agent restarts.
+	MounterFuse           = "fuse"
 	kubeletDefaultRootDir = "/var/lib/kubelet"
+	cephFuseTool          = "ceph-fuse"
+	fuseKeyringTemplate   = `
+[client.%s]
+key = %s
+`
 )
 
 var driverLogger = capnslog.NewPackageLogger("github.com/rook/rook", "flexdriver")
@@ -84,6 +105,15 @@ func NewController(context *clusterd.Context, volumeAttachment attachment.Attach
 
 // Attach attaches rook volume to the node
 func (c *Controller) Attach(attachOpts AttachOptions, devicePath *string) error {
+	trace := metrics.NewOperationTrace(logger, fmt.Sprintf("attach volume %s/%s", attachOpts.BlockPool, attachOpts.Image))
+	if reason, err := c.attach(attachOpts, devicePath, trace); err != nil {
+		return recordFailure(operationAttach, reason, err)
+	}
+	recordSuccess(operationAttach, trace.Done())
+	return nil
+}
+
+func (c *Controller) attach(attachOpts AttachOptions, devicePath *string, trace *metrics.OperationTrace) (string, error) {
 	namespace := os.Getenv(k8sutil.PodNamespaceEnvVar)
 	node := os.Getenv(k8sutil.NodeNameEnvVar)
 
@@ -94,7 +124,7 @@ func (c *Controller) Attach(attachOpts AttachOptions, devicePath *string) error
 	volumeattachObj, err := c.volumeAttachment.Get(namespace, crdName)
 	if err != nil {
 		if !errors.IsNotFound(err) {
-			return fmt.Errorf("failed to get volume CRD %s. %+v", crdName, err)
+			return reasonVolumeRecord, fmt.Errorf("failed to get volume CRD %s. %+v", crdName, err)
 		}
 		// No volumeattach CRD for this volume found. Create one
 		volumeattachObj = rookalpha.NewVolume(
@@ -111,10 +141,10 @@ func (c *Controller) Attach(attachOpts AttachOptions, devicePath *string) error
 		err = c.volumeAttachment.Create(volumeattachObj)
 		if err != nil {
 			if !errors.IsAlreadyExists(err) {
-				return fmt.Errorf("failed to create volume CRD %s. %+v", crdName, err)
+				return reasonVolumeRecord, fmt.Errorf("failed to create volume CRD %s. %+v", crdName, err)
 			}
 			// Some other attacher beat us in this race. Kubernetes will retry again.
-			return fmt.Errorf("failed to attach volume %s for pod %s/%s. Volume is already attached by a different pod",
+			return reasonAlreadyAttached, fmt.Errorf("failed to attach volume %s for pod %s/%s. Volume is already attached by a different pod",
 				crdName, attachOpts.PodNamespace, attachOpts.Pod)
 		}
 	} else {
@@ -142,7 +172,7 @@ func (c *Controller) Attach(attachOpts AttachOptions, devicePath *string) error
 				pod, err := c.context.Clientset.CoreV1().Pods(attachment.PodNamespace).Get(attachment.PodName, metav1.GetOptions{})
 				if err != nil || (attachment.PodNamespace == attachOpts.PodNamespace && attachment.PodName == attachOpts.Pod) {
 					if err != nil && !errors.IsNotFound(err) {
-						return fmt.Errorf("failed to get pod CRD %s/%s. %+v", attachment.PodNamespace, attachment.PodName, err)
+						return reasonVolumeRecord, fmt.Errorf("failed to get pod CRD %s/%s. %+v", attachment.PodNamespace, attachment.PodName, err)
 					}
 
 					logger.Infof("volume attachment record %s/%s is orphaned. Updating record with new attachment information for pod %s/%s", volumeattachObj.Namespace, volumeattachObj.Name, attachOpts.PodNamespace, attachOpts.Pod)
@@ -156,18 +186,18 @@ func (c *Controller) Attach(attachOpts AttachOptions, devicePath *string) error
 					attachment.ReadOnly = attachOpts.RW == ReadOnly
 					err = c.volumeAttachment.Update(volumeattachObj)
 					if err != nil {
-						return fmt.Errorf("failed to update volume CRD %s. %+v", crdName, err)
+						return reasonVolumeRecord, fmt.Errorf("failed to update volume CRD %s. %+v", crdName, err)
 					}
 				} else {
 					// Attachment is not orphaned. Original pod still exists. Dont attach.
-					return fmt.Errorf("failed to attach volume %s for pod %s/%s. Volume is already attached by pod %s/%s. Status %+v",
+					return reasonAlreadyAttached, fmt.Errorf("failed to attach volume %s for pod %s/%s. Volume is already attached by pod %s/%s. Status %+v",
 						crdName, attachOpts.PodNamespace, attachOpts.Pod, attachment.PodNamespace, attachment.PodName, pod.Status.Phase)
 				}
 			} else {
 				// No RW attachment found. Check if this is a RW attachment request.
 				// We only support RW once attachment. No mixing either with RO
 				if attachOpts.RW == "rw" && len(volumeattachObj.Attachments) > 0 {
-					return fmt.Errorf("failed to attach volume %s for pod %s/%s. Volume is already attached by one or more pods",
+					return reasonAlreadyAttached, fmt.Errorf("failed to attach volume %s for pod %s/%s. Volume is already attached by one or more pods",
 						crdName, attachOpts.PodNamespace, attachOpts.Pod)
 				}
 
@@ -183,16 +213,23 @@ func (c *Controller) Attach(attachOpts AttachOptions, devicePath *string) error
 				volumeattachObj.Attachments = append(volumeattachObj.Attachments, newAttach)
 				err = c.volumeAttachment.Update(volumeattachObj)
 				if err != nil {
-					return fmt.Errorf("failed to update volume CRD %s. %+v", crdName, err)
+					return reasonVolumeRecord, fmt.Errorf("failed to update volume CRD %s. %+v", crdName, err)
 				}
 			}
 		}
 	}
-	*devicePath, err = c.volumeManager.Attach(attachOpts.Image, attachOpts.BlockPool, attachOpts.MountUser, attachOpts.MountSecret, attachOpts.ClusterNamespace)
+	trace.Step("update volume record")
+
+	key, err := c.mountKey(attachOpts.ClusterNamespace, attachOpts.MountSecret)
+	if err != nil {
+		return reasonMap, fmt.Errorf("failed to get the key of user %s to attach volume %s/%s: %+v", attachOpts.MountUser, attachOpts.BlockPool, attachOpts.Image, err)
+	}
+	*devicePath, err = c.volumeManager.Attach(attachOpts.Image, attachOpts.BlockPool, attachOpts.MountUser, key, attachOpts.ClusterNamespace)
 	if err != nil {
-		return fmt.Errorf("failed to attach volume %s/%s: %+v", attachOpts.BlockPool, attachOpts.Image, err)
+		return reasonMap, fmt.Errorf("failed to attach volume %s/%s: %+v", attachOpts.BlockPool, attachOpts.Image, err)
 	}
-	return nil
+	trace.Step("map image")
+	return "", nil
 }
 
 // Detach detaches a rook volume to the node
@@ -206,27 +243,36 @@ func (c *Controller) DetachForce(detachOpts AttachOptions, _ *struct{} /* void r
 }
 
 func (c *Controller) doDetach(detachOpts AttachOptions, force bool) error {
+	trace := metrics.NewOperationTrace(logger, fmt.Sprintf("detach volume %s/%s", detachOpts.BlockPool, detachOpts.Image))
+	key, err := c.mountKey(detachOpts.ClusterNamespace, detachOpts.MountSecret)
+	if err != nil {
+		return recordFailure(operationDetach, reasonUnmap, fmt.Errorf("failed to get the key of user %s to detach volume %s/%s: %+v", detachOpts.MountUser, detachOpts.BlockPool, detachOpts.Image, err))
+	}
 	if err := c.volumeManager.Detach(
 		detachOpts.Image,
 		detachOpts.BlockPool,
 		detachOpts.MountUser,
-		detachOpts.MountSecret,
+		key,
 		detachOpts.ClusterNamespace,
 		force,
 	); err != nil {
-		return fmt.Errorf("failed to detach volume %s/%s: %+v", detachOpts.BlockPool, detachOpts.Image, err)
+		return recordFailure(operationDetach, reasonUnmap, fmt.Errorf("failed to detach volume %s/%s: %+v", detachOpts.BlockPool, detachOpts.Image, err))
 	}
+	trace.Step("unmap image")
 
 	namespace := os.Getenv(k8sutil.PodNamespaceEnvVar)
 	crdName := detachOpts.VolumeName
 	volumeAttach, err := c.volumeAttachment.Get(namespace, crdName)
 	if err != nil {
-		return fmt.Errorf("failed to get VolumeAttachment for %s in namespace %s. %+v", crdName, namespace, err)
+		return recordFailure(operationDetach, reasonVolumeRecord, fmt.Errorf("failed to get VolumeAttachment for %s in namespace %s. %+v", crdName, namespace, err))
 	}
 	if len(volumeAttach.Attachments) == 0 {
 		logger.Infof("Deleting Volume CRD %s/%s", namespace, crdName)
-		return c.volumeAttachment.Delete(namespace, crdName)
+		if err := c.volumeAttachment.Delete(namespace, crdName); err != nil {
+			return recordFailure(operationDetach, reasonVolumeRecord, err)
+		}
 	}
+	recordSuccess(operationDetach, trace.Done())
 	return nil
 }
 
@@ -346,6 +392,12 @@ func (c *Controller) GetAttachInfoFromMountDir(mountDir string, attachOptions *A
 	if attachOptions.StorageClass == "" {
 		attachOptions.StorageClass = pv.Spec.PersistentVolumeSource.FlexVolume.Options[StorageClassKey]
 	}
+	if attachOptions.MountUser == "" {
+		attachOptions.MountUser = pv.Spec.PersistentVolumeSource.FlexVolume.Options[MountUserKey]
+	}
+	if attachOptions.MountSecret == "" {
+		attachOptions.MountSecret = pv.Spec.PersistentVolumeSource.FlexVolume.Options[MountSecretKey]
+	}
 	if attachOptions.MountUser == "" {
 		attachOptions.MountUser = "admin"
 	}
@@ -397,17 +449,9 @@ func (c *Controller) GetClientAccessInfo(args []string, clientAccessInfo *Client
 	}
 
 	if secretKey != "" {
-		secret, err := c.context.Clientset.Core().Secrets(podNamespace).Get(secretKey, metav1.GetOptions{})
+		secretValue, err := c.mountKey(podNamespace, secretKey)
 		if err != nil {
-			return fmt.Errorf("unable to get mount secret %s from pod namespace %s. %+v", secretKey, podNamespace, err)
-		}
-		if len(secret.Data) == 0 || len(secret.Data) > 1 {
-			return fmt.Errorf("no data or more than one data (length %d) in mount secret %s in namespace %s", len(secret.Data), secretKey, podNamespace)
-		}
-		var secretValue string
-		for _, value := range secret.Data {
-			secretValue = string(value[:])
-			break
+			return err
 		}
 		clientAccessInfo.SecretKey = secretValue
 	} else if c.mountSecurityMode == agent.MountSecurityModeAny && secretKey == "" {
@@ -417,6 +461,27 @@ func (c *Controller) GetClientAccessInfo(args []string, clientAccessInfo *Client
 	return nil
 }
 
+// mountKey reads the key of the mount user from the secret with a single data item. No key is returned without secret,
+// the image is then mapped with the admin keyring of the cluster.
+func (c *Controller) mountKey(namespace, secretName string) (string, error) {
+	if secretName == "" {
+		return "", nil
+	}
+	secret, err := c.context.Clientset.Core().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to get mount secret %s from namespace %s. %+v", secretName, namespace, err)
+	}
+	if len(secret.Data) == 0 || len(secret.Data) > 1 {
+		return "", fmt.Errorf("no data or more than one data (length %d) in mount secret %s in namespace %s", len(secret.Data), secretName, namespace)
+	}
+	var secretValue string
+	for _, value := range secret.Data {
+		secretValue = string(value[:])
+		break
+	}
+	return secretValue, nil
+}
+
 // GetKernelVersion returns the kernel version of the current node.
 func (c *Controller) GetKernelVersion(_ *struct{} /* no inputs */, kernelVersion *string) error {
 	nodeName := os.Getenv(k8sutil.NodeNameEnvVar)
@@ -428,6 +493,44 @@ func (c *Controller) GetKernelVersion(_ *struct{} /* no inputs */, kernelVersion
 	return nil
 }
 
+// MountFuse mounts the filesystem with ceph-fuse on the mount dir of a pod, for the nodes whose kernel cannot mount it.
+// The ceph-fuse process runs in the agent, and the mount reaches the pod through the bidirectional propagation of the
+// pods dir of the kubelet. The mount is lost if the agent restarts.
+func (c *Controller) MountFuse(opts FuseMountOptions, _ *struct{} /* void reply */) error {
+	if err := os.MkdirAll(opts.MountDir, 0750); err != nil {
+		return fmt.Errorf("failed to create mount dir %s. %+v", opts.MountDir, err)
+	}
+
+	// ceph-fuse reads the key before it daemonizes, the keyring is not needed once the filesystem is mounted
+	keyring, err := ioutil.TempFile("", "ceph-fuse-keyring")
+	if err != nil {
+		return fmt.Errorf("failed to create the keyring to mount filesystem %s. %+v", opts.FsName, err)
+	}
+	defer os.Remove(keyring.Name())
+	_, err = keyring.WriteString(fmt.Sprintf(fuseKeyringTemplate, opts.UserName, opts.SecretKey))
+	keyring.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write the keyring to mount filesystem %s. %+v", opts.FsName, err)
+	}
+
+	logger.Infof("mounting filesystem %s:%s on %s with ceph-fuse", opts.FsName, opts.Path, opts.MountDir)
+	args := []string{
+		opts.MountDir,
+		"-m", strings.Join(opts.MonAddresses, ","),
+		"--id", opts.UserName,
+		"--keyring", keyring.Name(),
+		"--client_mountpoint", opts.Path,
+		"--client_mds_namespace", opts.FsName,
+	}
+	if opts.RW == ReadOnly {
+		args = append(args, "-o", "ro")
+	}
+	if err := c.context.Executor.ExecuteCommand(false, "", cephFuseTool, args...); err != nil {
+		return fmt.Errorf("failed to mount filesystem %s on %s with ceph-fuse. %+v", opts.FsName, opts.MountDir, err)
+	}
+	return nil
+}
+
 // getKubeletRootDir queries the kubelet configuration to find the kubelet root dir. Defaults to /var/lib/kubelet
 func (c *Controller) getKubeletRootDir() string {
 	// in k8s 1.8 it does not appear possible to change the default root dir
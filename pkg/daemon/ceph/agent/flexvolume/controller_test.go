@@ -33,6 +33,7 @@ import (
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/manager"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -92,6 +93,69 @@ func TestAttach(t *testing.T) {
 	assert.False(t, a.ReadOnly)
 }
 
+func TestAttachWithMountSecret(t *testing.T) {
+	clientset := test.New(3)
+
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-system")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+
+	os.Setenv(k8sutil.NodeNameEnvVar, "node1")
+	defer os.Unsetenv(k8sutil.NodeNameEnvVar)
+
+	context := &clusterd.Context{
+		Clientset:     clientset,
+		RookClientset: rookclient.NewSimpleClientset(),
+	}
+
+	opts := AttachOptions{
+		Image:            "image123",
+		BlockPool:        "testpool/tenant-a",
+		ClusterNamespace: "testCluster",
+		StorageClass:     "storageclass1",
+		MountDir:         "/test/pods/pod123/volumes/rook.io~rook/pvc-123",
+		MountUser:        "testpool.tenant-a",
+		MountSecret:      "rook-ceph-rados-namespace-tenant-a",
+		VolumeName:       "pvc-123",
+		Pod:              "myPod",
+		PodNamespace:     "Default",
+		RW:               "rw",
+	}
+	att, err := attachment.New(context)
+	assert.Nil(t, err)
+
+	var mapID, mapKey string
+	controller := &Controller{
+		context:          context,
+		volumeAttachment: att,
+		volumeManager: &manager.FakeVolumeManager{
+			FakeAttach: func(image, pool, id, key, clusterName string) (string, error) {
+				mapID = id
+				mapKey = key
+				return "/dev/rbd0", nil
+			},
+		},
+	}
+
+	// the secret of the mount user is missing from the cluster namespace
+	devicePath := ""
+	err = controller.Attach(opts, &devicePath)
+	assert.NotNil(t, err)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-rados-namespace-tenant-a", Namespace: "testCluster"},
+		Data:       map[string][]byte{"key": []byte("mykey")},
+	}
+	_, err = clientset.CoreV1().Secrets("testCluster").Create(secret)
+	assert.Nil(t, err)
+
+	// the image is mapped with the key of the mount user, not the name of its secret
+	err = controller.Attach(opts, &devicePath)
+	assert.Nil(t, err)
+	assert.Equal(t, "/dev/rbd0", devicePath)
+	assert.Equal(t, "testpool.tenant-a", mapID)
+	assert.Equal(t, "mykey", mapKey)
+}
+
 func TestAttachAlreadyExist(t *testing.T) {
 	clientset := test.New(3)
 
@@ -779,6 +843,48 @@ func TestGetAttachInfoFromMountDir(t *testing.T) {
 	assert.Equal(t, "testCluster", opts.ClusterNamespace)
 }
 
+func TestMountFuse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fuse")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	mountDir := dir + "/pods/123/volumes/rook.io~rook/pvc-123"
+
+	var fuseArgs []string
+	var keyring string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			assert.Equal(t, "ceph-fuse", command)
+			fuseArgs = args
+			content, err := ioutil.ReadFile(args[6])
+			assert.Nil(t, err)
+			keyring = string(content)
+			return nil
+		},
+	}
+	controller := &Controller{context: &clusterd.Context{Executor: executor}}
+
+	opts := FuseMountOptions{
+		FsName:       "myfs",
+		Path:         "/volumes/csi/pvc-123",
+		MountDir:     mountDir,
+		RW:           ReadOnly,
+		MonAddresses: []string{"10.0.0.1:6789", "10.0.0.2:6789"},
+		UserName:     "admin",
+		SecretKey:    "secret",
+	}
+	err = controller.MountFuse(opts, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{mountDir, "-m", "10.0.0.1:6789,10.0.0.2:6789", "--id", "admin", "--keyring", fuseArgs[6],
+		"--client_mountpoint", "/volumes/csi/pvc-123", "--client_mds_namespace", "myfs", "-o", "ro"}, fuseArgs)
+	assert.Contains(t, keyring, "[client.admin]\nkey = secret")
+
+	// the mount dir is created and the keyring is removed once mounted
+	_, err = os.Stat(mountDir)
+	assert.Nil(t, err)
+	_, err = os.Stat(fuseArgs[6])
+	assert.True(t, os.IsNotExist(err))
+}
+
 func TestParseClusterNamespace(t *testing.T) {
 	testParseClusterNamespace(t, "clusterNamespace")
 }
@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flexvolume
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rook/rook/pkg/util/metrics"
+)
+
+const (
+	operationAttach = "attach"
+	operationDetach = "detach"
+
+	reasonVolumeRecord    = "volume_record"
+	reasonAlreadyAttached = "already_attached"
+	reasonMap             = "map"
+	reasonUnmap           = "unmap"
+)
+
+var (
+	operationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "rook",
+			Subsystem: "flexvolume",
+			Name:      "operation_duration_seconds",
+			Help:      "Duration of the successful volume attach (map) and detach (unmap) operations of the rook agent",
+			Buckets:   metrics.DurationBuckets,
+		},
+		[]string{"operation"},
+	)
+	operationFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "rook",
+			Subsystem: "flexvolume",
+			Name:      "operation_failures_total",
+			Help:      "Number of failed volume operations of the rook agent by reason",
+		},
+		[]string{"operation", "reason"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(operationDuration, operationFailures)
+}
+
+func recordSuccess(operation string, duration time.Duration) {
+	operationDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// recordFailure counts the failed operation and returns the error for convenience
+func recordFailure(operation, reason string, err error) error {
+	operationFailures.WithLabelValues(operation, reason).Inc()
+	return err
+}
@@ -48,7 +48,8 @@ type AttachOptions struct {
 	StorageClass     string `json:"storageClass"`
 	MountDir         string `json:"mountDir"`
 	FsName           string `json:"fsName"`
-	Path             string `json:"path"` // Path within the CephFS to mount
+	Path             string `json:"path"`    // Path within the CephFS to mount
+	Mounter          string `json:"mounter"` // Client mounting the CephFS, kernel or fuse
 	MountUser        string `json:"mountUser"`
 	MountSecret      string `json:"mountSecret"`
 	RW               string `json:"kubernetes.io/readwrite"`
@@ -59,6 +60,17 @@ type AttachOptions struct {
 	PodNamespace     string `json:"kubernetes.io/pod.namespace"`
 }
 
+// FuseMountOptions are the settings to mount a filesystem with ceph-fuse in the agent
+type FuseMountOptions struct {
+	FsName       string   `json:"fsName"`
+	Path         string   `json:"path"`
+	MountDir     string   `json:"mountDir"`
+	RW           string   `json:"rw"`
+	MonAddresses []string `json:"monAddresses"`
+	UserName     string   `json:"userName"`
+	SecretKey    string   `json:"secretKey"`
+}
+
 type LogMessage struct {
 	Message string `json:"message"`
 	IsError bool   `json:"isError"`
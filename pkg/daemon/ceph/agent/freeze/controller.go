@@ -0,0 +1,212 @@
+/*
+Copyright 2017 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package freeze to freeze the filesystems of the volumes mounted on the node during the backup snapshots.
+package freeze
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/snapshot"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	fsfreezeTool      = "fsfreeze"
+	thawCheckInterval = time.Second
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "agent-freeze")
+
+// FreezeController freezes the filesystems of the mounts of the node while the operator takes the backup snapshots
+// of their volumes, and thaws them once the snapshots are taken
+type FreezeController struct {
+	context *clusterd.Context
+	node    string
+	// the mount dirs frozen by the agent, with the time they are thawed at the latest
+	frozen map[string]time.Time
+	lock   sync.Mutex
+}
+
+// NewFreezeController creates a new instance of a FreezeController
+func NewFreezeController(context *clusterd.Context) *FreezeController {
+	return &FreezeController{
+		context: context,
+		node:    os.Getenv(k8sutil.NodeNameEnvVar),
+		frozen:  map[string]time.Time{},
+	}
+}
+
+// StartWatch will start the watching of backup snapshot events by this controller
+func (c *FreezeController) StartWatch(namespace string, stopCh chan struct{}) error {
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+	}
+
+	logger.Infof("start watching backup snapshot resources")
+	watcher := opkit.NewWatcher(snapshot.BackupSnapshotResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephBackupSnapshot{}, stopCh)
+	go c.thawExpired(stopCh)
+	return nil
+}
+
+func (c *FreezeController) onAdd(obj interface{}) {
+	c.reconcile(obj.(*cephv1.CephBackupSnapshot).DeepCopy())
+}
+
+func (c *FreezeController) onUpdate(oldObj, newObj interface{}) {
+	c.reconcile(newObj.(*cephv1.CephBackupSnapshot).DeepCopy())
+}
+
+// reconcile freezes the mounts of the node while the snapshot is freezing, and thaws them in the other phases.
+// The mounts recorded as frozen are thawed even if the agent did not freeze them, in case the agent restarted, and
+// their deadline recorded in the status is restored so they are thawed in time while the snapshot is not completed.
+func (c *FreezeController) reconcile(s *cephv1.CephBackupSnapshot) {
+	c.restoreDeadlines(s)
+	freeze := s.Status.Phase == snapshot.PhaseFreezing
+	if s.Status.Phase == snapshot.PhaseSnapshotting {
+		return
+	}
+
+	// the deadlines of the mounts changed, zero when thawed
+	changed := map[string]time.Time{}
+	for _, v := range s.Status.Volumes {
+		for _, m := range v.Mounts {
+			if m.Node != c.node || m.Frozen == freeze {
+				continue
+			}
+			var deadline time.Time
+			if freeze {
+				var err error
+				deadline, err = c.freeze(m.MountDir, snapshot.FreezeTimeout(s))
+				if err != nil {
+					// the operator fails the snapshot when the mount is not frozen in time
+					logger.Errorf("failed to freeze %s for backup snapshot %s/%s. %+v", m.MountDir, s.Namespace, s.Name, err)
+					continue
+				}
+			} else {
+				c.thaw(m.MountDir)
+			}
+			changed[m.MountDir] = deadline
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	if _, err := snapshot.UpdateStatus(c.context, s, func(latest *cephv1.CephBackupSnapshot) {
+		for i, v := range latest.Status.Volumes {
+			for j, m := range v.Mounts {
+				deadline, ok := changed[m.MountDir]
+				if m.Node != c.node || !ok {
+					continue
+				}
+				latest.Status.Volumes[i].Mounts[j].Frozen = freeze
+				latest.Status.Volumes[i].Mounts[j].FrozenUntil = nil
+				if freeze {
+					until := metav1.NewTime(deadline)
+					latest.Status.Volumes[i].Mounts[j].FrozenUntil = &until
+				}
+			}
+		}
+	}); err != nil {
+		logger.Errorf("failed to update the mounts of backup snapshot %s/%s. %+v", s.Namespace, s.Name, err)
+	}
+}
+
+// restoreDeadlines tracks the mounts of the node recorded as frozen in the status, which the agent does not know
+// about if it restarted since it froze them
+func (c *FreezeController) restoreDeadlines(s *cephv1.CephBackupSnapshot) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, v := range s.Status.Volumes {
+		for _, m := range v.Mounts {
+			if m.Node != c.node || !m.Frozen || m.FrozenUntil == nil {
+				continue
+			}
+			if _, ok := c.frozen[m.MountDir]; !ok {
+				logger.Infof("%s was frozen before the agent started, thawing it at %s at the latest", m.MountDir, m.FrozenUntil.Time)
+				c.frozen[m.MountDir] = m.FrozenUntil.Time
+			}
+		}
+	}
+}
+
+// freeze suspends the writes to the filesystem of a mount dir, until it is thawed or the timeout expires. Returns
+// the time the mount is thawed at the latest.
+func (c *FreezeController) freeze(mountDir string, timeout time.Duration) (time.Time, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if deadline, ok := c.frozen[mountDir]; ok {
+		return deadline, nil
+	}
+
+	logger.Infof("freezing %s for at most %s", mountDir, timeout)
+	if err := c.context.Executor.ExecuteCommand(false, "", fsfreezeTool, "--freeze", mountDir); err != nil {
+		return time.Time{}, err
+	}
+	deadline := time.Now().Add(timeout)
+	c.frozen[mountDir] = deadline
+	return deadline, nil
+}
+
+// thaw resumes the writes to the filesystem of a mount dir
+func (c *FreezeController) thaw(mountDir string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	logger.Infof("thawing %s", mountDir)
+	if err := c.context.Executor.ExecuteCommand(false, "", fsfreezeTool, "--unfreeze", mountDir); err != nil {
+		// the filesystem is not frozen anymore if it was thawed after the timeout
+		logger.Warningf("failed to thaw %s. %+v", mountDir, err)
+	}
+	delete(c.frozen, mountDir)
+}
+
+// thawExpired thaws the filesystems frozen for longer than their timeout, so the applications are not blocked
+// if the operator does not complete the snapshot
+func (c *FreezeController) thawExpired(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("stopping the thaw of the expired freezes")
+			return
+		case <-time.After(thawCheckInterval):
+			now := time.Now()
+			var expired []string
+			c.lock.Lock()
+			for mountDir, deadline := range c.frozen {
+				if now.After(deadline) {
+					expired = append(expired, mountDir)
+				}
+			}
+			c.lock.Unlock()
+			for _, mountDir := range expired {
+				logger.Warningf("the freeze of %s expired", mountDir)
+				c.thaw(mountDir)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2017 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package freeze
+
+import (
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/snapshot"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFreezeMounts(t *testing.T) {
+	s := &cephv1.CephBackupSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "app"},
+		Status: cephv1.BackupSnapshotStatus{Phase: snapshot.PhaseFreezing, Volumes: []cephv1.BackupSnapshotVolume{
+			{PVC: "data", Mounts: []cephv1.BackupSnapshotMount{{Node: "node1", MountDir: "/pods/1/data"}, {Node: "node2", MountDir: "/pods/2/data"}}},
+		}},
+	}
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			commands = append(commands, command+" "+args[0]+" "+args[1])
+			return nil
+		},
+	}
+	context := &clusterd.Context{RookClientset: rookfake.NewSimpleClientset(s), Executor: executor}
+	c := NewFreezeController(context)
+	c.node = "node1"
+
+	// only the mount of the node is frozen
+	c.reconcile(s)
+	assert.Equal(t, []string{"fsfreeze --freeze /pods/1/data"}, commands)
+	s, err := context.RookClientset.CephV1().CephBackupSnapshots("app").Get("backup1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.True(t, s.Status.Volumes[0].Mounts[0].Frozen)
+	assert.NotNil(t, s.Status.Volumes[0].Mounts[0].FrozenUntil)
+	assert.False(t, s.Status.Volumes[0].Mounts[1].Frozen)
+	assert.Equal(t, 1, len(c.frozen))
+
+	// a restarted agent thaws the mount at the deadline recorded in the status
+	restarted := NewFreezeController(context)
+	restarted.node = "node1"
+	s.Status.Phase = snapshot.PhaseSnapshotting
+	restarted.reconcile(s)
+	assert.Equal(t, 1, len(commands))
+	assert.Equal(t, s.Status.Volumes[0].Mounts[0].FrozenUntil.Time, restarted.frozen["/pods/1/data"])
+
+	// nothing changes while the images are snapshotted
+	s.Status.Phase = snapshot.PhaseSnapshotting
+	c.reconcile(s)
+	assert.Equal(t, 1, len(commands))
+
+	// the mount is thawed when the snapshot is completed or failed
+	s.Status.Phase = snapshot.PhaseThawing
+	c.reconcile(s)
+	assert.Equal(t, []string{"fsfreeze --freeze /pods/1/data", "fsfreeze --unfreeze /pods/1/data"}, commands)
+	s, err = context.RookClientset.CephV1().CephBackupSnapshots("app").Get("backup1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.False(t, s.Status.Volumes[0].Mounts[0].Frozen)
+	assert.Nil(t, s.Status.Volumes[0].Mounts[0].FrozenUntil)
+	assert.Equal(t, 0, len(c.frozen))
+
+	// the freezes are thawed after their timeout
+	_, err = c.freeze("/pods/1/data", time.Millisecond)
+	assert.Nil(t, err)
+	stopCh := make(chan struct{})
+	go c.thawExpired(stopCh)
+	time.Sleep(2*thawCheckInterval + 100*time.Millisecond)
+	close(stopCh)
+	c.lock.Lock()
+	assert.Equal(t, 0, len(c.frozen))
+	c.lock.Unlock()
+}
@@ -69,6 +69,16 @@ func AuthGetOrCreateKey(context *clusterd.Context, clusterName, name string, cap
 	return parseAuthKey(buf)
 }
 
+// AuthUpdateCaps replaces the capabilities of an existing user.
+func AuthUpdateCaps(context *clusterd.Context, clusterName, name string, caps []string) error {
+	args := append([]string{"auth", "caps", name}, caps...)
+	_, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to update the caps of %s: %+v", name, err)
+	}
+	return nil
+}
+
 // AuthDelete will delete the given user.
 func AuthDelete(context *clusterd.Context, clusterName, name string) error {
 	args := []string{"auth", "del", name}
@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+const (
+	// the format of the expiration time of the blocklist entries
+	blocklistTimeFormat = "2006-01-02 15:04:05.000000"
+)
+
+// BlocklistEntry is a client address whose io is refused by the osds until the expiration time. The entries are
+// managed with the blacklist commands of ceph.
+type BlocklistEntry struct {
+	Addr  string `json:"addr"`
+	Until string `json:"until"`
+}
+
+// Expired returns whether the entry has expired, in which case ceph ignores it until it is purged from the osd map
+func (e BlocklistEntry) Expired(now time.Time) bool {
+	until, err := time.ParseInLocation(blocklistTimeFormat, e.Until, time.Local)
+	if err != nil {
+		return false
+	}
+	return until.Before(now)
+}
+
+// NodeBlocklistAddr returns the address blocking all the clients of the ip, whatever their port and nonce
+func NodeBlocklistAddr(ip string) string {
+	return net.JoinHostPort(ip, "0") + "/0"
+}
+
+// ListBlocklist lists the blocked client addresses
+func ListBlocklist(context *clusterd.Context, clusterName string) ([]BlocklistEntry, error) {
+	args := []string{"osd", "blacklist", "ls"}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the blocklist: %+v", err)
+	}
+
+	var entries []BlocklistEntry
+	if err := json.Unmarshal(buf, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal blocklist response: %+v", err)
+	}
+	return entries, nil
+}
+
+// AddToBlocklist blocks the io of the clients at the address for the duration, or for one hour by default
+func AddToBlocklist(context *clusterd.Context, clusterName, addr string, expire time.Duration) error {
+	args := []string{"osd", "blacklist", "add", addr}
+	if expire > 0 {
+		args = append(args, strconv.FormatInt(int64(expire.Seconds()), 10))
+	}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to add %s to the blocklist: %+v", addr, err)
+	}
+	return nil
+}
+
+// RemoveFromBlocklist allows the io of the clients at the address again
+func RemoveFromBlocklist(context *clusterd.Context, clusterName, addr string) error {
+	args := []string{"osd", "blacklist", "rm", addr}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to remove %s from the blocklist: %+v", addr, err)
+	}
+	return nil
+}
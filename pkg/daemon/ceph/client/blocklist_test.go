@@ -0,0 +1,51 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListBlocklist(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			assert.Equal(t, []string{"osd", "blacklist", "ls"}, args[:3])
+			return `[{"addr":"10.0.0.1:0/0","until":"2018-12-01 10:00:00.000000"},
+				{"addr":"10.0.0.2:0/3710147553","until":"2118-12-01 10:00:00.000000"}]`, nil
+		},
+	}
+
+	entries, err := ListBlocklist(&clusterd.Context{Executor: executor}, "mycluster")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "10.0.0.1:0/0", entries[0].Addr)
+
+	now := time.Now()
+	assert.True(t, entries[0].Expired(now))
+	assert.False(t, entries[1].Expired(now))
+	assert.False(t, BlocklistEntry{Until: "never"}.Expired(now))
+}
+
+func TestNodeBlocklistAddr(t *testing.T) {
+	assert.Equal(t, "10.0.0.1:0/0", NodeBlocklistAddr("10.0.0.1"))
+	assert.Equal(t, "[fd00::1]:0/0", NodeBlocklistAddr("fd00::1"))
+}
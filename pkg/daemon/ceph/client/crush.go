@@ -151,6 +151,18 @@ func SetCrushMap(context *clusterd.Context, clusterName, compiledMap string) (st
 	return string(buf), nil
 }
 
+// setCrushMapAtVersion sets the compiled crush map only if the version of the crush map of the cluster is still the
+// prior version, so the changes of the crush map made since it was read are not overwritten
+func setCrushMapAtVersion(context *clusterd.Context, clusterName, compiledMap string, priorVersion int) (string, error) {
+	args := []string{"osd", "setcrushmap", "-i", compiledMap, strconv.Itoa(priorVersion)}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return string(buf), fmt.Errorf("failed to set compiled crushmap at version %d. %v", priorVersion, err)
+	}
+
+	return string(buf), nil
+}
+
 func SetCrushTunables(context *clusterd.Context, clusterName, profile string) (string, error) {
 	args := []string{"osd", "crush", "tunables", profile}
 	buf, err := ExecuteCephCommandPlain(context, clusterName, args)
@@ -162,7 +174,7 @@ func SetCrushTunables(context *clusterd.Context, clusterName, profile string) (s
 }
 
 func CrushReweight(context *clusterd.Context, clusterName string, id int, weight float64) (string, error) {
-	args := []string{"osd", "crush", "reweight", fmt.Sprintf("osd.%d", id), fmt.Sprintf("%.1f", weight)}
+	args := []string{"osd", "crush", "reweight", fmt.Sprintf("osd.%d", id), fmt.Sprintf("%.4f", weight)}
 	buf, err := ExecuteCephCommand(context, clusterName, args)
 
 	return string(buf), err
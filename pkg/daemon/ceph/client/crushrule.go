@@ -0,0 +1,227 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+const (
+	endCrushMapComment = "# end crush map"
+	// the number of times a rule is set again when the crush map was changed while the rule was set
+	setCrushRuleAttempts = 5
+)
+
+var (
+	ruleStartRegex = regexp.MustCompile(`^rule (\S+) \{$`)
+	ruleIDRegex    = regexp.MustCompile(`^\s*(id|ruleset) (\d+)$`)
+)
+
+// SetCrushRule adds a rule with the given steps to the crush map. If a rule with the same name already exists, its
+// steps are replaced and it keeps its id so the pools using it are updated too. The steps are in the format of the
+// decompiled crush map, such as "take default class ssd" or "chooseleaf firstn 0 type host". The crush map is only set
+// if it was not changed since it was read, such as by an osd added to the crush map, otherwise the rule is set again
+// in the new crush map.
+func SetCrushRule(context *clusterd.Context, clusterName, name, ruleType string, steps []string) error {
+	osdDump, err := GetOSDDump(context, clusterName)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < setCrushRuleAttempts; i++ {
+		priorVersion := osdDump.CrushVersion
+		err = setCrushRuleAtVersion(context, clusterName, name, ruleType, steps, priorVersion)
+		if err == nil {
+			return nil
+		}
+
+		// the crush map is only set again if the failure is caused by a change of the crush map
+		var dumpErr error
+		osdDump, dumpErr = GetOSDDump(context, clusterName)
+		if dumpErr != nil || osdDump.CrushVersion == priorVersion {
+			return err
+		}
+		logger.Infof("the crush map changed from version %d to %d while setting rule %s. setting it again", priorVersion, osdDump.CrushVersion, name)
+	}
+	return fmt.Errorf("failed to set rule %s in a crush map changing concurrently. %+v", name, err)
+}
+
+func setCrushRuleAtVersion(context *clusterd.Context, clusterName, name, ruleType string, steps []string, priorVersion int) error {
+	crushMap, err := getDecompiledCrushMap(context, clusterName)
+	if err != nil {
+		return err
+	}
+
+	crushMap = setRuleInCrushMap(crushMap, name, ruleType, steps)
+
+	decompiledMap, err := ioutil.TempFile("", "")
+	if err != nil {
+		return fmt.Errorf("failed to open decompiled crush map temp file: %+v", err)
+	}
+	defer decompiledMap.Close()
+	defer os.Remove(decompiledMap.Name())
+	if _, err := decompiledMap.WriteString(crushMap); err != nil {
+		return fmt.Errorf("failed to write decompiled crush map to %s: %+v", decompiledMap.Name(), err)
+	}
+
+	compiledMap, err := ioutil.TempFile("", "")
+	if err != nil {
+		return fmt.Errorf("failed to open compiled crush map temp file: %+v", err)
+	}
+	defer compiledMap.Close()
+	defer os.Remove(compiledMap.Name())
+
+	args := []string{"-c", decompiledMap.Name(), "-o", compiledMap.Name()}
+	if output, err := context.Executor.ExecuteCommandWithOutput(false, "", CrushTool, args...); err != nil {
+		return fmt.Errorf("failed to compile crush map with rule %s: %+v. %s", name, err, output)
+	}
+
+	if output, err := setCrushMapAtVersion(context, clusterName, compiledMap.Name(), priorVersion); err != nil {
+		return fmt.Errorf("failed to set crush map with rule %s: %+v. %s", name, err, output)
+	}
+	return nil
+}
+
+// DeleteCrushRule removes a rule from the crush map. The rule cannot be removed while a pool is using it.
+func DeleteCrushRule(context *clusterd.Context, clusterName, name string) error {
+	args := []string{"osd", "crush", "rule", "rm", name}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to delete crush rule %s. %+v", name, err)
+	}
+	return nil
+}
+
+func getDecompiledCrushMap(context *clusterd.Context, clusterName string) (string, error) {
+	buf, err := ExecuteCephCommand(context, clusterName, []string{"osd", "getcrushmap"})
+	if err != nil {
+		return "", fmt.Errorf("failed to get crush map. %+v", err)
+	}
+
+	compiledMap, err := ioutil.TempFile("", "")
+	if err != nil {
+		return "", fmt.Errorf("failed to open compiled crush map temp file: %+v", err)
+	}
+	defer compiledMap.Close()
+	defer os.Remove(compiledMap.Name())
+	if _, err := compiledMap.Write(buf); err != nil {
+		return "", fmt.Errorf("failed to write compiled crush map to %s: %+v", compiledMap.Name(), err)
+	}
+
+	decompiledMap, err := ioutil.TempFile("", "")
+	if err != nil {
+		return "", fmt.Errorf("failed to open decompiled crush map temp file: %+v", err)
+	}
+	defer decompiledMap.Close()
+	defer os.Remove(decompiledMap.Name())
+
+	args := []string{"-d", compiledMap.Name(), "-o", decompiledMap.Name()}
+	if output, err := context.Executor.ExecuteCommandWithOutput(false, "", CrushTool, args...); err != nil {
+		return "", fmt.Errorf("failed to decompile crush map: %+v. %s", err, output)
+	}
+
+	crushMap, err := ioutil.ReadFile(decompiledMap.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read decompiled crush map: %+v", err)
+	}
+	return string(crushMap), nil
+}
+
+// setRuleInCrushMap replaces the rule with the given name in the decompiled crush map, or adds it with the next
+// available id at the end of the map
+func setRuleInCrushMap(crushMap, name, ruleType string, steps []string) string {
+	lines := strings.Split(crushMap, "\n")
+
+	// find the rule to replace and the highest rule id
+	start, end, id, maxID := -1, -1, -1, -1
+	inRule, inReplacedRule := false, false
+	for i, line := range lines {
+		if match := ruleStartRegex.FindStringSubmatch(line); match != nil {
+			inRule = true
+			inReplacedRule = match[1] == name
+			if inReplacedRule {
+				start = i
+			}
+			continue
+		}
+		if !inRule {
+			continue
+		}
+		if match := ruleIDRegex.FindStringSubmatch(line); match != nil {
+			ruleID, _ := strconv.Atoi(match[2])
+			if ruleID > maxID {
+				maxID = ruleID
+			}
+			if inReplacedRule {
+				id = ruleID
+			}
+		}
+		if line == "}" {
+			if inReplacedRule {
+				end = i
+			}
+			inRule, inReplacedRule = false, false
+		}
+	}
+	if id < 0 {
+		id = maxID + 1
+	}
+
+	// the erasure coded pools can have more chunks than the replicas of the replicated pools
+	maxSize := 10
+	if ruleType == "erasure" {
+		maxSize = 20
+	}
+	rule := []string{
+		fmt.Sprintf("rule %s {", name),
+		fmt.Sprintf("\tid %d", id),
+		fmt.Sprintf("\ttype %s", ruleType),
+		"\tmin_size 1",
+		fmt.Sprintf("\tmax_size %d", maxSize),
+	}
+	for _, step := range steps {
+		rule = append(rule, "\tstep "+step)
+	}
+	rule = append(rule, "}")
+
+	var result []string
+	if start >= 0 && end > start {
+		result = append(result, lines[:start]...)
+		result = append(result, rule...)
+		result = append(result, lines[end+1:]...)
+		return strings.Join(result, "\n")
+	}
+
+	// add the rule before the end of the map
+	insertAt := len(lines)
+	for i, line := range lines {
+		if line == endCrushMapComment {
+			insertAt = i
+			break
+		}
+	}
+	result = append(result, lines[:insertAt]...)
+	result = append(result, rule...)
+	result = append(result, "")
+	result = append(result, lines[insertAt:]...)
+	return strings.Join(result, "\n")
+}
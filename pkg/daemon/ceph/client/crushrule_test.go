@@ -0,0 +1,207 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/model"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDecompiledCrushMap = `# begin crush map
+tunable choose_total_tries 50
+
+# rules
+rule replicated_rule {
+	id 0
+	type replicated
+	min_size 1
+	max_size 10
+	step take default
+	step chooseleaf firstn 0 type host
+	step emit
+}
+rule fast {
+	id 3
+	type replicated
+	min_size 1
+	max_size 10
+	step take default class hdd
+	step chooseleaf firstn 0 type host
+	step emit
+}
+
+# end crush map
+`
+
+func TestSetRuleInCrushMap(t *testing.T) {
+	// a new rule is added before the end of the map with the next id
+	crushMap := setRuleInCrushMap(testDecompiledCrushMap, "ec", "erasure", []string{"take default", "chooseleaf indep 0 type rack", "emit"})
+	assert.Contains(t, crushMap, `rule ec {
+	id 4
+	type erasure
+	min_size 1
+	max_size 20
+	step take default
+	step chooseleaf indep 0 type rack
+	step emit
+}
+
+# end crush map`)
+	assert.Contains(t, crushMap, "rule replicated_rule {\n\tid 0\n")
+	assert.Contains(t, crushMap, "rule fast {\n\tid 3\n")
+
+	// an existing rule keeps its id when its steps are replaced
+	crushMap = setRuleInCrushMap(testDecompiledCrushMap, "fast", "replicated", []string{"take default class ssd", "chooseleaf firstn 0 type host", "emit"})
+	assert.Contains(t, crushMap, `rule fast {
+	id 3
+	type replicated
+	min_size 1
+	max_size 10
+	step take default class ssd
+	step chooseleaf firstn 0 type host
+	step emit
+}
+`)
+	assert.NotContains(t, crushMap, "class hdd")
+	assert.Contains(t, crushMap, "rule replicated_rule {\n\tid 0\n")
+	assert.Equal(t, len(testDecompiledCrushMap), len(crushMap))
+}
+
+func TestCreatePoolWithHybridStorage(t *testing.T) {
+	compiledCrushMap := ""
+	crushRuleSet := ""
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "osd" && args[1] == "dump" {
+			return `{"crush_version": 7}`, nil
+		}
+		if args[0] == "osd" && args[1] == "setcrushmap" {
+			assert.Equal(t, "7", args[4])
+		}
+		if args[0] == "osd" && args[1] == "pool" && args[2] == "set" && args[4] == "crush_rule" {
+			crushRuleSet = args[5]
+		}
+		return "", nil
+	}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		assert.Equal(t, CrushTool, command)
+		if args[0] == "-d" {
+			return "", ioutil.WriteFile(args[3], []byte(testDecompiledCrushMap), 0644)
+		}
+		if args[0] == "-c" {
+			buf, err := ioutil.ReadFile(args[1])
+			compiledCrushMap = string(buf)
+			return "", err
+		}
+		return "", nil
+	}
+
+	p := model.Pool{Name: "mypool", Type: model.Replicated, FailureDomain: "rack",
+		ReplicatedConfig: model.ReplicatedPoolConfig{Size: 3, PrimaryDeviceClass: "ssd", SecondaryDeviceClass: "hdd"}}
+	err := CreatePoolWithProfile(context, "myns", p, "myapp")
+	assert.Nil(t, err)
+	assert.Equal(t, "mypool_hybrid", crushRuleSet)
+	assert.Contains(t, compiledCrushMap, `rule mypool_hybrid {
+	id 4
+	type replicated
+	min_size 1
+	max_size 10
+	step take default class ssd
+	step chooseleaf firstn 1 type rack
+	step emit
+	step take default class hdd
+	step chooseleaf firstn -1 type rack
+	step emit
+}`)
+}
+
+func TestDeleteHybridPool(t *testing.T) {
+	var rulesDeleted []string
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[1] == "pool" && args[2] == "get" {
+			return `{"pool":"mypool","pool_id":1}{"pool":"mypool","crush_rule":"mypool_hybrid"}`, nil
+		}
+		if args[1] == "crush" && args[2] == "rule" && args[3] == "rm" {
+			rulesDeleted = append(rulesDeleted, args[4])
+		}
+		return "", nil
+	}
+
+	err := DeletePool(context, "myns", "mypool")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mypool", "mypool_hybrid"}, rulesDeleted)
+}
+
+func TestSetCrushRuleConcurrentChange(t *testing.T) {
+	crushVersion := 7
+	var priorVersions []string
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[0] == "osd" && args[1] == "dump" {
+			return fmt.Sprintf(`{"crush_version": %d}`, crushVersion), nil
+		}
+		if args[0] == "osd" && args[1] == "setcrushmap" {
+			priorVersions = append(priorVersions, args[4])
+			if len(priorVersions) == 1 {
+				// an osd was added to the crush map since it was read
+				crushVersion = 8
+				return "", fmt.Errorf("prior_version 7 != crush version 8")
+			}
+		}
+		return "", nil
+	}
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName, command string, args ...string) (string, error) {
+		if args[0] == "-d" {
+			return "", ioutil.WriteFile(args[3], []byte(testDecompiledCrushMap), 0644)
+		}
+		return "", nil
+	}
+
+	// the rule is set again in the new crush map
+	err := SetCrushRule(context, "myns", "myrule", "replicated", []string{"take default", "emit"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"7", "8"}, priorVersions)
+
+	// the rule is not set again when the crush map did not change
+	priorVersions = nil
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[0] == "osd" && args[1] == "dump" {
+			return `{"crush_version": 8}`, nil
+		}
+		if args[0] == "osd" && args[1] == "setcrushmap" {
+			priorVersions = append(priorVersions, args[4])
+			return "", fmt.Errorf("invalid crush map")
+		}
+		return "", nil
+	}
+	err = SetCrushRule(context, "myns", "myrule", "replicated", []string{"take default", "emit"})
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"8"}, priorVersions)
+}
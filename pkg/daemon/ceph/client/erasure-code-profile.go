@@ -65,18 +65,27 @@ func GetErasureCodeProfileDetails(context *clusterd.Context, clusterName, name s
 }
 
 func CreateErasureCodeProfile(context *clusterd.Context, clusterName string, config model.ErasureCodedPoolConfig, name, failureDomain, crushRoot string) error {
-	// look up the default profile so we can use the default plugin/technique
-	defaultProfile, err := GetErasureCodeProfileDetails(context, clusterName, "default")
-	if err != nil {
-		return fmt.Errorf("failed to look up default erasure code profile: %+v", err)
+	plugin, technique := config.Plugin, config.Technique
+	if plugin == "" {
+		// look up the default profile so we can use the default plugin/technique
+		defaultProfile, err := GetErasureCodeProfileDetails(context, clusterName, "default")
+		if err != nil {
+			return fmt.Errorf("failed to look up default erasure code profile: %+v", err)
+		}
+		plugin = defaultProfile.Plugin
+		if technique == "" {
+			technique = defaultProfile.Technique
+		}
 	}
 
 	// define the profile with a set of key/value pairs
 	profilePairs := []string{
 		fmt.Sprintf("k=%d", config.DataChunkCount),
 		fmt.Sprintf("m=%d", config.CodingChunkCount),
-		fmt.Sprintf("plugin=%s", defaultProfile.Plugin),
-		fmt.Sprintf("technique=%s", defaultProfile.Technique),
+		fmt.Sprintf("plugin=%s", plugin),
+	}
+	if technique != "" {
+		profilePairs = append(profilePairs, fmt.Sprintf("technique=%s", technique))
 	}
 	if failureDomain != "" {
 		profilePairs = append(profilePairs, fmt.Sprintf("crush-failure-domain=%s", failureDomain))
@@ -87,7 +96,7 @@ func CreateErasureCodeProfile(context *clusterd.Context, clusterName string, con
 
 	args := []string{"osd", "erasure-code-profile", "set", name}
 	args = append(args, profilePairs...)
-	_, err = ExecuteCephCommand(context, clusterName, args)
+	_, err := ExecuteCephCommand(context, clusterName, args)
 	if err != nil {
 		return fmt.Errorf("failed to set ec-profile. %+v", err)
 	}
@@ -70,3 +70,22 @@ func testCreateProfile(t *testing.T, failureDomain, crushRoot string) {
 	err := CreateErasureCodeProfile(context, "myns", cfg, "myapp", failureDomain, crushRoot)
 	assert.Nil(t, err)
 }
+
+func TestCreateProfileWithPlugin(t *testing.T) {
+	cfg := model.ErasureCodedPoolConfig{DataChunkCount: 4, CodingChunkCount: 2, Plugin: "isa", Technique: "cauchy"}
+
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[1] == "erasure-code-profile" && args[2] == "set" {
+			assert.Equal(t, []string{"k=4", "m=2", "plugin=isa", "technique=cauchy", "crush-failure-domain=rack"}, args[4:9])
+			return "", nil
+		}
+		// the default profile is not needed when the plugin is specified
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+
+	err := CreateErasureCodeProfile(context, "myns", cfg, "myapp", "rack", "")
+	assert.Nil(t, err)
+}
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"syscall"
+	"time"
 
 	"strconv"
 
@@ -30,8 +31,24 @@ import (
 
 const (
 	ImageMinSize = uint64(1048576) // 1 MB
+
+	// trashTimeFormat is the format of the expiration time of the images in the trash
+	trashTimeFormat = "2006-01-02 15:04:05"
 )
 
+// ImageOptions are the optional settings applied to a block image when it is created
+type ImageOptions struct {
+	// DataPoolName is the pool the image data is stored in. If empty, the data is stored in the image pool.
+	DataPoolName string
+	// Features are the rbd image features to enable, e.g. layering, exclusive-lock, object-map or fast-diff.
+	// If empty, the default features of the cluster are used.
+	Features []string
+	// StripeUnit is the size in bytes of the stripe unit. Striping is only enabled if both the unit and the count are set.
+	StripeUnit uint64
+	// StripeCount is the number of objects to stripe over before looping back to the first object
+	StripeCount uint64
+}
+
 type CephBlockImage struct {
 	Name     string `json:"image"`
 	Size     uint64 `json:"size"`
@@ -85,6 +102,11 @@ func getImageInfo(context *clusterd.Context, clusterName, name, poolName string)
 // CreateImage creates a block storage image.
 // If dataPoolName is not empty, the image will use poolName as the metadata pool and the dataPoolname for data.
 func CreateImage(context *clusterd.Context, clusterName, name, poolName, dataPoolName string, size uint64) (*CephBlockImage, error) {
+	return CreateImageWithOptions(context, clusterName, name, poolName, size, ImageOptions{DataPoolName: dataPoolName})
+}
+
+// CreateImageWithOptions creates a block storage image with the given features and striping.
+func CreateImageWithOptions(context *clusterd.Context, clusterName, name, poolName string, size uint64, opts ImageOptions) (*CephBlockImage, error) {
 	if size > 0 && size < ImageMinSize {
 		// rbd tool uses MB as the smallest unit for size input.  0 is OK but anything else smaller
 		// than 1 MB should just be rounded up to 1 MB.
@@ -100,8 +122,14 @@ func CreateImage(context *clusterd.Context, clusterName, name, poolName, dataPoo
 
 	args := []string{"create", imageSpec, "--size", strconv.Itoa(sizeMB)}
 
-	if dataPoolName != "" {
-		args = append(args, fmt.Sprintf("--data-pool=%s", dataPoolName))
+	if opts.DataPoolName != "" {
+		args = append(args, fmt.Sprintf("--data-pool=%s", opts.DataPoolName))
+	}
+	for _, feature := range opts.Features {
+		args = append(args, "--image-feature", feature)
+	}
+	if opts.StripeUnit > 0 && opts.StripeCount > 0 {
+		args = append(args, "--stripe-unit", strconv.FormatUint(opts.StripeUnit, 10), "--stripe-count", strconv.FormatUint(opts.StripeCount, 10))
 	}
 
 	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
@@ -125,6 +153,30 @@ func CreateImage(context *clusterd.Context, clusterName, name, poolName, dataPoo
 	return image, nil
 }
 
+// ExpandImage grows a block storage image to the given size and returns the resized image.
+// The size is rounded up to the next MB boundary in the same way as when the image is created.
+func ExpandImage(context *clusterd.Context, clusterName, name, poolName string, size uint64) (*CephBlockImage, error) {
+	if size < ImageMinSize {
+		size = ImageMinSize
+	}
+	sizeMB := int((size + ImageMinSize - 1) / ImageMinSize)
+
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"resize", imageSpec, "--size", strconv.Itoa(sizeMB)}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resize image %s in pool %s to size %d: %+v. output: %s",
+			name, poolName, size, err, string(buf))
+	}
+
+	image, err := getImageInfo(context, clusterName, name, poolName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image %s info after resizing it: %v", name, err)
+	}
+
+	return image, nil
+}
+
 func DeleteImage(context *clusterd.Context, clusterName, name, poolName string) error {
 	imageSpec := getImageSpec(name, poolName)
 	args := []string{"rm", imageSpec}
@@ -137,6 +189,55 @@ func DeleteImage(context *clusterd.Context, clusterName, name, poolName string)
 	return nil
 }
 
+// MoveImageToTrash moves a block storage image to the trash of its pool. The image can be restored with
+// `rbd trash restore` until it expires and is purged from the trash.
+func MoveImageToTrash(context *clusterd.Context, clusterName, name, poolName string, expiresAt time.Time) error {
+	imageSpec := getImageSpec(name, poolName)
+	args := []string{"trash", "mv", imageSpec, "--expires-at", expiresAt.UTC().Format(trashTimeFormat)}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to move image %s in pool %s to the trash: %+v. output: %s",
+			name, poolName, err, string(buf))
+	}
+
+	return nil
+}
+
+// PurgeTrash deletes the expired images from the trash of the pool
+func PurgeTrash(context *clusterd.Context, clusterName, poolName string) error {
+	args := []string{"trash", "purge", poolName}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to purge the trash of pool %s: %+v. output: %s", poolName, err, string(buf))
+	}
+
+	return nil
+}
+
+// CreateImageSnapshot takes a snapshot of a block storage image
+func CreateImageSnapshot(context *clusterd.Context, clusterName, name, poolName, snapName string) error {
+	snapSpec := getSnapSpec(name, poolName, snapName)
+	args := []string{"snap", "create", snapSpec}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot %s: %+v. output: %s", snapSpec, err, string(buf))
+	}
+
+	return nil
+}
+
+// DeleteImageSnapshot deletes a snapshot of a block storage image
+func DeleteImageSnapshot(context *clusterd.Context, clusterName, name, poolName, snapName string) error {
+	snapSpec := getSnapSpec(name, poolName, snapName)
+	args := []string{"snap", "rm", snapSpec}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot %s: %+v. output: %s", snapSpec, err, string(buf))
+	}
+
+	return nil
+}
+
 // MapImage maps an RBD image using admin cephfx and returns the device path
 func MapImage(context *clusterd.Context, imageName, poolName, id, keyring, clusterName, monitors string) error {
 	imageSpec := getImageSpec(imageName, poolName)
@@ -186,3 +287,7 @@ func UnMapImage(context *clusterd.Context, imageName, poolName, id, keyring, clu
 func getImageSpec(name, poolName string) string {
 	return fmt.Sprintf("%s/%s", poolName, name)
 }
+
+func getSnapSpec(name, poolName, snapName string) string {
+	return fmt.Sprintf("%s/%s@%s", poolName, name, snapName)
+}
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"strings"
+	"time"
 
 	"github.com/rook/rook/pkg/clusterd"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -142,6 +143,109 @@ func TestCreateImage(t *testing.T) {
 
 }
 
+func TestCreateImageWithOptions(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+
+	var createArgs []string
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, args ...string) (string, error) {
+		switch {
+		case command == "rbd" && args[0] == "create":
+			createArgs = args
+			return "", nil
+		case command == "rbd" && args[0] == "info":
+			return `{"name":"image1","size":1048576,"format":2,"features":["layering","exclusive-lock"]}`, nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+
+	opts := ImageOptions{
+		DataPoolName: "data1",
+		Features:     []string{"layering", "exclusive-lock"},
+		StripeUnit:   65536,
+		StripeCount:  16,
+	}
+	image, err := CreateImageWithOptions(context, "foocluster", "image1", "pool1", uint64(sizeMB), opts)
+	assert.Nil(t, err)
+	assert.Equal(t, "image1", image.Name)
+	assert.Equal(t, []string{"create", "pool1/image1", "--size", "1", "--data-pool=data1",
+		"--image-feature", "layering", "--image-feature", "exclusive-lock",
+		"--stripe-unit", "65536", "--stripe-count", "16"}, createArgs[:13])
+
+	// striping is ignored unless both the unit and count are set
+	opts = ImageOptions{StripeUnit: 65536}
+	_, err = CreateImageWithOptions(context, "foocluster", "image1", "pool1", uint64(sizeMB), opts)
+	assert.Nil(t, err)
+	for _, arg := range createArgs {
+		assert.NotEqual(t, "--stripe-unit", arg)
+	}
+}
+
+func TestMoveImageToTrash(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+
+	moved := false
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, args ...string) (string, error) {
+		if command == "rbd" && args[0] == "trash" && args[1] == "mv" {
+			moved = true
+			assert.Equal(t, "pool1/image1", args[2])
+			assert.Equal(t, "--expires-at", args[3])
+			assert.Equal(t, "2018-11-20 10:30:00", args[4])
+			return "", nil
+		}
+		if command == "rbd" && args[0] == "trash" && args[1] == "purge" {
+			assert.Equal(t, "pool1", args[2])
+			return "mocked purge error", fmt.Errorf("mocked error")
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+
+	expiresAt := time.Date(2018, 11, 20, 10, 30, 0, 0, time.UTC)
+	err := MoveImageToTrash(context, "foocluster", "image1", "pool1", expiresAt)
+	assert.Nil(t, err)
+	assert.True(t, moved)
+
+	err = PurgeTrash(context, "foocluster", "pool1")
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "mocked purge error"))
+}
+
+func TestExpandImage(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+
+	resizeCalled := false
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, args ...string) (string, error) {
+		switch {
+		case command == "rbd" && args[0] == "resize":
+			resizeCalled = true
+			assert.Equal(t, "pool1/image1", args[1])
+			assert.Equal(t, "3", args[3])
+			return "", nil
+		case command == "rbd" && args[0] == "info":
+			return `{"name":"image1","size":3145728,"objects":3,"order":20,"object_size":1048576,"block_name_prefix":"pool1_data.229226b8b4567",` +
+				`"format":2,"features":["layering"],"op_features":[],"flags":[],"create_timestamp":"Fri Oct  5 19:46:20 2018"}`, nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+
+	// (2 MB + 1 byte) --> 3MB
+	image, err := ExpandImage(context, "foocluster", "image1", "pool1", uint64(sizeMB*2+1))
+	assert.Nil(t, err)
+	assert.True(t, resizeCalled)
+	assert.Equal(t, "image1", image.Name)
+	assert.Equal(t, uint64(sizeMB*3), image.Size)
+
+	// the resize failure output is returned to the caller
+	executor.MockExecuteCommandWithOutput = func(debug bool, actionName string, command string, args ...string) (string, error) {
+		return "mocked detailed rbd error output stream", fmt.Errorf("some mocked error")
+	}
+	_, err = ExpandImage(context, "foocluster", "image1", "pool1", uint64(sizeMB))
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "mocked detailed rbd error output stream"))
+}
+
 func TestListImageLogLevelInfo(t *testing.T) {
 	executor := &exectest.MockExecutor{}
 	context := &clusterd.Context{Executor: executor}
@@ -242,3 +346,18 @@ func TestListImageLogLevelDebug(t *testing.T) {
 	assert.True(t, listCalled)
 	listCalled = false
 }
+
+func TestImageSnapshot(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			commands = append(commands, args[:3])
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.Nil(t, CreateImageSnapshot(context, "mycluster", "image1", "pool1", "backup"))
+	assert.Nil(t, DeleteImageSnapshot(context, "mycluster", "image1", "pool1", "backup"))
+	assert.Equal(t, [][]string{{"snap", "create", "pool1/image1@backup"}, {"snap", "rm", "pool1/image1@backup"}}, commands)
+}
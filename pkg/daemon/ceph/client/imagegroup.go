@@ -0,0 +1,172 @@
+/*
+Copyright 2016 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+// GroupImage is an image of an rbd group
+type GroupImage struct {
+	Image string `json:"image"`
+	Pool  string `json:"pool"`
+}
+
+// ImageSnapshot is a snapshot of an image, in the user namespace or in the namespace of the snapshots of a group
+type ImageSnapshot struct {
+	ID        uint64 `json:"id"`
+	Name      string `json:"name"`
+	Namespace struct {
+		Type          string `json:"type"`
+		Pool          string `json:"pool"`
+		Group         string `json:"group"`
+		GroupSnapshot string `json:"group snap"`
+	} `json:"namespace"`
+}
+
+type imageStatus struct {
+	Watchers []struct {
+		Address string `json:"address"`
+	} `json:"watchers"`
+}
+
+// ListImageGroups returns the names of the rbd groups of a pool
+func ListImageGroups(context *clusterd.Context, clusterName, poolName string) ([]string, error) {
+	args := []string{"group", "ls", poolName}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the groups of pool %s: %+v. output: %s", poolName, err, string(buf))
+	}
+
+	var groups []string
+	if err := json.Unmarshal(buf, &groups); err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+	return groups, nil
+}
+
+// CreateImageGroup creates an rbd group, to snapshot its images together
+func CreateImageGroup(context *clusterd.Context, clusterName, poolName, groupName string) error {
+	return runRBDCommand(context, clusterName, "group", "create", getImageSpec(groupName, poolName))
+}
+
+// DeleteImageGroup deletes an rbd group and its snapshots. The images are not deleted.
+func DeleteImageGroup(context *clusterd.Context, clusterName, poolName, groupName string) error {
+	return runRBDCommand(context, clusterName, "group", "rm", getImageSpec(groupName, poolName))
+}
+
+// ListGroupImages returns the images of an rbd group
+func ListGroupImages(context *clusterd.Context, clusterName, poolName, groupName string) ([]GroupImage, error) {
+	groupSpec := getImageSpec(groupName, poolName)
+	args := []string{"group", "image", "list", groupSpec}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the images of group %s: %+v. output: %s", groupSpec, err, string(buf))
+	}
+
+	var images []GroupImage
+	if err := json.Unmarshal(buf, &images); err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+	return images, nil
+}
+
+// AddImageToGroup adds an image to an rbd group. The image can be in another pool than the group.
+func AddImageToGroup(context *clusterd.Context, clusterName, poolName, groupName, imagePoolName, imageName string) error {
+	return runRBDCommand(context, clusterName, "group", "image", "add", getImageSpec(groupName, poolName), getImageSpec(imageName, imagePoolName))
+}
+
+// RemoveImageFromGroup removes an image from an rbd group
+func RemoveImageFromGroup(context *clusterd.Context, clusterName, poolName, groupName, imagePoolName, imageName string) error {
+	return runRBDCommand(context, clusterName, "group", "image", "rm", getImageSpec(groupName, poolName), getImageSpec(imageName, imagePoolName))
+}
+
+// CreateGroupSnapshot snapshots all the images of an rbd group atomically
+func CreateGroupSnapshot(context *clusterd.Context, clusterName, poolName, groupName, snapName string) error {
+	return runRBDCommand(context, clusterName, "group", "snap", "create", getSnapSpec(groupName, poolName, snapName))
+}
+
+// DeleteGroupSnapshot deletes a snapshot of an rbd group and the snapshots of its images
+func DeleteGroupSnapshot(context *clusterd.Context, clusterName, poolName, groupName, snapName string) error {
+	return runRBDCommand(context, clusterName, "group", "snap", "rm", getSnapSpec(groupName, poolName, snapName))
+}
+
+// RollbackGroupSnapshot rolls back all the images of an rbd group to a snapshot of the group
+func RollbackGroupSnapshot(context *clusterd.Context, clusterName, poolName, groupName, snapName string) error {
+	return runRBDCommand(context, clusterName, "group", "snap", "rollback", getSnapSpec(groupName, poolName, snapName))
+}
+
+// GetGroupSnapshotID returns the id of the snapshot of an image taken by a snapshot of its group
+func GetGroupSnapshotID(context *clusterd.Context, clusterName, poolName, imageName, groupName, groupSnapName string) (uint64, error) {
+	imageSpec := getImageSpec(imageName, poolName)
+	args := []string{"snap", "ls", "--all", imageSpec}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list the snapshots of image %s: %+v. output: %s", imageSpec, err, string(buf))
+	}
+
+	var snaps []ImageSnapshot
+	if err := json.Unmarshal(buf, &snaps); err != nil {
+		return 0, fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+	for _, s := range snaps {
+		if s.Namespace.Type == "group" && s.Namespace.Group == groupName && s.Namespace.GroupSnapshot == groupSnapName {
+			return s.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("no snapshot of group %s@%s found for image %s", groupName, groupSnapName, imageSpec)
+}
+
+// CloneImageFromSnapshotID clones an image from one of its snapshots by id, such as the snapshots of a group which
+// cannot be referenced by name. The clone format 2 does not need the snapshot to be protected.
+func CloneImageFromSnapshotID(context *clusterd.Context, clusterName, poolName, imageName string, snapID uint64, clonePoolName, cloneName string) error {
+	return runRBDCommand(context, clusterName, "clone", "--snap-id", strconv.FormatUint(snapID, 10), "--rbd-default-clone-format", "2",
+		getImageSpec(imageName, poolName), getImageSpec(cloneName, clonePoolName))
+}
+
+// GetImageWatchers returns the addresses of the clients watching an image, such as the nodes mapping the image
+func GetImageWatchers(context *clusterd.Context, clusterName, poolName, imageName string) ([]string, error) {
+	imageSpec := getImageSpec(imageName, poolName)
+	args := []string{"status", imageSpec}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the status of image %s: %+v. output: %s", imageSpec, err, string(buf))
+	}
+
+	var status imageStatus
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, fmt.Errorf("unmarshal failed: %+v. raw buffer response: %s", err, string(buf))
+	}
+	watchers := []string{}
+	for _, w := range status.Watchers {
+		watchers = append(watchers, w.Address)
+	}
+	return watchers, nil
+}
+
+func runRBDCommand(context *clusterd.Context, clusterName string, args ...string) error {
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to run rbd %s: %+v. output: %s", strings.Join(args, " "), err, string(buf))
+	}
+	return nil
+}
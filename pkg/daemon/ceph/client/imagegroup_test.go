@@ -0,0 +1,56 @@
+/*
+Copyright 2016 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupSnapshotID(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			assert.Equal(t, []string{"snap", "ls", "--all", "pool1/image1"}, args[:4])
+			return `[{"id": 3, "name": "backup", "namespace": {"type": "user"}},
+				{"id": 4, "name": ".group.2_10ab_11cd", "namespace": {"type": "group", "pool": "pool1", "group": "app.db", "group snap": "snap1"}}]`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	id, err := GetGroupSnapshotID(context, "mycluster", "pool1", "image1", "app.db", "snap1")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4), id)
+
+	_, err = GetGroupSnapshotID(context, "mycluster", "pool1", "image1", "app.db", "snap2")
+	assert.NotNil(t, err)
+}
+
+func TestImageWatchers(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			return `{"watchers": [{"address": "10.0.0.1:0/1234", "client": 4567, "cookie": 1}]}`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	watchers, err := GetImageWatchers(context, "mycluster", "pool1", "image1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.1:0/1234"}, watchers)
+}
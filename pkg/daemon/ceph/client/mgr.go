@@ -1,11 +1,13 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
+	"k8s.io/kubernetes/pkg/util/version"
 )
 
 // MgrEnableModule enables a mgr module
@@ -61,6 +63,33 @@ func MgrSetConfig(context *clusterd.Context, clusterName, mgrName, cephVersionNa
 	return hasChanged, nil
 }
 
+// MgrVersions returns the ceph versions of the running mgr daemons, parsed from `ceph versions` whose keys are
+// like "ceph version 14.2.5 (<sha1>) nautilus (stable)". No version is returned while no mgr is running.
+func MgrVersions(context *clusterd.Context, clusterName string) ([]*version.Version, error) {
+	buf, err := ExecuteCephCommand(context, clusterName, []string{"versions"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the ceph versions. %+v", err)
+	}
+	var daemons map[string]map[string]int
+	if err := json.Unmarshal(buf, &daemons); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the ceph versions. %+v", err)
+	}
+
+	var versions []*version.Version
+	for desc := range daemons["mgr"] {
+		fields := strings.Fields(desc)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("unexpected mgr version %q", desc)
+		}
+		v, err := version.ParseGeneric(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the mgr version %q. %+v", desc, err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
 func enableModule(context *clusterd.Context, clusterName, name string, force bool, action string) error {
 	args := []string{"mgr", "module", action, name}
 	if force {
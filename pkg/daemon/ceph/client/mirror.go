@@ -0,0 +1,238 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+const (
+	// MirroringModeImage enables the mirroring of each image explicitly
+	MirroringModeImage = "image"
+	// MirroringModePool mirrors all the images of the pool with journaling enabled
+	MirroringModePool = "pool"
+	// MirroringModeDisabled is the mode of the pools that are not mirrored
+	MirroringModeDisabled = "disabled"
+)
+
+// MirrorPoolInfo is the mirroring mode and the peers of a pool
+type MirrorPoolInfo struct {
+	Mode  string       `json:"mode"`
+	Peers []MirrorPeer `json:"peers"`
+}
+
+// MirrorPeer is a peer cluster of a mirrored pool or filesystem
+type MirrorPeer struct {
+	UUID        string `json:"uuid"`
+	SiteName    string `json:"site_name"`
+	ClusterName string `json:"cluster_name"`
+	ClientName  string `json:"client_name"`
+}
+
+// MirrorPoolStatus is the mirroring status of the images of a pool
+type MirrorPoolStatus struct {
+	Summary MirrorPoolSummary   `json:"summary"`
+	Images  []MirrorImageStatus `json:"images"`
+}
+
+// MirrorPoolSummary is the health of the mirroring of a pool and its number of images by state
+type MirrorPoolSummary struct {
+	Health       string         `json:"health"`
+	DaemonHealth string         `json:"daemon_health"`
+	ImageHealth  string         `json:"image_health"`
+	States       map[string]int `json:"states"`
+}
+
+// MirrorImageStatus is the mirroring status of an image
+type MirrorImageStatus struct {
+	Name        string `json:"name"`
+	GlobalID    string `json:"global_id"`
+	State       string `json:"state"`
+	Description string `json:"description"`
+	LastUpdate  string `json:"last_update"`
+}
+
+// FilesystemMirrorDaemonStatus is the status of a cephfs-mirror daemon and of the filesystems it mirrors
+type FilesystemMirrorDaemonStatus struct {
+	DaemonID    int                      `json:"daemon_id"`
+	Filesystems []FilesystemMirrorStatus `json:"filesystems"`
+}
+
+// FilesystemMirrorStatus is the snapshot mirroring of a filesystem by a cephfs-mirror daemon
+type FilesystemMirrorStatus struct {
+	FilesystemID   int                          `json:"filesystem_id"`
+	Name           string                       `json:"name"`
+	DirectoryCount int                          `json:"directory_count"`
+	Peers          []FilesystemMirrorPeerStatus `json:"peers"`
+}
+
+// FilesystemMirrorPeerStatus is a peer cluster of a mirrored filesystem with its synchronization failures
+type FilesystemMirrorPeerStatus struct {
+	UUID   string `json:"uuid"`
+	Remote struct {
+		ClientName  string `json:"client_name"`
+		ClusterName string `json:"cluster_name"`
+		FSName      string `json:"fs_name"`
+	} `json:"remote"`
+	Stats struct {
+		FailureCount  int `json:"failure_count"`
+		RecoveryCount int `json:"recovery_count"`
+	} `json:"stats"`
+}
+
+// GetPoolMirroringInfo returns the mirroring mode and the peers of a pool
+func GetPoolMirroringInfo(context *clusterd.Context, clusterName, poolName string) (*MirrorPoolInfo, error) {
+	args := []string{"mirror", "pool", "info", poolName}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the mirroring info of pool %s: %+v. output: %s", poolName, err, string(buf))
+	}
+
+	var info MirrorPoolInfo
+	if err := json.Unmarshal(buf, &info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the mirroring info of pool %s: %+v", poolName, err)
+	}
+	return &info, nil
+}
+
+// GetPoolMirroringStatus returns the mirroring status of the pool and of each of its mirrored images
+func GetPoolMirroringStatus(context *clusterd.Context, clusterName, poolName string) (*MirrorPoolStatus, error) {
+	args := []string{"mirror", "pool", "status", poolName, "--verbose"}
+	buf, err := ExecuteRBDCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the mirroring status of pool %s: %+v. output: %s", poolName, err, string(buf))
+	}
+
+	var status MirrorPoolStatus
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the mirroring status of pool %s: %+v", poolName, err)
+	}
+	return &status, nil
+}
+
+// GetFilesystemMirrorDaemonStatus returns the status of the cephfs-mirror daemons. Only supported since pacific.
+func GetFilesystemMirrorDaemonStatus(context *clusterd.Context, clusterName string) ([]FilesystemMirrorDaemonStatus, error) {
+	args := []string{"fs", "snapshot", "mirror", "daemon", "status"}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the status of the filesystem mirror daemons: %+v. output: %s", err, string(buf))
+	}
+
+	var status []FilesystemMirrorDaemonStatus
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the status of the filesystem mirror daemons: %+v", err)
+	}
+	return status, nil
+}
+
+// EnablePoolMirroring enables the mirroring of the images of a pool in the image or pool mode
+func EnablePoolMirroring(context *clusterd.Context, clusterName, poolName, mode string) error {
+	args := []string{"mirror", "pool", "enable", poolName, mode}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to enable the %s mirroring of pool %s: %+v. output: %s", mode, poolName, err, string(buf))
+	}
+	return nil
+}
+
+// CreatePoolPeerBootstrapToken creates the token a peer cluster imports to mirror a pool with this cluster. Only
+// supported since octopus.
+func CreatePoolPeerBootstrapToken(context *clusterd.Context, clusterName, poolName string) (string, error) {
+	args := []string{"mirror", "pool", "peer", "bootstrap", "create", poolName}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the peer bootstrap token of pool %s: %+v. output: %s", poolName, err, string(buf))
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// ImportPoolPeerBootstrapToken adds the peer cluster that created the token to the peers of a pool. Importing the
+// token of an existing peer again updates the peer. Only supported since octopus.
+func ImportPoolPeerBootstrapToken(context *clusterd.Context, clusterName, poolName, token string) error {
+	// rbd reads the token from a file
+	file, err := ioutil.TempFile(context.ConfigDir, fmt.Sprintf("%s-peer-token", poolName))
+	if err != nil {
+		return fmt.Errorf("failed to create the peer bootstrap token file of pool %s: %+v", poolName, err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(token)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write the peer bootstrap token file of pool %s: %+v", poolName, err)
+	}
+
+	args := []string{"mirror", "pool", "peer", "bootstrap", "import", poolName, file.Name()}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to import the peer bootstrap token of pool %s: %+v. output: %s", poolName, err, string(buf))
+	}
+	return nil
+}
+
+// EnableFilesystemMirroring enables the snapshot mirroring of a filesystem and the mirroring mgr module. Only
+// supported since pacific.
+func EnableFilesystemMirroring(context *clusterd.Context, clusterName, fsName string) error {
+	if err := MgrEnableModule(context, clusterName, "mirroring", false); err != nil {
+		return fmt.Errorf("failed to enable the mirroring mgr module: %+v", err)
+	}
+	args := []string{"fs", "snapshot", "mirror", "enable", fsName}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to enable the snapshot mirroring of filesystem %s: %+v. output: %s", fsName, err, string(buf))
+	}
+	return nil
+}
+
+// CreateFilesystemPeerBootstrapToken creates the token a peer cluster imports to mirror its filesystem to a filesystem
+// of this cluster, with the ceph user of the peer created for the token. Only supported since pacific.
+func CreateFilesystemPeerBootstrapToken(context *clusterd.Context, clusterName, fsName, clientName, siteName string) (string, error) {
+	args := []string{"fs", "snapshot", "mirror", "peer_bootstrap", "create", fsName, clientName, siteName}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the peer bootstrap token of filesystem %s: %+v. output: %s", fsName, err, string(buf))
+	}
+
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(buf, &result); err != nil {
+		return "", fmt.Errorf("failed to unmarshal the peer bootstrap token of filesystem %s: %+v", fsName, err)
+	}
+	return result.Token, nil
+}
+
+// ImportFilesystemPeerBootstrapToken adds the peer cluster that created the token to the peers of a filesystem. The
+// token of an existing peer is ignored. Only supported since pacific.
+func ImportFilesystemPeerBootstrapToken(context *clusterd.Context, clusterName, fsName, token string) error {
+	args := []string{"fs", "snapshot", "mirror", "peer_bootstrap", "import", fsName, token}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		if exec.ExitStatus(err) == int(syscall.EEXIST) {
+			return nil
+		}
+		return fmt.Errorf("failed to import the peer bootstrap token of filesystem %s: %+v. output: %s", fsName, err, string(buf))
+	}
+	return nil
+}
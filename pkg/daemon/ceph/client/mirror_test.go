@@ -0,0 +1,161 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPoolMirroring(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			assert.Equal(t, "rbd", command)
+			assert.Equal(t, "replicapool", args[3])
+			if args[2] == "info" {
+				return `{"mode":"pool","peers":[{"uuid":"8b2e","cluster_name":"site-b","client_name":"client.rbd-mirror-peer"}]}`, nil
+			}
+			assert.Equal(t, "--verbose", args[4])
+			return `{"summary":{"health":"WARNING","daemon_health":"OK","image_health":"WARNING","states":{"replaying":1,"syncing":1}},
+				"images":[{"name":"img1","global_id":"1a2b","state":"up+replaying","description":"replaying, entries_behind_master=3","last_update":"2019-03-01 10:00:00"}]}`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	info, err := GetPoolMirroringInfo(context, "mycluster", "replicapool")
+	assert.Nil(t, err)
+	assert.Equal(t, "pool", info.Mode)
+	assert.Equal(t, "site-b", info.Peers[0].ClusterName)
+	assert.Equal(t, "client.rbd-mirror-peer", info.Peers[0].ClientName)
+
+	status, err := GetPoolMirroringStatus(context, "mycluster", "replicapool")
+	assert.Nil(t, err)
+	assert.Equal(t, "WARNING", status.Summary.Health)
+	assert.Equal(t, 1, status.Summary.States["syncing"])
+	assert.Equal(t, "up+replaying", status.Images[0].State)
+	assert.Equal(t, "2019-03-01 10:00:00", status.Images[0].LastUpdate)
+}
+
+func TestGetFilesystemMirrorDaemonStatus(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			assert.Equal(t, []string{"fs", "snapshot", "mirror", "daemon", "status"}, args[:5])
+			return `[{"daemon_id":4115,"filesystems":[{"filesystem_id":1,"name":"myfs","directory_count":2,
+				"peers":[{"uuid":"c4f1","remote":{"client_name":"client.mirror_remote","cluster_name":"site-b","fs_name":"backupfs"},
+				"stats":{"failure_count":1,"recovery_count":0}}]}]}]`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	status, err := GetFilesystemMirrorDaemonStatus(context, "mycluster")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(status))
+	fs := status[0].Filesystems[0]
+	assert.Equal(t, "myfs", fs.Name)
+	assert.Equal(t, 2, fs.DirectoryCount)
+	assert.Equal(t, "backupfs", fs.Peers[0].Remote.FSName)
+	assert.Equal(t, 1, fs.Peers[0].Stats.FailureCount)
+}
+
+func TestPoolPeerBootstrap(t *testing.T) {
+	var imported string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			assert.Equal(t, "rbd", command)
+			assert.NotContains(t, args, "--format")
+			switch args[2] {
+			case "enable":
+				assert.Equal(t, []string{"mirror", "pool", "enable", "replicapool", "image"}, args[:5])
+				return "", nil
+			case "peer":
+				assert.Equal(t, "replicapool", args[5])
+				if args[4] == "create" {
+					return "eyJmc2lkIjoiYWJjIn0=\n", nil
+				}
+				assert.Equal(t, "import", args[4])
+				data, err := ioutil.ReadFile(args[6])
+				assert.Nil(t, err)
+				imported = string(data)
+				return "", nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.Nil(t, EnablePoolMirroring(context, "mycluster", "replicapool", MirroringModeImage))
+
+	token, err := CreatePoolPeerBootstrapToken(context, "mycluster", "replicapool")
+	assert.Nil(t, err)
+	assert.Equal(t, "eyJmc2lkIjoiYWJjIn0=", token)
+
+	// the token is passed in a file that is removed after the import
+	assert.Nil(t, ImportPoolPeerBootstrapToken(context, "mycluster", "replicapool", token))
+	assert.Equal(t, token, imported)
+}
+
+func TestFilesystemPeerBootstrap(t *testing.T) {
+	commands := [][]string{}
+	var importErr error
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			// the cluster args follow the command args
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--cluster=") {
+					commands = append(commands, args[:i])
+					break
+				}
+			}
+			if args[0] == "mgr" {
+				return "", nil
+			}
+			if args[4] == "create" {
+				return `{"token":"eyJmc2lkIjoiZGVmIn0="}`, nil
+			}
+			if args[4] == "import" {
+				return "", importErr
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.Nil(t, EnableFilesystemMirroring(context, "mycluster", "myfs"))
+	token, err := CreateFilesystemPeerBootstrapToken(context, "mycluster", "myfs", "client.mirror-remote", "site-b")
+	assert.Nil(t, err)
+	assert.Equal(t, "eyJmc2lkIjoiZGVmIn0=", token)
+	assert.Nil(t, ImportFilesystemPeerBootstrapToken(context, "mycluster", "myfs", token))
+
+	assert.Equal(t, [][]string{
+		{"mgr", "module", "enable", "mirroring"},
+		{"fs", "snapshot", "mirror", "enable", "myfs"},
+		{"fs", "snapshot", "mirror", "peer_bootstrap", "create", "myfs", "client.mirror-remote", "site-b"},
+		{"fs", "snapshot", "mirror", "peer_bootstrap", "import", "myfs", "eyJmc2lkIjoiZGVmIn0="},
+	}, commands)
+
+	// the import of an existing peer exits with EEXIST and is ignored
+	importErr = exectest.ExitError(int(syscall.EEXIST))
+	assert.Nil(t, ImportFilesystemPeerBootstrapToken(context, "mycluster", "myfs", token))
+	importErr = exectest.ExitError(int(syscall.EINVAL))
+	assert.NotNil(t, ImportFilesystemPeerBootstrapToken(context, "mycluster", "myfs", token))
+}
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 )
 
@@ -90,6 +91,7 @@ type MonTimeSkewStatus struct {
 	Skew    json.Number `json:"skew"`
 	Latency json.Number `json:"latency"`
 	Health  string      `json:"health"`
+	Details string      `json:"details,omitempty"`
 }
 
 func GetMonStats(context *clusterd.Context, clusterName string) (*MonStats, error) {
@@ -123,3 +125,39 @@ func GetMonTimeStatus(context *clusterd.Context, clusterName string) (*MonTimeSt
 
 	return &timeStatus, nil
 }
+
+// MonSetAllConfig applies a setting to all the mons. Luminous has no central config database, so the setting is
+// injected in the running mons and must be applied again when the mons restart.
+func MonSetAllConfig(context *clusterd.Context, clusterName, cephVersionName, key, val string) error {
+	var args []string
+	if cephVersionName == cephv1.Luminous || cephVersionName == "" {
+		args = []string{"tell", "mon.*", "injectargs", fmt.Sprintf("--%s=%s", key, val)}
+	} else {
+		args = []string{"config", "set", "mon", key, val}
+	}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to set mon config key %s to \"%s\": %+v", key, val, err)
+	}
+	return nil
+}
+
+// ConfigOption is a setting of the central config database of the mons
+type ConfigOption struct {
+	Section string `json:"section"`
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+}
+
+// ConfigDump returns the settings of the central config database, which only exists since mimic
+func ConfigDump(context *clusterd.Context, clusterName string) ([]ConfigOption, error) {
+	buf, err := ExecuteCephCommand(context, clusterName, []string{"config", "dump"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump the config: %+v", err)
+	}
+
+	var options []ConfigOption
+	if err := json.Unmarshal(buf, &options); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config dump response: %+v", err)
+	}
+	return options, nil
+}
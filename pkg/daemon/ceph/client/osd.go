@@ -19,8 +19,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
 )
 
 type OSDUsage struct {
@@ -59,10 +62,35 @@ type OSDPerfStats struct {
 
 type OSDDump struct {
 	OSDs []struct {
-		OSD json.Number `json:"osd"`
-		Up  json.Number `json:"up"`
-		In  json.Number `json:"in"`
+		OSD  json.Number `json:"osd"`
+		Up   json.Number `json:"up"`
+		In   json.Number `json:"in"`
+		UUID string      `json:"uuid"`
 	} `json:"osds"`
+	// The cluster wide osd flags, separated by commas
+	Flags string `json:"flags"`
+	// The version of the crush map, incremented by each change of the crush map
+	CrushVersion int `json:"crush_version"`
+}
+
+// HasFlag returns whether the cluster wide osd flag such as noout is set
+func (dump *OSDDump) HasFlag(flag string) bool {
+	for _, f := range strings.Split(dump.Flags, ",") {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// OSDMetadata is the metadata reported by an osd (subset of all available fields)
+type OSDMetadata struct {
+	ID          int    `json:"id"`
+	Hostname    string `json:"hostname"`
+	ObjectStore string `json:"osd_objectstore"`
+	// Devices is the comma-separated list of the devices of the osd, reported since mimic
+	Devices       string `json:"devices"`
+	BlockPartPath string `json:"bluestore_bdev_partition_path"`
 }
 
 // StatusByID returns status and inCluster states for given OSD id
@@ -136,18 +164,65 @@ func GetOSDDump(context *clusterd.Context, clusterName string) (*OSDDump, error)
 	return &osdDump, nil
 }
 
+// GetOSDMetadata returns the metadata of all the osds of the cluster
+func GetOSDMetadata(context *clusterd.Context, clusterName string) ([]OSDMetadata, error) {
+	args := []string{"osd", "metadata"}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get osd metadata: %+v", err)
+	}
+
+	var metadata []OSDMetadata
+	if err := json.Unmarshal(buf, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal osd metadata response: %+v", err)
+	}
+
+	return metadata, nil
+}
+
 func OSDOut(context *clusterd.Context, clusterName string, osdID int) (string, error) {
 	args := []string{"osd", "out", strconv.Itoa(osdID)}
 	buf, err := ExecuteCephCommand(context, clusterName, args)
 	return string(buf), err
 }
 
+func OSDIn(context *clusterd.Context, clusterName string, osdID int) (string, error) {
+	args := []string{"osd", "in", strconv.Itoa(osdID)}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	return string(buf), err
+}
+
 func OSDRemove(context *clusterd.Context, clusterName string, osdID int) (string, error) {
 	args := []string{"osd", "rm", strconv.Itoa(osdID)}
 	buf, err := ExecuteCephCommand(context, clusterName, args)
 	return string(buf), err
 }
 
+// OSDPurge removes the osd from the crush map, deletes its auth key and removes it from the osd map
+func OSDPurge(context *clusterd.Context, clusterName string, osdID int) error {
+	args := []string{"osd", "purge", strconv.Itoa(osdID), "--yes-i-really-mean-it"}
+	_, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to purge osd.%d. %+v", osdID, err)
+	}
+	return nil
+}
+
+// OSDSafeToDestroy returns whether the osd can be destroyed without reducing the durability of the data, which is
+// the case when no pg is stored on the osd anymore
+func OSDSafeToDestroy(context *clusterd.Context, clusterName string, osdID int) (bool, error) {
+	args := []string{"osd", "safe-to-destroy", strconv.Itoa(osdID)}
+	_, err := ExecuteCephCommand(context, clusterName, args)
+	if err == nil {
+		return true, nil
+	}
+	// ceph returns EBUSY while pgs are mapped to the osd, or EAGAIN while the pg stats are not known
+	if status := exec.ExitStatus(err); status == int(syscall.EBUSY) || status == int(syscall.EAGAIN) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check if osd.%d is safe to destroy. %+v", osdID, err)
+}
+
 func DisableScrubbing(context *clusterd.Context, clusterName string) (string, error) {
 	args := []string{"osd", "set", "noscrub"}
 	buf, err := ExecuteCephCommand(context, clusterName, args)
@@ -180,6 +255,24 @@ func EnableScrubbing(context *clusterd.Context, clusterName string) (string, err
 	return string(buf), nil
 }
 
+// OSDSetFlag sets a cluster wide osd flag such as noout
+func OSDSetFlag(context *clusterd.Context, clusterName, flag string) error {
+	args := []string{"osd", "set", flag}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to set %s: %+v", flag, err)
+	}
+	return nil
+}
+
+// OSDUnsetFlag unsets a cluster wide osd flag such as noout
+func OSDUnsetFlag(context *clusterd.Context, clusterName, flag string) error {
+	args := []string{"osd", "unset", flag}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to unset %s: %+v", flag, err)
+	}
+	return nil
+}
+
 func (usage *OSDUsage) ByID(osdID int) *OSDNodeUsage {
 	for i := range usage.OSDNodes {
 		if usage.OSDNodes[i].ID == osdID {
@@ -0,0 +1,55 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOSDSafeToDestroy(t *testing.T) {
+	var err error
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			assert.Equal(t, []string{"osd", "safe-to-destroy", "3"}, args[:3])
+			return "", err
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	safe, e := OSDSafeToDestroy(context, "mycluster", 3)
+	assert.Nil(t, e)
+	assert.True(t, safe)
+
+	// the ceph command exits with EBUSY while pgs are mapped to the osd and EAGAIN while their stats are unknown
+	err = exectest.ExitError(int(syscall.EBUSY))
+	safe, e = OSDSafeToDestroy(context, "mycluster", 3)
+	assert.Nil(t, e)
+	assert.False(t, safe)
+	err = exectest.ExitError(int(syscall.EAGAIN))
+	safe, e = OSDSafeToDestroy(context, "mycluster", 3)
+	assert.Nil(t, e)
+	assert.False(t, safe)
+
+	err = exectest.ExitError(int(syscall.EINVAL))
+	_, e = OSDSafeToDestroy(context, "mycluster", 3)
+	assert.NotNil(t, e)
+}
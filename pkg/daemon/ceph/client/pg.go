@@ -45,3 +45,13 @@ func GetPGDumpBrief(context *clusterd.Context, clusterName string) ([]PGDumpBrie
 
 	return pgDump, nil
 }
+
+// RepairPG instructs the primary osd of a pg to repair the inconsistencies found by the scrubs of the pg
+func RepairPG(context *clusterd.Context, clusterName, pgID string) error {
+	args := []string{"pg", "repair", pgID}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return fmt.Errorf("failed to repair pg %s: %+v. output: %s", pgID, err, string(buf))
+	}
+	return nil
+}
@@ -42,6 +42,7 @@ type CephStoragePoolDetails struct {
 	ErasureCodeProfile string `json:"erasure_code_profile"`
 	FailureDomain      string `json:"failureDomain"`
 	CrushRoot          string `json:"crushRoot"`
+	CrushRule          string `json:"crush_rule"`
 }
 
 type CephStoragePoolStats struct {
@@ -124,6 +125,58 @@ func GetPoolDetails(context *clusterd.Context, clusterName, name string) (CephSt
 }
 
 func CreatePoolWithProfile(context *clusterd.Context, clusterName string, newPoolReq model.Pool, appName string) error {
+	if err := createPoolWithProfile(context, clusterName, newPoolReq, appName); err != nil {
+		return err
+	}
+
+	crushRule := newPoolReq.CrushRule
+	if newPoolReq.Type == model.Replicated && newPoolReq.ReplicatedConfig.PrimaryDeviceClass != "" {
+		if err := createHybridCrushRule(context, clusterName, newPoolReq); err != nil {
+			return fmt.Errorf("failed to create hybrid crush rule for pool %s. %+v", newPoolReq.Name, err)
+		}
+		crushRule = GetHybridCrushRuleForPool(newPoolReq.Name)
+	}
+
+	// switch the pool to the requested rule after it was created with its own rule or erasure code profile
+	if crushRule != "" {
+		if err := SetPoolProperty(context, clusterName, newPoolReq.Name, "crush_rule", crushRule); err != nil {
+			return fmt.Errorf("failed to set crush rule %s on pool %s. %+v", crushRule, newPoolReq.Name, err)
+		}
+	}
+	return nil
+}
+
+// GetHybridCrushRuleForPool returns the name of the crush rule of a hybrid pool
+func GetHybridCrushRuleForPool(poolName string) string {
+	return fmt.Sprintf("%s_hybrid", poolName)
+}
+
+// createHybridCrushRule creates a rule placing the primary replica on the primary device class and the other replicas
+// on the secondary device class. The two classes are chosen by separate steps, and crush cannot exclude the failure
+// domain of the primary from the choice of the other replicas: a failure domain with osds of both classes may hold two
+// replicas of a pg.
+func createHybridCrushRule(context *clusterd.Context, clusterName string, pool model.Pool) error {
+	crushRoot := pool.CrushRoot
+	if crushRoot == "" {
+		crushRoot = "default"
+	}
+	failureDomain := pool.FailureDomain
+	if failureDomain == "" {
+		failureDomain = "host"
+	}
+
+	steps := []string{
+		fmt.Sprintf("take %s class %s", crushRoot, pool.ReplicatedConfig.PrimaryDeviceClass),
+		fmt.Sprintf("chooseleaf firstn 1 type %s", failureDomain),
+		"emit",
+		fmt.Sprintf("take %s class %s", crushRoot, pool.ReplicatedConfig.SecondaryDeviceClass),
+		fmt.Sprintf("chooseleaf firstn -1 type %s", failureDomain),
+		"emit",
+	}
+	return SetCrushRule(context, clusterName, GetHybridCrushRuleForPool(pool.Name), "replicated", steps)
+}
+
+func createPoolWithProfile(context *clusterd.Context, clusterName string, newPoolReq model.Pool, appName string) error {
 	newPool := ModelPoolToCephPool(newPoolReq)
 	if newPoolReq.Type == model.ErasureCoded {
 		// create a new erasure code profile for the new pool
@@ -164,11 +217,17 @@ func DeletePool(context *clusterd.Context, clusterName string, name string) erro
 		return fmt.Errorf("failed to delete pool %s. %+v", name, err)
 	}
 
-	// remove the crush rule for this pool and ignore the error in case the rule is still in use or not found
-	args = []string{"osd", "crush", "rule", "rm", name}
-	_, err = ExecuteCephCommand(context, clusterName, args)
-	if err != nil {
-		logger.Infof("did not delete crush rule %s. %+v", name, err)
+	// remove the crush rules for this pool and ignore the error in case the rule is still in use or not found
+	rules := []string{name}
+	if pool.CrushRule == GetHybridCrushRuleForPool(name) {
+		rules = append(rules, pool.CrushRule)
+	}
+	for _, rule := range rules {
+		args = []string{"osd", "crush", "rule", "rm", rule}
+		_, err = ExecuteCephCommand(context, clusterName, args)
+		if err != nil {
+			logger.Infof("did not delete crush rule %s. %+v", rule, err)
+		}
 	}
 
 	logger.Infof("purge completed for pool %s", name)
@@ -230,6 +289,12 @@ func CreateReplicatedPoolForApp(context *clusterd.Context, clusterName string, n
 		return err
 	}
 
+	// set the min size as ceph does for a new pool so the io continues with a replica lost, also when the size is reduced
+	minSize := newPool.Size - newPool.Size/2
+	if err = SetPoolProperty(context, clusterName, newPool.Name, "min_size", strconv.FormatUint(uint64(minSize), 10)); err != nil {
+		return err
+	}
+
 	// ensure that the newly created pool gets an application tag
 	err = givePoolAppTag(context, clusterName, newPool.Name, appName)
 	if err != nil {
@@ -360,6 +425,8 @@ func cephPoolToModelPool(cephPool CephStoragePoolDetails, ecpDetails map[string]
 		pool.ErasureCodedConfig.DataChunkCount = ecpDetails.DataChunkCount
 		pool.ErasureCodedConfig.CodingChunkCount = ecpDetails.CodingChunkCount
 		pool.ErasureCodedConfig.Algorithm = fmt.Sprintf("%s::%s", ecpDetails.Plugin, ecpDetails.Technique)
+		pool.ErasureCodedConfig.Plugin = ecpDetails.Plugin
+		pool.ErasureCodedConfig.Technique = ecpDetails.Technique
 	} else if cephPool.Size > 0 {
 		pool.Type = model.Replicated
 		pool.ReplicatedConfig.Size = cephPool.Size
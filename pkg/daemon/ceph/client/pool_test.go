@@ -106,6 +106,7 @@ func TestCreateReplicaPoolWithFailureDomain(t *testing.T) {
 
 func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot string) {
 	crushRuleCreated := false
+	minSizeSet := false
 	executor := &exectest.MockExecutor{}
 	context := &clusterd.Context{Executor: executor}
 	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
@@ -118,6 +119,11 @@ func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot string) {
 			}
 			if args[2] == "set" {
 				assert.Equal(t, "mypool", args[3])
+				if args[4] == "min_size" {
+					minSizeSet = true
+					assert.Equal(t, "6173", args[5])
+					return "", nil
+				}
 				assert.Equal(t, "size", args[4])
 				assert.Equal(t, "12345", args[5])
 				return "", nil
@@ -153,4 +159,5 @@ func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot string) {
 	err := CreateReplicatedPoolForApp(context, "myns", p, "myapp")
 	assert.Nil(t, err)
 	assert.True(t, crushRuleCreated)
+	assert.True(t, minSizeSet)
 }
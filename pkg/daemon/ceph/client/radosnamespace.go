@@ -0,0 +1,74 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+// PoolSpec returns the pool argument of the rbd commands for the images of a RADOS namespace of the pool.
+// The images of the default namespace are simply in the pool.
+func PoolSpec(poolName, namespace string) string {
+	if namespace == "" {
+		return poolName
+	}
+	return fmt.Sprintf("%s/%s", poolName, namespace)
+}
+
+// RadosNamespaceUser returns the id of the ceph user restricted to the images of a RADOS namespace, without the
+// client prefix like the mountUser of the storage classes
+func RadosNamespaceUser(poolName, namespace string) string {
+	return fmt.Sprintf("%s.%s", poolName, namespace)
+}
+
+// RadosNamespaceCaps returns the caps allowing a client to map and manage the images of a RADOS namespace only
+func RadosNamespaceCaps(poolName, namespace string) []string {
+	return []string{
+		"mon", "profile rbd",
+		"osd", fmt.Sprintf("profile rbd pool=%s namespace=%s", poolName, namespace),
+	}
+}
+
+// CreateRadosNamespace creates a RADOS namespace in a pool for the rbd images. Only supported since nautilus.
+func CreateRadosNamespace(context *clusterd.Context, clusterName, poolName, namespace string) error {
+	args := []string{"namespace", "create", "--pool", poolName, "--namespace", namespace}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		if cmdErr, ok := err.(*exec.CommandError); ok && cmdErr.ExitStatus() == int(syscall.EEXIST) {
+			return nil
+		}
+		return fmt.Errorf("failed to create namespace %s in pool %s: %+v. output: %s", namespace, poolName, err, string(buf))
+	}
+	return nil
+}
+
+// DeleteRadosNamespace removes a RADOS namespace from a pool. Fails if the namespace still has images.
+func DeleteRadosNamespace(context *clusterd.Context, clusterName, poolName, namespace string) error {
+	args := []string{"namespace", "remove", "--pool", poolName, "--namespace", namespace}
+	buf, err := ExecuteRBDCommandNoFormat(context, clusterName, args)
+	if err != nil {
+		if cmdErr, ok := err.(*exec.CommandError); ok && cmdErr.ExitStatus() == int(syscall.ENOENT) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove namespace %s from pool %s: %+v. output: %s", namespace, poolName, err, string(buf))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolSpec(t *testing.T) {
+	assert.Equal(t, "replicapool", PoolSpec("replicapool", ""))
+	assert.Equal(t, "replicapool/tenant-a", PoolSpec("replicapool", "tenant-a"))
+	assert.Equal(t, "replicapool/tenant-a/image1", getImageSpec("image1", PoolSpec("replicapool", "tenant-a")))
+	assert.Equal(t, "replicapool.tenant-a", RadosNamespaceUser("replicapool", "tenant-a"))
+	assert.Equal(t, []string{"mon", "profile rbd", "osd", "profile rbd pool=replicapool namespace=tenant-a"},
+		RadosNamespaceCaps("replicapool", "tenant-a"))
+}
+
+func TestRadosNamespace(t *testing.T) {
+	var fail bool
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			assert.Equal(t, "rbd", command)
+			assert.Equal(t, []string{"--pool", "replicapool", "--namespace", "tenant-a"}, args[2:6])
+			if fail {
+				return "", errors.New("mock failure")
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.Nil(t, CreateRadosNamespace(context, "mycluster", "replicapool", "tenant-a"))
+	assert.Nil(t, DeleteRadosNamespace(context, "mycluster", "replicapool", "tenant-a"))
+
+	fail = true
+	assert.NotNil(t, CreateRadosNamespace(context, "mycluster", "replicapool", "tenant-a"))
+	assert.NotNil(t, DeleteRadosNamespace(context, "mycluster", "replicapool", "tenant-a"))
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"k8s.io/kubernetes/pkg/util/version"
+)
+
+// the volumes module of the mgr managing the subvolumes was added in nautilus
+var subvolumeMinVersion = version.MustParseGeneric("14.0.0")
+
+// CheckSubvolumeSupport returns an error if the mgrs of the cluster are older than nautilus and cannot manage the
+// subvolumes
+func CheckSubvolumeSupport(context *clusterd.Context, clusterName string) error {
+	versions, err := MgrVersions(context, clusterName)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("no mgr is running in cluster %s to manage the subvolumes", clusterName)
+	}
+	for _, v := range versions {
+		if !v.AtLeast(subvolumeMinVersion) {
+			return fmt.Errorf("the filesystem volumes are subvolumes, which require the mgrs of ceph nautilus or newer, "+
+				"but a mgr of cluster %s runs version %s. upgrade the cluster or use a storage class with a blockPool", clusterName, v)
+		}
+	}
+	return nil
+}
+
+// CreateSubvolume creates a directory of the filesystem whose size is limited by its ceph.quota.max_bytes attribute.
+// The subvolumes are managed by the volumes module of the mgr, which requires nautilus.
+func CreateSubvolume(context *clusterd.Context, clusterName, fsName, name string, size uint64) error {
+	args := []string{"fs", "subvolume", "create", fsName, name, "--size", strconv.FormatUint(size, 10)}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to create subvolume %s of filesystem %s: %+v", name, fsName, err)
+	}
+	return nil
+}
+
+// GetSubvolumePath returns the path of the subvolume in the filesystem, which is the path mounted by the clients
+func GetSubvolumePath(context *clusterd.Context, clusterName, fsName, name string) (string, error) {
+	args := []string{"fs", "subvolume", "getpath", fsName, name}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the path of subvolume %s of filesystem %s: %+v", name, fsName, err)
+	}
+	path := strings.TrimSpace(string(buf))
+	if path == "" {
+		return "", fmt.Errorf("empty path for subvolume %s of filesystem %s", name, fsName)
+	}
+	return path, nil
+}
+
+// ResizeSubvolume sets the quota of the subvolume to the new size
+func ResizeSubvolume(context *clusterd.Context, clusterName, fsName, name string, size uint64) error {
+	args := []string{"fs", "subvolume", "resize", fsName, name, strconv.FormatUint(size, 10)}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to resize subvolume %s of filesystem %s: %+v", name, fsName, err)
+	}
+	return nil
+}
+
+// DeleteSubvolume deletes the subvolume and all its files
+func DeleteSubvolume(context *clusterd.Context, clusterName, fsName, name string) error {
+	args := []string{"fs", "subvolume", "rm", fsName, name}
+	if _, err := ExecuteCephCommand(context, clusterName, args); err != nil {
+		return fmt.Errorf("failed to delete subvolume %s of filesystem %s: %+v", name, fsName, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubvolume(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			commands = append(commands, args[:6])
+			if args[2] == "getpath" {
+				return "/volumes/_nogroup/pvc-1\n", nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.Nil(t, CreateSubvolume(context, "mycluster", "myfs", "pvc-1", 1073741824))
+	path, err := GetSubvolumePath(context, "mycluster", "myfs", "pvc-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "/volumes/_nogroup/pvc-1", path)
+	assert.Nil(t, ResizeSubvolume(context, "mycluster", "myfs", "pvc-1", 2147483648))
+	assert.Nil(t, DeleteSubvolume(context, "mycluster", "myfs", "pvc-1"))
+
+	assert.Equal(t, [][]string{
+		{"fs", "subvolume", "create", "myfs", "pvc-1", "--size"},
+		{"fs", "subvolume", "getpath", "myfs", "pvc-1", "--cluster=mycluster"},
+		{"fs", "subvolume", "resize", "myfs", "pvc-1", "2147483648"},
+		{"fs", "subvolume", "rm", "myfs", "pvc-1", "--cluster=mycluster"},
+	}, commands)
+}
+
+func TestCheckSubvolumeSupport(t *testing.T) {
+	versions := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return versions, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	versions = `{"mgr":{"ceph version 14.2.5 (ad5bd132e1492173c85fda2cc863152730b16a92) nautilus (stable)":2}}`
+	assert.Nil(t, CheckSubvolumeSupport(context, "mycluster"))
+
+	// a mgr is still on mimic during an upgrade
+	versions = `{"mgr":{"ceph version 13.2.2 (02899bfda814146b021136e9d8e80eba494e1126) mimic (stable)":1,` +
+		`"ceph version 14.2.5 (ad5bd132e1492173c85fda2cc863152730b16a92) nautilus (stable)":1}}`
+	assert.NotNil(t, CheckSubvolumeSupport(context, "mycluster"))
+
+	versions = `{"mon":{"ceph version 14.2.5 (ad5bd132e1492173c85fda2cc863152730b16a92) nautilus (stable)":3}}`
+	assert.NotNil(t, CheckSubvolumeSupport(context, "mycluster"))
+}
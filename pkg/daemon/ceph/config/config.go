@@ -47,6 +47,7 @@ type GlobalConfig struct {
 	RunDir                   string `ini:"run dir,omitempty"`
 	MonMembers               string `ini:"mon initial members,omitempty"`
 	MonHost                  string `ini:"mon host"`
+	MonDNSSRVName            string `ini:"mon_dns_srv_name,omitempty"`
 	LogFile                  string `ini:"log file,omitempty"`
 	MonClusterLogFile        string `ini:"mon cluster log file,omitempty"`
 	PublicAddr               string `ini:"public addr,omitempty"`
@@ -195,12 +196,19 @@ func CreateDefaultCephConfig(context *clusterd.Context, cluster *ClusterInfo, ru
 
 	cephLogLevel := logLevelToCephLogLevel(context.LogLevel)
 
+	// ceph only looks up the SRV records of the mons when mon_host is empty
+	monHost := strings.Join(monHosts, ",")
+	if cluster.MonDNSSRVName != "" {
+		monHost = ""
+	}
+
 	return &CephConfig{
 		GlobalConfig: &GlobalConfig{
 			FSID:                   cluster.FSID,
 			RunDir:                 runDir,
 			MonMembers:             strings.Join(monMembers, " "),
-			MonHost:                strings.Join(monHosts, ","),
+			MonHost:                monHost,
+			MonDNSSRVName:          cluster.MonDNSSRVName,
 			LogFile:                "/dev/stderr",
 			MonClusterLogFile:      "/dev/stderr",
 			PublicAddr:             context.NetworkInfo.PublicAddr,
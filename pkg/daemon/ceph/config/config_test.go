@@ -68,6 +68,14 @@ func TestCreateDefaultCephConfig(t *testing.T) {
 	assert.Equal(t, "10.1.1.0/24", cephConfig.PublicNetwork)
 	assert.Equal(t, "10.1.2.2", cephConfig.ClusterAddr)
 	assert.Equal(t, "10.1.2.0/24", cephConfig.ClusterNetwork)
+	assert.Equal(t, "", cephConfig.MonDNSSRVName)
+	assert.Equal(t, 2, len(strings.Split(cephConfig.MonHost, ",")))
+
+	// the clients find the mons from the SRV records instead of their endpoints
+	clusterInfo.MonDNSSRVName = "ceph-mon_rook-ceph-mon.ns.svc"
+	cephConfig = CreateDefaultCephConfig(context, clusterInfo, "/var/lib/rook1")
+	assert.Equal(t, "ceph-mon_rook-ceph-mon.ns.svc", cephConfig.MonDNSSRVName)
+	assert.Equal(t, "", cephConfig.MonHost)
 }
 
 func TestGenerateConfigFile(t *testing.T) {
@@ -32,6 +32,9 @@ type ClusterInfo struct {
 	AdminSecret   string
 	Name          string
 	Monitors      map[string]*MonInfo
+	// MonDNSSRVName is the name of the SRV records of the mons. When set, the clients find the mons from the DNS
+	// instead of their endpoints, which change when a mon fails over.
+	MonDNSSRVName string
 }
 
 // MonInfo is a collection of information about a Ceph mon.
@@ -0,0 +1,179 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package exporter exposes the perf counters of the ceph daemons of a node, read from their admin sockets.
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+const (
+	// DefaultPort is the default port of the prometheus metrics of the exporter
+	DefaultPort = 9926
+
+	daemonLabel = "ceph_daemon"
+	asokSuffix  = ".asok"
+	// the admin sockets are in the data dir or in the run dir of a daemon such as mon-a or osd0
+	maxSocketDepth = 1
+	// a daemon that is hung does not answer on its admin socket, which must not block the scrape of the other daemons
+	perfDumpTimeout = 5 * time.Second
+)
+
+var (
+	logger = capnslog.NewPackageLogger("github.com/rook/rook", "ceph-exporter")
+
+	invalidMetricChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+	// the admin sockets are named <cluster>-<type>.<id>.asok, where the cluster is the namespace for the daemons
+	// started by rook and ceph for the osds started by ceph-volume, for example rook-ceph-mon.a.asok or ceph-osd.0.asok
+	socketDaemonName = regexp.MustCompile(`^(?:.*?-)?((?:mon|mgr|osd|mds|client)\..+)$`)
+)
+
+// Exporter collects the perf counters of the daemons whose admin sockets are under the socket dir, the data dir of the
+// cluster on the host
+type Exporter struct {
+	context *clusterd.Context
+	sockDir string
+}
+
+// New creates an exporter of the perf counters of the daemons of the cluster
+func New(context *clusterd.Context, sockDir string) *Exporter {
+	return &Exporter{context: context, sockDir: sockDir}
+}
+
+// Describe sends no description since the perf counters depend on the daemons running on the node, which makes the
+// exporter an unchecked collector
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+}
+
+// Collect sends the perf counters of each daemon found on the node
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	sockets, err := e.findAdminSockets()
+	if err != nil {
+		logger.Errorf("failed to find the admin sockets. %+v", err)
+		return
+	}
+
+	for _, socket := range sockets {
+		daemon := e.daemonName(socket)
+		counters, err := e.perfDump(socket)
+		if err != nil {
+			// the socket of a daemon that is not running is left behind, and a hung daemon times out
+			logger.Debugf("failed to dump the perf counters of %s. %+v", daemon, err)
+			continue
+		}
+		for _, m := range perfMetrics(daemon, counters) {
+			ch <- m
+		}
+	}
+}
+
+// findAdminSockets returns the admin sockets in the socket dir and in the run dirs of the daemons under it
+func (e *Exporter) findAdminSockets() ([]string, error) {
+	var sockets []string
+	pattern := "*" + asokSuffix
+	dir := e.sockDir
+	for depth := 0; depth <= maxSocketDepth; depth++ {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, matches...)
+		dir = filepath.Join(dir, "*")
+	}
+	return sockets, nil
+}
+
+// daemonName returns the name of the daemon from the name of its admin socket, for example osd.0 for ceph-osd.0.asok
+func (e *Exporter) daemonName(socket string) string {
+	name := strings.TrimSuffix(filepath.Base(socket), asokSuffix)
+	if match := socketDaemonName.FindStringSubmatch(name); match != nil {
+		return match[1]
+	}
+	return name
+}
+
+func (e *Exporter) perfDump(socket string) (map[string]map[string]interface{}, error) {
+	output, err := e.context.Executor.ExecuteCommandWithTimeout(false, perfDumpTimeout, "", "ceph", "--admin-daemon", socket, "perf", "dump")
+	if err != nil {
+		return nil, err
+	}
+
+	var counters map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &counters); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal perf dump. %+v", err)
+	}
+	return counters, nil
+}
+
+// perfMetrics converts the perf counters of a daemon to metrics named ceph_<section>_<counter>. The averages are
+// exported as a _sum and a _count metric, and the histograms are skipped.
+func perfMetrics(daemon string, counters map[string]map[string]interface{}) []prometheus.Metric {
+	var metrics []prometheus.Metric
+	add := func(name string, value float64) {
+		desc := prometheus.NewDesc(name, "", []string{daemonLabel}, nil)
+		m, err := prometheus.NewConstMetric(desc, prometheus.UntypedValue, value, daemon)
+		if err != nil {
+			logger.Warningf("invalid metric %s of %s. %+v", name, daemon, err)
+			return
+		}
+		metrics = append(metrics, m)
+	}
+
+	var sections []string
+	for section := range counters {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+
+	for _, section := range sections {
+		var names []string
+		for counter := range counters[section] {
+			names = append(names, counter)
+		}
+		sort.Strings(names)
+
+		for _, counter := range names {
+			name := metricName(section, counter)
+			switch value := counters[section][counter].(type) {
+			case float64:
+				add(name, value)
+			case map[string]interface{}:
+				sum, hasSum := value["sum"].(float64)
+				count, hasCount := value["avgcount"].(float64)
+				if hasSum && hasCount {
+					add(name+"_sum", sum)
+					add(name+"_count", count)
+				}
+			}
+		}
+	}
+	return metrics
+}
+
+func metricName(section, counter string) string {
+	return invalidMetricChars.ReplaceAllString(fmt.Sprintf("ceph_%s_%s", section, counter), "_")
+}
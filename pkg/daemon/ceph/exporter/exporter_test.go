@@ -0,0 +1,109 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const perfDumpOutput = `{
+	"osd": {"op_r": 12, "op_latency": {"avgcount": 4, "sum": 0.5, "avgtime": 0.125}},
+	"throttle-msgr_dispatch_throttler-client": {"val": 3},
+	"mutex-OSDShard.0::sdata_wait_lock": {"wait": {"avgcount": 0, "sum": 0.0}},
+	"histograms": {"op_r_latency_out_bytes_histogram": {"axes": [], "values": []}}
+}`
+
+func collect(t *testing.T, e *Exporter) map[string]float64 {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(e)
+	families, err := registry.Gather()
+	assert.Nil(t, err)
+
+	result := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.Metric {
+			result[fmt.Sprintf("%s{%s}", family.GetName(), m.Label[0].GetValue())] = m.Untyped.GetValue()
+		}
+	}
+	return result
+}
+
+func TestCollect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// the sockets of the mon in its run dir, of an osd started by rook and of an osd started by ceph-volume, which
+	// uses the ceph cluster name, and a file that is not a socket
+	os.MkdirAll(path.Join(dir, "mon-a"), 0755)
+	os.MkdirAll(path.Join(dir, "osd0"), 0755)
+	os.MkdirAll(path.Join(dir, "osd1"), 0755)
+	ioutil.WriteFile(path.Join(dir, "mon-a", "rook-ceph-mon.a.asok"), []byte{}, 0644)
+	ioutil.WriteFile(path.Join(dir, "osd0", "rook-ceph-osd.0.asok"), []byte{}, 0644)
+	ioutil.WriteFile(path.Join(dir, "osd1", "ceph-osd.1.asok"), []byte{}, 0644)
+	ioutil.WriteFile(path.Join(dir, "osd1", "rook-ceph.config"), []byte{}, 0644)
+
+	dumped := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithTimeout: func(debug bool, timeout time.Duration, actionName string, command string, args ...string) (string, error) {
+			assert.Equal(t, "ceph", command)
+			assert.Equal(t, "--admin-daemon", args[0])
+			assert.Equal(t, []string{"perf", "dump"}, args[2:])
+			dumped = append(dumped, path.Base(args[1]))
+			if path.Base(args[1]) == "rook-ceph-mon.a.asok" {
+				// the mon is hung
+				return "", fmt.Errorf("Timeout waiting for the command ceph to return")
+			}
+			return perfDumpOutput, nil
+		},
+	}
+
+	e := New(&clusterd.Context{Executor: executor}, dir)
+	metrics := collect(t, e)
+	assert.ElementsMatch(t, []string{"rook-ceph-mon.a.asok", "rook-ceph-osd.0.asok", "ceph-osd.1.asok"}, dumped)
+	expected := map[string]float64{}
+	for _, osd := range []string{"osd.0", "osd.1"} {
+		expected["ceph_osd_op_r{"+osd+"}"] = 12
+		expected["ceph_osd_op_latency_sum{"+osd+"}"] = 0.5
+		expected["ceph_osd_op_latency_count{"+osd+"}"] = 4
+		expected["ceph_throttle_msgr_dispatch_throttler_client_val{"+osd+"}"] = 3
+		expected["ceph_mutex_OSDShard_0__sdata_wait_lock_wait_sum{"+osd+"}"] = 0
+		expected["ceph_mutex_OSDShard_0__sdata_wait_lock_wait_count{"+osd+"}"] = 0
+	}
+	assert.Equal(t, expected, metrics)
+}
+
+func TestDaemonName(t *testing.T) {
+	e := New(&clusterd.Context{}, "/var/lib/rook")
+	assert.Equal(t, "osd.0", e.daemonName("/var/lib/rook/osd0/rook-ceph-osd.0.asok"))
+	assert.Equal(t, "mon.a", e.daemonName("/var/lib/rook/mon-a/rook-ceph-mon.a.asok"))
+	assert.Equal(t, "client.admin", e.daemonName("/var/lib/rook/rook-ceph/rook-ceph-client.admin.asok"))
+	assert.Equal(t, "osd.1", e.daemonName("/var/lib/rook/osd1/ceph-osd.1.asok"))
+	assert.Equal(t, "mon.a", e.daemonName("/var/lib/rook/mon-a/ceph-mon.a.asok"))
+	assert.Equal(t, "mds.myfs-a", e.daemonName("/var/lib/rook/mds-myfs-a/rook-ceph-mds.myfs-a.asok"))
+	assert.Equal(t, "mon.a", e.daemonName("/var/run/ceph/mon.a.asok"))
+}
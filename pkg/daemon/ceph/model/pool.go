@@ -24,13 +24,17 @@ const (
 type PoolType int
 
 type ReplicatedPoolConfig struct {
-	Size uint `json:"size"`
+	Size                 uint   `json:"size"`
+	PrimaryDeviceClass   string `json:"primaryDeviceClass"`
+	SecondaryDeviceClass string `json:"secondaryDeviceClass"`
 }
 
 type ErasureCodedPoolConfig struct {
 	DataChunkCount   uint   `json:"dataChunkCount"`
 	CodingChunkCount uint   `json:"codingChunkCount"`
 	Algorithm        string `json:"algorithm"`
+	Plugin           string `json:"plugin"`
+	Technique        string `json:"technique"`
 }
 
 type Pool struct {
@@ -39,6 +43,7 @@ type Pool struct {
 	Type               PoolType               `json:"type"`
 	FailureDomain      string                 `json:"failureDomain"`
 	CrushRoot          string                 `json:"crushRoot"`
+	CrushRule          string                 `json:"crushRule"`
 	ReplicatedConfig   ReplicatedPoolConfig   `json:"replicatedConfig"`
 	ErasureCodedConfig ErasureCodedPoolConfig `json:"erasureCodedConfig"`
 }
@@ -0,0 +1,278 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/util/sys"
+)
+
+var rookPartitionLabel = regexp.MustCompile(`^ROOK-OSD(\d+)-(WAL|DB|BLOCK|FS-DATA)$`)
+
+var rookPartitionTypes = map[string]config.PartitionType{
+	"WAL":     config.WalPartitionType,
+	"DB":      config.DatabasePartitionType,
+	"BLOCK":   config.BlockPartitionType,
+	"FS-DATA": config.FilestoreDataPartitionType,
+}
+
+// bluestoreLabel is written by bluestore at the start of the block device of an osd
+type bluestoreLabel struct {
+	OSDUUID  string `json:"osd_uuid"`
+	CephFSID string `json:"ceph_fsid"`
+	WhoAmI   string `json:"whoami"`
+}
+
+// legacyPartition is a partition created by rook for an osd before ceph-volume
+type legacyPartition struct {
+	name   string
+	uuid   string
+	sizeMB int
+}
+
+// clusterCephVolumeOSDs returns the osds created by ceph-volume on the node that belong to the cluster. The osds are
+// adopted without being prepared again, for instance when the node joins the cluster again after kubernetes was
+// reinstalled.
+func (a *OsdAgent) clusterCephVolumeOSDs(context *clusterd.Context) ([]oposd.OSDInfo, error) {
+	osds, err := getCephVolumeOSDs(context, a.cluster.Name, a.cluster.FSID)
+	if err != nil || len(osds) == 0 {
+		return osds, err
+	}
+
+	osdMap, err := getOSDMapUUIDs(context, a.cluster.Name)
+	if err != nil {
+		logger.Warningf("failed to get the osd map. the ceph-volume osds are not checked against it. %+v", err)
+		return osds, nil
+	}
+
+	var adopted []oposd.OSDInfo
+	for _, osd := range osds {
+		if osdUUID, ok := osdMap[osd.ID]; !ok || osdUUID != osd.UUID {
+			logger.Warningf("skipping osd %d (%s) on device %s that is not in the osd map of the cluster", osd.ID, osd.UUID, osd.Device)
+			continue
+		}
+		adopted = append(adopted, osd)
+	}
+	return adopted, nil
+}
+
+// adoptLegacyDevices rebuilds the partition scheme entries of the osds of the cluster found on the rook partitions of
+// devices missing from the scheme of the node, which was lost with the config maps of the cluster. The devices of the
+// osds whose id is in the osd map of the cluster but that cannot be adopted are skipped rather than partitioned again.
+// Only the partitions of the osds purged from the cluster are reused as before.
+func (a *OsdAgent) adoptLegacyDevices(context *clusterd.Context, devices *DeviceOsdMapping, scheme *config.PerfScheme,
+	nameToUUID map[string]string) error {
+
+	var names []string
+	for name := range devices.Entries {
+		if _, pending := a.pendingDevices[name]; pending {
+			// the devices left partially prepared are resumed according to the resume policy
+			continue
+		}
+		if !isDeviceInUse(name, nameToUUID, scheme) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var osdMap map[int]string
+	adopted := false
+	for _, name := range names {
+		id, partitions := getLegacyPartitions(context, name)
+		if len(partitions) == 0 {
+			continue
+		}
+
+		if osdMap == nil {
+			var err error
+			if osdMap, err = getOSDMapUUIDs(context, a.cluster.Name); err != nil {
+				return fmt.Errorf("failed to get the osd map to adopt the osd %d on device %s. %+v", id, name, err)
+			}
+		}
+		osdUUID, inCluster := osdMap[id]
+		if !inCluster {
+			// osd purged from the cluster, the device is reused
+			continue
+		}
+
+		block, ok := partitions[config.BlockPartitionType]
+		if !ok {
+			logger.Warningf("skipping device %s. the legacy filestore osd %d found on it cannot be adopted", name, id)
+			a.skipLegacyDevice(devices, name)
+			continue
+		}
+		// the osd id is in the osd map, the device may hold a live osd of the cluster and must not be wiped
+		label, err := getBluestoreLabel(context, path.Join("/dev", block.name))
+		if err != nil {
+			logger.Warningf("skipping device %s. failed to read the bluestore label of osd %d. %+v", name, id, err)
+			a.skipLegacyDevice(devices, name)
+			continue
+		}
+		if label.CephFSID != a.cluster.FSID || label.OSDUUID != osdUUID || label.WhoAmI != strconv.Itoa(id) {
+			// osd of another cluster, or osd id reused after the osd was purged
+			logger.Warningf("skipping device %s. the bluestore label of osd %d does not match the osd map", name, id)
+			a.skipLegacyDevice(devices, name)
+			continue
+		}
+
+		if err := a.adoptLegacyOSD(context, name, id, label, partitions, nameToUUID, scheme); err != nil {
+			logger.Warningf("skipping device %s. failed to adopt osd %d. %+v", name, id, err)
+			a.skipLegacyDevice(devices, name)
+			continue
+		}
+		adopted = true
+	}
+
+	if !adopted {
+		return nil
+	}
+	if err := scheme.SaveScheme(a.kv, config.GetConfigStoreName(a.nodeName)); err != nil {
+		return fmt.Errorf("failed to save the partition scheme of the adopted osds. %+v", err)
+	}
+	return nil
+}
+
+func (a *OsdAgent) adoptLegacyOSD(context *clusterd.Context, name string, id int, label *bluestoreLabel,
+	partitions map[config.PartitionType]*legacyPartition, nameToUUID map[string]string, scheme *config.PerfScheme) error {
+
+	for _, t := range []config.PartitionType{config.WalPartitionType, config.DatabasePartitionType} {
+		if _, ok := partitions[t]; !ok {
+			return fmt.Errorf("the metadata partitions are not on the device")
+		}
+	}
+	diskUUID, ok := nameToUUID[name]
+	if !ok {
+		return fmt.Errorf("unknown disk uuid")
+	}
+	osdUUID, err := uuid.Parse(label.OSDUUID)
+	if err != nil {
+		return fmt.Errorf("invalid osd uuid %s. %+v", label.OSDUUID, err)
+	}
+
+	// the osd is started from its data dir, which holds the keyring of the osd
+	rootPath := getOSDRootDir(context.ConfigDir, id)
+	if isOSDDataNotExist(rootPath) {
+		return fmt.Errorf("the data dir %s of the osd is not found", rootPath)
+	}
+	fsid, err := ioutil.ReadFile(filepath.Join(rootPath, "fsid"))
+	if err != nil || strings.TrimSpace(string(fsid)) != label.OSDUUID {
+		return fmt.Errorf("the data dir %s belongs to another osd", rootPath)
+	}
+
+	entry := config.NewPerfSchemeEntry(config.Bluestore)
+	entry.ID = id
+	entry.OsdUUID = osdUUID
+	for t, p := range partitions {
+		entry.Partitions[t] = &config.PerfSchemePartitionDetails{
+			Device:        name,
+			DiskUUID:      diskUUID,
+			PartitionUUID: p.uuid,
+			SizeMB:        p.sizeMB,
+		}
+	}
+	scheme.Entries = append(scheme.Entries, entry)
+	logger.Infof("adopted osd %d found on device %s", id, name)
+	return nil
+}
+
+func (a *OsdAgent) skipLegacyDevice(devices *DeviceOsdMapping, name string) {
+	delete(devices.Entries, name)
+	a.skippedDevices = append(a.skippedDevices, name)
+}
+
+// getLegacyPartitions returns the rook partitions of the osd found on the device
+func getLegacyPartitions(context *clusterd.Context, name string) (int, map[config.PartitionType]*legacyPartition) {
+	id := -1
+	partitions := map[config.PartitionType]*legacyPartition{}
+	for _, device := range context.Devices {
+		if device.Parent != name || device.Type != sys.PartType {
+			continue
+		}
+		info, err := sys.GetUdevInfo(device.Name, context.Executor)
+		if err != nil {
+			logger.Warningf("failed to get the udev info of partition %s. %+v", device.Name, err)
+			return -1, nil
+		}
+		label := info["PARTNAME"]
+		if label == "" {
+			label = info["ID_PART_ENTRY_NAME"]
+		}
+		match := rookPartitionLabel.FindStringSubmatch(label)
+		if match == nil {
+			continue
+		}
+		partID, _ := strconv.Atoi(match[1])
+		if id != -1 && partID != id {
+			logger.Warningf("device %s has the partitions of several osds", name)
+			return -1, nil
+		}
+		id = partID
+		partitions[rookPartitionTypes[match[2]]] = &legacyPartition{
+			name:   device.Name,
+			uuid:   info["ID_PART_ENTRY_UUID"],
+			sizeMB: int(device.Size / 1024 / 1024),
+		}
+	}
+	return id, partitions
+}
+
+func getBluestoreLabel(context *clusterd.Context, devicePath string) (*bluestoreLabel, error) {
+	output, err := context.Executor.ExecuteCommandWithOutput(false, "", "ceph-bluestore-tool", "show-label", "--dev", devicePath)
+	if err != nil {
+		return nil, err
+	}
+	var labels map[string]bluestoreLabel
+	if err := json.Unmarshal([]byte(output), &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse the bluestore label of %s. %+v", devicePath, err)
+	}
+	label, ok := labels[devicePath]
+	if !ok {
+		return nil, fmt.Errorf("bluestore label of %s not found", devicePath)
+	}
+	return &label, nil
+}
+
+// getOSDMapUUIDs returns the uuid of each osd in the osd map of the cluster
+func getOSDMapUUIDs(context *clusterd.Context, clusterName string) (map[int]string, error) {
+	dump, err := client.GetOSDDump(context, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	uuids := map[int]string{}
+	for _, osd := range dump.OSDs {
+		id, err := osd.OSD.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid osd id in the osd map. %+v", err)
+		}
+		uuids[int(id)] = osd.UUID
+	}
+	return uuids, nil
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package osd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	adoptClusterFSID = "4bfe8b72-5e69-4330-b6c0-4d914db8ab89"
+	adoptOSDUUID     = "5c1d0dd1-d5c5-4b4e-8c1e-21e4a5b4a0b1"
+)
+
+func newAdoptTest(osdDump, label string) *clusterd.Context {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, name string, command string, args ...string) (string, error) {
+			switch {
+			case command == "ceph-volume":
+				return cephVolumeTestResult, nil
+			case command == "udevadm":
+				partitions := map[string]string{"sdb1": "ROOK-OSD3-WAL", "sdb2": "ROOK-OSD3-DB", "sdb3": "ROOK-OSD3-BLOCK", "sdc1": "data"}
+				dev := strings.TrimPrefix(args[2], "/dev/")
+				return fmt.Sprintf("PARTNAME=%s\nID_PART_ENTRY_UUID=uuid-%s", partitions[dev], dev), nil
+			case command == "ceph-bluestore-tool" && label != "":
+				return fmt.Sprintf(`{"%s": %s}`, args[2], label), nil
+			}
+			return "", fmt.Errorf("unexpected command %s %+v", command, args)
+		},
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "dump" {
+				return osdDump, nil
+			}
+			return "", fmt.Errorf("unexpected command %s %+v", command, args)
+		},
+	}
+	return &clusterd.Context{Executor: executor}
+}
+
+func TestAdoptCephVolumeOSDs(t *testing.T) {
+	osdDump := `{"osds":[{"osd":0,"up":0,"in":0,"uuid":"dbe407e0-c1cb-495e-b30a-02e01de6c8ae"},{"osd":1,"up":0,"in":0,"uuid":"00000000-0000-0000-0000-000000000001"}]}`
+	context := newAdoptTest(osdDump, "")
+	agent := &OsdAgent{cluster: &cephconfig.ClusterInfo{Name: "rook", FSID: adoptClusterFSID}}
+
+	// osd.1 was purged and its id reused by another osd
+	osds, err := agent.clusterCephVolumeOSDs(context)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(osds))
+	assert.Equal(t, 0, osds[0].ID)
+
+	// the osds of another cluster are not adopted
+	agent.cluster.FSID = "c5d0bc26-2d2f-4aa6-8e4f-bf4b0d1f7dd6"
+	osds, err = agent.clusterCephVolumeOSDs(context)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(osds))
+}
+
+func TestAdoptLegacyDevices(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "")
+	assert.Nil(t, err)
+	defer os.RemoveAll(configDir)
+
+	osdDump := fmt.Sprintf(`{"osds":[{"osd":3,"up":0,"in":0,"uuid":"%s"}]}`, adoptOSDUUID)
+	label := fmt.Sprintf(`{"osd_uuid": "%s", "ceph_fsid": "%s", "whoami": "3"}`, adoptOSDUUID, adoptClusterFSID)
+	context := newAdoptTest(osdDump, label)
+	context.ConfigDir = configDir
+	context.Devices = []*sys.LocalDisk{
+		{Name: "sdb", UUID: "disk-sdb", Type: sys.DiskType},
+		{Name: "sdb1", Parent: "sdb", Type: sys.PartType, Size: 576 * 1024 * 1024},
+		{Name: "sdb2", Parent: "sdb", Type: sys.PartType, Size: 20480 * 1024 * 1024},
+		{Name: "sdb3", Parent: "sdb", Type: sys.PartType, Size: 100000 * 1024 * 1024},
+		{Name: "sdc", UUID: "disk-sdc", Type: sys.DiskType},
+		{Name: "sdc1", Parent: "sdc", Type: sys.PartType},
+		{Name: "sdd", UUID: "disk-sdd", Type: sys.DiskType},
+	}
+	nameToUUID := map[string]string{"sdb": "disk-sdb", "sdc": "disk-sdc", "sdd": "disk-sdd"}
+	newDevices := func() *DeviceOsdMapping {
+		return &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+			"sdb": {Data: unassignedOSDID},
+			"sdc": {Data: unassignedOSDID},
+			"sdd": {Data: unassignedOSDID},
+		}}
+	}
+	newAgent := func() *OsdAgent {
+		return &OsdAgent{cluster: &cephconfig.ClusterInfo{Name: "rook", FSID: adoptClusterFSID}, kv: mockKVStore(), nodeName: "node1"}
+	}
+
+	// the data dir of the osd is missing, the device is skipped
+	agent := newAgent()
+	devices := newDevices()
+	scheme := config.NewPerfScheme()
+	err = agent.adoptLegacyDevices(context, devices, scheme, nameToUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(scheme.Entries))
+	assert.Equal(t, []string{"sdb"}, agent.skippedDevices)
+	assert.Equal(t, 2, len(devices.Entries))
+
+	// the osd is adopted with its data dir
+	rootPath := getOSDRootDir(configDir, 3)
+	assert.Nil(t, os.MkdirAll(rootPath, 0744))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(rootPath, "ready"), []byte("ready"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(rootPath, "fsid"), []byte(adoptOSDUUID+"\n"), 0644))
+	agent = newAgent()
+	devices = newDevices()
+	err = agent.adoptLegacyDevices(context, devices, scheme, nameToUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(agent.skippedDevices))
+	assert.Equal(t, 3, len(devices.Entries))
+	assert.Equal(t, 1, len(scheme.Entries))
+	entry := scheme.Entries[0]
+	assert.Equal(t, 3, entry.ID)
+	assert.Equal(t, adoptOSDUUID, entry.OsdUUID.String())
+	assert.Equal(t, config.Bluestore, entry.StoreType)
+	assert.Equal(t, 3, len(entry.Partitions))
+	block := entry.Partitions[config.BlockPartitionType]
+	assert.Equal(t, "sdb", block.Device)
+	assert.Equal(t, "disk-sdb", block.DiskUUID)
+	assert.Equal(t, "uuid-sdb3", block.PartitionUUID)
+	assert.Equal(t, 100000, block.SizeMB)
+	assert.True(t, isDeviceInUse("sdb", nameToUUID, scheme))
+
+	// the adopted osd is saved in the scheme of the node
+	saved, err := config.LoadScheme(agent.kv, config.GetConfigStoreName("node1"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(saved.Entries))
+
+	// the partitions of an osd purged from the cluster are reused
+	context = newAdoptTest(`{"osds":[]}`, label)
+	context.ConfigDir = configDir
+	context.Devices = []*sys.LocalDisk{{Name: "sdb", UUID: "disk-sdb", Type: sys.DiskType}, {Name: "sdb3", Parent: "sdb", Type: sys.PartType}}
+	agent = newAgent()
+	devices = newDevices()
+	scheme = config.NewPerfScheme()
+	err = agent.adoptLegacyDevices(context, devices, scheme, nameToUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(scheme.Entries))
+	assert.Equal(t, 3, len(devices.Entries))
+
+	// the id of the osd is in the osd map but the label does not match, the device is not wiped
+	context = newAdoptTest(osdDump, fmt.Sprintf(`{"osd_uuid": "%s", "ceph_fsid": "c5d0bc26-2d2f-4aa6-8e4f-bf4b0d1f7dd6", "whoami": "3"}`, adoptOSDUUID))
+	context.ConfigDir = configDir
+	context.Devices = []*sys.LocalDisk{{Name: "sdb", UUID: "disk-sdb", Type: sys.DiskType}, {Name: "sdb3", Parent: "sdb", Type: sys.PartType}}
+	agent = newAgent()
+	devices = newDevices()
+	err = agent.adoptLegacyDevices(context, devices, scheme, nameToUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(scheme.Entries))
+	assert.Equal(t, []string{"sdb"}, agent.skippedDevices)
+	assert.Equal(t, 2, len(devices.Entries))
+
+	// the label cannot be read, the device is not wiped either
+	context = newAdoptTest(osdDump, "")
+	context.ConfigDir = configDir
+	context.Devices = []*sys.LocalDisk{{Name: "sdb", UUID: "disk-sdb", Type: sys.DiskType}, {Name: "sdb3", Parent: "sdb", Type: sys.PartType}}
+	agent = newAgent()
+	devices = newDevices()
+	err = agent.adoptLegacyDevices(context, devices, scheme, nameToUUID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"sdb"}, agent.skippedDevices)
+	assert.Equal(t, 2, len(devices.Entries))
+}
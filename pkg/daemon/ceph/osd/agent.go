@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -58,6 +59,10 @@ type OsdAgent struct {
 	kv             *k8sutil.ConfigMapKVStore
 	configCounter  int32
 	osdsCompleted  chan struct{}
+	// pendingDevices are the devices being prepared, saved to resume an interrupted provisioning
+	pendingDevices map[string]config.PendingDevice
+	// skippedDevices are the devices left partially prepared and skipped by the resume policy
+	skippedDevices []string
 }
 
 type device struct {
@@ -156,12 +161,15 @@ func (a *OsdAgent) configureDevices(context *clusterd.Context, devices *DeviceOs
 		logger.Infof("skipping ceph-volume until the fast devices can be specified for the metadata")
 		cvSupported = false
 	}
+	if a.storeConfig.CacheDevice != "" && !cvSupported {
+		logger.Warningf("ignoring cache device %s since the osds are not configured with ceph-volume", a.storeConfig.CacheDevice)
+	}
 
 	var osds []oposd.OSDInfo
 	if devices == nil || len(devices.Entries) == 0 {
 		logger.Infof("no more devices to configure")
 		if cvSupported {
-			return getCephVolumeOSDs(context, a.cluster.Name)
+			return a.clusterCephVolumeOSDs(context)
 		}
 		return osds, nil
 	}
@@ -191,6 +199,9 @@ func (a *OsdAgent) configureDevices(context *clusterd.Context, devices *DeviceOs
 		if err != nil {
 			return osds, fmt.Errorf("failed to config osd %d. %+v", entry.ID, err)
 		}
+		if err := a.clearPendingDevice(path.Base(getDataDevice(entry))); err != nil {
+			return osds, err
+		}
 
 		succeeded++
 		osds = append(osds, *osd)
@@ -265,6 +276,11 @@ func (a *OsdAgent) getPartitionPerfScheme(context *clusterd.Context, devices *De
 		logger.Debugf("context.Device: %+v", device)
 	}
 
+	// adopt the osds of the cluster found on the devices when the scheme of the node was lost
+	if err := a.adoptLegacyDevices(context, devices, perfScheme, nameToUUID); err != nil {
+		return nil, skippedDevices, err
+	}
+
 	numDataNeeded := 0
 	var metadataEntry *DeviceOsdIDEntry
 
@@ -308,12 +324,13 @@ func (a *OsdAgent) getPartitionPerfScheme(context *clusterd.Context, devices *De
 			}
 
 			// register/create the OSD with ceph, which will assign it a cluster wide ID
-			osdID, osdUUID, err := registerOSD(context, a.cluster.Name)
+			osdID, osdUUID, err := a.registerDeviceOSD(context, name)
 			if err != nil {
 				return nil, nil, fmt.Errorf("failed to register OSD for device %s: %+v", name, err)
 			}
 
-			schemeEntry := config.NewPerfSchemeEntry(a.storeConfig.StoreType)
+			storeConfig := a.deviceStoreConfig(mapping.Config)
+			schemeEntry := config.NewPerfSchemeEntry(storeConfig.StoreType)
 			schemeEntry.ID = *osdID
 			schemeEntry.OsdUUID = *osdUUID
 
@@ -323,7 +340,7 @@ func (a *OsdAgent) getPartitionPerfScheme(context *clusterd.Context, devices *De
 				mapping.Data = *osdID
 
 				// populate the perf partition scheme entry with distributed partition details
-				err := config.PopulateDistributedPerfSchemeEntry(schemeEntry, name, perfScheme.Metadata, a.storeConfig)
+				err := config.PopulateDistributedPerfSchemeEntry(schemeEntry, name, perfScheme.Metadata, storeConfig)
 				if err != nil {
 					return nil, nil, fmt.Errorf("failed to create distributed perf scheme entry for %s: %+v", name, err)
 				}
@@ -335,7 +352,7 @@ func (a *OsdAgent) getPartitionPerfScheme(context *clusterd.Context, devices *De
 				mapping.Metadata = []int{*osdID}
 
 				// populate the perf partition scheme entry with collocated partition details
-				err := config.PopulateCollocatedPerfSchemeEntry(schemeEntry, name, a.storeConfig)
+				err := config.PopulateCollocatedPerfSchemeEntry(schemeEntry, name, storeConfig)
 				if err != nil {
 					return nil, nil, fmt.Errorf("failed to create collocated perf scheme entry for %s: %+v", name, err)
 				}
@@ -348,6 +365,15 @@ func (a *OsdAgent) getPartitionPerfScheme(context *clusterd.Context, devices *De
 	return perfScheme, skippedDevices, nil
 }
 
+// deviceStoreConfig returns the store config of the node with the store type overridden by the device if specified
+func (a *OsdAgent) deviceStoreConfig(device DesiredDevice) config.StoreConfig {
+	storeConfig := a.storeConfig
+	if device.StoreType != "" {
+		storeConfig.StoreType = device.StoreType
+	}
+	return storeConfig
+}
+
 // determines if the given device name is already in use with existing/committed partitions
 func isDeviceInUse(name string, nameToUUID map[string]string, scheme *config.PerfScheme) bool {
 	parts := findPartitionsForDevice(name, nameToUUID, scheme)
@@ -525,6 +551,9 @@ func getOSDInfo(clusterName string, config *osdConfig, devPartInfo *devicePartIn
 	if devPartInfo != nil {
 		osd.DevicePartUUID = devPartInfo.deviceUUID
 	}
+	if config.partitionScheme != nil {
+		osd.Device = getDataDevice(config.partitionScheme)
+	}
 
 	if isFilestore(config) {
 		osd.Journal = getOSDJournalPath(config.rootPath)
@@ -532,6 +561,16 @@ func getOSDInfo(clusterName string, config *osdConfig, devPartInfo *devicePartIn
 	return osd
 }
 
+// getDataDevice returns the device holding the data partition of the osd
+func getDataDevice(entry *config.PerfSchemeEntry) string {
+	for _, t := range []config.PartitionType{config.BlockPartitionType, config.FilestoreDataPartitionType} {
+		if p, ok := entry.Partitions[t]; ok {
+			return path.Join("/dev", p.Device)
+		}
+	}
+	return ""
+}
+
 func (a *OsdAgent) removeOSDConfigDir(configRoot string, id int) error {
 	// delete the OSD's local storage
 	osdRootDir := getOSDRootDir(configRoot, id)
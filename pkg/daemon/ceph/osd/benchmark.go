@@ -0,0 +1,123 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/util/sys"
+)
+
+const (
+	fioCmd = "fio"
+	// the benchmark is short so it does not delay the provisioning much
+	benchmarkRuntimeSeconds = 10
+)
+
+type fioResult struct {
+	Jobs []struct {
+		Read  fioStats `json:"read"`
+		Write fioStats `json:"write"`
+	} `json:"jobs"`
+}
+
+type fioStats struct {
+	IOPS  float64 `json:"iops"`
+	LatNs struct {
+		Mean float64 `json:"mean"`
+	} `json:"lat_ns"`
+}
+
+// benchmarkDevices runs a short random 4k IO benchmark on the new devices. The benchmark writes to the devices, so
+// only the blank devices that are about to be provisioned are benchmarked. A failed benchmark is logged and skipped.
+func benchmarkDevices(context *clusterd.Context, devices *DeviceOsdMapping) []oposd.DeviceBenchmark {
+	var names []string
+	for name, device := range devices.Entries {
+		if device.Data != unassignedOSDID {
+			continue
+		}
+		// the legacy partitions flag is also set on the blank devices, so the partitions are listed again to keep
+		// the devices with the partitions of a previous osd
+		partitions, _, err := sys.GetDevicePartitions(name, context.Executor)
+		if err != nil {
+			logger.Warningf("skipping the benchmark of device %s. %+v", name, err)
+			continue
+		}
+		if len(partitions) > 0 {
+			logger.Infof("skipping the benchmark of device %s with %d partitions", name, len(partitions))
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var benchmarks []oposd.DeviceBenchmark
+	for _, name := range names {
+		logger.Infof("benchmarking device %s for %d seconds", name, benchmarkRuntimeSeconds)
+		benchmark, err := benchmarkDevice(context, name)
+		if err != nil {
+			logger.Warningf("failed to benchmark device %s. %+v", name, err)
+			continue
+		}
+		benchmarks = append(benchmarks, *benchmark)
+	}
+	return benchmarks
+}
+
+func benchmarkDevice(context *clusterd.Context, name string) (*oposd.DeviceBenchmark, error) {
+	args := []string{
+		"--name=rook-benchmark",
+		"--filename=" + path.Join("/dev", name),
+		"--direct=1",
+		"--ioengine=libaio",
+		"--rw=randrw",
+		"--bs=4k",
+		"--iodepth=32",
+		"--time_based",
+		"--runtime=" + strconv.Itoa(benchmarkRuntimeSeconds),
+		"--output-format=json",
+	}
+	output, err := context.Executor.ExecuteCommandWithOutput(false, "", fioCmd, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed fio. %+v", err)
+	}
+
+	var result fioResult
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse fio result. %+v. raw: %s", err, output)
+	}
+	if len(result.Jobs) == 0 {
+		return nil, fmt.Errorf("no job in fio result")
+	}
+
+	job := result.Jobs[0]
+	return &oposd.DeviceBenchmark{
+		Device:         name,
+		ReadIOPS:       job.Read.IOPS,
+		WriteIOPS:      job.Write.IOPS,
+		ReadLatencyUs:  job.Read.LatNs.Mean / 1000,
+		WriteLatencyUs: job.Write.LatNs.Mean / 1000,
+		Time:           time.Now().UTC(),
+	}, nil
+}
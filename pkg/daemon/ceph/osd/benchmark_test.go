@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fioTestResult = `{
+  "fio version" : "fio-3.1",
+  "jobs" : [
+    {
+      "jobname" : "rook-benchmark",
+      "read" : {
+        "io_bytes" : 73728000,
+        "iops" : 1800.5,
+        "lat_ns" : {"min" : 101000, "max" : 90000000, "mean" : 8800000.0}
+      },
+      "write" : {
+        "io_bytes" : 73700000,
+        "iops" : 1799.2,
+        "lat_ns" : {"min" : 99000, "max" : 80000000, "mean" : 9000000.0}
+      }
+    }
+  ]
+}`
+
+func TestBenchmarkDevices(t *testing.T) {
+	var benchmarked []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, name string, command string, args ...string) (string, error) {
+			if command == "fio" {
+				benchmarked = append(benchmarked, args[1])
+				if args[1] == "--filename=/dev/sdc" {
+					return "", fmt.Errorf("device is busy")
+				}
+				return fioTestResult, nil
+			}
+			if command == "lsblk" {
+				if args[0] == "/dev/sde" {
+					return `NAME="sde" SIZE="65" TYPE="disk" PKNAME=""
+NAME="sde1" SIZE="30" TYPE="part" PKNAME="sde"`, nil
+				}
+				return "", nil
+			}
+			if command == "udevadm" {
+				return "PARTNAME=ROOK-OSD3-WAL", nil
+			}
+			return "", fmt.Errorf("unknown command %s %+v", command, args)
+		},
+	}
+
+	context := &clusterd.Context{Executor: executor}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sdb": {Data: unassignedOSDID, LegacyPartitionsFound: true},
+		"sdc": {Data: unassignedOSDID},
+		"sdd": {Data: 3},
+		"sde": {Data: unassignedOSDID, LegacyPartitionsFound: true},
+	}}
+
+	// only the new blank devices are benchmarked and the failed benchmarks are skipped
+	benchmarks := benchmarkDevices(context, devices)
+	assert.Equal(t, []string{"--filename=/dev/sdb", "--filename=/dev/sdc"}, benchmarked)
+	require.Equal(t, 1, len(benchmarks))
+	assert.Equal(t, "sdb", benchmarks[0].Device)
+	assert.Equal(t, 1800.5, benchmarks[0].ReadIOPS)
+	assert.Equal(t, 1799.2, benchmarks[0].WriteIOPS)
+	assert.Equal(t, float64(8800), benchmarks[0].ReadLatencyUs)
+	assert.Equal(t, float64(9000), benchmarks[0].WriteLatencyUs)
+}
+
+func TestBenchmarkAllDevices(t *testing.T) {
+	var benchmarked []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, name string, command string, args ...string) (string, error) {
+			switch command {
+			case "fio":
+				benchmarked = append(benchmarked, args[1])
+				return fioTestResult, nil
+			case "lsblk":
+				if args[0] == "/dev/sdb" {
+					// the partitions of a previous rook osd
+					return `NAME="sdb" SIZE="65" TYPE="disk" PKNAME=""
+NAME="sdb1" SIZE="30" TYPE="part" PKNAME="sdb"`, nil
+				}
+				return "", nil
+			case "udevadm":
+				if args[2] == "/dev/sdb1" {
+					return "PARTNAME=ROOK-OSD0-BLOCK", nil
+				}
+				return "", nil
+			}
+			return "", fmt.Errorf("unknown command %s %+v", command, args)
+		},
+	}
+
+	context := &clusterd.Context{Executor: executor}
+	context.Devices = []*sys.LocalDisk{{Name: "sda"}, {Name: "sdb"}, {Name: "sdc"}}
+
+	// with useAllDevices, the blank devices are benchmarked but not the device with the partitions of an osd
+	devices, err := getAvailableDevices(context, []DesiredDevice{{Name: "all"}}, "")
+	require.Nil(t, err)
+	require.Equal(t, 3, len(devices.Entries))
+	benchmarks := benchmarkDevices(context, devices)
+	assert.Equal(t, []string{"--filename=/dev/sda", "--filename=/dev/sdc"}, benchmarked)
+	assert.Equal(t, 2, len(benchmarks))
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+)
+
+const (
+	cachePartitionLabelFmt = "ROOK-CACHE-OSD%s"
+	cacheLVSuffix          = "-cache"
+	// the cache pool metadata is allocated on the cache partition next to the cached data
+	cachePoolExtents = "90%PVS"
+)
+
+// partitionExists returns whether the cache partition was already created, by a provisioning interrupted before the
+// cache was attached
+var partitionExists = func(partition string) bool {
+	_, err := os.Stat(partition)
+	return err == nil
+}
+
+// validateCacheMode returns the dm-cache mode to use for the OSDs, writethrough by default
+func validateCacheMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return config.CacheModeWritethrough, nil
+	case config.CacheModeWritethrough, config.CacheModeWriteback:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid cache mode %q. must be %s or %s", mode, config.CacheModeWritethrough, config.CacheModeWriteback)
+}
+
+// attachCache adds a dm-cache layer to the logical volumes created by ceph-volume on the data device. For each OSD,
+// a partition is created on the cache device and added to the volume group of the OSD to hold its cache pool. The
+// steps already done by an interrupted provisioning are skipped, so the attach can be retried.
+func (a *OsdAgent) attachCache(context *clusterd.Context, device string) error {
+	mode, err := validateCacheMode(a.storeConfig.CacheMode)
+	if err != nil {
+		return err
+	}
+	sizeMB := a.storeConfig.CacheSizeMB
+	if sizeMB <= 0 {
+		sizeMB = config.CacheDefaultSizeMB
+	}
+
+	result, err := context.Executor.ExecuteCommandWithOutput(false, "", cephVolumeCmd, "lvm", "list", device, "--format", "json")
+	if err != nil {
+		return fmt.Errorf("failed to list the osds on device %s. %+v", device, err)
+	}
+	var cephVolumeResult map[string][]osdInfo
+	if err := json.Unmarshal([]byte(result), &cephVolumeResult); err != nil {
+		return fmt.Errorf("failed to parse the osds on device %s. %+v", device, err)
+	}
+
+	cacheDevice := path.Join("/dev", a.storeConfig.CacheDevice)
+	for id, lvs := range cephVolumeResult {
+		for _, lv := range lvs {
+			// only the lv holding the osd data is cached, not the filestore journal
+			if lv.Type != "block" && lv.Type != "data" {
+				continue
+			}
+
+			if isCached(context, lv) {
+				logger.Infof("osd %s on device %s is already cached", id, device)
+				continue
+			}
+
+			label := fmt.Sprintf(cachePartitionLabelFmt, id)
+			partition := path.Join("/dev/disk/by-partlabel", label)
+			if !partitionExists(partition) {
+				logger.Infof("creating %s cache of %d MB for osd %s on device %s", mode, sizeMB, id, cacheDevice)
+				if err := context.Executor.ExecuteCommand(false, "", "sgdisk",
+					fmt.Sprintf("--new=0:0:+%dM", sizeMB), fmt.Sprintf("--change-name=0:%s", label), cacheDevice); err != nil {
+					return fmt.Errorf("failed to create cache partition for osd %s on %s. %+v", id, cacheDevice, err)
+				}
+				if err := context.Executor.ExecuteCommand(false, "", "udevadm", "settle"); err != nil {
+					logger.Warningf("failed to wait for the cache partition of osd %s. %+v", id, err)
+				}
+			}
+
+			vg, err := context.Executor.ExecuteCommandWithOutput(false, "", "pvs", "--noheadings", "-o", "vg_name", partition)
+			if err != nil {
+				if err := context.Executor.ExecuteCommand(false, "", "pvcreate", partition); err != nil {
+					return fmt.Errorf("failed to create physical volume %s. %+v", partition, err)
+				}
+			}
+			if strings.TrimSpace(vg) != lv.VGName {
+				if err := context.Executor.ExecuteCommand(false, "", "vgextend", lv.VGName, partition); err != nil {
+					return fmt.Errorf("failed to extend volume group %s with %s. %+v", lv.VGName, partition, err)
+				}
+			}
+			if err := context.Executor.ExecuteCommand(false, "", "lvcreate", "--type", "cache", "--cachemode", mode,
+				"-l", cachePoolExtents, "-n", lv.LVName+cacheLVSuffix, fmt.Sprintf("%s/%s", lv.VGName, lv.LVName), partition); err != nil {
+				return fmt.Errorf("failed to attach cache %s to osd %s. %+v", partition, id, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isCached returns whether the logical volume of the osd is already cached
+func isCached(context *clusterd.Context, lv osdInfo) bool {
+	segType, err := context.Executor.ExecuteCommandWithOutput(false, "", "lvs", "--noheadings", "-o", "segtype", fmt.Sprintf("%s/%s", lv.VGName, lv.LVName))
+	return err == nil && strings.TrimSpace(segType) == "cache"
+}
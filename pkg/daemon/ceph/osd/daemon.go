@@ -21,8 +21,8 @@ import (
 	"os"
 	"path"
 	"regexp"
-
 	"strings"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	"github.com/rook/rook/pkg/clusterd"
@@ -81,6 +81,8 @@ func RunFilestoreOnDevice(context *clusterd.Context, mountSourcePath, mountPath
 }
 
 func Provision(context *clusterd.Context, agent *OsdAgent) error {
+	start := time.Now()
+
 	// set the initial orchestration status
 	status := oposd.OrchestrationStatus{Status: oposd.OrchestrationStatusComputingDiff}
 	if err := oposd.UpdateNodeStatus(agent.kv, agent.nodeName, status); err != nil {
@@ -103,6 +105,11 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 	}
 	context.Devices = rawDevices
 
+	// apply the resume policy to the devices left partially prepared by an interrupted provisioning
+	if err := agent.resolvePendingDevices(context); err != nil {
+		return fmt.Errorf("failed to resume the devices left partially prepared. %+v", err)
+	}
+
 	logger.Infof("creating and starting the osds")
 
 	// determine the set of devices that can/should be used for OSDs.
@@ -110,6 +117,13 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 	if err != nil {
 		return fmt.Errorf("failed to get available devices. %+v", err)
 	}
+	if _, ok := devices.Entries[agent.storeConfig.CacheDevice]; ok {
+		// the cache device is partitioned for the osd caches, it cannot hold data
+		delete(devices.Entries, agent.storeConfig.CacheDevice)
+	}
+	for _, name := range agent.skippedDevices {
+		delete(devices.Entries, name)
+	}
 
 	// determine the set of removed OSDs and the node's crush name (if needed)
 	removedDevicesScheme, _, err := getRemovedDevices(agent)
@@ -123,6 +137,15 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 		return err
 	}
 
+	// benchmark the new devices before they are wiped by the provisioning
+	var benchmarks []oposd.DeviceBenchmark
+	if agent.storeConfig.Benchmark {
+		benchmarks = benchmarkDevices(context, devices)
+		if err := oposd.SaveDeviceBenchmarks(agent.kv, agent.nodeName, benchmarks); err != nil {
+			logger.Warningf("failed to save the device benchmarks. %+v", err)
+		}
+	}
+
 	// start the desired OSDs on devices
 	logger.Infof("configuring osd devices: %+v", devices)
 	deviceOSDs, err := agent.configureDevices(context, devices)
@@ -165,7 +188,13 @@ func Provision(context *clusterd.Context, agent *OsdAgent) error {
 	osds := append(deviceOSDs, dirOSDs...)
 
 	// orchestration is completed, update the status
-	status = oposd.OrchestrationStatus{OSDs: osds, Status: oposd.OrchestrationStatusCompleted}
+	status = oposd.OrchestrationStatus{
+		OSDs:              osds,
+		Status:            oposd.OrchestrationStatusCompleted,
+		ProvisionDuration: time.Since(start).Round(time.Second).String(),
+		Benchmarks:        benchmarks,
+		SkippedDevices:    agent.skippedDevices,
+	}
 	if err := oposd.UpdateNodeStatus(agent.kv, agent.nodeName, status); err != nil {
 		return err
 	}
@@ -63,6 +63,7 @@ type osdConfig struct {
 	partitionScheme *config.PerfSchemeEntry
 	kv              *k8sutil.ConfigMapKVStore
 	storeName       string
+	resources       OSDResources
 }
 
 type Device struct {
@@ -76,6 +77,8 @@ type DesiredDevice struct {
 	Name          string
 	OSDsPerDevice int
 	IsFilter      bool
+	// StoreType overrides the store type of the node for the osds of the device
+	StoreType string
 }
 
 type DeviceOsdMapping struct {
@@ -454,14 +457,15 @@ func getStoreSettings(cfg *osdConfig) (map[string]string, error) {
 	return settings, nil
 }
 
-func WriteConfigFile(context *clusterd.Context, cluster *cephconfig.ClusterInfo, kv *k8sutil.ConfigMapKVStore, osdID int, device bool, storeConfig config.StoreConfig, nodeName, location string) error {
+func WriteConfigFile(context *clusterd.Context, cluster *cephconfig.ClusterInfo, kv *k8sutil.ConfigMapKVStore, osdID int, device bool,
+	storeConfig config.StoreConfig, resources OSDResources, nodeName, location string) error {
 	scheme, err := config.LoadScheme(kv, config.GetConfigStoreName(nodeName))
 	if err != nil {
 		return fmt.Errorf("failed to load partition scheme: %+v", err)
 	}
 
 	cfg := &osdConfig{id: osdID, configRoot: context.ConfigDir, rootPath: getOSDRootDir(context.ConfigDir, osdID),
-		storeConfig: storeConfig, kv: kv, storeName: config.GetConfigStoreName(nodeName)}
+		storeConfig: storeConfig, kv: kv, storeName: config.GetConfigStoreName(nodeName), resources: resources}
 
 	// if a device, search the osd scheme for the requested osd id
 	for _, entry := range scheme.Entries {
@@ -522,6 +526,27 @@ func writeConfigFile(cfg *osdConfig, context *clusterd.Context, cluster *cephcon
 		return fmt.Errorf("failed to read store settings. %+v", err)
 	}
 
+	// the tuning profile adds settings sized for the resources of the osd
+	tuning, err := getTuningSettings(cfg.storeConfig.Tuning, cfg.resources)
+	if err != nil {
+		return err
+	}
+	for k, v := range tuning {
+		settings[k] = v
+	}
+	for k, v := range getHugePagesSettings(cfg.resources) {
+		settings[k] = v
+	}
+
+	// the mclock profile schedules the client and recovery operations
+	mclock, err := getMclockSettings(cfg.storeConfig.MclockProfile)
+	if err != nil {
+		return err
+	}
+	for k, v := range mclock {
+		settings[k] = v
+	}
+
 	// write the OSD config file to disk
 	_, err = cephconfig.GenerateConfigFile(context, cluster, cfg.rootPath, fmt.Sprintf("osd.%d", cfg.id),
 		getOSDKeyringPath(cfg.rootPath), cephConfig, settings)
@@ -643,6 +668,11 @@ func addOSDToCrushMap(context *clusterd.Context, config *osdConfig, clusterName,
 	// weight is ratio of (size in KB) / (1 GB)
 	weight := float64(totalBytes/1024) / 1073741824.0
 	weight, _ = strconv.ParseFloat(fmt.Sprintf("%.4f", weight), 64)
+	if config.storeConfig.WeightRampUpStep > 0 {
+		// the operator ramps up the weight of the osd gradually to limit the rebalance
+		logger.Infof("adding osd.%d with a weight of 0 instead of %.4f for the ramp up of its weight", osdID, weight)
+		weight = 0
+	}
 
 	osdEntity := fmt.Sprintf("osd.%d", osdID)
 	logger.Infof("adding %s (%s), bytes: %d, weight: %.4f, to crush map at '%s'",
@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+)
+
+// mclockShares are the reservation (the minimum IOPS), the weight (the share of the IOPS above the reservations)
+// and the limit (the maximum IOPS, 0 is unlimited) of a class of operations in the mclock op queue
+type mclockShares struct {
+	reservation int
+	weight      int
+	limit       int
+}
+
+// the shares of the client and recovery operations of the mclock profiles
+var mclockProfiles = map[string]struct{ client, recovery mclockShares }{
+	config.MclockHighClientOps:   {client: mclockShares{1000, 900, 0}, recovery: mclockShares{100, 100, 500}},
+	config.MclockBalanced:        {client: mclockShares{500, 500, 0}, recovery: mclockShares{500, 500, 0}},
+	config.MclockHighRecoveryOps: {client: mclockShares{500, 300, 0}, recovery: mclockShares{1000, 700, 0}},
+}
+
+// getMclockSettings returns the ceph settings scheduling the operations of an osd with the mclock op queue
+// according to the profile. The mclock_client queue is still experimental in mimic and nautilus.
+func getMclockSettings(profile string) (map[string]string, error) {
+	settings := map[string]string{}
+	if profile == "" {
+		return settings, nil
+	}
+	shares, ok := mclockProfiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("invalid mclock profile %q. must be one of %s, %s or %s",
+			profile, config.MclockHighClientOps, config.MclockBalanced, config.MclockHighRecoveryOps)
+	}
+
+	// the replication of the writes must go through the queue for the shares to apply to all the IO of the osd
+	settings["osd op queue"] = "mclock_client"
+	settings["osd op queue cut off"] = "high"
+	for class, s := range map[string]mclockShares{"client_op": shares.client, "recov": shares.recovery} {
+		settings[fmt.Sprintf("osd op queue mclock %s res", class)] = strconv.Itoa(s.reservation)
+		settings[fmt.Sprintf("osd op queue mclock %s wgt", class)] = strconv.Itoa(s.weight)
+		settings[fmt.Sprintf("osd op queue mclock %s lim", class)] = strconv.Itoa(s.limit)
+	}
+	return settings, nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMclockSettings(t *testing.T) {
+	// no setting without a profile
+	settings, err := getMclockSettings("")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(settings))
+
+	settings, err = getMclockSettings(config.MclockHighClientOps)
+	assert.Nil(t, err)
+	assert.Equal(t, "mclock_client", settings["osd op queue"])
+	assert.Equal(t, "high", settings["osd op queue cut off"])
+	assert.Equal(t, "1000", settings["osd op queue mclock client_op res"])
+	assert.Equal(t, "900", settings["osd op queue mclock client_op wgt"])
+	assert.Equal(t, "0", settings["osd op queue mclock client_op lim"])
+	assert.Equal(t, "500", settings["osd op queue mclock recov lim"])
+
+	// the recovery gets the larger share with the high recovery profile
+	settings, err = getMclockSettings(config.MclockHighRecoveryOps)
+	assert.Nil(t, err)
+	assert.Equal(t, "700", settings["osd op queue mclock recov wgt"])
+	assert.Equal(t, "300", settings["osd op queue mclock client_op wgt"])
+
+	_, err = getMclockSettings("fast")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/util/sys"
+)
+
+// ValidateResumePolicy checks the policy applied to the devices left partially prepared by an interrupted provisioning
+func ValidateResumePolicy(policy string) error {
+	switch policy {
+	case "", config.ResumePolicyResume, config.ResumePolicyClean, config.ResumePolicySkip:
+		return nil
+	}
+	return fmt.Errorf("invalid resume policy %q. must be %s, %s or %s", policy, config.ResumePolicyResume, config.ResumePolicyClean, config.ResumePolicySkip)
+}
+
+func (a *OsdAgent) resumePolicy() string {
+	if a.storeConfig.ResumePolicy == "" {
+		return config.ResumePolicyResume
+	}
+	return a.storeConfig.ResumePolicy
+}
+
+// resolvePendingDevices applies the resume policy to the devices that an interrupted provisioning was preparing.
+// The legacy devices resumed keep their pending entry to reuse the osd already registered in ceph.
+func (a *OsdAgent) resolvePendingDevices(context *clusterd.Context) error {
+	pending, err := config.LoadPendingDevices(a.kv, a.nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to load the pending devices. %+v", err)
+	}
+	a.pendingDevices = pending
+	if len(pending) == 0 {
+		return nil
+	}
+
+	scheme, err := config.LoadScheme(a.kv, config.GetConfigStoreName(a.nodeName))
+	if err != nil {
+		return fmt.Errorf("failed to load partition scheme: %+v", err)
+	}
+	nameToUUID := map[string]string{}
+	existing := map[string]bool{}
+	for _, disk := range context.Devices {
+		existing[disk.Name] = true
+		if disk.UUID != "" {
+			nameToUUID[disk.Name] = disk.UUID
+		}
+	}
+
+	var names []string
+	for name := range pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	policy := a.resumePolicy()
+	logger.Infof("devices %v were left partially prepared by an interrupted provisioning. resume policy is %s", names, policy)
+	for _, name := range names {
+		device := pending[name]
+		if !existing[name] {
+			logger.Warningf("device %s left partially prepared is not found anymore", name)
+			delete(pending, name)
+			continue
+		}
+		if !device.CephVolume && isDeviceInUse(name, nameToUUID, scheme) {
+			// the partitions were committed, the osd is initialized again by the provisioning
+			logger.Infof("resuming osd %d on the committed partitions of device %s", device.ID, name)
+			delete(pending, name)
+			continue
+		}
+
+		switch policy {
+		case config.ResumePolicySkip:
+			logger.Warningf("skipping device %s left partially prepared", name)
+			a.skippedDevices = append(a.skippedDevices, name)
+		case config.ResumePolicyClean:
+			if err := a.cleanPendingDevice(context, name, device); err != nil {
+				return err
+			}
+			delete(pending, name)
+		default:
+			if device.CephVolume {
+				if err := a.resumeCVDevice(context, name, device); err != nil {
+					return err
+				}
+				delete(pending, name)
+			} else {
+				logger.Infof("device %s will be partitioned again for osd %d", name, device.ID)
+			}
+		}
+	}
+
+	return a.savePendingDevices()
+}
+
+// resumeCVDevice keeps the osds prepared by ceph-volume before the interruption and attaches their cache if it was not
+// attached. ceph-volume cannot complete the preparation of an osd, the device is wiped to prepare it again if any of its
+// osds is incomplete.
+func (a *OsdAgent) resumeCVDevice(context *clusterd.Context, name string, device config.PendingDevice) error {
+	osds, err := getCephVolumeDeviceOSDs(context, name)
+	if err != nil {
+		logger.Infof("no osd found on device %s. %+v", name, err)
+	}
+
+	complete := len(osds) > 0 && len(osds) >= device.OSDs
+	for id, lvs := range osds {
+		if !isCephVolumeOSDComplete(context, lvs) {
+			logger.Infof("osd %s on device %s is incomplete", id, name)
+			complete = false
+		}
+	}
+	if complete {
+		logger.Infof("keeping the %d osds prepared on device %s before the interruption", len(osds), name)
+		if device.Cache {
+			// the provisioning may have been interrupted before the cache was attached
+			if err := a.attachCache(context, path.Join("/dev", name)); err != nil {
+				return fmt.Errorf("failed to attach cache to device %s. %+v", name, err)
+			}
+		}
+		return nil
+	}
+	return a.cleanPendingDevice(context, name, device)
+}
+
+// cleanPendingDevice purges the osds registered in ceph for the device and wipes it. The osds were never started,
+// they hold no data.
+func (a *OsdAgent) cleanPendingDevice(context *clusterd.Context, name string, device config.PendingDevice) error {
+	var ids []int
+	if device.CephVolume {
+		osds, err := getCephVolumeDeviceOSDs(context, name)
+		if err != nil {
+			logger.Infof("no osd found on device %s. %+v", name, err)
+		}
+		for id := range osds {
+			var osdID int
+			if _, err := fmt.Sscanf(id, "%d", &osdID); err == nil {
+				ids = append(ids, osdID)
+			}
+		}
+	} else if device.ID >= 0 {
+		ids = append(ids, device.ID)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		logger.Infof("purging osd %d of device %s left partially prepared", id, name)
+		if err := client.OSDPurge(context, a.cluster.Name, id); err != nil {
+			return err
+		}
+	}
+
+	logger.Infof("wiping device %s left partially prepared", name)
+	if device.CephVolume {
+		if err := context.Executor.ExecuteCommand(false, "", cephVolumeCmd, "lvm", "zap", "--destroy", path.Join("/dev", name)); err != nil {
+			return fmt.Errorf("failed to zap device %s. %+v", name, err)
+		}
+		return nil
+	}
+	return sys.RemovePartitions(name, context.Executor)
+}
+
+// registerDeviceOSD registers the osd of the legacy partitions of a device. The osd registered by an interrupted
+// provisioning is reused, ceph returns the id of the osd already created with the same uuid.
+func (a *OsdAgent) registerDeviceOSD(context *clusterd.Context, name string) (*int, *uuid.UUID, error) {
+	if device, ok := a.pendingDevices[name]; ok && !device.CephVolume && device.ID >= 0 {
+		if osdUUID, err := uuid.Parse(device.UUID); err == nil {
+			osdID, err := createOSD(context, a.cluster.Name, osdUUID)
+			if err == nil {
+				logger.Infof("reusing osd %d registered for device %s by the interrupted provisioning", osdID, name)
+				return &osdID, &osdUUID, nil
+			}
+			logger.Warningf("failed to reuse osd %d for device %s. %+v", device.ID, name, err)
+		}
+	}
+
+	osdID, osdUUID, err := registerOSD(context, a.cluster.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := a.setPendingDevice(name, config.PendingDevice{ID: *osdID, UUID: osdUUID.String()}); err != nil {
+		return nil, nil, err
+	}
+	return osdID, osdUUID, nil
+}
+
+// setPendingDevice saves the device before it is modified
+func (a *OsdAgent) setPendingDevice(name string, device config.PendingDevice) error {
+	if a.pendingDevices == nil {
+		a.pendingDevices = map[string]config.PendingDevice{}
+	}
+	a.pendingDevices[name] = device
+	return a.savePendingDevices()
+}
+
+// clearPendingDevice forgets the device once its osds are prepared
+func (a *OsdAgent) clearPendingDevice(name string) error {
+	if _, ok := a.pendingDevices[name]; !ok {
+		return nil
+	}
+	delete(a.pendingDevices, name)
+	return a.savePendingDevices()
+}
+
+func (a *OsdAgent) savePendingDevices() error {
+	if err := config.SavePendingDevices(a.kv, a.nodeName, a.pendingDevices); err != nil {
+		return fmt.Errorf("failed to save the pending devices. %+v", err)
+	}
+	return nil
+}
+
+func getCephVolumeDeviceOSDs(context *clusterd.Context, name string) (map[string][]osdInfo, error) {
+	result, err := context.Executor.ExecuteCommandWithOutput(false, "", cephVolumeCmd, "lvm", "list", path.Join("/dev", name), "--format", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the osds on device %s. %+v", name, err)
+	}
+	var osds map[string][]osdInfo
+	if err := json.Unmarshal([]byte(result), &osds); err != nil {
+		return nil, fmt.Errorf("failed to parse the osds on device %s. %+v", name, err)
+	}
+	return osds, nil
+}
+
+// isCephVolumeOSDComplete checks that the bluestore label was written on the block lv of the osd, or that the data
+// and journal lvs of a filestore osd were both created
+func isCephVolumeOSDComplete(context *clusterd.Context, lvs []osdInfo) bool {
+	var data, journal bool
+	for _, lv := range lvs {
+		switch lv.Type {
+		case "block":
+			_, err := context.Executor.ExecuteCommandWithOutput(false, "", "ceph-bluestore-tool", "show-label", "--dev", lv.Path)
+			return err == nil
+		case "data":
+			data = true
+		case "journal":
+			journal = true
+		}
+	}
+	return data && journal
+}
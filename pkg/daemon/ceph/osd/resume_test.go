@@ -0,0 +1,178 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package osd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateResumePolicy(t *testing.T) {
+	assert.Nil(t, ValidateResumePolicy(""))
+	assert.Nil(t, ValidateResumePolicy("resume"))
+	assert.Nil(t, ValidateResumePolicy("clean"))
+	assert.Nil(t, ValidateResumePolicy("skip"))
+	assert.NotNil(t, ValidateResumePolicy("wipe"))
+}
+
+func newResumeTest(labelWritten bool) (*clusterd.Context, *[]string) {
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			commands = append(commands, command+" "+strings.Join(args, " "))
+			return nil
+		},
+		MockExecuteCommandWithOutput: func(debug bool, name string, command string, args ...string) (string, error) {
+			switch {
+			case command == "ceph-volume" && args[1] == "list" && args[2] == "/dev/sdb":
+				return cephVolumeTestResult, nil
+			case command == "ceph-volume" && args[1] == "list":
+				return "", fmt.Errorf("no valid ceph lvm devices found")
+			case command == "ceph-bluestore-tool" && labelWritten:
+				return "{}", nil
+			}
+			return "", fmt.Errorf("unexpected command %s %+v", command, args)
+		},
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			// without the cluster, conf, keyring and format args
+			commands = append(commands, strings.Join(args[:len(args)-5], " "))
+			if args[0] == "osd" && args[1] == "create" {
+				return `{"osdid":7.0}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Executor: executor,
+		Devices:  []*sys.LocalDisk{{Name: "sdb"}, {Name: "sdc"}},
+	}
+	return context, &commands
+}
+
+func savePending(t *testing.T, a *OsdAgent, devices map[string]config.PendingDevice) {
+	assert.Nil(t, config.SavePendingDevices(a.kv, a.nodeName, devices))
+}
+
+func loadPending(t *testing.T, a *OsdAgent) map[string]config.PendingDevice {
+	devices, err := config.LoadPendingDevices(a.kv, a.nodeName)
+	assert.Nil(t, err)
+	return devices
+}
+
+func TestResumeCephVolumeDevice(t *testing.T) {
+	a := &OsdAgent{kv: mockKVStore(), nodeName: "node1", cluster: &cephconfig.ClusterInfo{Name: "rook-ceph"}}
+
+	// no device was left partially prepared
+	context, commands := newResumeTest(true)
+	assert.Nil(t, a.resolvePendingDevices(context))
+	assert.Equal(t, 0, len(*commands))
+
+	// the two osds of the device were prepared before the interruption, they are kept
+	savePending(t, a, map[string]config.PendingDevice{"sdb": {ID: -1, CephVolume: true, OSDs: 2}, "sdz": {ID: -1, CephVolume: true}})
+	assert.Nil(t, a.resolvePendingDevices(context))
+	assert.Equal(t, 0, len(*commands))
+	assert.Equal(t, 0, len(loadPending(t, a)))
+
+	// an osd is missing, its osds are purged and the device is wiped to prepare it again
+	savePending(t, a, map[string]config.PendingDevice{"sdb": {ID: -1, CephVolume: true, OSDs: 3}})
+	assert.Nil(t, a.resolvePendingDevices(context))
+	assert.Equal(t, []string{
+		"osd purge 0 --yes-i-really-mean-it",
+		"osd purge 1 --yes-i-really-mean-it",
+		"ceph-volume lvm zap --destroy /dev/sdb",
+	}, *commands)
+	assert.Equal(t, 0, len(loadPending(t, a)))
+
+	// the bluestore label was not written, the osds are incomplete
+	context, commands = newResumeTest(false)
+	savePending(t, a, map[string]config.PendingDevice{"sdb": {ID: -1, CephVolume: true, OSDs: 2}})
+	assert.Nil(t, a.resolvePendingDevices(context))
+	assert.Equal(t, 3, len(*commands))
+
+	// the osds were prepared but the provisioning was interrupted before their cache was attached
+	context, commands = newResumeTest(true)
+	a.storeConfig.CacheDevice = "nvme0n1"
+	savePending(t, a, map[string]config.PendingDevice{"sdb": {ID: -1, CephVolume: true, OSDs: 2, Cache: true}})
+	assert.Nil(t, a.resolvePendingDevices(context))
+	assert.Contains(t, *commands, "pvcreate /dev/disk/by-partlabel/ROOK-CACHE-OSD0")
+	assert.Contains(t, *commands, "pvcreate /dev/disk/by-partlabel/ROOK-CACHE-OSD1")
+	assert.Equal(t, 0, len(loadPending(t, a)))
+	a.storeConfig.CacheDevice = ""
+
+	// ceph-volume did not create any osd yet
+	context, commands = newResumeTest(true)
+	savePending(t, a, map[string]config.PendingDevice{"sdc": {ID: -1, CephVolume: true, OSDs: 1}})
+	assert.Nil(t, a.resolvePendingDevices(context))
+	assert.Equal(t, []string{"ceph-volume lvm zap --destroy /dev/sdc"}, *commands)
+}
+
+func TestResumeLegacyDevice(t *testing.T) {
+	a := &OsdAgent{kv: mockKVStore(), nodeName: "node1", cluster: &cephconfig.ClusterInfo{Name: "rook-ceph"}}
+	context, commands := newResumeTest(true)
+
+	// the legacy device keeps its entry to reuse the osd registered in ceph
+	osdUUID := "a8ee3a5c-1ad5-4c5a-ac2c-0c9ce3ec22ee"
+	savePending(t, a, map[string]config.PendingDevice{"sdc": {ID: 7, UUID: osdUUID}})
+	assert.Nil(t, a.resolvePendingDevices(context))
+	assert.Equal(t, 0, len(*commands))
+
+	osdID, registeredUUID, err := a.registerDeviceOSD(context, "sdc")
+	assert.Nil(t, err)
+	assert.Equal(t, 7, *osdID)
+	assert.Equal(t, osdUUID, registeredUUID.String())
+	assert.Equal(t, []string{"osd create " + osdUUID}, *commands)
+
+	// a new device is saved once its osd is registered, and forgotten once it is prepared
+	osdID, registeredUUID, err = a.registerDeviceOSD(context, "sdd")
+	assert.Nil(t, err)
+	pending := loadPending(t, a)
+	assert.Equal(t, config.PendingDevice{ID: *osdID, UUID: registeredUUID.String()}, pending["sdd"])
+	assert.Nil(t, a.clearPendingDevice("sdd"))
+	_, ok := loadPending(t, a)["sdd"]
+	assert.False(t, ok)
+
+	// the clean policy purges the registered osd and zaps the partitions
+	*commands = nil
+	a.storeConfig.ResumePolicy = config.ResumePolicyClean
+	assert.Nil(t, a.resolvePendingDevices(context))
+	assert.Equal(t, []string{
+		"osd purge 7 --yes-i-really-mean-it",
+		"sgdisk --zap-all /dev/sdc",
+		"sgdisk --clear --mbrtogpt /dev/sdc",
+	}, *commands)
+	assert.Equal(t, 0, len(loadPending(t, a)))
+}
+
+func TestSkipPendingDevice(t *testing.T) {
+	a := &OsdAgent{kv: mockKVStore(), nodeName: "node1", cluster: &cephconfig.ClusterInfo{Name: "rook-ceph"},
+		storeConfig: config.StoreConfig{ResumePolicy: config.ResumePolicySkip}}
+	context, commands := newResumeTest(false)
+
+	savePending(t, a, map[string]config.PendingDevice{"sdb": {ID: -1, CephVolume: true, OSDs: 1}})
+	assert.Nil(t, a.resolvePendingDevices(context))
+	assert.Equal(t, 0, len(*commands))
+	assert.Equal(t, []string{"sdb"}, a.skippedDevices)
+	// the device stays pending until the admin changes the policy
+	assert.Equal(t, 1, len(loadPending(t, a)))
+}
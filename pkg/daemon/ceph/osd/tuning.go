@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+)
+
+const (
+	// the share of the memory of the osd given to its caches, the rest is left for the other allocations of the daemon
+	memoryTargetRatio = 0.8
+	// below this target the bluestore caches are too small to be worth tuning
+	minMemoryTargetBytes = 1024 * 1024 * 1024
+	maxOpShards          = 16
+)
+
+// OSDResources are the resources available to an osd, used to size the settings of its tuning profile.
+// A zero value means the resource is not limited and the corresponding settings keep the Ceph defaults.
+type OSDResources struct {
+	MemoryBytes uint64
+	CPUs        int
+	HugePagesMB int
+}
+
+// getTuningSettings returns the ceph settings of the tuning profile for an osd with the given resources
+func getTuningSettings(profile string, resources OSDResources) (map[string]string, error) {
+	settings := map[string]string{}
+	switch profile {
+	case "":
+		return settings, nil
+	case config.TuningLatency, config.TuningThroughput, config.TuningBalanced:
+	default:
+		return nil, fmt.Errorf("invalid tuning profile %q. must be one of %s, %s or %s",
+			profile, config.TuningLatency, config.TuningThroughput, config.TuningBalanced)
+	}
+
+	target := uint64(float64(resources.MemoryBytes) * memoryTargetRatio)
+	if target >= minMemoryTargetBytes {
+		settings["bluestore cache autotune"] = "true"
+		settings["osd memory target"] = strconv.FormatUint(target, 10)
+	} else if resources.MemoryBytes > 0 {
+		logger.Warningf("memory limit of %d bytes is too low to tune the osd caches", resources.MemoryBytes)
+	}
+
+	switch profile {
+	case config.TuningLatency:
+		// keep more of the rocksdb and onode metadata in memory and spread the ops over one shard per cpu
+		settings["bluestore cache kv ratio"] = "0.4"
+		settings["bluestore cache meta ratio"] = "0.5"
+		if resources.CPUs > 0 {
+			settings["osd op num shards"] = strconv.Itoa(clampShards(resources.CPUs))
+			settings["osd op num threads per shard"] = "1"
+		}
+	case config.TuningThroughput:
+		// leave half of the cache for the data and use fewer shards with more threads to batch the ops
+		settings["bluestore cache kv ratio"] = "0.2"
+		settings["bluestore cache meta ratio"] = "0.3"
+		if resources.CPUs > 0 {
+			settings["osd op num shards"] = strconv.Itoa(clampShards(resources.CPUs / 2))
+			settings["osd op num threads per shard"] = "2"
+		}
+	}
+
+	return settings, nil
+}
+
+// getHugePagesSettings gives the hugepages of the osd to the SPDK memory of bluestore, which allocates it from the
+// hugetlbfs mount for the NVMe devices driven by SPDK. No other memory of the osd is allocated from the hugepages.
+func getHugePagesSettings(resources OSDResources) map[string]string {
+	settings := map[string]string{}
+	if resources.HugePagesMB > 0 {
+		settings["bluestore spdk mem"] = strconv.Itoa(resources.HugePagesMB)
+	}
+	return settings
+}
+
+func clampShards(shards int) int {
+	if shards < 1 {
+		return 1
+	}
+	if shards > maxOpShards {
+		return maxOpShards
+	}
+	return shards
+}
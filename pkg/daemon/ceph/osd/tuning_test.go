@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTuningSettings(t *testing.T) {
+	// no profile, no settings
+	settings, err := getTuningSettings("", OSDResources{MemoryBytes: 4 << 30, CPUs: 4})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(settings))
+
+	_, err = getTuningSettings("fast", OSDResources{})
+	assert.NotNil(t, err)
+
+	// the memory target is a share of the memory limit
+	settings, err = getTuningSettings(config.TuningBalanced, OSDResources{MemoryBytes: 4 << 30, CPUs: 4})
+	assert.Nil(t, err)
+	assert.Equal(t, "3435973836", settings["osd memory target"])
+	assert.Equal(t, "true", settings["bluestore cache autotune"])
+	assert.Equal(t, "", settings["osd op num shards"])
+
+	settings, err = getTuningSettings(config.TuningLatency, OSDResources{MemoryBytes: 4 << 30, CPUs: 32})
+	assert.Nil(t, err)
+	assert.Equal(t, "16", settings["osd op num shards"])
+	assert.Equal(t, "1", settings["osd op num threads per shard"])
+	assert.Equal(t, "0.5", settings["bluestore cache meta ratio"])
+
+	settings, err = getTuningSettings(config.TuningThroughput, OSDResources{MemoryBytes: 4 << 30, CPUs: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "1", settings["osd op num shards"])
+	assert.Equal(t, "2", settings["osd op num threads per shard"])
+
+	// without limits the memory and the shards keep the ceph defaults
+	settings, err = getTuningSettings(config.TuningLatency, OSDResources{})
+	assert.Nil(t, err)
+	assert.Equal(t, "", settings["osd memory target"])
+	assert.Equal(t, "", settings["osd op num shards"])
+	assert.Equal(t, "0.4", settings["bluestore cache kv ratio"])
+
+	// too little memory to tune the caches
+	settings, err = getTuningSettings(config.TuningBalanced, OSDResources{MemoryBytes: 512 << 20})
+	assert.Nil(t, err)
+	assert.Equal(t, "", settings["osd memory target"])
+}
+
+func TestHugePagesSettings(t *testing.T) {
+	assert.Equal(t, 0, len(getHugePagesSettings(OSDResources{})))
+	settings := getHugePagesSettings(OSDResources{HugePagesMB: 1024})
+	assert.Equal(t, "1024", settings["bluestore spdk mem"])
+}
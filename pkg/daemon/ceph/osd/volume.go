@@ -43,7 +43,7 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 	var err error
 	if len(devices.Entries) == 0 {
 		logger.Infof("no new devices to configure. returning devices already configured with ceph-volume.")
-		osds, err = getCephVolumeOSDs(context, a.cluster.Name)
+		osds, err = a.clusterCephVolumeOSDs(context)
 		if err != nil {
 			logger.Infof("failed to get devices already provisioned by ceph-volume. %+v", err)
 		}
@@ -59,22 +59,34 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 		return nil, fmt.Errorf("failed to initialize devices. %+v", err)
 	}
 
-	osds, err = getCephVolumeOSDs(context, a.cluster.Name)
+	osds, err = a.clusterCephVolumeOSDs(context)
 	return osds, err
 }
 
-func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceOsdMapping) error {
+// batchArgs returns the base ceph-volume args to prepare devices with the given store config
+func (a *OsdAgent) batchArgs(storeConfig config.StoreConfig) []string {
 	storeFlag := "--bluestore"
-	if a.storeConfig.StoreType == config.Filestore {
+	if storeConfig.StoreType == config.Filestore {
 		storeFlag = "--filestore"
 	}
 
-	baseArgs := []string{"lvm", "batch", "--prepare", storeFlag, "--yes"}
+	args := []string{"lvm", "batch", "--prepare", storeFlag, "--yes"}
 	if a.storeConfig.EncryptedDevice {
-		baseArgs = append(baseArgs, encryptedFlag)
+		args = append(args, encryptedFlag)
+	}
+	return args
+}
+
+func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceOsdMapping) error {
+
+	if a.storeConfig.CacheDevice != "" {
+		// fail before any device is prepared if the cache cannot be attached
+		if _, err := validateCacheMode(a.storeConfig.CacheMode); err != nil {
+			return err
+		}
 	}
 
-	batchArgs := append(baseArgs, []string{
+	batchArgs := append(a.batchArgs(a.storeConfig), []string{
 		osdsPerDeviceFlag,
 		strconv.Itoa(a.storeConfig.OSDsPerDevice),
 	}...)
@@ -84,6 +96,7 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 	metadataDeviceSpecified := false
 
 	configured := 0
+	var batchDevices []string
 	for name, device := range devices.Entries {
 		if device.LegacyPartitionsFound {
 			logger.Infof("skipping device %s configured with legacy rook osd", name)
@@ -93,13 +106,24 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 		if device.Data == -1 {
 			logger.Infof("configuring new device %s", name)
 			deviceArg := path.Join("/dev", name)
+			// remember the device until ceph-volume is done, to resume the provisioning if it is interrupted
+			pending := config.PendingDevice{ID: unassignedOSDID, CephVolume: true, OSDs: device.Config.OSDsPerDevice,
+				Cache: a.storeConfig.CacheDevice != ""}
+			if metadataDeviceSpecified {
+				pending.OSDs = a.storeConfig.OSDsPerDevice
+			}
+			if err := a.setPendingDevice(name, pending); err != nil {
+				return err
+			}
+
 			if metadataDeviceSpecified {
 				// the device will be configured as a batch at the end of the method
 				batchArgs = append(batchArgs, deviceArg)
+				batchDevices = append(batchDevices, name)
 				configured++
 			} else {
 				// execute ceph-volume immediately with the device-specific setting instead of batching up multiple devices together
-				immediateExecuteArgs := append(baseArgs, []string{
+				immediateExecuteArgs := append(a.batchArgs(a.deviceStoreConfig(device.Config)), []string{
 					deviceArg,
 					osdsPerDeviceFlag,
 					strconv.Itoa(device.Config.OSDsPerDevice),
@@ -109,6 +133,15 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 					return fmt.Errorf("failed ceph-volume. %+v", err)
 				}
 
+				if a.storeConfig.CacheDevice != "" {
+					if err := a.attachCache(context, deviceArg); err != nil {
+						return fmt.Errorf("failed to attach cache to device %s. %+v", name, err)
+					}
+				}
+
+				if err := a.clearPendingDevice(name); err != nil {
+					return err
+				}
 			}
 		} else {
 			logger.Infof("skipping device %s with osd %d already configured", name, device.Data)
@@ -119,6 +152,11 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 		if err := context.Executor.ExecuteCommand(false, "", cephVolumeCmd, batchArgs...); err != nil {
 			return fmt.Errorf("failed ceph-volume. %+v", err)
 		}
+		for _, name := range batchDevices {
+			if err := a.clearPendingDevice(name); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -140,7 +178,7 @@ func getCephVolumeSupported(context *clusterd.Context) (bool, error) {
 	return true, nil
 }
 
-func getCephVolumeOSDs(context *clusterd.Context, clusterName string) ([]oposd.OSDInfo, error) {
+func getCephVolumeOSDs(context *clusterd.Context, clusterName, clusterFSID string) ([]oposd.OSDInfo, error) {
 	result, err := context.Executor.ExecuteCommandWithOutput(false, "", cephVolumeCmd, "lvm", "list", "--format", "json")
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve ceph-volume results. %+v", err)
@@ -160,15 +198,23 @@ func getCephVolumeOSDs(context *clusterd.Context, clusterName string) ([]oposd.O
 			logger.Errorf("bad osd returned from ceph-volume: %s", name)
 			continue
 		}
-		var osdFSID string
+		var osdFSID, osdClusterFSID, device string
 		isFilestore := false
 		for _, osd := range osdInfo {
 			osdFSID = osd.Tags.OSDFSID
+			osdClusterFSID = osd.Tags.ClusterFSID
 			if osd.Type == "journal" {
 				isFilestore = true
 			}
+			if (osd.Type == "block" || osd.Type == "data") && len(osd.Devices) > 0 {
+				device = osd.Devices[0]
+			}
 		}
 		logger.Infof("osdInfo has %d elements. %+v", len(osdInfo), osdInfo)
+		if clusterFSID != "" && osdClusterFSID != "" && osdClusterFSID != clusterFSID {
+			logger.Warningf("skipping osd %d on device %s that belongs to cluster %s", id, device, osdClusterFSID)
+			continue
+		}
 
 		configDir := "/var/lib/rook/osd" + name
 		osd := oposd.OSDInfo{
@@ -180,6 +226,7 @@ func getCephVolumeOSDs(context *clusterd.Context, clusterName string) ([]oposd.O
 			UUID:                osdFSID,
 			CephVolumeInitiated: true,
 			IsFileStore:         isFilestore,
+			Device:              device,
 		}
 		osds = append(osds, osd)
 	}
@@ -189,14 +236,18 @@ func getCephVolumeOSDs(context *clusterd.Context, clusterName string) ([]oposd.O
 }
 
 type osdInfo struct {
-	Name string  `json:"name"`
-	Path string  `json:"path"`
-	Tags osdTags `json:"tags"`
+	Name    string   `json:"name"`
+	Path    string   `json:"path"`
+	LVName  string   `json:"lv_name"`
+	VGName  string   `json:"vg_name"`
+	Devices []string `json:"devices"`
+	Tags    osdTags  `json:"tags"`
 	// "data" or "journal" for filestore and "block" for bluestore
 	Type string `json:"type"`
 }
 
 type osdTags struct {
-	OSDFSID   string `json:"ceph.osd_fsid"`
-	Encrypted string `json:"ceph.encrypted"`
+	OSDFSID     string `json:"ceph.osd_fsid"`
+	ClusterFSID string `json:"ceph.cluster_fsid"`
+	Encrypted   string `json:"ceph.encrypted"`
 }
@@ -18,9 +18,12 @@ package osd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -102,8 +105,123 @@ func TestParseCephVolumeResult(t *testing.T) {
 	}
 
 	context := &clusterd.Context{Executor: executor}
-	osds, err := getCephVolumeOSDs(context, "rook")
+	osds, err := getCephVolumeOSDs(context, "rook", "")
 	assert.Nil(t, err)
 	require.NotNil(t, osds)
 	assert.Equal(t, 2, len(osds))
 }
+
+func TestInitializeDevicesWithCache(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, name string, command string, args ...string) error {
+			commands = append(commands, fmt.Sprintf("%s %s", command, strings.Join(args, " ")))
+			return nil
+		},
+		MockExecuteCommandWithOutput: func(debug bool, name string, command string, args ...string) (string, error) {
+			if command == "ceph-volume" && args[1] == "list" && args[2] == "/dev/sdb" {
+				return cephVolumeTestResult, nil
+			}
+			return "", fmt.Errorf("unknown command %s %+v", command, args)
+		},
+	}
+
+	context := &clusterd.Context{Executor: executor}
+	agent := &OsdAgent{storeConfig: config.StoreConfig{CacheDevice: "nvme0n1", CacheSizeMB: 2048, CacheMode: "writeback"}, kv: mockKVStore()}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sdb": {Data: -1, Config: DesiredDevice{OSDsPerDevice: 1}},
+	}}
+	err := agent.initializeDevices(context, devices)
+	assert.Nil(t, err)
+
+	// the test result contains two osds, each gets its own cache partition
+	require.Equal(t, 11, len(commands))
+	assert.Equal(t, "ceph-volume lvm batch --prepare --bluestore --yes /dev/sdb --osds-per-device 1", commands[0])
+	vg := "ceph-93550251-f76c-4219-a33f-df8805de7b9e"
+	lv := "osd-data-d1cb42c3-60f6-4347-82eb-3188dc3df894"
+	assert.Contains(t, commands, "sgdisk --new=0:0:+2048M --change-name=0:ROOK-CACHE-OSD0 /dev/nvme0n1")
+	assert.Contains(t, commands, "pvcreate /dev/disk/by-partlabel/ROOK-CACHE-OSD0")
+	assert.Contains(t, commands, fmt.Sprintf("vgextend %s /dev/disk/by-partlabel/ROOK-CACHE-OSD0", vg))
+	assert.Contains(t, commands, fmt.Sprintf("lvcreate --type cache --cachemode writeback -l 90%%PVS -n %s-cache %s/%s /dev/disk/by-partlabel/ROOK-CACHE-OSD0", lv, vg, lv))
+
+	// an invalid cache mode fails before any device is prepared
+	commands = nil
+	agent.storeConfig.CacheMode = "writearound"
+	err = agent.initializeDevices(context, devices)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(commands))
+}
+
+func TestAttachCacheResumed(t *testing.T) {
+	vg0 := "ceph-93550251-f76c-4219-a33f-df8805de7b9e"
+	lv0 := "osd-data-d1cb42c3-60f6-4347-82eb-3188dc3df894"
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, name string, command string, args ...string) error {
+			commands = append(commands, fmt.Sprintf("%s %s", command, strings.Join(args, " ")))
+			return nil
+		},
+		MockExecuteCommandWithOutput: func(debug bool, name string, command string, args ...string) (string, error) {
+			switch {
+			case command == "ceph-volume" && args[1] == "list" && args[2] == "/dev/sdb":
+				return cephVolumeTestResult, nil
+			case command == "lvs" && args[4] == vg0+"/"+lv0:
+				// the cache of osd 0 was attached before the interruption
+				return "  cache\n", nil
+			case command == "lvs":
+				return "  linear\n", nil
+			case command == "pvs" && args[4] == "/dev/disk/by-partlabel/ROOK-CACHE-OSD1":
+				return "", fmt.Errorf("failed to find physical volume")
+			}
+			return "", fmt.Errorf("unknown command %s %+v", command, args)
+		},
+	}
+	// the partition of osd 1 was created before the interruption
+	partitionExists = func(partition string) bool { return partition == "/dev/disk/by-partlabel/ROOK-CACHE-OSD1" }
+	defer func() {
+		partitionExists = func(partition string) bool {
+			_, err := os.Stat(partition)
+			return err == nil
+		}
+	}()
+
+	context := &clusterd.Context{Executor: executor}
+	agent := &OsdAgent{storeConfig: config.StoreConfig{CacheDevice: "nvme0n1"}, kv: mockKVStore()}
+	assert.Nil(t, agent.attachCache(context, "/dev/sdb"))
+
+	// only the missing steps of osd 1 are done
+	require.Equal(t, 3, len(commands))
+	assert.Equal(t, "pvcreate /dev/disk/by-partlabel/ROOK-CACHE-OSD1", commands[0])
+	assert.True(t, strings.HasPrefix(commands[1], "vgextend ceph-dfb1ca03-eb4f-4a5f-84b4-f4734aaefd42 "))
+	assert.True(t, strings.HasPrefix(commands[2], "lvcreate --type cache --cachemode writethrough"))
+}
+
+func TestInitializeDevicesWithStoreType(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, name string, command string, args ...string) error {
+			commands = append(commands, fmt.Sprintf("%s %s", command, strings.Join(args, " ")))
+			return nil
+		},
+	}
+
+	context := &clusterd.Context{Executor: executor}
+	agent := &OsdAgent{storeConfig: config.StoreConfig{StoreType: config.Filestore}, kv: mockKVStore()}
+	devices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{
+		"sdb": {Data: -1, Config: DesiredDevice{OSDsPerDevice: 1, StoreType: config.Bluestore}},
+	}}
+	err := agent.initializeDevices(context, devices)
+	assert.Nil(t, err)
+
+	// the store type of the device overrides the store type of the node
+	require.Equal(t, 1, len(commands))
+	assert.Equal(t, "ceph-volume lvm batch --prepare --bluestore --yes /dev/sdb --osds-per-device 1", commands[0])
+
+	// the store type of the node applies to the devices without their own
+	commands = nil
+	devices.Entries["sdb"].Config.StoreType = ""
+	err = agent.initializeDevices(context, devices)
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(commands))
+	assert.Equal(t, "ceph-volume lvm batch --prepare --filestore --yes /dev/sdb --osds-per-device 1", commands[0])
+}
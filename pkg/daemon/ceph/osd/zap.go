@@ -0,0 +1,276 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/sys"
+)
+
+const (
+	lvmMemberFS   = "LVM2_member"
+	cephVGPrefix  = "ceph-"
+	zapZeroSizeMB = 10
+)
+
+// deviceLocked reports whether a daemon holds the lock of a device. ceph-osd locks its block device, the lock is seen
+// from any container mounting the /dev of the host.
+var deviceLocked = isDeviceLocked
+
+// ZapReport lists what zapping a device destroys
+type ZapReport struct {
+	Device         string
+	OSDs           []string
+	VolumeGroups   []string
+	Partitions     []string
+	Filesystem     string
+	BluestoreLabel bool
+	Mounts         []string
+	Locked         []string
+
+	foreignPartitions []string
+}
+
+// Empty is true when no signature was found on the device
+func (r *ZapReport) Empty() bool {
+	return len(r.VolumeGroups) == 0 && len(r.Partitions) == 0 && r.Filesystem == "" && !r.BluestoreLabel
+}
+
+// foreignData lists the data of the device that was not created for a ceph osd
+func (r *ZapReport) foreignData() []string {
+	var foreign []string
+	for _, p := range r.foreignPartitions {
+		foreign = append(foreign, "partition "+p)
+	}
+	if r.Filesystem != "" && r.Filesystem != lvmMemberFS {
+		foreign = append(foreign, "filesystem "+r.Filesystem)
+	}
+	for _, vg := range r.VolumeGroups {
+		if !strings.HasPrefix(vg, cephVGPrefix) {
+			foreign = append(foreign, "volume group "+vg)
+		}
+	}
+	return foreign
+}
+
+// InspectDevice finds the ceph signatures, lvm metadata and partitions of a device
+func InspectDevice(context *clusterd.Context, device string) (*ZapReport, error) {
+	name := strings.TrimPrefix(device, "/dev/")
+	devicePath := path.Join("/dev", name)
+	report := &ZapReport{Device: devicePath}
+
+	props, err := sys.GetDeviceProperties(name, context.Executor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the properties of device %s. %+v", devicePath, err)
+	}
+	if deviceType := props["TYPE"]; deviceType != "" && deviceType != sys.DiskType {
+		return nil, fmt.Errorf("device %s is a %s. only whole disks can be zapped", devicePath, deviceType)
+	}
+
+	partitions, _, err := sys.GetDevicePartitions(name, context.Executor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the partitions of device %s. %+v", devicePath, err)
+	}
+	for _, p := range partitions {
+		report.Partitions = append(report.Partitions, path.Join("/dev", p.Name))
+		if !strings.HasPrefix(p.Label, "ROOK-OSD") {
+			report.foreignPartitions = append(report.foreignPartitions, p.Name)
+		}
+	}
+
+	report.Filesystem, err = sys.GetDeviceFilesystems(name, context.Executor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the filesystem of device %s. %+v", devicePath, err)
+	}
+
+	locks := append([]string{devicePath}, report.Partitions...)
+	if report.Filesystem == lvmMemberFS {
+		report.VolumeGroups, err = getVolumeGroups(context, devicePath)
+		if err != nil {
+			return nil, err
+		}
+		osds, err := getCephVolumeDeviceOSDs(context, name)
+		if err != nil {
+			logger.Infof("no ceph-volume osd found on device %s. %+v", devicePath, err)
+		}
+		for id, lvs := range osds {
+			report.OSDs = append(report.OSDs, id)
+			for _, lv := range lvs {
+				locks = append(locks, lv.Path)
+			}
+		}
+		sort.Strings(report.OSDs)
+	}
+
+	_, err = context.Executor.ExecuteCommandWithOutput(false, "", "ceph-bluestore-tool", "show-label", "--dev", devicePath)
+	report.BluestoreLabel = err == nil
+
+	mounts, err := context.Executor.ExecuteCommandWithOutput(false, "", "lsblk", devicePath, "--noheadings", "--output", "MOUNTPOINT")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the mount points of device %s. %+v", devicePath, err)
+	}
+	for _, mount := range strings.Split(mounts, "\n") {
+		if mount = strings.TrimSpace(mount); mount != "" {
+			report.Mounts = append(report.Mounts, mount)
+		}
+	}
+
+	for _, p := range locks {
+		locked, err := deviceLocked(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check the lock of %s. %+v", p, err)
+		}
+		if locked {
+			report.Locked = append(report.Locked, p)
+		}
+	}
+
+	return report, nil
+}
+
+// ZapDevice wipes the ceph signatures, lvm metadata and partition tables of a device that was used by an osd. The
+// device is never wiped while it is mounted or locked by a running osd, and it is only wiped if it holds data that
+// was not created for an osd when forced. With dryRun, the commands that would destroy the data are only logged.
+func ZapDevice(context *clusterd.Context, device string, dryRun, force bool) (*ZapReport, error) {
+	report, err := InspectDevice(context, device)
+	if err != nil {
+		return nil, err
+	}
+	logZapReport(report)
+
+	if len(report.Mounts) > 0 {
+		return report, fmt.Errorf("device %s is mounted on %v", report.Device, report.Mounts)
+	}
+	if len(report.Locked) > 0 {
+		return report, fmt.Errorf("device %s is in use by a running osd (%v are locked)", report.Device, report.Locked)
+	}
+	if foreign := report.foreignData(); len(foreign) > 0 && !force {
+		return report, fmt.Errorf("device %s holds data that was not created for an osd (%s). use --force to wipe it anyway",
+			report.Device, strings.Join(foreign, ", "))
+	}
+	if report.Empty() {
+		logger.Infof("nothing to zap on device %s", report.Device)
+		return report, nil
+	}
+
+	for _, cmd := range zapCommands(report) {
+		if dryRun {
+			logger.Infof("dry run: would run %s", strings.Join(cmd, " "))
+			continue
+		}
+		logger.Infof("running %s", strings.Join(cmd, " "))
+		if err := context.Executor.ExecuteCommand(false, "", cmd[0], cmd[1:]...); err != nil {
+			return report, fmt.Errorf("failed to zap device %s. %+v", report.Device, err)
+		}
+	}
+
+	if dryRun {
+		logger.Infof("dry run: device %s was not modified", report.Device)
+	} else {
+		logger.Infof("device %s was zapped", report.Device)
+	}
+	return report, nil
+}
+
+// zapCommands removes the volume groups with their logical volumes before wiping the signatures of the partitions and
+// of the device. The start of the device is zeroed to destroy the bluestore label that wipefs does not know.
+func zapCommands(report *ZapReport) [][]string {
+	var cmds [][]string
+	for _, vg := range report.VolumeGroups {
+		cmds = append(cmds, []string{"vgremove", "--force", vg})
+	}
+	if report.Filesystem == lvmMemberFS {
+		cmds = append(cmds, []string{"pvremove", "--force", "--force", "--yes", report.Device})
+	}
+	for _, p := range report.Partitions {
+		cmds = append(cmds, []string{"wipefs", "--all", p})
+	}
+	if len(report.Partitions) > 0 {
+		cmds = append(cmds, []string{"sgdisk", "--zap-all", report.Device})
+	}
+	cmds = append(cmds, []string{"wipefs", "--all", report.Device})
+	cmds = append(cmds, []string{"dd", "if=/dev/zero", "of=" + report.Device, "bs=1M", fmt.Sprintf("count=%d", zapZeroSizeMB), "oflag=direct"})
+	return cmds
+}
+
+func logZapReport(report *ZapReport) {
+	logger.Infof("inspected device %s", report.Device)
+	if len(report.OSDs) > 0 {
+		logger.Infof("  osds: %v", report.OSDs)
+	}
+	if len(report.VolumeGroups) > 0 {
+		logger.Infof("  volume groups: %v", report.VolumeGroups)
+	}
+	if len(report.Partitions) > 0 {
+		logger.Infof("  partitions: %v", report.Partitions)
+	}
+	if report.Filesystem != "" {
+		logger.Infof("  filesystem: %s", report.Filesystem)
+	}
+	if report.BluestoreLabel {
+		logger.Infof("  bluestore label")
+	}
+	if len(report.Mounts) > 0 {
+		logger.Infof("  mounted on: %v", report.Mounts)
+	}
+	if len(report.Locked) > 0 {
+		logger.Infof("  locked: %v", report.Locked)
+	}
+}
+
+func getVolumeGroups(context *clusterd.Context, devicePath string) ([]string, error) {
+	output, err := context.Executor.ExecuteCommandWithOutput(false, "", "pvs", "--noheadings", "--options", "vg_name", devicePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the volume groups of device %s. %+v", devicePath, err)
+	}
+	var vgs []string
+	for _, vg := range strings.Split(output, "\n") {
+		if vg = strings.TrimSpace(vg); vg != "" {
+			vgs = append(vgs, vg)
+		}
+	}
+	return vgs, nil
+}
+
+// isDeviceLocked tries to take the lock that ceph-osd holds on its block device while it runs
+func isDeviceLocked(devicePath string) (bool, error) {
+	f, err := os.Open(devicePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// an inactive logical volume has no device node and cannot be used
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if err == syscall.EWOULDBLOCK {
+			return true, nil
+		}
+		return false, err
+	}
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return false, nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package osd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type zapTestDevice struct {
+	fsType     string
+	partitions map[string]string
+	vgs        string
+	mounts     string
+	label      bool
+}
+
+func newZapTest(device zapTestDevice) (*clusterd.Context, *[]string) {
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(debug bool, actionName string, command string, args ...string) error {
+			commands = append(commands, command+" "+strings.Join(args, " "))
+			return nil
+		},
+		MockExecuteCommandWithOutput: func(debug bool, name string, command string, args ...string) (string, error) {
+			switch {
+			case command == "lsblk" && args[2] == "--nodeps":
+				return `SIZE="10737418240" ROTA="1" RO="0" TYPE="disk" PKNAME=""`, nil
+			case command == "lsblk" && args[2] == "--pairs":
+				output := `NAME="sdb" SIZE="10737418240" TYPE="disk" PKNAME=""`
+				for p := range device.partitions {
+					output += fmt.Sprintf("\nNAME=\"%s\" SIZE=\"1048576\" TYPE=\"part\" PKNAME=\"sdb\"", p)
+				}
+				return output, nil
+			case command == "lsblk":
+				return device.mounts, nil
+			case command == "udevadm" && args[2] == "/dev/sdb":
+				return "ID_FS_TYPE=" + device.fsType, nil
+			case command == "udevadm":
+				return "PARTNAME=" + device.partitions[strings.TrimPrefix(args[2], "/dev/")], nil
+			case command == "pvs":
+				return device.vgs, nil
+			case command == "ceph-volume" && device.vgs != "":
+				return cephVolumeTestResult, nil
+			case command == "ceph-volume":
+				return "", fmt.Errorf("no valid ceph lvm devices found")
+			case command == "ceph-bluestore-tool" && device.label:
+				return "{}", nil
+			case command == "ceph-bluestore-tool":
+				return "", fmt.Errorf("no label")
+			}
+			return "", fmt.Errorf("unexpected command %s %+v", command, args)
+		},
+	}
+	return &clusterd.Context{Executor: executor}, &commands
+}
+
+func TestZapCephVolumeDevice(t *testing.T) {
+	deviceLocked = func(string) (bool, error) { return false, nil }
+	defer func() { deviceLocked = isDeviceLocked }()
+
+	context, commands := newZapTest(zapTestDevice{fsType: "LVM2_member", vgs: "  ceph-93550251-f76c-4219-a33f-df8805de7b9e\n"})
+
+	// the dry run only reports what would be destroyed
+	report, err := ZapDevice(context, "/dev/sdb", true, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "/dev/sdb", report.Device)
+	assert.Equal(t, []string{"0", "1"}, report.OSDs)
+	assert.Equal(t, []string{"ceph-93550251-f76c-4219-a33f-df8805de7b9e"}, report.VolumeGroups)
+	assert.Equal(t, 0, len(*commands))
+
+	report, err = ZapDevice(context, "sdb", false, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"vgremove --force ceph-93550251-f76c-4219-a33f-df8805de7b9e",
+		"pvremove --force --force --yes /dev/sdb",
+		"wipefs --all /dev/sdb",
+		"dd if=/dev/zero of=/dev/sdb bs=1M count=10 oflag=direct",
+	}, *commands)
+}
+
+func TestZapLegacyDevice(t *testing.T) {
+	deviceLocked = func(string) (bool, error) { return false, nil }
+	defer func() { deviceLocked = isDeviceLocked }()
+
+	context, commands := newZapTest(zapTestDevice{partitions: map[string]string{"sdb1": "ROOK-OSD0-BLOCK"}})
+	report, err := ZapDevice(context, "sdb", false, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/dev/sdb1"}, report.Partitions)
+	assert.Equal(t, []string{
+		"wipefs --all /dev/sdb1",
+		"sgdisk --zap-all /dev/sdb",
+		"wipefs --all /dev/sdb",
+		"dd if=/dev/zero of=/dev/sdb bs=1M count=10 oflag=direct",
+	}, *commands)
+
+	// a bluestore label left on the device is destroyed
+	context, commands = newZapTest(zapTestDevice{label: true})
+	report, err = ZapDevice(context, "sdb", false, false)
+	assert.Nil(t, err)
+	assert.True(t, report.BluestoreLabel)
+	assert.Equal(t, 2, len(*commands))
+
+	// nothing to destroy on a clean device
+	context, commands = newZapTest(zapTestDevice{})
+	report, err = ZapDevice(context, "sdb", false, false)
+	assert.Nil(t, err)
+	assert.True(t, report.Empty())
+	assert.Equal(t, 0, len(*commands))
+}
+
+func TestZapRefused(t *testing.T) {
+	locked := false
+	deviceLocked = func(p string) (bool, error) { return locked && strings.HasPrefix(p, "/dev/ceph-"), nil }
+	defer func() { deviceLocked = isDeviceLocked }()
+
+	// mounted
+	context, commands := newZapTest(zapTestDevice{fsType: "xfs", mounts: "\n/var/lib/ceph/osd/ceph-3\n"})
+	report, err := ZapDevice(context, "sdb", false, true)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"/var/lib/ceph/osd/ceph-3"}, report.Mounts)
+
+	// locked by a running osd, even forced
+	locked = true
+	context, commands = newZapTest(zapTestDevice{fsType: "LVM2_member", vgs: "ceph-93550251-f76c-4219-a33f-df8805de7b9e"})
+	report, err = ZapDevice(context, "sdb", false, true)
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, len(report.Locked))
+	locked = false
+
+	// foreign data is only wiped when forced
+	context, commands = newZapTest(zapTestDevice{fsType: "ext4", partitions: map[string]string{"sdb1": "data"}})
+	_, err = ZapDevice(context, "sdb", false, false)
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "partition sdb1, filesystem ext4"))
+	assert.Equal(t, 0, len(*commands))
+	_, err = ZapDevice(context, "sdb", false, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(*commands))
+
+	context, commands = newZapTest(zapTestDevice{fsType: "LVM2_member", vgs: "data"})
+	_, err = ZapDevice(context, "sdb", false, false)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(*commands))
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package preflight checks the prerequisites of a node before the ceph daemons are configured on it.
+package preflight
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/coreos/pkg/capnslog"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "preflight")
+
+const (
+	// staUnsync is set in the status of the kernel clock while it is not synchronized
+	staUnsync = 0x0040
+	// timeError is the state of the kernel clock when it is not synchronized
+	timeError = 5
+)
+
+var (
+	// DefaultKernelModules are the kernel modules needed to map the ceph block volumes. The rbd module is loaded on
+	// demand by the first map, so it only needs to be available in the modules of the kernel.
+	DefaultKernelModules = []string{"rbd"}
+	// DefaultSysctls are the recommended minimum values of the kernel settings for the nodes running many osds. Ceph
+	// runs many threads per osd and bluestore submits its IOs asynchronously. A lower value is only a warning since a
+	// node with few osds runs fine with the defaults of the kernel.
+	DefaultSysctls = map[string]int64{
+		"kernel.pid_max": 4194303,
+		"fs.aio-max-nr":  1048576,
+	}
+
+	// the host paths are variables for the tests
+	sysModulePath  = "/sys/module"
+	libModulesPath = "/lib/modules"
+	procSysPath    = "/proc/sys"
+	devPath        = "/dev"
+	lookupHost     = net.LookupHost
+	clockSynced    = kernelClockSynced
+)
+
+// Config is the node and the prerequisites to check
+type Config struct {
+	// NodeName is the name that must resolve to an address of the node
+	NodeName string
+	// DataDir is where dataDirHostPath is mounted
+	DataDir string
+	// Devices are the names of the devices that must be visible under /dev
+	Devices []string
+	// KernelModules are the kernel modules that must be loaded or available to be loaded
+	KernelModules []string
+	// Sysctls are the recommended minimum values of the kernel settings, a lower value is a warning
+	Sysctls map[string]int64
+	// CheckHostname is whether NodeName must resolve. The name only needs to resolve when the daemons run on the
+	// network of the host.
+	CheckHostname bool
+}
+
+// Check is the result of one prerequisite
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	// Warning is set when the check did not pass but does not fail the report
+	Warning bool   `json:"warning,omitempty"`
+	Message string `json:"message"`
+}
+
+// Report is the result of the checks of a node
+type Report struct {
+	Node   string  `json:"node"`
+	Passed bool    `json:"passed"`
+	Checks []Check `json:"checks"`
+}
+
+// Run checks all the prerequisites of the node. A failed check does not stop the next ones so the report lists all
+// the problems of the node at once.
+func Run(config Config) *Report {
+	report := &Report{Node: config.NodeName, Passed: true}
+	add := func(name string, err error, message string) {
+		check := Check{Name: name, Passed: err == nil, Message: message}
+		if err != nil {
+			check.Message = err.Error()
+			report.Passed = false
+			logger.Warningf("preflight check %s failed. %s", name, check.Message)
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	warn := func(name string, err error, message string) {
+		check := Check{Name: name, Passed: err == nil, Warning: err != nil, Message: message}
+		if err != nil {
+			check.Message = err.Error()
+			logger.Warningf("preflight check %s did not pass. %s", name, check.Message)
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	for _, module := range config.KernelModules {
+		message, unknown, err := checkKernelModule(module)
+		if unknown {
+			warn("kernel module "+module, err, message)
+		} else {
+			add("kernel module "+module, err, message)
+		}
+	}
+
+	names := make([]string, 0, len(config.Sysctls))
+	for name := range config.Sysctls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, err := checkSysctl(name, config.Sysctls[name])
+		warn("sysctl "+name, err, value)
+	}
+
+	add("time sync", checkTimeSync(), "clock synchronized")
+
+	if config.CheckHostname {
+		addrs, err := checkHostname(config.NodeName)
+		add("hostname "+config.NodeName, err, "resolves to "+addrs)
+	}
+
+	add("data dir "+config.DataDir, checkWritable(config.DataDir), "writable")
+
+	for _, device := range config.Devices {
+		add("device "+device, checkDevice(device), "visible")
+	}
+
+	return report
+}
+
+// checkKernelModule checks that the module is loaded, or is in the modules of the running kernel so it can be loaded
+// on demand. unknown is true when the modules of the kernel are not visible, for example when /lib/modules is not
+// mounted in the container, in which case the module could not be checked.
+func checkKernelModule(module string) (message string, unknown bool, err error) {
+	// built-in modules are also listed under /sys/module
+	if _, err := os.Stat(path.Join(sysModulePath, module)); err == nil {
+		return "loaded", false, nil
+	} else if !os.IsNotExist(err) {
+		return "", false, fmt.Errorf("failed to check kernel module %s. %+v", module, err)
+	}
+
+	b, err := ioutil.ReadFile(path.Join(procSysPath, "kernel", "osrelease"))
+	if err != nil {
+		return "", true, fmt.Errorf("failed to get the kernel release to check module %s. %+v", module, err)
+	}
+	release := strings.TrimSpace(string(b))
+	visible := false
+	for _, index := range []string{"modules.builtin", "modules.dep"} {
+		b, err := ioutil.ReadFile(path.Join(libModulesPath, release, index))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", false, fmt.Errorf("failed to read the modules of kernel %s. %+v", release, err)
+		}
+		visible = true
+		if moduleListed(string(b), module) {
+			return "available", false, nil
+		}
+	}
+	if !visible {
+		return "", true, fmt.Errorf("kernel module %s is not loaded and the modules of kernel %s are not visible under %s", module, release, libModulesPath)
+	}
+	return "", false, fmt.Errorf("kernel module %s is not available in kernel %s. install the modules of the kernel", module, release)
+}
+
+// moduleListed returns whether the module is in a modules.dep or modules.builtin index, whose lines start with the
+// path of a module such as kernel/drivers/block/rbd.ko.xz
+func moduleListed(index, module string) bool {
+	module = strings.Replace(module, "-", "_", -1)
+	for _, line := range strings.Split(index, "\n") {
+		file := path.Base(strings.SplitN(line, ":", 2)[0])
+		name := strings.SplitN(file, ".ko", 2)[0]
+		if strings.Replace(name, "-", "_", -1) == module {
+			return true
+		}
+	}
+	return false
+}
+
+func checkSysctl(name string, min int64) (string, error) {
+	b, err := ioutil.ReadFile(path.Join(procSysPath, strings.Replace(name, ".", "/", -1)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read sysctl %s. %+v", name, err)
+	}
+	value := strings.TrimSpace(string(b))
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse sysctl %s=%s. %+v", name, value, err)
+	}
+	if v < min {
+		return "", fmt.Errorf("sysctl %s is %d, at least %d is recommended", name, v, min)
+	}
+	return value, nil
+}
+
+func checkTimeSync() error {
+	synced, err := clockSynced()
+	if err != nil {
+		return fmt.Errorf("failed to get the state of the clock. %+v", err)
+	}
+	if !synced {
+		return fmt.Errorf("the clock is not synchronized. the mons need the clocks of the nodes synchronized with ntp or chrony")
+	}
+	return nil
+}
+
+// kernelClockSynced returns whether the kernel clock is synchronized by ntp or chrony
+func kernelClockSynced() (bool, error) {
+	var timex syscall.Timex
+	state, err := syscall.Adjtimex(&timex)
+	if err != nil {
+		return false, err
+	}
+	return state != timeError && timex.Status&staUnsync == 0, nil
+}
+
+func checkHostname(name string) (string, error) {
+	addrs, err := lookupHost(name)
+	if err != nil {
+		return "", fmt.Errorf("hostname %s does not resolve. %+v", name, err)
+	}
+	return strings.Join(addrs, ","), nil
+}
+
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s. %+v", dir, err)
+	}
+	f, err := ioutil.TempFile(dir, ".preflight")
+	if err != nil {
+		return fmt.Errorf("%s is not writable. %+v", dir, err)
+	}
+	f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return fmt.Errorf("failed to remove %s. %+v", f.Name(), err)
+	}
+	return nil
+}
+
+func checkDevice(name string) error {
+	info, err := os.Stat(path.Join(devPath, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("device %s is not visible under %s", name, devPath)
+		}
+		return fmt.Errorf("failed to check device %s. %+v", name, err)
+	}
+	if info.Mode()&os.ModeDevice == 0 {
+		return fmt.Errorf("%s is not a device", path.Join(devPath, name))
+	}
+	return nil
+}
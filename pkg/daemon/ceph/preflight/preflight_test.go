@@ -0,0 +1,177 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	root, err := ioutil.TempDir("", "preflight")
+	require.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	sysModulePath = path.Join(root, "module")
+	libModulesPath = path.Join(root, "modules")
+	procSysPath = path.Join(root, "sys")
+	defer func() {
+		sysModulePath = "/sys/module"
+		libModulesPath = "/lib/modules"
+		procSysPath = "/proc/sys"
+		lookupHost = net.LookupHost
+		clockSynced = kernelClockSynced
+	}()
+	require.Nil(t, os.MkdirAll(path.Join(sysModulePath, "rbd"), 0755))
+	require.Nil(t, os.MkdirAll(path.Join(procSysPath, "kernel"), 0755))
+	require.Nil(t, ioutil.WriteFile(path.Join(procSysPath, "kernel", "pid_max"), []byte("4194304\n"), 0644))
+	require.Nil(t, ioutil.WriteFile(path.Join(procSysPath, "kernel", "osrelease"), []byte("4.18.0\n"), 0644))
+	require.Nil(t, os.MkdirAll(path.Join(libModulesPath, "4.18.0"), 0755))
+	require.Nil(t, ioutil.WriteFile(path.Join(libModulesPath, "4.18.0", "modules.dep"), []byte("kernel/net/ceph/libceph.ko.xz:\n"), 0644))
+	lookupHost = func(host string) ([]string, error) {
+		if host == "node1" {
+			return []string{"10.0.0.1"}, nil
+		}
+		return nil, fmt.Errorf("no such host")
+	}
+	clockSynced = func() (bool, error) { return true, nil }
+
+	config := Config{
+		NodeName:      "node1",
+		DataDir:       path.Join(root, "data"),
+		Devices:       []string{"null"},
+		KernelModules: []string{"rbd"},
+		Sysctls:       map[string]int64{"kernel.pid_max": 4194303},
+		CheckHostname: true,
+	}
+	report := Run(config)
+	assert.True(t, report.Passed)
+	require.Equal(t, 6, len(report.Checks))
+	for _, check := range report.Checks {
+		assert.True(t, check.Passed, check.Name)
+	}
+	assert.Equal(t, "4194304", report.Checks[1].Message)
+	assert.Equal(t, "resolves to 10.0.0.1", report.Checks[3].Message)
+
+	// the kernel settings below their recommended values are only warnings
+	config.Sysctls["kernel.pid_max"] = 5000000
+	config.Sysctls["fs.aio-max-nr"] = 1048576
+	report = Run(config)
+	assert.True(t, report.Passed)
+	warnings := map[string]bool{}
+	for _, check := range report.Checks {
+		if check.Warning {
+			assert.False(t, check.Passed, check.Name)
+			warnings[check.Name] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{"sysctl fs.aio-max-nr": true, "sysctl kernel.pid_max": true}, warnings)
+
+	// all the failed checks are reported
+	clockSynced = func() (bool, error) { return false, nil }
+	config.NodeName = "node2"
+	config.Devices = []string{"sdz"}
+	config.KernelModules = []string{"rbd", "ceph"}
+	report = Run(config)
+	assert.False(t, report.Passed)
+	failed := map[string]bool{}
+	for _, check := range report.Checks {
+		if !check.Passed && !check.Warning {
+			failed[check.Name] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{
+		"kernel module ceph": true,
+		"time sync":          true,
+		"hostname node2":     true,
+		"device sdz":         true,
+	}, failed)
+
+	// the node name is not resolved off the network of the host
+	config.CheckHostname = false
+	report = Run(config)
+	for _, check := range report.Checks {
+		assert.NotEqual(t, "hostname node2", check.Name)
+	}
+}
+
+func TestCheckKernelModule(t *testing.T) {
+	root, err := ioutil.TempDir("", "preflight")
+	require.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	sysModulePath = path.Join(root, "module")
+	libModulesPath = path.Join(root, "modules")
+	procSysPath = path.Join(root, "sys")
+	defer func() {
+		sysModulePath = "/sys/module"
+		libModulesPath = "/lib/modules"
+		procSysPath = "/proc/sys"
+	}()
+	require.Nil(t, os.MkdirAll(path.Join(procSysPath, "kernel"), 0755))
+	require.Nil(t, ioutil.WriteFile(path.Join(procSysPath, "kernel", "osrelease"), []byte("4.18.0\n"), 0644))
+
+	// the modules of the kernel are not mounted
+	_, unknown, err := checkKernelModule("rbd")
+	assert.True(t, unknown)
+	assert.NotNil(t, err)
+
+	// the module is not loaded but can be loaded on demand
+	require.Nil(t, os.MkdirAll(path.Join(libModulesPath, "4.18.0"), 0755))
+	dep := "kernel/net/ceph/libceph.ko.xz:\nkernel/drivers/block/rbd.ko.xz: kernel/net/ceph/libceph.ko.xz\n"
+	require.Nil(t, ioutil.WriteFile(path.Join(libModulesPath, "4.18.0", "modules.dep"), []byte(dep), 0644))
+	message, unknown, err := checkKernelModule("rbd")
+	assert.Nil(t, err)
+	assert.False(t, unknown)
+	assert.Equal(t, "available", message)
+
+	// the module is not in the kernel
+	_, unknown, err = checkKernelModule("ceph")
+	assert.False(t, unknown)
+	assert.NotNil(t, err)
+
+	// the module is loaded
+	require.Nil(t, os.MkdirAll(path.Join(sysModulePath, "ceph"), 0755))
+	message, _, err = checkKernelModule("ceph")
+	assert.Nil(t, err)
+	assert.Equal(t, "loaded", message)
+}
+
+func TestCheckWritable(t *testing.T) {
+	root, err := ioutil.TempDir("", "preflight")
+	require.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	// the data dir is created if missing and the test file is removed
+	dir := path.Join(root, "rook")
+	assert.Nil(t, checkWritable(dir))
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(files))
+
+	// a file is not a writable dir
+	file := path.Join(root, "file")
+	require.Nil(t, ioutil.WriteFile(file, []byte{}, 0644))
+	assert.NotNil(t, checkWritable(file))
+}
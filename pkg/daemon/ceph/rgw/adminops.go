@@ -0,0 +1,334 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rgw
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+const (
+	// AdminOpsCaps are the caps of the user of the admin ops api
+	AdminOpsCaps = "users=*;buckets=*;usage=read;metadata=read;zone=read"
+
+	adminOpsTimeout = 30 * time.Second
+)
+
+// AdminOpsClient runs the requests of the admin ops api of rgw with the keys of a user with the admin caps. The
+// endpoint is the http or https url of the rgw service of an object store, which can also be external to the cluster.
+type AdminOpsClient struct {
+	endpoint string
+	signer   *v4.Signer
+	client   *http.Client
+}
+
+// ObjectUserUsage represents the usage of the object store by a user
+type ObjectUserUsage struct {
+	BytesSent     uint64 `json:"bytes_sent"`
+	BytesReceived uint64 `json:"bytes_received"`
+	Ops           uint64 `json:"ops"`
+	SuccessfulOps uint64 `json:"successful_ops"`
+}
+
+type rgwQuota struct {
+	Enabled    bool  `json:"enabled"`
+	MaxObjects int64 `json:"max_objects"`
+	MaxSizeKB  int64 `json:"max_size_kb"`
+}
+
+// the error returned by the admin ops api
+type adminOpsError struct {
+	Status int
+	Code   string `json:"Code"`
+}
+
+func (e *adminOpsError) Error() string {
+	return fmt.Sprintf("rgw returned status %d %s", e.Status, e.Code)
+}
+
+// NewAdminOpsClient creates a client of the admin ops api of the object store with the keys of the admin ops user. The
+// pem certificates in caCert are trusted in addition to the system roots, for the https endpoints with a self-signed
+// or private certificate.
+func NewAdminOpsClient(accessKey, secretKey, endpoint string, caCert []byte) (*AdminOpsClient, error) {
+	client := &http.Client{Timeout: adminOpsTimeout}
+	if len(caCert) > 0 {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse the ca certificate of endpoint %s", endpoint)
+		}
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}
+	}
+	return &AdminOpsClient{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		signer:   v4.NewSigner(credentials.NewStaticCredentials(accessKey, secretKey, "")),
+		client:   client,
+	}, nil
+}
+
+// Run a request of the admin ops api and decode its json result if there is one
+func (a *AdminOpsClient) request(method, resource string, params url.Values, body interface{}, result interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode the request. %+v", err)
+		}
+	}
+
+	u := fmt.Sprintf("%s/admin/%s?%s", a.endpoint, resource, params.Encode())
+	req, err := http.NewRequest(method, u, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create the request. %+v", err)
+	}
+	if _, err := a.signer.Sign(req, bytes.NewReader(data), "s3", s3Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign the request. %+v", err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to run the admin ops request %s %s. %+v", method, resource, err)
+	}
+	defer resp.Body.Close()
+	output, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read the result of the admin ops request %s %s. %+v", method, resource, err)
+	}
+
+	if resp.StatusCode >= 300 {
+		rgwErr := &adminOpsError{Status: resp.StatusCode}
+		json.Unmarshal(output, rgwErr)
+		return rgwErr
+	}
+	if result != nil && len(output) > 0 {
+		if err := json.Unmarshal(output, result); err != nil {
+			return fmt.Errorf("failed to decode the result of the admin ops request %s %s. %+v, result=%s", method, resource, err, string(output))
+		}
+	}
+	return nil
+}
+
+// The rgw error code of the error returned by the admin ops api
+func adminOpsErrorCode(err error) int {
+	if rgwErr, ok := err.(*adminOpsError); ok {
+		if rgwErr.Status == http.StatusNotFound {
+			return RGWErrorNotFound
+		}
+		if rgwErr.Status == http.StatusConflict || rgwErr.Status == http.StatusBadRequest {
+			return RGWErrorBadData
+		}
+	}
+	return RGWErrorUnknown
+}
+
+// GetUser gets the user with its first key
+func (a *AdminOpsClient) GetUser(id string) (*ObjectUser, int, error) {
+	var user rgwUserInfo
+	if err := a.request("GET", "user", url.Values{"uid": {id}}, nil, &user); err != nil {
+		return nil, adminOpsErrorCode(err), fmt.Errorf("failed to get user %s. %+v", id, err)
+	}
+	return objectUser(user), RGWErrorNone, nil
+}
+
+// CreateUser creates the user with a generated key
+func (a *AdminOpsClient) CreateUser(user ObjectUser) (*ObjectUser, int, error) {
+	if strings.TrimSpace(user.UserID) == "" {
+		return nil, RGWErrorBadData, fmt.Errorf("userId cannot be empty")
+	}
+	if user.DisplayName == nil {
+		return nil, RGWErrorBadData, fmt.Errorf("displayName is required")
+	}
+
+	params := url.Values{"uid": {user.UserID}, "display-name": {*user.DisplayName}}
+	if user.Email != nil {
+		params.Set("email", *user.Email)
+	}
+	var info rgwUserInfo
+	if err := a.request("PUT", "user", params, nil, &info); err != nil {
+		return nil, adminOpsErrorCode(err), fmt.Errorf("failed to create user %s. %+v", user.UserID, err)
+	}
+	return objectUser(info), RGWErrorNone, nil
+}
+
+// DeleteUser deletes the user. The buckets of the user and their objects are kept.
+func (a *AdminOpsClient) DeleteUser(id string) (int, error) {
+	if err := a.request("DELETE", "user", url.Values{"uid": {id}}, nil, nil); err != nil {
+		return adminOpsErrorCode(err), fmt.Errorf("failed to delete user %s. %+v", id, err)
+	}
+	return RGWErrorNone, nil
+}
+
+// CreateKey generates a new s3 key of the user and returns its access key and secret key
+func (a *AdminOpsClient) CreateKey(uid string) (string, string, error) {
+	params := url.Values{"key": {""}, "uid": {uid}, "key-type": {"s3"}, "generate-key": {"True"}}
+	var keys []struct {
+		User      string `json:"user"`
+		AccessKey string `json:"access_key"`
+		SecretKey string `json:"secret_key"`
+	}
+	if err := a.request("PUT", "user", params, nil, &keys); err != nil {
+		return "", "", fmt.Errorf("failed to create a key of user %s. %+v", uid, err)
+	}
+
+	// rgw returns all the keys of the user with the new key last
+	if len(keys) == 0 {
+		return "", "", fmt.Errorf("no key returned for user %s", uid)
+	}
+	key := keys[len(keys)-1]
+	return key.AccessKey, key.SecretKey, nil
+}
+
+// DeleteKey deletes the s3 key of the user
+func (a *AdminOpsClient) DeleteKey(uid, accessKey string) error {
+	params := url.Values{"key": {""}, "uid": {uid}, "key-type": {"s3"}, "access-key": {accessKey}}
+	if err := a.request("DELETE", "user", params, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete a key of user %s. %+v", uid, err)
+	}
+	return nil
+}
+
+// SetSubuser creates the subuser <uid>:<name> of the user with a generated s3 key, or changes the access of the
+// subuser if it exists. The access is read, write, readwrite or full. Returns the s3 key of the subuser.
+func (a *AdminOpsClient) SetSubuser(uid, name, access string) (string, string, error) {
+	id := uid + ":" + name
+	var user rgwUserInfo
+	if err := a.request("GET", "user", url.Values{"uid": {uid}}, nil, &user); err != nil {
+		return "", "", fmt.Errorf("failed to get user %s. %+v", uid, err)
+	}
+	accessKey, secretKey, found := subuserKey(user, id)
+
+	params := url.Values{"uid": {uid}, "subuser": {id}, "access": {access}}
+	if found {
+		if err := a.request("POST", "user", params, nil, nil); err != nil {
+			return "", "", fmt.Errorf("failed to set the access of subuser %s. %+v", id, err)
+		}
+		return accessKey, secretKey, nil
+	}
+
+	params.Set("key-type", "s3")
+	params.Set("generate-secret", "True")
+	if err := a.request("PUT", "user", params, nil, nil); err != nil {
+		return "", "", fmt.Errorf("failed to create subuser %s. %+v", id, err)
+	}
+	// the subusers are returned by the creation, the keys with the user
+	if err := a.request("GET", "user", url.Values{"uid": {uid}}, nil, &user); err != nil {
+		return "", "", fmt.Errorf("failed to get user %s. %+v", uid, err)
+	}
+	if accessKey, secretKey, found = subuserKey(user, id); !found {
+		return "", "", fmt.Errorf("no s3 key returned for subuser %s", id)
+	}
+	return accessKey, secretKey, nil
+}
+
+// DeleteSubuser deletes the subuser <uid>:<name> of the user with its keys
+func (a *AdminOpsClient) DeleteSubuser(uid, name string) (int, error) {
+	id := uid + ":" + name
+	params := url.Values{"uid": {uid}, "subuser": {id}, "purge-keys": {"True"}}
+	if err := a.request("DELETE", "user", params, nil, nil); err != nil {
+		return adminOpsErrorCode(err), fmt.Errorf("failed to delete subuser %s. %+v", id, err)
+	}
+	return RGWErrorNone, nil
+}
+
+func subuserKey(user rgwUserInfo, id string) (string, string, bool) {
+	for _, key := range user.Keys {
+		if key.User == id {
+			return key.AccessKey, key.SecretKey, true
+		}
+	}
+	return "", "", false
+}
+
+// SetUserQuota sets the quota of all the buckets of the user, or disables it when there is no limit. A negative value
+// has no limit.
+func (a *AdminOpsClient) SetUserQuota(uid string, maxObjects, maxSize int64) error {
+	params := url.Values{"quota": {""}, "uid": {uid}, "quota-type": {"user"}}
+	if err := a.request("PUT", "user", params, makeQuota(maxObjects, maxSize), nil); err != nil {
+		return fmt.Errorf("failed to set the quota of user %s. %+v", uid, err)
+	}
+	return nil
+}
+
+// SetBucketQuota sets the quota of the bucket of the user, or disables it when there is no limit. A negative value has
+// no limit.
+func (a *AdminOpsClient) SetBucketQuota(uid, bucketName string, maxObjects, maxSize int64) error {
+	params := url.Values{"quota": {""}, "uid": {uid}, "bucket": {bucketName}}
+	if err := a.request("PUT", "bucket", params, makeQuota(maxObjects, maxSize), nil); err != nil {
+		return fmt.Errorf("failed to set the quota of bucket %s. %+v", bucketName, err)
+	}
+	return nil
+}
+
+// GetUsage gets the total usage of the object store by the user. The usage log must be enabled in rgw.
+func (a *AdminOpsClient) GetUsage(uid string) (*ObjectUserUsage, error) {
+	params := url.Values{"uid": {uid}, "show-entries": {"False"}, "show-summary": {"True"}}
+	var usage struct {
+		Summary []struct {
+			User  string          `json:"user"`
+			Total ObjectUserUsage `json:"total"`
+		} `json:"summary"`
+	}
+	if err := a.request("GET", "usage", params, nil, &usage); err != nil {
+		return nil, fmt.Errorf("failed to get the usage of user %s. %+v", uid, err)
+	}
+
+	result := &ObjectUserUsage{}
+	for _, s := range usage.Summary {
+		if s.User == uid {
+			*result = s.Total
+		}
+	}
+	return result, nil
+}
+
+func makeQuota(maxObjects, maxSize int64) rgwQuota {
+	quota := rgwQuota{Enabled: maxObjects >= 0 || maxSize >= 0, MaxObjects: -1, MaxSizeKB: -1}
+	if maxObjects >= 0 {
+		quota.MaxObjects = maxObjects
+	}
+	if maxSize >= 0 {
+		// the size is rounded up to the next kilobyte
+		quota.MaxSizeKB = (maxSize + 1023) / 1024
+	}
+	return quota
+}
+
+func objectUser(info rgwUserInfo) *ObjectUser {
+	user := &ObjectUser{UserID: info.UserID, DisplayName: &info.DisplayName, Email: &info.Email}
+	for i, key := range info.Keys {
+		// the keys of the subusers are listed with the keys of the user
+		if key.User == "" || key.User == info.UserID {
+			user.AccessKey = &info.Keys[i].AccessKey
+			user.SecretKey = &info.Keys[i].SecretKey
+			break
+		}
+	}
+	return user
+}
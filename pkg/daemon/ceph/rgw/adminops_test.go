@@ -0,0 +1,248 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rgw
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// an admin ops endpoint recording the requests it receives and returning the response of the resource
+type adminOpsServer struct {
+	requests  []string
+	bodies    []string
+	responses map[string]string
+	status    int
+}
+
+func (s *adminOpsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+	s.bodies = append(s.bodies, string(body))
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=myaccesskey/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		w.Write([]byte(`{"Code":"NoSuchUser"}`))
+		return
+	}
+	w.Write([]byte(s.responses[r.URL.Path]))
+}
+
+func TestAdminOpsUser(t *testing.T) {
+	server := &adminOpsServer{responses: map[string]string{
+		"/admin/user": `{"user_id":"myuser","display_name":"my user","email":"",
+			"keys":[{"user":"myuser","access_key":"useraccesskey","secret_key":"usersecretkey"}]}`,
+	}}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client := newTestAdminOpsClient(t, "myaccesskey", httpServer.URL+"/")
+
+	displayName := "my user"
+	user, rgwerr, err := client.CreateUser(ObjectUser{UserID: "myuser", DisplayName: &displayName})
+	assert.Nil(t, err)
+	assert.Equal(t, RGWErrorNone, rgwerr)
+	assert.Equal(t, "myuser", user.UserID)
+	assert.Equal(t, "useraccesskey", *user.AccessKey)
+	assert.Equal(t, "usersecretkey", *user.SecretKey)
+	assert.Equal(t, "PUT /admin/user?display-name=my%20user&uid=myuser", server.requests[0])
+
+	user, _, err = client.GetUser("myuser")
+	assert.Nil(t, err)
+	assert.Equal(t, "my user", *user.DisplayName)
+	assert.Equal(t, "GET /admin/user?uid=myuser", server.requests[1])
+
+	_, err = client.DeleteUser("myuser")
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE /admin/user?uid=myuser", server.requests[2])
+
+	// the display name is required
+	_, rgwerr, err = client.CreateUser(ObjectUser{UserID: "myuser"})
+	assert.NotNil(t, err)
+	assert.Equal(t, RGWErrorBadData, rgwerr)
+	assert.Equal(t, 3, len(server.requests))
+
+	// the errors of rgw are mapped to the rgw error codes
+	server.status = http.StatusNotFound
+	_, rgwerr, err = client.GetUser("otheruser")
+	assert.NotNil(t, err)
+	assert.Equal(t, RGWErrorNotFound, rgwerr)
+	server.status = http.StatusConflict
+	_, rgwerr, err = client.CreateUser(ObjectUser{UserID: "myuser", DisplayName: &displayName})
+	assert.NotNil(t, err)
+	assert.Equal(t, RGWErrorBadData, rgwerr)
+
+	// the keys of the admin are invalid
+	server.status = 0
+	client = newTestAdminOpsClient(t, "otheraccesskey", httpServer.URL)
+	_, rgwerr, err = client.GetUser("myuser")
+	assert.NotNil(t, err)
+	assert.Equal(t, RGWErrorUnknown, rgwerr)
+}
+
+func TestAdminOpsKeys(t *testing.T) {
+	server := &adminOpsServer{responses: map[string]string{
+		"/admin/user": `[{"user":"myuser","access_key":"oldaccesskey","secret_key":"oldsecretkey"},
+			{"user":"myuser","access_key":"newaccesskey","secret_key":"newsecretkey"}]`,
+	}}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client := newTestAdminOpsClient(t, "myaccesskey", httpServer.URL)
+
+	accessKey, secretKey, err := client.CreateKey("myuser")
+	assert.Nil(t, err)
+	assert.Equal(t, "newaccesskey", accessKey)
+	assert.Equal(t, "newsecretkey", secretKey)
+	assert.Equal(t, "PUT /admin/user?generate-key=True&key=&key-type=s3&uid=myuser", server.requests[0])
+
+	err = client.DeleteKey("myuser", "oldaccesskey")
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE /admin/user?access-key=oldaccesskey&key=&key-type=s3&uid=myuser", server.requests[1])
+}
+
+func TestAdminOpsSubuser(t *testing.T) {
+	userInfo := `{"user_id":"myuser","display_name":"my user","keys":[
+		{"user":"myuser:bundle","access_key":"subaccesskey","secret_key":"subsecretkey"},
+		{"user":"myuser","access_key":"useraccesskey","secret_key":"usersecretkey"}]}`
+	requests := []string{}
+	created := false
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		if r.Method == "PUT" {
+			created = true
+		}
+		if r.Method == "GET" {
+			if created {
+				w.Write([]byte(userInfo))
+			} else {
+				w.Write([]byte(`{"user_id":"myuser","keys":[{"user":"myuser","access_key":"useraccesskey","secret_key":"usersecretkey"}]}`))
+			}
+		}
+	}))
+	defer httpServer.Close()
+	client := newTestAdminOpsClient(t, "myaccesskey", httpServer.URL)
+
+	// the subuser is created with its s3 key
+	accessKey, secretKey, err := client.SetSubuser("myuser", "bundle", "read")
+	assert.Nil(t, err)
+	assert.Equal(t, "subaccesskey", accessKey)
+	assert.Equal(t, "subsecretkey", secretKey)
+	assert.Equal(t, []string{
+		"GET /admin/user?uid=myuser",
+		"PUT /admin/user?access=read&generate-secret=True&key-type=s3&subuser=myuser%3Abundle&uid=myuser",
+		"GET /admin/user?uid=myuser",
+	}, requests)
+
+	// the access of the existing subuser is changed, its key is kept
+	requests = []string{}
+	accessKey, _, err = client.SetSubuser("myuser", "bundle", "readwrite")
+	assert.Nil(t, err)
+	assert.Equal(t, "subaccesskey", accessKey)
+	assert.Equal(t, []string{
+		"GET /admin/user?uid=myuser",
+		"POST /admin/user?access=readwrite&subuser=myuser%3Abundle&uid=myuser",
+	}, requests)
+
+	// the key of the user is not the key of its subuser
+	user, _, err := client.GetUser("myuser")
+	assert.Nil(t, err)
+	assert.Equal(t, "useraccesskey", *user.AccessKey)
+
+	_, err = client.DeleteSubuser("myuser", "bundle")
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE /admin/user?purge-keys=True&subuser=myuser%3Abundle&uid=myuser", requests[3])
+}
+
+func TestAdminOpsQuota(t *testing.T) {
+	server := &adminOpsServer{}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client := newTestAdminOpsClient(t, "myaccesskey", httpServer.URL)
+
+	err := client.SetUserQuota("myuser", 100, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "PUT /admin/user?quota=&quota-type=user&uid=myuser", server.requests[0])
+	assert.Equal(t, `{"enabled":true,"max_objects":100,"max_size_kb":-1}`, server.bodies[0])
+
+	err = client.SetBucketQuota("myuser", "mybucket", -1, 1500)
+	assert.Nil(t, err)
+	assert.Equal(t, "PUT /admin/bucket?bucket=mybucket&quota=&uid=myuser", server.requests[1])
+	assert.Equal(t, `{"enabled":true,"max_objects":-1,"max_size_kb":2}`, server.bodies[1])
+
+	// the quota is disabled without limits
+	err = client.SetBucketQuota("myuser", "mybucket", -1, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"enabled":false,"max_objects":-1,"max_size_kb":-1}`, server.bodies[2])
+}
+
+func TestAdminOpsUsage(t *testing.T) {
+	server := &adminOpsServer{responses: map[string]string{
+		"/admin/usage": `{"entries":[],"summary":[
+			{"user":"otheruser","total":{"bytes_sent":1,"bytes_received":2,"ops":3,"successful_ops":3}},
+			{"user":"myuser","total":{"bytes_sent":100,"bytes_received":200,"ops":10,"successful_ops":9}}]}`,
+	}}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client := newTestAdminOpsClient(t, "myaccesskey", httpServer.URL)
+
+	usage, err := client.GetUsage("myuser")
+	assert.Nil(t, err)
+	assert.Equal(t, "GET /admin/usage?show-entries=False&show-summary=True&uid=myuser", server.requests[0])
+	assert.Equal(t, ObjectUserUsage{BytesSent: 100, BytesReceived: 200, Ops: 10, SuccessfulOps: 9}, *usage)
+
+	// the user has no usage yet
+	usage, err = client.GetUsage("newuser")
+	assert.Nil(t, err)
+	assert.Equal(t, ObjectUserUsage{}, *usage)
+}
+
+func TestAdminOpsCACert(t *testing.T) {
+	server := &adminOpsServer{responses: map[string]string{
+		"/admin/usage": `{"entries":[],"summary":[]}`,
+	}}
+	httpServer := httptest.NewTLSServer(server)
+	defer httpServer.Close()
+
+	// the self-signed certificate of the endpoint is not trusted by default
+	client := newTestAdminOpsClient(t, "myaccesskey", httpServer.URL)
+	_, err := client.GetUsage("myuser")
+	assert.NotNil(t, err)
+
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: httpServer.Certificate().Raw})
+	client, err = NewAdminOpsClient("myaccesskey", "mysecretkey", httpServer.URL, caCert)
+	require.Nil(t, err)
+	_, err = client.GetUsage("myuser")
+	assert.Nil(t, err)
+
+	_, err = NewAdminOpsClient("myaccesskey", "mysecretkey", httpServer.URL, []byte("not a certificate"))
+	assert.NotNil(t, err)
+}
+
+func newTestAdminOpsClient(t *testing.T, accessKey, endpoint string) *AdminOpsClient {
+	client, err := NewAdminOpsClient(accessKey, "mysecretkey", endpoint, nil)
+	require.Nil(t, err)
+	return client
+}
@@ -42,6 +42,7 @@ caps osd = "allow *"
 type Config struct {
 	Name            string
 	Host            string
+	DNSName         string
 	Port            int
 	SecurePort      int
 	Keyring         string
@@ -96,6 +97,10 @@ func generateConfigFiles(context *clusterd.Context, config *Config) error {
 		"rgw_zone":                       config.Name,
 		"rgw_zonegroup":                  config.Name,
 	}
+	if config.DNSName != "" {
+		// the domain of the virtual hosted style requests, the other names are the hostnames of the zonegroup
+		settings["rgw dns name"] = config.DNSName
+	}
 	configFile, err := cephconfig.GenerateConfigFile(context, config.ClusterInfo, getRGWConfDir(context.ConfigDir),
 		"client.radosgw.gateway", getRGWKeyringPath(context.ConfigDir), nil, settings)
 	if err != nil {
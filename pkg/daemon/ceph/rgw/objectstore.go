@@ -19,6 +19,10 @@ package rgw
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
 	"strings"
 
 	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
@@ -159,6 +163,56 @@ func createRealm(context *Context, serviceIP string, port int32) error {
 	return nil
 }
 
+// SetHostnames sets the hostnames of the zonegroup of the object store. The rgw accepts the virtual hosted style
+// requests for the buckets that are subdomains of the hostnames.
+func SetHostnames(context *Context, hostnames []string) error {
+	output, err := runAdminCommand(context, "zonegroup", "get")
+	if err != nil {
+		return fmt.Errorf("failed to get rgw zonegroup %s. %+v", context.Name, err)
+	}
+	var zonegroup map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &zonegroup); err != nil {
+		return fmt.Errorf("failed to unmarshal rgw zonegroup %s. %+v", context.Name, err)
+	}
+
+	current := []string{}
+	if list, ok := zonegroup["hostnames"].([]interface{}); ok {
+		for _, h := range list {
+			if hostname, ok := h.(string); ok {
+				current = append(current, hostname)
+			}
+		}
+	}
+	if hostnames == nil {
+		hostnames = []string{}
+	}
+	if reflect.DeepEqual(current, hostnames) {
+		logger.Debugf("rgw zonegroup %s hostnames %v did not change", context.Name, hostnames)
+		return nil
+	}
+
+	zonegroup["hostnames"] = hostnames
+	data, err := json.Marshal(zonegroup)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rgw zonegroup %s. %+v", context.Name, err)
+	}
+	file := path.Join(context.context.ConfigDir, fmt.Sprintf("%s-zonegroup.json", context.Name))
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		return fmt.Errorf("failed to write rgw zonegroup %s. %+v", context.Name, err)
+	}
+	defer os.Remove(file)
+
+	if _, err := runAdminCommand(context, "zonegroup", "set", "--infile", file); err != nil {
+		return fmt.Errorf("failed to set rgw zonegroup %s hostnames. %+v", context.Name, err)
+	}
+	if _, err := runAdminCommandNoRealm(context, "period", "update", "--commit"); err != nil {
+		return fmt.Errorf("failed to update period. %+v", err)
+	}
+
+	logger.Infof("set rgw zonegroup %s hostnames to %v", context.Name, hostnames)
+	return nil
+}
+
 func deleteRealm(context *Context) error {
 	//  <name>
 	_, err := runAdminCommand(context, "realm", "delete", "--rgw-realm", context.Name)
@@ -252,7 +306,14 @@ func createPools(context *Context, metadataSpec, dataSpec model.Pool) error {
 }
 
 func createSimilarPools(context *Context, pools []string, poolSpec model.Pool) error {
+	// the erasure code profile of the pools is named after the object store
 	poolSpec.Name = context.Name
+	return createPoolsWithProfile(context, pools, poolSpec)
+}
+
+// createPoolsWithProfile creates the pools that do not exist yet. The erasure code profile of the EC pools is named
+// after the name of the pool spec.
+func createPoolsWithProfile(context *Context, pools []string, poolSpec model.Pool) error {
 	cephConfig := ceph.ModelPoolToCephPool(poolSpec)
 	isECPool := cephConfig.ErasureCodeProfile != ""
 	if isECPool {
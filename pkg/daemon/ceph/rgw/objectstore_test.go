@@ -18,6 +18,8 @@ package rgw
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/rook/rook/pkg/clusterd"
@@ -160,3 +162,50 @@ func deleteStore(t *testing.T, name string, existingStores string, expectedDelet
 	assert.Equal(t, expectedDeleteRootPool, deletedRootPool)
 	assert.Equal(t, true, deletedErasureCodeProfile)
 }
+
+func TestSetHostnames(t *testing.T) {
+	zonegroup := `{"id":"test-id","name":"myobject","hostnames":["s3.example.com"],"zones":[]}`
+	var setZonegroup string
+	committed := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			logger.Infof("Execute: %s %v", command, args)
+			if args[0] == "zonegroup" && args[1] == "get" {
+				return zonegroup, nil
+			}
+			if args[0] == "zonegroup" && args[1] == "set" {
+				assert.Equal(t, "--infile", args[2])
+				data, err := ioutil.ReadFile(args[3])
+				assert.Nil(t, err)
+				setZonegroup = string(data)
+				return setZonegroup, nil
+			}
+			if args[0] == "period" {
+				committed = true
+				return "", nil
+			}
+			return "", fmt.Errorf("unexpected command %v", args)
+		},
+	}
+
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	objContext := NewContext(&clusterd.Context{Executor: executor, ConfigDir: configDir}, "myobject", "mycluster")
+
+	// the zonegroup is not set again when the hostnames did not change
+	err := SetHostnames(objContext, []string{"s3.example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, "", setZonegroup)
+	assert.False(t, committed)
+
+	// the other settings of the zonegroup are kept
+	err = SetHostnames(objContext, []string{"s3.example.com", "objects.example.com"})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id":"test-id","name":"myobject","hostnames":["s3.example.com","objects.example.com"],"zones":[]}`, setZonegroup)
+	assert.True(t, committed)
+
+	// the hostnames are removed
+	err = SetHostnames(objContext, nil)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"id":"test-id","name":"myobject","hostnames":[],"zones":[]}`, setZonegroup)
+}
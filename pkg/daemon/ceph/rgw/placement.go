@@ -0,0 +1,238 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rgw
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/daemon/ceph/model"
+)
+
+const (
+	// DefaultPlacement is the placement target created with the zone, storing its STANDARD class in the data pool
+	DefaultPlacement = "default-placement"
+	// StandardStorageClass is the storage class of the objects when the requests do not select one
+	StandardStorageClass = "STANDARD"
+)
+
+// PlacementTarget is a placement target of an object store and the pools of its storage classes
+type PlacementTarget struct {
+	Name string
+	// The pool of the STANDARD storage class, nil for the default placement stored in the data pool of the object store
+	DataPool *model.Pool
+	// The storage classes in addition to STANDARD
+	StorageClasses []StorageClass
+}
+
+// StorageClass is a storage class of a placement target
+type StorageClass struct {
+	Name     string
+	DataPool model.Pool
+}
+
+type zonePlacement struct {
+	PlacementPools []struct {
+		Key string `json:"key"`
+		Val struct {
+			IndexPool      string                     `json:"index_pool"`
+			DataExtraPool  string                     `json:"data_extra_pool"`
+			StorageClasses map[string]json.RawMessage `json:"storage_classes"`
+		} `json:"val"`
+	} `json:"placement_pools"`
+}
+
+type zonegroupPlacement struct {
+	PlacementTargets []struct {
+		Name           string   `json:"name"`
+		StorageClasses []string `json:"storage_classes"`
+	} `json:"placement_targets"`
+}
+
+// placementPool is the name of the pool of a storage class of a placement target, without the object store prefix
+func placementPool(target, storageClass string) string {
+	if storageClass == StandardStorageClass {
+		return fmt.Sprintf("rgw.%s.data", target)
+	}
+	return fmt.Sprintf("rgw.%s.%s.data", target, strings.ToLower(storageClass))
+}
+
+// ConfigurePlacement creates the pools of the placement targets and adds the targets and storage classes missing from
+// the zonegroup and the zone of the object store. The targets and classes already configured are not changed.
+func ConfigurePlacement(context *Context, targets []PlacementTarget) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	if err := createPlacementPools(context, targets); err != nil {
+		return err
+	}
+
+	zoneArg := fmt.Sprintf("--rgw-zone=%s", context.Name)
+	output, err := runAdminCommand(context, "zonegroup", "get")
+	if err != nil {
+		return fmt.Errorf("failed to get rgw zonegroup %s. %+v", context.Name, err)
+	}
+	var zonegroup zonegroupPlacement
+	if err := json.Unmarshal([]byte(output), &zonegroup); err != nil {
+		return fmt.Errorf("failed to unmarshal rgw zonegroup %s. %+v", context.Name, err)
+	}
+	output, err = runAdminCommand(context, "zone", "get", zoneArg)
+	if err != nil {
+		return fmt.Errorf("failed to get rgw zone %s. %+v", context.Name, err)
+	}
+	var zone zonePlacement
+	if err := json.Unmarshal([]byte(output), &zone); err != nil {
+		return fmt.Errorf("failed to unmarshal rgw zone %s. %+v", context.Name, err)
+	}
+
+	// the classes configured for each target in the zonegroup and the zone
+	zonegroupClasses := map[string]map[string]bool{}
+	for _, t := range zonegroup.PlacementTargets {
+		zonegroupClasses[t.Name] = map[string]bool{}
+		for _, class := range t.StorageClasses {
+			zonegroupClasses[t.Name][class] = true
+		}
+	}
+	zoneClasses := map[string]map[string]bool{}
+	indexPool := poolName(context.Name, "rgw.buckets.index")
+	dataExtraPool := ""
+	for _, p := range zone.PlacementPools {
+		zoneClasses[p.Key] = map[string]bool{}
+		for class := range p.Val.StorageClasses {
+			zoneClasses[p.Key][class] = true
+		}
+		// the new targets share the index pool of the default placement
+		if p.Key == DefaultPlacement {
+			indexPool = p.Val.IndexPool
+			dataExtraPool = p.Val.DataExtraPool
+		}
+	}
+
+	updatePeriod := false
+	for _, target := range targets {
+		placementArg := fmt.Sprintf("--placement-id=%s", target.Name)
+		if _, ok := zonegroupClasses[target.Name]; !ok {
+			if _, err := runAdminCommand(context, "zonegroup", "placement", "add", placementArg); err != nil {
+				return fmt.Errorf("failed to add placement target %s to rgw zonegroup %s. %+v", target.Name, context.Name, err)
+			}
+			updatePeriod = true
+		}
+		if _, ok := zoneClasses[target.Name]; !ok && target.DataPool != nil {
+			args := []string{"zone", "placement", "add", zoneArg, placementArg,
+				fmt.Sprintf("--data-pool=%s", poolName(context.Name, placementPool(target.Name, StandardStorageClass))),
+				fmt.Sprintf("--index-pool=%s", indexPool)}
+			if dataExtraPool != "" {
+				args = append(args, fmt.Sprintf("--data-extra-pool=%s", dataExtraPool))
+			}
+			if _, err := runAdminCommand(context, args...); err != nil {
+				return fmt.Errorf("failed to add placement target %s to rgw zone %s. %+v", target.Name, context.Name, err)
+			}
+			updatePeriod = true
+		}
+
+		for _, class := range target.StorageClasses {
+			classArg := fmt.Sprintf("--storage-class=%s", class.Name)
+			if !zonegroupClasses[target.Name][class.Name] {
+				if _, err := runAdminCommand(context, "zonegroup", "placement", "add", placementArg, classArg); err != nil {
+					return fmt.Errorf("failed to add storage class %s of placement target %s to rgw zonegroup %s. %+v", class.Name, target.Name, context.Name, err)
+				}
+				updatePeriod = true
+			}
+			if !zoneClasses[target.Name][class.Name] {
+				dataPoolArg := fmt.Sprintf("--data-pool=%s", poolName(context.Name, placementPool(target.Name, class.Name)))
+				if _, err := runAdminCommand(context, "zone", "placement", "add", zoneArg, placementArg, classArg, dataPoolArg); err != nil {
+					return fmt.Errorf("failed to add storage class %s of placement target %s to rgw zone %s. %+v", class.Name, target.Name, context.Name, err)
+				}
+				updatePeriod = true
+			}
+		}
+	}
+
+	if !updatePeriod {
+		logger.Debugf("rgw placement targets of object store %s did not change", context.Name)
+		return nil
+	}
+	if _, err := runAdminCommandNoRealm(context, "period", "update", "--commit"); err != nil {
+		return fmt.Errorf("failed to update period. %+v", err)
+	}
+	logger.Infof("configured the rgw placement targets of object store %s", context.Name)
+	return nil
+}
+
+// createPlacementPools creates the pools of the storage classes, each with its own erasure code profile
+func createPlacementPools(context *Context, targets []PlacementTarget) error {
+	for _, target := range targets {
+		pools := map[string]model.Pool{}
+		if target.DataPool != nil {
+			pools[StandardStorageClass] = *target.DataPool
+		}
+		for _, class := range target.StorageClasses {
+			pools[class.Name] = class.DataPool
+		}
+		for class, poolSpec := range pools {
+			pool := placementPool(target.Name, class)
+			poolSpec.Name = poolName(context.Name, pool)
+			if err := createPoolsWithProfile(context, []string{pool}, poolSpec); err != nil {
+				return fmt.Errorf("failed to create the pool of storage class %s of placement target %s. %+v", class, target.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// DeletePlacementPools deletes the pools of the storage classes of the placement targets and their erasure code profiles
+func DeletePlacementPools(context *Context, targets []PlacementTarget) error {
+	names := []string{}
+	for _, target := range targets {
+		if target.DataPool != nil {
+			names = append(names, poolName(context.Name, placementPool(target.Name, StandardStorageClass)))
+		}
+		for _, class := range target.StorageClasses {
+			names = append(names, poolName(context.Name, placementPool(target.Name, class.Name)))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	for _, name := range names {
+		if err := ceph.DeletePool(context.context, context.ClusterName, name); err != nil {
+			logger.Warningf("failed to delete pool %s. %+v", name, err)
+		}
+	}
+
+	erasureCodes, err := ceph.ListErasureCodeProfiles(context.context, context.ClusterName)
+	if err != nil {
+		return fmt.Errorf("failed to list erasure code profiles for cluster %s: %+v", context.ClusterName, err)
+	}
+	profiles := map[string]bool{}
+	for _, profile := range erasureCodes {
+		profiles[profile] = true
+	}
+	for _, name := range names {
+		profile := ceph.GetErasureCodeProfileForPool(name)
+		if !profiles[profile] {
+			continue
+		}
+		if err := ceph.DeleteErasureCodeProfile(context.context, context.ClusterName, profile); err != nil {
+			return fmt.Errorf("failed to delete erasure code profile %s for object store %s: %+v", profile, context.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rgw
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/model"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func testPlacementTargets() []PlacementTarget {
+	replicated := model.Pool{Type: model.Replicated, ReplicatedConfig: model.ReplicatedPoolConfig{Size: 3}}
+	erasureCoded := model.Pool{Type: model.ErasureCoded, ErasureCodedConfig: model.ErasureCodedPoolConfig{DataChunkCount: 2, CodingChunkCount: 1, Plugin: "jerasure"}}
+	return []PlacementTarget{
+		{Name: DefaultPlacement, StorageClasses: []StorageClass{{Name: "COLD", DataPool: erasureCoded}}},
+		{Name: "fast", DataPool: &replicated, StorageClasses: []StorageClass{{Name: "COLD", DataPool: erasureCoded}}},
+	}
+}
+
+func TestConfigurePlacement(t *testing.T) {
+	zonegroup := `{"id":"zg","placement_targets":[{"name":"default-placement","tags":[],"storage_classes":["STANDARD"]}]}`
+	zone := `{"id":"z","placement_pools":[{"key":"default-placement","val":{"index_pool":"myobj.rgw.buckets.index",
+		"storage_classes":{"STANDARD":{"data_pool":"myobj.rgw.buckets.data"}},"data_extra_pool":"myobj.rgw.buckets.non-ec"}}]}`
+	createdPools := []string{}
+	profiles := []string{}
+	adminCommands := []string{}
+	committed := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "pool" && args[2] == "get" {
+				return "", fmt.Errorf("induce a create")
+			}
+			if args[0] == "osd" && args[1] == "pool" && args[2] == "create" {
+				createdPools = append(createdPools, args[3])
+			}
+			if args[0] == "osd" && args[1] == "erasure-code-profile" && args[2] == "set" {
+				profiles = append(profiles, args[3])
+			}
+			return "", nil
+		},
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			logger.Infof("Execute: %s %v", command, args)
+			if args[1] == "get" {
+				if args[0] == "zonegroup" {
+					return zonegroup, nil
+				}
+				return zone, nil
+			}
+			if args[0] == "period" {
+				committed = true
+				return "", nil
+			}
+			// the realm, zonegroup and cluster args follow the command args
+			command = strings.Join(args, " ")
+			adminCommands = append(adminCommands, command[:strings.Index(command, " --rgw-realm")])
+			return "", nil
+		},
+	}
+	objContext := NewContext(&clusterd.Context{Executor: executor}, "myobj", "ns")
+
+	// no placement targets
+	err := ConfigurePlacement(objContext, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(createdPools))
+	assert.False(t, committed)
+
+	// the pools of the classes are created with their own erasure code profile
+	err = ConfigurePlacement(objContext, testPlacementTargets())
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"myobj.rgw.default-placement.cold.data", "myobj.rgw.fast.data", "myobj.rgw.fast.cold.data"}, createdPools)
+	assert.ElementsMatch(t, []string{"myobj.rgw.default-placement.cold.data_ecprofile", "myobj.rgw.fast.cold.data_ecprofile"}, profiles)
+
+	// the missing targets and classes are added to the zonegroup and the zone
+	assert.Equal(t, []string{
+		"zonegroup placement add --placement-id=default-placement --storage-class=COLD",
+		"zone placement add --rgw-zone=myobj --placement-id=default-placement --storage-class=COLD --data-pool=myobj.rgw.default-placement.cold.data",
+		"zonegroup placement add --placement-id=fast",
+		"zone placement add --rgw-zone=myobj --placement-id=fast --data-pool=myobj.rgw.fast.data --index-pool=myobj.rgw.buckets.index --data-extra-pool=myobj.rgw.buckets.non-ec",
+		"zonegroup placement add --placement-id=fast --storage-class=COLD",
+		"zone placement add --rgw-zone=myobj --placement-id=fast --storage-class=COLD --data-pool=myobj.rgw.fast.cold.data",
+	}, adminCommands)
+	assert.True(t, committed)
+
+	// nothing is added when the targets are already configured
+	zonegroup = `{"id":"zg","placement_targets":[{"name":"default-placement","storage_classes":["COLD","STANDARD"]},
+		{"name":"fast","storage_classes":["COLD","STANDARD"]}]}`
+	zone = `{"id":"z","placement_pools":[{"key":"default-placement","val":{"storage_classes":{"STANDARD":{},"COLD":{}}}},
+		{"key":"fast","val":{"storage_classes":{"STANDARD":{},"COLD":{}}}}]}`
+	adminCommands = []string{}
+	committed = false
+	err = ConfigurePlacement(objContext, testPlacementTargets())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(adminCommands))
+	assert.False(t, committed)
+}
+
+func TestDeletePlacementPools(t *testing.T) {
+	deletedPools := []string{}
+	deletedProfiles := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "pool" {
+				if args[2] == "get" {
+					return `{"pool_id":1}`, nil
+				}
+				if args[2] == "delete" {
+					deletedPools = append(deletedPools, args[3])
+				}
+			}
+			if args[0] == "osd" && args[1] == "erasure-code-profile" {
+				if args[2] == "ls" {
+					return `["default","myobj_ecprofile","myobj.rgw.fast.cold.data_ecprofile"]`, nil
+				}
+				if args[2] == "rm" {
+					deletedProfiles = append(deletedProfiles, args[3])
+				}
+			}
+			return "", nil
+		},
+	}
+	objContext := NewContext(&clusterd.Context{Executor: executor}, "myobj", "ns")
+
+	err := DeletePlacementPools(objContext, testPlacementTargets())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"myobj.rgw.default-placement.cold.data", "myobj.rgw.fast.data", "myobj.rgw.fast.cold.data"}, deletedPools)
+	// only the profiles of the ec pools that exist are deleted
+	assert.Equal(t, []string{"myobj.rgw.fast.cold.data_ecprofile"}, deletedProfiles)
+}
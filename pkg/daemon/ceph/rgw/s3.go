@@ -0,0 +1,134 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rgw
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// the region is ignored by rgw, but it is required by the s3 client
+	s3Region = "us-east-1"
+)
+
+// S3Agent runs the s3 requests of a user against the rgw endpoint of an object store
+type S3Agent struct {
+	client *s3.S3
+}
+
+// NewS3Agent creates an agent for the s3 requests of a user with its keys. The endpoint is the http or https url of the
+// rgw service.
+func NewS3Agent(accessKey, secretKey, endpoint string) (*S3Agent, error) {
+	sess, err := session.NewSession(aws.NewConfig().
+		WithRegion(s3Region).
+		WithCredentials(credentials.NewStaticCredentials(accessKey, secretKey, "")).
+		WithEndpoint(endpoint).
+		WithS3ForcePathStyle(true).
+		WithDisableSSL(strings.HasPrefix(endpoint, "http://")).
+		WithMaxRetries(3))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the s3 session. %+v", err)
+	}
+	return &S3Agent{client: s3.New(sess)}, nil
+}
+
+// CreateBucket creates the bucket if the user does not own it yet
+func (a *S3Agent) CreateBucket(name string) error {
+	_, err := a.client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String(name)})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeBucketAlreadyOwnedByYou {
+			return nil
+		}
+		return fmt.Errorf("failed to create bucket %s. %+v", name, err)
+	}
+	logger.Infof("created bucket %s", name)
+	return nil
+}
+
+// PutBucketLifecycle replaces the lifecycle rules of the bucket, or removes them when there is no rule
+func (a *S3Agent) PutBucketLifecycle(name string, rules []*s3.LifecycleRule) error {
+	if len(rules) == 0 {
+		if _, err := a.client.DeleteBucketLifecycle(&s3.DeleteBucketLifecycleInput{Bucket: aws.String(name)}); err != nil {
+			return fmt.Errorf("failed to delete the lifecycle of bucket %s. %+v", name, err)
+		}
+		return nil
+	}
+
+	_, err := a.client.PutBucketLifecycleConfiguration(&s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(name),
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{Rules: rules},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put the lifecycle of bucket %s. %+v", name, err)
+	}
+	return nil
+}
+
+// PutBucketPolicy replaces the policy of the bucket, or removes it when the policy is empty
+func (a *S3Agent) PutBucketPolicy(name, policy string) error {
+	if policy == "" {
+		if _, err := a.client.DeleteBucketPolicy(&s3.DeleteBucketPolicyInput{Bucket: aws.String(name)}); err != nil {
+			return fmt.Errorf("failed to delete the policy of bucket %s. %+v", name, err)
+		}
+		return nil
+	}
+
+	if _, err := a.client.PutBucketPolicy(&s3.PutBucketPolicyInput{Bucket: aws.String(name), Policy: aws.String(policy)}); err != nil {
+		return fmt.Errorf("failed to put the policy of bucket %s. %+v", name, err)
+	}
+	return nil
+}
+
+// PutObject uploads the content of an object to the bucket
+func (a *S3Agent) PutObject(bucket, key string, body []byte) error {
+	if _, err := a.client.PutObject(&s3.PutObjectInput{Bucket: aws.String(bucket), Key: aws.String(key), Body: bytes.NewReader(body)}); err != nil {
+		return fmt.Errorf("failed to put object %s in bucket %s. %+v", key, bucket, err)
+	}
+	return nil
+}
+
+// ListObjects returns the keys of the objects of the bucket with the prefix
+func (a *S3Agent) ListObjects(bucket, prefix string) ([]string, error) {
+	var keys []string
+	input := &s3.ListObjectsInput{Bucket: aws.String(bucket), Prefix: aws.String(prefix)}
+	err := a.client.ListObjectsPages(input, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, object := range page.Contents {
+			keys = append(keys, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the objects of bucket %s. %+v", bucket, err)
+	}
+	return keys, nil
+}
+
+// DeleteObject deletes an object of the bucket
+func (a *S3Agent) DeleteObject(bucket, key string) error {
+	if _, err := a.client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)}); err != nil {
+		return fmt.Errorf("failed to delete object %s of bucket %s. %+v", key, bucket, err)
+	}
+	return nil
+}
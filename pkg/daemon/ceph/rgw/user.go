@@ -58,6 +58,8 @@ type rgwUserInfo struct {
 	DisplayName string `json:"display_name"`
 	Email       string `json:"email"`
 	Keys        []struct {
+		// the user or the subuser of the key
+		User      string `json:"user"`
 		AccessKey string `json:"access_key"`
 		SecretKey string `json:"secret_key"`
 	}
@@ -70,14 +72,7 @@ func decodeUser(data string) (*ObjectUser, int, error) {
 		return nil, RGWErrorParse, fmt.Errorf("Failed to unmarshal json: %+v", err)
 	}
 
-	rookUser := ObjectUser{UserID: user.UserID, DisplayName: &user.DisplayName, Email: &user.Email}
-
-	if len(user.Keys) > 0 {
-		rookUser.AccessKey = &user.Keys[0].AccessKey
-		rookUser.SecretKey = &user.Keys[0].SecretKey
-	}
-
-	return &rookUser, RGWErrorNone, nil
+	return objectUser(user), RGWErrorNone, nil
 }
 
 func GetUser(c *Context, id string) (*ObjectUser, int, error) {
@@ -168,3 +163,26 @@ func DeleteUser(c *Context, id string) (string, int, error) {
 
 	return result, RGWErrorNone, nil
 }
+
+// GetOrCreateAdminOpsUser gets the user of the admin ops api of the object store, or creates it with the admin caps. The
+// user is created with radosgw-admin since the admin ops api requires its keys.
+func GetOrCreateAdminOpsUser(c *Context, id string) (*ObjectUser, error) {
+	user, _, err := GetUser(c, id)
+	if err == nil && user.AccessKey != nil {
+		return user, nil
+	}
+
+	logger.Infof("creating admin ops user %s", id)
+	result, err := runAdminCommand(c, "user", "create", "--uid", id, "--display-name", id, "--caps", AdminOpsCaps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create admin ops user %s. %+v", id, err)
+	}
+	user, _, err = decodeUser(result)
+	if err != nil {
+		return nil, err
+	}
+	if user.AccessKey == nil {
+		return nil, fmt.Errorf("no key created for admin ops user %s", id)
+	}
+	return user, nil
+}
@@ -31,7 +31,12 @@ const (
 )
 
 // FindRBDMappedFile search for the mapped RBD volume and returns its device path
+// The pool can be given as pool/namespace for the images of a RADOS namespace.
 func FindRBDMappedFile(imageName, poolName, sysBusDir string) (string, error) {
+	namespace := ""
+	if i := strings.Index(poolName, "/"); i >= 0 {
+		poolName, namespace = poolName[:i], poolName[i+1:]
+	}
 
 	sysBusDeviceDir := filepath.Join(sysBusDir, RBDDevicesDir)
 	// if sysPath does not exist, no attachments has happened
@@ -49,7 +54,7 @@ func FindRBDMappedFile(imageName, poolName, sysBusDir string) (string, error) {
 		if err == nil && imageName == strings.TrimSpace(string(nameContent)) {
 			// the image for the current rbd device matches, now try to match pool
 			poolContent, err := ioutil.ReadFile(filepath.Join(sysBusDeviceDir, idFile.Name(), "pool"))
-			if err == nil && poolName == strings.TrimSpace(string(poolContent)) {
+			if err == nil && poolName == strings.TrimSpace(string(poolContent)) && namespace == readRBDPoolNamespace(sysBusDeviceDir, idFile.Name()) {
 				// match current device matches both image name and pool name, return the device
 				return idFile.Name(), nil
 			}
@@ -57,3 +62,12 @@ func FindRBDMappedFile(imageName, poolName, sysBusDir string) (string, error) {
 	}
 	return "", nil
 }
+
+// readRBDPoolNamespace returns the RADOS namespace of a mapped device, empty if the kernel is too old to know them
+func readRBDPoolNamespace(sysBusDeviceDir, id string) string {
+	content, err := ioutil.ReadFile(filepath.Join(sysBusDeviceDir, id, "pool_ns"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
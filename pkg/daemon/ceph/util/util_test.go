@@ -39,3 +39,29 @@ func TestFindDevicePath(t *testing.T) {
 	mappedImageFile, _ := FindRBDMappedFile("myimage1", "mypool1", mockRBDSysBusPath)
 	assert.Equal(t, "3", mappedImageFile)
 }
+
+func TestFindDevicePathInNamespace(t *testing.T) {
+	mockRBDSysBusPath, err := ioutil.TempDir("", "TestFindDevicePathInNamespace")
+	if err != nil {
+		t.Fatalf("failed to create temp rbd sys bus dir: %+v", err)
+	}
+	defer os.RemoveAll(mockRBDSysBusPath)
+	// the same image name in the default namespace and in the namespace of a tenant
+	dev0Path := filepath.Join(mockRBDSysBusPath, "devices", "0")
+	os.MkdirAll(dev0Path, 0777)
+	ioutil.WriteFile(filepath.Join(dev0Path, "name"), []byte("myimage1"), 0777)
+	ioutil.WriteFile(filepath.Join(dev0Path, "pool"), []byte("mypool1"), 0777)
+	ioutil.WriteFile(filepath.Join(dev0Path, "pool_ns"), []byte("\n"), 0777)
+	dev1Path := filepath.Join(mockRBDSysBusPath, "devices", "1")
+	os.MkdirAll(dev1Path, 0777)
+	ioutil.WriteFile(filepath.Join(dev1Path, "name"), []byte("myimage1"), 0777)
+	ioutil.WriteFile(filepath.Join(dev1Path, "pool"), []byte("mypool1"), 0777)
+	ioutil.WriteFile(filepath.Join(dev1Path, "pool_ns"), []byte("tenant-a\n"), 0777)
+
+	mappedImageFile, _ := FindRBDMappedFile("myimage1", "mypool1/tenant-a", mockRBDSysBusPath)
+	assert.Equal(t, "1", mappedImageFile)
+	mappedImageFile, _ = FindRBDMappedFile("myimage1", "mypool1", mockRBDSysBusPath)
+	assert.Equal(t, "0", mappedImageFile)
+	mappedImageFile, _ = FindRBDMappedFile("myimage1", "mypool1/tenant-b", mockRBDSysBusPath)
+	assert.Equal(t, "", mappedImageFile)
+}
@@ -41,6 +41,8 @@ const (
 	agentDaemonsetTolerationEnv    = "AGENT_TOLERATION"
 	agentDaemonsetTolerationKeyEnv = "AGENT_TOLERATION_KEY"
 	AgentMountSecurityModeEnv      = "AGENT_MOUNT_SECURITY_MODE"
+	agentMetricsPortEnv            = "AGENT_METRICS_PORT"
+	kubeletPodsDirPath             = "/var/lib/kubelet/pods"
 
 	// MountSecurityModeAny "any" security mode for the agent for mount action
 	MountSecurityModeAny = "Any"
@@ -86,6 +88,9 @@ func (a *Agent) createAgentDaemonSet(namespace, agentImage, serviceAccount strin
 	}
 
 	privileged := true
+	// the volumes mounted in the pods after the agent started are visible to the agent, and the filesystems mounted
+	// by the agent with ceph-fuse are visible to the pods
+	mountPropagation := v1.MountPropagationBidirectional
 	ds := &extensions.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: agentDaemonsetName,
@@ -127,12 +132,19 @@ func (a *Agent) createAgentDaemonSet(namespace, agentImage, serviceAccount strin
 									Name:      "libmodules",
 									MountPath: "/lib/modules",
 								},
+								{
+									// the mounts of the pods are frozen during the backup snapshots, and mounted with ceph-fuse
+									// on the nodes without the ceph kernel client
+									Name:             "kubelet-pods",
+									MountPath:        kubeletPodsDirPath,
+									MountPropagation: &mountPropagation,
+								},
 							},
-							Env: []v1.EnvVar{
+							Env: append([]v1.EnvVar{
 								k8sutil.NamespaceEnvVar(),
 								k8sutil.NodeEnvVar(),
 								{Name: AgentMountSecurityModeEnv, Value: agentMountSecurityMode},
-							},
+							}, k8sutil.LogFormatEnvVars(false)...),
 						},
 					},
 					Volumes: []v1.Volume{
@@ -168,6 +180,14 @@ func (a *Agent) createAgentDaemonSet(namespace, agentImage, serviceAccount strin
 								},
 							},
 						},
+						{
+							Name: "kubelet-pods",
+							VolumeSource: v1.VolumeSource{
+								HostPath: &v1.HostPathVolumeSource{
+									Path: kubeletPodsDirPath,
+								},
+							},
+						},
 					},
 					HostNetwork: true,
 				},
@@ -204,6 +224,12 @@ func (a *Agent) createAgentDaemonSet(namespace, agentImage, serviceAccount strin
 		}
 	}
 
+	// Serve the agent metrics if a port is given
+	if metricsPort := os.Getenv(agentMetricsPortEnv); metricsPort != "" {
+		ds.Spec.Template.Spec.Containers[0].Env = append(ds.Spec.Template.Spec.Containers[0].Env,
+			v1.EnvVar{Name: "ROOK_METRICS_PORT", Value: metricsPort})
+	}
+
 	// Add toleration if any
 	tolerationValue := os.Getenv(agentDaemonsetTolerationEnv)
 	if tolerationValue != "" {
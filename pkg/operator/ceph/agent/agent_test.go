@@ -69,9 +69,10 @@ func TestStartAgentDaemonset(t *testing.T) {
 	assert.Equal(t, "mysa", agentDS.Spec.Template.Spec.ServiceAccountName)
 	assert.True(t, *agentDS.Spec.Template.Spec.Containers[0].SecurityContext.Privileged)
 	volumes := agentDS.Spec.Template.Spec.Volumes
-	assert.Equal(t, 4, len(volumes))
+	assert.Equal(t, 5, len(volumes))
 	volumeMounts := agentDS.Spec.Template.Spec.Containers[0].VolumeMounts
-	assert.Equal(t, 4, len(volumeMounts))
+	assert.Equal(t, 5, len(volumeMounts))
+	assert.Equal(t, v1.MountPropagationBidirectional, *volumeMounts[4].MountPropagation)
 	envs := agentDS.Spec.Template.Spec.Containers[0].Env
 	assert.Equal(t, 3, len(envs))
 	image := agentDS.Spec.Template.Spec.Containers[0].Image
@@ -0,0 +1,443 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package api serves the authenticated admin REST API of the operator, for portals and automation to list the
+// clusters and their osds, get the inventory of the devices of the nodes, trigger the reconcile of a cluster and wipe the
+// devices of the removed osds.
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/discover"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-api")
+
+const (
+	// the key of the token in the secret of the api
+	tokenSecretKey = "token"
+	tokenLength    = 32
+
+	clustersPath  = "/api/v1/clusters"
+	inventoryPath = "/api/v1/inventory"
+)
+
+var (
+	// Port is the port the api is served on. The api is disabled if not set.
+	Port = 0
+
+	// TokenSecretName is the name of the secret in the namespace of the operator with the bearer token of the api.
+	// The secret is created with a random token if it does not exist.
+	TokenSecretName = "rook-ceph-admin-api"
+
+	// TLSSecretName is the name of the kubernetes.io/tls secret in the namespace of the operator with the certificate
+	// the api is served with. Without a certificate, the api only listens on localhost so the token is never sent in
+	// clear over the network, and is reached with kubectl port-forward.
+	TLSSecretName = ""
+
+	listDevices = discover.ListDevices
+	listOSDs    = oposd.ListOSDs
+)
+
+// ClusterInfo is the summary of a cluster returned by the api
+type ClusterInfo struct {
+	Name       string                    `json:"name"`
+	Namespace  string                    `json:"namespace"`
+	State      cephv1.ClusterState       `json:"state"`
+	Message    string                    `json:"message,omitempty"`
+	CephImage  string                    `json:"cephImage"`
+	Usage      *cephv1.ClusterUsage      `json:"usage,omitempty"`
+	Conditions []cephv1.ClusterCondition `json:"conditions,omitempty"`
+}
+
+// ZapFunc launches the job wiping a device of a node of the cluster of a namespace
+type ZapFunc func(namespace, nodeName, device string, dryRun bool) error
+
+// ZapRequest is the device wiped by a zap job started through the api
+type ZapRequest struct {
+	Node   string `json:"node"`
+	Device string `json:"device"`
+	DryRun bool   `json:"dryRun"`
+}
+
+// errorResponse is the body of the responses of the failed requests
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the admin api
+type Server struct {
+	context   *clusterd.Context
+	namespace string
+	mux       *http.ServeMux
+	zapDevice ZapFunc
+	// the token of the secret of the api, kept up to date by a watch of the secret
+	tokenLock sync.RWMutex
+	token     []byte
+}
+
+// New creates the admin api of the operator running in the given namespace, which starts the zap jobs with zapDevice
+func New(context *clusterd.Context, namespace string, zapDevice ZapFunc) *Server {
+	s := &Server{context: context, namespace: namespace, mux: http.NewServeMux(), zapDevice: zapDevice}
+	s.mux.HandleFunc(clustersPath, s.listClusters)
+	s.mux.HandleFunc(clustersPath+"/", s.handleCluster)
+	s.mux.HandleFunc(inventoryPath, s.getInventory)
+	return s
+}
+
+// Start serves the api on its port until the stop channel is closed. The api is not started if the port is not set.
+func (s *Server) Start(stopCh chan struct{}) error {
+	if Port <= 0 {
+		logger.Infof("admin api is disabled")
+		return nil
+	}
+	if err := s.ensureToken(); err != nil {
+		return fmt.Errorf("failed to create the token of the admin api. %+v", err)
+	}
+	s.startTokenWatch(stopCh)
+
+	server := &http.Server{Addr: fmt.Sprintf("127.0.0.1:%d", Port), Handler: s}
+	if TLSSecretName != "" {
+		tlsConfig, err := s.loadTLSConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load the certificate of the admin api. %+v", err)
+		}
+		server.Addr = fmt.Sprintf(":%d", Port)
+		server.TLSConfig = tlsConfig
+	}
+	go func() {
+		var err error
+		if server.TLSConfig != nil {
+			logger.Infof("serving the admin api with tls on port %d", Port)
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			logger.Infof("serving the admin api on localhost port %d. set a tls secret to serve it on the network of the pod", Port)
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logger.Errorf("admin api stopped. %+v", err)
+		}
+	}()
+	go func() {
+		<-stopCh
+		server.Close()
+	}()
+	return nil
+}
+
+// ServeHTTP authenticates the requests with the bearer token of the api before handling them
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.authenticate(r); err != nil {
+		logger.Warningf("unauthorized %s request to %s from %s. %+v", r.Method, r.URL.Path, r.RemoteAddr, err)
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// loadTLSConfig loads the certificate and the key of the tls secret of the api
+func (s *Server) loadTLSConfig() (*tls.Config, error) {
+	secret, err := s.context.Clientset.CoreV1().Secrets(s.namespace).Get(TLSSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s. %+v", TLSSecretName, err)
+	}
+	cert, err := tls.X509KeyPair(secret.Data[v1.TLSCertKey], secret.Data[v1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s or %s in secret %s. %+v", v1.TLSCertKey, v1.TLSPrivateKeyKey, TLSSecretName, err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// ensureToken creates the secret of the api with a random token if it does not exist
+func (s *Server) ensureToken() error {
+	existing, err := s.context.Clientset.CoreV1().Secrets(s.namespace).Get(TokenSecretName, metav1.GetOptions{})
+	if err == nil {
+		s.setToken(existing)
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	token := make([]byte, tokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: TokenSecretName, Namespace: s.namespace},
+		Data:       map[string][]byte{tokenSecretKey: []byte(hex.EncodeToString(token))},
+		Type:       v1.SecretTypeOpaque,
+	}
+	created, err := s.context.Clientset.CoreV1().Secrets(s.namespace).Create(secret)
+	if err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		// the secret was created meanwhile, its token is set by the watch
+		return nil
+	}
+	s.setToken(created)
+	logger.Infof("created the token of the admin api in secret %s", TokenSecretName)
+	return nil
+}
+
+// startTokenWatch watches the secret of the api until the stop channel is closed, so the token can be rotated
+// without restarting the operator
+func (s *Server) startTokenWatch(stopCh chan struct{}) {
+	watchlist := cache.NewListWatchFromClient(s.context.Clientset.CoreV1().RESTClient(), "secrets", s.namespace,
+		fields.OneTermEqualSelector("metadata.name", TokenSecretName))
+	_, controller := cache.NewInformer(watchlist, &v1.Secret{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc:    s.onTokenAdd,
+		UpdateFunc: s.onTokenUpdate,
+		DeleteFunc: s.onTokenDelete,
+	})
+	go controller.Run(stopCh)
+}
+
+func (s *Server) onTokenAdd(obj interface{}) {
+	if secret, ok := obj.(*v1.Secret); ok {
+		s.setToken(secret)
+	}
+}
+
+func (s *Server) onTokenUpdate(oldObj, newObj interface{}) {
+	if secret, ok := newObj.(*v1.Secret); ok {
+		logger.Infof("the token of the admin api was updated in secret %s", TokenSecretName)
+		s.setToken(secret)
+	}
+}
+
+func (s *Server) onTokenDelete(obj interface{}) {
+	logger.Warningf("secret %s was deleted, the admin api rejects all the requests until it is created again", TokenSecretName)
+	s.setToken(nil)
+}
+
+func (s *Server) setToken(secret *v1.Secret) {
+	s.tokenLock.Lock()
+	defer s.tokenLock.Unlock()
+	s.token = nil
+	if secret != nil {
+		s.token = secret.Data[tokenSecretKey]
+	}
+}
+
+// authenticate checks the bearer token of a request against the token of the secret of the api
+func (s *Server) authenticate(r *http.Request) error {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return fmt.Errorf("no bearer token")
+	}
+	s.tokenLock.RLock()
+	token := s.token
+	s.tokenLock.RUnlock()
+	if len(token) == 0 {
+		return fmt.Errorf("no %s key in secret %s", tokenSecretKey, TokenSecretName)
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, "Bearer ")), token) != 1 {
+		return fmt.Errorf("invalid token")
+	}
+	return nil
+}
+
+// listClusters returns the summary of the clusters of all the namespaces
+func (s *Server) listClusters(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	clusters, err := s.context.RookClientset.CephV1().CephClusters(v1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list clusters. %+v", err))
+		return
+	}
+	infos := []ClusterInfo{}
+	for _, c := range clusters.Items {
+		infos = append(infos, clusterInfo(&c))
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// handleCluster handles the requests to a cluster, at /api/v1/clusters/<namespace>[/osds|/reconcile|/zap]
+func (s *Server) handleCluster(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, clustersPath), "/"), "/")
+	if len(parts) > 2 || parts[0] == "" {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown path %s", r.URL.Path))
+		return
+	}
+	namespace := parts[0]
+	clust, err := s.getCluster(namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if clust == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no cluster in namespace %s", namespace))
+		return
+	}
+
+	if len(parts) == 1 {
+		if allowMethod(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, clusterInfo(clust))
+		}
+		return
+	}
+	switch parts[1] {
+	case "osds":
+		if allowMethod(w, r, http.MethodGet) {
+			s.listOSDs(w, namespace)
+		}
+	case "reconcile":
+		if allowMethod(w, r, http.MethodPost) {
+			s.reconcile(w, clust)
+		}
+	case "zap":
+		if allowMethod(w, r, http.MethodPost) {
+			s.zap(w, r, namespace)
+		}
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown path %s", r.URL.Path))
+	}
+}
+
+// getCluster returns the cluster of a namespace, or nil if there is none
+func (s *Server) getCluster(namespace string) (*cephv1.CephCluster, error) {
+	clusters, err := s.context.RookClientset.CephV1().CephClusters(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the cluster of namespace %s. %+v", namespace, err)
+	}
+	if len(clusters.Items) == 0 {
+		return nil, nil
+	}
+	return &clusters.Items[0], nil
+}
+
+func (s *Server) listOSDs(w http.ResponseWriter, namespace string) {
+	osds, err := listOSDs(s.context, namespace)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list the osds of cluster %s. %+v", namespace, err))
+		return
+	}
+	if osds == nil {
+		osds = []oposd.OSDSummary{}
+	}
+	writeJSON(w, http.StatusOK, osds)
+}
+
+// reconcile sets the reconcile annotation of a cluster to the current time, so the operator orchestrates it again
+func (s *Server) reconcile(w http.ResponseWriter, clust *cephv1.CephCluster) {
+	if clust.Annotations == nil {
+		clust.Annotations = map[string]string{}
+	}
+	clust.Annotations[cluster.ReconcileAnnotation] = time.Now().UTC().Format(time.RFC3339Nano)
+	if _, err := s.context.RookClientset.CephV1().CephClusters(clust.Namespace).Update(clust); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to request the reconcile of cluster %s. %+v", clust.Namespace, err))
+		return
+	}
+	logger.Infof("reconcile of cluster %s requested through the admin api", clust.Namespace)
+	writeJSON(w, http.StatusAccepted, clusterInfo(clust))
+}
+
+// zap starts the job wiping the device of the device query parameter on the node of the node query parameter. The job
+// only lists what would be destroyed unless the dryRun query parameter is false.
+func (s *Server) zap(w http.ResponseWriter, r *http.Request, namespace string) {
+	query := r.URL.Query()
+	req := ZapRequest{Node: query.Get("node"), Device: query.Get("device"), DryRun: true}
+	if req.Node == "" || req.Device == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("the node and device parameters are required"))
+		return
+	}
+	if value := query.Get("dryRun"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid dryRun parameter %q. %+v", value, err))
+			return
+		}
+		req.DryRun = dryRun
+	}
+	if err := s.zapDevice(namespace, req.Node, req.Device, req.DryRun); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to zap device %s of node %s. %+v", req.Device, req.Node, err))
+		return
+	}
+	logger.Infof("zap of device %s of node %s in cluster %s requested through the admin api (dry run: %t)", req.Device, req.Node, namespace, req.DryRun)
+	writeJSON(w, http.StatusAccepted, req)
+}
+
+// getInventory returns the devices discovered on all the nodes, or on the node of the node query parameter
+func (s *Server) getInventory(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	devices, err := listDevices(s.context, s.namespace, r.URL.Query().Get("node"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list the devices of the nodes. %+v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, devices)
+}
+
+func clusterInfo(c *cephv1.CephCluster) ClusterInfo {
+	return ClusterInfo{
+		Name:       c.Name,
+		Namespace:  c.Namespace,
+		State:      c.Status.State,
+		Message:    c.Status.Message,
+		CephImage:  c.Spec.CephVersion.Image,
+		Usage:      c.Status.Usage,
+		Conditions: c.Status.Conditions,
+	}
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Warningf("failed to write the response. %+v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/discover"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *clusterd.Context, string) {
+	clientset := fake.NewSimpleClientset()
+	rookClientset := rookfake.NewSimpleClientset(&cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "rook-ceph"},
+		Spec:       cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v13.2.2"}},
+		Status:     cephv1.ClusterStatus{State: cephv1.ClusterStateCreated},
+	})
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookClientset}
+	s := New(context, "rook-system", testZap)
+	require.Nil(t, s.ensureToken())
+	secret, err := clientset.CoreV1().Secrets("rook-system").Get(TokenSecretName, metav1.GetOptions{})
+	require.Nil(t, err)
+	token := string(secret.Data[tokenSecretKey])
+	assert.Equal(t, 2*tokenLength, len(token))
+
+	// an existing token is kept
+	require.Nil(t, s.ensureToken())
+	secret, err = clientset.CoreV1().Secrets("rook-system").Get(TokenSecretName, metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.Equal(t, token, string(secret.Data[tokenSecretKey]))
+
+	return httptest.NewServer(s), context, token
+}
+
+// the zap jobs started by the test servers
+var zapped []ZapRequest
+
+func testZap(namespace, nodeName, device string, dryRun bool) error {
+	if device == "sdz" {
+		return fmt.Errorf("device sdz not found")
+	}
+	zapped = append(zapped, ZapRequest{Node: namespace + "/" + nodeName, Device: device, DryRun: dryRun})
+	return nil
+}
+
+func request(t *testing.T, method, url, token string, body interface{}) int {
+	req, err := http.NewRequest(method, url, nil)
+	require.Nil(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	if body != nil {
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(body))
+	}
+	return resp.StatusCode
+}
+
+func TestAuthentication(t *testing.T) {
+	server, _, token := newTestServer(t)
+	defer server.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, request(t, "GET", server.URL+clustersPath, "", nil))
+	assert.Equal(t, http.StatusUnauthorized, request(t, "GET", server.URL+clustersPath, "invalid", nil))
+	assert.Equal(t, http.StatusOK, request(t, "GET", server.URL+clustersPath, token, nil))
+}
+
+func TestTokenRotation(t *testing.T) {
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), RookClientset: rookfake.NewSimpleClientset()}
+	s := New(context, "rook-system", testZap)
+	require.Nil(t, s.ensureToken())
+	server := httptest.NewServer(s)
+	defer server.Close()
+	token := string(s.token)
+
+	// the secret is not read again for the requests
+	require.Nil(t, context.Clientset.CoreV1().Secrets("rook-system").Delete(TokenSecretName, &metav1.DeleteOptions{}))
+	assert.Equal(t, http.StatusOK, request(t, "GET", server.URL+clustersPath, token, nil))
+
+	// the token is replaced when the watch sees the secret updated
+	rotated := &v1.Secret{Data: map[string][]byte{tokenSecretKey: []byte("rotated")}}
+	s.onTokenUpdate(nil, rotated)
+	assert.Equal(t, http.StatusUnauthorized, request(t, "GET", server.URL+clustersPath, token, nil))
+	assert.Equal(t, http.StatusOK, request(t, "GET", server.URL+clustersPath, "rotated", nil))
+
+	// no request is accepted without the secret
+	s.onTokenDelete(rotated)
+	assert.Equal(t, http.StatusUnauthorized, request(t, "GET", server.URL+clustersPath, "rotated", nil))
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid-tls", Namespace: "rook-system"},
+		Data:       map[string][]byte{v1.TLSCertKey: []byte("cert"), v1.TLSPrivateKeyKey: []byte("key")},
+	})
+	s := New(&clusterd.Context{Clientset: clientset}, "rook-system", testZap)
+	defer func() { TLSSecretName = "" }()
+
+	TLSSecretName = "missing-tls"
+	_, err := s.loadTLSConfig()
+	assert.NotNil(t, err)
+
+	TLSSecretName = "invalid-tls"
+	_, err = s.loadTLSConfig()
+	assert.NotNil(t, err)
+}
+
+func TestClusters(t *testing.T) {
+	server, context, token := newTestServer(t)
+	defer server.Close()
+
+	var clusters []ClusterInfo
+	assert.Equal(t, http.StatusOK, request(t, "GET", server.URL+clustersPath, token, &clusters))
+	require.Equal(t, 1, len(clusters))
+	assert.Equal(t, "rook-ceph", clusters[0].Name)
+	assert.Equal(t, "rook-ceph", clusters[0].Namespace)
+	assert.Equal(t, cephv1.ClusterStateCreated, clusters[0].State)
+	assert.Equal(t, "ceph/ceph:v13.2.2", clusters[0].CephImage)
+
+	var info ClusterInfo
+	assert.Equal(t, http.StatusOK, request(t, "GET", server.URL+clustersPath+"/rook-ceph", token, &info))
+	assert.Equal(t, "rook-ceph", info.Name)
+	assert.Equal(t, http.StatusNotFound, request(t, "GET", server.URL+clustersPath+"/other", token, nil))
+	assert.Equal(t, http.StatusNotFound, request(t, "GET", server.URL+clustersPath+"/rook-ceph/unknown", token, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, request(t, "DELETE", server.URL+clustersPath+"/rook-ceph", token, nil))
+
+	// the reconcile sets the annotation of the cluster
+	assert.Equal(t, http.StatusMethodNotAllowed, request(t, "GET", server.URL+clustersPath+"/rook-ceph/reconcile", token, nil))
+	assert.Equal(t, http.StatusAccepted, request(t, "POST", server.URL+clustersPath+"/rook-ceph/reconcile", token, nil))
+	clust, err := context.RookClientset.CephV1().CephClusters("rook-ceph").Get("rook-ceph", metav1.GetOptions{})
+	require.Nil(t, err)
+	assert.NotEmpty(t, clust.Annotations[cluster.ReconcileAnnotation])
+}
+
+func TestOSDs(t *testing.T) {
+	server, _, token := newTestServer(t)
+	defer server.Close()
+	defer func() { listOSDs = oposd.ListOSDs }()
+
+	listOSDs = func(context *clusterd.Context, namespace string) ([]oposd.OSDSummary, error) {
+		assert.Equal(t, "rook-ceph", namespace)
+		return []oposd.OSDSummary{{ID: 0, Node: "node1", Up: true, In: true}, {ID: 1, Node: "node2"}}, nil
+	}
+	var osds []oposd.OSDSummary
+	assert.Equal(t, http.StatusOK, request(t, "GET", server.URL+clustersPath+"/rook-ceph/osds", token, &osds))
+	require.Equal(t, 2, len(osds))
+	assert.True(t, osds[0].Up)
+	assert.Equal(t, "node2", osds[1].Node)
+
+	listOSDs = func(context *clusterd.Context, namespace string) ([]oposd.OSDSummary, error) {
+		return nil, fmt.Errorf("mon timeout")
+	}
+	var resp errorResponse
+	assert.Equal(t, http.StatusInternalServerError, request(t, "GET", server.URL+clustersPath+"/rook-ceph/osds", token, &resp))
+	assert.Contains(t, resp.Error, "mon timeout")
+}
+
+func TestInventory(t *testing.T) {
+	server, _, token := newTestServer(t)
+	defer server.Close()
+	defer func() { listDevices = discover.ListDevices }()
+
+	var node string
+	listDevices = func(context *clusterd.Context, namespace, nodeName string) (map[string][]sys.LocalDisk, error) {
+		assert.Equal(t, "rook-system", namespace)
+		node = nodeName
+		return map[string][]sys.LocalDisk{"node1": {{Name: "sdb", Size: 1024}}}, nil
+	}
+	var devices map[string][]sys.LocalDisk
+	assert.Equal(t, http.StatusOK, request(t, "GET", server.URL+inventoryPath+"?node=node1", token, &devices))
+	assert.Equal(t, "node1", node)
+	require.Equal(t, 1, len(devices["node1"]))
+	assert.Equal(t, "sdb", devices["node1"][0].Name)
+	assert.Equal(t, http.StatusMethodNotAllowed, request(t, "POST", server.URL+inventoryPath, token, nil))
+}
+
+func TestZap(t *testing.T) {
+	server, _, token := newTestServer(t)
+	defer server.Close()
+	defer func() { zapped = nil }()
+
+	// the zap is a dry run unless dryRun is false
+	var req ZapRequest
+	assert.Equal(t, http.StatusAccepted, request(t, "POST", server.URL+clustersPath+"/rook-ceph/zap?node=node1&device=sdb", token, &req))
+	assert.Equal(t, ZapRequest{Node: "node1", Device: "sdb", DryRun: true}, req)
+	assert.Equal(t, http.StatusAccepted, request(t, "POST", server.URL+clustersPath+"/rook-ceph/zap?node=node1&device=sdb&dryRun=false", token, nil))
+	assert.Equal(t, []ZapRequest{{Node: "rook-ceph/node1", Device: "sdb", DryRun: true}, {Node: "rook-ceph/node1", Device: "sdb", DryRun: false}}, zapped)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, request(t, "GET", server.URL+clustersPath+"/rook-ceph/zap?node=node1&device=sdb", token, nil))
+	assert.Equal(t, http.StatusBadRequest, request(t, "POST", server.URL+clustersPath+"/rook-ceph/zap?node=node1", token, nil))
+	assert.Equal(t, http.StatusBadRequest, request(t, "POST", server.URL+clustersPath+"/rook-ceph/zap?node=node1&device=sdb&dryRun=maybe", token, nil))
+	assert.Equal(t, http.StatusNotFound, request(t, "POST", server.URL+clustersPath+"/other/zap?node=node1&device=sdb", token, nil))
+	var resp errorResponse
+	assert.Equal(t, http.StatusInternalServerError, request(t, "POST", server.URL+clustersPath+"/rook-ceph/zap?node=node1&device=sdz", token, &resp))
+	assert.Contains(t, resp.Error, "device sdz not found")
+	assert.Equal(t, 2, len(zapped))
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	archiveTimeFormat = "20060102-150405"
+	archiveExt        = ".tar.gz"
+	resourcesFile     = "resources.yaml"
+)
+
+// the files of the archive with the maps and the keys of the cluster, and the ceph commands exporting them
+var cephExports = []struct {
+	file  string
+	args  []string
+	plain bool
+	keys  bool
+}{
+	{file: "monmap", args: []string{"mon", "getmap"}},
+	{file: "osdmap", args: []string{"osd", "getmap"}},
+	{file: "crushmap", args: []string{"osd", "getcrushmap"}},
+	{file: "keyring", args: []string{"auth", "export"}, plain: true, keys: true},
+	{file: "config-key.json", args: []string{"config-key", "dump"}},
+	{file: "fsmap.json", args: []string{"fs", "dump"}},
+}
+
+// archiveName returns the name of the archive of a backup taken at the given time. The names of the archives of a
+// backup resource sort by time.
+func archiveName(backupName string, t time.Time) string {
+	return fmt.Sprintf("%s-%s%s", backupName, t.UTC().Format(archiveTimeFormat), archiveExt)
+}
+
+// createArchive exports the maps of the cluster with the resources of its namespace to a gzipped tar archive. The keys
+// of the cluster are only exported when they are included, since they are not encrypted in the archive. The files of
+// the archive are in a directory named after the archive.
+func createArchive(context *clusterd.Context, namespace, name string, includeKeys bool) ([]byte, error) {
+	var names []string
+	files := map[string][]byte{}
+	for _, export := range cephExports {
+		if export.keys && !includeKeys {
+			continue
+		}
+		var output []byte
+		var err error
+		if export.plain {
+			output, err = client.ExecuteCephCommandPlain(context, namespace, export.args)
+		} else {
+			output, err = client.ExecuteCephCommand(context, namespace, export.args)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to export the %s of the cluster. %+v", export.file, err)
+		}
+		names = append(names, export.file)
+		files[export.file] = output
+	}
+
+	resources, err := exportResources(context, namespace, includeKeys)
+	if err != nil {
+		return nil, err
+	}
+	names = append(names, resourcesFile)
+	files[resourcesFile] = resources
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	dir := strings.TrimSuffix(name, archiveExt)
+	for _, file := range names {
+		header := &tar.Header{Name: path.Join(dir, file), Mode: 0600, Size: int64(len(files[file])), ModTime: time.Now()}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to archive %s. %+v", file, err)
+		}
+		if _, err := tw.Write(files[file]); err != nil {
+			return nil, fmt.Errorf("failed to archive %s. %+v", file, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close the archive. %+v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress the archive. %+v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// exportResources returns the manifest of the rook resources of the namespace, with the mon secret and endpoints
+// holding the identity of the cluster. The keys of the mon secret are removed unless they are included. The resources
+// are stripped of the metadata set by kubernetes, so they can be created again with kubectl.
+func exportResources(context *clusterd.Context, namespace string, includeKeys bool) ([]byte, error) {
+	var objects []interface{}
+	ceph := context.RookClientset.CephV1()
+	opts := metav1.ListOptions{}
+
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(mon.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the mon secret. %+v", err)
+	}
+	if !includeKeys {
+		for _, key := range mon.SecretKeyNames {
+			delete(secret.Data, key)
+		}
+	}
+	secret.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+	secret.ObjectMeta = exportedMeta(secret.ObjectMeta)
+	objects = append(objects, secret)
+
+	configMap, err := context.Clientset.CoreV1().ConfigMaps(namespace).Get(mon.EndpointConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the mon endpoints. %+v", err)
+	}
+	configMap.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+	configMap.ObjectMeta = exportedMeta(configMap.ObjectMeta)
+	objects = append(objects, configMap)
+
+	clusters, err := ceph.CephClusters(namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the clusters. %+v", err)
+	}
+	for _, r := range clusters.Items {
+		r.TypeMeta, r.ObjectMeta = cephTypeMeta("CephCluster"), exportedMeta(r.ObjectMeta)
+		objects = append(objects, r)
+	}
+	crushRules, err := ceph.CephCrushRules(namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the crush rules. %+v", err)
+	}
+	for _, r := range crushRules.Items {
+		r.TypeMeta, r.ObjectMeta = cephTypeMeta("CephCrushRule"), exportedMeta(r.ObjectMeta)
+		objects = append(objects, r)
+	}
+	pools, err := ceph.CephBlockPools(namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pools. %+v", err)
+	}
+	for _, r := range pools.Items {
+		r.TypeMeta, r.ObjectMeta = cephTypeMeta("CephBlockPool"), exportedMeta(r.ObjectMeta)
+		objects = append(objects, r)
+	}
+	radosNamespaces, err := ceph.CephBlockPoolRadosNamespaces(namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the rados namespaces. %+v", err)
+	}
+	for _, r := range radosNamespaces.Items {
+		r.TypeMeta, r.ObjectMeta, r.Status = cephTypeMeta("CephBlockPoolRadosNamespace"), exportedMeta(r.ObjectMeta), nil
+		objects = append(objects, r)
+	}
+	filesystems, err := ceph.CephFilesystems(namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the filesystems. %+v", err)
+	}
+	for _, r := range filesystems.Items {
+		r.TypeMeta, r.ObjectMeta = cephTypeMeta("CephFilesystem"), exportedMeta(r.ObjectMeta)
+		objects = append(objects, r)
+	}
+	stores, err := ceph.CephObjectStores(namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the object stores. %+v", err)
+	}
+	for _, r := range stores.Items {
+		r.TypeMeta, r.ObjectMeta = cephTypeMeta("CephObjectStore"), exportedMeta(r.ObjectMeta)
+		objects = append(objects, r)
+	}
+	users, err := ceph.CephObjectStoreUsers(namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the object store users. %+v", err)
+	}
+	for _, r := range users.Items {
+		r.TypeMeta, r.ObjectMeta = cephTypeMeta("CephObjectStoreUser"), exportedMeta(r.ObjectMeta)
+		objects = append(objects, r)
+	}
+	buckets, err := ceph.CephObjectBuckets(namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the object buckets. %+v", err)
+	}
+	for _, r := range buckets.Items {
+		r.TypeMeta, r.ObjectMeta = cephTypeMeta("CephObjectBucket"), exportedMeta(r.ObjectMeta)
+		objects = append(objects, r)
+	}
+	drivers, err := ceph.CephCOSIDrivers(namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the cosi drivers. %+v", err)
+	}
+	for _, r := range drivers.Items {
+		r.TypeMeta, r.ObjectMeta = cephTypeMeta("CephCOSIDriver"), exportedMeta(r.ObjectMeta)
+		objects = append(objects, r)
+	}
+	backups, err := ceph.CephMetadataBackups(namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the metadata backups. %+v", err)
+	}
+	for _, r := range backups.Items {
+		r.TypeMeta, r.ObjectMeta = cephTypeMeta("CephMetadataBackup"), exportedMeta(r.ObjectMeta)
+		objects = append(objects, r)
+	}
+
+	var manifest bytes.Buffer
+	for _, obj := range objects {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export the resources. %+v", err)
+		}
+		manifest.WriteString("---\n")
+		manifest.Write(b)
+	}
+	return manifest.Bytes(), nil
+}
+
+func cephTypeMeta(kind string) metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: "ceph.rook.io/v1", Kind: kind}
+}
+
+// exportedMeta keeps the metadata of a resource set by its owner
+func exportedMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        meta.Name,
+		Namespace:   meta.Namespace,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}
+}
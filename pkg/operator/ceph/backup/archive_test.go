@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestContext(t *testing.T) *clusterd.Context {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return args[0] + " " + args[1], nil
+		},
+	}
+	clientset := fake.NewSimpleClientset()
+	rookClientset := rookfake.NewSimpleClientset()
+	meta := metav1.ObjectMeta{Namespace: "ns", ResourceVersion: "10", UID: "1234"}
+
+	meta.Name = mon.SecretName
+	_, err := clientset.CoreV1().Secrets("ns").Create(&v1.Secret{ObjectMeta: meta, Data: map[string][]byte{"fsid": []byte("abc"), "admin-secret": []byte("AQBadminkey==")}})
+	assert.Nil(t, err)
+	meta.Name = mon.EndpointConfigMapName
+	_, err = clientset.CoreV1().ConfigMaps("ns").Create(&v1.ConfigMap{ObjectMeta: meta, Data: map[string]string{"data": "a=1.2.3.4:6790"}})
+	assert.Nil(t, err)
+	meta.Name = "replicapool"
+	_, err = rookClientset.CephV1().CephBlockPools("ns").Create(&cephv1.CephBlockPool{ObjectMeta: meta})
+	assert.Nil(t, err)
+	meta.Name = "tenant-a"
+	_, err = rookClientset.CephV1().CephBlockPoolRadosNamespaces("ns").Create(&cephv1.CephBlockPoolRadosNamespace{ObjectMeta: meta,
+		Status: &cephv1.RadosNamespaceStatus{Phase: cephv1.RadosNamespacePhaseReady}})
+	assert.Nil(t, err)
+
+	return &clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookClientset, ConfigDir: "/var/lib/rook"}
+}
+
+func readArchive(t *testing.T, archive []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	assert.Nil(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		content, err := ioutil.ReadAll(tr)
+		assert.Nil(t, err)
+		files[header.Name] = string(content)
+	}
+	return files
+}
+
+func TestArchiveName(t *testing.T) {
+	taken := time.Date(2018, 11, 2, 3, 4, 5, 0, time.UTC)
+	assert.Equal(t, "daily-20181102-030405.tar.gz", archiveName("daily", taken))
+}
+
+func TestCreateArchive(t *testing.T) {
+	context := newTestContext(t)
+	archive, err := createArchive(context, "ns", "daily-20181102-030405.tar.gz", true)
+	assert.Nil(t, err)
+
+	files := readArchive(t, archive)
+	assert.Equal(t, 7, len(files))
+	assert.Equal(t, "mon getmap", files["daily-20181102-030405/monmap"])
+	assert.Equal(t, "osd getcrushmap", files["daily-20181102-030405/crushmap"])
+	assert.Equal(t, "auth export", files["daily-20181102-030405/keyring"])
+
+	// the resources are stripped of the metadata set by kubernetes
+	resources := files["daily-20181102-030405/resources.yaml"]
+	assert.Contains(t, resources, "kind: Secret")
+	assert.Contains(t, resources, "name: rook-ceph-mon-endpoints")
+	assert.Contains(t, resources, "kind: CephBlockPool")
+	assert.Contains(t, resources, "name: replicapool")
+	assert.Contains(t, resources, "kind: CephBlockPoolRadosNamespace")
+	assert.NotContains(t, resources, "phase: Ready")
+	assert.NotContains(t, resources, "resourceVersion")
+	assert.NotContains(t, resources, "uid")
+	assert.Contains(t, resources, "admin-secret")
+
+	// the keys are left out of the archive by default
+	archive, err = createArchive(context, "ns", "daily-20181102-030405.tar.gz", false)
+	assert.Nil(t, err)
+	files = readArchive(t, archive)
+	assert.Equal(t, 6, len(files))
+	_, ok := files["daily-20181102-030405/keyring"]
+	assert.False(t, ok)
+	resources = files["daily-20181102-030405/resources.yaml"]
+	assert.Contains(t, resources, "fsid")
+	assert.NotContains(t, resources, "admin-secret")
+
+	// the archive is not created without the identity of the cluster
+	assert.Nil(t, context.Clientset.CoreV1().Secrets("ns").Delete(mon.SecretName, &metav1.DeleteOptions{}))
+	_, err = createArchive(context, "ns", "daily-20181102-030405.tar.gz", true)
+	assert.NotNil(t, err)
+}
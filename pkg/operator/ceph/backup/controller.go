@@ -0,0 +1,416 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backup to take the periodic backups of the metadata of a cluster.
+package backup
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephrgw "github.com/rook/rook/pkg/daemon/ceph/rgw"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// DefaultIntervalMinutes is the number of minutes between two backups if the interval is not set
+	DefaultIntervalMinutes = 24 * 60
+	// DefaultRetention is the number of backups kept if the retention is not set
+	DefaultRetention = 7
+
+	appName          = "rook-ceph-metadata-backup"
+	archiveMountPath = "/var/lib/rook-backup/archive"
+	backupMountPath  = "/var/lib/rook-backup/backups"
+	retryInterval    = 10 * time.Minute
+	// the archive is passed to the job in a secret, whose size is limited by kubernetes
+	maxSecretSize = 1024 * 1024
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-backup")
+
+// MetadataBackupResource represents the MetadataBackup custom resource object
+var MetadataBackupResource = opkit.CustomResource{
+	Name:    "cephmetadatabackup",
+	Plural:  "cephmetadatabackups",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephMetadataBackup{}).Name(),
+}
+
+// MetadataBackupController represents a controller object for metadata backup custom resources
+type MetadataBackupController struct {
+	context       *clusterd.Context
+	rookImage     string
+	ownerRef      metav1.OwnerReference
+	retryInterval time.Duration
+	stopCh        chan struct{}
+	// the channels stopping the schedules of the backup resources, by name
+	schedules map[string]chan struct{}
+	lock      sync.Mutex
+}
+
+// NewMetadataBackupController create controller for watching metadata backup custom resources created
+func NewMetadataBackupController(context *clusterd.Context, rookImage string, ownerRef metav1.OwnerReference) *MetadataBackupController {
+	return &MetadataBackupController{
+		context:       context,
+		rookImage:     rookImage,
+		ownerRef:      ownerRef,
+		retryInterval: retryInterval,
+		schedules:     map[string]chan struct{}{},
+	}
+}
+
+// StartWatch watches for instances of MetadataBackup custom resources and acts on them
+func (c *MetadataBackupController) StartWatch(namespace string, stopCh chan struct{}) error {
+	c.stopCh = stopCh
+
+	// the resources are not reconciled while their cluster is in maintenance mode
+	resourceHandlerFuncs := maintenance.PausableHandlers(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	})
+
+	logger.Infof("start watching metadata backup resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(MetadataBackupResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephMetadataBackup{}, stopCh)
+
+	return nil
+}
+
+func (c *MetadataBackupController) onAdd(obj interface{}) {
+	b, err := getMetadataBackupObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get metadata backup object: %+v", err)
+		return
+	}
+
+	c.schedule(b)
+}
+
+func (c *MetadataBackupController) onUpdate(oldObj, newObj interface{}) {
+	oldBackup, err := getMetadataBackupObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old metadata backup object: %+v", err)
+		return
+	}
+	b, err := getMetadataBackupObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new metadata backup object: %+v", err)
+		return
+	}
+
+	if reflect.DeepEqual(oldBackup.Spec, b.Spec) {
+		// the status was updated
+		return
+	}
+	logger.Infof("updating the schedule of metadata backup %s/%s", b.Namespace, b.Name)
+	c.unschedule(b)
+	c.schedule(b)
+}
+
+func (c *MetadataBackupController) onDelete(obj interface{}) {
+	b, err := getMetadataBackupObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get metadata backup object: %+v", err)
+		return
+	}
+
+	// the archives of the backups are kept
+	c.unschedule(b)
+}
+
+func (c *MetadataBackupController) schedule(b *cephv1.CephMetadataBackup) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.schedules[b.Name]; ok {
+		return
+	}
+	stop := make(chan struct{})
+	c.schedules[b.Name] = stop
+	go c.run(b.Namespace, b.Name, stop)
+}
+
+func (c *MetadataBackupController) unschedule(b *cephv1.CephMetadataBackup) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if stop, ok := c.schedules[b.Name]; ok {
+		close(stop)
+		delete(c.schedules, b.Name)
+	}
+}
+
+// run takes the backups at the interval of the backup resource until its schedule is stopped. A failed backup is
+// retried after the retry interval.
+func (c *MetadataBackupController) run(namespace, name string, stop chan struct{}) {
+	wait := time.Duration(0)
+	for {
+		select {
+		case <-time.After(wait):
+		case <-stop:
+			return
+		case <-c.stopCh:
+			return
+		}
+
+		if maintenance.Paused(namespace) {
+			logger.Infof("postponing the metadata backup %s/%s while the cluster is in maintenance mode", namespace, name)
+			wait = c.retryInterval
+			continue
+		}
+
+		b, err := c.context.RookClientset.CephV1().CephMetadataBackups(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return
+			}
+			logger.Errorf("failed to get metadata backup %s/%s. %+v", namespace, name, err)
+			wait = c.retryInterval
+			continue
+		}
+
+		wait = untilNextBackup(b, time.Now())
+		if wait > 0 {
+			continue
+		}
+		if err := c.backup(b); err != nil {
+			logger.Errorf("failed to back up the metadata of cluster %s. %+v", namespace, err)
+			wait = c.retryInterval
+			continue
+		}
+		wait = interval(b)
+	}
+}
+
+// backup archives the metadata of the cluster and keeps the archive in the store of the backup resource
+func (c *MetadataBackupController) backup(b *cephv1.CephMetadataBackup) error {
+	name := archiveName(b.Name, time.Now())
+	err := c.storeArchive(b, name)
+
+	b.Status.Message = ""
+	if err != nil {
+		b.Status.Message = err.Error()
+	} else {
+		now := metav1.Now()
+		b.Status.LastBackup = name
+		b.Status.LastBackupTime = &now
+		logger.Infof("metadata of cluster %s backed up to %s", b.Namespace, name)
+	}
+	if updateErr := c.updateStatus(b); updateErr != nil {
+		logger.Errorf("failed to update the status of metadata backup %s/%s. %+v", b.Namespace, b.Name, updateErr)
+	}
+	return err
+}
+
+func (c *MetadataBackupController) storeArchive(b *cephv1.CephMetadataBackup, name string) error {
+	if err := ValidateMetadataBackup(b); err != nil {
+		return err
+	}
+	archive, err := createArchive(c.context, b.Namespace, name, b.Spec.IncludeKeys)
+	if err != nil {
+		return err
+	}
+
+	if b.Spec.S3 != nil {
+		store, err := c.newS3Store(b)
+		if err != nil {
+			return err
+		}
+		if err := store.Put(name, archive); err != nil {
+			return err
+		}
+		return Prune(store, b.Name, retention(b))
+	}
+
+	// the archive is written to the pvc by a job
+	if len(archive) > maxSecretSize {
+		return fmt.Errorf("the archive of %d bytes is too large to be written to a pvc. back up to an s3 bucket instead", len(archive))
+	}
+	return c.startStoreJob(b, name, archive)
+}
+
+func (c *MetadataBackupController) newS3Store(b *cephv1.CephMetadataBackup) (Store, error) {
+	secret, err := c.context.Clientset.CoreV1().Secrets(b.Namespace).Get(b.Spec.S3.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the keys of bucket %s. %+v", b.Spec.S3.Bucket, err)
+	}
+	agent, err := cephrgw.NewS3Agent(string(secret.Data["AccessKey"]), string(secret.Data["SecretKey"]), b.Spec.S3.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Store{agent: agent, bucket: b.Spec.S3.Bucket, prefix: b.Spec.S3.Prefix}, nil
+}
+
+// startStoreJob passes the archive in a secret to a job writing it to the pvc
+func (c *MetadataBackupController) startStoreJob(b *cephv1.CephMetadataBackup, name string, archive []byte) error {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName(b),
+			Namespace: b.Namespace,
+			Labels:    labels(b),
+		},
+		Data: map[string][]byte{name: archive},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, b.Namespace, &secret.ObjectMeta, &c.ownerRef)
+	if _, err := c.context.Clientset.CoreV1().Secrets(b.Namespace).Create(secret); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create the secret of archive %s. %+v", name, err)
+		}
+		if _, err := c.context.Clientset.CoreV1().Secrets(b.Namespace).Update(secret); err != nil {
+			return fmt.Errorf("failed to update the secret of archive %s. %+v", name, err)
+		}
+	}
+
+	if err := k8sutil.RunReplaceableJob(c.context.Clientset, c.makeStoreJob(b, name)); err != nil {
+		return fmt.Errorf("failed to start the job writing archive %s to pvc %s. %+v", name, b.Spec.PVC, err)
+	}
+	return nil
+}
+
+func (c *MetadataBackupController) makeStoreJob(b *cephv1.CephMetadataBackup, name string) *batch.Job {
+	podSpec := v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Args: []string{
+					"ceph",
+					"metadata-backup",
+					"--archive", path.Join(archiveMountPath, name),
+					"--backup-dir", backupMountPath,
+					"--backup-name", b.Name,
+					"--retention", strconv.Itoa(retention(b)),
+				},
+				Name:  "store",
+				Image: k8sutil.MakeRookImage(c.rookImage),
+				VolumeMounts: []v1.VolumeMount{
+					{Name: "archive", MountPath: archiveMountPath, ReadOnly: true},
+					{Name: "backups", MountPath: backupMountPath},
+				},
+				Env: k8sutil.LogFormatEnvVars(false),
+			},
+		},
+		RestartPolicy: v1.RestartPolicyOnFailure,
+		Volumes: []v1.Volume{
+			{Name: "archive", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: resourceName(b)}}},
+			{Name: "backups", VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: b.Spec.PVC}}},
+		},
+	}
+
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName(b),
+			Namespace: b.Namespace,
+			Labels:    labels(b),
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels(b)},
+				Spec:       podSpec,
+			},
+		},
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, b.Namespace, &job.ObjectMeta, &c.ownerRef)
+	return job
+}
+
+func (c *MetadataBackupController) updateStatus(b *cephv1.CephMetadataBackup) error {
+	latest, err := c.context.RookClientset.CephV1().CephMetadataBackups(b.Namespace).Get(b.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	latest.Status = b.Status
+	_, err = c.context.RookClientset.CephV1().CephMetadataBackups(b.Namespace).Update(latest)
+	return err
+}
+
+// ValidateMetadataBackup checks that the backups are kept in either a pvc or a bucket
+func ValidateMetadataBackup(b *cephv1.CephMetadataBackup) error {
+	if b.Spec.PVC == "" && b.Spec.S3 == nil {
+		return fmt.Errorf("a pvc or an s3 bucket is required to keep the backups")
+	}
+	if b.Spec.PVC != "" && b.Spec.S3 != nil {
+		return fmt.Errorf("the backups are kept in either a pvc or an s3 bucket, not both")
+	}
+	if s3 := b.Spec.S3; s3 != nil && (s3.Endpoint == "" || s3.Bucket == "" || s3.SecretName == "") {
+		return fmt.Errorf("the endpoint, bucket and secretName of the s3 bucket are required")
+	}
+	if b.Spec.IntervalMinutes < 0 || b.Spec.Retention < 0 {
+		return fmt.Errorf("the interval and the retention must not be negative")
+	}
+	return nil
+}
+
+// untilNextBackup returns the duration until the next backup is due
+func untilNextBackup(b *cephv1.CephMetadataBackup, now time.Time) time.Duration {
+	if b.Status.LastBackupTime == nil {
+		return 0
+	}
+	wait := b.Status.LastBackupTime.Add(interval(b)).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+func interval(b *cephv1.CephMetadataBackup) time.Duration {
+	if b.Spec.IntervalMinutes <= 0 {
+		return DefaultIntervalMinutes * time.Minute
+	}
+	return time.Duration(b.Spec.IntervalMinutes) * time.Minute
+}
+
+func retention(b *cephv1.CephMetadataBackup) int {
+	if b.Spec.Retention <= 0 {
+		return DefaultRetention
+	}
+	return b.Spec.Retention
+}
+
+func resourceName(b *cephv1.CephMetadataBackup) string {
+	return fmt.Sprintf("%s-%s", appName, b.Name)
+}
+
+func labels(b *cephv1.CephMetadataBackup) map[string]string {
+	return map[string]string{
+		k8sutil.AppAttr:     appName,
+		k8sutil.ClusterAttr: b.Namespace,
+		"backup":            b.Name,
+	}
+}
+
+func getMetadataBackupObject(obj interface{}) (*cephv1.CephMetadataBackup, error) {
+	b, ok := obj.(*cephv1.CephMetadataBackup)
+	if ok {
+		return b.DeepCopy(), nil
+	}
+	return nil, fmt.Errorf("not a known metadata backup object: %+v", obj)
+}
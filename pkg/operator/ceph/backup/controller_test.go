@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateMetadataBackup(t *testing.T) {
+	b := &cephv1.CephMetadataBackup{}
+	assert.NotNil(t, ValidateMetadataBackup(b))
+
+	b.Spec.PVC = "backups"
+	assert.Nil(t, ValidateMetadataBackup(b))
+
+	b.Spec.S3 = &cephv1.MetadataBackupS3Spec{Endpoint: "http://s3:80", Bucket: "backups", SecretName: "s3-keys"}
+	assert.NotNil(t, ValidateMetadataBackup(b))
+
+	b.Spec.PVC = ""
+	assert.Nil(t, ValidateMetadataBackup(b))
+
+	b.Spec.S3.Bucket = ""
+	assert.NotNil(t, ValidateMetadataBackup(b))
+}
+
+func TestUntilNextBackup(t *testing.T) {
+	now := time.Now()
+	b := &cephv1.CephMetadataBackup{}
+	assert.Equal(t, time.Duration(0), untilNextBackup(b, now))
+
+	// daily backups by default
+	last := metav1.NewTime(now.Add(-time.Hour))
+	b.Status.LastBackupTime = &last
+	assert.Equal(t, 23*time.Hour, untilNextBackup(b, now))
+
+	b.Spec.IntervalMinutes = 30
+	assert.Equal(t, time.Duration(0), untilNextBackup(b, now))
+}
+
+func TestBackupToPVC(t *testing.T) {
+	context := newTestContext(t)
+	c := NewMetadataBackupController(context, "rook/ceph:master", metav1.OwnerReference{})
+	b := &cephv1.CephMetadataBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "daily", Namespace: "ns"},
+		Spec:       cephv1.MetadataBackupSpec{PVC: "backups", Retention: 3},
+	}
+	_, err := context.RookClientset.CephV1().CephMetadataBackups("ns").Create(b)
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.backup(b))
+
+	// the archive is passed to the job in a secret
+	status, err := context.RookClientset.CephV1().CephMetadataBackups("ns").Get("daily", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.NotNil(t, status.Status.LastBackupTime)
+	assert.Equal(t, "", status.Status.Message)
+	secret, err := context.Clientset.CoreV1().Secrets("ns").Get("rook-ceph-metadata-backup-daily", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(secret.Data))
+	assert.NotNil(t, secret.Data[status.Status.LastBackup])
+
+	job, err := context.Clientset.BatchV1().Jobs("ns").Get("rook-ceph-metadata-backup-daily", metav1.GetOptions{})
+	assert.Nil(t, err)
+	spec := job.Spec.Template.Spec
+	assert.Equal(t, "rook/ceph:master", spec.Containers[0].Image)
+	assert.Equal(t, []string{"ceph", "metadata-backup",
+		"--archive", "/var/lib/rook-backup/archive/" + status.Status.LastBackup,
+		"--backup-dir", "/var/lib/rook-backup/backups",
+		"--backup-name", "daily",
+		"--retention", "3"}, spec.Containers[0].Args)
+	assert.Equal(t, "backups", spec.Volumes[1].PersistentVolumeClaim.ClaimName)
+
+	// the failure is reported in the status
+	b.Spec.PVC = ""
+	assert.NotNil(t, c.backup(b))
+	status, err = context.RookClientset.CephV1().CephMetadataBackups("ns").Get("daily", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Contains(t, status.Status.Message, "pvc or an s3 bucket is required")
+	assert.NotEqual(t, "", status.Status.LastBackup)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	cephrgw "github.com/rook/rook/pkg/daemon/ceph/rgw"
+)
+
+// Store keeps the archives of the backups
+type Store interface {
+	Put(name string, archive []byte) error
+	List() ([]string, error)
+	Delete(name string) error
+}
+
+// DirStore keeps the archives in a directory, such as the mount of a pvc
+type DirStore struct {
+	Dir string
+}
+
+// Put writes the archive to the directory
+func (s *DirStore) Put(name string, archive []byte) error {
+	// the archive is renamed once complete so an interrupted write does not leave a truncated backup
+	tmp := path.Join(s.Dir, "."+name)
+	if err := ioutil.WriteFile(tmp, archive, 0600); err != nil {
+		return fmt.Errorf("failed to write archive %s. %+v", name, err)
+	}
+	return os.Rename(tmp, path.Join(s.Dir, name))
+}
+
+// List returns the names of the files of the directory
+func (s *DirStore) List() ([]string, error) {
+	files, err := ioutil.ReadDir(s.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the archives in %s. %+v", s.Dir, err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	return names, nil
+}
+
+// Delete removes the archive from the directory
+func (s *DirStore) Delete(name string) error {
+	return os.Remove(path.Join(s.Dir, name))
+}
+
+// s3Store keeps the archives in a bucket, under the prefix
+type s3Store struct {
+	agent  *cephrgw.S3Agent
+	bucket string
+	prefix string
+}
+
+func (s *s3Store) Put(name string, archive []byte) error {
+	return s.agent.PutObject(s.bucket, s.prefix+name, archive)
+}
+
+func (s *s3Store) List() ([]string, error) {
+	keys, err := s.agent.ListObjects(s.bucket, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, key := range keys {
+		names = append(names, strings.TrimPrefix(key, s.prefix))
+	}
+	return names, nil
+}
+
+func (s *s3Store) Delete(name string) error {
+	return s.agent.DeleteObject(s.bucket, s.prefix+name)
+}
+
+// Prune deletes the oldest archives of a backup resource from the store, keeping the number of archives of the
+// retention. The archives of the other backup resources are ignored.
+func Prune(store Store, backupName string, retention int) error {
+	names, err := store.List()
+	if err != nil {
+		return err
+	}
+	archivePattern := regexp.MustCompile(fmt.Sprintf(`^%s-\d{8}-\d{6}%s$`, regexp.QuoteMeta(backupName), regexp.QuoteMeta(archiveExt)))
+	var archives []string
+	for _, name := range names {
+		if archivePattern.MatchString(name) {
+			archives = append(archives, name)
+		}
+	}
+	sort.Strings(archives)
+
+	for i := 0; i < len(archives)-retention; i++ {
+		logger.Infof("deleting backup %s beyond the retention of %d backups", archives[i], retention)
+		if err := store.Delete(archives[i]); err != nil {
+			return fmt.Errorf("failed to delete backup %s. %+v", archives[i], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	store := &DirStore{Dir: dir}
+
+	names := []string{
+		"daily-20181102-030405.tar.gz",
+		"daily-20181101-030405.tar.gz",
+		"daily-20181103-030405.tar.gz",
+		"weekly-20181001-030405.tar.gz",
+		"daily.txt",
+	}
+	for _, name := range names {
+		assert.Nil(t, store.Put(name, []byte(name)))
+	}
+	content, err := ioutil.ReadFile(dir + "/daily-20181102-030405.tar.gz")
+	assert.Nil(t, err)
+	assert.Equal(t, "daily-20181102-030405.tar.gz", string(content))
+
+	// the oldest archive of the backup is deleted, the other files are kept
+	assert.Nil(t, Prune(store, "daily", 2))
+	stored, err := store.List()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"daily-20181102-030405.tar.gz", "daily-20181103-030405.tar.gz", "daily.txt", "weekly-20181001-030405.tar.gz"}, stored)
+
+	// nothing to prune within the retention
+	assert.Nil(t, Prune(store, "weekly", 2))
+	stored, err = store.List()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(stored))
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package capacity checks the raw utilization of the clusters before creating the pools, filesystems and volumes.
+package capacity
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/coreos/pkg/capnslog"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// GuardrailIgnore disables the checks of the raw utilization
+	GuardrailIgnore = "Ignore"
+	// GuardrailAlert logs a warning when a creation would push the raw utilization above the threshold
+	GuardrailAlert = "Alert"
+	// GuardrailBlock also refuses the creation
+	GuardrailBlock = "Block"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-capacity")
+
+var (
+	// GuardrailPolicy is what is done when the creation of a pool, filesystem or volume would push the projected raw
+	// utilization of a cluster above the fullness threshold
+	GuardrailPolicy = GuardrailIgnore
+	// FullnessThresholdPercent is the percentage of the raw capacity of a cluster above which the creations are
+	// checked. The default is below the nearfull ratio of ceph (85%) so the cluster does not become nearfull.
+	FullnessThresholdPercent = 80
+)
+
+// ValidateGuardrail checks the policy and the threshold of the capacity guardrail
+func ValidateGuardrail() error {
+	switch GuardrailPolicy {
+	case GuardrailIgnore, GuardrailAlert, GuardrailBlock:
+	default:
+		return fmt.Errorf("invalid capacity guardrail policy %q. must be one of %s, %s or %s",
+			GuardrailPolicy, GuardrailIgnore, GuardrailAlert, GuardrailBlock)
+	}
+	if FullnessThresholdPercent <= 0 || FullnessThresholdPercent > 100 {
+		return fmt.Errorf("invalid fullness threshold %d%%. must be between 1 and 100", FullnessThresholdPercent)
+	}
+	return nil
+}
+
+// Enabled returns whether the creations are checked against the raw utilization of the clusters
+func Enabled() bool {
+	return GuardrailPolicy == GuardrailAlert || GuardrailPolicy == GuardrailBlock
+}
+
+// Check returns an error with the Block policy if creating the resource, which may use the additional raw bytes, would
+// push the raw utilization of the cluster above the fullness threshold. The utilization is projected from the current
+// usage reported by ceph df, and the thin provisioned volumes only count when they are created or expanded. If the
+// usage cannot be read, the creation is allowed.
+func Check(context *clusterd.Context, clusterName, resource string, additionalRawBytes uint64) error {
+	if !Enabled() {
+		return nil
+	}
+	usage, err := client.Usage(context, clusterName)
+	if err != nil {
+		logger.Warningf("failed to check the raw utilization of cluster %s before creating %s. %+v", clusterName, resource, err)
+		return nil
+	}
+	total := parseBytes(usage.Stats.TotalBytes.String())
+	if total == 0 {
+		return nil
+	}
+	used := parseBytes(usage.Stats.TotalUsedBytes.String())
+	projected := float64(used+additionalRawBytes) * 100 / float64(total)
+	if projected <= float64(FullnessThresholdPercent) {
+		return nil
+	}
+
+	err = fmt.Errorf("creating %s would raise the raw utilization of cluster %s to %.1f%%, above the fullness threshold of %d%%",
+		resource, clusterName, projected, FullnessThresholdPercent)
+	if GuardrailPolicy == GuardrailAlert {
+		logger.Warningf("%+v", err)
+		return nil
+	}
+	return err
+}
+
+// PoolRawBytes returns the raw bytes used by storing the bytes in the pool, with its replicas or its coding chunks
+func PoolRawBytes(context *clusterd.Context, clusterName, poolName string, bytes int64) (uint64, error) {
+	if bytes <= 0 {
+		return 0, nil
+	}
+	details, err := client.GetPoolDetails(context, clusterName, poolName)
+	if err != nil {
+		return 0, err
+	}
+	factor := float64(details.Size)
+	if details.ErasureCodeProfile != "" {
+		profile, err := client.GetErasureCodeProfileDetails(context, clusterName, details.ErasureCodeProfile)
+		if err != nil {
+			return 0, err
+		}
+		if profile.DataChunkCount > 0 {
+			factor = float64(profile.DataChunkCount+profile.CodingChunkCount) / float64(profile.DataChunkCount)
+		}
+	}
+	if factor < 1 {
+		factor = 1
+	}
+	return uint64(float64(bytes) * factor), nil
+}
+
+// SpecRawBytes returns the raw bytes used by the expected size of a pool which does not exist yet, with the replicas or
+// the coding chunks of its spec
+func SpecRawBytes(spec cephv1.PoolSpec) (uint64, error) {
+	if spec.ExpectedSize == "" {
+		return 0, nil
+	}
+	size, err := resource.ParseQuantity(spec.ExpectedSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expected size %q. %+v", spec.ExpectedSize, err)
+	}
+	if size.Value() <= 0 {
+		return 0, nil
+	}
+	factor := float64(1)
+	if r := spec.Replication(); r != nil {
+		factor = float64(r.Size)
+	} else if ec := spec.ErasureCode(); ec != nil && ec.DataChunks > 0 {
+		factor = float64(ec.DataChunks+ec.CodingChunks) / float64(ec.DataChunks)
+	}
+	return uint64(float64(size.Value()) * factor), nil
+}
+
+// FilesystemRawBytes returns the raw bytes used by storing the bytes in the default data pool of the filesystem
+func FilesystemRawBytes(context *clusterd.Context, clusterName, fsName string, bytes int64) (uint64, error) {
+	if bytes <= 0 {
+		return 0, nil
+	}
+	fs, err := client.GetFilesystem(context, clusterName, fsName)
+	if err != nil {
+		return 0, err
+	}
+	if len(fs.MDSMap.DataPools) == 0 {
+		return 0, fmt.Errorf("filesystem %s has no data pool", fsName)
+	}
+	names, err := client.GetPoolNamesByID(context, clusterName)
+	if err != nil {
+		return 0, err
+	}
+	poolName, ok := names[fs.MDSMap.DataPools[0]]
+	if !ok {
+		return 0, fmt.Errorf("data pool %d of filesystem %s not found", fs.MDSMap.DataPools[0], fsName)
+	}
+	return PoolRawBytes(context, clusterName, poolName, bytes)
+}
+
+func parseBytes(value string) uint64 {
+	bytes, err := strconv.ParseFloat(value, 64)
+	if err != nil || bytes < 0 {
+		return 0
+	}
+	return uint64(bytes)
+}
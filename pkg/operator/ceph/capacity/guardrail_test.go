@@ -0,0 +1,143 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"errors"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const dfOutput = `{"stats":{"total_bytes":1000,"total_used_bytes":700,"total_avail_bytes":300,"total_objects":10}}`
+
+func newTestContext(dfFails bool) *clusterd.Context {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outFileArg string, args ...string) (string, error) {
+			if args[0] == "df" {
+				if dfFails {
+					return "", errors.New("timed out")
+				}
+				return dfOutput, nil
+			}
+			if args[0] == "osd" && args[1] == "pool" && args[2] == "get" {
+				switch args[3] {
+				case "replicapool":
+					return `{"pool":"replicapool","size":3}{"pool":"replicapool","erasure_code_profile":""}`, nil
+				case "ecpool":
+					return `{"pool":"ecpool","size":3}{"pool":"ecpool","erasure_code_profile":"ecpool"}`, nil
+				}
+				return "", errors.New("pool not found")
+			}
+			if args[0] == "osd" && args[1] == "erasure-code-profile" {
+				return `{"k":"2","m":"1","plugin":"jerasure"}`, nil
+			}
+			return "", nil
+		},
+	}
+	return &clusterd.Context{Executor: executor}
+}
+
+func setGuardrail(policy string, threshold int) func() {
+	oldPolicy, oldThreshold := GuardrailPolicy, FullnessThresholdPercent
+	GuardrailPolicy, FullnessThresholdPercent = policy, threshold
+	return func() {
+		GuardrailPolicy, FullnessThresholdPercent = oldPolicy, oldThreshold
+	}
+}
+
+func TestValidateGuardrail(t *testing.T) {
+	defer setGuardrail(GuardrailBlock, 80)()
+	assert.Nil(t, ValidateGuardrail())
+
+	GuardrailPolicy = "Deny"
+	assert.NotNil(t, ValidateGuardrail())
+
+	GuardrailPolicy = GuardrailAlert
+	FullnessThresholdPercent = 0
+	assert.NotNil(t, ValidateGuardrail())
+	FullnessThresholdPercent = 101
+	assert.NotNil(t, ValidateGuardrail())
+}
+
+func TestCheck(t *testing.T) {
+	context := newTestContext(false)
+
+	// the cluster is not checked when the guardrail is ignored
+	restore := setGuardrail(GuardrailIgnore, 50)
+	assert.Nil(t, Check(context, "rook-ceph", "pool a", 0))
+	restore()
+
+	defer setGuardrail(GuardrailBlock, 80)()
+	assert.Nil(t, Check(context, "rook-ceph", "pool a", 0))
+	assert.Nil(t, Check(context, "rook-ceph", "volume a", 100))
+	err := Check(context, "rook-ceph", "volume a", 101)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "80.1%")
+
+	// the cluster is already above the threshold
+	FullnessThresholdPercent = 60
+	assert.NotNil(t, Check(context, "rook-ceph", "pool a", 0))
+
+	// the creations are only logged with the alert policy
+	GuardrailPolicy = GuardrailAlert
+	assert.Nil(t, Check(context, "rook-ceph", "pool a", 0))
+
+	// the creations are allowed when the usage is unknown
+	GuardrailPolicy = GuardrailBlock
+	assert.Nil(t, Check(newTestContext(true), "rook-ceph", "pool a", 0))
+}
+
+func TestPoolRawBytes(t *testing.T) {
+	context := newTestContext(false)
+
+	bytes, err := PoolRawBytes(context, "rook-ceph", "replicapool", 100)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(300), bytes)
+
+	bytes, err = PoolRawBytes(context, "rook-ceph", "ecpool", 100)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(150), bytes)
+
+	bytes, err = PoolRawBytes(context, "rook-ceph", "replicapool", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), bytes)
+
+	_, err = PoolRawBytes(context, "rook-ceph", "missing", 100)
+	assert.NotNil(t, err)
+}
+
+func TestSpecRawBytes(t *testing.T) {
+	// a pool without expected size is counted as empty
+	bytes, err := SpecRawBytes(cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), bytes)
+
+	bytes, err = SpecRawBytes(cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}, ExpectedSize: "1Ki"})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3072), bytes)
+
+	bytes, err = SpecRawBytes(cephv1.PoolSpec{ErasureCoded: cephv1.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}, ExpectedSize: "1Ki"})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1536), bytes)
+
+	_, err = SpecRawBytes(cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}, ExpectedSize: "lots"})
+	assert.NotNil(t, err)
+}
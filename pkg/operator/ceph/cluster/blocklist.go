@@ -0,0 +1,224 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// BlocklistAnnotation fences the rbd and cephfs clients of a node, such as a crashed node whose volumes are
+	// mounted on another node. The value is "true" to block the clients until the annotation is removed, or the
+	// duration of the block such as "2h".
+	BlocklistAnnotation = "ceph.rook.io/blocklist"
+
+	// the time the clients of the node were first blocked, set by the operator so the duration of the block counts
+	// from it when the annotation is changed or processed again after a restart of the operator
+	blocklistSinceAnnotation = "ceph.rook.io/blocklist-since"
+
+	// the expiration of the entries blocking the clients until the annotation is removed
+	permanentBlocklistExpiration = 10 * 365 * 24 * time.Hour
+
+	// the reason of the events of the blocks refused on the clusters using the host network
+	blocklistRefusedReason = "BlocklistRefused"
+)
+
+// updateNodeBlocklist adds or removes the addresses of the node in the blocklist of the clusters when the blocklist
+// annotation of the node is set, changed or removed
+func (c *ClusterController) updateNodeBlocklist(oldNode, node *v1.Node) {
+	oldValue, oldBlocked := oldNode.Annotations[BlocklistAnnotation]
+	value, blocked := node.Annotations[BlocklistAnnotation]
+	if oldBlocked == blocked && oldValue == value {
+		return
+	}
+	c.applyNodeBlocklist(c.clusters(), node, time.Now())
+}
+
+// syncNodeBlocklists applies the blocklist annotations of the nodes to a cluster once it is initialized, since the
+// annotations set or removed before, such as while the operator was restarting, were skipped for this cluster
+func (c *ClusterController) syncNodeBlocklists(initialized *cluster) {
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		logger.Warningf("failed to list the nodes to sync the blocklist of cluster %s. %+v", initialized.Namespace, err)
+		return
+	}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		_, blocked := node.Annotations[BlocklistAnnotation]
+		_, wasBlocked := node.Annotations[blocklistSinceAnnotation]
+		if blocked || wasBlocked {
+			c.applyNodeBlocklist([]*cluster{initialized}, node, time.Now())
+		}
+	}
+}
+
+// applyNodeBlocklist blocks the clients of the node in the initialized clusters while the node has the blocklist
+// annotation, and allows them again once the annotation is removed
+func (c *ClusterController) applyNodeBlocklist(clusters []*cluster, node *v1.Node, now time.Time) {
+	value, blocked := node.Annotations[BlocklistAnnotation]
+	var since time.Time
+	if blocked {
+		var err error
+		if since, err = c.blocklistSince(node, now); err != nil {
+			logger.Errorf("failed to update the blocklist of node %s. %+v", node.Name, err)
+			return
+		}
+	}
+
+	allowed := true
+	for _, cluster := range clusters {
+		if !cluster.initCompleted {
+			allowed = false
+			continue
+		}
+		var err error
+		if blocked {
+			err = blocklistNode(cluster, node, value, since, now)
+		} else {
+			err = unblocklistNode(cluster, node)
+		}
+		if err != nil {
+			allowed = false
+			logger.Errorf("failed to update the blocklist of node %s in cluster %s. %+v", node.Name, cluster.Namespace, err)
+		}
+	}
+
+	// the start of the block is kept until the clients are allowed again in all the clusters
+	if _, ok := node.Annotations[blocklistSinceAnnotation]; ok && !blocked && allowed {
+		if err := c.annotateBlocklistSince(node.Name, nil); err != nil {
+			logger.Warningf("failed to remove the blocklist start of node %s. %+v", node.Name, err)
+		}
+	}
+}
+
+// blocklistSince returns the time the clients of the node were first blocked, which is now for a new block
+func (c *ClusterController) blocklistSince(node *v1.Node, now time.Time) (time.Time, error) {
+	if value, ok := node.Annotations[blocklistSinceAnnotation]; ok {
+		since, err := time.Parse(time.RFC3339, value)
+		if err == nil {
+			return since, nil
+		}
+		logger.Warningf("invalid %s annotation %q of node %s. the block starts now", blocklistSinceAnnotation, value, node.Name)
+	}
+	value := now.UTC().Format(time.RFC3339)
+	if err := c.annotateBlocklistSince(node.Name, &value); err != nil {
+		return time.Time{}, err
+	}
+	return now, nil
+}
+
+// annotateBlocklistSince sets the start of the block of the node, or removes it if the value is nil
+func (c *ClusterController) annotateBlocklistSince(nodeName string, value *string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]*string{blocklistSinceAnnotation: value},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the annotation of node %s. %+v", nodeName, err)
+	}
+	if _, err := c.context.Clientset.CoreV1().Nodes().Patch(nodeName, types.MergePatchType, patch); err != nil {
+		return fmt.Errorf("failed to annotate node %s with the start of its block. %+v", nodeName, err)
+	}
+	return nil
+}
+
+// blocklistNode blocks the clients of all the addresses of the node for the duration of the annotation value, counted
+// from the start of the block. The clients are not blocked if the daemons of the cluster use the host network, since
+// the daemons running on the node would be blocked as well.
+func blocklistNode(cluster *cluster, node *v1.Node, value string, since, now time.Time) error {
+	if cluster.getSpec().Network.HostNetwork {
+		message := fmt.Sprintf("refused to block the clients of node %s since the daemons of the cluster use the host network and would be blocked as well", node.Name)
+		if err := recordClusterEvent(cluster, cluster.crdName, v1.EventTypeWarning, blocklistRefusedReason, message); err != nil {
+			logger.Warningf("failed to record blocklist event of cluster %s. %+v", cluster.Namespace, err)
+		}
+		return errors.New(message)
+	}
+
+	expire, err := blocklistExpiration(value)
+	if err != nil {
+		return err
+	}
+	if expire != permanentBlocklistExpiration {
+		expire = since.Add(expire).Sub(now)
+		if expire <= 0 {
+			logger.Infof("the block of the clients of node %s started at %s has expired", node.Name, since)
+			return unblocklistNode(cluster, node)
+		}
+	}
+	for _, ip := range nodeIPs(node) {
+		if err := client.AddToBlocklist(cluster.context, cluster.Namespace, client.NodeBlocklistAddr(ip), expire); err != nil {
+			return err
+		}
+	}
+	logger.Infof("blocked the clients of node %s in cluster %s for %s", node.Name, cluster.Namespace, expire)
+	return nil
+}
+
+// unblocklistNode allows the clients of all the addresses of the node again. The addresses which are not blocked
+// anymore, such as after the expiration of the block, are skipped.
+func unblocklistNode(cluster *cluster, node *v1.Node) error {
+	entries, err := client.ListBlocklist(cluster.context, cluster.Namespace)
+	if err != nil {
+		return err
+	}
+	blocked := map[string]bool{}
+	for _, entry := range entries {
+		blocked[entry.Addr] = true
+	}
+
+	for _, ip := range nodeIPs(node) {
+		addr := client.NodeBlocklistAddr(ip)
+		if !blocked[addr] {
+			continue
+		}
+		if err := client.RemoveFromBlocklist(cluster.context, cluster.Namespace, addr); err != nil {
+			return err
+		}
+	}
+	logger.Infof("allowed the clients of node %s in cluster %s", node.Name, cluster.Namespace)
+	return nil
+}
+func blocklistExpiration(value string) (time.Duration, error) {
+	if value == "true" {
+		return permanentBlocklistExpiration, nil
+	}
+	expire, err := time.ParseDuration(value)
+	if err != nil || expire <= 0 {
+		return 0, fmt.Errorf("invalid %s annotation %q. must be true or a duration such as 2h", BlocklistAnnotation, value)
+	}
+	return expire, nil
+}
+
+// nodeIPs returns the internal and external ips of the node used by its clients
+func nodeIPs(node *v1.Node) []string {
+	var ips []string
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP || addr.Type == v1.NodeExternalIP {
+			ips = append(ips, addr.Address)
+		}
+	}
+	return ips
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestUpdateNodeBlocklist(t *testing.T) {
+	commands := []string{}
+	blocked := `[{"addr":"10.0.0.1:0/0","until":"2028-10-15 10:00:00.000000"}]`
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			// the ceph command before the connection and format flags
+			i := 0
+			for i < len(args) && !strings.HasPrefix(args[i], "--") {
+				i++
+			}
+			commands = append(commands, strings.Join(args[:i], " "))
+			if args[1] == "blacklist" && args[2] == "ls" {
+				return blocked, nil
+			}
+			return "", nil
+		},
+	}
+	oldNode := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+			{Type: v1.NodeHostName, Address: "node1"},
+			{Type: v1.NodeInternalIP, Address: "10.0.0.1"},
+		}},
+	}
+	clientset := fake.NewSimpleClientset(oldNode)
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+	c := &ClusterController{context: context, clusterMap: map[string]*cluster{
+		"ns":      {context: context, Namespace: "ns", Spec: &cephv1.ClusterSpec{}, initCompleted: true},
+		"pending": {context: context, Namespace: "pending", Spec: &cephv1.ClusterSpec{}},
+	}}
+
+	// the annotation is set until it is removed, and the start of the block is recorded
+	node := oldNode.DeepCopy()
+	node.Annotations = map[string]string{BlocklistAnnotation: "true"}
+	c.updateNodeBlocklist(oldNode, node)
+	assert.Equal(t, []string{"osd blacklist add 10.0.0.1:0/0 315360000"}, commands)
+	updated, err := clientset.CoreV1().Nodes().Get("node1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	since, err := time.Parse(time.RFC3339, updated.Annotations[blocklistSinceAnnotation])
+	assert.Nil(t, err)
+
+	// the annotation is not changed
+	commands = nil
+	c.updateNodeBlocklist(node, node)
+	assert.Equal(t, 0, len(commands))
+
+	// the duration of the block is changed, and counts from the start of the block
+	commands = nil
+	newNode := node.DeepCopy()
+	newNode.Annotations[BlocklistAnnotation] = "2h"
+	newNode.Annotations[blocklistSinceAnnotation] = since.Add(-time.Hour).Format(time.RFC3339)
+	c.applyNodeBlocklist(c.clusters(), newNode, since)
+	assert.Equal(t, []string{"osd blacklist add 10.0.0.1:0/0 3600"}, commands)
+
+	// the block has expired
+	commands = nil
+	c.applyNodeBlocklist(c.clusters(), newNode, since.Add(2*time.Hour))
+	assert.Equal(t, []string{"osd blacklist ls", "osd blacklist rm 10.0.0.1:0/0"}, commands)
+
+	// the annotation is removed, and the start of the block as well
+	commands = nil
+	c.clusterMap["pending"].initCompleted = true
+	updated.Annotations[BlocklistAnnotation] = "true"
+	c.updateNodeBlocklist(updated, oldNode)
+	assert.Equal(t, []string{"osd blacklist ls", "osd blacklist rm 10.0.0.1:0/0", "osd blacklist ls", "osd blacklist rm 10.0.0.1:0/0"}, commands)
+
+	// the annotation is removed after the block expired
+	commands = nil
+	blocked = "[]"
+	c.updateNodeBlocklist(updated, oldNode)
+	assert.Equal(t, []string{"osd blacklist ls", "osd blacklist ls"}, commands)
+
+	// an invalid duration is ignored
+	commands = nil
+	newNode.Annotations[BlocklistAnnotation] = "yes"
+	c.updateNodeBlocklist(oldNode, newNode)
+	assert.Equal(t, 0, len(commands))
+}
+
+func TestBlocklistHostNetwork(t *testing.T) {
+	commands := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			commands++
+			return "", nil
+		},
+	}
+	clientset := fake.NewSimpleClientset()
+	c := &cluster{context: &clusterd.Context{Executor: executor, Clientset: clientset}, Namespace: "ns", crdName: "mycluster",
+		Spec: &cephv1.ClusterSpec{Network: rookalpha.NetworkSpec{HostNetwork: true}}, initCompleted: true}
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: map[string]string{BlocklistAnnotation: "true"}},
+		Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}},
+	}
+
+	// the daemons of the node would be blocked as well
+	err := blocklistNode(c, node, "true", time.Now(), time.Now())
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, commands)
+	events, err := clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Equal(t, blocklistRefusedReason, events.Items[0].Reason)
+}
+
+func TestSyncNodeBlocklists(t *testing.T) {
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			commands = append(commands, strings.Join(args[:3], " "))
+			if args[2] == "ls" {
+				return `[{"addr":"10.0.0.2:0/0","until":"2028-10-15 10:00:00.000000"}]`, nil
+			}
+			return "", nil
+		},
+	}
+	address := func(ip string) v1.NodeStatus {
+		return v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: ip}}}
+	}
+	clientset := fake.NewSimpleClientset(
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "blocked", Annotations: map[string]string{BlocklistAnnotation: "true"}}, Status: address("10.0.0.1")},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "allowed", Annotations: map[string]string{blocklistSinceAnnotation: "2026-10-15T10:00:00Z"}}, Status: address("10.0.0.2")},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "other"}, Status: address("10.0.0.3")},
+	)
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+	c := &ClusterController{context: context}
+
+	// the annotations changed while the operator was restarting are applied
+	c.syncNodeBlocklists(&cluster{context: context, Namespace: "ns", Spec: &cephv1.ClusterSpec{}, initCompleted: true})
+	sort.Strings(commands)
+	assert.Equal(t, []string{"osd blacklist add", "osd blacklist ls", "osd blacklist rm"}, commands)
+	var patches []string
+	for _, action := range clientset.Actions() {
+		if patch, ok := action.(k8stesting.PatchAction); ok {
+			patches = append(patches, patch.GetName()+" "+string(patch.GetPatch()))
+		}
+	}
+	assert.Equal(t, 2, len(patches))
+	assert.Contains(t, patches, `allowed {"metadata":{"annotations":{"ceph.rook.io/blocklist-since":null}}}`)
+}
+
+func TestBlocklistExpiration(t *testing.T) {
+	expire, err := blocklistExpiration("true")
+	assert.Nil(t, err)
+	assert.Equal(t, permanentBlocklistExpiration, expire)
+
+	expire, err = blocklistExpiration("30m")
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Minute, expire)
+
+	for _, invalid := range []string{"", "false", "-1h", "0s"} {
+		_, err = blocklistExpiration(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the reason of the events of the stale resources deleted by the cleanup
+	staleResourcesDeletedReason = "StaleResourcesDeleted"
+)
+
+var (
+	// how often the stale resources of a cluster are deleted
+	cleanupInterval = time.Hour
+	// how old a resource must be to be deleted, so the resources being created with their daemon are not deleted
+	staleResourceMinAge = time.Hour
+)
+
+// cleaner deletes the resources of the daemons and nodes which do not exist anymore, which accumulate in long lived
+// clusters: the orchestration status and benchmarks config maps of the removed nodes, the services of the mons removed
+// by a failover, and the keyrings of the removed daemons
+type cleaner struct {
+	cluster *cluster
+	crdName string
+}
+
+func newCleaner(cluster *cluster, crdName string) *cleaner {
+	return &cleaner{cluster: cluster, crdName: crdName}
+}
+
+// Start deletes the stale resources at each interval until the cluster is stopped
+func (c *cleaner) Start() {
+	for {
+		select {
+		case <-c.cluster.stopCh:
+			logger.Infof("stopping the cleanup of cluster %s", c.cluster.Namespace)
+			return
+		case <-time.After(cleanupInterval):
+			if c.cluster.getSpec().MaintenanceMode {
+				continue
+			}
+			if err := c.cleanupOnce(time.Now()); err != nil {
+				logger.Warningf("failed to delete the stale resources of cluster %s. %+v", c.cluster.Namespace, err)
+			}
+		}
+	}
+}
+
+// cleanupOnce deletes the stale resources older than the min age, and records the deleted resources as an event of
+// the cluster. The other kinds of resources are still cleaned up if the stale resources of a kind cannot be listed.
+func (c *cleaner) cleanupOnce(now time.Time) error {
+	clientset := c.cluster.context.Clientset
+	namespace := c.cluster.Namespace
+	options := &metav1.DeleteOptions{}
+	deleted := []string{}
+	var errs []string
+	stale := func(meta metav1.ObjectMeta) bool {
+		return now.Sub(meta.CreationTimestamp.Time) >= staleResourceMinAge
+	}
+	deleteResource := func(kind, name string, err error) {
+		if err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("failed to delete %s %s. %+v", kind, name, err))
+			return
+		}
+		logger.Infof("deleted stale %s %s of cluster %s", kind, name, namespace)
+		deleted = append(deleted, fmt.Sprintf("%s %s", kind, name))
+	}
+
+	configMaps, err := osd.StaleNodeConfigMaps(clientset, namespace)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	for _, cm := range configMaps {
+		if stale(cm.ObjectMeta) {
+			deleteResource("config map", cm.Name, clientset.CoreV1().ConfigMaps(namespace).Delete(cm.Name, options))
+		}
+	}
+
+	services, err := mon.StaleServices(clientset, namespace)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	for _, service := range services {
+		if stale(service.ObjectMeta) {
+			deleteResource("mon service", service.Name, clientset.CoreV1().Services(namespace).Delete(service.Name, options))
+		}
+	}
+
+	keyrings, err := opspec.StaleKeyrings(clientset, namespace)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	for _, secret := range keyrings {
+		if stale(secret.ObjectMeta) {
+			deleteResource("keyring", secret.Name, clientset.CoreV1().Secrets(namespace).Delete(secret.Name, options))
+		}
+	}
+
+	if len(deleted) > 0 {
+		sort.Strings(deleted)
+		message := fmt.Sprintf("deleted %d stale resources: %s", len(deleted), strings.Join(deleted, ", "))
+		if err := recordClusterEvent(c.cluster, c.crdName, v1.EventTypeNormal, staleResourcesDeletedReason, message); err != nil {
+			logger.Warningf("failed to record the deleted stale resources of cluster %s. %+v", namespace, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ". "))
+	}
+	return nil
+}
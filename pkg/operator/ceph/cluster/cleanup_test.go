@@ -0,0 +1,139 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCleanupStaleResources(t *testing.T) {
+	now := time.Now()
+	old := metav1.NewTime(now.Add(-2 * time.Hour))
+	recent := metav1.NewTime(now.Add(-time.Minute))
+	meta := func(name string, created metav1.Time, labels map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "ns", CreationTimestamp: created, Labels: labels}
+	}
+	osdLabels := func(node string) map[string]string {
+		return map[string]string{k8sutil.AppAttr: "rook-ceph-osd", "status": "provisioning", "node": node}
+	}
+	monLabels := func(name string) map[string]string {
+		return map[string]string{k8sutil.AppAttr: "rook-ceph-mon", "mon": name}
+	}
+	keyring := func(name string, created metav1.Time) *v1.Secret {
+		return &v1.Secret{ObjectMeta: meta(name, created, nil), Type: k8sutil.RookType, Data: map[string][]byte{"keyring": []byte("key")}}
+	}
+	deployment := func(name, secret string) *extensions.Deployment {
+		d := &extensions.Deployment{ObjectMeta: meta(name, old, nil)}
+		d.Spec.Template.Spec.Containers = []v1.Container{{Env: []v1.EnvVar{{Name: "KEYRING",
+			ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: secret}, Key: "keyring"}}}}}}
+		return d
+	}
+
+	clientset := fake.NewSimpleClientset(
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{"kubernetes.io/hostname": "host2"}}},
+		// the config maps of the removed node3 are deleted once old enough
+		&v1.ConfigMap{ObjectMeta: meta("rook-ceph-osd-node1-status", old, osdLabels("node1"))},
+		&v1.ConfigMap{ObjectMeta: meta("rook-ceph-osd-host2-status", old, osdLabels("host2"))},
+		&v1.ConfigMap{ObjectMeta: meta("rook-ceph-osd-node3-status", old, osdLabels("node3"))},
+		&v1.ConfigMap{ObjectMeta: meta("rook-ceph-osd-node3-benchmarks", old, map[string]string{k8sutil.AppAttr: "rook-ceph-osd", "node": "node3"})},
+		&v1.ConfigMap{ObjectMeta: meta("rook-ceph-osd-node4-status", recent, osdLabels("node4"))},
+		// the service of mon c was left by a failover
+		&v1.ConfigMap{ObjectMeta: meta(mon.EndpointConfigMapName, old, nil), Data: map[string]string{mon.EndpointDataKey: "a=1.2.3.4:6789,b=1.2.3.5:6789,d=1.2.3.6:6789"}},
+		&v1.Service{ObjectMeta: meta("rook-ceph-mon-a", old, monLabels("a"))},
+		&v1.Service{ObjectMeta: meta("rook-ceph-mon-b", old, monLabels("b"))},
+		&v1.Service{ObjectMeta: meta("rook-ceph-mon-c", old, monLabels("c"))},
+		&v1.Service{ObjectMeta: meta("rook-ceph-mon-d", old, monLabels("d"))},
+		&v1.Service{ObjectMeta: meta("rook-ceph-mon-e", recent, monLabels("e"))},
+		// the keyrings of the removed mgr b and mds are deleted
+		deployment("rook-ceph-mgr-a", "rook-ceph-mgr-a"),
+		keyring("rook-ceph-mgr-a", old),
+		keyring("rook-ceph-mgr-b", old),
+		keyring("rook-ceph-mds-myfs-c", old),
+		keyring("rook-ceph-rbd-mirror-a", recent),
+		&v1.Secret{ObjectMeta: meta("rook-ceph-mon", old, nil), Type: k8sutil.RookType, Data: map[string][]byte{"admin-secret": []byte("key")}},
+	)
+	c := &cluster{
+		Namespace: "ns",
+		context:   &clusterd.Context{Clientset: clientset},
+		ownerRef:  ClusterOwnerRef("ns", "cluster-uid"),
+	}
+
+	err := newCleaner(c, "cluster").cleanupOnce(now)
+	assert.Nil(t, err)
+
+	configMaps, err := clientset.CoreV1().ConfigMaps("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	names := []string{}
+	for _, cm := range configMaps.Items {
+		names = append(names, cm.Name)
+	}
+	assert.ElementsMatch(t, []string{"rook-ceph-osd-node1-status", "rook-ceph-osd-host2-status", "rook-ceph-osd-node4-status", mon.EndpointConfigMapName}, names)
+
+	services, err := clientset.CoreV1().Services("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	names = []string{}
+	for _, s := range services.Items {
+		names = append(names, s.Name)
+	}
+	assert.ElementsMatch(t, []string{"rook-ceph-mon-a", "rook-ceph-mon-b", "rook-ceph-mon-d", "rook-ceph-mon-e"}, names)
+
+	secrets, err := clientset.CoreV1().Secrets("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	names = []string{}
+	for _, s := range secrets.Items {
+		names = append(names, s.Name)
+	}
+	assert.ElementsMatch(t, []string{"rook-ceph-mgr-a", "rook-ceph-rbd-mirror-a", "rook-ceph-mon"}, names)
+
+	// the deleted resources are recorded as one event
+	events, err := clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Equal(t, staleResourcesDeletedReason, events.Items[0].Reason)
+	assert.Equal(t, "deleted 5 stale resources: config map rook-ceph-osd-node3-benchmarks, config map rook-ceph-osd-node3-status, "+
+		"keyring rook-ceph-mds-myfs-c, keyring rook-ceph-mgr-b, mon service rook-ceph-mon-c", events.Items[0].Message)
+
+	// nothing else is deleted
+	err = newCleaner(c, "cluster").cleanupOnce(now)
+	assert.Nil(t, err)
+	events, err = clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events.Items))
+}
+
+func TestCleanupKeepsMonServicesWithoutEndpoints(t *testing.T) {
+	// the services are kept if the mon endpoints are not known
+	clientset := fake.NewSimpleClientset(
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a", Namespace: "ns", Labels: map[string]string{k8sutil.AppAttr: "rook-ceph-mon", "mon": "a"}}},
+	)
+	c := &cluster{Namespace: "ns", context: &clusterd.Context{Clientset: clientset}, ownerRef: ClusterOwnerRef("ns", "cluster-uid")}
+	err := newCleaner(c, "cluster").cleanupOnce(time.Now())
+	assert.Nil(t, err)
+	_, err = clientset.CoreV1().Services("ns").Get("rook-ceph-mon-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the reasons of the events of the clock skew of the mons
+	clockSkewReason         = "ClockSkew"
+	clockSynchronizedReason = "ClockSynchronized"
+)
+
+// reportClockSkew sets the ClockSkew condition of the cluster with the nodes of the skewed mons and records an event when
+// the skewed nodes change, or removes the condition once the clocks are synchronized again
+func reportClockSkew(cluster *cluster, crdName string, skews []mon.ClockSkew) error {
+	clusterObj, err := cluster.context.RookClientset.CephV1().CephClusters(cluster.Namespace).Get(crdName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s. %+v", crdName, err)
+	}
+
+	var conditions []cephv1.ClusterCondition
+	var previous *cephv1.ClusterCondition
+	for i, condition := range clusterObj.Status.Conditions {
+		if condition.Type == cephv1.ClusterConditionClockSkew {
+			previous = &clusterObj.Status.Conditions[i]
+		} else {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	current := clockSkewCondition(skews)
+	eventType, reason := v1.EventTypeWarning, clockSkewReason
+	if current == nil {
+		if previous == nil {
+			return nil
+		}
+		eventType, reason = v1.EventTypeNormal, clockSynchronizedReason
+		current = &cephv1.ClusterCondition{Message: "the clocks of the mons are synchronized again"}
+	} else {
+		if previous != nil && reflect.DeepEqual(previous.Nodes, current.Nodes) {
+			// the event was already recorded for these nodes
+			return nil
+		}
+		conditions = append(conditions, *current)
+	}
+
+	clusterObj.Status.Conditions = conditions
+	if _, err := cluster.context.RookClientset.CephV1().CephClusters(cluster.Namespace).Update(clusterObj); err != nil {
+		return fmt.Errorf("failed to update the conditions of cluster %s. %+v", crdName, err)
+	}
+	return recordClusterEvent(cluster, crdName, eventType, reason, current.Message)
+}
+
+// clockSkewCondition returns the ClockSkew condition naming the skewed mons and their nodes, with the remediation hints,
+// or nil if no clock is skewed
+func clockSkewCondition(skews []mon.ClockSkew) *cephv1.ClusterCondition {
+	if len(skews) == 0 {
+		return nil
+	}
+
+	var mons []string
+	nodes := map[string]bool{}
+	for _, skew := range skews {
+		node := skew.Node
+		if node == "" {
+			node = "unknown"
+		}
+		mons = append(mons, fmt.Sprintf("mon %s on node %s (skew %ss)", skew.Mon, node, skew.Skew))
+		nodes[node] = true
+	}
+	var nodeNames []string
+	for node := range nodes {
+		nodeNames = append(nodeNames, node)
+	}
+	sort.Strings(nodeNames)
+
+	return &cephv1.ClusterCondition{
+		Type:   cephv1.ClusterConditionClockSkew,
+		Status: v1.ConditionTrue,
+		Reason: clockSkewReason,
+		Message: fmt.Sprintf("clock skew detected on %s. check that ntp or chrony synchronizes the clock of the nodes %s, "+
+			"or enable the chrony sidecar of the mons (mon.chrony) if the time sync of the hosts cannot be fixed",
+			strings.Join(mons, ", "), strings.Join(nodeNames, ", ")),
+		Nodes:              nodeNames,
+		LastTransitionTime: metav1.Now(),
+	}
+}
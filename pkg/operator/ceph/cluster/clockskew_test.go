@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReportClockSkew(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	rookClientset := rookfake.NewSimpleClientset(&cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "ns"},
+		Status:     cephv1.ClusterStatus{State: cephv1.ClusterStateCreated},
+	})
+	c := &cluster{Namespace: "ns", context: &clusterd.Context{Clientset: clientset, RookClientset: rookClientset}, ownerRef: ClusterOwnerRef("ns", "cluster-uid")}
+	getCondition := func() *cephv1.ClusterCondition {
+		clusterObj, err := rookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+		require.Nil(t, err)
+		for _, condition := range clusterObj.Status.Conditions {
+			if condition.Type == cephv1.ClusterConditionClockSkew {
+				return &condition
+			}
+		}
+		return nil
+	}
+	getEvents := func() []v1.Event {
+		events, err := clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+		require.Nil(t, err)
+		return events.Items
+	}
+
+	// no condition nor event while the clocks are synchronized
+	assert.Nil(t, reportClockSkew(c, "mycluster", nil))
+	assert.Nil(t, getCondition())
+	assert.Equal(t, 0, len(getEvents()))
+
+	// the condition names the node of the skewed mon
+	skews := []mon.ClockSkew{{Mon: "b", Node: "node2", Skew: "0.2"}}
+	assert.Nil(t, reportClockSkew(c, "mycluster", skews))
+	condition := getCondition()
+	require.NotNil(t, condition)
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, []string{"node2"}, condition.Nodes)
+	assert.Contains(t, condition.Message, "mon b on node node2 (skew 0.2s)")
+	events := getEvents()
+	require.Equal(t, 1, len(events))
+	assert.Equal(t, v1.EventTypeWarning, events[0].Type)
+	assert.Equal(t, "ClockSkew", events[0].Reason)
+
+	// the event is recorded once for the same nodes
+	assert.Nil(t, reportClockSkew(c, "mycluster", skews))
+	assert.Equal(t, 1, len(getEvents()))
+
+	// the condition is removed once the clocks are synchronized again
+	assert.Nil(t, reportClockSkew(c, "mycluster", nil))
+	assert.Nil(t, getCondition())
+	events = getEvents()
+	require.Equal(t, 2, len(events))
+	reasons := []string{events[0].Reason, events[1].Reason}
+	assert.Contains(t, reasons, "ClockSynchronized")
+}
@@ -23,14 +23,17 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookv1alpha2 "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/exporter"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/notify"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
 	"github.com/rook/rook/pkg/operator/k8sutil"
@@ -48,22 +51,65 @@ var (
 	// supportedVersions are production-ready versions that rook supports
 	supportedVersions = []string{cephv1.Luminous, cephv1.Mimic}
 	// allVersions includes all supportedVersions as well as unreleased versions that are being tested with rook
-	allVersions = append(supportedVersions, cephv1.Nautilus)
+	allVersions = append(supportedVersions, cephv1.Nautilus, cephv1.Octopus, cephv1.Pacific)
 )
 
 type cluster struct {
 	context   *clusterd.Context
 	Namespace string
+	crdName   string
 	Spec      *cephv1.ClusterSpec
 	mons      *mon.Cluster
 	stopCh    chan struct{}
 	ownerRef  metav1.OwnerReference
+	// whether the ceph version is being upgraded, during which the down osds must not be marked out
+	upgrading bool
+	// whether the admin unset the noout set by the current upgrade, which is then not set again by its retries
+	upgradeNooutUnset bool
+	// the osds that were not up before the timeout after their update
+	stuckOSDs []int
+	// whether the cluster was created, after which the nodes added and removed are reconciled
+	initCompleted bool
+	// prevents the orchestration of the osds for a node event while the cluster is created or updated
+	orchestrationLock sync.Mutex
+	// whether a rolling restart of the daemons is in progress, set atomically
+	restarting int32
+	// serializes the updates of the owners of the osd flags
+	osdFlagsLock sync.Mutex
+	// posts the events of the cluster to its webhooks
+	notifier *notify.Notifier
+	// guards the replacement of the spec and the settings changed in place, read by the resync loop
+	specLock sync.RWMutex
+}
+
+// setSpec replaces the spec of the cluster after an update of the cluster CRD
+func (c *cluster) setSpec(spec *cephv1.ClusterSpec) {
+	c.specLock.Lock()
+	defer c.specLock.Unlock()
+	c.Spec = spec
+}
+
+// getSpec returns a copy of the spec of the cluster, for the closures and the background loops which run concurrently
+// with the updates of the cluster CRD
+func (c *cluster) getSpec() *cephv1.ClusterSpec {
+	c.specLock.RLock()
+	defer c.specLock.RUnlock()
+	return c.Spec.DeepCopy()
+}
+
+// resyncSettings returns the interval of the resyncs of the cluster, and whether the cluster is in maintenance mode
+func (c *cluster) resyncSettings() (int, bool) {
+	c.specLock.RLock()
+	defer c.specLock.RUnlock()
+	return c.Spec.ReconcileInterval, c.Spec.MaintenanceMode
 }
 
 func newCluster(c *cephv1.CephCluster, context *clusterd.Context) *cluster {
-	return &cluster{Namespace: c.Namespace, Spec: &c.Spec, context: context,
+	cluster := &cluster{Namespace: c.Namespace, crdName: c.Name, Spec: &c.Spec, context: context,
 		stopCh:   make(chan struct{}),
 		ownerRef: ClusterOwnerRef(c.Namespace, string(c.UID))}
+	cluster.notifier = notify.New(context, c.Namespace, c.Name, cluster.getSpec)
+	return cluster
 }
 
 func (c *cluster) detectCephMajorVersion(image string, timeout time.Duration) (string, error) {
@@ -123,6 +169,8 @@ func (c *cluster) detectCephMajorVersion(image string, timeout time.Duration) (s
 }
 
 func (c *cluster) createInstance(rookImage string) error {
+	c.orchestrationLock.Lock()
+	defer c.orchestrationLock.Unlock()
 
 	// Create a configmap for overriding ceph config settings
 	// These settings should only be modified by a user after they are initialized
@@ -142,9 +190,36 @@ func (c *cluster) createInstance(rookImage string) error {
 		return fmt.Errorf("failed to create override configmap %s. %+v", c.Namespace, err)
 	}
 
+	// prevent the osds restarted with the new version from being marked out and rebalanced. the flag stays set when
+	// the upgrade fails, since the osds of the failed step may be down, and is released once the osds are updated by
+	// a retry, an orchestration after a restart of the operator or a rollback of the image. a noout unset by the
+	// admin during the upgrade is not set again.
+	if c.upgrading && !c.upgradeNooutUnset {
+		unset, err := c.osdFlagUnsetByAdmin("noout", upgradeFlagOwner)
+		if err != nil {
+			logger.Warningf("failed to check if noout was unset during the upgrade. %+v", err)
+		}
+		if unset {
+			logger.Infof("noout was unset by the admin during the upgrade of cluster %s", c.Namespace)
+			c.upgradeNooutUnset = true
+			if err := c.releaseOSDFlag("noout", upgradeFlagOwner); err != nil {
+				logger.Warningf("failed to release noout after it was unset. %+v", err)
+			}
+		} else {
+			logger.Infof("setting noout during the upgrade of cluster %s", c.Namespace)
+			if err := c.acquireOSDFlag("noout", upgradeFlagOwner); err != nil {
+				logger.Warningf("failed to set noout before the upgrade. %+v", err)
+			}
+		}
+	}
+
 	// Start the mon pods
+	if err := c.checkUpgrade("mons"); err != nil {
+		return err
+	}
 	c.mons = mon.New(c.context, c.Namespace, c.Spec.DataDirHostPath, rookImage, c.Spec.CephVersion, c.Spec.Mon, cephv1.GetMonPlacement(c.Spec.Placement),
-		c.Spec.Network.HostNetwork, cephv1.GetMonResources(c.Spec.Resources), c.ownerRef)
+		c.Spec.Network.HostNetwork, cephv1.GetMonResources(c.Spec.Resources), cephv1.GetMonLivenessProbe(c.Spec.HealthCheck), c.ownerRef)
+	c.mons.PodExtension = cephv1.GetMonPodExtension(c.Spec.PodExtensions)
 	err = c.mons.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start the mons. %+v", err)
@@ -155,33 +230,85 @@ func (c *cluster) createInstance(rookImage string) error {
 		return fmt.Errorf("failed to create initial crushmap: %+v", err)
 	}
 
-	mgrs := mgr.New(c.context, c.Namespace, rookImage, c.Spec.CephVersion, cephv1.GetMgrPlacement(c.Spec.Placement),
-		c.Spec.Network.HostNetwork, c.Spec.Dashboard, cephv1.GetMgrResources(c.Spec.Resources), c.ownerRef)
+	mgrs := mgr.New(c.context, c.Namespace, c.crdName, rookImage, c.Spec.CephVersion, cephv1.GetMgrPlacement(c.Spec.Placement),
+		c.Spec.Network.HostNetwork, c.Spec.Dashboard, cephv1.GetMgrResources(c.Spec.Resources),
+		cephv1.GetMgrLivenessProbe(c.Spec.HealthCheck), c.ownerRef)
+	mgrs.Telemetry = c.Spec.Telemetry
+	mgrs.PodExtension = cephv1.GetMgrPodExtension(c.Spec.PodExtensions)
+	if err := c.checkUpgrade("mgrs"); err != nil {
+		return err
+	}
 	err = mgrs.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start the ceph mgr. %+v", err)
 	}
 
 	// Start the OSDs
-	osds := osd.New(c.context, c.Namespace, rookImage, c.Spec.CephVersion, c.Spec.Storage, c.Spec.DataDirHostPath,
-		cephv1.GetOSDPlacement(c.Spec.Placement), c.Spec.Network.HostNetwork, cephv1.GetOSDResources(c.Spec.Resources), c.ownerRef)
+	osds := c.newOSDs(rookImage)
+	if err := c.checkUpgrade("osds"); err != nil {
+		return err
+	}
 	err = osds.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start the osds. %+v", err)
 	}
+	c.stuckOSDs = osds.StuckOSDs
+
+	if err := c.releaseOSDFlag("noout", upgradeFlagOwner); err != nil {
+		return fmt.Errorf("failed to unset noout after the upgrade. %+v", err)
+	}
+	if c.upgrading {
+		c.upgrading = false
+		c.notifier.Notify(notify.EventUpgrade, notify.SeverityInfo,
+			fmt.Sprintf("the mons, mgrs and osds were upgraded to ceph %s (%s)", c.Spec.CephVersion.Name, c.Spec.CephVersion.Image))
+	}
 
 	// Start the rbd mirroring daemon(s)
 	rbdmirror := rbd.New(c.context, c.Namespace, rookImage, c.Spec.CephVersion, cephv1.GetRBDMirrorPlacement(c.Spec.Placement),
 		c.Spec.Network.HostNetwork, c.Spec.RBDMirroring, cephv1.GetRBDMirrorResources(c.Spec.Resources), c.ownerRef)
+	rbdmirror.PodExtension = cephv1.GetRBDMirrorPodExtension(c.Spec.PodExtensions)
 	err = rbdmirror.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start the rbd mirrors. %+v", err)
 	}
 
+	// Start the exporters of the perf counters on the nodes
+	exporters := exporter.New(c.context, c.Namespace, rookImage, c.Spec.DataDirHostPath, cephv1.GetExporterPlacement(c.Spec.Placement),
+		c.Spec.Network.HostNetwork, c.Spec.Exporter, cephv1.GetExporterResources(c.Spec.Resources), c.ownerRef)
+	exporters.PodExtension = cephv1.GetExporterPodExtension(c.Spec.PodExtensions)
+	err = exporters.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start the exporters. %+v", err)
+	}
+
+	c.initCompleted = true
 	logger.Infof("Done creating rook instance in namespace %s", c.Namespace)
 	return nil
 }
 
+func (c *cluster) newOSDs(rookImage string) *osd.Cluster {
+	osds := osd.New(c.context, c.Namespace, rookImage, c.Spec.CephVersion, c.Spec.Storage, c.Spec.DataDirHostPath,
+		cephv1.GetOSDPlacement(c.Spec.Placement), c.Spec.Network.HostNetwork, cephv1.GetOSDResources(c.Spec.Resources),
+		cephv1.GetOSDLivenessProbe(c.Spec.HealthCheck), time.Duration(c.Spec.WaitTimeoutForHealthyOSD)*time.Second,
+		c.Spec.NodeExclusion, c.ownerRef)
+	osds.PodExtension = cephv1.GetOSDPodExtension(c.Spec.PodExtensions)
+	return osds
+}
+
+// reconcileOSDs orchestrates the osds of the cluster, for example to provision a new node or remove a deleted node
+func (c *cluster) reconcileOSDs(rookImage string) error {
+	c.orchestrationLock.Lock()
+	defer c.orchestrationLock.Unlock()
+
+	osds := c.newOSDs(rookImage)
+	if err := osds.Start(); err != nil {
+		return fmt.Errorf("failed to start the osds. %+v", err)
+	}
+	c.stuckOSDs = osds.StuckOSDs
+	logger.Infof("reconciled the osds of cluster %s", c.Namespace)
+	return nil
+}
+
 func (c *cluster) createInitialCrushMap() error {
 	configMapExists := false
 	createCrushMap := false
@@ -284,6 +411,21 @@ func clusterChanged(oldCluster, newCluster cephv1.ClusterSpec, clusterRef *clust
 		changeFound = true
 	}
 
+	if !reflect.DeepEqual(oldCluster.Dashboard.Ingress, newCluster.Dashboard.Ingress) {
+		logger.Infof("dashboard ingress has changed from %+v to %+v", oldCluster.Dashboard.Ingress, newCluster.Dashboard.Ingress)
+		changeFound = true
+	}
+
+	if !reflect.DeepEqual(oldCluster.Telemetry, newCluster.Telemetry) {
+		logger.Infof("telemetry has changed from %+v to %+v", oldCluster.Telemetry, newCluster.Telemetry)
+		changeFound = true
+	}
+
+	if !reflect.DeepEqual(oldCluster.PodExtensions, newCluster.PodExtensions) {
+		logger.Infof("pod extensions have changed from %+v to %+v", oldCluster.PodExtensions, newCluster.PodExtensions)
+		changeFound = true
+	}
+
 	if oldCluster.Mon.Count != newCluster.Mon.Count {
 		logger.Infof("number of mons have changed from %d to %d. The health check will update the mons...", oldCluster.Mon.Count, newCluster.Mon.Count)
 		clusterRef.mons.MonCountMutex.Lock()
@@ -298,11 +440,61 @@ func clusterChanged(oldCluster, newCluster cephv1.ClusterSpec, clusterRef *clust
 		clusterRef.mons.MonCountMutex.Unlock()
 	}
 
+	if oldCluster.Mon.ZoneLabel != newCluster.Mon.ZoneLabel || oldCluster.Mon.StrictZoneSpread != newCluster.Mon.StrictZoneSpread {
+		logger.Infof("mon zone spread changed from %s/%t to %s/%t. The new mons will use it...", oldCluster.Mon.ZoneLabel, oldCluster.Mon.StrictZoneSpread,
+			newCluster.Mon.ZoneLabel, newCluster.Mon.StrictZoneSpread)
+		clusterRef.mons.MonCountMutex.Lock()
+		clusterRef.mons.ZoneLabel = newCluster.Mon.ZoneLabel
+		clusterRef.mons.StrictZoneSpread = newCluster.Mon.StrictZoneSpread
+		clusterRef.mons.MonCountMutex.Unlock()
+	}
+
+	if oldCluster.ReconcileInterval != newCluster.ReconcileInterval {
+		logger.Infof("reconcile interval changed from %d to %d. The next resync will use it...", oldCluster.ReconcileInterval, newCluster.ReconcileInterval)
+		clusterRef.specLock.Lock()
+		clusterRef.Spec.ReconcileInterval = newCluster.ReconcileInterval
+		clusterRef.specLock.Unlock()
+	}
+
+	if oldCluster.RemoveOSDsIfOutAndSafeToRemove != newCluster.RemoveOSDsIfOutAndSafeToRemove ||
+		oldCluster.RemoveOSDsAfterSeconds != newCluster.RemoveOSDsAfterSeconds {
+		logger.Infof("removal of the out osds changed from %t/%d to %t/%d. The osd monitor will use it...", oldCluster.RemoveOSDsIfOutAndSafeToRemove,
+			oldCluster.RemoveOSDsAfterSeconds, newCluster.RemoveOSDsIfOutAndSafeToRemove, newCluster.RemoveOSDsAfterSeconds)
+		clusterRef.specLock.Lock()
+		clusterRef.Spec.RemoveOSDsIfOutAndSafeToRemove = newCluster.RemoveOSDsIfOutAndSafeToRemove
+		clusterRef.Spec.RemoveOSDsAfterSeconds = newCluster.RemoveOSDsAfterSeconds
+		clusterRef.specLock.Unlock()
+	}
+
+	if !reflect.DeepEqual(oldCluster.PGAutoRepair, newCluster.PGAutoRepair) {
+		logger.Infof("pg auto repair changed from %+v to %+v. The next check will use it...", oldCluster.PGAutoRepair, newCluster.PGAutoRepair)
+		clusterRef.specLock.Lock()
+		clusterRef.Spec.PGAutoRepair = newCluster.PGAutoRepair
+		clusterRef.specLock.Unlock()
+	}
+
+	if oldCluster.Mon.DownOutInterval != newCluster.Mon.DownOutInterval ||
+		oldCluster.Mon.DownOutSubtreeLimit != newCluster.Mon.DownOutSubtreeLimit {
+		logger.Infof("mon down out settings changed from %d/%s to %d/%s", oldCluster.Mon.DownOutInterval, oldCluster.Mon.DownOutSubtreeLimit,
+			newCluster.Mon.DownOutInterval, newCluster.Mon.DownOutSubtreeLimit)
+		changeFound = true
+	}
+
 	if oldCluster.RBDMirroring.Workers != newCluster.RBDMirroring.Workers {
 		logger.Infof("rbd mirrors changed from %d to %d", oldCluster.RBDMirroring.Workers, newCluster.RBDMirroring.Workers)
 		changeFound = true
 	}
 
+	if !reflect.DeepEqual(oldCluster.Exporter, newCluster.Exporter) {
+		logger.Infof("exporter changed from %+v to %+v", oldCluster.Exporter, newCluster.Exporter)
+		changeFound = true
+	}
+
+	if oldCluster.IsPrivileged() != newCluster.IsPrivileged() {
+		logger.Infof("privileged has changed from %t to %t", oldCluster.IsPrivileged(), newCluster.IsPrivileged())
+		changeFound = true
+	}
+
 	if oldCluster.CephVersion.AllowUnsupported != newCluster.CephVersion.AllowUnsupported {
 		logger.Infof("ceph version allowUnsupported has changed from %t to %t", oldCluster.CephVersion.AllowUnsupported, newCluster.CephVersion.AllowUnsupported)
 		changeFound = true
@@ -0,0 +1,47 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractCephVersion(t *testing.T) {
+	versions := map[string]string{
+		"ceph version 12.2.8 (ae699615bac534ea496ee965ac6192cb7e0e07c0) luminous (stable)":          cephv1.Luminous,
+		"ceph version 13.2.2 (02899bfda814146b021136e9d8e80eba494e1126) mimic (stable)":             cephv1.Mimic,
+		"ceph version 14.2.0 (3a54b2b6d167d4a2a19e003a705696d4fe619afc) nautilus (stable)":          cephv1.Nautilus,
+		"ceph version 15.2.4 (7447c15c6ff58d7fce91843b705a268a1917325c) octopus (stable)":           cephv1.Octopus,
+		"ceph version 16.2.0 (0c2054e95bcd9b30fdd908a79ac1d8bbc3394442) pacific (stable)":           cephv1.Pacific,
+		"ceph version 14.1.0-559-gf1a72cf (f1a72cff10e04ddd8ce6e2ce1d4d0bc6d2ec8e54) nautilus (rc)": cephv1.Nautilus,
+	}
+	for output, expected := range versions {
+		version, err := extractCephVersion(output)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, version)
+	}
+
+	_, err := extractCephVersion("ceph version 10.2.11 (e4b061b47f07f583c92a050d9e84b1813a35671e) jewel (stable)")
+	assert.NotNil(t, err)
+
+	// octopus and pacific are detected but not supported without allowUnsupported
+	assert.False(t, versionSupported(cephv1.Octopus))
+	assert.False(t, versionSupported(cephv1.Pacific))
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/coreos/pkg/capnslog"
@@ -30,12 +31,22 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
 
+	"github.com/rook/rook/pkg/operator/ceph/backup"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/notify"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/crushrule"
 	"github.com/rook/rook/pkg/operator/ceph/file"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	"github.com/rook/rook/pkg/operator/ceph/mirroring"
 	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/ceph/object/bucket"
+	"github.com/rook/rook/pkg/operator/ceph/object/cosi"
 	"github.com/rook/rook/pkg/operator/ceph/object/user"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
+	"github.com/rook/rook/pkg/operator/ceph/smb"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
 	"github.com/rook/rook/pkg/operator/discover"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
@@ -91,7 +102,9 @@ type ClusterController struct {
 	volumeAttachment attachment.Attachment
 	devicesInUse     bool
 	rookImage        string
-	clusterMap       map[string]*cluster
+	// the clusters are added and removed by the cluster watcher while the node watcher reads them
+	clusterMap     map[string]*cluster
+	clusterMapLock sync.Mutex
 }
 
 // NewClusterController create controller for watching cluster custom resources created
@@ -119,16 +132,49 @@ func (c *ClusterController) StartWatch(namespace string, stopCh chan struct{}) e
 	// watch for events on all legacy types too
 	c.watchLegacyClusters(namespace, stopCh, resourceHandlerFuncs)
 
+	// reconcile the osds of the clusters using all nodes when nodes are added or removed
+	c.startNodeWatcher(stopCh)
+
 	return nil
 }
 
 func (c *ClusterController) StopWatch() {
+	c.clusterMapLock.Lock()
+	defer c.clusterMapLock.Unlock()
 	for _, cluster := range c.clusterMap {
 		close(cluster.stopCh)
 	}
 	c.clusterMap = make(map[string]*cluster)
 }
 
+// getCluster returns the cluster of the namespace
+func (c *ClusterController) getCluster(namespace string) (*cluster, bool) {
+	c.clusterMapLock.Lock()
+	defer c.clusterMapLock.Unlock()
+	cluster, ok := c.clusterMap[namespace]
+	return cluster, ok
+}
+
+// StartZapJob launches the job wiping a device previously used by an osd on a node of the cluster of the namespace
+func (c *ClusterController) StartZapJob(namespace, nodeName, device string, dryRun bool) error {
+	cluster, ok := c.getCluster(namespace)
+	if !ok {
+		return fmt.Errorf("cluster %s is not orchestrated by the operator", namespace)
+	}
+	return cluster.newOSDs(c.rookImage).StartZapJob(nodeName, device, dryRun)
+}
+
+// clusters returns a snapshot of the clusters, which can be iterated while clusters are added or removed
+func (c *ClusterController) clusters() []*cluster {
+	c.clusterMapLock.Lock()
+	defer c.clusterMapLock.Unlock()
+	clusters := make([]*cluster, 0, len(c.clusterMap))
+	for _, cluster := range c.clusterMap {
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
 // ************************************************************************************************
 // Add event functions
 // ************************************************************************************************
@@ -151,7 +197,15 @@ func (c *ClusterController) onAdd(obj interface{}) {
 	}
 
 	cluster := newCluster(clusterObj, c.context)
+	c.clusterMapLock.Lock()
 	c.clusterMap[cluster.Namespace] = cluster
+	c.clusterMapLock.Unlock()
+
+	if cluster.Spec.MaintenanceMode {
+		// the cluster is started once maintenance mode is disabled
+		c.enterMaintenance(cluster, clusterObj.Name)
+		return
+	}
 
 	logger.Infof("starting cluster in namespace %s", cluster.Namespace)
 
@@ -164,6 +218,15 @@ func (c *ClusterController) onAdd(obj interface{}) {
 		return
 	}
 
+	if err := validatePrivileged(cluster.Spec); err != nil {
+		message := err.Error()
+		logger.Error(message)
+		if err := c.updateClusterStatus(clusterObj.Namespace, clusterObj.Name, cephv1.ClusterStateError, message); err != nil {
+			logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
+		}
+		return
+	}
+
 	if cluster.Spec.Storage.AnyUseAllDevices() {
 		c.devicesInUse = true
 	}
@@ -208,7 +271,7 @@ func (c *ClusterController) onAdd(obj interface{}) {
 		}
 
 		// cluster is created, update the cluster CRD status now
-		if err := c.updateClusterStatus(clusterObj.Namespace, clusterObj.Name, cephv1.ClusterStateCreated, ""); err != nil {
+		if err := c.setClusterStatus(clusterObj.Namespace, clusterObj.Name, createdClusterStatus(cluster)); err != nil {
 			logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
 			return false, nil
 		}
@@ -224,10 +287,21 @@ func (c *ClusterController) onAdd(obj interface{}) {
 		return
 	}
 
+	// Block the clients of the nodes annotated before the cluster was initialized
+	c.syncNodeBlocklists(cluster)
+
 	// Start pool CRD watcher
-	poolController := pool.NewPoolController(c.context)
+	poolController := pool.NewPoolController(c.context, cluster.Spec.CephVersion)
 	poolController.StartWatch(cluster.Namespace, cluster.stopCh)
 
+	// Start rados namespace CRD watcher
+	radosNamespaceController := pool.NewRadosNamespaceController(c.context, cluster.Spec.CephVersion, cluster.ownerRef)
+	radosNamespaceController.StartWatch(cluster.Namespace, cluster.stopCh)
+
+	// Start crush rule CRD watcher
+	crushRuleController := crushrule.NewCrushRuleController(c.context)
+	crushRuleController.StartWatch(cluster.Namespace, cluster.stopCh)
+
 	// Start object store CRD watcher
 	objectStoreController := object.NewObjectStoreController(c.context, c.rookImage, cluster.Spec.CephVersion, cluster.Spec.Network.HostNetwork, cluster.ownerRef)
 	objectStoreController.StartWatch(cluster.Namespace, cluster.stopCh)
@@ -236,18 +310,68 @@ func (c *ClusterController) onAdd(obj interface{}) {
 	objectStoreUserController := objectuser.NewObjectStoreUserController(c.context, cluster.ownerRef)
 	objectStoreUserController.StartWatch(cluster.Namespace, cluster.stopCh)
 
+	// Start object bucket CRD watcher
+	objectBucketController := objectbucket.NewObjectBucketController(c.context)
+	objectBucketController.StartWatch(cluster.Namespace, cluster.stopCh)
+
+	// Start COSI driver CRD watcher
+	cosiDriverController := cosi.NewCOSIDriverController(c.context, cluster.ownerRef)
+	cosiDriverController.StartWatch(cluster.Namespace, cluster.stopCh)
+
 	// Start file system CRD watcher
 	fileController := file.NewFilesystemController(c.context, c.rookImage, cluster.Spec.CephVersion, cluster.Spec.Network.HostNetwork, cluster.ownerRef)
 	fileController.StartWatch(cluster.Namespace, cluster.stopCh)
 
+	// Start metadata backup CRD watcher
+	metadataBackupController := backup.NewMetadataBackupController(c.context, c.rookImage, cluster.ownerRef)
+	metadataBackupController.StartWatch(cluster.Namespace, cluster.stopCh)
+
+	// Start samba gateway CRD watcher
+	smbGatewayController := smb.NewSMBGatewayController(c.context, c.rookImage, cluster.ownerRef)
+	smbGatewayController.StartWatch(cluster.Namespace, cluster.stopCh)
+
+	// Start mirroring status CRD watcher
+	mirroringStatusController := mirroring.NewMirroringStatusController(c.context, cluster.Spec.CephVersion)
+	mirroringStatusController.StartWatch(cluster.Namespace, cluster.stopCh)
+
 	// Start mon health checker
 	healthChecker := mon.NewHealthChecker(cluster.mons)
+	healthChecker.Paused = func() bool { return cluster.getSpec().MaintenanceMode }
+	healthChecker.ClockSkewed = func(skews []mon.ClockSkew) {
+		if err := reportClockSkew(cluster, clusterObj.Name, skews); err != nil {
+			logger.Warningf("failed to report the clock skew of cluster %s. %+v", cluster.Namespace, err)
+		}
+	}
 	go healthChecker.Check(cluster.stopCh)
 
 	// Start the osd health checker
-	osdChecker := osd.NewMonitor(c.context, cluster.Namespace)
+	osdChecker := osd.NewMonitor(c.context, cluster.Namespace, cluster.getSpec)
 	go osdChecker.Start(cluster.stopCh)
 
+	// Start the telemetry checker, which opts in again after the content of the reports changed
+	telemetryChecker := mgr.NewTelemetryChecker(c.context, cluster.Namespace, cluster.getSpec)
+	go telemetryChecker.Check(cluster.stopCh)
+
+	// Start the notifications of the health and the osds of the cluster to its webhooks
+	notifyMonitor := notify.NewMonitor(cluster.notifier)
+	go notifyMonitor.Start(cluster.stopCh)
+
+	// Start the usage collector of the cluster, pools and object store users
+	usageCollector := newUsageCollector(c.context, cluster.Namespace, clusterObj.Name)
+	go usageCollector.Start(cluster.stopCh)
+
+	// Start the periodic resync of the cluster, which repairs the drift of its resources
+	go c.resyncCluster(cluster, clusterObj.Name)
+
+	// Resume the rolling restart requested while the operator was stopped
+	c.checkRestart(clusterObj, cluster)
+
+	// Start the automatic repair of the inconsistent pgs
+	go newPGRepairer(cluster, clusterObj.Name).Start()
+
+	// Start the periodic deletion of the resources of the removed nodes and daemons
+	go newCleaner(cluster, clusterObj.Name).Start()
+
 	// add the finalizer to the crd
 	err = c.addFinalizer(clusterObj)
 	if err != nil {
@@ -305,13 +429,25 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 		c.removeFinalizer(newClust)
 		return
 	}
-	cluster, ok := c.clusterMap[newClust.Namespace]
+	cluster, ok := c.getCluster(newClust.Namespace)
 	if !ok {
 		logger.Errorf("Cannot update cluster %s that does not exist", newClust.Namespace)
 		return
 	}
 
-	if !clusterChanged(oldClust.Spec, newClust.Spec, cluster) {
+	skip, orchestrate := c.checkMaintenance(oldClust, newClust, cluster)
+	if skip {
+		return
+	}
+
+	c.checkRestart(newClust, cluster)
+
+	if reconcileRequested(oldClust, newClust) {
+		logger.Infof("reconcile of cluster %s requested", newClust.Namespace)
+		orchestrate = true
+	}
+
+	if !clusterChanged(oldClust.Spec, newClust.Spec, cluster) && !orchestrate {
 		logger.Infof("update event for cluster %s is not supported", newClust.Namespace)
 		return
 	}
@@ -327,6 +463,8 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 			return
 		}
 		newClust.Spec.CephVersion.Name = version
+		cluster.upgrading = true
+		cluster.upgradeNooutUnset = false
 	} else {
 		logger.Infof("ceph version is still %s on image %s", cluster.Spec.CephVersion.Name, cluster.Spec.CephVersion.Image)
 		newClust.Spec.CephVersion.Name = cluster.Spec.CephVersion.Name
@@ -335,7 +473,15 @@ func (c *ClusterController) onUpdate(oldObj, newObj interface{}) {
 	logger.Debugf("old cluster: %+v", oldClust.Spec)
 	logger.Debugf("new cluster: %+v", newClust.Spec)
 
-	cluster.Spec = &newClust.Spec
+	if err := validatePrivileged(newClust.Spec); err != nil {
+		logger.Errorf("failed to update cluster %s. %+v", newClust.Namespace, err)
+		if err := c.updateClusterStatus(newClust.Namespace, newClust.Name, cephv1.ClusterStateError, err.Error()); err != nil {
+			logger.Errorf("failed to update cluster status in namespace %s: %+v", newClust.Namespace, err)
+		}
+		return
+	}
+
+	cluster.setSpec(&newClust.Spec)
 
 	// attempt to update the cluster.  note this is done outside of wait.Poll because that function
 	// will wait for the retry interval before trying for the first time.
@@ -368,7 +514,7 @@ func (c *ClusterController) handleUpdate(crdName string, cluster *cluster) (bool
 		return false, nil
 	}
 
-	if err := c.updateClusterStatus(cluster.Namespace, crdName, cephv1.ClusterStateCreated, ""); err != nil {
+	if err := c.setClusterStatus(cluster.Namespace, crdName, createdClusterStatus(cluster)); err != nil {
 		logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
 		return false, nil
 	}
@@ -400,10 +546,14 @@ func (c *ClusterController) onDelete(obj interface{}) {
 	if err != nil {
 		logger.Errorf("failed to delete cluster. %+v", err)
 	}
+	c.clusterMapLock.Lock()
 	if cluster, ok := c.clusterMap[clust.Namespace]; ok {
 		close(cluster.stopCh)
 		delete(c.clusterMap, clust.Namespace)
 	}
+	c.clusterMapLock.Unlock()
+	// the controllers of the resources of a cluster deleted in maintenance mode handle their deletion
+	maintenance.Resume(clust.Namespace)
 	if clust.Spec.Storage.AnyUseAllDevices() {
 		c.devicesInUse = false
 	}
@@ -554,14 +704,28 @@ func (c *ClusterController) removeFinalizer(obj interface{}) {
 }
 
 func (c *ClusterController) updateClusterStatus(namespace, name string, state cephv1.ClusterState, message string) error {
+	return c.setClusterStatus(namespace, name, cephv1.ClusterStatus{State: state, Message: message})
+}
+
+func (c *ClusterController) setClusterStatus(namespace, name string, status cephv1.ClusterStatus) error {
 	// get the most recent cluster CRD object
 	cluster, err := c.context.RookClientset.CephV1().CephClusters(namespace).Get(name, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to get cluster from namespace %s prior to updating its status: %+v", namespace, err)
 	}
 
-	// update the status on the retrieved cluster object
-	cluster.Status = cephv1.ClusterStatus{State: state, Message: message}
+	// update the status on the retrieved cluster object, keeping the usage updated by the usage collector, the
+	// conditions updated by the health checks, the osd flags held by the operations and the spec saved by the
+	// maintenance mode
+	usage := cluster.Status.Usage
+	conditions := cluster.Status.Conditions
+	osdFlags := cluster.Status.OSDFlags
+	specBeforeMaintenance := cluster.Status.SpecBeforeMaintenance
+	cluster.Status = status
+	cluster.Status.Usage = usage
+	cluster.Status.Conditions = conditions
+	cluster.Status.OSDFlags = osdFlags
+	cluster.Status.SpecBeforeMaintenance = specBeforeMaintenance
 	if _, err := c.context.RookClientset.CephV1().CephClusters(cluster.Namespace).Update(cluster); err != nil {
 		return fmt.Errorf("failed to update cluster %s status: %+v", cluster.Namespace, err)
 	}
@@ -569,6 +733,16 @@ func (c *ClusterController) updateClusterStatus(namespace, name string, state ce
 	return nil
 }
 
+// createdClusterStatus returns the status of a created cluster, which reports the osds that were not up after their update
+func createdClusterStatus(cluster *cluster) cephv1.ClusterStatus {
+	status := cephv1.ClusterStatus{State: cephv1.ClusterStateCreated}
+	if len(cluster.stuckOSDs) > 0 {
+		status.Message = fmt.Sprintf("osds %v were not up after their update", cluster.stuckOSDs)
+		status.StuckOSDs = cluster.stuckOSDs
+	}
+	return status
+}
+
 func ClusterOwnerRef(namespace, clusterID string) metav1.OwnerReference {
 	blockOwner := true
 	return metav1.OwnerReference{
@@ -579,3 +753,20 @@ func ClusterOwnerRef(namespace, clusterID string) metav1.OwnerReference {
 		BlockOwnerDeletion: &blockOwner,
 	}
 }
+
+// validatePrivileged rejects the clusters that are not privileged but need privileged pods: the host network, and the
+// dataDirHostPath of the mons on the hosts requiring privileged containers to write to it. On SELinux hosts, the
+// dataDirHostPath must be labeled for the containers instead (container_file_t) so the mons write to it unprivileged.
+func validatePrivileged(spec cephv1.ClusterSpec) error {
+	if spec.IsPrivileged() {
+		return nil
+	}
+	if spec.Network.HostNetwork {
+		return fmt.Errorf("the host network requires privileged to be true")
+	}
+	if spec.DataDirHostPath != "" && opspec.HostPathRequiresPrivileged() {
+		return fmt.Errorf("the hosts require privileged containers to write to dataDirHostPath %s (ROOK_HOSTPATH_REQUIRES_PRIVILEGED). "+
+			"set privileged to true, or label the path for the containers and unset ROOK_HOSTPATH_REQUIRES_PRIVILEGED", spec.DataDirHostPath)
+	}
+	return nil
+}
@@ -33,6 +33,7 @@ import (
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -157,6 +158,34 @@ func TestClusterChanged(t *testing.T) {
 	assert.False(t, clusterChanged(old, new, c))
 	assert.Equal(t, 0, c.Spec.Mon.Count)
 
+	// the reconcile interval is applied without orchestrating the cluster
+	new.ReconcileInterval = 3600
+	assert.False(t, clusterChanged(old, new, c))
+	assert.Equal(t, 3600, c.Spec.ReconcileInterval)
+	new.ReconcileInterval = 0
+
+	// the removal of the out osds is applied by the osd monitor without orchestrating the cluster
+	new.RemoveOSDsIfOutAndSafeToRemove = true
+	assert.False(t, clusterChanged(old, new, c))
+	assert.True(t, c.Spec.RemoveOSDsIfOutAndSafeToRemove)
+	new.RemoveOSDsIfOutAndSafeToRemove = false
+
+	// opting in to the telemetry is a change
+	new.Telemetry.Enabled = true
+	assert.True(t, clusterChanged(old, new, c))
+	new.Telemetry.Enabled = false
+
+	// a sidecar added to the osds is a change
+	new.PodExtensions = rookalpha.PodExtensionSpec{"osd": {Sidecars: []v1.Container{{Name: "backup"}}}}
+	assert.True(t, clusterChanged(old, new, c))
+	new.PodExtensions = nil
+
+	// disabling the privileged pods is a change
+	privileged := false
+	new.Privileged = &privileged
+	assert.True(t, clusterChanged(old, new, c))
+	new.Privileged = nil
+
 	// If the number of mons changes, the mon count on the cluster should be updated so the health check can adjust the mons
 	new.Mon.Count = 3
 	new.Mon.AllowMultiplePerNode = true
@@ -164,6 +193,14 @@ func TestClusterChanged(t *testing.T) {
 	assert.False(t, clusterChanged(old, new, c))
 	assert.Equal(t, 3, c.mons.Count)
 	assert.True(t, c.mons.AllowMultiplePerNode)
+
+	// the mon down out settings are applied when the mons are started again
+	old.Mon = new.Mon
+	new.Mon.DownOutInterval = 900
+	assert.True(t, clusterChanged(old, new, c))
+	old.Mon = new.Mon
+	new.Mon.DownOutSubtreeLimit = "rack"
+	assert.True(t, clusterChanged(old, new, c))
 }
 
 func TestRemoveFinalizer(t *testing.T) {
@@ -224,3 +261,59 @@ func TestRemoveFinalizer(t *testing.T) {
 	assert.NotNil(t, legacyRookCluster)
 	assert.Len(t, legacyRookCluster.Finalizers, 0)
 }
+
+func TestCreatedClusterStatus(t *testing.T) {
+	status := createdClusterStatus(&cluster{})
+	assert.Equal(t, cephv1.ClusterStateCreated, status.State)
+	assert.Equal(t, "", status.Message)
+	assert.Nil(t, status.StuckOSDs)
+
+	// the osds that were not up after their update are reported
+	status = createdClusterStatus(&cluster{stuckOSDs: []int{2, 5}})
+	assert.Equal(t, cephv1.ClusterStateCreated, status.State)
+	assert.Equal(t, "osds [2 5] were not up after their update", status.Message)
+	assert.Equal(t, []int{2, 5}, status.StuckOSDs)
+}
+
+func TestValidatePrivileged(t *testing.T) {
+	privileged := false
+	spec := cephv1.ClusterSpec{DataDirHostPath: "/var/lib/rook"}
+	assert.Nil(t, validatePrivileged(spec))
+	spec.Privileged = &privileged
+	assert.Nil(t, validatePrivileged(spec))
+
+	// the host network needs privileged pods
+	spec.Network.HostNetwork = true
+	assert.NotNil(t, validatePrivileged(spec))
+	spec.Network.HostNetwork = false
+
+	// the mons need privileged containers to write to the host path on the hosts requiring it
+	os.Setenv("ROOK_HOSTPATH_REQUIRES_PRIVILEGED", "true")
+	defer os.Unsetenv("ROOK_HOSTPATH_REQUIRES_PRIVILEGED")
+	assert.NotNil(t, validatePrivileged(spec))
+	spec.DataDirHostPath = ""
+	assert.Nil(t, validatePrivileged(spec))
+	spec.DataDirHostPath = "/var/lib/rook"
+	spec.Privileged = nil
+	assert.Nil(t, validatePrivileged(spec))
+}
+
+func TestStartZapJob(t *testing.T) {
+	context := &clusterd.Context{Clientset: testop.New(1), Executor: &exectest.MockExecutor{}}
+	clust := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "rook-ceph"},
+		Spec:       cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v13.2.2"}},
+	}
+	c := &ClusterController{context: context, rookImage: "rook/rook:myversion", clusterMap: map[string]*cluster{
+		"rook-ceph": newCluster(clust, context),
+	}}
+
+	// the zap job is started with the settings of the osds of the cluster
+	assert.Nil(t, c.StartZapJob("rook-ceph", "node0", "sdb", true))
+	job, err := context.Clientset.Batch().Jobs("rook-ceph").Get("rook-ceph-osd-zap-node0-sdb", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "ceph/ceph:v13.2.2", job.Spec.Template.Spec.Containers[1].Image)
+	assert.Contains(t, job.Spec.Template.Spec.Containers[1].Args, "--dry-run")
+
+	assert.NotNil(t, c.StartZapJob("other", "node0", "sdb", true))
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the reason of the events of the resources recreated or reverted by a resync
+	driftRepairedReason = "DriftRepaired"
+)
+
+var (
+	// how often the resync loop checks whether the resync was enabled when the reconcile interval is not set
+	resyncCheckInterval = time.Minute
+)
+
+// resourceStates maps the kind and name of the resources owned by a cluster to a hash of the content the orchestration
+// sets, or to an empty string for the secrets and configmaps whose content is the state of the cluster. The settings of
+// the central config database are also mapped to a hash of their value.
+type resourceStates map[string]string
+
+// resyncCluster periodically orchestrates the cluster again until the cluster is stopped. The orchestration recreates
+// the deleted resources and reverts the manual edits of the daemon deployments and of the ceph settings it applies, which
+// are reported as events.
+func (c *ClusterController) resyncCluster(cluster *cluster, crdName string) {
+	// the resources seen since the cluster was orchestrated, to tell the deleted resources from the resources of the new
+	// daemons
+	known, err := clusterStates(cluster)
+	if err != nil {
+		logger.Warningf("failed to list the resources of cluster %s. %+v", cluster.Namespace, err)
+	}
+	for {
+		interval := resyncCheckInterval
+		reconcileInterval, maintenanceMode := cluster.resyncSettings()
+		if reconcileInterval > 0 {
+			interval = time.Duration(reconcileInterval) * time.Second
+		}
+
+		select {
+		case <-cluster.stopCh:
+			logger.Infof("stopping the resync of cluster %s", cluster.Namespace)
+			return
+		case <-time.After(interval):
+			reconcileInterval, maintenanceMode = cluster.resyncSettings()
+			if reconcileInterval > 0 && !maintenanceMode {
+				after, err := c.resyncOnce(cluster, crdName, known)
+				if err != nil {
+					logger.Errorf("failed to resync cluster %s. %+v", cluster.Namespace, err)
+					continue
+				}
+				known = after
+			}
+		}
+	}
+}
+
+// resyncOnce orchestrates the cluster and reports the owned resources that were recreated or reverted. It returns the
+// resources owned by the cluster after the orchestration.
+func (c *ClusterController) resyncOnce(cluster *cluster, crdName string, known resourceStates) (resourceStates, error) {
+	logger.Infof("resyncing cluster %s", cluster.Namespace)
+	before, err := clusterStates(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if known == nil {
+		known = before
+	}
+
+	if err := cluster.createInstance(c.rookImage); err != nil {
+		return nil, err
+	}
+
+	after, err := clusterStates(cluster)
+	if err != nil {
+		return nil, err
+	}
+	for _, message := range driftMessages(known, before, after) {
+		logger.Warningf("cluster %s: %s", cluster.Namespace, message)
+		if err := recordClusterEvent(cluster, crdName, v1.EventTypeWarning, driftRepairedReason, message); err != nil {
+			logger.Warningf("failed to record drift event of cluster %s. %+v", cluster.Namespace, err)
+		}
+	}
+	return after, nil
+}
+
+// clusterStates returns the state of the resources owned by the cluster and of its ceph settings. The ceph settings are
+// skipped if they cannot be dumped, so that the resources are still compared while the mons are down.
+func clusterStates(cluster *cluster) (resourceStates, error) {
+	states, err := ownedResources(cluster)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := cephConfigStates(cluster)
+	if err != nil {
+		logger.Warningf("failed to dump the ceph settings of cluster %s. %+v", cluster.Namespace, err)
+		return states, nil
+	}
+	for key, state := range settings {
+		states[key] = state
+	}
+	return states, nil
+}
+
+// cephConfigStates returns the state of the settings of the central config database, which are changed with
+// `ceph config set`. Luminous has no central config database and its settings are not compared.
+func cephConfigStates(cluster *cluster) (resourceStates, error) {
+	states := resourceStates{}
+	cephVersionName := cluster.getSpec().CephVersion.Name
+	if !cephv1.VersionAtLeast(cephVersionName, cephv1.Mimic) {
+		return states, nil
+	}
+
+	options, err := client.ConfigDump(cluster.context, cluster.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		states[fmt.Sprintf("ceph config %s %s", option.Section, option.Name)] = k8sutil.Hash(option.Value)
+	}
+	return states, nil
+}
+
+// ownedResources returns the state of the deployments, daemonsets, services, secrets and configmaps owned by the
+// cluster. The state of the deployments and daemonsets is their pod template, and the state of the services is their
+// ports and selector, which the orchestration reverts. The resource versions are not compared since they change with
+// the status of the resources and with each update of the orchestration.
+func ownedResources(cluster *cluster) (resourceStates, error) {
+	states := resourceStates{}
+	add := func(kind string, meta metav1.ObjectMeta, content interface{}) {
+		for _, ref := range meta.OwnerReferences {
+			if ref.UID != cluster.ownerRef.UID {
+				continue
+			}
+			states[kind+" "+meta.Name] = ""
+			if content != nil {
+				serialized, err := json.Marshal(content)
+				if err != nil {
+					logger.Warningf("failed to serialize %s %s. %+v", kind, meta.Name, err)
+					return
+				}
+				states[kind+" "+meta.Name] = k8sutil.Hash(string(serialized))
+			}
+			return
+		}
+	}
+	opts := metav1.ListOptions{}
+
+	deployments, err := cluster.context.Clientset.Extensions().Deployments(cluster.Namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments. %+v", err)
+	}
+	for _, d := range deployments.Items {
+		add("deployment", d.ObjectMeta, []interface{}{d.Spec.Replicas, d.Spec.Template})
+	}
+	daemonsets, err := cluster.context.Clientset.Extensions().DaemonSets(cluster.Namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets. %+v", err)
+	}
+	for _, d := range daemonsets.Items {
+		add("daemonset", d.ObjectMeta, d.Spec.Template)
+	}
+	services, err := cluster.context.Clientset.CoreV1().Services(cluster.Namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services. %+v", err)
+	}
+	for _, s := range services.Items {
+		add("service", s.ObjectMeta, []interface{}{s.Spec.Ports, s.Spec.Selector, s.Spec.Type})
+	}
+	secrets, err := cluster.context.Clientset.CoreV1().Secrets(cluster.Namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets. %+v", err)
+	}
+	for _, s := range secrets.Items {
+		add("secret", s.ObjectMeta, nil)
+	}
+	configMaps, err := cluster.context.Clientset.CoreV1().ConfigMaps(cluster.Namespace).List(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps. %+v", err)
+	}
+	for _, cm := range configMaps.Items {
+		add("configmap", cm.ObjectMeta, nil)
+	}
+	return states, nil
+}
+
+// driftMessages describes the resources that were recreated or reverted by the orchestration. A resource missing before
+// the orchestration is only reported if it was known from a previous resync, since the resources of the new daemons
+// are also created by the orchestration.
+func driftMessages(known, before, after resourceStates) []string {
+	var messages []string
+	for key, state := range after {
+		previous, existed := before[key]
+		if !existed {
+			if _, ok := known[key]; ok {
+				messages = append(messages, fmt.Sprintf("%s was missing and has been recreated", key))
+			}
+		} else if previous != state {
+			messages = append(messages, fmt.Sprintf("%s had drifted and has been reverted", key))
+		}
+	}
+	sort.Strings(messages)
+	return messages
+}
+
+// recordClusterEvent creates an event for the cluster CRD
+func recordClusterEvent(cluster *cluster, crdName, eventType, reason, message string) error {
+	now := metav1.Now()
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", crdName, now.UnixNano()),
+			Namespace: cluster.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion: fmt.Sprintf("%s/%s", ClusterResource.Group, ClusterResource.Version),
+			Kind:       ClusterResource.Kind,
+			Name:       crdName,
+			Namespace:  cluster.Namespace,
+			UID:        cluster.ownerRef.UID,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: "rook-ceph-operator"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	_, err := cluster.context.Clientset.CoreV1().Events(cluster.Namespace).Create(event)
+	return err
+}
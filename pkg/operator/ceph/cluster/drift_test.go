@@ -0,0 +1,133 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestOwnedResources(t *testing.T) {
+	ownerRef := ClusterOwnerRef("ns", "cluster-uid")
+	owned := metav1.ObjectMeta{Name: "rook-ceph-mgr-a", Namespace: "ns", ResourceVersion: "10", OwnerReferences: []metav1.OwnerReference{ownerRef}}
+	deployment := &extensions.Deployment{ObjectMeta: owned}
+	deployment.Spec.Template.Spec.Containers = []v1.Container{{Name: "mgr", Image: "ceph/ceph:v13"}}
+	clientset := fake.NewSimpleClientset(
+		deployment,
+		&v1.Service{ObjectMeta: owned},
+		&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-config", Namespace: "ns", OwnerReferences: []metav1.OwnerReference{ownerRef}}},
+		&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "ns", ResourceVersion: "11"}},
+	)
+	c := &cluster{Namespace: "ns", context: &clusterd.Context{Clientset: clientset}, ownerRef: ownerRef}
+
+	// the resources not owned by the cluster are ignored
+	states, err := ownedResources(c)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(states))
+	assert.NotEqual(t, "", states["deployment rook-ceph-mgr-a"])
+	assert.NotEqual(t, "", states["service rook-ceph-mgr-a"])
+	assert.Equal(t, "", states["configmap rook-ceph-config"])
+
+	// a new resource version without change is not a drift
+	deployment.ResourceVersion = "12"
+	deployment.Status.ReadyReplicas = 1
+	_, err = clientset.Extensions().Deployments("ns").Update(deployment)
+	assert.Nil(t, err)
+	unchanged, err := ownedResources(c)
+	assert.Nil(t, err)
+	assert.Equal(t, states, unchanged)
+
+	// an edit of the pod template is a drift
+	deployment.Spec.Template.Spec.Containers[0].Image = "ceph/ceph:v12"
+	_, err = clientset.Extensions().Deployments("ns").Update(deployment)
+	assert.Nil(t, err)
+	edited, err := ownedResources(c)
+	assert.Nil(t, err)
+	assert.NotEqual(t, states["deployment rook-ceph-mgr-a"], edited["deployment rook-ceph-mgr-a"])
+}
+
+func TestClusterStates(t *testing.T) {
+	value := "true"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			if args[0] == "config" && args[1] == "dump" {
+				return `[{"section":"mon","name":"mon_allow_pool_delete","value":"` + value + `","level":"advanced"}]`, nil
+			}
+			return "", fmt.Errorf("unexpected ceph command %v", args)
+		},
+	}
+	c := &cluster{Namespace: "ns", context: &clusterd.Context{Clientset: fake.NewSimpleClientset(), Executor: executor},
+		ownerRef: ClusterOwnerRef("ns", "cluster-uid"), Spec: &cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Name: cephv1.Luminous}}}
+
+	// luminous has no central config database
+	states, err := clusterStates(c)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(states))
+
+	c.Spec.CephVersion.Name = cephv1.Mimic
+	states, err = clusterStates(c)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(states))
+	assert.NotEqual(t, "", states["ceph config mon mon_allow_pool_delete"])
+
+	// a setting changed with `ceph config set` is a drift
+	value = "false"
+	edited, err := clusterStates(c)
+	assert.Nil(t, err)
+	assert.NotEqual(t, states["ceph config mon mon_allow_pool_delete"], edited["ceph config mon mon_allow_pool_delete"])
+}
+
+func TestDriftMessages(t *testing.T) {
+	known := resourceStates{"deployment rook-ceph-mgr-a": "a", "service rook-ceph-mgr-a": "b", "secret rook-ceph-mon": "",
+		"service rook-ceph-mon-a": "c"}
+	before := resourceStates{"deployment rook-ceph-mgr-a": "a2", "service rook-ceph-mgr-a": "b", "secret rook-ceph-mon": ""}
+	after := resourceStates{"deployment rook-ceph-mgr-a": "a", "service rook-ceph-mgr-a": "b", "secret rook-ceph-mon": "",
+		"service rook-ceph-mon-a": "c", "deployment rook-ceph-osd-3": "d"}
+
+	// the resources of the new osd were not known before
+	assert.Equal(t, []string{
+		"deployment rook-ceph-mgr-a had drifted and has been reverted",
+		"service rook-ceph-mon-a was missing and has been recreated",
+	}, driftMessages(known, before, after))
+
+	assert.Nil(t, driftMessages(after, after, after))
+}
+
+func TestRecordClusterEvent(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	c := &cluster{Namespace: "ns", context: &clusterd.Context{Clientset: clientset}, ownerRef: ClusterOwnerRef("ns", "cluster-uid")}
+
+	err := recordClusterEvent(c, "mycluster", v1.EventTypeWarning, driftRepairedReason, "service rook-ceph-mon-a was missing and has been recreated")
+	assert.Nil(t, err)
+
+	events, err := clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Equal(t, "CephCluster", events.Items[0].InvolvedObject.Kind)
+	assert.Equal(t, "mycluster", events.Items[0].InvolvedObject.Name)
+	assert.Equal(t, "ceph.rook.io/v1", events.Items[0].InvolvedObject.APIVersion)
+	assert.Equal(t, "DriftRepaired", events.Items[0].Reason)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package exporter to run the exporter of the perf counters of the ceph daemons on each node
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/coreos/pkg/capnslog"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	exporterdaemon "github.com/rook/rook/pkg/daemon/ceph/exporter"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-exporter")
+
+const (
+	appName = "rook-ceph-exporter"
+)
+
+// Cluster represents the settings of the exporters of a cluster
+type Cluster struct {
+	context         *clusterd.Context
+	Namespace       string
+	rookVersion     string
+	dataDirHostPath string
+	placement       rookalpha.Placement
+	PodExtension    rookalpha.PodExtension
+	hostNetwork     bool
+	spec            cephv1.ExporterSpec
+	resources       v1.ResourceRequirements
+	ownerRef        metav1.OwnerReference
+}
+
+// New creates an instance of the exporters
+func New(context *clusterd.Context, namespace, rookVersion, dataDirHostPath string, placement rookalpha.Placement, hostNetwork bool,
+	spec cephv1.ExporterSpec, resources v1.ResourceRequirements, ownerRef metav1.OwnerReference) *Cluster {
+	return &Cluster{
+		context:         context,
+		Namespace:       namespace,
+		rookVersion:     rookVersion,
+		dataDirHostPath: dataDirHostPath,
+		placement:       placement,
+		hostNetwork:     hostNetwork,
+		spec:            spec,
+		resources:       resources,
+		ownerRef:        ownerRef,
+	}
+}
+
+// Start creates or updates the exporter daemonset and its metrics service, or removes them when the exporter is
+// disabled
+func (c *Cluster) Start() error {
+	if !c.spec.Enabled {
+		return c.remove()
+	}
+	if c.dataDirHostPath == "" {
+		// the admin sockets of the daemons are only found on the node under the data dir
+		logger.Warningf("the exporter requires the dataDirHostPath to find the admin sockets of the daemons")
+		return c.remove()
+	}
+
+	ds := c.makeDaemonSet()
+	if _, err := c.context.Clientset.ExtensionsV1beta1().DaemonSets(c.Namespace).Create(ds); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create the exporter daemonset. %+v", err)
+		}
+		if _, err := c.context.Clientset.ExtensionsV1beta1().DaemonSets(c.Namespace).Update(ds); err != nil {
+			return fmt.Errorf("failed to update the exporter daemonset. %+v", err)
+		}
+		logger.Infof("exporter daemonset updated")
+	} else {
+		logger.Infof("exporter daemonset started")
+	}
+
+	service := c.makeMetricsService()
+	if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Create(service); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create the exporter service. %+v", err)
+		}
+		// keep the cluster ip of the existing service when the port is updated
+		existing, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get(appName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get the exporter service. %+v", err)
+		}
+		existing.Spec.Ports = service.Spec.Ports
+		if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Update(existing); err != nil {
+			return fmt.Errorf("failed to update the exporter service. %+v", err)
+		}
+		logger.Infof("exporter service updated")
+	} else {
+		logger.Infof("exporter service started")
+	}
+	return nil
+}
+
+func (c *Cluster) remove() error {
+	if err := k8sutil.DeleteDaemonset(c.context.Clientset, c.Namespace, appName); err != nil {
+		return fmt.Errorf("failed to remove the exporter daemonset. %+v", err)
+	}
+	err := c.context.Clientset.CoreV1().Services(c.Namespace).Delete(appName, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to remove the exporter service. %+v", err)
+	}
+	return nil
+}
+
+func (c *Cluster) port() int {
+	if c.spec.Port == 0 {
+		return exporterdaemon.DefaultPort
+	}
+	return c.spec.Port
+}
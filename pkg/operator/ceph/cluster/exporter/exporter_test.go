@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStartExporter(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset}
+	spec := cephv1.ExporterSpec{Enabled: true}
+	c := New(context, "ns", "myversion", "/var/lib/rook", rookalpha.Placement{}, false, spec, v1.ResourceRequirements{}, metav1.OwnerReference{})
+
+	err := c.Start()
+	assert.Nil(t, err)
+	ds, err := clientset.ExtensionsV1beta1().DaemonSets("ns").Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	container := ds.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "myversion", container.Image)
+	assert.Equal(t, []string{"ceph", "exporter", "--port", "9926"}, container.Args)
+	assert.Equal(t, "/var/lib/rook", ds.Spec.Template.Spec.Volumes[0].HostPath.Path)
+	assert.Equal(t, k8sutil.DataDir, container.VolumeMounts[0].MountPath)
+	svc, err := clientset.CoreV1().Services("ns").Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(9926), svc.Spec.Ports[0].Port)
+
+	// the daemonset is updated with the new port
+	c.spec.Port = 9000
+	err = c.Start()
+	assert.Nil(t, err)
+	ds, err = clientset.ExtensionsV1beta1().DaemonSets("ns").Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ceph", "exporter", "--port", "9000"}, ds.Spec.Template.Spec.Containers[0].Args)
+	svc, err = clientset.CoreV1().Services("ns").Get(appName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(9000), svc.Spec.Ports[0].Port)
+
+	// the daemonset and the service are removed when the exporter is disabled
+	c.spec.Enabled = false
+	err = c.Start()
+	assert.Nil(t, err)
+	_, err = clientset.ExtensionsV1beta1().DaemonSets("ns").Get(appName, metav1.GetOptions{})
+	assert.NotNil(t, err)
+	_, err = clientset.CoreV1().Services("ns").Get(appName, metav1.GetOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestExporterWithoutDataDirHostPath(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset}
+	spec := cephv1.ExporterSpec{Enabled: true}
+	c := New(context, "ns", "myversion", "", rookalpha.Placement{}, false, spec, v1.ResourceRequirements{}, metav1.OwnerReference{})
+
+	// the admin sockets are not on the nodes
+	err := c.Start()
+	assert.Nil(t, err)
+	_, err = clientset.ExtensionsV1beta1().DaemonSets("ns").Get(appName, metav1.GetOptions{})
+	assert.NotNil(t, err)
+}
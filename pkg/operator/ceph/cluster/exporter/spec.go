@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"strconv"
+
+	opmon "github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *Cluster) makeDaemonSet() *extensions.DaemonSet {
+	labels := opspec.AppLabels(appName, c.Namespace)
+	podSpec := v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   appName,
+			Labels: labels,
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				c.makeExporterContainer(),
+			},
+			RestartPolicy: v1.RestartPolicyAlways,
+			Volumes:       opspec.PodVolumes(c.dataDirHostPath),
+			HostNetwork:   c.hostNetwork,
+		},
+	}
+	if c.hostNetwork {
+		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
+	}
+	c.placement.ApplyToPodSpec(&podSpec.Spec)
+	c.PodExtension.ApplyToPodSpec(&podSpec.Spec)
+
+	ds := &extensions.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: c.Namespace,
+			Labels:    labels,
+		},
+		Spec: extensions.DaemonSetSpec{
+			UpdateStrategy: extensions.DaemonSetUpdateStrategy{
+				Type: extensions.RollingUpdateDaemonSetStrategyType,
+			},
+			Template: podSpec,
+		},
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &ds.ObjectMeta, &c.ownerRef)
+	return ds
+}
+
+func (c *Cluster) makeExporterContainer() v1.Container {
+	return v1.Container{
+		Name: "exporter",
+		Args: []string{
+			"ceph",
+			"exporter",
+			"--port", strconv.Itoa(c.port()),
+		},
+		Image: k8sutil.MakeRookImage(c.rookVersion),
+		Ports: []v1.ContainerPort{
+			{
+				Name:          "http-metrics",
+				ContainerPort: int32(c.port()),
+				Protocol:      v1.ProtocolTCP,
+			},
+		},
+		Env: append([]v1.EnvVar{
+			opmon.ClusterNameEnvVar(c.Namespace),
+		}, k8sutil.LogFormatEnvVars(true)...),
+		VolumeMounts: opspec.RookVolumeMounts(),
+		Resources:    c.resources,
+	}
+}
+
+func (c *Cluster) makeMetricsService() *v1.Service {
+	labels := opspec.AppLabels(appName, c.Namespace)
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: c.Namespace,
+			Labels:    labels,
+		},
+		Spec: v1.ServiceSpec{
+			Selector: labels,
+			Type:     v1.ServiceTypeClusterIP,
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http-metrics",
+					Port:     int32(c.port()),
+					Protocol: v1.ProtocolTCP,
+				},
+			},
+		},
+	}
+
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &svc.ObjectMeta, &c.ownerRef)
+	return svc
+}
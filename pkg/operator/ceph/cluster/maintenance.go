@@ -0,0 +1,123 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	maintenanceMessage = "maintenance mode is enabled, the cluster is not orchestrated"
+
+	// the owner of the osd flags set in maintenance mode
+	maintenanceFlagOwner = "maintenance"
+)
+
+var (
+	// the osd flags set in maintenance mode, so the data is not moved while the admins repair the cluster
+	maintenanceFlags = []string{"noout", "nobackfill"}
+)
+
+// checkMaintenance enters or exits maintenance mode when it is enabled or disabled in the cluster spec. It returns
+// whether the update must be skipped, and whether it must be orchestrated even without change because maintenance
+// mode was disabled. The old spec is replaced by the spec before maintenance mode, saved in the status of the cluster
+// so it survives a restart of the operator, so the updates made during the maintenance are all orchestrated.
+func (c *ClusterController) checkMaintenance(oldClust, newClust *cephv1.CephCluster, cluster *cluster) (skip, orchestrate bool) {
+	if newClust.Spec.MaintenanceMode {
+		if !cluster.Spec.MaintenanceMode {
+			cluster.specLock.Lock()
+			cluster.Spec.MaintenanceMode = true
+			cluster.specLock.Unlock()
+			if err := c.saveSpecBeforeMaintenance(newClust.Namespace, newClust.Name, oldClust.Spec.DeepCopy()); err != nil {
+				logger.Errorf("failed to save the spec of cluster %s before maintenance mode. %+v", cluster.Namespace, err)
+			}
+			c.enterMaintenance(cluster, newClust.Name)
+		}
+		logger.Infof("cluster %s is in maintenance mode. the update will be orchestrated when maintenance mode is disabled", cluster.Namespace)
+		return true, false
+	}
+	if !cluster.Spec.MaintenanceMode {
+		return false, false
+	}
+
+	exitMaintenance(cluster)
+	specBeforeMaintenance := newClust.Status.SpecBeforeMaintenance
+	if err := c.saveSpecBeforeMaintenance(newClust.Namespace, newClust.Name, nil); err != nil {
+		logger.Warningf("failed to clear the spec of cluster %s saved before maintenance mode. %+v", cluster.Namespace, err)
+	}
+	if !cluster.initCompleted {
+		// the cluster was added in maintenance mode, or the operator restarted during the maintenance, so the cluster
+		// was not started by this operator
+		c.onAdd(newClust)
+		return true, false
+	}
+	if specBeforeMaintenance != nil {
+		oldClust.Spec = *specBeforeMaintenance
+	}
+	return false, true
+}
+
+// enterMaintenance pauses the controllers of the cluster, sets the osd flags of maintenance mode and reports it in the
+// status of the cluster
+func (c *ClusterController) enterMaintenance(cluster *cluster, crdName string) {
+	logger.Infof("entering maintenance mode in cluster %s", cluster.Namespace)
+	maintenance.Pause(cluster.Namespace)
+	for _, flag := range maintenanceFlags {
+		if err := cluster.acquireOSDFlag(flag, maintenanceFlagOwner); err != nil {
+			logger.Warningf("failed to set %s in maintenance mode. %+v", flag, err)
+		}
+	}
+	if err := c.updateClusterStatus(cluster.Namespace, crdName, cephv1.ClusterStateMaintenance, maintenanceMessage); err != nil {
+		logger.Errorf("failed to update cluster status in namespace %s: %+v", cluster.Namespace, err)
+	}
+}
+
+// exitMaintenance unsets the osd flags of maintenance mode, unless they are held by the admin or another operation,
+// and resumes the controllers of the cluster
+func exitMaintenance(cluster *cluster) {
+	logger.Infof("exiting maintenance mode in cluster %s", cluster.Namespace)
+	cluster.specLock.Lock()
+	cluster.Spec.MaintenanceMode = false
+	cluster.specLock.Unlock()
+	for _, flag := range maintenanceFlags {
+		if err := cluster.releaseOSDFlag(flag, maintenanceFlagOwner); err != nil {
+			logger.Warningf("failed to unset %s after maintenance mode. %+v", flag, err)
+		}
+	}
+	maintenance.Resume(cluster.Namespace)
+}
+
+// saveSpecBeforeMaintenance saves the spec orchestrated before maintenance mode in the status of the cluster, or
+// clears it if the spec is nil
+func (c *ClusterController) saveSpecBeforeMaintenance(namespace, name string, spec *cephv1.ClusterSpec) error {
+	clust, err := c.context.RookClientset.CephV1().CephClusters(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s. %+v", namespace, err)
+	}
+	if spec == nil && clust.Status.SpecBeforeMaintenance == nil {
+		return nil
+	}
+	clust.Status.SpecBeforeMaintenance = spec
+	if _, err := c.context.RookClientset.CephV1().CephClusters(namespace).Update(clust); err != nil {
+		return fmt.Errorf("failed to update cluster %s. %+v", namespace, err)
+	}
+	return nil
+}
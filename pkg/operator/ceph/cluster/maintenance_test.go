@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckMaintenance(t *testing.T) {
+	var flags []string
+	setFlags := "sortbitwise"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			if args[1] == "dump" {
+				return `{"osds":[],"flags":"` + setFlags + `"}`, nil
+			}
+			flags = append(flags, args[1]+" "+args[2])
+			return "", nil
+		},
+	}
+	rookClientset := rookfake.NewSimpleClientset()
+	context := &clusterd.Context{Executor: executor, RookClientset: rookClientset}
+	c := &ClusterController{context: context}
+	oldClust := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "ns"}}
+	oldClust.Spec.Mon.Count = 3
+	_, err := rookClientset.CephV1().CephClusters("ns").Create(oldClust)
+	assert.Nil(t, err)
+	cluster := &cluster{context: context, Namespace: "ns", crdName: "rook-ceph", Spec: oldClust.Spec.DeepCopy(), initCompleted: true}
+
+	// no change of maintenance mode
+	newClust := oldClust.DeepCopy()
+	skip, orchestrate := c.checkMaintenance(oldClust, newClust, cluster)
+	assert.False(t, skip)
+	assert.False(t, orchestrate)
+	assert.Equal(t, 0, len(flags))
+
+	// the update enabling maintenance mode is not orchestrated, and the controllers of the cluster are paused
+	newClust.Spec.MaintenanceMode = true
+	skip, orchestrate = c.checkMaintenance(oldClust, newClust, cluster)
+	assert.True(t, skip)
+	assert.False(t, orchestrate)
+	assert.True(t, cluster.Spec.MaintenanceMode)
+	assert.True(t, maintenance.Paused("ns"))
+	assert.Equal(t, []string{"set noout", "set nobackfill"}, flags)
+	status, err := rookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ClusterStateMaintenance, status.Status.State)
+	assert.Equal(t, 3, status.Status.SpecBeforeMaintenance.Mon.Count)
+
+	// neither are the updates in maintenance mode
+	flags = nil
+	updated := newClust.DeepCopy()
+	updated.Spec.Mon.Count = 5
+	skip, _ = c.checkMaintenance(newClust, updated, cluster)
+	assert.True(t, skip)
+	assert.Equal(t, 0, len(flags))
+
+	// the updates are orchestrated against the spec saved before maintenance mode once it is disabled
+	disabled, err := rookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	assert.Nil(t, err)
+	disabled.Spec = updated.Spec
+	disabled.Spec.MaintenanceMode = false
+	previous := updated.DeepCopy()
+	skip, orchestrate = c.checkMaintenance(previous, disabled, cluster)
+	assert.False(t, skip)
+	assert.True(t, orchestrate)
+	assert.False(t, cluster.Spec.MaintenanceMode)
+	assert.False(t, maintenance.Paused("ns"))
+	assert.Equal(t, 3, previous.Spec.Mon.Count)
+	assert.False(t, previous.Spec.MaintenanceMode)
+	assert.Equal(t, []string{"unset noout", "unset nobackfill"}, flags)
+	status, err = rookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, status.Status.SpecBeforeMaintenance)
+	assert.Equal(t, 0, len(status.Status.OSDFlags))
+
+	// the flags set by the admin before the maintenance stay set
+	flags = nil
+	setFlags = "noout,sortbitwise"
+	newClust = status.DeepCopy()
+	newClust.Spec.MaintenanceMode = true
+	c.checkMaintenance(status, newClust, cluster)
+	disabled, err = rookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	assert.Nil(t, err)
+	disabled.Spec.MaintenanceMode = false
+	c.checkMaintenance(newClust, disabled, cluster)
+	assert.Equal(t, []string{"set noout", "set nobackfill", "unset nobackfill"}, flags)
+}
@@ -27,6 +27,7 @@ import (
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -89,6 +90,27 @@ func (c *Cluster) configureDashboard(port int) error {
 		}
 	}
 
+	return c.configureDashboardIngress(dashboardService.Name, port)
+}
+
+// create or update the ingress of the dashboard service, or remove it when the dashboard or the ingress is disabled
+func (c *Cluster) configureDashboardIngress(serviceName string, port int) error {
+	if !c.dashboard.Enabled || !c.dashboard.Ingress.Enabled {
+		if err := opspec.DeleteIngress(c.context.Clientset, c.Namespace, serviceName); err != nil {
+			return fmt.Errorf("failed to delete dashboard ingress. %+v", err)
+		}
+		return nil
+	}
+
+	hosts := []string{}
+	if c.dashboard.Ingress.Host != "" {
+		hosts = append(hosts, c.dashboard.Ingress.Host)
+	}
+	ingress := opspec.MakeIngress(serviceName, c.Namespace, opspec.AppLabels(appName, c.Namespace), hosts, serviceName, int32(port), c.dashboard.Ingress)
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &ingress.ObjectMeta, &c.ownerRef)
+	if err := opspec.CreateOrUpdateIngress(c.context.Clientset, ingress); err != nil {
+		return fmt.Errorf("failed to create or update dashboard ingress. %+v", err)
+	}
 	return nil
 }
 
@@ -125,3 +125,33 @@ func TestStartSecureDashboard(t *testing.T) {
 	assert.True(t, errors.IsNotFound(err))
 	assert.Nil(t, svc)
 }
+
+func TestDashboardIngress(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return "", nil
+		},
+	}
+	c := &Cluster{context: &clusterd.Context{Clientset: test.New(3), Executor: executor}, Namespace: "myns",
+		dashboard:   cephv1.DashboardSpec{Enabled: true, Ingress: cephv1.IngressSpec{Enabled: true, Host: "dashboard.example.com", Class: "nginx", TLSSecretName: "mycert"}},
+		cephVersion: cephv1.CephVersionSpec{Name: cephv1.Mimic, Image: "ceph/ceph:v13.2.2"}}
+	dashboardInitWaitTime = 0
+
+	err := c.configureDashboard(dashboardPortHttps)
+	assert.Nil(t, err)
+	ingress, err := c.context.Clientset.ExtensionsV1beta1().Ingresses(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx", ingress.Annotations["kubernetes.io/ingress.class"])
+	assert.Equal(t, "dashboard.example.com", ingress.Spec.Rules[0].Host)
+	backend := ingress.Spec.Rules[0].HTTP.Paths[0].Backend
+	assert.Equal(t, "rook-ceph-mgr-dashboard", backend.ServiceName)
+	assert.Equal(t, dashboardPortHttps, backend.ServicePort.IntValue())
+	assert.Equal(t, []string{"dashboard.example.com"}, ingress.Spec.TLS[0].Hosts)
+
+	// the ingress is removed when it is disabled
+	c.dashboard.Ingress.Enabled = false
+	err = c.configureDashboard(dashboardPortHttps)
+	assert.Nil(t, err)
+	_, err = c.context.Clientset.ExtensionsV1beta1().Ingresses(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+}
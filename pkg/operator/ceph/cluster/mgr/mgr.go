@@ -45,18 +45,22 @@ var mgrNames = []string{"a", "b"}
 
 // Cluster represents the Rook and environment configuration settings needed to set up Ceph mgrs.
 type Cluster struct {
-	Namespace   string
-	Replicas    int
-	placement   rookalpha.Placement
-	context     *clusterd.Context
-	dataDir     string
-	HostNetwork bool
-	resources   v1.ResourceRequirements
-	ownerRef    metav1.OwnerReference
-	dashboard   cephv1.DashboardSpec
-	cephVersion cephv1.CephVersionSpec
-	rookVersion string
-	exitCode    func(err error) (int, bool)
+	Namespace     string
+	crdName       string
+	Replicas      int
+	placement     rookalpha.Placement
+	context       *clusterd.Context
+	dataDir       string
+	HostNetwork   bool
+	resources     v1.ResourceRequirements
+	livenessProbe cephv1.ProbeSpec
+	ownerRef      metav1.OwnerReference
+	dashboard     cephv1.DashboardSpec
+	Telemetry     cephv1.TelemetrySpec
+	PodExtension  rookalpha.PodExtension
+	cephVersion   cephv1.CephVersionSpec
+	rookVersion   string
+	exitCode      func(err error) (int, bool)
 }
 
 // mgrConfig for a single mgr
@@ -66,21 +70,23 @@ type mgrConfig struct {
 }
 
 // New creates an instance of the mgr
-func New(context *clusterd.Context, namespace, rookVersion string, cephVersion cephv1.CephVersionSpec, placement rookalpha.Placement, hostNetwork bool, dashboard cephv1.DashboardSpec,
-	resources v1.ResourceRequirements, ownerRef metav1.OwnerReference) *Cluster {
+func New(context *clusterd.Context, namespace, crdName, rookVersion string, cephVersion cephv1.CephVersionSpec, placement rookalpha.Placement, hostNetwork bool, dashboard cephv1.DashboardSpec,
+	resources v1.ResourceRequirements, livenessProbe cephv1.ProbeSpec, ownerRef metav1.OwnerReference) *Cluster {
 	return &Cluster{
-		context:     context,
-		Namespace:   namespace,
-		placement:   placement,
-		rookVersion: rookVersion,
-		cephVersion: cephVersion,
-		Replicas:    1,
-		dataDir:     k8sutil.DataDir,
-		dashboard:   dashboard,
-		HostNetwork: hostNetwork,
-		resources:   resources,
-		ownerRef:    ownerRef,
-		exitCode:    getExitCode,
+		context:       context,
+		Namespace:     namespace,
+		crdName:       crdName,
+		placement:     placement,
+		rookVersion:   rookVersion,
+		cephVersion:   cephVersion,
+		Replicas:      1,
+		dataDir:       k8sutil.DataDir,
+		dashboard:     dashboard,
+		HostNetwork:   hostNetwork,
+		resources:     resources,
+		livenessProbe: livenessProbe,
+		ownerRef:      ownerRef,
+		exitCode:      getExitCode,
 	}
 }
 
@@ -150,6 +156,10 @@ func (c *Cluster) Start() error {
 		logger.Errorf("failed to enable mgr dashboard. %+v", err)
 	}
 
+	if err := c.configureTelemetry(); err != nil {
+		logger.Errorf("failed to configure mgr telemetry. %+v", err)
+	}
+
 	// create the metrics service
 	service := c.makeMetricsService(appName)
 	if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Create(service); err != nil {
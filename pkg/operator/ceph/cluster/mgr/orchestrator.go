@@ -19,9 +19,12 @@ package mgr
 
 import (
 	"fmt"
+	"os"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
 )
 
 const (
@@ -48,3 +51,18 @@ func (c *Cluster) configureOrchestratorModules() error {
 
 	return nil
 }
+
+// orchestratorEnvVars are the settings of the rook mgr module to find the resources managed by the operator.
+// The module lists the devices from the discovery configmaps in the operator namespace, creates osds by adding
+// the devices of a node to the storage of the cluster CRD, and scales the file systems and object stores by
+// updating their CRDs. The operator then orchestrates the changes of the CRDs as if they were made by the admin.
+func (c *Cluster) orchestratorEnvVars() []v1.EnvVar {
+	if !cephv1.VersionAtLeast(c.cephVersion.Name, cephv1.Nautilus) {
+		return []v1.EnvVar{}
+	}
+	return []v1.EnvVar{
+		{Name: "ROOK_OPERATOR_NAMESPACE", Value: os.Getenv(k8sutil.PodNamespaceEnvVar)},
+		{Name: "ROOK_CEPH_CLUSTER_CRD_NAME", Value: c.crdName},
+		{Name: "ROOK_CEPH_CLUSTER_CRD_VERSION", Value: cephv1.Version},
+	}
+}
@@ -17,10 +17,12 @@ package mgr
 
 import (
 	"fmt"
+	"os"
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -76,3 +78,22 @@ func TestOrchestratorModules(t *testing.T) {
 	assert.True(t, rookModuleEnabled)
 	assert.True(t, rookBackendSet)
 }
+
+func TestOrchestratorEnvVars(t *testing.T) {
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-ceph-system")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+	c := &Cluster{Namespace: "ns", crdName: "my-cluster"}
+
+	// the module is not enabled before nautilus
+	c.cephVersion.Name = cephv1.Mimic
+	assert.Equal(t, 0, len(c.orchestratorEnvVars()))
+
+	c.cephVersion.Name = cephv1.Nautilus
+	env := map[string]string{}
+	for _, e := range c.orchestratorEnvVars() {
+		env[e.Name] = e.Value
+	}
+	assert.Equal(t, "rook-ceph-system", env["ROOK_OPERATOR_NAMESPACE"])
+	assert.Equal(t, "my-cluster", env["ROOK_CEPH_CLUSTER_CRD_NAME"])
+	assert.Equal(t, "v1", env["ROOK_CEPH_CLUSTER_CRD_VERSION"])
+}
@@ -59,6 +59,7 @@ func (c *Cluster) makeDeployment(mgrConfig *mgrConfig, port int) *extensions.Dep
 		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	}
 	c.placement.ApplyToPodSpec(&podSpec.Spec)
+	c.PodExtension.ApplyToPodSpec(&podSpec.Spec)
 
 	replicas := int32(1)
 	d := &extensions.Deployment{
@@ -88,7 +89,7 @@ func (c *Cluster) makeConfigInitContainer(mgrConfig *mgrConfig) v1.Container {
 			fmt.Sprintf("--mgr-name=%s", mgrConfig.DaemonName),
 		},
 		Image: k8sutil.MakeRookImage(c.rookVersion),
-		Env: []v1.EnvVar{
+		Env: append([]v1.EnvVar{
 			// Set '--mgr-keyring' flag with an env var sourced from the secret
 			{Name: "ROOK_MGR_KEYRING",
 				ValueFrom: &v1.EnvVarSource{
@@ -104,7 +105,7 @@ func (c *Cluster) makeConfigInitContainer(mgrConfig *mgrConfig) v1.Container {
 			opmon.SecretEnvVar(),
 			opmon.AdminSecretEnvVar(),
 			k8sutil.ConfigOverrideEnvVar(),
-		},
+		}, k8sutil.LogFormatEnvVars(true)...),
 		VolumeMounts: opspec.RookVolumeMounts(),
 		// config file creation does not require ports to be open
 		Resources: c.resources,
@@ -141,10 +142,12 @@ func (c *Cluster) makeMgrDaemonContainer(mgrConfig *mgrConfig, port int) v1.Cont
 				Protocol:      v1.ProtocolTCP,
 			},
 		},
-		Env:       k8sutil.ClusterDaemonEnvVars(),
-		Resources: c.resources,
+		Env:           k8sutil.ClusterDaemonEnvVars(),
+		Resources:     c.resources,
+		LivenessProbe: opspec.LivenessProbe("mgr", mgrConfig.DaemonName, c.livenessProbe),
 	}
 	container.Env = append(container.Env, opmon.ClusterNameEnvVar(c.Namespace))
+	container.Env = append(container.Env, c.orchestratorEnvVars()...)
 	return container
 }
 
@@ -40,6 +40,7 @@ func TestPodSpec(t *testing.T) {
 	c := New(
 		&clusterd.Context{Clientset: testop.New(1)},
 		"ns",
+		"rook-ceph",
 		"rook/rook:myversion",
 		cephv1.CephVersionSpec{Image: "ceph/ceph:myceph"},
 		rookalpha.Placement{},
@@ -53,7 +54,7 @@ func TestPodSpec(t *testing.T) {
 				v1.ResourceMemory: *resource.NewQuantity(1337.0, resource.BinarySI),
 			},
 		},
-		metav1.OwnerReference{},
+		cephv1.ProbeSpec{}, metav1.OwnerReference{},
 	)
 
 	mgrTestConfig := mgrConfig{
@@ -147,7 +148,7 @@ func TestPodSpec(t *testing.T) {
 }
 
 func TestServiceSpec(t *testing.T) {
-	c := New(&clusterd.Context{}, "ns", "myversion", cephv1.CephVersionSpec{}, rookalpha.Placement{}, false, cephv1.DashboardSpec{}, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c := New(&clusterd.Context{}, "ns", "rook-ceph", "myversion", cephv1.CephVersionSpec{}, rookalpha.Placement{}, false, cephv1.DashboardSpec{}, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 
 	s := c.makeMetricsService("rook-mgr")
 	assert.NotNil(t, s)
@@ -159,13 +160,14 @@ func TestHostNetwork(t *testing.T) {
 	c := New(
 		&clusterd.Context{Clientset: testop.New(1)},
 		"ns",
+		"rook-ceph",
 		"myversion",
 		cephv1.CephVersionSpec{},
 		rookalpha.Placement{},
 		true,
 		cephv1.DashboardSpec{},
 		v1.ResourceRequirements{},
-		metav1.OwnerReference{},
+		cephv1.ProbeSpec{}, metav1.OwnerReference{},
 	)
 
 	mgrTestConfig := mgrConfig{
@@ -179,3 +181,22 @@ func TestHostNetwork(t *testing.T) {
 	assert.Equal(t, true, d.Spec.Template.Spec.HostNetwork)
 	assert.Equal(t, v1.DNSClusterFirstWithHostNet, d.Spec.Template.Spec.DNSPolicy)
 }
+
+func TestPodExtension(t *testing.T) {
+	c := New(&clusterd.Context{Clientset: testop.New(1)}, "ns", "rook-ceph", "myversion", cephv1.CephVersionSpec{}, rookalpha.Placement{}, false,
+		cephv1.DashboardSpec{}, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
+	c.PodExtension = rookalpha.PodExtension{
+		Sidecars:     []v1.Container{{Name: "backup", Image: "backup-agent"}},
+		Volumes:      []v1.Volume{{Name: "backup"}},
+		VolumeMounts: []v1.VolumeMount{{Name: "backup", MountPath: "/backup"}},
+	}
+
+	d := c.makeDeployment(&mgrConfig{DaemonName: "a", ResourceName: "mgr-a"}, dashboardPortHttp)
+	spec := d.Spec.Template.Spec
+	assert.Equal(t, 2, len(spec.Containers))
+	assert.Equal(t, "backup", spec.Containers[1].Name)
+	assert.Equal(t, "backup", spec.Volumes[len(spec.Volumes)-1].Name)
+	assert.Equal(t, v1.VolumeMount{Name: "backup", MountPath: "/backup"}, spec.Containers[0].VolumeMounts[len(spec.Containers[0].VolumeMounts)-1])
+	// the generated init containers are not changed
+	assert.Equal(t, 1, len(spec.InitContainers))
+}
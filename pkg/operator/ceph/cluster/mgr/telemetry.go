@@ -0,0 +1,163 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mgr for the Ceph manager.
+package mgr
+
+import (
+	"fmt"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"k8s.io/kubernetes/pkg/util/version"
+)
+
+const (
+	telemetryModuleName = "telemetry"
+	// the license the reports are shared under, required to opt in
+	telemetryLicense = "sharing-1-0"
+	// the health check raised by the module when the content of the reports changed since the opt-in
+	telemetryChangedCheck = "TELEMETRY_CHANGED"
+)
+
+var (
+	telemetryChannels = []string{"basic", "crash", "device", "ident"}
+	// the channels and the license were added to the module in this nautilus point release
+	telemetryMinVersion = version.MustParseGeneric("14.2.5")
+
+	// TelemetryCheckInterval is the interval to check if the telemetry needs to be opted in again
+	TelemetryCheckInterval = 10 * time.Minute
+)
+
+// Ceph docs about the telemetry module: http://docs.ceph.com/docs/master/mgr/telemetry/
+func (c *Cluster) configureTelemetry() error {
+	if !cephv1.VersionAtLeast(c.cephVersion.Name, cephv1.Nautilus) {
+		logger.Infof("skipping the telemetry module on releases older than nautilus")
+		return nil
+	}
+
+	if !c.Telemetry.Enabled {
+		if err := client.MgrDisableModule(c.context, c.Namespace, telemetryModuleName); err != nil {
+			return fmt.Errorf("failed to disable mgr telemetry module. %+v", err)
+		}
+		return nil
+	}
+
+	// the name of the release in the cluster CRD does not tell the point release of the mgrs
+	mgrVersions, err := client.MgrVersions(c.context, c.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get the version of the mgrs. %+v", err)
+	}
+	if len(mgrVersions) == 0 {
+		return fmt.Errorf("no mgr is running to check its version")
+	}
+	for _, v := range mgrVersions {
+		if !v.AtLeast(telemetryMinVersion) {
+			logger.Warningf("skipping the telemetry module on mgr version %s, the channels and the license require %s or newer", v, telemetryMinVersion)
+			return nil
+		}
+	}
+
+	if err := client.MgrEnableModule(c.context, c.Namespace, telemetryModuleName, false); err != nil {
+		return fmt.Errorf("failed to enable mgr telemetry module. %+v", err)
+	}
+	settings := map[string]string{
+		"contact":      c.Telemetry.Contact,
+		"description":  c.Telemetry.Description,
+		"organization": c.Telemetry.Organization,
+		"proxy":        c.Telemetry.Proxy,
+	}
+	for _, channel := range telemetryChannels {
+		// the empty settings are removed to get the defaults of ceph
+		settings["channel_"+channel] = ""
+		if len(c.Telemetry.Channels) > 0 {
+			settings["channel_"+channel] = "false"
+		}
+	}
+	for _, channel := range c.Telemetry.Channels {
+		if _, ok := settings["channel_"+channel]; !ok {
+			logger.Warningf("ignoring unknown telemetry channel %s", channel)
+			continue
+		}
+		settings["channel_"+channel] = "true"
+	}
+	for key, val := range settings {
+		if _, err := client.MgrSetAllConfig(c.context, c.Namespace, c.cephVersion.Name, "mgr/telemetry/"+key, val); err != nil {
+			return fmt.Errorf("failed to set telemetry %s. %+v", key, err)
+		}
+	}
+	return telemetryOn(c.context, c.Namespace)
+}
+
+// telemetryOn opts in to the reports with their current content
+func telemetryOn(context *clusterd.Context, clusterName string) error {
+	if _, err := client.ExecuteCephCommand(context, clusterName, []string{"telemetry", "on", "--license", telemetryLicense}); err != nil {
+		return fmt.Errorf("failed to opt in to the telemetry. %+v", err)
+	}
+	return nil
+}
+
+// TelemetryChecker opts in to the telemetry again when the module stopped sending the reports because their
+// content changed, after an upgrade of ceph, since the opt-in declared in the cluster CRD
+type TelemetryChecker struct {
+	context     *clusterd.Context
+	clusterName string
+	clusterSpec func() *cephv1.ClusterSpec
+}
+
+// NewTelemetryChecker creates a new TelemetryChecker object
+func NewTelemetryChecker(context *clusterd.Context, clusterName string, clusterSpec func() *cephv1.ClusterSpec) *TelemetryChecker {
+	return &TelemetryChecker{
+		context:     context,
+		clusterName: clusterName,
+		clusterSpec: clusterSpec,
+	}
+}
+
+// Check periodically checks if the telemetry needs to be opted in again
+func (t *TelemetryChecker) Check(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("Stopping the telemetry checker in namespace %s", t.clusterName)
+			return
+
+		case <-time.After(TelemetryCheckInterval):
+			if err := t.checkTelemetry(); err != nil {
+				logger.Warningf("failed to check the telemetry. %+v", err)
+			}
+		}
+	}
+}
+
+func (t *TelemetryChecker) checkTelemetry() error {
+	spec := t.clusterSpec()
+	if !spec.Telemetry.Enabled || spec.MaintenanceMode || !cephv1.VersionAtLeast(spec.CephVersion.Name, cephv1.Nautilus) {
+		return nil
+	}
+
+	status, err := client.Status(t.context, t.clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to get ceph status. %+v", err)
+	}
+	if _, ok := status.Health.Checks[telemetryChangedCheck]; !ok {
+		return nil
+	}
+	logger.Infof("the content of the telemetry reports changed, opting in again")
+	return telemetryOn(t.context, t.clusterName)
+}
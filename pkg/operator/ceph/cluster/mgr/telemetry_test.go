@@ -0,0 +1,137 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureTelemetry(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	moduleEnabled := false
+	optedIn := false
+	settings := map[string]string{}
+	mgrVersion := "14.2.4"
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "versions" {
+			return fmt.Sprintf(`{"mgr": {"ceph version %s (75f4de193b3ea58512f204623e6c5c346e0ec0c2) nautilus (stable)": 1}}`, mgrVersion), nil
+		}
+		if args[0] == "mgr" && args[1] == "module" && args[3] == "telemetry" {
+			moduleEnabled = args[2] == "enable"
+			return "", nil
+		}
+		if args[0] == "config" && args[1] == "get" {
+			return "", nil
+		}
+		if args[0] == "config" && args[1] == "set" && args[2] == "mgr." {
+			settings[args[3]] = args[4]
+			return "", nil
+		}
+		if args[0] == "config" && args[1] == "rm" && args[2] == "mgr." {
+			delete(settings, args[3])
+			return "", nil
+		}
+		if args[0] == "telemetry" && args[1] == "on" && args[2] == "--license" && args[3] == "sharing-1-0" {
+			optedIn = true
+			return "", nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+
+	c := &Cluster{context: context, Namespace: "ns"}
+	c.Telemetry = cephv1.TelemetrySpec{Enabled: true, Channels: []string{"basic", "ident"}, Contact: "admin@example.com"}
+
+	// the module is skipped on mimic
+	c.cephVersion.Name = cephv1.Mimic
+	assert.Nil(t, c.configureTelemetry())
+	assert.False(t, moduleEnabled)
+	assert.False(t, optedIn)
+
+	// the module is skipped on the nautilus point releases without the channels and the license
+	c.cephVersion.Name = cephv1.Nautilus
+	assert.Nil(t, c.configureTelemetry())
+	assert.False(t, moduleEnabled)
+	assert.False(t, optedIn)
+
+	// the channels and the contact are set before opting in on nautilus 14.2.5
+	mgrVersion = "14.2.5"
+	assert.Nil(t, c.configureTelemetry())
+	assert.True(t, moduleEnabled)
+	assert.True(t, optedIn)
+	assert.Equal(t, map[string]string{
+		"mgr/telemetry/channel_basic":  "true",
+		"mgr/telemetry/channel_crash":  "false",
+		"mgr/telemetry/channel_device": "false",
+		"mgr/telemetry/channel_ident":  "true",
+		"mgr/telemetry/contact":        "admin@example.com",
+	}, settings)
+
+	// the default channels of ceph are restored when no channel is set
+	c.Telemetry.Channels = nil
+	assert.Nil(t, c.configureTelemetry())
+	assert.Equal(t, map[string]string{"mgr/telemetry/contact": "admin@example.com"}, settings)
+
+	// the module is disabled when opting out
+	c.Telemetry.Enabled = false
+	assert.Nil(t, c.configureTelemetry())
+	assert.False(t, moduleEnabled)
+}
+
+func TestCheckTelemetry(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	changed := false
+	optedIn := false
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[0] == "status" {
+			if changed {
+				return `{"health": {"status": "HEALTH_WARN", "checks": {"TELEMETRY_CHANGED": {"severity": "HEALTH_WARN"}}}}`, nil
+			}
+			return `{"health": {"status": "HEALTH_OK", "checks": {}}}`, nil
+		}
+		if args[0] == "telemetry" && args[1] == "on" {
+			optedIn = true
+			return "", nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+	spec := &cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Name: cephv1.Nautilus}, Telemetry: cephv1.TelemetrySpec{Enabled: true}}
+	checker := NewTelemetryChecker(context, "ns", func() *cephv1.ClusterSpec { return spec })
+
+	// nothing to do while the content of the reports did not change
+	assert.Nil(t, checker.checkTelemetry())
+	assert.False(t, optedIn)
+
+	// the telemetry is opted in again when the content changed
+	changed = true
+	assert.Nil(t, checker.checkTelemetry())
+	assert.True(t, optedIn)
+
+	// but not after opting out
+	optedIn = false
+	spec.Telemetry.Enabled = false
+	assert.Nil(t, checker.checkTelemetry())
+	assert.False(t, optedIn)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	chronyContainerName = "chrony"
+	defaultNTPPool      = "pool.ntp.org"
+	healthOK            = "HEALTH_OK"
+)
+
+// ClockSkew is a mon whose clock is not synchronized with the clock of the leader of the mons
+type ClockSkew struct {
+	Mon  string
+	Node string
+	// Skew is the offset in seconds of the clock of the mon
+	Skew    string
+	Details string
+}
+
+// ClockSkews returns the mons whose clocks are skewed according to the time checks of the leader of the mons, with the
+// nodes running them
+func (c *Cluster) ClockSkews() ([]ClockSkew, error) {
+	status, err := client.GetMonTimeStatus(c.context, c.clusterInfo.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var skews []ClockSkew
+	for name, mon := range status.Skew {
+		if mon.Health == healthOK || mon.Health == "" {
+			continue
+		}
+		skew := ClockSkew{Mon: name, Skew: mon.Skew.String(), Details: mon.Details}
+		if node, ok := c.mapping.Node[name]; ok {
+			skew.Node = node.Name
+		}
+		skews = append(skews, skew)
+	}
+	sort.Slice(skews, func(i, j int) bool { return skews[i].Mon < skews[j].Mon })
+	return skews, nil
+}
+
+// makeChronyContainer runs chronyd next to the mon to synchronize the clock of the node. The clock is not namespaced, so
+// the container only needs the capability to set the time of the host.
+func (c *Cluster) makeChronyContainer() v1.Container {
+	servers := c.Chrony.Servers
+	directives := []string{}
+	if len(servers) == 0 {
+		directives = append(directives, fmt.Sprintf("pool %s iburst", defaultNTPPool))
+	}
+	for _, server := range servers {
+		directives = append(directives, fmt.Sprintf("server %s iburst", server))
+	}
+	// step the clock at any time instead of slewing it when the offset is larger than a second, the mons would
+	// report the skew for hours while a large offset is slewed
+	directives = append(directives, "makestep 1.0 -1")
+
+	return v1.Container{
+		Name:    chronyContainerName,
+		Image:   c.Chrony.Image,
+		Command: []string{"chronyd"},
+		// the directives on the command line replace the config file of the image
+		Args: append([]string{"-d"}, directives...),
+		SecurityContext: &v1.SecurityContext{
+			Capabilities: &v1.Capabilities{Add: []v1.Capability{"SYS_TIME"}},
+		},
+	}
+}
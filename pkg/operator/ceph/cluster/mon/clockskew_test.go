@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClockSkews(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "time-sync-status" {
+				return `{"time_skew_status":{
+					"a":{"skew":0.000000,"latency":0.000000,"health":"HEALTH_OK"},
+					"b":{"skew":-0.204060,"latency":0.000680,"health":"HEALTH_WARN","details":"clock skew 0.20406s > max 0.05s"}},
+					"timechecks":{"epoch":10,"round":4,"round_status":"finished"}}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: test.New(1), Executor: executor}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3},
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.mapping.Node["b"] = &NodeInfo{Name: "node2"}
+
+	skews, err := c.ClockSkews()
+	assert.Nil(t, err)
+	require.Equal(t, 1, len(skews))
+	assert.Equal(t, ClockSkew{Mon: "b", Node: "node2", Skew: "-0.204060", Details: "clock skew 0.20406s > max 0.05s"}, skews[0])
+}
+
+func TestChronyContainer(t *testing.T) {
+	c := New(&clusterd.Context{}, "ns", "", "myversion", cephv1.CephVersionSpec{},
+		cephv1.MonSpec{Count: 3, Chrony: &cephv1.ChronySpec{Image: "chrony:1"}},
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
+
+	// the pool is used without servers
+	cont := c.makeChronyContainer()
+	assert.Equal(t, "chrony:1", cont.Image)
+	assert.Equal(t, []string{"-d", "pool pool.ntp.org iburst", "makestep 1.0 -1"}, cont.Args)
+	assert.Equal(t, []v1.Capability{"SYS_TIME"}, cont.SecurityContext.Capabilities.Add)
+
+	c.Chrony.Servers = []string{"ntp1.local", "ntp2.local"}
+	cont = c.makeChronyContainer()
+	assert.Equal(t, []string{"-d", "server ntp1.local iburst", "server ntp2.local iburst", "makestep 1.0 -1"}, cont.Args)
+}
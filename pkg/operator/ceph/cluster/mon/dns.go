@@ -0,0 +1,115 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DNSServiceName is the name of the headless service whose DNS records resolve to the endpoints of all the mons
+	DNSServiceName = appName
+	// DNSPortName is the name of the port of the mons in the SRV records of the service
+	DNSPortName = "ceph-mon"
+	// DNSSRVNameKey is the name of the key inside the mon configmap with the mon_dns_srv_name setting of the clients
+	DNSSRVNameKey = "dnsSrvName"
+)
+
+// DNSSRVName returns the mon_dns_srv_name setting with which the clients in the cluster find the mons from the SRV
+// records of the mon service, instead of a mon_host setting that must be updated when a mon fails over
+func DNSSRVName(namespace string) string {
+	return fmt.Sprintf("%s_%s.%s.svc", DNSPortName, DNSServiceName, namespace)
+}
+
+// updateDNSService creates or updates the headless service of the mons and sets its endpoints to the public
+// addresses of the mons. The mons do not accept the connections to their pod ip since they advertise the ip of their
+// own service, so the endpoints are maintained by the operator instead of a selector.
+func (c *Cluster) updateDNSService() error {
+	s := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DNSServiceName,
+			Namespace: c.Namespace,
+			Labels:    opspec.AppLabels(appName, c.Namespace),
+		},
+		Spec: v1.ServiceSpec{
+			ClusterIP: v1.ClusterIPNone,
+			Ports:     []v1.ServicePort{{Name: DNSPortName, Port: int32(mondaemon.DefaultPort), Protocol: v1.ProtocolTCP}},
+		},
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &s.ObjectMeta, &c.ownerRef)
+	if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Create(s); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create mon dns service. %+v", err)
+	}
+
+	endpoints := &v1.Endpoints{
+		ObjectMeta: s.ObjectMeta,
+		Subsets:    c.dnsEndpointSubsets(),
+	}
+	if _, err := c.context.Clientset.CoreV1().Endpoints(c.Namespace).Create(endpoints); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create mon dns endpoints. %+v", err)
+		}
+		if _, err := c.context.Clientset.CoreV1().Endpoints(c.Namespace).Update(endpoints); err != nil {
+			return fmt.Errorf("failed to update mon dns endpoints. %+v", err)
+		}
+	}
+	return nil
+}
+
+// dnsEndpointSubsets groups the public addresses of the mons by port, which differ on the host network. The mons
+// whose address is not known yet are skipped.
+func (c *Cluster) dnsEndpointSubsets() []v1.EndpointSubset {
+	var names []string
+	for name := range c.clusterInfo.Monitors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var subsets []v1.EndpointSubset
+	subsetIndex := map[int32]int{}
+	for _, name := range names {
+		host, portStr, err := net.SplitHostPort(c.clusterInfo.Monitors[name].Endpoint)
+		if err != nil || host == "" {
+			continue
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			continue
+		}
+
+		i, ok := subsetIndex[int32(port)]
+		if !ok {
+			i = len(subsets)
+			subsetIndex[int32(port)] = i
+			subsets = append(subsets, v1.EndpointSubset{
+				Ports: []v1.EndpointPort{{Name: DNSPortName, Port: int32(port), Protocol: v1.ProtocolTCP}},
+			})
+		}
+		subsets[i].Addresses = append(subsets[i].Addresses, v1.EndpointAddress{IP: host, Hostname: name})
+	}
+	return subsets
+}
@@ -29,6 +29,14 @@ func EndpointEnvVar() v1.EnvVar {
 	return v1.EnvVar{Name: "ROOK_MON_ENDPOINTS", ValueFrom: &v1.EnvVarSource{ConfigMapKeyRef: ref}}
 }
 
+// DNSSRVNameEnvVar is the env var with the name of the SRV records of the mons. The key is optional for the clients
+// started before the operator published it, which then find the mons from their endpoints.
+func DNSSRVNameEnvVar() v1.EnvVar {
+	optional := true
+	ref := &v1.ConfigMapKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: EndpointConfigMapName}, Key: DNSSRVNameKey, Optional: &optional}
+	return v1.EnvVar{Name: "ROOK_MON_DNS_SRV_NAME", ValueFrom: &v1.EnvVarSource{ConfigMapKeyRef: ref}}
+}
+
 // SecretEnvVar is the mon secret environment var
 func SecretEnvVar() v1.EnvVar {
 	ref := &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: appName}, Key: monSecretName}
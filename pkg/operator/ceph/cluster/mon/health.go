@@ -39,6 +39,11 @@ var (
 // HealthChecker aggregates the mon/cluster info needed to check the health of the monitors
 type HealthChecker struct {
 	monCluster *Cluster
+	// Paused returns whether the mons must not be failed over, such as in maintenance mode
+	Paused func() bool
+	// ClockSkewed reports the mons with a clock skew after each health check, an empty list when the clocks are
+	// synchronized
+	ClockSkewed func(skews []ClockSkew)
 }
 
 // NewHealthChecker creates a new HealthChecker object
@@ -57,15 +62,35 @@ func (hc *HealthChecker) Check(stopCh chan struct{}) {
 			return
 
 		case <-time.After(HealthCheckInterval):
+			if hc.Paused != nil && hc.Paused() {
+				logger.Debugf("the health check of the mons is paused")
+				continue
+			}
 			logger.Debugf("checking health of mons")
 			err := hc.monCluster.checkHealth()
 			if err != nil {
 				logger.Infof("failed to check mon health. %+v", err)
 			}
+			hc.checkClockSkew()
 		}
 	}
 }
 
+func (hc *HealthChecker) checkClockSkew() {
+	if hc.ClockSkewed == nil {
+		return
+	}
+	skews, err := hc.monCluster.ClockSkews()
+	if err != nil {
+		logger.Infof("failed to check the clock skew of the mons. %+v", err)
+		return
+	}
+	for _, skew := range skews {
+		logger.Warningf("mon %s on node %s has a clock skew of %ss. %s", skew.Mon, skew.Node, skew.Skew, skew.Details)
+	}
+	hc.ClockSkewed(skews)
+}
+
 func (c *Cluster) checkHealth() error {
 	logger.Debugf("Checking health for mons (desired=%d). %+v", c.Count, c.clusterInfo)
 
@@ -149,6 +174,11 @@ func (c *Cluster) checkHealth() error {
 	// after all unhealthy mons have been removed/failovered
 	// handle all mons that haven't been in the Ceph mon map
 	for mon := range monsNotFound {
+		if c.importedMons[mon] {
+			// the imported mon was removed from the cluster, rook does not replace it
+			logger.Infof("imported mon %s NOT found in ceph mon map, forgetting it", mon)
+			return c.removeMon(mon)
+		}
 		logger.Warningf("mon %s NOT found in ceph mon map, failover", mon)
 		c.failMon(len(c.clusterInfo.Monitors), desiredMonCount, mon)
 		// only deal with one "not found in ceph mon map" mon per health check
@@ -168,6 +198,10 @@ func (c *Cluster) checkHealth() error {
 		return err
 	}
 
+	if len(c.importedMons) > 0 {
+		return c.retireImportedMons(desiredMonCount, allMonsInQuorum)
+	}
+
 	// create/start new mons when there are fewer mons than the desired count in the CRD
 	if len(status.MonMap.Mons) < desiredMonCount {
 		logger.Infof("adding mons. currently %d mons are in quorum and the desired count is %d.", len(status.MonMap.Mons), desiredMonCount)
@@ -266,7 +300,7 @@ func (c *Cluster) failoverMon(name string) error {
 	mConf := []*monConfig{m}
 
 	// Assign the pod to a node
-	if err = c.assignMons(mConf); err != nil {
+	if err = c.assignMonsReplacing(mConf, name); err != nil {
 		return fmt.Errorf("failed to place new mon on a node. %+v", err)
 	}
 
@@ -314,6 +348,7 @@ func (c *Cluster) removeMon(daemonName string) error {
 		return fmt.Errorf("failed to remove mon %s from quorum. %+v", daemonName, err)
 	}
 	delete(c.clusterInfo.Monitors, daemonName)
+	delete(c.importedMons, daemonName)
 	// check if a mapping exists for the mon
 	if _, ok := c.mapping.Node[daemonName]; ok {
 		nodeName := c.mapping.Node[daemonName].Name
@@ -58,7 +58,7 @@ func TestCheckHealth(t *testing.T) {
 		Executor:  executor,
 	}
 	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(1)
 	logger.Infof("initial mons: %v", c.clusterInfo.Monitors)
 	c.waitForStart = false
@@ -111,7 +111,7 @@ func TestCheckHealthNotFound(t *testing.T) {
 		Executor:  executor,
 	}
 	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(2)
 	c.waitForStart = false
 	defer os.RemoveAll(c.context.ConfigDir)
@@ -183,7 +183,7 @@ func TestCheckHealthTwoMonsOneNode(t *testing.T) {
 		Executor:  executor,
 	}
 	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(2)
 	c.waitForStart = false
 	defer os.RemoveAll(c.context.ConfigDir)
@@ -294,7 +294,7 @@ func TestCheckMonsValid(t *testing.T) {
 		Executor:  executor,
 	}
 	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(1)
 	c.waitForStart = false
 	defer os.RemoveAll(c.context.ConfigDir)
@@ -382,7 +382,7 @@ func TestAddRemoveMons(t *testing.T) {
 		Executor:  executor,
 	}
 	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 5, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.maxMonID = 0
 	c.clusterInfo = test.CreateConfigDir(0)
 	c.waitForStart = false
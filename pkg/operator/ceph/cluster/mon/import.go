@@ -0,0 +1,160 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ImportedMonsKey is the name of the key inside the mon configmap with the mons of an imported cluster, which are
+	// not run by rook
+	ImportedMonsKey = "imported"
+)
+
+// ImportResources returns the secret and the config map of the mons with which the operator takes over an existing
+// cluster. The mons of the cluster are kept as imported mons until the rook mons have joined their quorum.
+func ImportResources(namespace string, clusterInfo *cephconfig.ClusterInfo) (*v1.Secret, *v1.ConfigMap, error) {
+	if len(clusterInfo.Monitors) == 0 {
+		return nil, nil, fmt.Errorf("no mons to import")
+	}
+
+	// the rook mons are named after the max mon id, which must be above the ids of the imported mons with rook names
+	maxMonID := -1
+	imported := map[string]bool{}
+	for name := range clusterInfo.Monitors {
+		if id, err := k8sutil.NameToIndex(name); err == nil && id > maxMonID {
+			maxMonID = id
+		}
+		imported[name] = true
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: namespace,
+		},
+		StringData: map[string]string{
+			clusterSecretName: namespace,
+			fsidSecretName:    clusterInfo.FSID,
+			monSecretName:     clusterInfo.MonitorSecret,
+			adminSecretName:   clusterInfo.AdminSecret,
+		},
+		Type: k8sutil.RookType,
+	}
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      EndpointConfigMapName,
+			Namespace: namespace,
+		},
+		Data: map[string]string{
+			EndpointDataKey: mondaemon.FlattenMonEndpoints(clusterInfo.Monitors),
+			MaxMonIDKey:     strconv.Itoa(maxMonID),
+			MappingKey:      `{"node":{},"port":{}}`,
+			ImportedMonsKey: flattenImportedMons(imported),
+		},
+	}
+	return secret, configMap, nil
+}
+
+// loadImportedMons returns the mons of an imported cluster that have not been retired yet
+func loadImportedMons(clientset kubernetes.Interface, namespace string) (map[string]bool, error) {
+	imported := map[string]bool{}
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(EndpointConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return imported, nil
+		}
+		return nil, err
+	}
+	for _, name := range strings.Split(cm.Data[ImportedMonsKey], ",") {
+		if name != "" {
+			imported[name] = true
+		}
+	}
+	return imported, nil
+}
+
+func flattenImportedMons(imported map[string]bool) string {
+	var names []string
+	for name := range imported {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// managedMonCount returns the number of mons run by rook
+func (c *Cluster) managedMonCount() int {
+	return len(c.clusterInfo.Monitors) - len(c.importedMons)
+}
+
+// importedMonPort returns the highest port of the imported mons listening on an address of the node, or 0 if none of
+// them runs on the node. The imported mons keep their port until they are retired, so the rook mons on the host network
+// of the same node must listen on a higher port.
+func (c *Cluster) importedMonPort(node v1.Node) int32 {
+	addresses := map[string]bool{}
+	for _, address := range node.Status.Addresses {
+		addresses[address.Address] = true
+	}
+
+	var port int32
+	for name := range c.importedMons {
+		mon, ok := c.clusterInfo.Monitors[name]
+		if !ok {
+			continue
+		}
+		host, _, err := net.SplitHostPort(mon.Endpoint)
+		if err != nil || !addresses[host] {
+			continue
+		}
+		if p := getPortFromEndpoint(mon.Endpoint); p > port {
+			port = p
+		}
+	}
+	return port
+}
+
+// retireImportedMons starts the rook mons of an imported cluster, then removes the imported mons from the quorum one
+// at a time once all the mons are in quorum. The imported mon daemons must be stopped once removed.
+func (c *Cluster) retireImportedMons(desiredMonCount int, allMonsInQuorum bool) error {
+	if c.managedMonCount() < desiredMonCount {
+		logger.Infof("adding mons. currently %d rook mons are running and the desired count is %d.", c.managedMonCount(), desiredMonCount)
+		return c.startMons()
+	}
+	if !allMonsInQuorum {
+		logger.Infof("waiting for all the mons to be in quorum before retiring the imported mons")
+		return nil
+	}
+
+	name := strings.Split(flattenImportedMons(c.importedMons), ",")[0]
+	logger.Infof("retiring imported mon %s. %d imported mons remaining", name, len(c.importedMons)-1)
+	return c.removeMon(name)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestImportResources(t *testing.T) {
+	clusterInfo := &cephconfig.ClusterInfo{
+		FSID:          "4bfe8b72-5e69-4330-b6c0-4d914db8ab89",
+		MonitorSecret: "monsecret",
+		AdminSecret:   "adminsecret",
+		Monitors: map[string]*cephconfig.MonInfo{
+			"mon1": {Name: "mon1", Endpoint: "10.0.0.1:6789"},
+			"ceph": {Name: "ceph", Endpoint: "10.0.0.2:6789"},
+		},
+	}
+	secret, configMap, err := ImportResources("rook-ceph", clusterInfo)
+	assert.Nil(t, err)
+	assert.Equal(t, appName, secret.Name)
+	assert.Equal(t, "rook-ceph", secret.StringData[clusterSecretName])
+	assert.Equal(t, clusterInfo.FSID, secret.StringData[fsidSecretName])
+	assert.Equal(t, "monsecret", secret.StringData[monSecretName])
+	assert.Equal(t, "adminsecret", secret.StringData[adminSecretName])
+
+	assert.Equal(t, EndpointConfigMapName, configMap.Name)
+	assert.Equal(t, "ceph,mon1", configMap.Data[ImportedMonsKey])
+	assert.Equal(t, clusterInfo.Monitors, mondaemon.ParseMonEndpoints(configMap.Data[EndpointDataKey]))
+
+	// the rook mons are not given the name of an imported mon
+	id, _ := k8sutil.NameToIndex("ceph")
+	assert.Equal(t, strconv.Itoa(id), configMap.Data[MaxMonIDKey])
+
+	_, _, err = ImportResources("rook-ceph", &cephconfig.ClusterInfo{})
+	assert.NotNil(t, err)
+}
+
+func TestRetireImportedMons(t *testing.T) {
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	var c *Cluster
+	var removed []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "mon" && args[1] == "remove" {
+				removed = append(removed, args[2])
+			}
+			return clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors), nil
+		},
+	}
+	context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: executor}
+	c = New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.waitForStart = false
+	for _, name := range []string{"mon1", "mon2"} {
+		c.clusterInfo.Monitors[name] = &cephconfig.MonInfo{Name: name, Endpoint: "10.0.0.1:6789"}
+		c.importedMons[name] = true
+	}
+
+	// the imported mons are not run by rook
+	mons := c.initMonConfig(3)
+	assert.Equal(t, 3, len(mons))
+	for _, m := range mons {
+		assert.False(t, c.importedMons[m.DaemonName])
+	}
+
+	// the imported mons are retired one at a time once the rook mons are in quorum
+	err := c.checkHealth()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mon1"}, removed)
+	assert.Equal(t, 4, len(c.clusterInfo.Monitors))
+	cm, err := context.Clientset.CoreV1().ConfigMaps("ns").Get(EndpointConfigMapName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "mon2", cm.Data[ImportedMonsKey])
+
+	err = c.checkHealth()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mon1", "mon2"}, removed)
+	assert.Equal(t, 0, len(c.importedMons))
+	imported, err := loadImportedMons(context.Clientset, "ns")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(imported))
+
+	// an imported mon missing from the mon map is forgotten
+	c.clusterInfo.Monitors["mon3"] = &cephconfig.MonInfo{Name: "mon3", Endpoint: "10.0.0.3:6789"}
+	c.importedMons["mon3"] = true
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+		return clienttest.MonInQuorumResponseFromMons(test.CreateConfigDir(3).Monitors), nil
+	}
+	err = c.checkHealth()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(c.clusterInfo.Monitors))
+	assert.Equal(t, 0, len(c.importedMons))
+}
+
+func TestAssignMonsBesideImportedMons(t *testing.T) {
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: test.New(1), ConfigDir: configDir}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
+		rookalpha.Placement{}, true, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
+	c.clusterInfo = test.CreateConfigDir(0)
+
+	// an imported mon listens on the default port of the rook mons on node0, the other runs on a host outside of kubernetes
+	c.clusterInfo.Monitors["mon1"] = &cephconfig.MonInfo{Name: "mon1", Endpoint: "0.0.0.0:6790"}
+	c.clusterInfo.Monitors["mon2"] = &cephconfig.MonInfo{Name: "mon2", Endpoint: "10.0.0.2:6792"}
+	c.importedMons["mon1"] = true
+	c.importedMons["mon2"] = true
+
+	mons := []*monConfig{
+		{ResourceName: "rook-ceph-mon-a", DaemonName: "a", Port: mondaemon.DefaultPort},
+		{ResourceName: "rook-ceph-mon-b", DaemonName: "b", Port: mondaemon.DefaultPort},
+	}
+	err := c.assignMons(mons)
+	assert.Nil(t, err)
+	err = c.initMonIPs(mons)
+	assert.Nil(t, err)
+
+	// the rook mons on the host network do not take the port of the imported mon
+	assert.Equal(t, "node0", c.mapping.Node["a"].Name)
+	assert.Equal(t, "node0", c.mapping.Node["b"].Name)
+	assert.Equal(t, strconv.Itoa(mondaemon.DefaultPort+1), strings.Split(c.clusterInfo.Monitors["a"].Endpoint, ":")[1])
+	assert.Equal(t, strconv.Itoa(mondaemon.DefaultPort+2), strings.Split(c.clusterInfo.Monitors["b"].Endpoint, ":")[1])
+	assert.Equal(t, int32(mondaemon.DefaultPort+2), c.mapping.Port["node0"])
+}
@@ -48,6 +48,8 @@ var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-mon")
 const (
 	// EndpointConfigMapName is the name of the configmap with mon endpoints
 	EndpointConfigMapName = "rook-ceph-mon-endpoints"
+	// SecretName is the name of the secret with the fsid and the keys of the cluster
+	SecretName = appName
 	// EndpointDataKey is the name of the key inside the mon configmap to get the endpoints
 	EndpointDataKey = "data"
 	// MaxMonIDKey is the name of the max mon id used
@@ -70,6 +72,9 @@ const (
 	MaxMonCount = 9
 )
 
+// SecretKeyNames are the entries of the mon secret with the keys of the cluster
+var SecretKeyNames = []string{monSecretName, adminSecretName}
+
 // Cluster represents the Rook and environment configuration settings needed to set up Ceph mons.
 type Cluster struct {
 	context              *clusterd.Context
@@ -79,6 +84,10 @@ type Cluster struct {
 	cephVersion          cephv1.CephVersionSpec
 	Count                int
 	AllowMultiplePerNode bool
+	DownOutInterval      int
+	DownOutSubtreeLimit  string
+	ZoneLabel            string
+	StrictZoneSpread     bool
 	MonCountMutex        sync.Mutex
 	Port                 int32
 	clusterInfo          *cephconfig.ClusterInfo
@@ -90,9 +99,16 @@ type Cluster struct {
 	monPodTimeout        time.Duration
 	monTimeoutList       map[string]time.Time
 	HostNetwork          bool
-	mapping              *Mapping
-	resources            v1.ResourceRequirements
-	ownerRef             metav1.OwnerReference
+	// The user-defined containers and volumes added to the mon pods
+	PodExtension rookalpha.PodExtension
+	// The chrony sidecar of the mons, nil if the clocks of the nodes are synchronized by the hosts
+	Chrony  *cephv1.ChronySpec
+	mapping *Mapping
+	// The mons of an imported cluster that are retired once the rook mons are in quorum
+	importedMons  map[string]bool
+	resources     v1.ResourceRequirements
+	livenessProbe cephv1.ProbeSpec
+	ownerRef      metav1.OwnerReference
 }
 
 // monConfig for a single monitor
@@ -122,7 +138,7 @@ type NodeInfo struct {
 
 // New creates an instance of a mon cluster
 func New(context *clusterd.Context, namespace, dataDirHostPath, rookVersion string, cephVersion cephv1.CephVersionSpec, mon cephv1.MonSpec,
-	placement rookalpha.Placement, hostNetwork bool, resources v1.ResourceRequirements, ownerRef metav1.OwnerReference) *Cluster {
+	placement rookalpha.Placement, hostNetwork bool, resources v1.ResourceRequirements, livenessProbe cephv1.ProbeSpec, ownerRef metav1.OwnerReference) *Cluster {
 	return &Cluster{
 		context:              context,
 		placement:            placement,
@@ -132,6 +148,11 @@ func New(context *clusterd.Context, namespace, dataDirHostPath, rookVersion stri
 		cephVersion:          cephVersion,
 		Count:                mon.Count,
 		AllowMultiplePerNode: mon.AllowMultiplePerNode,
+		DownOutInterval:      mon.DownOutInterval,
+		DownOutSubtreeLimit:  mon.DownOutSubtreeLimit,
+		ZoneLabel:            mon.ZoneLabel,
+		StrictZoneSpread:     mon.StrictZoneSpread,
+		Chrony:               mon.Chrony,
 		maxMonID:             -1,
 		waitForStart:         true,
 		monPodRetryInterval:  6 * time.Second,
@@ -142,8 +163,10 @@ func New(context *clusterd.Context, namespace, dataDirHostPath, rookVersion stri
 			Node: map[string]*NodeInfo{},
 			Port: map[string]int32{},
 		},
-		resources: resources,
-		ownerRef:  ownerRef,
+		importedMons:  map[string]bool{},
+		resources:     resources,
+		livenessProbe: livenessProbe,
+		ownerRef:      ownerRef,
 	}
 }
 
@@ -151,12 +174,40 @@ func New(context *clusterd.Context, namespace, dataDirHostPath, rookVersion stri
 func (c *Cluster) Start() error {
 	logger.Infof("start running mons")
 
+	if c.Chrony != nil && c.Chrony.Image == "" {
+		return fmt.Errorf("the image of the chrony sidecar of the mons is required")
+	}
+
 	if err := c.initClusterInfo(); err != nil {
 		return fmt.Errorf("failed to initialize ceph cluster info. %+v", err)
 	}
 
 	// create the mons for a new cluster or ensure mons are running in an existing cluster
-	return c.startMons()
+	if err := c.startMons(); err != nil {
+		return err
+	}
+
+	return c.configureDownOut()
+}
+
+// configureDownOut applies the settings that control when the mons mark the down osds out. The settings are applied
+// each time the mons are started since luminous does not keep them when the mons restart.
+func (c *Cluster) configureDownOut() error {
+	settings := map[string]string{}
+	if c.DownOutInterval > 0 {
+		settings["mon_osd_down_out_interval"] = strconv.Itoa(c.DownOutInterval)
+	}
+	if c.DownOutSubtreeLimit != "" {
+		settings["mon_osd_down_out_subtree_limit"] = c.DownOutSubtreeLimit
+	}
+
+	for key, val := range settings {
+		logger.Infof("setting mon config %s to %s", key, val)
+		if err := client.MonSetAllConfig(c.context, c.Namespace, c.cephVersion.Name, key, val); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (c *Cluster) startMons() error {
@@ -171,7 +222,7 @@ func (c *Cluster) startMons() error {
 	// Start one monitor at a time
 	for i := 0; i < c.Count; i++ {
 		logger.Infof("ensuring mon %s (%s) is started", mons[i].ResourceName, mons[i].DaemonName)
-		endIndex := len(c.clusterInfo.Monitors)
+		endIndex := c.managedMonCount()
 		if endIndex < c.Count {
 			endIndex++
 		}
@@ -211,6 +262,9 @@ func (c *Cluster) initClusterInfo() error {
 	if err != nil {
 		return fmt.Errorf("failed to get cluster info. %+v", err)
 	}
+	if c.importedMons, err = loadImportedMons(c.context.Clientset, c.Namespace); err != nil {
+		return fmt.Errorf("failed to get the imported mons. %+v", err)
+	}
 
 	// save cluster monitor config
 	if err = c.saveMonConfig(); err != nil {
@@ -225,11 +279,15 @@ func (c *Cluster) initMonConfig(size int) []*monConfig {
 
 	// initialize the mon pod info for mons that have been previously created
 	for _, monitor := range c.clusterInfo.Monitors {
+		if c.importedMons[monitor.Name] {
+			// the mons of an imported cluster are not run by rook
+			continue
+		}
 		mons = append(mons, &monConfig{ResourceName: resourceName(monitor.Name), DaemonName: monitor.Name, Port: getPortFromEndpoint(monitor.Endpoint)})
 	}
 
 	// initialize mon info if we don't have enough mons (at first startup)
-	for i := len(c.clusterInfo.Monitors); i < size; i++ {
+	for i := len(mons); i < size; i++ {
 		c.maxMonID++
 		mons = append(mons, newMonConfig(c.maxMonID))
 	}
@@ -317,12 +375,27 @@ func (c *Cluster) createService(mon *monConfig) (string, error) {
 }
 
 func (c *Cluster) assignMons(mons []*monConfig) error {
+	return c.assignMonsReplacing(mons, "")
+}
+
+// assignMonsReplacing assigns the mons to the nodes, not counting the mon being replaced by a failover in the zone of
+// its node so the new mon can take its place even with the strict spreading
+func (c *Cluster) assignMonsReplacing(mons []*monConfig, replacedMon string) error {
 	// schedule the mons on different nodes if we have enough nodes to be unique
 	availableNodes, err := c.getMonNodes()
 	if err != nil {
 		return fmt.Errorf("failed to get available nodes for mons. %+v", err)
 	}
 
+	// spread the mons across the zones of the nodes
+	monsPerZone, zones, err := c.getMonZones(replacedMon)
+	if err != nil {
+		return fmt.Errorf("failed to get the zones of the mons. %+v", err)
+	}
+	if c.StrictZoneSpread && c.Count > zones.Count() {
+		return fmt.Errorf("cannot spread %d mons across %d zones with the label %s", c.Count, zones.Count(), c.zoneLabel())
+	}
+
 	nodeIndex := 0
 	for _, m := range mons {
 		if _, ok := c.mapping.Node[m.DaemonName]; ok {
@@ -336,7 +409,11 @@ func (c *Cluster) assignMons(mons []*monConfig) error {
 		}
 
 		// pick one of the available nodes where the mon will be assigned
-		node := availableNodes[nodeIndex%len(availableNodes)]
+		node, ok := c.pickMonNode(availableNodes, nodeIndex, monsPerZone)
+		if !ok {
+			return fmt.Errorf("no nodes available for mon placement in a zone without mons")
+		}
+		monsPerZone[c.nodeZone(node)]++
 		logger.Debugf("mon %s assigned to node %s", m.DaemonName, node.Name)
 		nodeInfo, err := getNodeInfoFromNode(node)
 		if err != nil {
@@ -349,6 +426,9 @@ func (c *Cluster) assignMons(mons []*monConfig) error {
 				// assignment and that the node was chosen
 				m.Port = c.mapping.Port[node.Name] + int32(1)
 			}
+			if port := c.importedMonPort(node); port >= m.Port {
+				m.Port = port + int32(1)
+			}
 			c.mapping.Port[node.Name] = m.Port
 		}
 		c.mapping.Node[m.DaemonName] = nodeInfo
@@ -359,6 +439,16 @@ func (c *Cluster) assignMons(mons []*monConfig) error {
 	return nil
 }
 
+// Plan assigns the mons of a new cluster to the nodes like the operator would, without creating anything, and returns
+// the nodes keyed by mon name
+func (c *Cluster) Plan() (map[string]*NodeInfo, error) {
+	c.clusterInfo = &cephconfig.ClusterInfo{Monitors: map[string]*cephconfig.MonInfo{}}
+	if err := c.assignMons(c.initMonConfig(c.Count)); err != nil {
+		return nil, err
+	}
+	return c.mapping.Node, nil
+}
+
 func getNodeInfoFromNode(n v1.Node) (*NodeInfo, error) {
 	nr := &NodeInfo{
 		Name:     n.Name,
@@ -434,6 +524,15 @@ func (c *Cluster) saveMonConfig() error {
 		EndpointDataKey: mondaemon.FlattenMonEndpoints(c.clusterInfo.Monitors),
 		MaxMonIDKey:     strconv.Itoa(c.maxMonID),
 		MappingKey:      string(monMapping),
+		DNSSRVNameKey:   DNSSRVName(c.Namespace),
+	}
+	if len(c.importedMons) > 0 {
+		configMap.Data[ImportedMonsKey] = flattenImportedMons(c.importedMons)
+	}
+
+	// the records of the dns service are up to date before the clients look up the name published in the configmap
+	if err := c.updateDNSService(); err != nil {
+		return err
 	}
 
 	if _, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Create(configMap); err != nil {
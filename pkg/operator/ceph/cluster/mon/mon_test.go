@@ -31,6 +31,7 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
 	cephtest "github.com/rook/rook/pkg/daemon/ceph/test"
 	"github.com/rook/rook/pkg/operator/test"
@@ -168,7 +169,7 @@ func TestSaveMonEndpoints(t *testing.T) {
 	defer os.RemoveAll(configDir)
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: configDir}, "ns", "", "myversion", cephv1.CephVersionSpec{},
 		cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{}, false,
-		v1.ResourceRequirements{}, metav1.OwnerReference{})
+		v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(1)
 
 	// create the initial config map
@@ -180,6 +181,19 @@ func TestSaveMonEndpoints(t *testing.T) {
 	assert.Equal(t, "a=1.2.3.1:6790", cm.Data[EndpointDataKey])
 	assert.Equal(t, `{"node":{},"port":{}}`, cm.Data[MappingKey])
 	assert.Equal(t, "-1", cm.Data[MaxMonIDKey])
+	assert.Equal(t, "ceph-mon_rook-ceph-mon.ns.svc", cm.Data[DNSSRVNameKey])
+
+	// the mon dns service resolves to the public addresses of the mons
+	svc, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get(DNSServiceName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, v1.ClusterIPNone, svc.Spec.ClusterIP)
+	assert.Nil(t, svc.Spec.Selector)
+	ep, err := c.context.Clientset.CoreV1().Endpoints(c.Namespace).Get(DNSServiceName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []v1.EndpointSubset{{
+		Addresses: []v1.EndpointAddress{{IP: "1.2.3.1", Hostname: "a"}},
+		Ports:     []v1.EndpointPort{{Name: "ceph-mon", Port: 6790, Protocol: v1.ProtocolTCP}},
+	}}, ep.Subsets)
 
 	// update the config map
 	c.clusterInfo.Monitors["a"].Endpoint = "2.3.4.5:6790"
@@ -198,6 +212,24 @@ func TestSaveMonEndpoints(t *testing.T) {
 	assert.Equal(t, "a=2.3.4.5:6790", cm.Data[EndpointDataKey])
 	assert.Equal(t, `{"node":{"a":{"Name":"node0","Hostname":"myhost","Address":"1.1.1.1"}},"port":{"node0":12345}}`, cm.Data[MappingKey])
 	assert.Equal(t, "2", cm.Data[MaxMonIDKey])
+
+	// the endpoints follow the mons, grouped by port on the host network
+	c.clusterInfo.Monitors["b"] = cephconfig.NewMonInfo("b", "1.1.1.2", 6791)
+	c.clusterInfo.Monitors["c"] = cephconfig.NewMonInfo("c", "", 6790)
+	err = c.saveMonConfig()
+	assert.Nil(t, err)
+	ep, err = c.context.Clientset.CoreV1().Endpoints(c.Namespace).Get(DNSServiceName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []v1.EndpointSubset{
+		{
+			Addresses: []v1.EndpointAddress{{IP: "2.3.4.5", Hostname: "a"}},
+			Ports:     []v1.EndpointPort{{Name: "ceph-mon", Port: 6790, Protocol: v1.ProtocolTCP}},
+		},
+		{
+			Addresses: []v1.EndpointAddress{{IP: "1.1.1.2", Hostname: "b"}},
+			Ports:     []v1.EndpointPort{{Name: "ceph-mon", Port: 6791, Protocol: v1.ProtocolTCP}},
+		},
+	}, ep.Subsets)
 }
 
 func TestMonInQuorum(t *testing.T) {
@@ -242,7 +274,7 @@ func TestAvailableMonNodes(t *testing.T) {
 	clientset := test.New(1)
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1.CephVersionSpec{},
 		cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 	nodes, err := c.getMonNodes()
 	assert.Nil(t, err)
@@ -269,7 +301,7 @@ func TestAvailableNodesInUse(t *testing.T) {
 	clientset := test.New(3)
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1.CephVersionSpec{},
 		cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	// all three nodes are available by default
@@ -310,7 +342,7 @@ func TestTaintedNodes(t *testing.T) {
 	clientset := test.New(3)
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1.CephVersionSpec{},
 		cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	nodes, err := c.getMonNodes()
@@ -345,7 +377,7 @@ func TestNodeAffinity(t *testing.T) {
 	clientset := test.New(3)
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1.CephVersionSpec{},
 		cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	nodes, err := c.getMonNodes()
@@ -387,7 +419,7 @@ func TestHostNetwork(t *testing.T) {
 	clientset := test.New(3)
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1.CephVersionSpec{},
 		cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	c.HostNetwork = true
@@ -419,7 +451,7 @@ func TestGetNodeInfoFromNode(t *testing.T) {
 
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1.CephVersionSpec{},
 		cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{},
-		true, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		true, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	var info *NodeInfo
@@ -447,7 +479,7 @@ func TestHostNetworkPortIncrease(t *testing.T) {
 	defer os.RemoveAll(configDir)
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: configDir},
 		"ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
-		rookalpha.Placement{}, true, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.Placement{}, true, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
 	c.clusterInfo = test.CreateConfigDir(0)
 
 	mons := []*monConfig{
@@ -478,3 +510,59 @@ func TestHostNetworkPortIncrease(t *testing.T) {
 	sEndpoint = strings.Split(c.clusterInfo.Monitors["b"].Endpoint, ":")
 	assert.Equal(t, strconv.Itoa(mondaemon.DefaultPort+1), sEndpoint[1])
 }
+
+func TestConfigureDownOut(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			commands = append(commands, args)
+			return "", nil
+		},
+	}
+	c := newCluster(&clusterd.Context{Executor: executor}, "ns", false, v1.ResourceRequirements{})
+
+	// nothing is set by default
+	assert.Nil(t, c.configureDownOut())
+	assert.Equal(t, 0, len(commands))
+
+	// the settings are stored in the mon config database
+	c.cephVersion = cephv1.CephVersionSpec{Name: cephv1.Mimic}
+	c.DownOutInterval = 900
+	c.DownOutSubtreeLimit = "rack"
+	assert.Nil(t, c.configureDownOut())
+	assert.Equal(t, 2, len(commands))
+	set := map[string]string{}
+	for _, args := range commands {
+		assert.Equal(t, []string{"config", "set", "mon"}, args[0:3])
+		set[args[3]] = args[4]
+	}
+	assert.Equal(t, map[string]string{"mon_osd_down_out_interval": "900", "mon_osd_down_out_subtree_limit": "rack"}, set)
+
+	// luminous injects the settings in the running mons
+	commands = nil
+	c.cephVersion = cephv1.CephVersionSpec{Name: cephv1.Luminous}
+	c.DownOutSubtreeLimit = ""
+	assert.Nil(t, c.configureDownOut())
+	assert.Equal(t, 1, len(commands))
+	assert.Equal(t, []string{"tell", "mon.*", "injectargs", "--mon_osd_down_out_interval=900"}, commands[0][0:4])
+}
+
+func TestPlan(t *testing.T) {
+	context := &clusterd.Context{Clientset: test.New(3)}
+	c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+	c.AllowMultiplePerNode = false
+
+	nodes, err := c.Plan()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(nodes))
+	assigned := map[string]bool{}
+	for _, node := range nodes {
+		assigned[node.Name] = true
+	}
+	assert.Equal(t, 3, len(assigned))
+
+	// nothing was created
+	deployments, err := context.Clientset.Extensions().Deployments("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(deployments.Items))
+}
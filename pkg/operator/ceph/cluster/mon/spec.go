@@ -19,7 +19,6 @@ package mon
 import (
 	"fmt"
 	"net"
-	"os"
 	"path"
 
 	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
@@ -97,10 +96,14 @@ func (c *Cluster) makeMonPod(monConfig *monConfig, hostname string) *v1.Pod {
 		Volumes:       opspec.PodVolumes(c.dataDirHostPath),
 		HostNetwork:   c.HostNetwork,
 	}
+	if c.Chrony != nil {
+		podSpec.Containers = append(podSpec.Containers, c.makeChronyContainer())
+	}
 	if c.HostNetwork {
 		podSpec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	}
 	c.placement.ApplyToPodSpec(&podSpec)
+	c.PodExtension.ApplyToPodSpec(&podSpec)
 	// remove Pod (anti-)affinity because we have our own placement logic
 	c.placement.PodAffinity = nil
 	c.placement.PodAntiAffinity = nil
@@ -124,11 +127,7 @@ func (c *Cluster) makeMonPod(monConfig *monConfig, hostname string) *v1.Pod {
 
 // Init and daemon containers require the same context, so we call it 'pod' context
 func podSecurityContext() *v1.SecurityContext {
-	privileged := false
-	if os.Getenv("ROOK_HOSTPATH_REQUIRES_PRIVILEGED") == "true" {
-		privileged = true
-	}
-	return &v1.SecurityContext{Privileged: &privileged}
+	return opspec.HostPathSecurityContext()
 }
 
 func (c *Cluster) makeConfigInitContainer(monConfig *monConfig) v1.Container {
@@ -143,7 +142,7 @@ func (c *Cluster) makeConfigInitContainer(monConfig *monConfig) v1.Container {
 			fmt.Sprintf("--fsid=%s", c.clusterInfo.FSID),
 		},
 		Image: k8sutil.MakeRookImage(c.rookVersion),
-		Env: []v1.EnvVar{
+		Env: append([]v1.EnvVar{
 			k8sutil.PodIPEnvVar(k8sutil.PrivateIPEnvVar),
 			{Name: k8sutil.PublicIPEnvVar, Value: monConfig.PublicIP},
 			ClusterNameEnvVar(c.Namespace),
@@ -151,7 +150,7 @@ func (c *Cluster) makeConfigInitContainer(monConfig *monConfig) v1.Container {
 			SecretEnvVar(),
 			AdminSecretEnvVar(),
 			k8sutil.ConfigOverrideEnvVar(),
-		},
+		}, k8sutil.LogFormatEnvVars(true)...),
 		VolumeMounts:    opspec.RookVolumeMounts(),
 		SecurityContext: podSecurityContext(),
 		Resources:       c.resources,
@@ -256,8 +255,9 @@ func (c *Cluster) makeMonDaemonContainer(monConfig *monConfig) v1.Container {
 				Protocol:      v1.ProtocolTCP,
 			},
 		},
-		Env:       k8sutil.ClusterDaemonEnvVars(),
-		Resources: c.resources,
+		Env:           k8sutil.ClusterDaemonEnvVars(),
+		Resources:     c.resources,
+		LivenessProbe: opspec.LivenessProbe("mon", monConfig.DaemonName, c.livenessProbe),
 	}
 }
 
@@ -18,6 +18,7 @@ package mon
 
 import (
 	"fmt"
+	"os"
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -65,7 +66,7 @@ func testPodSpec(t *testing.T, dataDir string) {
 				v1.ResourceMemory: *resource.NewQuantity(1337.0, resource.BinarySI),
 			},
 		},
-		metav1.OwnerReference{},
+		cephv1.ProbeSpec{}, metav1.OwnerReference{},
 	)
 	c.clusterInfo = testop.CreateConfigDir(0)
 	name := "a"
@@ -205,3 +206,20 @@ func testPodSpec(t *testing.T, dataDir string) {
 	assert.Equal(t, "100", cont.Resources.Limits.Cpu().String())
 	assert.Equal(t, "1337", cont.Resources.Requests.Memory().String())
 }
+
+func TestHostPathPrivilegedPodSpec(t *testing.T) {
+	os.Setenv("ROOK_HOSTPATH_REQUIRES_PRIVILEGED", "true")
+	defer os.Unsetenv("ROOK_HOSTPATH_REQUIRES_PRIVILEGED")
+
+	c := New(&clusterd.Context{Clientset: testop.New(1), ConfigDir: "/var/lib/rook"}, "ns", "/var/lib/mydatadir",
+		"rook/rook:myversion", cephv1.CephVersionSpec{Image: "ceph/ceph:myceph"}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
+	c.clusterInfo = testop.CreateConfigDir(0)
+	config := &monConfig{ResourceName: "a", DaemonName: "a", Port: 6790, PublicIP: "2.4.6.1"}
+
+	// the mon containers are privileged to write to the host path
+	pod := c.makeMonPod(config, "foo")
+	for _, cont := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		assert.True(t, *cont.SecurityContext.Privileged, cont.Name)
+	}
+}
@@ -153,6 +153,40 @@ func loadMonConfig(clientset kubernetes.Interface, namespace string) (map[string
 	return monEndpointMap, maxMonID, monMapping, nil
 }
 
+// StaleServices returns the services of the mons which are neither in the endpoints config map nor deployed, left by
+// a failover which did not complete. No service is returned if the endpoints config map has no mon.
+func StaleServices(clientset kubernetes.Interface, namespace string) ([]v1.Service, error) {
+	mons, _, _, err := loadMonConfig(clientset, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the mon endpoints. %+v", err)
+	}
+	if len(mons) == 0 {
+		return nil, nil
+	}
+
+	selector := fmt.Sprintf("%s=%s", k8sutil.AppAttr, appName)
+	services, err := clientset.CoreV1().Services(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the mon services. %+v", err)
+	}
+	stale := []v1.Service{}
+	for _, service := range services.Items {
+		daemonName := service.Labels["mon"]
+		if _, ok := mons[daemonName]; ok || daemonName == "" {
+			continue
+		}
+		_, err := clientset.ExtensionsV1beta1().Deployments(namespace).Get(service.Name, metav1.GetOptions{})
+		if err == nil {
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get mon deployment %s. %+v", service.Name, err)
+		}
+		stale = append(stale, service)
+	}
+	return stale, nil
+}
+
 func createClusterAccessSecret(clientset kubernetes.Interface, namespace string, clusterInfo *cephconfig.ClusterInfo, ownerRef *metav1.OwnerReference) error {
 	logger.Infof("creating mon secrets for a new cluster")
 	var err error
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func (c *Cluster) zoneLabel() string {
+	if c.ZoneLabel == "" {
+		return apis.LabelZoneFailureDomain
+	}
+	return c.ZoneLabel
+}
+
+// nodeZone returns the zone of the node, or an empty string when the node does not have the zone label
+func (c *Cluster) nodeZone(node v1.Node) string {
+	return node.Labels[c.zoneLabel()]
+}
+
+// getMonZones returns the number of mons already assigned to each zone, except the mon being replaced if any, and
+// the zones of the nodes where the mons can run
+func (c *Cluster) getMonZones(replacedMon string) (map[string]int, *util.Set, error) {
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	monsPerZone := map[string]int{}
+	zones := util.NewSet()
+	for _, node := range nodes.Items {
+		zone := c.nodeZone(node)
+		for mon, info := range c.mapping.Node {
+			if info.Name == node.Name && mon != replacedMon {
+				monsPerZone[zone]++
+			}
+		}
+
+		if zone == "" {
+			continue
+		}
+		valid, err := k8sutil.ValidNode(node, c.placement)
+		if err != nil {
+			logger.Warningf("failed to validate node %s %v", node.Name, err)
+		} else if valid {
+			zones.Add(zone)
+		}
+	}
+	return monsPerZone, zones, nil
+}
+
+// pickMonNode picks the node for a new mon in the zone with the fewest mons, starting from the given index to
+// rotate between the nodes of the same zone. With the strict spreading, only the nodes in a zone without mons are
+// picked.
+func (c *Cluster) pickMonNode(nodes []v1.Node, index int, monsPerZone map[string]int) (v1.Node, bool) {
+	best := -1
+	for i := 0; i < len(nodes); i++ {
+		n := (index + i) % len(nodes)
+		zone := c.nodeZone(nodes[n])
+		if c.StrictZoneSpread && (zone == "" || monsPerZone[zone] > 0) {
+			continue
+		}
+		if best == -1 || monsPerZone[zone] < monsPerZone[c.nodeZone(nodes[best])] {
+			best = n
+		}
+	}
+
+	if best == -1 {
+		return v1.Node{}, false
+	}
+	return nodes[best], true
+}
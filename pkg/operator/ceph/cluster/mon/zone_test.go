@@ -0,0 +1,159 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newZoneTestCluster(clientset *fake.Clientset, zones []string, label string) *Cluster {
+	for i, zone := range zones {
+		node, _ := clientset.CoreV1().Nodes().Get(fmt.Sprintf("node%d", i), metav1.GetOptions{})
+		if zone != "" {
+			node.Labels = map[string]string{label: zone}
+		}
+		clientset.CoreV1().Nodes().Update(node)
+	}
+
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1.CephVersionSpec{},
+		cephv1.MonSpec{Count: 3}, rookalpha.Placement{},
+		false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, metav1.OwnerReference{})
+	c.clusterInfo = test.CreateConfigDir(0)
+	return c
+}
+
+func testMons(count int) []*monConfig {
+	mons := []*monConfig{}
+	for i := 0; i < count; i++ {
+		name := k8sutil.IndexToName(i)
+		mons = append(mons, &monConfig{ResourceName: resourceName(name), DaemonName: name})
+	}
+	return mons
+}
+
+func TestAssignMonsAcrossZones(t *testing.T) {
+	clientset := test.New(4)
+	c := newZoneTestCluster(clientset, []string{"zone-a", "zone-a", "zone-b", "zone-c"}, "failure-domain.beta.kubernetes.io/zone")
+
+	// one mon in each zone even if the first nodes are in the same zone
+	err := c.assignMons(testMons(3))
+	assert.Nil(t, err)
+	assert.Equal(t, "node0", c.mapping.Node["a"].Name)
+	assert.Equal(t, "node2", c.mapping.Node["b"].Name)
+	assert.Equal(t, "node3", c.mapping.Node["c"].Name)
+
+	// the zones with the mons are counted when a new mon is assigned
+	monsPerZone, zones, err := c.getMonZones("")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, zones.Count())
+	assert.Equal(t, map[string]int{"zone-a": 1, "zone-b": 1, "zone-c": 1}, monsPerZone)
+
+	// the mon being replaced is not counted
+	monsPerZone, _, err = c.getMonZones("b")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"zone-a": 1, "zone-c": 1}, monsPerZone)
+}
+
+func TestAssignMonsWithoutZones(t *testing.T) {
+	// the nodes without the zone label are assigned in turn
+	clientset := test.New(3)
+	c := newZoneTestCluster(clientset, []string{}, "")
+	err := c.assignMons(testMons(3))
+	assert.Nil(t, err)
+	assert.Equal(t, "node0", c.mapping.Node["a"].Name)
+	assert.Equal(t, "node1", c.mapping.Node["b"].Name)
+	assert.Equal(t, "node2", c.mapping.Node["c"].Name)
+
+	// the strict spreading requires the zone label
+	c = newZoneTestCluster(test.New(3), []string{}, "")
+	c.StrictZoneSpread = true
+	err = c.assignMons(testMons(3))
+	assert.NotNil(t, err)
+}
+
+func TestAssignMonsCustomZoneLabel(t *testing.T) {
+	clientset := test.New(3)
+	c := newZoneTestCluster(clientset, []string{"rack1", "rack1", "rack2"}, "topology.rook.io/rack")
+	c.ZoneLabel = "topology.rook.io/rack"
+	err := c.assignMons(testMons(2))
+	assert.Nil(t, err)
+	assert.Equal(t, "node0", c.mapping.Node["a"].Name)
+	assert.Equal(t, "node2", c.mapping.Node["b"].Name)
+}
+
+func TestStrictZoneSpread(t *testing.T) {
+	// more mons than zones
+	clientset := test.New(4)
+	c := newZoneTestCluster(clientset, []string{"zone-a", "zone-a", "zone-b", "zone-b"}, "failure-domain.beta.kubernetes.io/zone")
+	c.StrictZoneSpread = true
+	err := c.assignMons(testMons(3))
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(c.mapping.Node))
+
+	// the nodes without the zone label are not used
+	clientset = test.New(4)
+	c = newZoneTestCluster(clientset, []string{"", "zone-a", "zone-b", "zone-c"}, "failure-domain.beta.kubernetes.io/zone")
+	c.StrictZoneSpread = true
+	err = c.assignMons(testMons(3))
+	assert.Nil(t, err)
+	assert.Equal(t, "node1", c.mapping.Node["a"].Name)
+	assert.Equal(t, "node2", c.mapping.Node["b"].Name)
+	assert.Equal(t, "node3", c.mapping.Node["c"].Name)
+
+	// a failed mon is replaced in its zone even if all the zones have a mon
+	clientset = test.New(4)
+	c = newZoneTestCluster(clientset, []string{"zone-a", "zone-b", "zone-c", "zone-b"}, "failure-domain.beta.kubernetes.io/zone")
+	c.StrictZoneSpread = true
+	assert.Nil(t, c.assignMons(testMons(3)))
+	replacement := []*monConfig{{ResourceName: resourceName("d"), DaemonName: "d"}}
+	assert.NotNil(t, c.assignMons(replacement))
+	assert.Nil(t, c.assignMonsReplacing(replacement, "b"))
+	assert.Contains(t, []string{"node1", "node3"}, c.mapping.Node["d"].Name)
+}
+
+func TestPickMonNode(t *testing.T) {
+	c := &Cluster{StrictZoneSpread: true}
+	nodes := []v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node0", Labels: map[string]string{"failure-domain.beta.kubernetes.io/zone": "zone-a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"failure-domain.beta.kubernetes.io/zone": "zone-b"}}},
+	}
+
+	node, ok := c.pickMonNode(nodes, 0, map[string]int{"zone-a": 1})
+	assert.True(t, ok)
+	assert.Equal(t, "node1", node.Name)
+
+	// all the zones have a mon
+	_, ok = c.pickMonNode(nodes, 0, map[string]int{"zone-a": 1, "zone-b": 1})
+	assert.False(t, ok)
+
+	// the zone with the fewest mons is picked when the spreading is not strict
+	c.StrictZoneSpread = false
+	node, ok = c.pickMonNode(nodes, 0, map[string]int{"zone-a": 2, "zone-b": 1})
+	assert.True(t, ok)
+	assert.Equal(t, "node1", node.Name)
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/operator/discover"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+var (
+	// the time to wait for the discovery of the devices of a new node before its osds are provisioned
+	discoveredNodeTimeout  = 5 * time.Minute
+	discoveredNodeInterval = 10 * time.Second
+)
+
+// startNodeWatcher watches the kubernetes nodes so the osds of the clusters using all the nodes are provisioned on the
+// new nodes and removed from the deleted nodes without an update of the cluster CRD. The clients of the nodes with the
+// blocklist annotation are fenced.
+func (c *ClusterController) startNodeWatcher(stopCh chan struct{}) {
+	source := cache.NewListWatchFromClient(c.context.Clientset.CoreV1().RESTClient(), "nodes", v1.NamespaceAll, fields.Everything())
+	_, controller := cache.NewInformer(source, &v1.Node{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onK8sNodeAdd,
+		UpdateFunc: c.onK8sNodeUpdate,
+		DeleteFunc: c.onK8sNodeDelete,
+	})
+
+	logger.Infof("start watching the nodes")
+	go controller.Run(stopCh)
+}
+
+func (c *ClusterController) onK8sNodeAdd(obj interface{}) {
+	node, ok := obj.(*v1.Node)
+	if !ok {
+		return
+	}
+
+	for _, cluster := range c.clusters() {
+		if !usesNodeForOSDs(cluster, node) {
+			continue
+		}
+		logger.Infof("node %s added to cluster %s", node.Name, cluster.Namespace)
+		go c.provisionNewNode(cluster, nodeHostname(node))
+	}
+
+	// a node added with the annotation is blocked as well
+	c.updateNodeBlocklist(&v1.Node{}, node)
+}
+
+func (c *ClusterController) onK8sNodeUpdate(oldObj, newObj interface{}) {
+	oldNode, ok := oldObj.(*v1.Node)
+	if !ok {
+		return
+	}
+	node, ok := newObj.(*v1.Node)
+	if !ok {
+		return
+	}
+
+	// a node that was not valid to run osds, for example while it was not ready or cordoned, is provisioned as a new node
+	for _, cluster := range c.clusters() {
+		if usesNodeForOSDs(cluster, oldNode) || !usesNodeForOSDs(cluster, node) {
+			continue
+		}
+		logger.Infof("node %s is now valid to run osds in cluster %s", node.Name, cluster.Namespace)
+		go c.provisionNewNode(cluster, nodeHostname(node))
+	}
+
+	c.updateNodeBlocklist(oldNode, node)
+}
+
+func (c *ClusterController) onK8sNodeDelete(obj interface{}) {
+	node, ok := obj.(*v1.Node)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			return
+		}
+		if node, ok = tombstone.Obj.(*v1.Node); !ok {
+			return
+		}
+	}
+
+	for _, cluster := range c.clusters() {
+		spec := cluster.getSpec()
+		if !cluster.initCompleted || !spec.Storage.UseAllNodes || spec.MaintenanceMode {
+			continue
+		}
+		logger.Infof("node %s deleted from cluster %s", node.Name, cluster.Namespace)
+		go c.removeDeletedNode(cluster, node.Name)
+	}
+}
+
+// provisionNewNode waits for the devices of the node to be discovered and orchestrates the osds of the cluster
+func (c *ClusterController) provisionNewNode(cluster *cluster, hostname string) {
+	// the osds on directories do not need the devices of the node
+	if usesDevices(cluster.getSpec().Storage) {
+		if err := waitForDiscoveredNode(c, hostname); err != nil {
+			logger.Errorf("failed to provision the osds of new node %s. %+v", hostname, err)
+			return
+		}
+	}
+	if err := cluster.reconcileOSDs(c.rookImage); err != nil {
+		logger.Errorf("failed to provision the osds of new node %s in cluster %s. %+v", hostname, cluster.Namespace, err)
+	}
+}
+
+// removeDeletedNode orchestrates the osds of the cluster, which removes the osds of the nodes that do not exist anymore
+func (c *ClusterController) removeDeletedNode(cluster *cluster, nodeName string) {
+	if err := cluster.reconcileOSDs(c.rookImage); err != nil {
+		logger.Errorf("failed to remove the osds of node %s in cluster %s. %+v", nodeName, cluster.Namespace, err)
+	}
+}
+
+// waitForDiscoveredNode waits for the discover daemon to report the devices of the node, which is needed for the node
+// to be part of the storage nodes when all the nodes are used
+func waitForDiscoveredNode(c *ClusterController, hostname string) error {
+	rookSystemNS := os.Getenv(k8sutil.PodNamespaceEnvVar)
+	start := time.Now()
+	for {
+		devices, err := discover.ListDevices(c.context, rookSystemNS, hostname)
+		if err != nil {
+			logger.Warningf("failed to list the devices of node %s. %+v", hostname, err)
+		} else if len(devices) > 0 {
+			return nil
+		}
+		if time.Since(start) >= discoveredNodeTimeout {
+			return fmt.Errorf("the devices of node %s were not discovered after %s", hostname, discoveredNodeTimeout)
+		}
+		logger.Infof("waiting for the devices of node %s to be discovered", hostname)
+		time.Sleep(discoveredNodeInterval)
+	}
+}
+
+// usesDevices returns whether the storage selects devices for the osds, on all the nodes or on some of them
+func usesDevices(storage rookalpha.StorageScopeSpec) bool {
+	selections := []rookalpha.Selection{storage.Selection}
+	for _, node := range storage.Nodes {
+		selections = append(selections, node.Selection)
+	}
+	for _, selection := range selections {
+		if selection.GetUseAllDevices() || selection.DeviceFilter != "" || len(selection.Devices) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// usesNodeForOSDs returns whether an initialized cluster uses all the nodes and the node is valid to run its osds
+func usesNodeForOSDs(cluster *cluster, node *v1.Node) bool {
+	spec := cluster.getSpec()
+	if !cluster.initCompleted || !spec.Storage.UseAllNodes || spec.MaintenanceMode {
+		return false
+	}
+	valid, err := k8sutil.ValidNode(*node, cephv1.GetOSDPlacement(spec.Placement))
+	if err != nil {
+		logger.Warningf("failed to validate node %s for cluster %s. %+v", node.Name, cluster.Namespace, err)
+		return false
+	}
+	return valid
+}
+
+func nodeHostname(node *v1.Node) string {
+	hostname := node.Labels[apis.LabelHostname]
+	if hostname == "" {
+		// fall back to the node name if the hostname label is not set
+		return node.Name
+	}
+	return hostname
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func TestUsesNodeForOSDs(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}},
+	}
+	c := &cluster{Namespace: "ns", Spec: &cephv1.ClusterSpec{Storage: rookalpha.StorageScopeSpec{UseAllNodes: true}}}
+
+	// the nodes are not reconciled before the cluster is created
+	assert.False(t, usesNodeForOSDs(c, node))
+
+	c.initCompleted = true
+	assert.True(t, usesNodeForOSDs(c, node))
+
+	// a cordoned node does not run osds
+	node.Spec.Unschedulable = true
+	assert.False(t, usesNodeForOSDs(c, node))
+	node.Spec.Unschedulable = false
+
+	// a node that did not report its readiness yet does not run osds
+	node.Status.Conditions = nil
+	assert.False(t, usesNodeForOSDs(c, node))
+
+	// the nodes are declared in the cluster when all the nodes are not used
+	c.Spec.Storage.UseAllNodes = false
+	assert.False(t, usesNodeForOSDs(c, node))
+}
+
+func TestNodeHostname(t *testing.T) {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	assert.Equal(t, "node1", nodeHostname(node))
+
+	node.Labels = map[string]string{apis.LabelHostname: "host1"}
+	assert.Equal(t, "host1", nodeHostname(node))
+}
+
+func TestWaitForDiscoveredNode(t *testing.T) {
+	discoveredNodeTimeout = 20 * time.Millisecond
+	discoveredNodeInterval = time.Millisecond
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-system")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+
+	clientset := testop.New(1)
+	c := &ClusterController{context: &clusterd.Context{Clientset: clientset}}
+	addDiscoveredNode := func(nodeName string) {
+		cm := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "local-device-" + nodeName,
+				Namespace: "rook-system",
+				Labels: map[string]string{
+					k8sutil.AppAttr:         discoverDaemon.AppName,
+					discoverDaemon.NodeAttr: nodeName,
+				},
+			},
+			Data: map[string]string{discoverDaemon.LocalDiskCMData: `[{"name":"sda"}]`},
+		}
+		_, err := clientset.CoreV1().ConfigMaps("rook-system").Create(cm)
+		assert.Nil(t, err)
+	}
+
+	// the devices of the new node are not discovered yet
+	addDiscoveredNode("node0")
+	assert.NotNil(t, waitForDiscoveredNode(c, "node1"))
+
+	addDiscoveredNode("node1")
+	assert.Nil(t, waitForDiscoveredNode(c, "node1"))
+}
+
+func TestUsesDevices(t *testing.T) {
+	useAllDevices := true
+	noDevices := false
+
+	// the osds only run on directories
+	storage := rookalpha.StorageScopeSpec{Selection: rookalpha.Selection{
+		UseAllDevices: &noDevices,
+		Directories:   []rookalpha.Directory{{Path: "/var/lib/rook"}},
+	}}
+	assert.False(t, usesDevices(storage))
+
+	storage.Selection.UseAllDevices = &useAllDevices
+	assert.True(t, usesDevices(storage))
+
+	storage.Selection.UseAllDevices = nil
+	storage.Selection.DeviceFilter = "^sd[b-d]"
+	assert.True(t, usesDevices(storage))
+
+	// the devices of a single node
+	storage.Selection.DeviceFilter = ""
+	storage.Nodes = []rookalpha.Node{{Name: "node1", Selection: rookalpha.Selection{Devices: []rookalpha.Device{{Name: "sdb"}}}}}
+	assert.True(t, usesDevices(storage))
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+const (
+	healthOK   = "HEALTH_OK"
+	healthWarn = "HEALTH_WARN"
+)
+
+var (
+	healthCheckInterval = time.Minute
+)
+
+// Monitor watches the health and the osds of a cluster to notify the webhooks of their changes
+type Monitor struct {
+	notifier *Notifier
+	// the health of the cluster at the previous check, empty before the first check
+	lastHealth string
+	// the osds that were down at the previous check, nil before the first check
+	downOSDs map[int]bool
+}
+
+// NewMonitor creates a monitor notifying the webhooks of the notifier
+func NewMonitor(notifier *Notifier) *Monitor {
+	return &Monitor{notifier: notifier}
+}
+
+// Start checks the health and the osds at set intervals until the cluster is stopped
+func (m *Monitor) Start(stopCh chan struct{}) {
+	for {
+		select {
+		case <-time.After(healthCheckInterval):
+			if !m.notifier.Enabled() {
+				// start again from the current state when webhooks are added
+				m.lastHealth = ""
+				m.downOSDs = nil
+				continue
+			}
+			if err := m.check(); err != nil {
+				logger.Warningf("failed to check the cluster %s for notifications. %+v", m.notifier.namespace, err)
+			}
+
+		case <-stopCh:
+			logger.Infof("stopping the notifications of cluster %s", m.notifier.namespace)
+			return
+		}
+	}
+}
+
+// check notifies the health transitions and the osds that went down since the previous check. The state found by the
+// first check is not notified since it may have been notified before the operator restarted.
+func (m *Monitor) check() error {
+	status, err := client.Status(m.notifier.context, m.notifier.namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get ceph status. %+v", err)
+	}
+	health := status.Health.Status
+	if m.lastHealth != "" && health != m.lastHealth {
+		message := fmt.Sprintf("cluster health changed from %s to %s", m.lastHealth, health)
+		if checks := healthChecks(status); len(checks) > 0 {
+			message += ": " + strings.Join(checks, ", ")
+		}
+		m.notifier.Notify(EventHealth, healthSeverity(health), message)
+	}
+	m.lastHealth = health
+
+	dump, err := client.GetOSDDump(m.notifier.context, m.notifier.namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get osd dump. %+v", err)
+	}
+	down := map[int]bool{}
+	var newlyDown []int
+	for _, osd := range dump.OSDs {
+		id, err := osd.OSD.Int64()
+		if err != nil {
+			continue
+		}
+		if up, err := osd.Up.Int64(); err == nil && up == 1 {
+			continue
+		}
+		down[int(id)] = true
+		if m.downOSDs != nil && !m.downOSDs[int(id)] {
+			newlyDown = append(newlyDown, int(id))
+		}
+	}
+	m.downOSDs = down
+
+	sort.Ints(newlyDown)
+	for _, id := range newlyDown {
+		m.notifier.Notify(EventOSDDown, SeverityWarning, fmt.Sprintf("osd.%d is down", id))
+	}
+	return nil
+}
+
+// healthChecks returns the summaries of the failed health checks
+func healthChecks(status client.CephStatus) []string {
+	var checks []string
+	for name, check := range status.Health.Checks {
+		checks = append(checks, fmt.Sprintf("%s (%s)", name, check.Summary.Message))
+	}
+	sort.Strings(checks)
+	return checks
+}
+
+func healthSeverity(health string) string {
+	switch health {
+	case healthOK:
+		return SeverityInfo
+	case healthWarn:
+		return SeverityWarning
+	}
+	return SeverityError
+}
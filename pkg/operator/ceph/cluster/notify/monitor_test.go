@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"encoding/json"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitorCheck(t *testing.T) {
+	server, payloads := webhookServer(t)
+	defer server.Close()
+
+	health := `{"status":"HEALTH_OK","checks":{}}`
+	osds := `[{"osd":0,"up":1,"in":1},{"osd":1,"up":1,"in":1}]`
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "status" {
+				return `{"health":` + health + `}`, nil
+			}
+			if args[0] == "osd" && args[1] == "dump" {
+				return `{"osds":` + osds + `}`, nil
+			}
+			return "", nil
+		},
+	}
+	spec := &cephv1.ClusterSpec{Notifications: cephv1.NotificationsSpec{Webhooks: []cephv1.WebhookSpec{{Name: "generic", URL: server.URL}}}}
+	n := New(&clusterd.Context{Executor: executor}, "ns", "mycluster", func() *cephv1.ClusterSpec { return spec })
+	m := NewMonitor(n)
+	notifications := func() []Notification {
+		var result []Notification
+		for _, p := range *payloads {
+			var notification Notification
+			require.Nil(t, json.Unmarshal([]byte(p), &notification))
+			result = append(result, notification)
+		}
+		*payloads = nil
+		return result
+	}
+
+	// the state found by the first check is not notified
+	osds = `[{"osd":0,"up":1,"in":1},{"osd":1,"up":0,"in":1}]`
+	assert.Nil(t, m.check())
+	assert.Equal(t, 0, len(notifications()))
+
+	// the transitions of the health and the osds going down are notified
+	health = `{"status":"HEALTH_WARN","checks":{"OSD_DOWN":{"severity":"HEALTH_WARN","summary":{"message":"2 osds down"}}}}`
+	osds = `[{"osd":0,"up":0,"in":1},{"osd":1,"up":0,"in":1}]`
+	assert.Nil(t, m.check())
+	result := notifications()
+	require.Equal(t, 2, len(result))
+	assert.Equal(t, EventHealth, result[0].Event)
+	assert.Equal(t, SeverityWarning, result[0].Severity)
+	assert.Equal(t, "cluster health changed from HEALTH_OK to HEALTH_WARN: OSD_DOWN (2 osds down)", result[0].Message)
+	assert.Equal(t, EventOSDDown, result[1].Event)
+	assert.Equal(t, "osd.0 is down", result[1].Message)
+
+	// nothing changed
+	assert.Nil(t, m.check())
+	assert.Equal(t, 0, len(notifications()))
+
+	health = `{"status":"HEALTH_ERR","checks":{}}`
+	assert.Nil(t, m.check())
+	result = notifications()
+	require.Equal(t, 1, len(result))
+	assert.Equal(t, SeverityError, result[0].Severity)
+	assert.Equal(t, "cluster health changed from HEALTH_WARN to HEALTH_ERR", result[0].Message)
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notify posts the events of a cluster to the webhooks configured in the cluster CRD.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-notify")
+
+const (
+	// EventHealth is posted when the health of the cluster changes
+	EventHealth = "health"
+	// EventOSDDown is posted when an osd goes down
+	EventOSDDown = "osdDown"
+	// EventUpgrade is posted when the upgrade of the ceph version of the cluster completes
+	EventUpgrade = "upgrade"
+
+	// FormatGeneric posts the notification as json
+	FormatGeneric = "generic"
+	// FormatSlack posts the notification as the text of a slack message
+	FormatSlack = "slack"
+
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+
+	// the key of the url in the secret of a webhook
+	urlSecretKey   = "url"
+	webhookTimeout = 10 * time.Second
+)
+
+// Notification is the payload posted to the generic webhooks
+type Notification struct {
+	Cluster   string    `json:"cluster"`
+	Namespace string    `json:"namespace"`
+	Event     string    `json:"event"`
+	Severity  string    `json:"severity"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+// slackMessage is the payload posted to the slack incoming webhooks
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Notifier posts the events of a cluster to its webhooks
+type Notifier struct {
+	context     *clusterd.Context
+	namespace   string
+	clusterName string
+	// clusterSpec returns the current settings of the cluster, with the webhooks
+	clusterSpec func() *cephv1.ClusterSpec
+	client      *http.Client
+}
+
+// New creates a notifier for the webhooks of a cluster
+func New(context *clusterd.Context, namespace, clusterName string, clusterSpec func() *cephv1.ClusterSpec) *Notifier {
+	return &Notifier{
+		context:     context,
+		namespace:   namespace,
+		clusterName: clusterName,
+		clusterSpec: clusterSpec,
+		client:      &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Enabled returns whether webhooks are configured
+func (n *Notifier) Enabled() bool {
+	return len(n.clusterSpec().Notifications.Webhooks) > 0
+}
+
+// Notify posts an event to the webhooks subscribed to it. A webhook that fails is logged and does not prevent the
+// others from being notified.
+func (n *Notifier) Notify(event, severity, message string) {
+	notification := Notification{
+		Cluster:   n.clusterName,
+		Namespace: n.namespace,
+		Event:     event,
+		Severity:  severity,
+		Message:   message,
+		Time:      time.Now().UTC(),
+	}
+	for _, webhook := range n.clusterSpec().Notifications.Webhooks {
+		if !subscribed(webhook, event) {
+			continue
+		}
+		if err := n.post(webhook, notification); err != nil {
+			logger.Warningf("failed to notify webhook %s of the %s event of cluster %s. %+v", webhook.Name, event, n.namespace, err)
+		}
+	}
+}
+
+func subscribed(webhook cephv1.WebhookSpec, event string) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, e := range webhook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) post(webhook cephv1.WebhookSpec, notification Notification) error {
+	url, err := n.webhookURL(webhook)
+	if err != nil {
+		return err
+	}
+	body, err := payload(webhook.Format, notification)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post. %+v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	logger.Debugf("notified webhook %s of the %s event", webhook.Name, notification.Event)
+	return nil
+}
+
+func (n *Notifier) webhookURL(webhook cephv1.WebhookSpec) (string, error) {
+	if webhook.SecretName == "" {
+		if webhook.URL == "" {
+			return "", fmt.Errorf("no url nor secret")
+		}
+		return webhook.URL, nil
+	}
+	secret, err := n.context.Clientset.CoreV1().Secrets(n.namespace).Get(webhook.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret %s. %+v", webhook.SecretName, err)
+	}
+	url, ok := secret.Data[urlSecretKey]
+	if !ok {
+		return "", fmt.Errorf("no %s key in secret %s", urlSecretKey, webhook.SecretName)
+	}
+	return string(url), nil
+}
+
+func payload(format string, notification Notification) ([]byte, error) {
+	switch format {
+	case "", FormatGeneric:
+		return json.Marshal(notification)
+	case FormatSlack:
+		text := fmt.Sprintf("[%s/%s] %s: %s", notification.Namespace, notification.Cluster, notification.Severity, notification.Message)
+		return json.Marshal(slackMessage{Text: text})
+	}
+	return nil, fmt.Errorf("unknown format %q. must be %s or %s", format, FormatGeneric, FormatSlack)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// webhookServer records the payloads posted to it
+func webhookServer(t *testing.T) (*httptest.Server, *[]string) {
+	var payloads []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.Nil(t, err)
+		payloads = append(payloads, string(body))
+	}))
+	return server, &payloads
+}
+
+func TestNotify(t *testing.T) {
+	server, payloads := webhookServer(t)
+	defer server.Close()
+	clientset := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "slack-url", Namespace: "ns"},
+		Data:       map[string][]byte{"url": []byte(server.URL + "/slack")},
+	})
+	spec := &cephv1.ClusterSpec{Notifications: cephv1.NotificationsSpec{Webhooks: []cephv1.WebhookSpec{
+		{Name: "generic", URL: server.URL},
+		{Name: "slack", SecretName: "slack-url", Format: FormatSlack, Events: []string{EventHealth}},
+	}}}
+	n := New(&clusterd.Context{Clientset: clientset}, "ns", "mycluster", func() *cephv1.ClusterSpec { return spec })
+	assert.True(t, n.Enabled())
+
+	// the slack webhook only subscribed to the health events
+	n.Notify(EventHealth, SeverityWarning, "cluster health changed from HEALTH_OK to HEALTH_WARN")
+	n.Notify(EventOSDDown, SeverityWarning, "osd.1 is down")
+	require.Equal(t, 3, len(*payloads))
+
+	var notification Notification
+	assert.Nil(t, json.Unmarshal([]byte((*payloads)[0]), &notification))
+	assert.Equal(t, "mycluster", notification.Cluster)
+	assert.Equal(t, "ns", notification.Namespace)
+	assert.Equal(t, EventHealth, notification.Event)
+	assert.Equal(t, SeverityWarning, notification.Severity)
+	assert.Equal(t, `{"text":"[ns/mycluster] warning: cluster health changed from HEALTH_OK to HEALTH_WARN"}`, (*payloads)[1])
+	assert.Nil(t, json.Unmarshal([]byte((*payloads)[2]), &notification))
+	assert.Equal(t, EventOSDDown, notification.Event)
+
+	// a webhook failing does not prevent the others from being notified
+	spec.Notifications.Webhooks[0].SecretName = "missing"
+	*payloads = nil
+	n.Notify(EventHealth, SeverityInfo, "cluster health changed from HEALTH_WARN to HEALTH_OK")
+	assert.Equal(t, 1, len(*payloads))
+
+	spec.Notifications.Webhooks = nil
+	assert.False(t, n.Enabled())
+}
+
+func TestPayload(t *testing.T) {
+	_, err := payload("teams", Notification{})
+	assert.NotNil(t, err)
+}
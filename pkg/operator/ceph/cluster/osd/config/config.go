@@ -18,9 +18,11 @@ limitations under the License.
 package config
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/coreos/pkg/capnslog"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 )
 
@@ -44,6 +46,43 @@ const (
 	OSDsPerDeviceKey   = "osdsPerDevice"
 	EncryptedDeviceKey = "encryptedDevice"
 	MetadataDeviceKey  = "metadataDevice"
+	CacheDeviceKey     = "cacheDevice"
+	CacheSizeMBKey     = "cacheSizeMB"
+	CacheModeKey       = "cacheMode"
+	TuningKey          = "tuning"
+	CPUPinningKey      = "cpuPinning"
+	BenchmarkKey       = "benchmarkDevices"
+	WeightRampUpKey    = "weightRampUpStep"
+	ResumePolicyKey    = "resumePolicy"
+	PreflightKey       = "preflightChecks"
+	MclockProfileKey   = "mclockProfile"
+
+	// CacheModeWritethrough caches the reads and writes but only acknowledges the writes once they are on the data device
+	CacheModeWritethrough = "writethrough"
+	// CacheModeWriteback acknowledges the writes as soon as they are on the cache device
+	CacheModeWriteback = "writeback"
+
+	// TuningLatency favors the response time of small IOs with more shards and a larger metadata cache
+	TuningLatency = "latency"
+	// TuningThroughput favors large sequential IOs with fewer, busier shards and a larger data cache
+	TuningThroughput = "throughput"
+	// TuningBalanced only sizes the memory of the OSDs and keeps the Ceph defaults for the rest
+	TuningBalanced = "balanced"
+
+	// ResumePolicyResume keeps the osds completed before the provisioning was interrupted, reuses the osd ids already
+	// registered for the legacy partitions and prepares the other interrupted devices again
+	ResumePolicyResume = "resume"
+	// ResumePolicyClean purges the osds of the interrupted devices and wipes them before preparing them again
+	ResumePolicyClean = "clean"
+	// ResumePolicySkip leaves the interrupted devices untouched for the admin to inspect them
+	ResumePolicySkip = "skip"
+
+	// MclockHighClientOps gives most of the IO of the osds to the clients and throttles the recovery
+	MclockHighClientOps = "highClientOps"
+	// MclockBalanced shares the IO of the osds equally between the clients and the recovery
+	MclockBalanced = "balanced"
+	// MclockHighRecoveryOps gives most of the IO of the osds to the recovery so the cluster is healthy again sooner
+	MclockHighRecoveryOps = "highRecoveryOps"
 )
 
 type StoreConfig struct {
@@ -53,6 +92,22 @@ type StoreConfig struct {
 	JournalSizeMB   int    `json:"journalSizeMB,omitempty"`
 	OSDsPerDevice   int    `json:"osdsPerDevice,omitempty"`
 	EncryptedDevice bool   `json:"encryptedDevice,omitempty"`
+	CacheDevice     string `json:"cacheDevice,omitempty"`
+	CacheSizeMB     int    `json:"cacheSizeMB,omitempty"`
+	CacheMode       string `json:"cacheMode,omitempty"`
+	Tuning          string `json:"tuning,omitempty"`
+	CPUPinning      bool   `json:"cpuPinning,omitempty"`
+	Benchmark       bool   `json:"benchmarkDevices,omitempty"`
+	// WeightRampUpStep is the percentage of their full crush weight the new OSDs gain at each step of their ramp up.
+	// The new OSDs are added with a crush weight of 0 when it is set.
+	WeightRampUpStep int `json:"weightRampUpStep,omitempty"`
+	// ResumePolicy is what the provisioning does with the devices it left partially prepared when it was interrupted
+	ResumePolicy string `json:"resumePolicy,omitempty"`
+	// Preflight checks the prerequisites of the node before its osds are provisioned
+	Preflight bool `json:"preflightChecks,omitempty"`
+	// MclockProfile schedules the operations of the osds with the mclock op queue and shares their IO between the
+	// clients and the recovery according to the profile
+	MclockProfile string `json:"mclockProfile,omitempty"`
 }
 
 func ToStoreConfig(config map[string]string) StoreConfig {
@@ -71,12 +126,57 @@ func ToStoreConfig(config map[string]string) StoreConfig {
 			storeConfig.OSDsPerDevice = convertToIntIgnoreErr(v)
 		case EncryptedDeviceKey:
 			storeConfig.EncryptedDevice = (v == "true")
+		case CacheDeviceKey:
+			storeConfig.CacheDevice = v
+		case CacheSizeMBKey:
+			storeConfig.CacheSizeMB = convertToIntIgnoreErr(v)
+		case CacheModeKey:
+			storeConfig.CacheMode = v
+		case TuningKey:
+			storeConfig.Tuning = v
+		case CPUPinningKey:
+			storeConfig.CPUPinning = (v == "true")
+		case BenchmarkKey:
+			storeConfig.Benchmark = (v == "true")
+		case WeightRampUpKey:
+			storeConfig.WeightRampUpStep = convertToIntIgnoreErr(v)
+		case ResumePolicyKey:
+			storeConfig.ResumePolicy = v
+		case PreflightKey:
+			storeConfig.Preflight = (v == "true")
+		case MclockProfileKey:
+			storeConfig.MclockProfile = v
 		}
 	}
 
 	return storeConfig
 }
 
+// ValidateStoreType checks the store type of the new osds of a node or of a device. Empty is the default bluestore.
+func ValidateStoreType(storeType string) error {
+	switch storeType {
+	case "", Bluestore, Filestore:
+		return nil
+	}
+	return fmt.Errorf("invalid store type %q. must be %s or %s", storeType, Bluestore, Filestore)
+}
+
+// ValidateMclockProfile checks the mclock profile of the osds of a node and that the ceph version of the cluster
+// supports the mclock settings of the osds, which are only available from mimic
+func ValidateMclockProfile(profile, cephVersion string) error {
+	switch profile {
+	case "":
+		return nil
+	case MclockHighClientOps, MclockBalanced, MclockHighRecoveryOps:
+	default:
+		return fmt.Errorf("invalid mclock profile %q. must be one of %s, %s or %s", profile, MclockHighClientOps, MclockBalanced, MclockHighRecoveryOps)
+	}
+	if !cephv1.VersionAtLeast(cephVersion, cephv1.Mimic) {
+		return fmt.Errorf("the mclock profiles are not supported by ceph %s. they require mimic or newer", cephVersion)
+	}
+	return nil
+}
+
 func MetadataDevice(config map[string]string) string {
 	for k, v := range config {
 		switch k {
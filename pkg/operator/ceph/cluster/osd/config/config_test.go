@@ -0,0 +1,38 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMclockProfile(t *testing.T) {
+	assert.Nil(t, ValidateMclockProfile("", cephv1.Luminous))
+	assert.Nil(t, ValidateMclockProfile(MclockBalanced, cephv1.Mimic))
+	assert.Nil(t, ValidateMclockProfile(MclockHighClientOps, cephv1.Nautilus))
+	assert.NotNil(t, ValidateMclockProfile("fast", cephv1.Nautilus))
+
+	// the running version must support the mclock settings
+	assert.NotNil(t, ValidateMclockProfile(MclockHighRecoveryOps, cephv1.Luminous))
+	assert.NotNil(t, ValidateMclockProfile(MclockHighRecoveryOps, ""))
+
+	storeConfig := ToStoreConfig(map[string]string{MclockProfileKey: MclockHighClientOps})
+	assert.Equal(t, MclockHighClientOps, storeConfig.MclockProfile)
+}
@@ -46,3 +46,27 @@ func TestLoadOSDDirMap(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, dirMap, loadedDirMap)
 }
+
+func TestPendingDevices(t *testing.T) {
+	kv := mockKVStore()
+	nodeName := "node418"
+
+	// no device is pending before the first provisioning
+	devices, err := LoadPendingDevices(kv, nodeName)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(devices))
+
+	devices["sda"] = PendingDevice{ID: -1, CephVolume: true}
+	devices["sdb"] = PendingDevice{ID: 3, UUID: "a8ee3a5c-1ad5-4c5a-ac2c-0c9ce3ec22ee"}
+	assert.Nil(t, SavePendingDevices(kv, nodeName, devices))
+
+	loaded, err := LoadPendingDevices(kv, nodeName)
+	assert.Nil(t, err)
+	assert.Equal(t, devices, loaded)
+
+	// the devices are removed from the store once prepared
+	assert.Nil(t, SavePendingDevices(kv, nodeName, map[string]PendingDevice{}))
+	loaded, err = LoadPendingDevices(kv, nodeName)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(loaded))
+}
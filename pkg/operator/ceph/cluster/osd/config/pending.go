@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const osdPendingDevicesKeyName = "osd-pending-devices"
+
+// PendingDevice is a device being prepared for an osd. It is saved before the device is modified and removed once the
+// osd is prepared, so the next provisioning finds the devices left partially prepared by a crashed provisioning.
+type PendingDevice struct {
+	// ID is the osd id registered in ceph for the legacy partitions of the device, -1 if ceph-volume assigns it
+	ID int `json:"id"`
+	// UUID is the uuid of the osd registered in ceph
+	UUID string `json:"uuid,omitempty"`
+	// CephVolume is true if the device is prepared by ceph-volume, false for the legacy partitions of rook
+	CephVolume bool `json:"cephVolume"`
+	// OSDs is the number of osds prepared on the device by ceph-volume
+	OSDs int `json:"osds,omitempty"`
+	// Cache is true if a dm-cache must be attached to the osds of the device once ceph-volume prepared them
+	Cache bool `json:"cache,omitempty"`
+}
+
+// LoadPendingDevices loads the devices of the node being prepared, by device name
+func LoadPendingDevices(kv *k8sutil.ConfigMapKVStore, nodeName string) (map[string]PendingDevice, error) {
+	devices := map[string]PendingDevice{}
+	raw, err := kv.GetValue(GetConfigStoreName(nodeName), osdPendingDevicesKeyName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return devices, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(raw), &devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// SavePendingDevices saves the devices of the node being prepared
+func SavePendingDevices(kv *k8sutil.ConfigMapKVStore, nodeName string, devices map[string]PendingDevice) error {
+	b, err := json.Marshal(devices)
+	if err != nil {
+		return err
+	}
+	return kv.SetValue(GetConfigStoreName(nodeName), osdPendingDevicesKeyName, string(b))
+}
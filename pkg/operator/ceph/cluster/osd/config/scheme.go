@@ -33,6 +33,7 @@ const (
 	WalDefaultSizeMB      = 576
 	DBDefaultSizeMB       = 20480
 	JournalDefaultSizeMB  = 5120
+	CacheDefaultSizeMB    = 10240
 	BluestoreDirBlockName = "bluestore-block"
 	BluestoreDirWalName   = "bluestore-wal"
 	BluestoreDirDBName    = "bluestore-db"
@@ -0,0 +1,247 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	defaultExcludedNodeMarkOutAfter = 600 * time.Second
+	// excludedOutAnnotation records on the deployment of an osd that it was marked out because its node was excluded,
+	// so it is marked in again when the node is not excluded anymore
+	excludedOutAnnotation = "ceph.rook.io/excludedOut"
+)
+
+// isExcludedNode returns whether the node has one of the taints or labels excluding it from the placement of the osds
+func isExcludedNode(node v1.Node, exclusion cephv1.NodeExclusionSpec) bool {
+	for _, key := range exclusion.Taints {
+		for _, taint := range node.Spec.Taints {
+			if taint.Key == key {
+				return true
+			}
+		}
+	}
+	for _, label := range exclusion.Labels {
+		key, value := label, ""
+		hasValue := false
+		if i := strings.Index(label, "="); i >= 0 {
+			key, value, hasValue = label[:i], label[i+1:], true
+		}
+		v, ok := node.Labels[key]
+		if ok && (!hasValue || v == value) {
+			return true
+		}
+	}
+	return false
+}
+
+// getExcludedNodes returns the hostnames of the nodes excluded from the placement of the osds
+func getExcludedNodes(nodes []v1.Node, exclusion cephv1.NodeExclusionSpec) map[string]bool {
+	excluded := map[string]bool{}
+	for _, node := range nodes {
+		if isExcludedNode(node, exclusion) {
+			excluded[nodeHostname(node)] = true
+		}
+	}
+	return excluded
+}
+
+// excludeNodes removes the excluded nodes from the storage nodes so no new osd is placed on them
+func (c *Cluster) excludeNodes(nodes []rookalpha.Node) []rookalpha.Node {
+	c.excludedNodes = map[string]bool{}
+	if len(c.nodeExclusion.Taints) == 0 && len(c.nodeExclusion.Labels) == 0 {
+		return nodes
+	}
+
+	k8sNodes, err := c.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		logger.Warningf("failed to list the nodes to exclude. %+v", err)
+		return nodes
+	}
+	c.excludedNodes = getExcludedNodes(k8sNodes.Items, c.nodeExclusion)
+
+	var result []rookalpha.Node
+	for _, node := range nodes {
+		if c.excludedNodes[node.Name] {
+			logger.Infof("node %s is excluded from the placement of the osds", node.Name)
+			continue
+		}
+		result = append(result, node)
+	}
+	return result
+}
+
+// markOutExcludedNodes marks out the osds of the nodes that are excluded for longer than the grace period, so their
+// data is moved to the other nodes. The osds it marked out are marked in again once their node is not excluded.
+func (m *Monitor) markOutExcludedNodes() error {
+	exclusion := m.clusterSpec().NodeExclusion
+	excluded := map[string]bool{}
+	if len(exclusion.Taints) > 0 || len(exclusion.Labels) > 0 {
+		nodes, err := m.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list the nodes. %+v", err)
+		}
+		excluded = getExcludedNodes(nodes.Items, exclusion)
+	}
+	for node := range m.excludedSince {
+		if !excluded[node] {
+			logger.Infof("node %s is not excluded anymore", node)
+			delete(m.excludedSince, node)
+		}
+	}
+
+	osdsByNode, err := getOSDDeploymentsByNode(m.context, m.clusterName)
+	if err != nil {
+		return err
+	}
+	m.markInIncludedNodes(osdsByNode, excluded)
+	if len(excluded) == 0 {
+		return nil
+	}
+
+	gracePeriod := defaultExcludedNodeMarkOutAfter
+	if exclusion.MarkOutAfterSeconds > 0 {
+		gracePeriod = time.Duration(exclusion.MarkOutAfterSeconds) * time.Second
+	}
+
+	osdDump, err := client.GetOSDDump(m.context, m.clusterName)
+	if err != nil {
+		return err
+	}
+
+	for node := range excluded {
+		since, ok := m.excludedSince[node]
+		if !ok {
+			logger.Infof("node %s is excluded. its osds will be marked out after %s", node, gracePeriod)
+			m.excludedSince[node] = time.Now()
+			continue
+		}
+		if time.Since(since) < gracePeriod {
+			continue
+		}
+
+		for _, d := range osdsByNode[node] {
+			id := getIDFromDeployment(d)
+			if id == unknownID {
+				continue
+			}
+			_, in, err := osdDump.StatusByID(int64(id))
+			if err != nil || in != inStatus {
+				continue
+			}
+			// the osd is recorded before it is marked out so it is never left out without being marked in later
+			if err := m.setExcludedOut(d.Name, true); err != nil {
+				logger.Warningf("not marking out osd.%d. failed to record it on its deployment. %+v", id, err)
+				continue
+			}
+			logger.Infof("marking out osd.%d on node %s excluded since %s", id, node, since)
+			if _, err := client.OSDOut(m.context, m.clusterName, id); err != nil {
+				logger.Warningf("failed to mark out osd.%d. %+v", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// markInIncludedNodes marks in the osds that were marked out by the exclusion of their node once the node is not
+// excluded anymore
+func (m *Monitor) markInIncludedNodes(osdsByNode map[string][]*extensions.Deployment, excluded map[string]bool) {
+	for node, deployments := range osdsByNode {
+		if excluded[node] {
+			continue
+		}
+		for _, d := range deployments {
+			if d.Annotations[excludedOutAnnotation] != "true" {
+				continue
+			}
+			id := getIDFromDeployment(d)
+			if id == unknownID {
+				continue
+			}
+			logger.Infof("marking in osd.%d on node %s that is not excluded anymore", id, node)
+			if _, err := client.OSDIn(m.context, m.clusterName, id); err != nil {
+				logger.Warningf("failed to mark in osd.%d. %+v", id, err)
+				continue
+			}
+			if err := m.setExcludedOut(d.Name, false); err != nil {
+				logger.Warningf("failed to clear the exclusion of osd.%d on its deployment. %+v", id, err)
+			}
+		}
+	}
+}
+
+func (m *Monitor) setExcludedOut(deploymentName string, out bool) error {
+	d, err := m.context.Clientset.Extensions().Deployments(m.clusterName).Get(deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if out {
+		if d.Annotations == nil {
+			d.Annotations = map[string]string{}
+		}
+		d.Annotations[excludedOutAnnotation] = "true"
+	} else {
+		delete(d.Annotations, excludedOutAnnotation)
+	}
+	_, err = m.context.Clientset.Extensions().Deployments(m.clusterName).Update(d)
+	return err
+}
+
+// getExcludedOutOSDs returns the ids of the osds marked out by the exclusion of their node
+func getExcludedOutOSDs(osdsByNode map[string][]*extensions.Deployment) map[int]bool {
+	ids := map[int]bool{}
+	for _, deployments := range osdsByNode {
+		for _, d := range deployments {
+			if d.Annotations[excludedOutAnnotation] == "true" {
+				ids[getIDFromDeployment(d)] = true
+			}
+		}
+	}
+	return ids
+}
+
+// keepExcludedOut copies the exclusion of the osd from its original deployment to the updated one
+func keepExcludedOut(original, deployment *extensions.Deployment) {
+	value, ok := original.Annotations[excludedOutAnnotation]
+	if !ok {
+		return
+	}
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[excludedOutAnnotation] = value
+}
+
+func nodeHostname(node v1.Node) string {
+	hostname := node.Labels[apis.LabelHostname]
+	if hostname == "" {
+		// fall back to the node name if the hostname label is not set
+		return node.Name
+	}
+	return hostname
+}
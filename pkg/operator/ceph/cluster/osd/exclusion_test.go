@@ -0,0 +1,170 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func TestIsExcludedNode(t *testing.T) {
+	exclusion := cephv1.NodeExclusionSpec{
+		Taints: []string{"node.kubernetes.io/out-of-service"},
+		Labels: []string{"storage-disabled", "rack=r1"},
+	}
+
+	node := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"rack": "r2"}}}
+	assert.False(t, isExcludedNode(node, exclusion))
+
+	// the value of the label must match when it is specified
+	node.Labels["rack"] = "r1"
+	assert.True(t, isExcludedNode(node, exclusion))
+	node.Labels["rack"] = "r2"
+
+	// any value of the label matches when only the key is specified
+	node.Labels["storage-disabled"] = ""
+	assert.True(t, isExcludedNode(node, exclusion))
+	delete(node.Labels, "storage-disabled")
+
+	node.Spec.Taints = []v1.Taint{{Key: "node.kubernetes.io/out-of-service", Effect: v1.TaintEffectNoExecute}}
+	assert.True(t, isExcludedNode(node, exclusion))
+
+	// nothing is excluded by default
+	assert.False(t, isExcludedNode(node, cephv1.NodeExclusionSpec{}))
+}
+
+func TestExcludeNodes(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	for _, name := range []string{"node1", "node2"} {
+		node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{apis.LabelHostname: name}}}
+		if name == "node2" {
+			node.Spec.Taints = []v1.Taint{{Key: "node.kubernetes.io/out-of-service", Effect: v1.TaintEffectNoExecute}}
+		}
+		_, err := clientset.CoreV1().Nodes().Create(node)
+		assert.Nil(t, err)
+	}
+
+	c := &Cluster{context: &clusterd.Context{Clientset: clientset}, Namespace: "ns"}
+	nodes := []rookalpha.Node{{Name: "node1"}, {Name: "node2"}}
+	assert.Equal(t, nodes, c.excludeNodes(nodes))
+	assert.Equal(t, 0, len(c.excludedNodes))
+
+	c.nodeExclusion = cephv1.NodeExclusionSpec{Taints: []string{"node.kubernetes.io/out-of-service"}}
+	assert.Equal(t, []rookalpha.Node{{Name: "node1"}}, c.excludeNodes(nodes))
+	assert.True(t, c.excludedNodes["node2"])
+
+	// the osds of the excluded node are not removed
+	replicas := int32(1)
+	d := &extensions.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1", Namespace: "ns", Labels: map[string]string{k8sutil.AppAttr: appName, osdLabelKey: "1"}},
+		Spec: extensions.DeploymentSpec{
+			Replicas: &replicas,
+			Template: v1.PodTemplateSpec{Spec: v1.PodSpec{NodeSelector: map[string]string{apis.LabelHostname: "node2"}}},
+		},
+	}
+	_, err := clientset.Extensions().Deployments("ns").Create(d)
+	assert.Nil(t, err)
+	c.Storage.Nodes = []rookalpha.Node{{Name: "node1"}}
+	removed, err := c.findRemovedNodes()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(removed))
+}
+
+func TestMarkOutExcludedNodes(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{apis.LabelHostname: "node1"}}}
+	_, err := clientset.CoreV1().Nodes().Create(node)
+	assert.Nil(t, err)
+	d := &extensions.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-3", Namespace: "ns", Labels: map[string]string{k8sutil.AppAttr: appName, osdLabelKey: "3"}},
+		Spec: extensions.DeploymentSpec{
+			Template: v1.PodTemplateSpec{Spec: v1.PodSpec{NodeSelector: map[string]string{apis.LabelHostname: "node1"}}},
+		},
+	}
+	_, err = clientset.Extensions().Deployments("ns").Create(d)
+	assert.Nil(t, err)
+
+	var markedOut, markedIn []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "dump" {
+				return `{"osds": [{"osd": 3, "up": 1, "in": 1}]}`, nil
+			}
+			if args[0] == "osd" && args[1] == "out" {
+				markedOut = append(markedOut, args[2])
+			}
+			if args[0] == "osd" && args[1] == "in" {
+				markedIn = append(markedIn, args[2])
+			}
+			return "", nil
+		},
+	}
+
+	exclusion := cephv1.NodeExclusionSpec{Labels: []string{"storage-disabled"}, MarkOutAfterSeconds: 1}
+	m := NewMonitor(&clusterd.Context{Clientset: clientset, Executor: executor}, "ns", func() *cephv1.ClusterSpec { return &cephv1.ClusterSpec{NodeExclusion: exclusion} })
+
+	// the node is not excluded
+	assert.Nil(t, m.markOutExcludedNodes())
+	assert.Equal(t, 0, len(m.excludedSince))
+
+	// the osds are not marked out before the grace period
+	node.Labels["storage-disabled"] = "true"
+	_, err = clientset.CoreV1().Nodes().Update(node)
+	assert.Nil(t, err)
+	assert.Nil(t, m.markOutExcludedNodes())
+	assert.Equal(t, 1, len(m.excludedSince))
+	assert.Equal(t, 0, len(markedOut))
+
+	m.excludedSince["node1"] = time.Now().Add(-2 * time.Second)
+	assert.Nil(t, m.markOutExcludedNodes())
+	assert.Equal(t, []string{"3"}, markedOut)
+	assert.Equal(t, 0, len(markedIn))
+	d, err = clientset.Extensions().Deployments("ns").Get("rook-ceph-osd-3", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "true", d.Annotations[excludedOutAnnotation])
+	osdsByNode, err := getOSDDeploymentsByNode(m.context, "ns")
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]bool{3: true}, getExcludedOutOSDs(osdsByNode))
+
+	// the osd marked out is marked in when the node is not excluded anymore, and the node is tracked again when it
+	// is excluded later
+	delete(node.Labels, "storage-disabled")
+	_, err = clientset.CoreV1().Nodes().Update(node)
+	assert.Nil(t, err)
+	assert.Nil(t, m.markOutExcludedNodes())
+	assert.Equal(t, 0, len(m.excludedSince))
+	assert.Equal(t, []string{"3"}, markedIn)
+	d, err = clientset.Extensions().Deployments("ns").Get("rook-ceph-osd-3", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "", d.Annotations[excludedOutAnnotation])
+
+	// the osd is only marked in once
+	assert.Nil(t, m.markOutExcludedNodes())
+	assert.Equal(t, []string{"3"}, markedIn)
+}
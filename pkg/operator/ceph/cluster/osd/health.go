@@ -16,17 +16,24 @@ limitations under the License.
 package osd
 
 import (
+	"fmt"
 	"time"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 )
 
-const upStatus = 1
+const (
+	upStatus = 1
+	inStatus = 1
+)
 
 var (
 	healthCheckInterval = 60 * time.Second
 	osdGracePeriod      = 600 * time.Second
+	// the interval between the checks of the status of an updated osd
+	healthyOSDCheckInterval = 5 * time.Second
 )
 
 // Monitor defines OSD process monitoring
@@ -37,11 +44,20 @@ type Monitor struct {
 	// lastStatus keeps track of OSDs status
 	// key - OSD id; value: time of the status change.
 	lastStatus map[int]time.Time
+
+	// clusterSpec returns the current settings of the cluster, such as the taints and labels excluding its nodes
+	clusterSpec func() *cephv1.ClusterSpec
+	// excludedSince keeps track of the excluded nodes
+	// key - node hostname; value: time the node was first seen excluded.
+	excludedSince map[string]time.Time
+	// outSince keeps track of the osds that are down and out
+	// key - OSD id; value: time the osd was first seen down and out.
+	outSince map[int]time.Time
 }
 
 // newMonitor instantiates OSD monitoring
-func NewMonitor(context *clusterd.Context, clusterName string) *Monitor {
-	return &Monitor{context, clusterName, make(map[int]time.Time)}
+func NewMonitor(context *clusterd.Context, clusterName string, clusterSpec func() *cephv1.ClusterSpec) *Monitor {
+	return &Monitor{context, clusterName, make(map[int]time.Time), clusterSpec, make(map[string]time.Time), make(map[int]time.Time)}
 }
 
 // Run runs monitoring logic for osds status at set intervals
@@ -50,11 +66,24 @@ func (m *Monitor) Start(stopCh chan struct{}) {
 	for {
 		select {
 		case <-time.After(healthCheckInterval):
+			if m.clusterSpec().MaintenanceMode {
+				logger.Debugf("the osd health check is paused in maintenance mode")
+				continue
+			}
 			logger.Debug("Checking osd processes status.")
 			err := m.osdStatus()
 			if err != nil {
 				logger.Warningf("Failed OSD status check: %+v", err)
 			}
+			if err := m.markOutExcludedNodes(); err != nil {
+				logger.Warningf("failed to mark out the osds of the excluded nodes. %+v", err)
+			}
+			if err := m.removeOutOSDs(); err != nil {
+				logger.Warningf("failed to remove the osds that are out. %+v", err)
+			}
+			if err := m.rampUpWeights(); err != nil {
+				logger.Warningf("failed to ramp up the weight of the osds. %+v", err)
+			}
 
 		case <-stopCh:
 			logger.Infof("Stopping monitoring of OSDs in namespace %s", m.clusterName)
@@ -115,3 +144,40 @@ func (m *Monitor) osdStatus() error {
 
 	return nil
 }
+
+// waitForHealthyOSD waits for an osd to be up after its deployment is updated, until the timeout of the cluster
+func (c *Cluster) waitForHealthyOSD(id int) error {
+	start := time.Now()
+	for {
+		up, err := isOSDUp(c.context, c.Namespace, id)
+		if err != nil {
+			logger.Warningf("failed to get the status of osd.%d. %+v", id, err)
+		} else if up {
+			logger.Infof("osd.%d is up", id)
+			return nil
+		}
+		if time.Since(start) >= c.waitTimeoutHealthyOSD {
+			return fmt.Errorf("osd.%d is not up after %s", id, c.waitTimeoutHealthyOSD)
+		}
+		logger.Infof("waiting for osd.%d to be up", id)
+		time.Sleep(healthyOSDCheckInterval)
+	}
+}
+
+func isOSDUp(context *clusterd.Context, clusterName string, id int) (bool, error) {
+	osdDump, err := client.GetOSDDump(context, clusterName)
+	if err != nil {
+		return false, err
+	}
+	status, _, err := osdDump.StatusByID(int64(id))
+	if err != nil {
+		return false, err
+	}
+	return status == upStatus, nil
+}
+
+// flagStuckOSD records an osd that did not become healthy so the orchestration continues with the other osds
+func (c *Cluster) flagStuckOSD(id int, err error) {
+	logger.Warningf("continuing without osd.%d. %+v", id, err)
+	c.StuckOSDs = append(c.StuckOSDs, id)
+}
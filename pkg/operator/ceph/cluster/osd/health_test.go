@@ -16,13 +16,18 @@ limitations under the License.
 package osd
 
 import (
+	"syscall"
 	"testing"
 	"time"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 
 	"github.com/stretchr/testify/assert"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestOSDStatus(t *testing.T) {
@@ -57,7 +62,7 @@ func TestOSDStatus(t *testing.T) {
 		Executor: executor,
 	}
 	// Initializing an OSD monitoring
-	osdMon := NewMonitor(context, cluster)
+	osdMon := NewMonitor(context, cluster, func() *cephv1.ClusterSpec { return &cephv1.ClusterSpec{} })
 	// Run OSD monitoring routine
 	err := osdMon.osdStatus()
 	assert.Nil(t, err)
@@ -78,8 +83,96 @@ func TestOSDStatus(t *testing.T) {
 
 func TestMonitorStart(t *testing.T) {
 	stopCh := make(chan struct{})
-	osdMon := NewMonitor(&clusterd.Context{}, "cluster")
+	osdMon := NewMonitor(&clusterd.Context{}, "cluster", func() *cephv1.ClusterSpec { return &cephv1.ClusterSpec{} })
 	logger.Infof("starting osd monitor")
 	go osdMon.Start(stopCh)
 	close(stopCh)
 }
+
+func TestWaitForHealthyOSD(t *testing.T) {
+	healthyOSDCheckInterval = time.Millisecond
+
+	dumps := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "dump" {
+				dumps++
+				// the osd is up after the second check
+				if dumps > 1 {
+					return `{"osds": [{"osd": 0, "up": 1, "in": 1}, {"osd": 1, "up": 0, "in": 1}]}`, nil
+				}
+				return `{"osds": [{"osd": 0, "up": 0, "in": 1}, {"osd": 1, "up": 0, "in": 1}]}`, nil
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{context: &clusterd.Context{Executor: executor}, Namespace: "ns", waitTimeoutHealthyOSD: 50 * time.Millisecond}
+
+	err := c.waitForHealthyOSD(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, dumps)
+
+	// the osd never comes up
+	err = c.waitForHealthyOSD(1)
+	assert.NotNil(t, err)
+
+	// an unknown osd is not up
+	err = c.waitForHealthyOSD(2)
+	assert.NotNil(t, err)
+
+	c.flagStuckOSD(1, err)
+	assert.Equal(t, []int{1}, c.StuckOSDs)
+}
+
+func TestRemoveOutOSDs(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	_, err := clientset.Extensions().Deployments("ns").Create(&v1beta1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1", Namespace: "ns"}})
+	assert.Nil(t, err)
+
+	safe := false
+	var removed []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "dump" {
+				return `{"osds": [{"osd": 0, "up": 1, "in": 1}, {"osd": 1, "up": 0, "in": 0}, {"osd": 2, "up": 0, "in": 1}]}`, nil
+			}
+			if args[0] == "osd" && args[1] == "safe-to-destroy" {
+				if !safe {
+					// ceph returns EBUSY while pgs are mapped to the osd
+					return "", exectest.ExitError(int(syscall.EBUSY))
+				}
+				return "", nil
+			}
+			if args[0] == "osd" && args[1] == "rm" {
+				removed = append(removed, args[2])
+			}
+			return "", nil
+		},
+	}
+
+	spec := &cephv1.ClusterSpec{}
+	m := NewMonitor(&clusterd.Context{Clientset: clientset, Executor: executor}, "ns", func() *cephv1.ClusterSpec { return spec })
+
+	// the osds are not tracked when the removal is disabled
+	assert.Nil(t, m.removeOutOSDs())
+	assert.Equal(t, 0, len(m.outSince))
+
+	// only the osd down and out is tracked, and not removed before the grace period
+	spec.RemoveOSDsIfOutAndSafeToRemove = true
+	spec.RemoveOSDsAfterSeconds = 1
+	assert.Nil(t, m.removeOutOSDs())
+	assert.Equal(t, 1, len(m.outSince))
+	assert.Equal(t, 0, len(removed))
+
+	// the osd is not removed until it is safe to destroy
+	m.outSince[1] = time.Now().Add(-2 * time.Second)
+	assert.Nil(t, m.removeOutOSDs())
+	assert.Equal(t, 0, len(removed))
+
+	safe = true
+	assert.Nil(t, m.removeOutOSDs())
+	assert.Equal(t, []string{"1"}, removed)
+	assert.Equal(t, 0, len(m.outSince))
+	_, err = clientset.Extensions().Deployments("ns").Get("rook-ceph-osd-1", metav1.GetOptions{})
+	assert.NotNil(t, err)
+}
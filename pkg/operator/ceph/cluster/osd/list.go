@@ -0,0 +1,157 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+// OSDSummary combines the state, the crush location, the devices and the usage of an osd reported by ceph with the
+// node the operator runs the osd on
+type OSDSummary struct {
+	ID          int     `json:"id"`
+	Node        string  `json:"node"`
+	Device      string  `json:"device,omitempty"`
+	Class       string  `json:"class,omitempty"`
+	Up          bool    `json:"up"`
+	In          bool    `json:"in"`
+	CrushWeight float64 `json:"crushWeight"`
+	SizeKB      uint64  `json:"sizeKB"`
+	UsedKB      uint64  `json:"usedKB"`
+	AvailKB     uint64  `json:"availKB"`
+	Utilization float64 `json:"utilization"`
+	PGs         int     `json:"pgs"`
+}
+
+// ListOSDs returns the summary of all the osds of the cluster in the given namespace, sorted by id
+func ListOSDs(context *clusterd.Context, namespace string) ([]OSDSummary, error) {
+	dump, err := client.GetOSDDump(context, namespace)
+	if err != nil {
+		return nil, err
+	}
+	crushMap, err := client.GetCrushMap(context, namespace)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := client.GetOSDUsage(context, namespace)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := client.GetOSDMetadata(context, namespace)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := getOSDNodes(context, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := map[int]string{}
+	for _, d := range crushMap.Devices {
+		classes[d.ID] = d.Class
+	}
+	hosts := map[int]string{}
+	for _, b := range crushMap.Buckets {
+		if b.TypeName != "host" {
+			continue
+		}
+		for _, item := range b.Items {
+			if item.ID >= 0 {
+				hosts[item.ID] = b.Name
+			}
+		}
+	}
+
+	devices := map[int]string{}
+	for _, m := range metadata {
+		if m.Hostname != "" {
+			hosts[m.ID] = m.Hostname
+		}
+		devices[m.ID] = m.Devices
+		if devices[m.ID] == "" {
+			// ceph reports the devices of an osd only since mimic
+			devices[m.ID] = m.BlockPartPath
+		}
+	}
+
+	var osds []OSDSummary
+	for _, o := range dump.OSDs {
+		id, err := o.OSD.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("bad osd id %s in osd dump. %+v", o.OSD, err)
+		}
+		summary := OSDSummary{
+			ID:     int(id),
+			Node:   hosts[int(id)],
+			Device: devices[int(id)],
+			Class:  classes[int(id)],
+			Up:     o.Up.String() == "1",
+			In:     o.In.String() == "1",
+		}
+		if node, ok := nodes[summary.ID]; ok {
+			summary.Node = node
+		}
+		if u := usage.ByID(summary.ID); u != nil {
+			summary.CrushWeight, _ = u.CrushWeight.Float64()
+			summary.SizeKB = parseUint(u.KB)
+			summary.UsedKB = parseUint(u.UsedKB)
+			summary.AvailKB = parseUint(u.AvailKB)
+			summary.Utilization, _ = u.Utilization.Float64()
+			pgs, _ := u.Pgs.Int64()
+			summary.PGs = int(pgs)
+		}
+		osds = append(osds, summary)
+	}
+
+	sort.Slice(osds, func(i, j int) bool { return osds[i].ID < osds[j].ID })
+	return osds, nil
+}
+
+// getOSDNodes returns the node selected by the deployment of each osd. The orchestration status of a node cannot be
+// used since it is deleted once the node is provisioned.
+func getOSDNodes(context *clusterd.Context, namespace string) (map[int]string, error) {
+	selector := fmt.Sprintf("%s=%s", k8sutil.AppAttr, appName)
+	deployments, err := context.Clientset.Extensions().Deployments(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the osd deployments. %+v", err)
+	}
+
+	nodes := map[int]string{}
+	for _, d := range deployments.Items {
+		id, err := strconv.Atoi(d.Labels[osdLabelKey])
+		if err != nil {
+			continue
+		}
+		if node, ok := d.Spec.Template.Spec.NodeSelector[apis.LabelHostname]; ok {
+			nodes[id] = node
+		}
+	}
+	return nodes, nil
+}
+
+func parseUint(n fmt.Stringer) uint64 {
+	val, _ := strconv.ParseUint(n.String(), 10, 64)
+	return val
+}
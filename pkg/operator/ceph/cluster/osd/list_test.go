@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func TestListOSDs(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			switch {
+			case args[0] == "osd" && args[1] == "dump":
+				return `{"osds":[{"osd":1,"up":0,"in":1},{"osd":0,"up":1,"in":1}]}`, nil
+			case args[0] == "osd" && args[1] == "crush":
+				return `{"devices":[{"id":0,"name":"osd.0","class":"ssd"},{"id":1,"name":"osd.1","class":"hdd"}],` +
+					`"buckets":[{"id":-1,"name":"default","type_name":"root","items":[{"id":-2},{"id":-3}]},` +
+					`{"id":-2,"name":"node-a","type_name":"host","items":[{"id":0}]},` +
+					`{"id":-3,"name":"node-b","type_name":"host","items":[{"id":1}]}]}`, nil
+			case args[0] == "osd" && args[1] == "df":
+				return `{"nodes":[{"id":0,"name":"osd.0","crush_weight":0.5,"kb":1048576,"kb_used":524288,` +
+					`"kb_avail":524288,"utilization":50.0,"pgs":100}],"summary":{}}`, nil
+			case args[0] == "osd" && args[1] == "metadata":
+				return `[{"id":0,"hostname":"node-a","osd_objectstore":"bluestore","devices":"sdb"},` +
+					`{"id":1,"hostname":"node-b","osd_objectstore":"bluestore","bluestore_bdev_partition_path":"/dev/sdc2"}]`, nil
+			}
+			return "", fmt.Errorf("unexpected command %v", args)
+		},
+	}
+
+	// the deployment of osd 0 selects its node, the orchestration status of the node is already deleted
+	clientset := fake.NewSimpleClientset()
+	d := &v1beta1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rook-ceph-osd-0",
+			Namespace: "ns",
+			Labels:    map[string]string{k8sutil.AppAttr: appName, osdLabelKey: "0"},
+		},
+		Spec: v1beta1.DeploymentSpec{Template: v1.PodTemplateSpec{Spec: v1.PodSpec{
+			NodeSelector: map[string]string{apis.LabelHostname: "node-a.example.com"},
+		}}},
+	}
+	_, err := clientset.Extensions().Deployments("ns").Create(d)
+	assert.Nil(t, err)
+
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	osds, err := ListOSDs(context, "ns")
+	assert.Nil(t, err)
+	require.Equal(t, 2, len(osds))
+
+	assert.Equal(t, 0, osds[0].ID)
+	assert.Equal(t, "node-a.example.com", osds[0].Node)
+	assert.Equal(t, "sdb", osds[0].Device)
+	assert.Equal(t, "ssd", osds[0].Class)
+	assert.True(t, osds[0].Up)
+	assert.Equal(t, uint64(1048576), osds[0].SizeKB)
+	assert.Equal(t, 50.0, osds[0].Utilization)
+	assert.Equal(t, 100, osds[0].PGs)
+
+	// osd 1 has no deployment, the node is the host in its metadata and the device its partition before mimic
+	assert.Equal(t, 1, osds[1].ID)
+	assert.Equal(t, "node-b", osds[1].Node)
+	assert.Equal(t, "/dev/sdc2", osds[1].Device)
+	assert.False(t, osds[1].Up)
+	assert.True(t, osds[1].In)
+}
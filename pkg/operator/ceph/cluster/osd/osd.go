@@ -22,6 +22,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -52,6 +53,7 @@ const (
 	clusterAvailableSpaceReserve = 0.05
 	serviceAccountName           = "rook-ceph-osd"
 	unknownID                    = -1
+	defaultWaitTimeoutHealthyOSD = 10 * time.Minute
 )
 
 // Cluster keeps track of the OSDs
@@ -59,6 +61,7 @@ type Cluster struct {
 	context         *clusterd.Context
 	Namespace       string
 	placement       rookalpha.Placement
+	PodExtension    rookalpha.PodExtension
 	Keyring         string
 	rookVersion     string
 	cephVersion     cephv1.CephVersionSpec
@@ -66,8 +69,17 @@ type Cluster struct {
 	dataDirHostPath string
 	HostNetwork     bool
 	resources       v1.ResourceRequirements
+	livenessProbe   cephv1.ProbeSpec
 	ownerRef        metav1.OwnerReference
 	kv              *k8sutil.ConfigMapKVStore
+	// the time to wait for an osd to be up after its deployment is updated
+	waitTimeoutHealthyOSD time.Duration
+	// StuckOSDs are the osds that were not up before the timeout during the last orchestration
+	StuckOSDs []int
+	// the taints and labels of the nodes excluded from the placement of new osds
+	nodeExclusion cephv1.NodeExclusionSpec
+	// the hostnames of the nodes excluded during the orchestration, whose osds must not be removed
+	excludedNodes map[string]bool
 }
 
 // New creates an instance of the OSD manager
@@ -81,22 +93,32 @@ func New(
 	placement rookalpha.Placement,
 	hostNetwork bool,
 	resources v1.ResourceRequirements,
+	livenessProbe cephv1.ProbeSpec,
+	waitTimeoutHealthyOSD time.Duration,
+	nodeExclusion cephv1.NodeExclusionSpec,
 	ownerRef metav1.OwnerReference,
 ) *Cluster {
 
-	return &Cluster{
-		context:         context,
-		Namespace:       namespace,
-		placement:       placement,
-		rookVersion:     rookVersion,
-		cephVersion:     cephVersion,
-		Storage:         storageSpec,
-		dataDirHostPath: dataDirHostPath,
-		HostNetwork:     hostNetwork,
-		resources:       resources,
-		ownerRef:        ownerRef,
-		kv:              k8sutil.NewConfigMapKVStore(namespace, context.Clientset, ownerRef),
+	c := &Cluster{
+		context:               context,
+		Namespace:             namespace,
+		placement:             placement,
+		rookVersion:           rookVersion,
+		cephVersion:           cephVersion,
+		Storage:               storageSpec,
+		dataDirHostPath:       dataDirHostPath,
+		HostNetwork:           hostNetwork,
+		resources:             resources,
+		livenessProbe:         livenessProbe,
+		waitTimeoutHealthyOSD: waitTimeoutHealthyOSD,
+		nodeExclusion:         nodeExclusion,
+		ownerRef:              ownerRef,
+		kv:                    k8sutil.NewConfigMapKVStore(namespace, context.Clientset, ownerRef),
 	}
+	if c.waitTimeoutHealthyOSD == 0 {
+		c.waitTimeoutHealthyOSD = defaultWaitTimeoutHealthyOSD
+	}
+	return c
 }
 
 type OSDInfo struct {
@@ -111,17 +133,37 @@ type OSDInfo struct {
 	IsDirectory         bool   `json:"is-directory"`
 	DevicePartUUID      string `json:"device-part-uuid"`
 	CephVolumeInitiated bool   `json:"ceph-volume-initiated"`
+	Device              string `json:"device,omitempty"`
 }
 
 type OrchestrationStatus struct {
 	OSDs    []OSDInfo `json:"osds"`
 	Status  string    `json:"status"`
 	Message string    `json:"message"`
+	// ProvisionDuration is how long the provisioning of the node took
+	ProvisionDuration string `json:"provisionDuration,omitempty"`
+	// Benchmarks are the results of the benchmarks of the devices provisioned by this orchestration
+	Benchmarks []DeviceBenchmark `json:"benchmarks,omitempty"`
+	// SkippedDevices are the devices left partially prepared by an interrupted provisioning and skipped by the resume policy
+	SkippedDevices []string `json:"skippedDevices,omitempty"`
+}
+
+// DeviceBenchmark is the result of a short random 4k IO benchmark run on a device before it is provisioned
+type DeviceBenchmark struct {
+	Device string `json:"device"`
+	// ReadIOPS and WriteIOPS are the IO operations per second of the mixed random read/write load
+	ReadIOPS  float64 `json:"readIOPS"`
+	WriteIOPS float64 `json:"writeIOPS"`
+	// ReadLatencyUs and WriteLatencyUs are the mean latencies in microseconds
+	ReadLatencyUs  float64   `json:"readLatencyUs"`
+	WriteLatencyUs float64   `json:"writeLatencyUs"`
+	Time           time.Time `json:"time"`
 }
 
 // Start the osd management
 func (c *Cluster) Start() error {
 	logger.Infof("start running osds in namespace %s", c.Namespace)
+	c.StuckOSDs = nil
 
 	if c.Storage.UseAllNodes == false && len(c.Storage.Nodes) == 0 {
 		logger.Warningf("useAllNodes is set to false and no nodes are specified, no OSD pods are going to be created")
@@ -172,7 +214,7 @@ func (c *Cluster) Start() error {
 		return nil
 	}
 	logger.Infof("%d of the %d storage nodes are valid", len(validNodes), len(c.Storage.Nodes))
-	c.Storage.Nodes = validNodes
+	c.Storage.Nodes = c.excludeNodes(validNodes)
 
 	// orchestrate individual nodes, starting with any that are still ongoing (in the case that we
 	// are resuming a previous orchestration attempt)
@@ -240,6 +282,15 @@ func (c *Cluster) startProvisioning(config *provisionConfig) {
 		// create the job that prepares osds on the node
 		storeConfig := osdconfig.ToStoreConfig(n.Config)
 		metadataDevice := osdconfig.MetadataDevice(n.Config)
+		if err := c.validateStoreConfig(storeConfig, config.devicesToUse[n.Name]); err != nil {
+			message := fmt.Sprintf("invalid storage config of node %s. %+v", n.Name, err)
+			config.addError(message)
+			status := OrchestrationStatus{Status: OrchestrationStatusCompleted, Message: message}
+			if err := c.updateNodeStatus(n.Name, status); err != nil {
+				config.addError("failed to update node %s status. %+v", n.Name, err)
+			}
+			continue
+		}
 		job, err := c.makeJob(n.Name, config.devicesToUse[n.Name], n.Selection, n.Resources, storeConfig, metadataDevice, n.Location)
 		if err != nil {
 			message := fmt.Sprintf("failed to create prepare job node %s: %v", n.Name, err)
@@ -259,6 +310,28 @@ func (c *Cluster) startProvisioning(config *provisionConfig) {
 	}
 }
 
+// validateStoreConfig checks the settings of the osds of a node before they are provisioned
+func (c *Cluster) validateStoreConfig(storeConfig osdconfig.StoreConfig, devices []rookalpha.Device) error {
+	if err := validateStoreTypes(storeConfig, devices); err != nil {
+		return err
+	}
+	return osdconfig.ValidateMclockProfile(storeConfig.MclockProfile, c.cephVersion.Name)
+}
+
+// validateStoreTypes checks the store type of the node and the store types overriding it on its devices, so the
+// bluestore osds can be phased in device by device on the nodes with filestore osds
+func validateStoreTypes(storeConfig osdconfig.StoreConfig, devices []rookalpha.Device) error {
+	if err := osdconfig.ValidateStoreType(storeConfig.StoreType); err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if err := osdconfig.ValidateStoreType(device.Config[osdconfig.StoreTypeKey]); err != nil {
+			return fmt.Errorf("device %s: %+v", device.Name, err)
+		}
+	}
+	return nil
+}
+
 func (c *Cluster) runJob(job *batch.Job, nodeName string, config *provisionConfig, action string) bool {
 	if err := k8sutil.RunReplaceableJob(c.context.Clientset, job); err != nil {
 		if !errors.IsAlreadyExists(err) {
@@ -279,6 +352,13 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 
 	osds := status.OSDs
 	logger.Infof("starting %d osd daemons on node %s", len(osds), nodeName)
+	if status.ProvisionDuration != "" {
+		logger.Infof("node %s was provisioned in %s", nodeName, status.ProvisionDuration)
+	}
+	for _, b := range status.Benchmarks {
+		logger.Infof("benchmark of device %s on node %s: read %.0f iops %.0fus, write %.0f iops %.0fus",
+			b.Device, nodeName, b.ReadIOPS, b.ReadLatencyUs, b.WriteIOPS, b.WriteLatencyUs)
+	}
 
 	// fully resolve the storage config and resources for this node
 	n := c.resolveNode(nodeName)
@@ -320,8 +400,15 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 				continue
 			}
 			logger.Infof("deployment for osd %d already exists. updating if needed", osd.ID)
+			if original, err := c.context.Clientset.Extensions().Deployments(c.Namespace).Get(dp.Name, metav1.GetOptions{}); err == nil {
+				keepWeightRampUp(original, dp)
+				keepExcludedOut(original, dp)
+			}
+			// an osd that does not start must not block the update of the other osds
 			if err = k8sutil.UpdateDeploymentAndWait(c.context, dp, c.Namespace); err != nil {
-				config.addError(fmt.Sprintf("failed to update osd deployment %d. %+v", osd.ID, err))
+				c.flagStuckOSD(osd.ID, fmt.Errorf("failed to update osd deployment. %+v", err))
+			} else if err = c.waitForHealthyOSD(osd.ID); err != nil {
+				c.flagStuckOSD(osd.ID, err)
 			}
 		}
 
@@ -433,9 +520,13 @@ func (c *Cluster) cleanupRemovedNode(config *provisionConfig, nodeName, crushNam
 }
 
 func (c *Cluster) discoverStorageNodes() (map[string][]*extensions.Deployment, error) {
+	return getOSDDeploymentsByNode(c.context, c.Namespace)
+}
 
+// getOSDDeploymentsByNode returns the osd deployments keyed by the name of the node they run on
+func getOSDDeploymentsByNode(context *clusterd.Context, namespace string) (map[string][]*extensions.Deployment, error) {
 	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", appName)}
-	osdDeployments, err := c.context.Clientset.Extensions().Deployments(c.Namespace).List(listOpts)
+	osdDeployments, err := context.Clientset.Extensions().Deployments(namespace).List(listOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list osd deployment: %+v", err)
 	}
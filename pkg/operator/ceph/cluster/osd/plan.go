@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"regexp"
+	"sort"
+
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/sys"
+)
+
+// NodePlan is the storage of a node that would be provisioned for osds
+type NodePlan struct {
+	Name        string   `json:"name"`
+	Devices     []string `json:"devices,omitempty"`
+	Directories []string `json:"directories,omitempty"`
+	// the devices of the node that would not get an osd, with the reason
+	SkippedDevices map[string]string `json:"skippedDevices,omitempty"`
+}
+
+// Plan evaluates the storage spec against the nodes of the cluster and the devices found by the discover daemons,
+// keyed by node name, and returns the storage of the nodes that would be provisioned for osds. Nothing is created.
+func (c *Cluster) Plan(inventory map[string][]sys.LocalDisk) ([]NodePlan, error) {
+	hostnameMap, err := k8sutil.GetNodeHostNames(c.context.Clientset)
+	if err != nil {
+		return nil, err
+	}
+	// fall back to the node name if no hostname is set
+	nodeNames := map[string]string{}
+	for nodeName, hostname := range hostnameMap {
+		if hostname == "" {
+			hostnameMap[nodeName] = nodeName
+			hostname = nodeName
+		}
+		nodeNames[hostname] = nodeName
+	}
+
+	if c.Storage.UseAllNodes {
+		c.Storage.Nodes = nil
+		for nodeName := range inventory {
+			// the nodes that are not in the cluster anymore are not valid
+			if hostname, ok := hostnameMap[nodeName]; ok {
+				c.Storage.Nodes = append(c.Storage.Nodes, rookalpha.Node{Name: hostname})
+			}
+		}
+	}
+	validNodes := c.excludeNodes(k8sutil.GetValidNodes(c.Storage.Nodes, c.context.Clientset, c.placement))
+
+	plans := []NodePlan{}
+	for _, node := range validNodes {
+		n := c.resolveNode(node.Name)
+		if n == nil {
+			continue
+		}
+		nodeName, ok := nodeNames[n.Name]
+		if !ok {
+			nodeName = n.Name
+		}
+		plan := planDevices(inventory[nodeName], n.Devices, n.Selection.DeviceFilter, n.Selection.GetUseAllDevices(),
+			osdconfig.MetadataDevice(n.Config))
+		plan.Name = n.Name
+		for _, dir := range n.Directories {
+			plan.Directories = append(plan.Directories, dir.Path)
+		}
+		if len(plan.Devices) == 0 && len(plan.Directories) == 0 && c.dataDirHostPath != "" {
+			// the osd prepare job falls back to a directory osd in the dataDirHostPath
+			plan.Directories = []string{c.dataDirHostPath}
+		}
+		plans = append(plans, plan)
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].Name < plans[j].Name })
+	return plans, nil
+}
+
+// planDevices selects the data devices of a node like the osd prepare job, from the devices found by the discover
+// daemon instead of the devices of the host
+func planDevices(disks []sys.LocalDisk, devices []rookalpha.Device, filter string, useAllDevices bool, metadataDevice string) NodePlan {
+	plan := NodePlan{SkippedDevices: map[string]string{}}
+	for _, disk := range disks {
+		if disk.Type == sys.PartType {
+			continue
+		}
+		if disk.Filesystem != "" || len(disk.Partitions) > 0 {
+			plan.SkippedDevices[disk.Name] = "in use, it has a filesystem or partitions"
+			continue
+		}
+		if disk.Name == metadataDevice {
+			plan.SkippedDevices[disk.Name] = "metadata device"
+			continue
+		}
+
+		selected := false
+		if len(devices) > 0 {
+			for _, device := range devices {
+				if device.Name == disk.Name {
+					selected = true
+				}
+			}
+		} else if filter != "" {
+			matched, err := regexp.MatchString(filter, disk.Name)
+			selected = err == nil && matched
+		} else {
+			selected = useAllDevices
+		}
+
+		if selected {
+			plan.Devices = append(plan.Devices, disk.Name)
+		} else {
+			plan.SkippedDevices[disk.Name] = "not selected by the devices, deviceFilter or useAllDevices"
+		}
+	}
+	return plan
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPlan(t *testing.T) {
+	inventory := map[string][]sys.LocalDisk{
+		"node0": {{Name: "sda", Filesystem: "ext4"}, {Name: "sdb"}, {Name: "sdb1", Type: sys.PartType}, {Name: "nvme0n1"}},
+		"node1": {{Name: "sdb"}, {Name: "sdc", Partitions: []sys.Partition{{Name: "sdc1"}}}},
+		"node2": {{Name: "sdb"}},
+	}
+	storage := rookalpha.StorageScopeSpec{
+		UseAllNodes: true,
+		Selection:   rookalpha.Selection{DeviceFilter: "^sd."},
+		Config:      map[string]string{"metadataDevice": "nvme0n1"},
+	}
+	c := New(&clusterd.Context{Clientset: test.New(2)}, "ns", "myversion", cephv1.CephVersionSpec{}, storage, "/var/lib/rook",
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, 0, cephv1.NodeExclusionSpec{}, metav1.OwnerReference{})
+
+	// node2 is in the inventory but not in the cluster
+	plans, err := c.Plan(inventory)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(plans))
+	assert.Equal(t, "node0", plans[0].Name)
+	assert.Equal(t, []string{"sdb"}, plans[0].Devices)
+	assert.Equal(t, map[string]string{"sda": "in use, it has a filesystem or partitions", "nvme0n1": "metadata device"}, plans[0].SkippedDevices)
+	assert.Equal(t, "node1", plans[1].Name)
+	assert.Equal(t, []string{"sdb"}, plans[1].Devices)
+	assert.Equal(t, 1, len(plans[1].SkippedDevices))
+
+	// the nodes without selected devices fall back to a directory
+	c.Storage = rookalpha.StorageScopeSpec{Nodes: []rookalpha.Node{{Name: "node1", Selection: rookalpha.Selection{Devices: []rookalpha.Device{{Name: "sdx"}}}}}}
+	plans, err = c.Plan(inventory)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(plans))
+	assert.Equal(t, 0, len(plans[0].Devices))
+	assert.Equal(t, []string{"/var/lib/rook"}, plans[0].Directories)
+	assert.Equal(t, "not selected by the devices, deviceFilter or useAllDevices", plans[0].SkippedDevices["sdb"])
+}
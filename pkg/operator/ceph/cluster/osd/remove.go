@@ -33,6 +33,102 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+// the time an osd must be down and out before it is removed, unless set in the cluster
+const defaultRemoveOutOSDsAfter = 24 * time.Hour
+
+// removeOutOSDs purges the osds that are down and out for longer than the grace period of the cluster, once they are
+// safe to destroy, and deletes their deployment. The osds of the nodes lost permanently are removed without waiting
+// for an admin.
+func (m *Monitor) removeOutOSDs() error {
+	spec := m.clusterSpec()
+	if !spec.RemoveOSDsIfOutAndSafeToRemove {
+		m.outSince = map[int]time.Time{}
+		return nil
+	}
+	gracePeriod := defaultRemoveOutOSDsAfter
+	if spec.RemoveOSDsAfterSeconds > 0 {
+		gracePeriod = time.Duration(spec.RemoveOSDsAfterSeconds) * time.Second
+	}
+
+	osdDump, err := client.GetOSDDump(m.context, m.clusterName)
+	if err != nil {
+		return err
+	}
+
+	out := map[int]bool{}
+	for _, osdStatus := range osdDump.OSDs {
+		id64, err := osdStatus.OSD.Int64()
+		if err != nil {
+			continue
+		}
+		id := int(id64)
+		up, in, err := osdDump.StatusByID(id64)
+		if err != nil || up == upStatus || in == inStatus {
+			continue
+		}
+		out[id] = true
+		if _, ok := m.outSince[id]; !ok {
+			logger.Infof("osd.%d is down and out. it will be removed after %s if it is safe to destroy", id, gracePeriod)
+			m.outSince[id] = time.Now()
+		}
+	}
+	for id := range m.outSince {
+		if !out[id] {
+			logger.Infof("osd.%d is not down and out anymore", id)
+			delete(m.outSince, id)
+		}
+	}
+
+	// the osds marked out by the exclusion of their node are kept to be marked in when the node is back
+	osdsByNode, err := getOSDDeploymentsByNode(m.context, m.clusterName)
+	if err != nil {
+		return err
+	}
+	excludedOut := getExcludedOutOSDs(osdsByNode)
+
+	for id, since := range m.outSince {
+		if time.Since(since) < gracePeriod {
+			continue
+		}
+		if excludedOut[id] {
+			logger.Debugf("not removing osd.%d marked out by the exclusion of its node", id)
+			continue
+		}
+		safe, err := client.OSDSafeToDestroy(m.context, m.clusterName, id)
+		if err != nil {
+			logger.Warningf("not removing osd.%d. %+v", id, err)
+			continue
+		}
+		if !safe {
+			logger.Infof("not removing osd.%d that is down and out since %s until it is safe to destroy", id, since)
+			continue
+		}
+
+		logger.Infof("removing osd.%d that is down and out since %s", id, since)
+		if err := purgeOutOSD(m.context, m.clusterName, id); err != nil {
+			logger.Warningf("failed to remove osd.%d. %+v", id, err)
+			continue
+		}
+		delete(m.outSince, id)
+	}
+	return nil
+}
+
+// purgeOutOSD deletes the deployment of an osd whose data was already moved to the other osds and purges it from
+// the cluster
+func purgeOutOSD(context *clusterd.Context, namespace string, id int) error {
+	if err := k8sutil.DeleteDeployment(context.Clientset, namespace, fmt.Sprintf(osdAppNameFmt, id)); err != nil {
+		return fmt.Errorf("failed to delete the deployment of osd.%d. %+v", id, err)
+	}
+	if err := purgeOSD(context, namespace, id); err != nil {
+		return err
+	}
+	if err := deleteOSDFileSystem(context.Clientset, namespace, id); err != nil {
+		logger.Warningf("failed to delete osd.%d filesystem, it may need to be cleaned up manually: %+v", id, err)
+	}
+	return nil
+}
+
 func removeOSD(context *clusterd.Context, namespace, deploymentName string, id int) error {
 	// get a baseline for OSD usage so we can compare usage to it later on to know when migration has started
 	initialUsage, err := client.GetOSDUsage(context, namespace)
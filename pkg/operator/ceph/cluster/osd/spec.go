@@ -33,6 +33,7 @@ import (
 	batch "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
 	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubernetes/pkg/kubelet/apis"
 )
@@ -46,6 +47,19 @@ const (
 	osdsPerDeviceEnvVarName     = "ROOK_OSDS_PER_DEVICE"
 	encryptedDeviceEnvVarName   = "ROOK_ENCRYPTED_DEVICE"
 	osdMetadataDeviceEnvVarName = "ROOK_METADATA_DEVICE"
+	osdCacheDeviceEnvVarName    = "ROOK_OSD_CACHE_DEVICE"
+	osdCacheSizeEnvVarName      = "ROOK_OSD_CACHE_SIZE"
+	osdCacheModeEnvVarName      = "ROOK_OSD_CACHE_MODE"
+	osdTuningEnvVarName         = "ROOK_OSD_TUNING"
+	osdMclockProfileEnvVarName  = "ROOK_OSD_MCLOCK_PROFILE"
+	osdBenchmarkEnvVarName      = "ROOK_OSD_BENCHMARK_DEVICES"
+	osdWeightRampUpEnvVarName   = "ROOK_OSD_WEIGHT_RAMP_UP_STEP"
+	osdMemoryLimitEnvVarName    = "ROOK_OSD_MEMORY_LIMIT"
+	osdCPULimitEnvVarName       = "ROOK_OSD_CPU_LIMIT"
+	osdHugePagesEnvVarName      = "ROOK_OSD_HUGEPAGES"
+	resumePolicyEnvVarName      = "ROOK_RESUME_POLICY"
+	hugePagesVolumeName         = "hugepages"
+	hugePagesMountPath          = "/dev/hugepages"
 	rookBinariesMountPath       = "/rook"
 	rookBinariesVolumeName      = "rook-binaries"
 )
@@ -84,6 +98,15 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 	configVolumeMounts := opspec.RookVolumeMounts()
 	volumes := opspec.PodVolumes(c.dataDirHostPath)
 
+	if storeConfig.CPUPinning {
+		pinned, err := guaranteedResources(resources)
+		if err != nil {
+			logger.Warningf("cannot pin the cpus of osd %d. %+v", osd.ID, err)
+		} else {
+			resources = pinned
+		}
+	}
+
 	var dataDir string
 	if osd.IsDirectory {
 		// Mount the path to the directory-based osd
@@ -113,10 +136,20 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 		return nil, fmt.Errorf("empty volumes")
 	}
 
+	hugePagesMB := hugePagesSizeMB(resources)
+	if hugePagesMB > 0 {
+		// the hugepages requested by the osd are only usable through a hugetlbfs mount
+		hugePagesSource := v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{Medium: v1.StorageMediumHugePages}}
+		volumes = append(volumes, v1.Volume{Name: hugePagesVolumeName, VolumeSource: hugePagesSource})
+		volumeMounts = append(volumeMounts, v1.VolumeMount{Name: hugePagesVolumeName, MountPath: hugePagesMountPath})
+	}
+
 	storeType := config.Bluestore
 	if osd.IsFileStore {
 		storeType = config.Filestore
 	}
+	// the store type of the node does not apply to the osds of the devices overriding it
+	storeConfig.StoreType = storeType
 
 	osdID := strconv.Itoa(osd.ID)
 	tiniEnvVar := v1.EnvVar{Name: "TINI_SUBREAPER", Value: ""}
@@ -140,6 +173,12 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 	if !osd.IsDirectory {
 		configEnvVars = append(configEnvVars, v1.EnvVar{Name: "ROOK_IS_DEVICE", Value: "true"})
 	}
+	if storeConfig.Tuning != "" {
+		configEnvVars = append(configEnvVars, tuningResourcesEnvVars(resources)...)
+	}
+	if hugePagesMB > 0 {
+		configEnvVars = append(configEnvVars, v1.EnvVar{Name: osdHugePagesEnvVarName, Value: strconv.FormatInt(hugePagesMB, 10)})
+	}
 
 	commonArgs := []string{
 		"--foreground",
@@ -159,6 +198,9 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 	volumes = append(volumes, copyBinariesVolume)
 	volumeMounts = append(volumeMounts, copyBinariesContainer.VolumeMounts[0])
 
+	// the liveness probe needs the config and cluster name of the osd to find its admin socket
+	probeArgs := []string{"--conf", osd.Config, "--cluster", osd.Cluster}
+
 	var command []string
 	var args []string
 	if !osd.IsDirectory && osd.IsFileStore && !osd.CephVolumeInitiated {
@@ -190,6 +232,11 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 			"--conf", osd.Config,
 			"--cluster", "ceph",
 		}
+		if storeConfig.WeightRampUpStep > 0 {
+			// ceph-osd adds itself to the crush map when it starts for the first time, the operator ramps up its weight
+			args = append(args, "--osd-crush-initial-weight", "0")
+		}
+		probeArgs = []string{"--conf", osd.Config, "--cluster", "ceph"}
 	} else {
 		// other osds can launch the osd daemon directly
 		command = []string{"ceph-osd"}
@@ -261,6 +308,7 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 							Env:             envVars,
 							Resources:       resources,
 							SecurityContext: securityContext,
+							LivenessProbe:   opspec.LivenessProbe("osd", osdID, c.livenessProbe, probeArgs...),
 						},
 					},
 					Volumes: volumes,
@@ -271,6 +319,7 @@ func (c *Cluster) makeDeployment(nodeName string, devices []rookalpha.Device, se
 	}
 	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &deployment.ObjectMeta, &c.ownerRef)
 	c.placement.ApplyToPodSpec(&deployment.Spec.Template.Spec)
+	c.PodExtension.ApplyToPodSpec(&deployment.Spec.Template.Spec)
 	return deployment, nil
 }
 
@@ -319,16 +368,20 @@ func (c *Cluster) provisionPodTemplateSpec(devices []rookalpha.Device, selection
 		return nil, fmt.Errorf("empty volumes")
 	}
 
+	provisionContainer := c.provisionOSDContainer(devices, selection, resources, storeConfig, metadataDevice, nodeName, location, copyBinariesContainer.VolumeMounts[0])
 	podSpec := v1.PodSpec{
 		ServiceAccountName: serviceAccountName,
 		Containers: []v1.Container{
 			*copyBinariesContainer,
-			c.provisionOSDContainer(devices, selection, resources, storeConfig, metadataDevice, nodeName, location, copyBinariesContainer.VolumeMounts[0]),
+			provisionContainer,
 		},
 		RestartPolicy: restart,
 		Volumes:       volumes,
 		HostNetwork:   c.HostNetwork,
 	}
+	if storeConfig.Preflight {
+		podSpec.InitContainers = []v1.Container{c.preflightContainer(provisionContainer, copyBinariesContainer.VolumeMounts[0])}
+	}
 	if c.HostNetwork {
 		podSpec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	}
@@ -366,6 +419,7 @@ func (c *Cluster) getConfigEnvVars(storeConfig config.StoreConfig, dataDir, node
 			},
 		}},
 	}
+	envVars = append(envVars, k8sutil.LogFormatEnvVars(true)...)
 
 	if storeConfig.StoreType != "" {
 		envVars = append(envVars, v1.EnvVar{Name: osdStoreEnvVarName, Value: storeConfig.StoreType})
@@ -391,6 +445,34 @@ func (c *Cluster) getConfigEnvVars(storeConfig config.StoreConfig, dataDir, node
 		envVars = append(envVars, v1.EnvVar{Name: encryptedDeviceEnvVarName, Value: "true"})
 	}
 
+	if storeConfig.CacheDevice != "" {
+		envVars = append(envVars, v1.EnvVar{Name: osdCacheDeviceEnvVarName, Value: storeConfig.CacheDevice})
+	}
+
+	if storeConfig.CacheSizeMB != 0 {
+		envVars = append(envVars, v1.EnvVar{Name: osdCacheSizeEnvVarName, Value: strconv.Itoa(storeConfig.CacheSizeMB)})
+	}
+
+	if storeConfig.CacheMode != "" {
+		envVars = append(envVars, v1.EnvVar{Name: osdCacheModeEnvVarName, Value: storeConfig.CacheMode})
+	}
+
+	if storeConfig.Tuning != "" {
+		envVars = append(envVars, v1.EnvVar{Name: osdTuningEnvVarName, Value: storeConfig.Tuning})
+	}
+
+	if storeConfig.MclockProfile != "" {
+		envVars = append(envVars, v1.EnvVar{Name: osdMclockProfileEnvVarName, Value: storeConfig.MclockProfile})
+	}
+
+	if storeConfig.Benchmark {
+		envVars = append(envVars, v1.EnvVar{Name: osdBenchmarkEnvVarName, Value: "true"})
+	}
+
+	if storeConfig.WeightRampUpStep != 0 {
+		envVars = append(envVars, v1.EnvVar{Name: osdWeightRampUpEnvVarName, Value: strconv.Itoa(storeConfig.WeightRampUpStep)})
+	}
+
 	if location != "" {
 		envVars = append(envVars, rookalpha.LocationEnvVar(location))
 	}
@@ -402,6 +484,10 @@ func (c *Cluster) provisionOSDContainer(devices []rookalpha.Device, selection ro
 	storeConfig config.StoreConfig, metadataDevice, nodeName, location string, copyBinariesMount v1.VolumeMount) v1.Container {
 
 	envVars := c.getConfigEnvVars(storeConfig, k8sutil.DataDir, nodeName, location)
+	if storeConfig.ResumePolicy != "" {
+		// only the provisioning resumes the devices it left partially prepared
+		envVars = append(envVars, v1.EnvVar{Name: resumePolicyEnvVarName, Value: storeConfig.ResumePolicy})
+	}
 	devMountNeeded := false
 	privileged := false
 
@@ -410,10 +496,18 @@ func (c *Cluster) provisionOSDContainer(devices []rookalpha.Device, selection ro
 		deviceNames := make([]string, len(devices))
 		for i, device := range devices {
 			countSuffix := ""
-			if count, ok := device.Config[config.OSDsPerDeviceKey]; ok {
+			count, ok := device.Config[config.OSDsPerDeviceKey]
+			if ok {
 				logger.Infof("%s osds requested on device %s (node %s)", count, device.Name, nodeName)
 				countSuffix = ":" + count
 			}
+			if storeType := device.Config[config.StoreTypeKey]; storeType != "" {
+				logger.Infof("%s osds requested on device %s (node %s)", storeType, device.Name, nodeName)
+				if !ok {
+					countSuffix = ":1"
+				}
+				countSuffix += ":" + storeType
+			}
 			deviceNames[i] = device.Name + countSuffix
 		}
 		envVars = append(envVars, dataDevicesEnvVar(strings.Join(deviceNames, ",")))
@@ -478,6 +572,91 @@ func (c *Cluster) provisionOSDContainer(devices []rookalpha.Device, selection ro
 	}
 }
 
+// preflightContainer checks the prerequisites of the node with the mounts and the devices of the provisioning. The
+// osds are not provisioned on the node until the checks pass, the report is in the logs of the container. The kernel
+// settings below their recommended values are only warnings.
+func (c *Cluster) preflightContainer(provisionContainer v1.Container, copyBinariesMount v1.VolumeMount) v1.Container {
+	var volumeMounts []v1.VolumeMount
+	for _, mount := range provisionContainer.VolumeMounts {
+		// the binaries are only copied by the main containers
+		if mount.Name != copyBinariesMount.Name {
+			volumeMounts = append(volumeMounts, mount)
+		}
+	}
+	var envVars []v1.EnvVar
+	for _, env := range provisionContainer.Env {
+		if env.Name == nodeNameEnvVar("").Name || env.Name == dataDevicesEnvVar("").Name {
+			envVars = append(envVars, env)
+		}
+	}
+	// the node name only needs to resolve for the daemons on the network of the host
+	envVars = append(envVars, v1.EnvVar{Name: "ROOK_CHECK_HOSTNAME", Value: strconv.FormatBool(c.HostNetwork)})
+
+	return v1.Container{
+		// the osds do not need the kernel modules of the clients
+		Args:            []string{"ceph", "preflight", "--kernel-modules="},
+		Name:            "preflight",
+		Image:           k8sutil.MakeRookImage(c.rookVersion),
+		VolumeMounts:    volumeMounts,
+		Env:             envVars,
+		SecurityContext: provisionContainer.SecurityContext,
+	}
+}
+
+// tuningResourcesEnvVars passes the limits of the osd container to the config init container, which sizes the
+// settings of the tuning profile with them
+func tuningResourcesEnvVars(resources v1.ResourceRequirements) []v1.EnvVar {
+	var envVars []v1.EnvVar
+	if memory, ok := resources.Limits[v1.ResourceMemory]; ok {
+		envVars = append(envVars, v1.EnvVar{Name: osdMemoryLimitEnvVarName, Value: strconv.FormatInt(memory.Value(), 10)})
+	}
+	if cpu, ok := resources.Limits[v1.ResourceCPU]; ok {
+		// the shards are sized with whole cpus, a partial cpu counts as one
+		cpus := (cpu.MilliValue() + 999) / 1000
+		envVars = append(envVars, v1.EnvVar{Name: osdCPULimitEnvVarName, Value: strconv.FormatInt(cpus, 10)})
+	}
+	return envVars
+}
+
+// guaranteedResources rounds the cpus of the osd up to whole cpus and sets the requests equal to the limits. The osd pod
+// is then in the Guaranteed QoS class and gets exclusive cpus from the static policy of the kubelet CPU manager.
+func guaranteedResources(resources v1.ResourceRequirements) (v1.ResourceRequirements, error) {
+	pinned := v1.ResourceRequirements{Limits: v1.ResourceList{}, Requests: v1.ResourceList{}}
+	for name, quantity := range resources.Requests {
+		pinned.Limits[name] = quantity
+	}
+	// the limits win over the requests
+	for name, quantity := range resources.Limits {
+		pinned.Limits[name] = quantity
+	}
+
+	cpu, ok := pinned.Limits[v1.ResourceCPU]
+	if !ok {
+		return resources, fmt.Errorf("the osd resources do not set the cpu")
+	}
+	if _, ok := pinned.Limits[v1.ResourceMemory]; !ok {
+		return resources, fmt.Errorf("the osd resources do not set the memory")
+	}
+	cpus := (cpu.MilliValue() + 999) / 1000
+	pinned.Limits[v1.ResourceCPU] = *resource.NewQuantity(cpus, resource.DecimalSI)
+
+	for name, quantity := range pinned.Limits {
+		pinned.Requests[name] = quantity
+	}
+	return pinned, nil
+}
+
+// hugePagesSizeMB returns the total size of the hugepages of all sizes in the limits of the osd
+func hugePagesSizeMB(resources v1.ResourceRequirements) int64 {
+	var size int64
+	for name, quantity := range resources.Limits {
+		if strings.HasPrefix(string(name), v1.ResourceHugePagesPrefix) {
+			size += quantity.Value()
+		}
+	}
+	return size / (1024 * 1024)
+}
+
 func nodeNameEnvVar(name string) v1.EnvVar {
 	return v1.EnvVar{Name: "ROOK_NODE_NAME", Value: name}
 }
@@ -534,6 +713,18 @@ func getConfigFromContainer(osdContainer v1.Container) map[string]string {
 			cfg[config.JournalSizeMBKey] = envVar.Value
 		case osdMetadataDeviceEnvVarName:
 			cfg[config.MetadataDeviceKey] = envVar.Value
+		case osdCacheDeviceEnvVarName:
+			cfg[config.CacheDeviceKey] = envVar.Value
+		case osdCacheSizeEnvVarName:
+			cfg[config.CacheSizeMBKey] = envVar.Value
+		case osdCacheModeEnvVarName:
+			cfg[config.CacheModeKey] = envVar.Value
+		case osdTuningEnvVarName:
+			cfg[config.TuningKey] = envVar.Value
+		case osdMclockProfileEnvVarName:
+			cfg[config.MclockProfileKey] = envVar.Value
+		case osdWeightRampUpEnvVarName:
+			cfg[config.WeightRampUpKey] = envVar.Value
 		}
 	}
 
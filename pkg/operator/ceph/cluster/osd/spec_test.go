@@ -74,7 +74,7 @@ func testPodDevices(t *testing.T, dataDir, deviceName string, allDevices bool) {
 	clientset := fake.NewSimpleClientset()
 	cephVersion := cephv1.CephVersionSpec{Image: "ceph/ceph:v12.2.8"}
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephVersion,
-		storageSpec, dataDir, rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		storageSpec, dataDir, rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, 0, cephv1.NodeExclusionSpec{}, metav1.OwnerReference{})
 
 	devMountNeeded := deviceName != "" || allDevices
 
@@ -124,6 +124,7 @@ func testPodDevices(t *testing.T, dataDir, deviceName string, allDevices bool) {
 	assert.Equal(t, cephVersion.Image, cont.Image)
 	assert.Equal(t, 4, len(cont.VolumeMounts))
 	assert.Equal(t, "ceph-osd", cont.Command[0])
+	assert.Equal(t, []string{"ceph", "--conf", osd.Config, "--cluster", osd.Cluster, "daemon", "osd.0", "status"}, cont.LivenessProbe.Exec.Command)
 }
 
 func verifyEnvVar(t *testing.T, envVars []v1.EnvVar, expectedName, expectedValue string, expectedFound bool) {
@@ -157,7 +158,7 @@ func TestStorageSpecDevicesAndDirectories(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset()
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
-		storageSpec, "/var/lib/rook", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		storageSpec, "/var/lib/rook", rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, 0, cephv1.NodeExclusionSpec{}, metav1.OwnerReference{})
 
 	n := c.Storage.ResolveNode(storageSpec.Nodes[0].Name)
 	osd := OSDInfo{
@@ -221,11 +222,12 @@ func TestStorageSpecConfig(t *testing.T) {
 				Name:     "node1",
 				Location: "rack=foo",
 				Config: map[string]string{
-					"storeType":      "bluestore",
-					"databaseSizeMB": "10",
-					"walSizeMB":      "20",
-					"journalSizeMB":  "30",
-					"metadataDevice": "nvme093",
+					"storeType":        "bluestore",
+					"databaseSizeMB":   "10",
+					"walSizeMB":        "20",
+					"journalSizeMB":    "30",
+					"metadataDevice":   "nvme093",
+					"weightRampUpStep": "25",
 				},
 				Selection: rookalpha.Selection{
 					Directories: []rookalpha.Directory{{Path: "/rook/storageDir472"}},
@@ -244,7 +246,7 @@ func TestStorageSpecConfig(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset()
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
-		storageSpec, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		storageSpec, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, 0, cephv1.NodeExclusionSpec{}, metav1.OwnerReference{})
 
 	n := c.Storage.ResolveNode(storageSpec.Nodes[0].Name)
 	storeConfig := config.ToStoreConfig(storageSpec.Nodes[0].Config)
@@ -265,6 +267,7 @@ func TestStorageSpecConfig(t *testing.T) {
 	verifyEnvVar(t, container.Env, "ROOK_OSD_JOURNAL_SIZE", "30", true)
 	verifyEnvVar(t, container.Env, "ROOK_LOCATION", "rack=foo", true)
 	verifyEnvVar(t, container.Env, "ROOK_METADATA_DEVICE", "nvme093", true)
+	verifyEnvVar(t, container.Env, "ROOK_OSD_WEIGHT_RAMP_UP_STEP", "25", true)
 
 	assert.Equal(t, "100", container.Resources.Limits.Cpu().String())
 	assert.Equal(t, "1337", container.Resources.Requests.Memory().String())
@@ -294,7 +297,7 @@ func TestHostNetwork(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset()
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1.CephVersionSpec{},
-		storageSpec, "", rookalpha.Placement{}, true, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		storageSpec, "", rookalpha.Placement{}, true, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, 0, cephv1.NodeExclusionSpec{}, metav1.OwnerReference{})
 
 	n := c.Storage.ResolveNode(storageSpec.Nodes[0].Name)
 	osd := OSDInfo{
@@ -308,3 +311,96 @@ func TestHostNetwork(t *testing.T) {
 	assert.Equal(t, true, r.Spec.Template.Spec.HostNetwork)
 	assert.Equal(t, v1.DNSClusterFirstWithHostNet, r.Spec.Template.Spec.DNSPolicy)
 }
+
+func TestTuningResourcesEnvVars(t *testing.T) {
+	envVars := tuningResourcesEnvVars(v1.ResourceRequirements{})
+	assert.Equal(t, 0, len(envVars))
+
+	envVars = tuningResourcesEnvVars(v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    *resource.NewMilliQuantity(2500, resource.DecimalSI),
+			v1.ResourceMemory: *resource.NewQuantity(4294967296, resource.BinarySI),
+		},
+	})
+	verifyEnvVar(t, envVars, "ROOK_OSD_MEMORY_LIMIT", "4294967296", true)
+	verifyEnvVar(t, envVars, "ROOK_OSD_CPU_LIMIT", "3", true)
+}
+
+func TestGuaranteedResources(t *testing.T) {
+	// the memory is required to pin the cpus
+	_, err := guaranteedResources(v1.ResourceRequirements{
+		Limits: v1.ResourceList{v1.ResourceCPU: *resource.NewQuantity(2, resource.DecimalSI)},
+	})
+	assert.NotNil(t, err)
+
+	pinned, err := guaranteedResources(v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceCPU:    *resource.NewMilliQuantity(1500, resource.DecimalSI),
+			v1.ResourceMemory: resource.MustParse("4Gi"),
+		},
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    *resource.NewMilliQuantity(500, resource.DecimalSI),
+			v1.ResourceMemory: resource.MustParse("2Gi"),
+			"hugepages-2Mi":   resource.MustParse("1Gi"),
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "2", pinned.Limits.Cpu().String())
+	assert.Equal(t, "2", pinned.Requests.Cpu().String())
+	assert.Equal(t, "4Gi", pinned.Requests.Memory().String())
+	hugePages := pinned.Limits["hugepages-2Mi"]
+	assert.Equal(t, "1Gi", hugePages.String())
+	assert.Equal(t, int64(1024), hugePagesSizeMB(pinned))
+}
+
+func TestPreflightContainer(t *testing.T) {
+	storageSpec := rookalpha.StorageScopeSpec{
+		Nodes: []rookalpha.Node{
+			{
+				Name:   "node1",
+				Config: map[string]string{"preflightChecks": "true"},
+				Selection: rookalpha.Selection{
+					Devices: []rookalpha.Device{{Name: "sda"}},
+				},
+			},
+		},
+	}
+
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{},
+		storageSpec, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, 0, cephv1.NodeExclusionSpec{}, metav1.OwnerReference{})
+
+	n := c.Storage.ResolveNode(storageSpec.Nodes[0].Name)
+	storeConfig := config.ToStoreConfig(storageSpec.Nodes[0].Config)
+	job, err := c.makeJob(n.Name, n.Devices, n.Selection, v1.ResourceRequirements{}, storeConfig, "", n.Location)
+	require.Nil(t, err)
+
+	// the checks run before the provisioning, with its devices and mounts but without the binaries of the rook image
+	podSpec := job.Spec.Template.Spec
+	require.Equal(t, 1, len(podSpec.InitContainers))
+	cont := podSpec.InitContainers[0]
+	assert.Equal(t, "preflight", cont.Name)
+	assert.Equal(t, []string{"ceph", "preflight", "--kernel-modules="}, cont.Args)
+	assert.Equal(t, "rook/rook:myversion", cont.Image)
+	assert.Equal(t, 3, len(cont.Env))
+	verifyEnvVar(t, cont.Env, "ROOK_NODE_NAME", "node1", true)
+	verifyEnvVar(t, cont.Env, "ROOK_DATA_DEVICES", "sda", true)
+	// the node name does not need to resolve on the network of the pods
+	verifyEnvVar(t, cont.Env, "ROOK_CHECK_HOSTNAME", "false", true)
+	assert.Equal(t, len(podSpec.Containers[1].VolumeMounts)-1, len(cont.VolumeMounts))
+	for _, mount := range cont.VolumeMounts {
+		assert.NotEqual(t, rookBinariesVolumeName, mount.Name)
+	}
+
+	// the node name must resolve for the daemons on the network of the host
+	c.HostNetwork = true
+	job, err = c.makeJob(n.Name, n.Devices, n.Selection, v1.ResourceRequirements{}, storeConfig, "", n.Location)
+	require.Nil(t, err)
+	verifyEnvVar(t, job.Spec.Template.Spec.InitContainers[0].Env, "ROOK_CHECK_HOSTNAME", "true", true)
+
+	// the checks are optional
+	storeConfig.Preflight = false
+	job, err = c.makeJob(n.Name, n.Devices, n.Selection, v1.ResourceRequirements{}, storeConfig, "", n.Location)
+	require.Nil(t, err)
+	assert.Equal(t, 0, len(job.Spec.Template.Spec.InitContainers))
+}
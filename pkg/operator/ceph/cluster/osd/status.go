@@ -31,6 +31,8 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
 )
 
 const (
@@ -40,6 +42,8 @@ const (
 	OrchestrationStatusCompleted     = "completed"
 	OrchestrationStatusFailed        = "failed"
 	orchestrationStatusMapName       = "rook-ceph-osd-%s-status"
+	benchmarksMapName                = "rook-ceph-osd-%s-benchmarks"
+	benchmarksLabelValue             = "benchmarks"
 	orchestrationStatusKey           = "status"
 	provisioningLabelKey             = "provisioning"
 	nodeLabelKey                     = "node"
@@ -85,6 +89,55 @@ func UpdateNodeStatus(kv *k8sutil.ConfigMapKVStore, node string, status Orchestr
 	return nil
 }
 
+// SaveDeviceBenchmarks stores the benchmark of each device in the benchmarks config map of the node. The results of the
+// devices benchmarked in previous orchestrations are kept.
+func SaveDeviceBenchmarks(kv *k8sutil.ConfigMapKVStore, node string, benchmarks []DeviceBenchmark) error {
+	labels := map[string]string{
+		k8sutil.AppAttr: appName,
+		nodeLabelKey:    node,
+		"type":          benchmarksLabelValue,
+	}
+
+	for _, benchmark := range benchmarks {
+		b, _ := json.Marshal(benchmark)
+		if err := kv.SetValueWithLabels(k8sutil.TruncateNodeName(benchmarksMapName, node), benchmark.Device, string(b), labels); err != nil {
+			return fmt.Errorf("failed to save benchmark of device %s on node %s. %+v", benchmark.Device, node, err)
+		}
+	}
+	return nil
+}
+
+// StaleNodeConfigMaps returns the orchestration status and benchmarks config maps of the nodes which were removed
+// from the kubernetes cluster. The config maps are kept if the nodes cannot be listed.
+func StaleNodeConfigMaps(clientset kubernetes.Interface, namespace string) ([]corev1.ConfigMap, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes. %+v", err)
+	}
+	if len(nodes.Items) == 0 {
+		return nil, nil
+	}
+	// the nodes of the storage spec are named by their name or by their hostname
+	existing := map[string]bool{}
+	for _, node := range nodes.Items {
+		existing[node.Name] = true
+		existing[node.Labels[apis.LabelHostname]] = true
+	}
+
+	selector := fmt.Sprintf("%s=%s,%s", k8sutil.AppAttr, appName, nodeLabelKey)
+	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the config maps of the nodes. %+v", err)
+	}
+	stale := []corev1.ConfigMap{}
+	for _, cm := range configMaps.Items {
+		if node := cm.Labels[nodeLabelKey]; node != "" && !existing[node] {
+			stale = append(stale, cm)
+		}
+	}
+	return stale, nil
+}
+
 func (c *Cluster) handleOrchestrationFailure(config *provisionConfig, nodeName, message string) {
 	config.addError(message)
 	status := OrchestrationStatus{Status: OrchestrationStatusFailed, Message: message}
@@ -255,6 +308,10 @@ func (c *Cluster) handleStatusConfigMapStatus(nodeName string, config *provision
 
 	logger.Infof("osd orchestration status for node %s is %s", nodeName, status.Status)
 	if status.Status == OrchestrationStatusCompleted {
+		if len(status.SkippedDevices) > 0 {
+			logger.Warningf("devices %v of node %s were left partially prepared and are skipped. wipe them or change the resume policy to prepare them again",
+				status.SkippedDevices, nodeName)
+		}
 		if configOSDs {
 			c.startOSDDaemonsOnNode(nodeName, config, configMap, status)
 		}
@@ -284,7 +341,8 @@ func (c *Cluster) findRemovedNodes() (map[string][]*extensions.Deployment, error
 	}
 
 	for existingNode, osdDeployments := range discoveredNodes {
-		found := false
+		// the osds of the excluded nodes are marked out by the monitor, not removed
+		found := c.excludedNodes[existingNode]
 		for _, declaredNode := range c.Storage.Nodes {
 			// discovered storage node still exists in the current storage spec, move on to next discovered node
 			if existingNode == declaredNode.Name {
@@ -37,7 +37,7 @@ import (
 func TestOrchestrationStatus(t *testing.T) {
 	clientset := fake.NewSimpleClientset()
 	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "myversion", cephv1.CephVersionSpec{},
-		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, 0, cephv1.NodeExclusionSpec{}, metav1.OwnerReference{})
 	kv := k8sutil.NewConfigMapKVStore(c.Namespace, clientset, metav1.OwnerReference{})
 	nodeName := "mynode"
 	cmName := fmt.Sprintf(orchestrationStatusMapName, nodeName)
@@ -118,3 +118,22 @@ func waitForOrchestrationCompletion(c *Cluster, nodeName string, startCompleted
 		<-time.After(50 * time.Millisecond)
 	}
 }
+
+func TestSaveDeviceBenchmarks(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	kv := k8sutil.NewConfigMapKVStore("ns", clientset, metav1.OwnerReference{})
+
+	err := SaveDeviceBenchmarks(kv, "node1", []DeviceBenchmark{{Device: "sdb", ReadIOPS: 100}})
+	assert.Nil(t, err)
+	err = SaveDeviceBenchmarks(kv, "node1", []DeviceBenchmark{{Device: "sdc", ReadIOPS: 200}})
+	assert.Nil(t, err)
+
+	// the benchmarks of the previous orchestrations are kept
+	cm, err := clientset.CoreV1().ConfigMaps("ns").Get("rook-ceph-osd-node1-benchmarks", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(cm.Data))
+	var benchmark DeviceBenchmark
+	assert.Nil(t, json.Unmarshal([]byte(cm.Data["sdc"]), &benchmark))
+	assert.Equal(t, float64(200), benchmark.ReadIOPS)
+	assert.Equal(t, "benchmarks", cm.Labels["type"])
+}
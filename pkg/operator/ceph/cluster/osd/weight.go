@@ -0,0 +1,198 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// weightRampUpAnnotation records on the deployment of an osd the last crush weight set by its ramp up, or that the
+	// ramp up is complete
+	weightRampUpAnnotation = "ceph.rook.io/weightRampUp"
+	weightRampUpComplete   = "complete"
+	// the crush weights are stored in fixed point by ceph
+	weightTolerance = 0.001
+)
+
+type weightRampUp struct {
+	step       int
+	deployment *extensions.Deployment
+}
+
+// rampUpWeights steps up the crush weight of the new osds configured with a weight ramp up, until they reach the
+// weight of their capacity. Only the osds of a single crush host are stepped up at a time, and only once all the pgs
+// are clean again, so the data moves to the new osds in small batches instead of a single large rebalance. The ramp
+// up of an osd stops for good once it reaches its full weight, or when its weight is changed by something else than
+// the ramp up, such as the removal of the osd or an admin.
+func (m *Monitor) rampUpWeights() error {
+	rampUps, err := m.weightRampUps()
+	if err != nil {
+		return err
+	}
+	if len(rampUps) == 0 {
+		return nil
+	}
+
+	usage, err := client.GetOSDUsage(m.context, m.clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to get the osd usage. %+v", err)
+	}
+	osdDump, err := client.GetOSDDump(m.context, m.clusterName)
+	if err != nil {
+		return err
+	}
+
+	type rampUp struct {
+		id         int
+		weight     float64
+		deployment string
+	}
+	hosts := map[string][]rampUp{}
+	for _, osd := range usage.OSDNodes {
+		r, ok := rampUps[osd.ID]
+		if !ok {
+			continue
+		}
+		// the osds that are out are being drained or removed
+		up, in, err := osdDump.StatusByID(int64(osd.ID))
+		if err != nil || up != upStatus || in != inStatus {
+			continue
+		}
+		kb, err := osd.KB.Float64()
+		if err != nil || kb == 0 {
+			continue
+		}
+		weight, err := osd.CrushWeight.Float64()
+		if err != nil {
+			continue
+		}
+		if last, ok := r.deployment.Annotations[weightRampUpAnnotation]; ok {
+			lastWeight, err := strconv.ParseFloat(last, 64)
+			if err != nil || math.Abs(weight-lastWeight) > weightTolerance {
+				logger.Infof("the weight of osd.%d was changed to %.4f outside of its ramp up. stopping the ramp up", osd.ID, weight)
+				m.completeWeightRampUp(osd.ID, r.deployment.Name)
+				continue
+			}
+		}
+		// the same weight as the one of the osds added without ramp up, in TiB
+		target := kb / 1073741824.0
+		if weight >= target-weightTolerance {
+			logger.Infof("osd.%d reached its full weight %.4f. the ramp up is complete", osd.ID, weight)
+			m.completeWeightRampUp(osd.ID, r.deployment.Name)
+			continue
+		}
+
+		host, err := client.GetCrushHostName(m.context, m.clusterName, osd.ID)
+		if err != nil {
+			logger.Warningf("failed to find the host of osd.%d. %+v", osd.ID, err)
+			continue
+		}
+		hosts[host] = append(hosts[host], rampUp{id: osd.ID, weight: math.Min(target, weight+target*float64(r.step)/100), deployment: r.deployment.Name})
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	// wait for the data moved by the previous step
+	if err := client.IsClusterClean(m.context, m.clusterName); err != nil {
+		logger.Debugf("waiting for the pgs to be clean to ramp up the weight of the osds. %+v", err)
+		return nil
+	}
+
+	var names []string
+	for name := range hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, osd := range hosts[names[0]] {
+		logger.Infof("ramping up the weight of osd.%d on host %s to %.4f", osd.id, names[0], osd.weight)
+		if _, err := client.CrushReweight(m.context, m.clusterName, osd.id, osd.weight); err != nil {
+			return fmt.Errorf("failed to reweight osd.%d. %+v", osd.id, err)
+		}
+		if err := m.setWeightRampUp(osd.deployment, fmt.Sprintf("%.4f", osd.weight)); err != nil {
+			return fmt.Errorf("failed to save the ramp up of osd.%d. %+v", osd.id, err)
+		}
+	}
+	return nil
+}
+
+// weightRampUps returns the osds with a weight ramp up that is not complete, keyed by id, from their deployments
+func (m *Monitor) weightRampUps() (map[int]weightRampUp, error) {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", appName)}
+	deployments, err := m.context.Clientset.Extensions().Deployments(m.clusterName).List(listOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list osd deployments. %+v", err)
+	}
+
+	rampUps := map[int]weightRampUp{}
+	for i, d := range deployments.Items {
+		if d.Annotations[weightRampUpAnnotation] == weightRampUpComplete || d.DeletionTimestamp != nil {
+			continue
+		}
+		for _, c := range d.Spec.Template.Spec.InitContainers {
+			if c.Name != opspec.ConfigInitContainerName {
+				continue
+			}
+			storeConfig := config.ToStoreConfig(getConfigFromContainer(c))
+			if storeConfig.WeightRampUpStep > 0 {
+				rampUps[getIDFromDeployment(&deployments.Items[i])] = weightRampUp{step: storeConfig.WeightRampUpStep, deployment: &deployments.Items[i]}
+			}
+		}
+	}
+	return rampUps, nil
+}
+
+func (m *Monitor) completeWeightRampUp(id int, deploymentName string) {
+	if err := m.setWeightRampUp(deploymentName, weightRampUpComplete); err != nil {
+		logger.Warningf("failed to save the end of the ramp up of osd.%d. %+v", id, err)
+	}
+}
+
+func (m *Monitor) setWeightRampUp(deploymentName, value string) error {
+	d, err := m.context.Clientset.Extensions().Deployments(m.clusterName).Get(deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if d.Annotations == nil {
+		d.Annotations = map[string]string{}
+	}
+	d.Annotations[weightRampUpAnnotation] = value
+	_, err = m.context.Clientset.Extensions().Deployments(m.clusterName).Update(d)
+	return err
+}
+
+// keepWeightRampUp copies the state of the weight ramp up of the osd from its original deployment to the updated one
+func keepWeightRampUp(original, deployment *extensions.Deployment) {
+	value, ok := original.Annotations[weightRampUpAnnotation]
+	if !ok {
+		return
+	}
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[weightRampUpAnnotation] = value
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRampUpWeights(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	for id, step := range map[int]string{0: "", 1: "25", 2: "25", 3: "50"} {
+		d := &extensions.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("rook-ceph-osd-%d", id), Namespace: "ns",
+				Labels: map[string]string{k8sutil.AppAttr: appName, osdLabelKey: fmt.Sprintf("%d", id)}},
+		}
+		if id == 3 {
+			// osd.3 was ramped up, then reweighted to 0 by its removal
+			d.Annotations = map[string]string{weightRampUpAnnotation: "0.2500"}
+		}
+		if step != "" {
+			d.Spec.Template.Spec.InitContainers = []v1.Container{
+				{Name: opspec.ConfigInitContainerName, Env: []v1.EnvVar{{Name: osdWeightRampUpEnvVarName, Value: step}}},
+			}
+		}
+		_, err := clientset.Extensions().Deployments("ns").Create(d)
+		assert.Nil(t, err)
+	}
+
+	clean := false
+	weight1 := "0.5"
+	reweights := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "df" {
+				// osd.0 is not ramped up, osd.2 reached its full weight
+				return fmt.Sprintf(`{"nodes": [{"id": 0, "crush_weight": 0, "kb": 1073741824}, {"id": 1, "crush_weight": %s, "kb": 1073741824},
+					{"id": 2, "crush_weight": 2.0, "kb": 2147483648}, {"id": 3, "crush_weight": 0, "kb": 1073741824}]}`, weight1), nil
+			}
+			if args[0] == "osd" && args[1] == "dump" {
+				return `{"osds": [{"osd": 0, "up": 1, "in": 1}, {"osd": 1, "up": 1, "in": 1}, {"osd": 2, "up": 1, "in": 1}, {"osd": 3, "up": 1, "in": 1}]}`, nil
+			}
+			if args[0] == "osd" && args[1] == "find" {
+				return fmt.Sprintf(`{"osd": %s, "crush_location": {"host": "node%s"}}`, args[2], args[2]), nil
+			}
+			if args[0] == "status" {
+				if clean {
+					return `{"pgmap": {"num_pgs": 1, "pgs_by_state": [{"state_name": "active+clean", "count": 1}]}}`, nil
+				}
+				return `{"pgmap": {"num_pgs": 1, "pgs_by_state": [{"state_name": "active+remapped+backfilling", "count": 1}]}}`, nil
+			}
+			if args[0] == "osd" && args[1] == "crush" && args[2] == "reweight" {
+				reweights[args[3]] = args[4]
+				return "", nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	m := NewMonitor(&clusterd.Context{Clientset: clientset, Executor: executor}, "ns", func() *cephv1.ClusterSpec { return &cephv1.ClusterSpec{} })
+
+	// the weights are not stepped up while the data is moving
+	assert.Nil(t, m.rampUpWeights())
+	assert.Equal(t, 0, len(reweights))
+
+	// only the osds of the first host are stepped up
+	clean = true
+	assert.Nil(t, m.rampUpWeights())
+	assert.Equal(t, map[string]string{"osd.1": "0.7500"}, reweights)
+	assert.Equal(t, "0.7500", rampUpState(t, clientset, 1))
+	assert.Equal(t, weightRampUpComplete, rampUpState(t, clientset, 2))
+	assert.Equal(t, weightRampUpComplete, rampUpState(t, clientset, 3))
+
+	// osd.1 reaches its full weight
+	weight1 = "0.75"
+	reweights = map[string]string{}
+	assert.Nil(t, m.rampUpWeights())
+	assert.Equal(t, map[string]string{"osd.1": "1.0000"}, reweights)
+	weight1 = "1.0"
+	reweights = map[string]string{}
+	assert.Nil(t, m.rampUpWeights())
+	assert.Equal(t, 0, len(reweights))
+	assert.Equal(t, weightRampUpComplete, rampUpState(t, clientset, 1))
+
+	// the ramp up is not resumed when the weight is lowered again
+	weight1 = "0.1"
+	assert.Nil(t, m.rampUpWeights())
+	assert.Equal(t, 0, len(reweights))
+}
+
+func TestRampUpOutOfBand(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	d := &extensions.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1", Namespace: "ns",
+			Labels:      map[string]string{k8sutil.AppAttr: appName, osdLabelKey: "1"},
+			Annotations: map[string]string{weightRampUpAnnotation: "0.2500"}},
+	}
+	d.Spec.Template.Spec.InitContainers = []v1.Container{
+		{Name: opspec.ConfigInitContainerName, Env: []v1.EnvVar{{Name: osdWeightRampUpEnvVarName, Value: "25"}}},
+	}
+	_, err := clientset.Extensions().Deployments("ns").Create(d)
+	assert.Nil(t, err)
+
+	reweighted := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "df" {
+				// an admin reweighted the osd
+				return `{"nodes": [{"id": 1, "crush_weight": 0.6, "kb": 1073741824}]}`, nil
+			}
+			if args[0] == "osd" && args[1] == "dump" {
+				return `{"osds": [{"osd": 1, "up": 1, "in": 1}]}`, nil
+			}
+			if args[0] == "osd" && args[1] == "crush" && args[2] == "reweight" {
+				reweighted = true
+				return "", nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	m := NewMonitor(&clusterd.Context{Clientset: clientset, Executor: executor}, "ns", func() *cephv1.ClusterSpec { return &cephv1.ClusterSpec{} })
+
+	assert.Nil(t, m.rampUpWeights())
+	assert.False(t, reweighted)
+	assert.Equal(t, weightRampUpComplete, rampUpState(t, clientset, 1))
+
+	// the state of the ramp up is kept when the deployment is updated
+	updated := &extensions.Deployment{}
+	original, err := clientset.Extensions().Deployments("ns").Get("rook-ceph-osd-1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	keepWeightRampUp(original, updated)
+	assert.Equal(t, weightRampUpComplete, updated.Annotations[weightRampUpAnnotation])
+}
+
+func rampUpState(t *testing.T, clientset *fake.Clientset, id int) string {
+	d, err := clientset.Extensions().Deployments("ns").Get(fmt.Sprintf("rook-ceph-osd-%d", id), metav1.GetOptions{})
+	assert.Nil(t, err)
+	return d.Annotations[weightRampUpAnnotation]
+}
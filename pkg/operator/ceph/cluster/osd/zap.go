@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+const (
+	zapAppName    = "rook-ceph-osd-zap"
+	zapAppNameFmt = "rook-ceph-osd-zap-%s"
+	zapDeviceAttr = "device"
+)
+
+var invalidNameChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// StartZapJob launches a job that wipes a device previously used by an osd on the node. With dryRun, the job only
+// logs what would be destroyed.
+func (c *Cluster) StartZapJob(nodeName, device string, dryRun bool) error {
+	job := c.makeZapJob(nodeName, device, dryRun)
+	if err := k8sutil.RunReplaceableJob(c.context.Clientset, job); err != nil {
+		return fmt.Errorf("failed to start the zap job of device %s on node %s. %+v", device, nodeName, err)
+	}
+	logger.Infof("osd zap job %s started for device %s on node %s (dry run: %t)", job.Name, device, nodeName, dryRun)
+	return nil
+}
+
+func (c *Cluster) makeZapJob(nodeName, device string, dryRun bool) *batch.Job {
+	deviceName := path.Base(device)
+	copyBinariesVolume, copyBinariesContainer := c.getCopyBinariesContainer()
+	devVolume := v1.Volume{Name: "devices", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/dev"}}}
+	udevVolume := v1.Volume{Name: "udev", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/run/udev"}}}
+
+	args := []string{"--", path.Join(rookBinariesMountPath, "rook"), "ceph", "osd", "zap", "--device", path.Join("/dev", strings.TrimPrefix(device, "/dev/"))}
+	if dryRun {
+		args = append(args, "--dry-run")
+	}
+
+	privileged := true
+	runAsUser := int64(0)
+	labels := map[string]string{
+		k8sutil.AppAttr:     zapAppName,
+		k8sutil.ClusterAttr: c.Namespace,
+		zapDeviceAttr:       invalidNameChars.ReplaceAllString(strings.ToLower(deviceName), "-"),
+	}
+
+	podSpec := v1.PodSpec{
+		ServiceAccountName: serviceAccountName,
+		Containers: []v1.Container{
+			*copyBinariesContainer,
+			{
+				Command: []string{path.Join(rookBinariesMountPath, "tini")},
+				Args:    args,
+				Name:    "zap",
+				Image:   c.cephVersion.Image,
+				VolumeMounts: []v1.VolumeMount{
+					copyBinariesContainer.VolumeMounts[0],
+					{Name: "devices", MountPath: "/dev"},
+					{Name: "udev", MountPath: "/run/udev"},
+				},
+				Env: k8sutil.LogFormatEnvVars(true),
+				SecurityContext: &v1.SecurityContext{
+					Privileged: &privileged,
+					RunAsUser:  &runAsUser,
+				},
+			},
+		},
+		NodeSelector:  map[string]string{apis.LabelHostname: nodeName},
+		RestartPolicy: v1.RestartPolicyOnFailure,
+		Volumes:       []v1.Volume{copyBinariesVolume, devVolume, udevVolume},
+		HostNetwork:   c.HostNetwork,
+	}
+	c.placement.ApplyToPodSpec(&podSpec)
+
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      k8sutil.TruncateNodeName(zapAppNameFmt, nodeName+"-"+labels[zapDeviceAttr]),
+			Namespace: c.Namespace,
+			Labels:    labels,
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, c.Namespace, &job.ObjectMeta, &c.ownerRef)
+	return job
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func TestZapJob(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cephVersion := cephv1.CephVersionSpec{Image: "ceph/ceph:v12.2.8"}
+	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephVersion,
+		rookalpha.StorageScopeSpec{}, "", rookalpha.Placement{}, false, v1.ResourceRequirements{}, cephv1.ProbeSpec{}, 0, cephv1.NodeExclusionSpec{}, metav1.OwnerReference{})
+
+	job := c.makeZapJob("node1", "sdb", true)
+	assert.Equal(t, "rook-ceph-osd-zap-node1-sdb", job.Name)
+	assert.Equal(t, "ns", job.Namespace)
+	assert.Equal(t, "node1", job.Spec.Template.Spec.NodeSelector[apis.LabelHostname])
+	assert.Equal(t, v1.RestartPolicyOnFailure, job.Spec.Template.Spec.RestartPolicy)
+	assert.Equal(t, 2, len(job.Spec.Template.Spec.Containers))
+	assert.Equal(t, "copybins", job.Spec.Template.Spec.Containers[0].Args[2])
+	container := job.Spec.Template.Spec.Containers[1]
+	assert.Equal(t, "ceph/ceph:v12.2.8", container.Image)
+	assert.Equal(t, []string{"--", "/rook/rook", "ceph", "osd", "zap", "--device", "/dev/sdb", "--dry-run"}, container.Args)
+	assert.True(t, *container.SecurityContext.Privileged)
+	assert.Equal(t, 3, len(container.VolumeMounts))
+
+	// the device paths are turned into valid job names
+	job = c.makeZapJob("node1", "/dev/disk/by-id/ata-SAMSUNG_SSD_1", false)
+	assert.Equal(t, "rook-ceph-osd-zap-node1-ata-samsung-ssd-1", job.Name)
+	container = job.Spec.Template.Spec.Containers[1]
+	assert.Equal(t, []string{"--", "/rook/rook", "ceph", "osd", "zap", "--device", "/dev/disk/by-id/ata-SAMSUNG_SSD_1"}, container.Args)
+
+	assert.Nil(t, c.StartZapJob("node1", "sdb", false))
+	_, err := clientset.Batch().Jobs("ns").Get("rook-ceph-osd-zap-node1-sdb", metav1.GetOptions{})
+	assert.Nil(t, err)
+}
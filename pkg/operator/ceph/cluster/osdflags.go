@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// the owner of the osd flags that were already set when the operator set them, which the operator never unsets
+	adminFlagOwner = "admin"
+	// the owner of the noout flag during an upgrade of the ceph version
+	upgradeFlagOwner = "upgrade"
+)
+
+// acquireOSDFlag sets a cluster wide osd flag such as noout on behalf of an operation of the operator. The operations
+// holding the flags are kept in the status of the cluster, so the flags are released even after a restart of the
+// operator, and a flag already set by the admin stays set once the operations release it.
+func (c *cluster) acquireOSDFlag(flag, owner string) error {
+	c.osdFlagsLock.Lock()
+	defer c.osdFlagsLock.Unlock()
+
+	clust, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.crdName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s to set %s. %+v", c.Namespace, flag, err)
+	}
+	owners := clust.Status.OSDFlags[flag]
+	if !containsOwner(owners, owner) {
+		if len(owners) == 0 {
+			osdDump, err := client.GetOSDDump(c.context, c.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to check if %s is already set. %+v", flag, err)
+			}
+			if osdDump.HasFlag(flag) {
+				owners = append(owners, adminFlagOwner)
+			}
+		}
+		if clust.Status.OSDFlags == nil {
+			clust.Status.OSDFlags = map[string][]string{}
+		}
+		clust.Status.OSDFlags[flag] = append(owners, owner)
+		if _, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Update(clust); err != nil {
+			return fmt.Errorf("failed to save the owners of %s in cluster %s. %+v", flag, c.Namespace, err)
+		}
+	}
+	return client.OSDSetFlag(c.context, c.Namespace, flag)
+}
+
+// releaseOSDFlag releases a cluster wide osd flag held by an operation of the operator. The flag is unset once
+// neither another operation nor the admin holds it. Releasing a flag the operation does not hold does nothing.
+func (c *cluster) releaseOSDFlag(flag, owner string) error {
+	c.osdFlagsLock.Lock()
+	defer c.osdFlagsLock.Unlock()
+
+	clust, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.crdName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s to unset %s. %+v", c.Namespace, flag, err)
+	}
+	owners := clust.Status.OSDFlags[flag]
+	if !containsOwner(owners, owner) {
+		return nil
+	}
+	var remaining []string
+	for _, o := range owners {
+		if o != owner {
+			remaining = append(remaining, o)
+		}
+	}
+
+	if len(remaining) == 0 {
+		if err := client.OSDUnsetFlag(c.context, c.Namespace, flag); err != nil {
+			return err
+		}
+	}
+	if len(remaining) == 0 || (len(remaining) == 1 && remaining[0] == adminFlagOwner) {
+		delete(clust.Status.OSDFlags, flag)
+	} else {
+		clust.Status.OSDFlags[flag] = remaining
+	}
+	if _, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Update(clust); err != nil {
+		return fmt.Errorf("failed to save the owners of %s in cluster %s. %+v", flag, c.Namespace, err)
+	}
+	return nil
+}
+
+// osdFlagUnsetByAdmin returns whether a flag held by an operation of the operator is no longer set in ceph, in which
+// case the admin unset it and the operation should not set it again.
+func (c *cluster) osdFlagUnsetByAdmin(flag, owner string) (bool, error) {
+	c.osdFlagsLock.Lock()
+	defer c.osdFlagsLock.Unlock()
+
+	clust, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.crdName, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get cluster %s to check %s. %+v", c.Namespace, flag, err)
+	}
+	if !containsOwner(clust.Status.OSDFlags[flag], owner) {
+		return false, nil
+	}
+	osdDump, err := client.GetOSDDump(c.context, c.Namespace)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if %s is still set. %+v", flag, err)
+	}
+	return !osdDump.HasFlag(flag), nil
+}
+
+func containsOwner(owners []string, owner string) bool {
+	for _, o := range owners {
+		if o == owner {
+			return true
+		}
+	}
+	return false
+}
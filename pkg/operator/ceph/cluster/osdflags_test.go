@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newOSDFlagsTestCluster returns a cluster whose osd flag commands are recorded, with the flags initially set in ceph
+func newOSDFlagsTestCluster(t *testing.T, setFlags string) (*cluster, *[]string) {
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "dump" {
+				return `{"osds":[],"flags":"` + setFlags + `"}`, nil
+			}
+			if args[0] == "osd" {
+				commands = append(commands, args[1]+" "+args[2])
+			}
+			return "", nil
+		},
+	}
+	rookClientset := rookfake.NewSimpleClientset()
+	_, err := rookClientset.CephV1().CephClusters("ns").Create(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "ns"}})
+	assert.Nil(t, err)
+	context := &clusterd.Context{Executor: executor, RookClientset: rookClientset}
+	return &cluster{context: context, Namespace: "ns", crdName: "rook-ceph"}, &commands
+}
+
+func osdFlagOwnersOf(t *testing.T, c *cluster, flag string) []string {
+	clust, err := c.context.RookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	assert.Nil(t, err)
+	return clust.Status.OSDFlags[flag]
+}
+
+func TestOSDFlagOwners(t *testing.T) {
+	c, commands := newOSDFlagsTestCluster(t, "sortbitwise")
+
+	// the flag is unset when the last operation releases it
+	assert.Nil(t, c.acquireOSDFlag("noout", upgradeFlagOwner))
+	assert.Nil(t, c.acquireOSDFlag("noout", "other"))
+	assert.Equal(t, []string{upgradeFlagOwner, "other"}, osdFlagOwnersOf(t, c, "noout"))
+	assert.Nil(t, c.releaseOSDFlag("noout", upgradeFlagOwner))
+	assert.Equal(t, []string{"set noout", "set noout"}, *commands)
+	assert.Nil(t, c.releaseOSDFlag("noout", "other"))
+	assert.Equal(t, []string{"set noout", "set noout", "unset noout"}, *commands)
+	assert.Nil(t, osdFlagOwnersOf(t, c, "noout"))
+
+	// releasing a flag that is not held does nothing
+	*commands = nil
+	assert.Nil(t, c.releaseOSDFlag("noout", upgradeFlagOwner))
+	assert.Equal(t, 0, len(*commands))
+}
+
+func TestOSDFlagSetByAdmin(t *testing.T) {
+	c, commands := newOSDFlagsTestCluster(t, "noout,sortbitwise")
+
+	// the flag set by the admin before the upgrade stays set
+	assert.Nil(t, c.acquireOSDFlag("noout", upgradeFlagOwner))
+	assert.Equal(t, []string{adminFlagOwner, upgradeFlagOwner}, osdFlagOwnersOf(t, c, "noout"))
+	assert.Nil(t, c.releaseOSDFlag("noout", upgradeFlagOwner))
+	assert.Equal(t, []string{"set noout"}, *commands)
+	assert.Nil(t, osdFlagOwnersOf(t, c, "noout"))
+}
+
+func TestOSDFlagUnsetByAdmin(t *testing.T) {
+	// the flag held by the upgrade is still set
+	c, _ := newOSDFlagsTestCluster(t, "noout,sortbitwise")
+	unset, err := c.osdFlagUnsetByAdmin("noout", upgradeFlagOwner)
+	assert.Nil(t, err)
+	assert.False(t, unset)
+	clust, err := c.context.RookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	assert.Nil(t, err)
+	clust.Status.OSDFlags = map[string][]string{"noout": {upgradeFlagOwner}}
+	_, err = c.context.RookClientset.CephV1().CephClusters("ns").Update(clust)
+	assert.Nil(t, err)
+	unset, err = c.osdFlagUnsetByAdmin("noout", upgradeFlagOwner)
+	assert.Nil(t, err)
+	assert.False(t, unset)
+
+	// the admin unset the flag held by the upgrade
+	c, _ = newOSDFlagsTestCluster(t, "sortbitwise")
+	clust, err = c.context.RookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	assert.Nil(t, err)
+	clust.Status.OSDFlags = map[string][]string{"noout": {upgradeFlagOwner}}
+	_, err = c.context.RookClientset.CephV1().CephClusters("ns").Update(clust)
+	assert.Nil(t, err)
+	unset, err = c.osdFlagUnsetByAdmin("noout", upgradeFlagOwner)
+	assert.Nil(t, err)
+	assert.True(t, unset)
+}
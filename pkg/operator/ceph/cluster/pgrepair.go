@@ -0,0 +1,195 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// the reasons of the events of the automatic repair of the pgs
+	pgRepairedReason      = "PGRepaired"
+	pgRepairSkippedReason = "PGRepairSkipped"
+
+	defaultPGRepairMaxScrubErrors  = 10
+	defaultPGRepairIntervalSeconds = 600
+
+	// the health check of ceph counting the scrub errors
+	scrubErrorsCheck = "OSD_SCRUB_ERRORS"
+	// the pg id of a missing osd in the up and acting sets
+	crushItemNone = 2147483647
+)
+
+var (
+	// how long a repaired pg is not repaired again, while its repair is queued or running
+	pgRepairRetryInterval = time.Hour
+
+	scrubErrorsRegexp = regexp.MustCompile(`^(\d+) scrub errors?`)
+
+	// the pg states during which a repair is not safe or would not complete
+	unsafePGStates = []string{"repair", "scrubbing", "degraded", "undersized", "down", "peering", "incomplete", "stale",
+		"recovering", "recovery_wait", "backfilling", "backfill_wait", "remapped", "failed_repair"}
+)
+
+// pgRepairer repairs the inconsistent pgs of a cluster when it is safe, and records each repair as an event of the cluster
+type pgRepairer struct {
+	cluster *cluster
+	crdName string
+	// the time of the last repair of the pgs
+	repaired map[string]time.Time
+	// the number of scrub errors of the last skipped repair, so the skip is only recorded once
+	skippedErrors int
+}
+
+func newPGRepairer(cluster *cluster, crdName string) *pgRepairer {
+	return &pgRepairer{cluster: cluster, crdName: crdName, repaired: map[string]time.Time{}}
+}
+
+// Start checks the inconsistent pgs at the interval of the cluster until the cluster is stopped. The pgs are only
+// repaired if the automatic repair is enabled and the cluster is not in maintenance.
+func (r *pgRepairer) Start() {
+	for {
+		spec := r.cluster.getSpec()
+		interval := defaultPGRepairIntervalSeconds * time.Second
+		if spec.PGAutoRepair.IntervalSeconds > 0 {
+			interval = time.Duration(spec.PGAutoRepair.IntervalSeconds) * time.Second
+		}
+
+		select {
+		case <-r.cluster.stopCh:
+			logger.Infof("stopping the pg repair of cluster %s", r.cluster.Namespace)
+			return
+		case <-time.After(interval):
+			// the spec may have changed while waiting
+			if spec = r.cluster.getSpec(); spec.PGAutoRepair.Enabled && !spec.MaintenanceMode {
+				if err := r.repairOnce(time.Now()); err != nil {
+					logger.Warningf("failed to repair the inconsistent pgs of cluster %s. %+v", r.cluster.Namespace, err)
+				}
+			}
+		}
+	}
+}
+
+// repairOnce repairs the inconsistent pgs whose replicas are all up, if the cluster has fewer scrub errors than the
+// threshold
+func (r *pgRepairer) repairOnce(now time.Time) error {
+	status, err := client.Status(r.cluster.context, r.cluster.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get the status. %+v", err)
+	}
+	scrubErrors := scrubErrorCount(status)
+	if scrubErrors == 0 {
+		r.skippedErrors = 0
+		return nil
+	}
+
+	maxScrubErrors := r.cluster.getSpec().PGAutoRepair.MaxScrubErrors
+	if maxScrubErrors <= 0 {
+		maxScrubErrors = defaultPGRepairMaxScrubErrors
+	}
+	if scrubErrors > maxScrubErrors {
+		if scrubErrors != r.skippedErrors {
+			r.skippedErrors = scrubErrors
+			message := fmt.Sprintf("%d scrub errors exceed the maximum of %d for the automatic repair. the inconsistent pgs must be repaired manually",
+				scrubErrors, maxScrubErrors)
+			logger.Warningf("cluster %s: %s", r.cluster.Namespace, message)
+			return recordClusterEvent(r.cluster, r.crdName, v1.EventTypeWarning, pgRepairSkippedReason, message)
+		}
+		return nil
+	}
+	r.skippedErrors = 0
+
+	pgs, err := client.GetPGDumpBrief(r.cluster.context, r.cluster.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, pg := range pgs {
+		if !hasPGState(pg.State, "inconsistent") {
+			continue
+		}
+		if reason := unsafePGRepairReason(pg); reason != "" {
+			logger.Infof("not repairing inconsistent pg %s of cluster %s: %s", pg.ID, r.cluster.Namespace, reason)
+			continue
+		}
+		if last, ok := r.repaired[pg.ID]; ok && now.Sub(last) < pgRepairRetryInterval {
+			continue
+		}
+
+		if err := client.RepairPG(r.cluster.context, r.cluster.Namespace, pg.ID); err != nil {
+			return err
+		}
+		r.repaired[pg.ID] = now
+		message := fmt.Sprintf("repair of inconsistent pg %s started on osds %v, with %d scrub errors in the cluster", pg.ID, pg.ActingOsdIDs, scrubErrors)
+		logger.Infof("cluster %s: %s", r.cluster.Namespace, message)
+		if err := recordClusterEvent(r.cluster, r.crdName, v1.EventTypeNormal, pgRepairedReason, message); err != nil {
+			logger.Warningf("failed to record the repair event of cluster %s. %+v", r.cluster.Namespace, err)
+		}
+	}
+	return nil
+}
+
+// unsafePGRepairReason returns why a pg must not be repaired, or an empty string if all its replicas are up and the
+// pg is active and clean apart from its inconsistency
+func unsafePGRepairReason(pg client.PGDumpBrief) string {
+	if !hasPGState(pg.State, "active") || !hasPGState(pg.State, "clean") {
+		return fmt.Sprintf("the pg is %s", pg.State)
+	}
+	for _, state := range unsafePGStates {
+		if hasPGState(pg.State, state) {
+			return fmt.Sprintf("the pg is %s", pg.State)
+		}
+	}
+	if len(pg.ActingOsdIDs) == 0 || len(pg.UpOsdIDs) != len(pg.ActingOsdIDs) {
+		return fmt.Sprintf("the acting osds %v are not the up osds %v", pg.ActingOsdIDs, pg.UpOsdIDs)
+	}
+	for i, id := range pg.ActingOsdIDs {
+		if id == crushItemNone || pg.UpOsdIDs[i] != id {
+			return fmt.Sprintf("the acting osds %v are not the up osds %v", pg.ActingOsdIDs, pg.UpOsdIDs)
+		}
+	}
+	return ""
+}
+
+// scrubErrorCount returns the number of scrub errors of the OSD_SCRUB_ERRORS health check of the cluster
+func scrubErrorCount(status client.CephStatus) int {
+	check, ok := status.Health.Checks[scrubErrorsCheck]
+	if !ok {
+		return 0
+	}
+	match := scrubErrorsRegexp.FindStringSubmatch(check.Summary.Message)
+	if match == nil {
+		return 0
+	}
+	count, _ := strconv.Atoi(match[1])
+	return count
+}
+
+func hasPGState(states, state string) bool {
+	for _, s := range strings.Split(states, "+") {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
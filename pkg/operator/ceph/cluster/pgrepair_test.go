@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRepairPGs(t *testing.T) {
+	scrubErrors := "3 scrub errors"
+	var repaired []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			switch {
+			case args[0] == "status":
+				return `{"health":{"status":"HEALTH_ERR","checks":{"OSD_SCRUB_ERRORS":{"severity":"HEALTH_ERR","summary":{"message":"` + scrubErrors + `"}}}}}`, nil
+			case args[0] == "pg" && args[1] == "dump":
+				return `[{"pgid":"1.0","state":"active+clean+inconsistent","up":[0,1,2],"acting":[0,1,2]},
+					{"pgid":"1.1","state":"active+clean","up":[0,1,2],"acting":[0,1,2]},
+					{"pgid":"1.2","state":"active+undersized+degraded+inconsistent","up":[0,2147483647,2],"acting":[0,2147483647,2]},
+					{"pgid":"1.3","state":"active+clean+scrubbing+deep+inconsistent+repair","up":[1,2,0],"acting":[1,2,0]}]`, nil
+			case args[0] == "pg" && args[1] == "repair":
+				repaired = append(repaired, args[2])
+			}
+			return "", nil
+		},
+	}
+	clientset := fake.NewSimpleClientset()
+	c := &cluster{
+		Namespace: "ns",
+		Spec:      &cephv1.ClusterSpec{PGAutoRepair: cephv1.PGAutoRepairSpec{Enabled: true}},
+		context:   &clusterd.Context{Clientset: clientset, Executor: executor},
+		ownerRef:  ClusterOwnerRef("ns", "cluster-uid"),
+	}
+	getEvents := func() []v1.Event {
+		events, err := clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+		require.Nil(t, err)
+		return events.Items
+	}
+	r := newPGRepairer(c, "mycluster")
+	now := time.Now()
+
+	// only the inconsistent pg with all its replicas up is repaired
+	assert.Nil(t, r.repairOnce(now))
+	assert.Equal(t, []string{"1.0"}, repaired)
+	events := getEvents()
+	require.Equal(t, 1, len(events))
+	assert.Equal(t, pgRepairedReason, events[0].Reason)
+	assert.Equal(t, "repair of inconsistent pg 1.0 started on osds [0 1 2], with 3 scrub errors in the cluster", events[0].Message)
+
+	// the pg is not repaired again while its repair is running
+	assert.Nil(t, r.repairOnce(now.Add(time.Minute)))
+	assert.Equal(t, 1, len(repaired))
+	assert.Nil(t, r.repairOnce(now.Add(pgRepairRetryInterval+time.Minute)))
+	assert.Equal(t, 2, len(repaired))
+
+	// too many scrub errors are left to the admins, with a single event
+	scrubErrors = "25 scrub errors"
+	repaired = nil
+	assert.Nil(t, r.repairOnce(now.Add(3*pgRepairRetryInterval)))
+	assert.Nil(t, r.repairOnce(now.Add(4*pgRepairRetryInterval)))
+	assert.Equal(t, 0, len(repaired))
+	events = getEvents()
+	require.Equal(t, 3, len(events))
+	skipped := 0
+	for _, event := range events {
+		if event.Reason == pgRepairSkippedReason {
+			skipped++
+			assert.Equal(t, v1.EventTypeWarning, event.Type)
+		}
+	}
+	assert.Equal(t, 1, skipped)
+
+	c.Spec.PGAutoRepair.MaxScrubErrors = 30
+	assert.Nil(t, r.repairOnce(now.Add(5*pgRepairRetryInterval)))
+	assert.Equal(t, []string{"1.0"}, repaired)
+}
+
+func TestUnsafePGRepairReason(t *testing.T) {
+	pg := client.PGDumpBrief{ID: "1.0", State: "active+clean+inconsistent", UpOsdIDs: []int{0, 1}, ActingOsdIDs: []int{0, 1}}
+	assert.Equal(t, "", unsafePGRepairReason(pg))
+
+	pg.State = "active+clean+inconsistent+failed_repair"
+	assert.Equal(t, "the pg is active+clean+inconsistent+failed_repair", unsafePGRepairReason(pg))
+	pg.State = "active+recovery_wait+inconsistent"
+	assert.NotEqual(t, "", unsafePGRepairReason(pg))
+
+	pg.State = "active+clean+inconsistent"
+	pg.ActingOsdIDs = []int{0, 2}
+	assert.Equal(t, "the acting osds [0 2] are not the up osds [0 1]", unsafePGRepairReason(pg))
+}
+
+func TestScrubErrorCount(t *testing.T) {
+	status := client.CephStatus{}
+	assert.Equal(t, 0, scrubErrorCount(status))
+	status.Health.Checks = map[string]client.CheckMessage{}
+	check := client.CheckMessage{}
+	check.Summary.Message = "1 scrub error"
+	status.Health.Checks[scrubErrorsCheck] = check
+	assert.Equal(t, 1, scrubErrorCount(status))
+	check.Summary.Message = "12 scrub errors"
+	status.Health.Checks[scrubErrorsCheck] = check
+	assert.Equal(t, 12, scrubErrorCount(status))
+}
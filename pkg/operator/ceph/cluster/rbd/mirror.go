@@ -39,15 +39,16 @@ const (
 
 // Cluster represents the Rook and environment configuration settings needed to set up rbd mirroring.
 type Mirroring struct {
-	Namespace   string
-	placement   rookalpha.Placement
-	context     *clusterd.Context
-	resources   v1.ResourceRequirements
-	ownerRef    metav1.OwnerReference
-	spec        cephv1.RBDMirroringSpec
-	cephVersion cephv1.CephVersionSpec
-	rookVersion string
-	hostNetwork bool
+	Namespace    string
+	placement    rookalpha.Placement
+	PodExtension rookalpha.PodExtension
+	context      *clusterd.Context
+	resources    v1.ResourceRequirements
+	ownerRef     metav1.OwnerReference
+	spec         cephv1.RBDMirroringSpec
+	cephVersion  cephv1.CephVersionSpec
+	rookVersion  string
+	hostNetwork  bool
 }
 
 // New creates an instance of the rbd mirroring
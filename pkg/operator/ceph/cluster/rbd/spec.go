@@ -48,6 +48,7 @@ func (m *Mirroring) makeDeployment(resourceName, daemonName string) *extensions.
 		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	}
 	m.placement.ApplyToPodSpec(&podSpec.Spec)
+	m.PodExtension.ApplyToPodSpec(&podSpec.Spec)
 
 	replicas := int32(1)
 	d := &extensions.Deployment{
@@ -69,7 +70,7 @@ func (m *Mirroring) makeConfigInitContainer(resourceName, daemonName string) v1.
 			"config-init",
 		},
 		Image: k8sutil.MakeRookImage(m.rookVersion),
-		Env: []v1.EnvVar{
+		Env: append([]v1.EnvVar{
 			{Name: "ROOK_USERNAME", Value: fullDaemonName(daemonName)},
 			{Name: "ROOK_KEYRING",
 				ValueFrom: &v1.EnvVarSource{
@@ -80,8 +81,9 @@ func (m *Mirroring) makeConfigInitContainer(resourceName, daemonName string) v1.
 			k8sutil.PodIPEnvVar(k8sutil.PrivateIPEnvVar),
 			k8sutil.PodIPEnvVar(k8sutil.PublicIPEnvVar),
 			opmon.EndpointEnvVar(),
+			opmon.DNSSRVNameEnvVar(),
 			k8sutil.ConfigOverrideEnvVar(),
-		},
+		}, k8sutil.LogFormatEnvVars(true)...),
 		VolumeMounts: opspec.RookVolumeMounts(),
 		Resources:    m.resources,
 	}
@@ -0,0 +1,31 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+)
+
+// ReconcileAnnotation orchestrates the cluster again without a change of its settings when set or changed on a cluster,
+// such as to the current time.
+const ReconcileAnnotation = "ceph.rook.io/reconcile"
+
+// reconcileRequested returns whether the reconcile annotation of the cluster was set or changed by the update
+func reconcileRequested(oldClust, newClust *cephv1.CephCluster) bool {
+	requested, ok := newClust.Annotations[ReconcileAnnotation]
+	return ok && oldClust.Annotations[ReconcileAnnotation] != requested
+}
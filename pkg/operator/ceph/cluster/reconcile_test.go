@@ -0,0 +1,41 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcileRequested(t *testing.T) {
+	clust := func(requested string) *cephv1.CephCluster {
+		c := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+		if requested != "" {
+			c.Annotations[ReconcileAnnotation] = requested
+		}
+		return c
+	}
+
+	assert.False(t, reconcileRequested(clust(""), clust("")))
+	assert.True(t, reconcileRequested(clust(""), clust("2018-10-01T10:00:00Z")))
+	assert.False(t, reconcileRequested(clust("2018-10-01T10:00:00Z"), clust("2018-10-01T10:00:00Z")))
+	assert.True(t, reconcileRequested(clust("2018-10-01T10:00:00Z"), clust("2018-10-01T11:00:00Z")))
+	assert.False(t, reconcileRequested(clust("2018-10-01T10:00:00Z"), clust("")))
+}
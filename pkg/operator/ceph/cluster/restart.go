@@ -0,0 +1,164 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RestartAnnotation restarts the daemons of a type one at a time when set on a cluster, such as "osd" after a
+	// change of config the daemons only read at startup. The operator removes the annotation once the restart is done.
+	RestartAnnotation = "ceph.rook.io/restart"
+
+	// the owner of the noout flag during the restart of the osds
+	restartFlagOwner = "restart"
+)
+
+var (
+	// the apps of the deployments of the daemon types that can be restarted
+	restartableDaemons = map[string]string{
+		"mon":        "rook-ceph-mon",
+		"mgr":        "rook-ceph-mgr",
+		"osd":        "rook-ceph-osd",
+		"mds":        "rook-ceph-mds",
+		"rgw":        "rook-ceph-rgw",
+		"rbd-mirror": "rook-ceph-rbd-mirror",
+	}
+
+	updateDeploymentAndWait = k8sutil.UpdateDeploymentAndWait
+)
+
+// checkRestart starts a rolling restart of the daemons when the restart annotation of the cluster is set. The
+// annotation stays on the cluster until the restart is done, so a restart requested while the cluster is in
+// maintenance mode or while the operator is stopped is started later. A restart of another type of daemon requested
+// during a restart is started once the restart is done.
+func (c *ClusterController) checkRestart(clust *cephv1.CephCluster, cluster *cluster) {
+	if _, ok := clust.Annotations[RestartAnnotation]; !ok {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&cluster.restarting, 0, 1) {
+		logger.Debugf("restart of the daemons of cluster %s already in progress", cluster.Namespace)
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&cluster.restarting, 0)
+		for {
+			current, err := c.context.RookClientset.CephV1().CephClusters(cluster.Namespace).Get(clust.Name, metav1.GetOptions{})
+			if err != nil {
+				logger.Errorf("failed to get the restart annotation of cluster %s. %+v", cluster.Namespace, err)
+				return
+			}
+			daemonType, ok := current.Annotations[RestartAnnotation]
+			if !ok || current.Spec.MaintenanceMode {
+				return
+			}
+			if err := cluster.restartDaemons(daemonType); err != nil {
+				logger.Errorf("failed to restart the %s daemons of cluster %s. %+v", daemonType, cluster.Namespace, err)
+			}
+			if err := c.removeRestartAnnotation(current.Namespace, current.Name, daemonType); err != nil {
+				logger.Errorf("failed to remove the restart annotation of cluster %s. %+v", cluster.Namespace, err)
+				return
+			}
+		}
+	}()
+}
+
+// restartDaemons restarts the deployments of a type of daemon one at a time, waiting for the cluster to be healthy
+// before each restart with the same checks as during an upgrade. The cluster is not orchestrated during the restart.
+func (c *cluster) restartDaemons(daemonType string) error {
+	app, ok := restartableDaemons[daemonType]
+	if !ok {
+		var types []string
+		for t := range restartableDaemons {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		return fmt.Errorf("invalid %s annotation %q. must be one of %s", RestartAnnotation, daemonType, strings.Join(types, ", "))
+	}
+
+	c.orchestrationLock.Lock()
+	defer c.orchestrationLock.Unlock()
+
+	deployments, err := k8sutil.GetDeployments(c.context.Clientset, c.Namespace, fmt.Sprintf("%s=%s", k8sutil.AppAttr, app))
+	if err != nil {
+		return err
+	}
+	sort.Slice(deployments.Items, func(i, j int) bool { return deployments.Items[i].Name < deployments.Items[j].Name })
+
+	// the restarted osds must not be marked out and their data rebalanced
+	if daemonType == "osd" {
+		if err := c.acquireOSDFlag("noout", restartFlagOwner); err != nil {
+			return err
+		}
+		defer func() {
+			if err := c.releaseOSDFlag("noout", restartFlagOwner); err != nil {
+				logger.Errorf("failed to unset noout after the restart of the osds. %+v", err)
+			}
+		}()
+	}
+
+	restartedAt := time.Now().UTC().Format(time.RFC3339)
+	logger.Infof("restarting %d %s daemons of cluster %s", len(deployments.Items), daemonType, c.Namespace)
+	for i := range deployments.Items {
+		select {
+		case <-c.stopCh:
+			return fmt.Errorf("cluster %s was deleted", c.Namespace)
+		default:
+		}
+
+		d := &deployments.Items[i]
+		if err := c.checkUpgradeHealth(d.Name); err != nil {
+			return err
+		}
+		if d.Spec.Template.Annotations == nil {
+			d.Spec.Template.Annotations = map[string]string{}
+		}
+		d.Spec.Template.Annotations[k8sutil.RestartedAtAnnotation] = restartedAt
+		logger.Infof("restarting %s", d.Name)
+		if err := updateDeploymentAndWait(c.context, d, c.Namespace); err != nil {
+			return fmt.Errorf("failed to restart %s. %+v", d.Name, err)
+		}
+	}
+
+	logger.Infof("restarted the %s daemons of cluster %s", daemonType, c.Namespace)
+	return nil
+}
+
+// removeRestartAnnotation removes the restart annotation of the cluster once the type of daemon is restarted, so the
+// same type of daemon can be restarted again. The annotation is kept if another type of daemon was requested since.
+func (c *ClusterController) removeRestartAnnotation(namespace, name, daemonType string) error {
+	clust, err := c.context.RookClientset.CephV1().CephClusters(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if value, ok := clust.Annotations[RestartAnnotation]; !ok || value != daemonType {
+		return nil
+	}
+	delete(clust.Annotations, RestartAnnotation)
+	_, err = c.context.RookClientset.CephV1().CephClusters(namespace).Update(clust)
+	return err
+}
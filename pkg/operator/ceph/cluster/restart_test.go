@@ -0,0 +1,125 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRestartDaemons(t *testing.T) {
+	upgradeCheckInterval = time.Millisecond
+	var deploymentsUpdated *[]*extensions.Deployment
+	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
+
+	status := `{"health":{"status":"HEALTH_OK","checks":{}}}`
+	var flags []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			switch args[0] {
+			case "status":
+				return status, nil
+			case "osd":
+				if args[1] == "dump" {
+					return `{"osds":[],"flags":"sortbitwise"}`, nil
+				}
+				flags = append(flags, args[1]+" "+args[2])
+				return "", nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	clientset := fake.NewSimpleClientset()
+	for _, name := range []string{"rook-ceph-osd-1", "rook-ceph-osd-0", "rook-ceph-mon-a"} {
+		app := "rook-ceph-osd"
+		if name == "rook-ceph-mon-a" {
+			app = "rook-ceph-mon"
+		}
+		d := &extensions.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", Labels: map[string]string{"app": app}}}
+		_, err := clientset.Extensions().Deployments("ns").Create(d)
+		assert.Nil(t, err)
+	}
+	rookClientset := rookfake.NewSimpleClientset()
+	_, err := rookClientset.CephV1().CephClusters("ns").Create(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "ns"}})
+	assert.Nil(t, err)
+	c := &cluster{context: &clusterd.Context{Executor: executor, Clientset: clientset, RookClientset: rookClientset}, Namespace: "ns",
+		crdName: "rook-ceph", stopCh: make(chan struct{}), Spec: &cephv1.ClusterSpec{UpgradeChecks: cephv1.UpgradeChecksSpec{TimeoutSeconds: 1}}}
+
+	// the osds are restarted in order with noout set
+	assert.Nil(t, c.restartDaemons("osd"))
+	assert.Equal(t, []string{"rook-ceph-osd-0", "rook-ceph-osd-1"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+	assert.NotEqual(t, "", (*deploymentsUpdated)[0].Spec.Template.Annotations[k8sutil.RestartedAtAnnotation])
+	assert.Equal(t, []string{"set noout", "unset noout"}, flags)
+
+	*deploymentsUpdated = nil
+	assert.Nil(t, c.restartDaemons("mon"))
+	assert.Equal(t, []string{"rook-ceph-mon-a"}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
+
+	// the restart stops when the cluster is not healthy
+	*deploymentsUpdated = nil
+	flags = nil
+	status = unhealthyStatus
+	err = c.restartDaemons("osd")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "PG_DEGRADED")
+	assert.Equal(t, 0, len(*deploymentsUpdated))
+	assert.Equal(t, []string{"set noout", "unset noout"}, flags)
+
+	// the noout still needed by another operation stays set after the restart
+	status = `{"health":{"status":"HEALTH_OK","checks":{}}}`
+	flags = nil
+	assert.Nil(t, c.acquireOSDFlag("noout", upgradeFlagOwner))
+	assert.Nil(t, c.restartDaemons("osd"))
+	assert.Equal(t, []string{"set noout", "set noout"}, flags)
+
+	err = c.restartDaemons("osds")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "must be one of mds, mgr, mon, osd, rbd-mirror, rgw")
+}
+
+func TestRemoveRestartAnnotation(t *testing.T) {
+	rookClientset := rookfake.NewSimpleClientset()
+	c := &ClusterController{context: &clusterd.Context{RookClientset: rookClientset}}
+	clust := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "ns",
+		Annotations: map[string]string{RestartAnnotation: "osd", "other": "value"}}}
+	_, err := rookClientset.CephV1().CephClusters("ns").Create(clust)
+	assert.Nil(t, err)
+
+	// the restart of another type of daemon requested since is kept
+	assert.Nil(t, c.removeRestartAnnotation("ns", "rook-ceph", "mon"))
+	clust, err = rookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "osd", clust.Annotations[RestartAnnotation])
+
+	assert.Nil(t, c.removeRestartAnnotation("ns", "rook-ceph", "osd"))
+	clust, err = rookClientset.CephV1().CephClusters("ns").Get("rook-ceph", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"other": "value"}, clust.Annotations)
+	assert.Nil(t, c.removeRestartAnnotation("ns", "rook-ceph", "osd"))
+}
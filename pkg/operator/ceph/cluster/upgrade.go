@@ -0,0 +1,117 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+const (
+	defaultUpgradeCheckTimeout = 10 * time.Minute
+)
+
+var (
+	upgradeCheckInterval = 10 * time.Second
+
+	// the health checks raised by the upgrade itself, such as the noout flag set by the operator
+	upgradeHealthChecks = []string{"OSDMAP_FLAGS"}
+)
+
+// checkUpgrade checks the health of the cluster before a type of daemon is updated, only during an upgrade
+func (c *cluster) checkUpgrade(daemonType string) error {
+	if !c.upgrading {
+		return nil
+	}
+	return c.checkUpgradeHealth(daemonType)
+}
+
+// checkUpgradeHealth waits for the cluster to be healthy before a type of daemon is updated during an upgrade
+func (c *cluster) checkUpgradeHealth(daemonType string) error {
+	if c.Spec.SkipUpgradeChecks {
+		logger.Infof("skipping the upgrade checks before updating the %s", daemonType)
+		return nil
+	}
+
+	timeout := defaultUpgradeCheckTimeout
+	if c.Spec.UpgradeChecks.TimeoutSeconds > 0 {
+		timeout = time.Duration(c.Spec.UpgradeChecks.TimeoutSeconds) * time.Second
+	}
+
+	var err error
+	start := time.Now()
+	for {
+		if err = isHealthyForUpgrade(c.context, c.Namespace, c.Spec.UpgradeChecks); err == nil {
+			logger.Infof("cluster %s is healthy to update the %s", c.Namespace, daemonType)
+			return nil
+		}
+		if time.Since(start) >= timeout {
+			break
+		}
+		logger.Infof("waiting for the cluster to be healthy before updating the %s. %+v", daemonType, err)
+		time.Sleep(upgradeCheckInterval)
+	}
+
+	if c.Spec.ContinueUpgradeAfterChecksEvenIfNotHealthy {
+		logger.Warningf("continuing to update the %s even if the cluster is not healthy. %+v", daemonType, err)
+		return nil
+	}
+	return fmt.Errorf("cluster is not healthy to update the %s after %s. %+v", daemonType, timeout, err)
+}
+
+func isHealthyForUpgrade(context *clusterd.Context, namespace string, checks cephv1.UpgradeChecksSpec) error {
+	status, err := client.Status(context, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get ceph status. %+v", err)
+	}
+
+	failed := failedHealthChecks(status, checks.IgnoredHealthChecks)
+	if len(failed) > 0 {
+		return fmt.Errorf("failed health checks: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// failedHealthChecks returns the health checks of the cluster that are not ignored
+func failedHealthChecks(status client.CephStatus, ignored []string) []string {
+	var failed []string
+	for name, check := range status.Health.Checks {
+		if isIgnoredHealthCheck(name, ignored) {
+			continue
+		}
+		failed = append(failed, fmt.Sprintf("%s (%s)", name, check.Summary.Message))
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+func isIgnoredHealthCheck(name string, ignored []string) bool {
+	for _, list := range [][]string{upgradeHealthChecks, ignored} {
+		for _, n := range list {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
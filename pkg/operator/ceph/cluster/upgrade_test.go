@@ -0,0 +1,80 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const unhealthyStatus = `{"health":{"status":"HEALTH_WARN","checks":{
+	"OSDMAP_FLAGS":{"severity":"HEALTH_WARN","summary":{"message":"noout flag(s) set"}},
+	"PG_DEGRADED":{"severity":"HEALTH_WARN","summary":{"message":"Degraded data redundancy: 10 pgs degraded"}},
+	"POOL_APP_NOT_ENABLED":{"severity":"HEALTH_WARN","summary":{"message":"application not enabled on 1 pool(s)"}}}}}`
+
+func TestCheckUpgradeHealth(t *testing.T) {
+	upgradeCheckInterval = time.Millisecond
+	statusCalls := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			if args[0] == "status" {
+				statusCalls++
+				return unhealthyStatus, nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	c := &cluster{context: &clusterd.Context{Executor: executor}, Namespace: "ns",
+		Spec: &cephv1.ClusterSpec{UpgradeChecks: cephv1.UpgradeChecksSpec{TimeoutSeconds: 1}}}
+
+	// the checks are only run during an upgrade
+	assert.Nil(t, c.checkUpgrade("mons"))
+	assert.Equal(t, 0, statusCalls)
+
+	// the upgrade is blocked by the failed health checks
+	c.upgrading = true
+	err := c.checkUpgrade("mons")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "PG_DEGRADED")
+	assert.Contains(t, err.Error(), "POOL_APP_NOT_ENABLED")
+	assert.NotContains(t, err.Error(), "OSDMAP_FLAGS")
+	assert.True(t, statusCalls > 1)
+
+	// the upgrade continues after the timeout
+	c.Spec.ContinueUpgradeAfterChecksEvenIfNotHealthy = true
+	assert.Nil(t, c.checkUpgrade("mons"))
+	c.Spec.ContinueUpgradeAfterChecksEvenIfNotHealthy = false
+
+	// the ignored health checks don't block the upgrade
+	statusCalls = 0
+	c.Spec.UpgradeChecks.IgnoredHealthChecks = []string{"PG_DEGRADED", "POOL_APP_NOT_ENABLED"}
+	assert.Nil(t, c.checkUpgrade("mons"))
+	assert.Equal(t, 1, statusCalls)
+
+	// the checks are skipped
+	statusCalls = 0
+	c.Spec.UpgradeChecks.IgnoredHealthChecks = nil
+	c.Spec.SkipUpgradeChecks = true
+	assert.Nil(t, c.checkUpgrade("mons"))
+	assert.Equal(t, 0, statusCalls)
+}
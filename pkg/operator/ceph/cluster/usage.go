@@ -0,0 +1,309 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	usageCollectionInterval = 5 * time.Minute
+
+	clusterBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "cluster_bytes",
+			Help:      "Raw capacity of the ceph cluster by type: total, used or available",
+		},
+		[]string{"namespace", "type"},
+	)
+	poolUsedBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "pool_used_bytes",
+			Help:      "Bytes stored in the pool, before replication or erasure coding",
+		},
+		[]string{"namespace", "pool"},
+	)
+	poolMaxAvailableBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "pool_max_available_bytes",
+			Help:      "Bytes that can still be stored in the pool",
+		},
+		[]string{"namespace", "pool"},
+	)
+	poolObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "pool_objects",
+			Help:      "Number of objects in the pool",
+		},
+		[]string{"namespace", "pool"},
+	)
+	objectUserBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "object_user_bytes",
+			Help:      "Bytes sent and received by the object store user by direction: sent or received",
+		},
+		[]string{"namespace", "store", "user", "direction"},
+	)
+	objectUserOps = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "object_user_ops",
+			Help:      "Number of requests of the object store user by result: total or successful",
+		},
+		[]string{"namespace", "store", "user", "result"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(clusterBytes, poolUsedBytes, poolMaxAvailableBytes, poolObjects, objectUserBytes, objectUserOps)
+}
+
+// usageCollector periodically publishes the usage of the cluster, of its pools and of its object store users in their
+// status and in the prometheus metrics of the operator
+type usageCollector struct {
+	context     *clusterd.Context
+	namespace   string
+	clusterName string
+	// the url of the rgw service of an object store
+	endpoint func(namespace, store string) (string, error)
+	// the pools and the stores of the object store users whose metrics were published, to delete the metrics of the
+	// pools and users that are removed
+	pools map[string]bool
+	users map[string]string
+}
+
+func newUsageCollector(context *clusterd.Context, namespace, clusterName string) *usageCollector {
+	return &usageCollector{
+		context:     context,
+		namespace:   namespace,
+		clusterName: clusterName,
+		endpoint: func(namespace, store string) (string, error) {
+			return object.GetStoreEndpoint(context, namespace, store)
+		},
+		pools: map[string]bool{},
+		users: map[string]string{},
+	}
+}
+
+// Start collects the usage at set intervals until the cluster is stopped
+func (u *usageCollector) Start(stopCh chan struct{}) {
+	for {
+		select {
+		case <-time.After(usageCollectionInterval):
+			u.collect()
+
+		case <-stopCh:
+			logger.Infof("stopping the usage collection of cluster %s", u.namespace)
+			u.removeMetrics()
+			return
+		}
+	}
+}
+
+func (u *usageCollector) collect() {
+	if err := u.collectPoolUsage(); err != nil {
+		logger.Warningf("failed to collect the usage of the pools of cluster %s. %+v", u.namespace, err)
+	}
+	if err := u.collectObjectUserUsage(); err != nil {
+		logger.Warningf("failed to collect the usage of the object store users of cluster %s. %+v", u.namespace, err)
+	}
+}
+
+// Collect the usage of the cluster and of its pools from ceph df
+func (u *usageCollector) collectPoolUsage() error {
+	usage, err := client.Usage(u.context, u.namespace)
+	if err != nil {
+		return err
+	}
+	poolStats, err := client.GetPoolStats(u.context, u.namespace)
+	if err != nil {
+		return err
+	}
+	now := metav1.Now()
+
+	clusterUsage := &cephv1.ClusterUsage{
+		TotalBytes:     parseUsage(usage.Stats.TotalBytes),
+		UsedBytes:      parseUsage(usage.Stats.TotalUsedBytes),
+		AvailableBytes: parseUsage(usage.Stats.TotalAvailBytes),
+		Objects:        parseUsage(usage.Stats.TotalObjects),
+		LastUpdated:    now,
+	}
+	clusterBytes.WithLabelValues(u.namespace, "total").Set(float64(clusterUsage.TotalBytes))
+	clusterBytes.WithLabelValues(u.namespace, "used").Set(float64(clusterUsage.UsedBytes))
+	clusterBytes.WithLabelValues(u.namespace, "available").Set(float64(clusterUsage.AvailableBytes))
+
+	cluster, err := u.context.RookClientset.CephV1().CephClusters(u.namespace).Get(u.clusterName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s. %+v", u.clusterName, err)
+	}
+	cluster.Status.Usage = clusterUsage
+	if _, err := u.context.RookClientset.CephV1().CephClusters(u.namespace).Update(cluster); err != nil {
+		return fmt.Errorf("failed to update the usage of cluster %s. %+v", u.clusterName, err)
+	}
+
+	pools := map[string]*cephv1.PoolUsage{}
+	published := map[string]bool{}
+	for _, p := range poolStats.Pools {
+		pools[p.Name] = &cephv1.PoolUsage{
+			UsedBytes:         uint64(p.Stats.BytesUsed),
+			MaxAvailableBytes: uint64(p.Stats.MaxAvail),
+			Objects:           uint64(p.Stats.Objects),
+			LastUpdated:       now,
+		}
+		poolUsedBytes.WithLabelValues(u.namespace, p.Name).Set(p.Stats.BytesUsed)
+		poolMaxAvailableBytes.WithLabelValues(u.namespace, p.Name).Set(p.Stats.MaxAvail)
+		poolObjects.WithLabelValues(u.namespace, p.Name).Set(p.Stats.Objects)
+		published[p.Name] = true
+	}
+	for name := range u.pools {
+		if !published[name] {
+			removePoolMetrics(u.namespace, name)
+		}
+	}
+	u.pools = published
+
+	// the pools of the object stores and filesystems only have metrics, they have no pool resource
+	blockPools, err := u.context.RookClientset.CephV1().CephBlockPools(u.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list the pools. %+v", err)
+	}
+	for _, pool := range blockPools.Items {
+		poolUsage, ok := pools[pool.Name]
+		if !ok {
+			logger.Debugf("no usage for pool %s", pool.Name)
+			continue
+		}
+		pool.Status.Usage = poolUsage
+		if _, err := u.context.RookClientset.CephV1().CephBlockPools(u.namespace).Update(&pool); err != nil {
+			logger.Warningf("failed to update the usage of pool %s. %+v", pool.Name, err)
+		}
+	}
+	return nil
+}
+
+// Collect the usage of the object store users from the usage log of rgw
+func (u *usageCollector) collectObjectUserUsage() error {
+	users, err := u.context.RookClientset.CephV1().CephObjectStoreUsers(u.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list the object store users. %+v", err)
+	}
+
+	// the metrics of a user are kept while it exists, even if its usage cannot be collected this time
+	existing := map[string]string{}
+	for _, user := range users.Items {
+		existing[user.Name] = user.Spec.Store
+	}
+	for name, store := range u.users {
+		if existing[name] != store {
+			removeObjectUserMetrics(u.namespace, store, name)
+			delete(u.users, name)
+		}
+	}
+
+	for _, user := range users.Items {
+		endpoint, err := u.endpoint(u.namespace, user.Spec.Store)
+		if err != nil {
+			logger.Warningf("failed to get the usage of object store user %s. %+v", user.Name, err)
+			continue
+		}
+		adminOps, err := object.NewAdminOpsClient(u.context, u.namespace, user.Spec.Store, endpoint)
+		if err != nil {
+			logger.Warningf("failed to get the usage of object store user %s. %+v", user.Name, err)
+			continue
+		}
+		usage, err := adminOps.GetUsage(user.Name)
+		if err != nil {
+			logger.Warningf("failed to get the usage of object store user %s. %+v", user.Name, err)
+			continue
+		}
+
+		objectUserBytes.WithLabelValues(u.namespace, user.Spec.Store, user.Name, "sent").Set(float64(usage.BytesSent))
+		objectUserBytes.WithLabelValues(u.namespace, user.Spec.Store, user.Name, "received").Set(float64(usage.BytesReceived))
+		objectUserOps.WithLabelValues(u.namespace, user.Spec.Store, user.Name, "total").Set(float64(usage.Ops))
+		objectUserOps.WithLabelValues(u.namespace, user.Spec.Store, user.Name, "successful").Set(float64(usage.SuccessfulOps))
+		u.users[user.Name] = user.Spec.Store
+
+		user.Status.Usage = &cephv1.ObjectUserUsage{
+			BytesSent:     usage.BytesSent,
+			BytesReceived: usage.BytesReceived,
+			Ops:           usage.Ops,
+			SuccessfulOps: usage.SuccessfulOps,
+			LastUpdated:   metav1.Now(),
+		}
+		if _, err := u.context.RookClientset.CephV1().CephObjectStoreUsers(u.namespace).Update(&user); err != nil {
+			logger.Warningf("failed to update the usage of object store user %s. %+v", user.Name, err)
+		}
+	}
+	return nil
+}
+
+// removeMetrics deletes the metrics of the cluster, of its pools and of its object store users
+func (u *usageCollector) removeMetrics() {
+	for _, usageType := range []string{"total", "used", "available"} {
+		clusterBytes.DeleteLabelValues(u.namespace, usageType)
+	}
+	for name := range u.pools {
+		removePoolMetrics(u.namespace, name)
+	}
+	for name, store := range u.users {
+		removeObjectUserMetrics(u.namespace, store, name)
+	}
+	u.pools = map[string]bool{}
+	u.users = map[string]string{}
+}
+
+func removePoolMetrics(namespace, name string) {
+	poolUsedBytes.DeleteLabelValues(namespace, name)
+	poolMaxAvailableBytes.DeleteLabelValues(namespace, name)
+	poolObjects.DeleteLabelValues(namespace, name)
+}
+
+func removeObjectUserMetrics(namespace, store, name string) {
+	objectUserBytes.DeleteLabelValues(namespace, store, name, "sent")
+	objectUserBytes.DeleteLabelValues(namespace, store, name, "received")
+	objectUserOps.DeleteLabelValues(namespace, store, name, "total")
+	objectUserOps.DeleteLabelValues(namespace, store, name, "successful")
+}
+
+func parseUsage(n json.Number) uint64 {
+	value, err := strconv.ParseUint(n.String(), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCollectUsage(t *testing.T) {
+	df := `{"stats":{"total_bytes":3000,"total_used_bytes":1000,"total_avail_bytes":2000,"total_objects":12},
+		"pools":[{"name":"replicapool","id":1,"stats":{"bytes_used":300,"max_avail":600,"objects":10}},
+		{"name":"mystore.rgw.buckets.data","id":2,"stats":{"bytes_used":10,"max_avail":600,"objects":2}}]}`
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "df" && args[1] == "detail" {
+				return df, nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/admin/usage", r.URL.Path)
+		w.Write([]byte(`{"summary":[{"user":"myuser","total":{"bytes_sent":100,"bytes_received":200,"ops":10,"successful_ops":9}}]}`))
+	}))
+	defer httpServer.Close()
+
+	cluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "mycluster", Namespace: "ns"},
+		Status:     cephv1.ClusterStatus{State: cephv1.ClusterStateCreated},
+	}
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "ns"}}
+	user := &cephv1.CephObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "myuser", Namespace: "ns"},
+		Spec:       cephv1.ObjectStoreUserSpec{Store: "mystore"},
+	}
+	otherUser := &cephv1.CephObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "otheruser", Namespace: "ns"},
+		Spec:       cephv1.ObjectStoreUserSpec{Store: "otherstore"},
+	}
+	adminSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-rgw-mystore-admin-ops", Namespace: "ns"},
+		Data:       map[string][]byte{"AccessKey": []byte("adminaccesskey"), "SecretKey": []byte("adminsecretkey")},
+	}
+	context := &clusterd.Context{
+		Executor:      executor,
+		Clientset:     fake.NewSimpleClientset(adminSecret),
+		RookClientset: rookfake.NewSimpleClientset(cluster, pool, user, otherUser),
+	}
+	u := newUsageCollector(context, "ns", "mycluster")
+	u.endpoint = func(namespace, store string) (string, error) {
+		return httpServer.URL, nil
+	}
+
+	u.collect()
+
+	cluster, err := context.RookClientset.CephV1().CephClusters("ns").Get("mycluster", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ClusterStateCreated, cluster.Status.State)
+	assert.Equal(t, uint64(3000), cluster.Status.Usage.TotalBytes)
+	assert.Equal(t, uint64(1000), cluster.Status.Usage.UsedBytes)
+	assert.Equal(t, uint64(2000), cluster.Status.Usage.AvailableBytes)
+	assert.Equal(t, uint64(12), cluster.Status.Usage.Objects)
+
+	pool, err = context.RookClientset.CephV1().CephBlockPools("ns").Get("replicapool", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(300), pool.Status.Usage.UsedBytes)
+	assert.Equal(t, uint64(600), pool.Status.Usage.MaxAvailableBytes)
+	assert.Equal(t, uint64(10), pool.Status.Usage.Objects)
+
+	user, err = context.RookClientset.CephV1().CephObjectStoreUsers("ns").Get("myuser", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(100), user.Status.Usage.BytesSent)
+	assert.Equal(t, uint64(200), user.Status.Usage.BytesReceived)
+	assert.Equal(t, uint64(10), user.Status.Usage.Ops)
+	assert.Equal(t, uint64(9), user.Status.Usage.SuccessfulOps)
+
+	// the admin ops user of the other store was not created
+	otherUser, err = context.RookClientset.CephV1().CephObjectStoreUsers("ns").Get("otheruser", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, otherUser.Status.Usage)
+
+	// the metrics of the deleted pools and users are removed
+	df = `{"stats":{"total_bytes":3000,"total_used_bytes":1000,"total_avail_bytes":2000,"total_objects":10},
+		"pools":[{"name":"replicapool","id":1,"stats":{"bytes_used":300,"max_avail":600,"objects":10}}]}`
+	assert.Nil(t, context.RookClientset.CephV1().CephObjectStoreUsers("ns").Delete("myuser", &metav1.DeleteOptions{}))
+	u.collect()
+	assert.False(t, poolObjects.DeleteLabelValues("ns", "mystore.rgw.buckets.data"))
+	assert.False(t, objectUserOps.DeleteLabelValues("ns", "mystore", "myuser", "total"))
+	assert.True(t, poolObjects.DeleteLabelValues("ns", "replicapool"))
+
+	// the metrics of the cluster are removed when it is stopped
+	u.removeMetrics()
+	assert.False(t, clusterBytes.DeleteLabelValues("ns", "total"))
+	assert.False(t, poolUsedBytes.DeleteLabelValues("ns", "replicapool"))
+}
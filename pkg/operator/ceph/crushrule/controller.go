@@ -0,0 +1,285 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package crushrule to manage the custom crush rules of a rook cluster.
+package crushrule
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	replicatedType       = "replicated"
+	erasureType          = "erasure"
+	defaultRoot          = "default"
+	defaultFailureDomain = "host"
+
+	stepTake       = "take"
+	stepChoose     = "choose"
+	stepChooseLeaf = "chooseleaf"
+	stepEmit       = "emit"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-crushrule")
+
+// CrushRuleResource represents the CrushRule custom resource object
+var CrushRuleResource = opkit.CustomResource{
+	Name:    "cephcrushrule",
+	Plural:  "cephcrushrules",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephCrushRule{}).Name(),
+}
+
+// CrushRuleController represents a controller object for crush rule custom resources
+type CrushRuleController struct {
+	context *clusterd.Context
+}
+
+// NewCrushRuleController create controller for watching crush rule custom resources created
+func NewCrushRuleController(context *clusterd.Context) *CrushRuleController {
+	return &CrushRuleController{
+		context: context,
+	}
+}
+
+// StartWatch watches for instances of CrushRule custom resources and acts on them
+func (c *CrushRuleController) StartWatch(namespace string, stopCh chan struct{}) error {
+
+	// the resources are not reconciled while their cluster is in maintenance mode
+	resourceHandlerFuncs := maintenance.PausableHandlers(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	})
+
+	logger.Infof("start watching crush rule resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(CrushRuleResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephCrushRule{}, stopCh)
+
+	return nil
+}
+
+func (c *CrushRuleController) onAdd(obj interface{}) {
+	rule, err := getCrushRuleObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get crush rule object: %+v", err)
+		return
+	}
+
+	if err := createCrushRule(c.context, rule); err != nil {
+		logger.Errorf("failed to create crush rule %s. %+v", rule.Name, err)
+	}
+}
+
+func (c *CrushRuleController) onUpdate(oldObj, newObj interface{}) {
+	oldRule, err := getCrushRuleObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old crush rule object: %+v", err)
+		return
+	}
+	rule, err := getCrushRuleObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new crush rule object: %+v", err)
+		return
+	}
+
+	if reflect.DeepEqual(oldRule.Spec, rule.Spec) {
+		logger.Debugf("crush rule %s not changed", rule.Name)
+		return
+	}
+
+	// the rule keeps its id when it is modified so the pools using it will move their data to the new placement
+	logger.Infof("updating crush rule %s", rule.Name)
+	if err := createCrushRule(c.context, rule); err != nil {
+		logger.Errorf("failed to update crush rule %s. %+v", rule.Name, err)
+	}
+}
+
+func (c *CrushRuleController) onDelete(obj interface{}) {
+	rule, err := getCrushRuleObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get crush rule object: %+v", err)
+		return
+	}
+
+	if err := ceph.DeleteCrushRule(c.context, rule.Namespace, rule.Name); err != nil {
+		logger.Errorf("failed to delete crush rule %s. %+v", rule.Name, err)
+	}
+}
+
+// Create or update the crush rule
+func createCrushRule(context *clusterd.Context, r *cephv1.CephCrushRule) error {
+	steps, err := validateCrushRule(context, r)
+	if err != nil {
+		return fmt.Errorf("invalid crush rule %s arguments. %+v", r.Name, err)
+	}
+
+	logger.Infof("setting crush rule %s in namespace %s with steps %v", r.Name, r.Namespace, steps)
+	if err := ceph.SetCrushRule(context, r.Namespace, r.Name, ruleType(r.Spec), steps); err != nil {
+		return fmt.Errorf("failed to set crush rule %s. %+v", r.Name, err)
+	}
+
+	logger.Infof("set crush rule %s", r.Name)
+	return nil
+}
+
+// validateCrushRule checks the rule against the buckets, types and device classes of the crush map and returns the
+// steps of the rule in the format of the decompiled crush map
+func validateCrushRule(context *clusterd.Context, r *cephv1.CephCrushRule) ([]string, error) {
+	if r.Name == "" {
+		return nil, fmt.Errorf("missing name")
+	}
+	if r.Namespace == "" {
+		return nil, fmt.Errorf("missing namespace")
+	}
+	t := ruleType(r.Spec)
+	if t != replicatedType && t != erasureType {
+		return nil, fmt.Errorf("unrecognized rule type %s", r.Spec.Type)
+	}
+
+	crush, err := ceph.GetCrushMap(context, r.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get crush map. %+v", err)
+	}
+
+	steps := ruleSteps(r.Spec)
+	for _, step := range steps {
+		if step.Type != "" && !hasType(crush, step.Type) {
+			return nil, fmt.Errorf("unrecognized failure domain %s", step.Type)
+		}
+		if step.Item != "" && !hasBucket(crush, step.Item) {
+			return nil, fmt.Errorf("unrecognized crush bucket %s", step.Item)
+		}
+		if step.DeviceClass != "" && !hasDeviceClass(crush, step.DeviceClass) {
+			return nil, fmt.Errorf("unrecognized device class %s", step.DeviceClass)
+		}
+	}
+
+	return formatSteps(t, steps)
+}
+
+func ruleType(spec cephv1.CrushRuleSpec) string {
+	if spec.Type == "" {
+		return replicatedType
+	}
+	return spec.Type
+}
+
+// ruleSteps returns the explicit steps of the rule, or generates the steps from the root, failure domain and device
+// class when no steps are specified
+func ruleSteps(spec cephv1.CrushRuleSpec) []cephv1.CrushRuleStep {
+	if len(spec.Steps) > 0 {
+		return spec.Steps
+	}
+
+	root := spec.Root
+	if root == "" {
+		root = defaultRoot
+	}
+	failureDomain := spec.FailureDomain
+	if failureDomain == "" {
+		failureDomain = defaultFailureDomain
+	}
+	return []cephv1.CrushRuleStep{
+		{Op: stepTake, Item: root, DeviceClass: spec.DeviceClass},
+		{Op: stepChooseLeaf, Type: failureDomain},
+		{Op: stepEmit},
+	}
+}
+
+func formatSteps(ruleType string, steps []cephv1.CrushRuleStep) ([]string, error) {
+	// the erasure coded pools need the chunks to stay in the same position
+	mode := "firstn"
+	if ruleType == erasureType {
+		mode = "indep"
+	}
+
+	var result []string
+	for i, step := range steps {
+		switch step.Op {
+		case stepTake:
+			if step.Item == "" {
+				return nil, fmt.Errorf("missing item for step %d", i)
+			}
+			s := []string{stepTake, step.Item}
+			if step.DeviceClass != "" {
+				s = append(s, "class", step.DeviceClass)
+			}
+			result = append(result, strings.Join(s, " "))
+		case stepChoose, stepChooseLeaf:
+			if step.Type == "" {
+				return nil, fmt.Errorf("missing type for step %d", i)
+			}
+			result = append(result, fmt.Sprintf("%s %s %d type %s", step.Op, mode, step.Num, step.Type))
+		case stepEmit:
+			result = append(result, stepEmit)
+		default:
+			return nil, fmt.Errorf("unrecognized op %s for step %d", step.Op, i)
+		}
+	}
+	if len(result) == 0 || result[len(result)-1] != stepEmit {
+		return nil, fmt.Errorf("the last step must be %s", stepEmit)
+	}
+	return result, nil
+}
+
+func hasType(crush ceph.CrushMap, name string) bool {
+	for _, t := range crush.Types {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasBucket(crush ceph.CrushMap, name string) bool {
+	for _, b := range crush.Buckets {
+		if b.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDeviceClass(crush ceph.CrushMap, class string) bool {
+	for _, d := range crush.Devices {
+		if d.Class == class {
+			return true
+		}
+	}
+	return false
+}
+
+func getCrushRuleObject(obj interface{}) (*cephv1.CephCrushRule, error) {
+	rule, ok := obj.(*cephv1.CephCrushRule)
+	if ok {
+		return rule.DeepCopy(), nil
+	}
+	return nil, fmt.Errorf("not a known crush rule object: %+v", obj)
+}
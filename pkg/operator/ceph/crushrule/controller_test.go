@@ -0,0 +1,115 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crushrule
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const testCrushMap = `{
+	"devices": [{"id": 0, "name": "osd.0", "class": "hdd"}, {"id": 1, "name": "osd.1", "class": "ssd"}],
+	"types": [{"type_id": 0, "name": "osd"}, {"type_id": 1, "name": "host"}, {"type_id": 3, "name": "rack"}, {"type_id": 10, "name": "root"}],
+	"buckets": [{"id": -1, "name": "default", "type_id": 10, "type_name": "root"}, {"id": -2, "name": "node1", "type_id": 1, "type_name": "host"}],
+	"rules": []
+}`
+
+func newTestContext() *clusterd.Context {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			if args[1] == "crush" && args[2] == "dump" {
+				return testCrushMap, nil
+			}
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		},
+	}
+	return &clusterd.Context{Executor: executor}
+}
+
+func TestValidateCrushRule(t *testing.T) {
+	context := newTestContext()
+	meta := metav1.ObjectMeta{Name: "myrule", Namespace: "myns"}
+
+	// the default steps
+	r := &cephv1.CephCrushRule{ObjectMeta: meta}
+	steps, err := validateCrushRule(context, r)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"take default", "chooseleaf firstn 0 type host", "emit"}, steps)
+
+	// an erasure coded rule with a failure domain and device class
+	r.Spec = cephv1.CrushRuleSpec{Type: "erasure", FailureDomain: "rack", DeviceClass: "ssd"}
+	steps, err = validateCrushRule(context, r)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"take default class ssd", "chooseleaf indep 0 type rack", "emit"}, steps)
+
+	// explicit steps to place the primary on ssd and the other replicas on hdd
+	r.Spec = cephv1.CrushRuleSpec{Steps: []cephv1.CrushRuleStep{
+		{Op: "take", Item: "default", DeviceClass: "ssd"},
+		{Op: "chooseleaf", Num: 1, Type: "host"},
+		{Op: "emit"},
+		{Op: "take", Item: "default", DeviceClass: "hdd"},
+		{Op: "chooseleaf", Num: -1, Type: "host"},
+		{Op: "emit"},
+	}}
+	steps, err = validateCrushRule(context, r)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"take default class ssd", "chooseleaf firstn 1 type host", "emit",
+		"take default class hdd", "chooseleaf firstn -1 type host", "emit"}, steps)
+
+	// invalid rule type
+	r.Spec = cephv1.CrushRuleSpec{Type: "foo"}
+	_, err = validateCrushRule(context, r)
+	assert.NotNil(t, err)
+
+	// unknown failure domain, root and device class
+	r.Spec = cephv1.CrushRuleSpec{FailureDomain: "datacenter"}
+	_, err = validateCrushRule(context, r)
+	assert.NotNil(t, err)
+	r.Spec = cephv1.CrushRuleSpec{Root: "other"}
+	_, err = validateCrushRule(context, r)
+	assert.NotNil(t, err)
+	r.Spec = cephv1.CrushRuleSpec{DeviceClass: "nvme"}
+	_, err = validateCrushRule(context, r)
+	assert.NotNil(t, err)
+
+	// invalid steps
+	r.Spec = cephv1.CrushRuleSpec{Steps: []cephv1.CrushRuleStep{{Op: "take"}, {Op: "emit"}}}
+	_, err = validateCrushRule(context, r)
+	assert.NotNil(t, err)
+	r.Spec = cephv1.CrushRuleSpec{Steps: []cephv1.CrushRuleStep{{Op: "take", Item: "default"}, {Op: "choose"}, {Op: "emit"}}}
+	_, err = validateCrushRule(context, r)
+	assert.NotNil(t, err)
+	r.Spec = cephv1.CrushRuleSpec{Steps: []cephv1.CrushRuleStep{{Op: "take", Item: "default"}, {Op: "set_choose_tries"}, {Op: "emit"}}}
+	_, err = validateCrushRule(context, r)
+	assert.NotNil(t, err)
+	r.Spec = cephv1.CrushRuleSpec{Steps: []cephv1.CrushRuleStep{{Op: "take", Item: "default"}}}
+	_, err = validateCrushRule(context, r)
+	assert.NotNil(t, err)
+
+	// must specify the name and namespace
+	_, err = validateCrushRule(context, &cephv1.CephCrushRule{ObjectMeta: metav1.ObjectMeta{Namespace: "myns"}})
+	assert.NotNil(t, err)
+	_, err = validateCrushRule(context, &cephv1.CephCrushRule{ObjectMeta: metav1.ObjectMeta{Name: "myrule"}})
+	assert.NotNil(t, err)
+}
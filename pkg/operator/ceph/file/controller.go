@@ -22,6 +22,7 @@ import (
 	"reflect"
 
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
 
 	"github.com/coreos/pkg/capnslog"
@@ -84,11 +85,12 @@ func NewFilesystemController(
 // StartWatch watches for instances of Filesystem custom resources and acts on them
 func (c *FilesystemController) StartWatch(namespace string, stopCh chan struct{}) error {
 
-	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+	// the resources are not reconciled while their cluster is in maintenance mode
+	resourceHandlerFuncs := maintenance.PausableHandlers(cache.ResourceEventHandlerFuncs{
 		AddFunc:    c.onAdd,
 		UpdateFunc: c.onUpdate,
 		DeleteFunc: c.onDelete,
-	}
+	})
 
 	logger.Infof("start watching filesystem resource in namespace %s", namespace)
 	watcher := opkit.NewWatcher(FilesystemResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
@@ -191,6 +193,14 @@ func filesystemChanged(oldFS, newFS cephv1.FilesystemSpec) bool {
 		logger.Infof("mds active standby changed from %t to %t", oldFS.MetadataServer.ActiveStandby, newFS.MetadataServer.ActiveStandby)
 		return true
 	}
+	if !reflect.DeepEqual(oldFS.MetadataServer.PodExtension, newFS.MetadataServer.PodExtension) {
+		logger.Infof("mds pod extension changed from %+v to %+v", oldFS.MetadataServer.PodExtension, newFS.MetadataServer.PodExtension)
+		return true
+	}
+	if !reflect.DeepEqual(oldFS.Mirroring, newFS.Mirroring) {
+		logger.Infof("filesystem mirroring changed from %+v to %+v", oldFS.Mirroring, newFS.Mirroring)
+		return true
+	}
 	return false
 }
 
@@ -46,6 +46,14 @@ func TestFilesystemChanged(t *testing.T) {
 
 	new = cephv1.FilesystemSpec{MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 1, ActiveStandby: false}}
 	assert.True(t, filesystemChanged(old, new))
+
+	new = cephv1.FilesystemSpec{MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 1, ActiveStandby: true,
+		PodExtension: rookv1alpha2.PodExtension{Sidecars: []v1.Container{{Name: "backup"}}}}}
+	assert.True(t, filesystemChanged(old, new))
+
+	new = cephv1.FilesystemSpec{MetadataServer: old.MetadataServer,
+		Mirroring: &cephv1.MirroringSpec{Peers: cephv1.MirroringPeerSpec{SecretNames: []string{"site-b"}}}}
+	assert.True(t, filesystemChanged(old, new))
 }
 
 func TestGetFilesystemObject(t *testing.T) {
@@ -24,7 +24,9 @@ import (
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	mdsdaemon "github.com/rook/rook/pkg/daemon/ceph/mds"
 	"github.com/rook/rook/pkg/daemon/ceph/model"
+	"github.com/rook/rook/pkg/operator/ceph/capacity"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
+	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -40,8 +42,17 @@ func createFilesystem(
 	if err := validateFilesystem(context, fs); err != nil {
 		return err
 	}
+	if err := validateFilesystemMirroring(fs, cephVersion); err != nil {
+		return err
+	}
 
 	if len(fs.Spec.DataPools) != 0 {
+		if err := checkFilesystemCapacity(context, fs); err != nil {
+			if eventErr := recordFilesystemEvent(context, fs, v1.EventTypeWarning, "CapacityExceeded", err.Error()); eventErr != nil {
+				logger.Warningf("failed to record the capacity event of file system %s. %+v", fs.Name, eventErr)
+			}
+			return err
+		}
 		var dataPools []*model.Pool
 		for _, p := range fs.Spec.DataPools {
 			dataPools = append(dataPools, p.ToModel(""))
@@ -64,6 +75,13 @@ func createFilesystem(
 		}
 	}
 
+	// import the peers the snapshots of the filesystem are mirrored to
+	if fs.Spec.Mirroring != nil {
+		if err := setFilesystemMirroring(context, fs); err != nil {
+			logger.Warningf("failed to set the mirroring of file system %s. %+v", fs.Name, err)
+		}
+	}
+
 	logger.Infof("start running mdses for file system %s", fs.Name)
 	c := newCluster(context, rookVersion, cephVersion, hostNetwork, fs, filesystem, ownerRefs)
 	if err := c.start(); err != nil {
@@ -116,3 +134,49 @@ func validateFilesystem(context *clusterd.Context, f cephv1.CephFilesystem) erro
 
 	return nil
 }
+
+// checkFilesystemCapacity checks the raw utilization of the cluster before creating a filesystem that does not exist yet
+func checkFilesystemCapacity(context *clusterd.Context, fs cephv1.CephFilesystem) error {
+	if !capacity.Enabled() {
+		return nil
+	}
+	if _, err := client.GetFilesystem(context, fs.Namespace, fs.Name); err == nil {
+		return nil
+	}
+	var rawBytes uint64
+	for _, p := range append([]cephv1.PoolSpec{fs.Spec.MetadataPool}, fs.Spec.DataPools...) {
+		bytes, err := capacity.SpecRawBytes(p)
+		if err != nil {
+			return err
+		}
+		rawBytes += bytes
+	}
+	return capacity.Check(context, fs.Namespace, fmt.Sprintf("file system %s", fs.Name), rawBytes)
+}
+
+// recordFilesystemEvent creates an event for the filesystem CRD, which has no status
+func recordFilesystemEvent(context *clusterd.Context, fs cephv1.CephFilesystem, eventType, reason, message string) error {
+	now := metav1.Now()
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", fs.Name, now.UnixNano()),
+			Namespace: fs.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion: fmt.Sprintf("%s/%s", FilesystemResource.Group, FilesystemResource.Version),
+			Kind:       FilesystemResource.Kind,
+			Name:       fs.Name,
+			Namespace:  fs.Namespace,
+			UID:        fs.UID,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: "rook-ceph-operator"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	_, err := context.Clientset.CoreV1().Events(fs.Namespace).Create(event)
+	return err
+}
@@ -27,6 +27,7 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephtest "github.com/rook/rook/pkg/daemon/ceph/test"
+	"github.com/rook/rook/pkg/operator/ceph/capacity"
 	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -51,7 +52,7 @@ func TestValidateSpec(t *testing.T) {
 
 	// missing data pools
 	assert.NotNil(t, validateFilesystem(context, fs))
-	p := cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}}
+	p := cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}}
 	fs.Spec.DataPools = append(fs.Spec.DataPools, p)
 
 	// missing metadata pool
@@ -66,6 +67,46 @@ func TestValidateSpec(t *testing.T) {
 	assert.Nil(t, validateFilesystem(context, fs))
 }
 
+func TestCreateFilesystemBeyondCapacity(t *testing.T) {
+	capacity.GuardrailPolicy = capacity.GuardrailBlock
+	defer func() { capacity.GuardrailPolicy = capacity.GuardrailIgnore }()
+
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "get" {
+				return "", errors.New("filesystem not found")
+			}
+			if args[0] == "df" {
+				return `{"stats":{"total_bytes":1000,"total_used_bytes":700,"total_avail_bytes":300,"total_objects":10}}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: testop.New(3)}
+	fs := cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec: cephv1.FilesystemSpec{
+			MetadataPool:   cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}},
+			DataPools:      []cephv1.PoolSpec{{Replicated: cephv1.ReplicatedSpec{Size: 3}, ExpectedSize: "10"}},
+			MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 1},
+		},
+	}
+
+	// the 30 raw bytes of the expected size of the data pool fit below the threshold
+	err := checkFilesystemCapacity(context, fs)
+	assert.Nil(t, err)
+
+	// the blocked filesystem is reported in an event
+	fs.Spec.DataPools[0].ExpectedSize = "100"
+	err = createFilesystem(context, fs, "v0.1", cephv1.CephVersionSpec{}, false, []metav1.OwnerReference{})
+	assert.NotNil(t, err)
+	events, err := context.Clientset.CoreV1().Events("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Equal(t, "CapacityExceeded", events.Items[0].Reason)
+	assert.Equal(t, "myfs", events.Items[0].InvolvedObject.Name)
+}
+
 func TestCreateFilesystem(t *testing.T) {
 	var deploymentsUpdated *[]*extensions.Deployment
 	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
@@ -94,8 +135,8 @@ func TestCreateFilesystem(t *testing.T) {
 	fs := cephv1.CephFilesystem{
 		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
 		Spec: cephv1.FilesystemSpec{
-			MetadataPool: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}},
-			DataPools:    []cephv1.PoolSpec{{Replicated: cephv1.ReplicatedSpec{Size: 1}}},
+			MetadataPool: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}},
+			DataPools:    []cephv1.PoolSpec{{Replicated: cephv1.ReplicatedSpec{Size: 3}}},
 			MetadataServer: cephv1.MetadataServerSpec{
 				ActiveCount: 1,
 				Resources: v1.ResourceRequirements{
@@ -0,0 +1,56 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"fmt"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/pool"
+)
+
+// validateFilesystemMirroring checks that the ceph version of the cluster supports the snapshot mirroring of the
+// filesystems
+func validateFilesystemMirroring(fs cephv1.CephFilesystem, cephVersion cephv1.CephVersionSpec) error {
+	if fs.Spec.Mirroring != nil && !cephv1.VersionAtLeast(cephVersion.Name, cephv1.Pacific) {
+		return fmt.Errorf("the mirroring of the filesystems requires pacific")
+	}
+	return nil
+}
+
+// setFilesystemMirroring enables the snapshot mirroring of a filesystem and imports the bootstrap tokens of its peers.
+// The mirroring is not disabled and the peers are not removed when they are removed from the spec.
+func setFilesystemMirroring(context *clusterd.Context, fs cephv1.CephFilesystem) error {
+	tokens, err := pool.PeerBootstrapTokens(context, fs.Namespace, fs.Spec.Mirroring.Peers)
+	if err != nil {
+		return err
+	}
+	if err := client.EnableFilesystemMirroring(context, fs.Namespace, fs.Name); err != nil {
+		return err
+	}
+	for i, token := range tokens {
+		if err := client.ImportFilesystemPeerBootstrapToken(context, fs.Namespace, fs.Name, token); err != nil {
+			return fmt.Errorf("failed to import the peer of secret %s. %+v", fs.Spec.Mirroring.Peers.SecretNames[i], err)
+		}
+	}
+	if len(tokens) > 0 {
+		logger.Infof("imported %d mirroring peers of filesystem %s", len(tokens), fs.Name)
+	}
+	return nil
+}
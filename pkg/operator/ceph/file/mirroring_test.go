@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/pool"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateFilesystemMirroring(t *testing.T) {
+	fs := cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "myns"}}
+	assert.Nil(t, validateFilesystemMirroring(fs, cephv1.CephVersionSpec{Name: cephv1.Nautilus}))
+
+	fs.Spec.Mirroring = &cephv1.MirroringSpec{}
+	assert.NotNil(t, validateFilesystemMirroring(fs, cephv1.CephVersionSpec{Name: cephv1.Octopus}))
+	assert.Nil(t, validateFilesystemMirroring(fs, cephv1.CephVersionSpec{Name: cephv1.Pacific}))
+}
+
+func TestSetFilesystemMirroring(t *testing.T) {
+	enabled := false
+	imported := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outFileArg string, args ...string) (string, error) {
+			if args[0] == "fs" && args[3] == "enable" {
+				assert.Equal(t, "myfs", args[4])
+				enabled = true
+			}
+			if args[0] == "fs" && args[3] == "peer_bootstrap" {
+				assert.Equal(t, "import", args[4])
+				assert.Equal(t, "myfs", args[5])
+				imported = append(imported, args[6])
+			}
+			return "", nil
+		},
+	}
+	clientset := testop.New(1)
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+	fs := cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "myns"},
+		Spec:       cephv1.FilesystemSpec{Mirroring: &cephv1.MirroringSpec{Peers: cephv1.MirroringPeerSpec{SecretNames: []string{"site-b"}}}},
+	}
+
+	// the secret of the peer is missing
+	assert.NotNil(t, setFilesystemMirroring(context, fs))
+	assert.False(t, enabled)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "site-b", Namespace: "myns"},
+		Data:       map[string][]byte{pool.PeerTokenKey: []byte("eyJmc2lkIjoiZGVmIn0=")},
+	}
+	_, err := clientset.CoreV1().Secrets("myns").Create(secret)
+	assert.Nil(t, err)
+	assert.Nil(t, setFilesystemMirroring(context, fs))
+	assert.True(t, enabled)
+	assert.Equal(t, []string{"eyJmc2lkIjoiZGVmIn0="}, imported)
+}
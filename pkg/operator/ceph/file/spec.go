@@ -55,6 +55,7 @@ func (c *cluster) makeDeployment(mdsConfig *mdsConfig) *extensions.Deployment {
 		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	}
 	c.fs.Spec.MetadataServer.Placement.ApplyToPodSpec(&podSpec.Spec)
+	c.fs.Spec.MetadataServer.PodExtension.ApplyToPodSpec(&podSpec.Spec)
 
 	replicas := int32(1)
 	d := &extensions.Deployment{
@@ -86,7 +87,7 @@ func (c *cluster) makeConfigInitContainer(mdsConfig *mdsConfig) v1.Container {
 			"--active-standby", strconv.FormatBool(c.fs.Spec.MetadataServer.ActiveStandby),
 		},
 		Image: k8sutil.MakeRookImage(c.rookVersion),
-		Env: []v1.EnvVar{
+		Env: append([]v1.EnvVar{
 			// Set '--mds-keyring' flag with an env var sourced from the secret
 			{Name: "ROOK_MDS_KEYRING",
 				ValueFrom: &v1.EnvVarSource{
@@ -101,7 +102,7 @@ func (c *cluster) makeConfigInitContainer(mdsConfig *mdsConfig) v1.Container {
 			opmon.SecretEnvVar(),
 			opmon.AdminSecretEnvVar(),
 			k8sutil.ConfigOverrideEnvVar(),
-		},
+		}, k8sutil.LogFormatEnvVars(true)...),
 		VolumeMounts: opspec.RookVolumeMounts(),
 		Resources:    c.fs.Spec.MetadataServer.Resources,
 	}
@@ -122,7 +123,8 @@ func (c *cluster) makeMdsDaemonContainer(mdsConfig *mdsConfig) v1.Container {
 		Env:          k8sutil.ClusterDaemonEnvVars(),
 		VolumeMounts: opspec.CephVolumeMounts(),
 		// TODO: mds doesn't need ports?
-		Resources: c.fs.Spec.MetadataServer.Resources,
+		Resources:     c.fs.Spec.MetadataServer.Resources,
+		LivenessProbe: opspec.LivenessProbe("mds", mdsConfig.DaemonName, c.fs.Spec.MetadataServer.LivenessProbe),
 	}
 }
 
@@ -138,6 +138,7 @@ func TestPodSpecs(t *testing.T) {
 	daemonContainerDefinition.TestContainer(t, "main mon daemon", cont, logger)
 	assert.Equal(t, "100", cont.Resources.Limits.Cpu().String())
 	assert.Equal(t, "1337", cont.Resources.Requests.Memory().String())
+	assert.Equal(t, []string{"ceph", "daemon", "mds.myfs-a", "status"}, cont.LivenessProbe.Exec.Command)
 
 	// Verify that all the mounts have volumes and that there are no extraneous volumes
 	volsMountsTestDef := testop.VolumesAndMountsTestDefinition{
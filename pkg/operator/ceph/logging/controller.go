@@ -0,0 +1,149 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logging to change the log level of the operator packages at runtime.
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/clusterd"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// ConfigMapName is the name of the configmap in the operator namespace with the log levels of the packages
+	ConfigMapName = "rook-ceph-log-level"
+	// DefaultLevelKey is the key of the configmap with the log level of the packages that are not listed
+	DefaultLevelKey = "default"
+	rookRepo        = "github.com/rook/rook"
+	allPackages     = "*"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-logging")
+
+// LogLevelController applies the log levels of the configmap to the operator packages
+type LogLevelController struct {
+	context      *clusterd.Context
+	namespace    string
+	defaultLevel capnslog.LogLevel
+}
+
+// NewLogLevelController creates a controller watching the log level configmap in the namespace of the operator.
+// The packages fall back to the log level of the operator command line when the configmap is deleted.
+func NewLogLevelController(context *clusterd.Context, namespace string) *LogLevelController {
+	return &LogLevelController{
+		context:      context,
+		namespace:    namespace,
+		defaultLevel: context.LogLevel,
+	}
+}
+
+// StartWatch watches the log level configmap until the stop channel is closed
+func (c *LogLevelController) StartWatch(stopCh chan struct{}) {
+	logger.Infof("start watching configmap %s in namespace %s for log level changes", ConfigMapName, c.namespace)
+	watchlist := cache.NewListWatchFromClient(c.context.Clientset.CoreV1().RESTClient(), "configmaps", c.namespace,
+		fields.OneTermEqualSelector("metadata.name", ConfigMapName))
+	_, controller := cache.NewInformer(watchlist, &v1.ConfigMap{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	})
+	go controller.Run(stopCh)
+}
+
+func (c *LogLevelController) onAdd(obj interface{}) {
+	if cm, ok := obj.(*v1.ConfigMap); ok {
+		c.apply(cm.Data)
+	}
+}
+
+func (c *LogLevelController) onUpdate(oldObj, newObj interface{}) {
+	if cm, ok := newObj.(*v1.ConfigMap); ok {
+		c.apply(cm.Data)
+	}
+}
+
+func (c *LogLevelController) onDelete(obj interface{}) {
+	logger.Infof("configmap %s deleted, resetting the log level of all packages to %s", ConfigMapName, c.defaultLevel)
+	c.apply(nil)
+}
+
+// apply sets the log level of the packages listed in the configmap and resets the other packages to the default level
+func (c *LogLevelController) apply(data map[string]string) {
+	repo, err := capnslog.GetRepoLogger(rookRepo)
+	if err != nil {
+		logger.Errorf("failed to get the rook loggers. %+v", err)
+		return
+	}
+
+	levels, err := ParseLogLevels(data, c.defaultLevel)
+	if err != nil {
+		logger.Errorf("ignoring configmap %s. %+v", ConfigMapName, err)
+		return
+	}
+
+	for _, pkg := range sortedPackages(levels) {
+		if _, ok := repo[pkg]; !ok {
+			logger.Warningf("unknown package %s in configmap %s", pkg, ConfigMapName)
+		}
+	}
+
+	// the logger of this package may be silenced below, log the change first
+	logger.Infof("setting log level %s for all packages with overrides %s", levels[allPackages], formatLevels(levels))
+	repo.SetLogLevel(levels)
+}
+
+// ParseLogLevels parses the log levels of the packages of the configmap. The package "*" is set to the
+// level of the "default" key, or to the given default level if the key is not set.
+func ParseLogLevels(data map[string]string, defaultLevel capnslog.LogLevel) (map[string]capnslog.LogLevel, error) {
+	levels := map[string]capnslog.LogLevel{allPackages: defaultLevel}
+	for pkg, value := range data {
+		level, err := capnslog.ParseLevel(strings.ToUpper(strings.TrimSpace(value)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level %s for package %s. %+v", value, pkg, err)
+		}
+		if pkg == DefaultLevelKey {
+			pkg = allPackages
+		}
+		levels[pkg] = level
+	}
+	return levels, nil
+}
+
+func sortedPackages(levels map[string]capnslog.LogLevel) []string {
+	var pkgs []string
+	for pkg := range levels {
+		if pkg != allPackages {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+func formatLevels(levels map[string]capnslog.LogLevel) string {
+	var overrides []string
+	for _, pkg := range sortedPackages(levels) {
+		overrides = append(overrides, fmt.Sprintf("%s=%s", pkg, levels[pkg]))
+	}
+	return "[" + strings.Join(overrides, ",") + "]"
+}
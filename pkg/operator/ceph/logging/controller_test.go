@@ -0,0 +1,59 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"testing"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogLevels(t *testing.T) {
+	levels, err := ParseLogLevels(nil, capnslog.INFO)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]capnslog.LogLevel{"*": capnslog.INFO}, levels)
+
+	levels, err = ParseLogLevels(map[string]string{"default": "warning", "op-osd": "DEBUG", "op-mon": " trace "}, capnslog.INFO)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]capnslog.LogLevel{"*": capnslog.WARNING, "op-osd": capnslog.DEBUG, "op-mon": capnslog.TRACE}, levels)
+
+	_, err = ParseLogLevels(map[string]string{"op-osd": "verbose"}, capnslog.INFO)
+	assert.NotNil(t, err)
+}
+
+func TestApplyLogLevels(t *testing.T) {
+	c := NewLogLevelController(&clusterd.Context{LogLevel: capnslog.INFO}, "rook-ceph-system")
+	defer c.apply(nil)
+
+	c.apply(map[string]string{"op-logging": "DEBUG"})
+	assert.True(t, logger.LevelAt(capnslog.DEBUG))
+
+	// an invalid configmap keeps the current levels
+	c.apply(map[string]string{"op-logging": "INFO", "op-osd": "verbose"})
+	assert.True(t, logger.LevelAt(capnslog.DEBUG))
+
+	// the packages not listed are reset to the default level
+	c.apply(map[string]string{"default": "WARNING"})
+	assert.False(t, logger.LevelAt(capnslog.INFO))
+	assert.True(t, logger.LevelAt(capnslog.WARNING))
+
+	c.apply(nil)
+	assert.True(t, logger.LevelAt(capnslog.INFO))
+	assert.False(t, logger.LevelAt(capnslog.DEBUG))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package maintenance pauses the controllers of the clusters in maintenance mode.
+package maintenance
+
+import (
+	"sync"
+
+	"github.com/coreos/pkg/capnslog"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-maintenance")
+
+var (
+	pausedLock sync.Mutex
+	// the namespaces of the clusters in maintenance mode, with the channel closed when the maintenance mode ends
+	paused = map[string]chan struct{}{}
+)
+
+// Pause pauses the controllers of the resources in the namespace of a cluster entering maintenance mode
+func Pause(namespace string) {
+	pausedLock.Lock()
+	defer pausedLock.Unlock()
+	if _, ok := paused[namespace]; !ok {
+		paused[namespace] = make(chan struct{})
+	}
+}
+
+// Resume resumes the controllers of the resources in the namespace of a cluster exiting maintenance mode or deleted
+func Resume(namespace string) {
+	pausedLock.Lock()
+	defer pausedLock.Unlock()
+	if ch, ok := paused[namespace]; ok {
+		close(ch)
+		delete(paused, namespace)
+	}
+}
+
+// Paused returns whether the cluster of the namespace is in maintenance mode
+func Paused(namespace string) bool {
+	pausedLock.Lock()
+	defer pausedLock.Unlock()
+	_, ok := paused[namespace]
+	return ok
+}
+
+// Wait blocks while the cluster of the namespace is in maintenance mode
+func Wait(namespace string) {
+	pausedLock.Lock()
+	ch, ok := paused[namespace]
+	pausedLock.Unlock()
+	if ok {
+		logger.Infof("waiting for the end of the maintenance mode of cluster %s", namespace)
+		<-ch
+	}
+}
+
+// PausableHandlers returns the handlers of the events of a controller, which wait while the cluster of the namespace
+// of the resource is in maintenance mode. The events are queued by the watcher of the controller in the meantime, so
+// the changes made during the maintenance are reconciled in order once it ends.
+func PausableHandlers(funcs cache.ResourceEventHandlerFuncs) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			Wait(objectNamespace(obj))
+			if funcs.AddFunc != nil {
+				funcs.AddFunc(obj)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			Wait(objectNamespace(newObj))
+			if funcs.UpdateFunc != nil {
+				funcs.UpdateFunc(oldObj, newObj)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			Wait(objectNamespace(obj))
+			if funcs.DeleteFunc != nil {
+				funcs.DeleteFunc(obj)
+			}
+		},
+	}
+}
+
+func objectNamespace(obj interface{}) string {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		namespace, _, err := cache.SplitMetaNamespaceKey(tombstone.Key)
+		if err != nil {
+			return ""
+		}
+		return namespace
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetNamespace()
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestPausableHandlers(t *testing.T) {
+	added := make(chan string, 2)
+	handlers := PausableHandlers(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { added <- obj.(*cephv1.CephBlockPool).Name },
+	})
+
+	// the events of the other clusters are handled during the maintenance
+	Pause("ns")
+	assert.True(t, Paused("ns"))
+	handlers.AddFunc(&cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other-ns"}})
+	assert.Equal(t, "other", <-added)
+
+	// the events of the cluster wait for the end of the maintenance
+	go handlers.AddFunc(&cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "ns"}})
+	select {
+	case <-added:
+		assert.Fail(t, "event handled during the maintenance")
+	case <-time.After(50 * time.Millisecond):
+	}
+	Resume("ns")
+	assert.False(t, Paused("ns"))
+	assert.Equal(t, "pool", <-added)
+
+	// the handlers not set are ignored
+	handlers.DeleteFunc(cache.DeletedFinalStateUnknown{Key: "ns/pool"})
+	Resume("ns")
+}
+
+func TestObjectNamespace(t *testing.T) {
+	assert.Equal(t, "ns", objectNamespace(&cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "ns"}}))
+	assert.Equal(t, "ns", objectNamespace(cache.DeletedFinalStateUnknown{Key: "ns/pool"}))
+	assert.Equal(t, "", objectNamespace("pool"))
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mirroring to report the health of the mirroring of the pools and filesystems to the peer clusters.
+package mirroring
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// DefaultIntervalSeconds is the number of seconds between two checks if the interval is not set
+	DefaultIntervalSeconds = 60
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-mirroring")
+
+// MirroringStatusResource represents the MirroringStatus custom resource object
+var MirroringStatusResource = opkit.CustomResource{
+	Name:    "cephmirroringstatus",
+	Plural:  "cephmirroringstatuses",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephMirroringStatus{}).Name(),
+}
+
+// MirroringStatusController represents a controller object for mirroring status custom resources
+type MirroringStatusController struct {
+	context     *clusterd.Context
+	cephVersion cephv1.CephVersionSpec
+	stopCh      chan struct{}
+	// the channels stopping the checks of the mirroring status resources, by name
+	checks map[string]chan struct{}
+	lock   sync.Mutex
+}
+
+// NewMirroringStatusController create controller for watching mirroring status custom resources created
+func NewMirroringStatusController(context *clusterd.Context, cephVersion cephv1.CephVersionSpec) *MirroringStatusController {
+	return &MirroringStatusController{
+		context:     context,
+		cephVersion: cephVersion,
+		checks:      map[string]chan struct{}{},
+	}
+}
+
+// StartWatch watches for instances of MirroringStatus custom resources and acts on them
+func (c *MirroringStatusController) StartWatch(namespace string, stopCh chan struct{}) error {
+	c.stopCh = stopCh
+
+	// the resources are not reconciled while their cluster is in maintenance mode
+	resourceHandlerFuncs := maintenance.PausableHandlers(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	})
+
+	logger.Infof("start watching mirroring status resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(MirroringStatusResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephMirroringStatus{}, stopCh)
+
+	return nil
+}
+
+func (c *MirroringStatusController) onAdd(obj interface{}) {
+	s, err := getMirroringStatusObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get mirroring status object: %+v", err)
+		return
+	}
+
+	c.startCheck(s)
+}
+
+func (c *MirroringStatusController) onUpdate(oldObj, newObj interface{}) {
+	oldStatus, err := getMirroringStatusObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old mirroring status object: %+v", err)
+		return
+	}
+	s, err := getMirroringStatusObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new mirroring status object: %+v", err)
+		return
+	}
+
+	if reflect.DeepEqual(oldStatus.Spec, s.Spec) {
+		// the status was updated
+		return
+	}
+	logger.Infof("updating the mirroring checks of %s/%s", s.Namespace, s.Name)
+	c.stopCheck(s)
+	c.startCheck(s)
+}
+
+func (c *MirroringStatusController) onDelete(obj interface{}) {
+	s, err := getMirroringStatusObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get mirroring status object: %+v", err)
+		return
+	}
+
+	c.stopCheck(s)
+	removeMetrics(s.Namespace, s.Name, &s.Status)
+}
+
+func (c *MirroringStatusController) startCheck(s *cephv1.CephMirroringStatus) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.checks[s.Name]; ok {
+		return
+	}
+	stop := make(chan struct{})
+	c.checks[s.Name] = stop
+	go c.run(s.Namespace, s.Name, stop)
+}
+
+func (c *MirroringStatusController) stopCheck(s *cephv1.CephMirroringStatus) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if stop, ok := c.checks[s.Name]; ok {
+		close(stop)
+		delete(c.checks, s.Name)
+	}
+}
+
+// run checks the mirroring at the interval of the mirroring status resource until its check is stopped
+func (c *MirroringStatusController) run(namespace, name string, stop chan struct{}) {
+	wait := time.Duration(0)
+	for {
+		select {
+		case <-time.After(wait):
+		case <-stop:
+			return
+		case <-c.stopCh:
+			return
+		}
+
+		s, err := c.context.RookClientset.CephV1().CephMirroringStatuses(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return
+			}
+			logger.Errorf("failed to get mirroring status %s/%s. %+v", namespace, name, err)
+			wait = DefaultIntervalSeconds * time.Second
+			continue
+		}
+
+		wait = interval(s)
+		if err := c.check(s); err != nil {
+			logger.Errorf("failed to check the mirroring of %s/%s. %+v", namespace, name, err)
+		}
+	}
+}
+
+// check collects the mirroring status of the pools and filesystems, then publishes it in the status of the resource
+// and in the metrics of the operator
+func (c *MirroringStatusController) check(s *cephv1.CephMirroringStatus) error {
+	health := collect(c.context, s, c.cephVersion, time.Now())
+	publishMetrics(s.Namespace, s.Name, &s.Status, health)
+	if health.Health != s.Status.Health {
+		logger.Infof("mirroring health of %s/%s changed from %q to %q", s.Namespace, s.Name, s.Status.Health, health.Health)
+	}
+
+	latest, err := c.context.RookClientset.CephV1().CephMirroringStatuses(s.Namespace).Get(s.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	latest.Status = *health
+	if _, err := c.context.RookClientset.CephV1().CephMirroringStatuses(s.Namespace).Update(latest); err != nil {
+		return fmt.Errorf("failed to update the status. %+v", err)
+	}
+	return nil
+}
+
+func interval(s *cephv1.CephMirroringStatus) time.Duration {
+	if s.Spec.IntervalSeconds <= 0 {
+		return DefaultIntervalSeconds * time.Second
+	}
+	return time.Duration(s.Spec.IntervalSeconds) * time.Second
+}
+
+func getMirroringStatusObject(obj interface{}) (*cephv1.CephMirroringStatus, error) {
+	s, ok := obj.(*cephv1.CephMirroringStatus)
+	if ok {
+		return s.DeepCopy(), nil
+	}
+	return nil, fmt.Errorf("not a known mirroring status object: %+v", obj)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirroring
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func gaugeValue(t *testing.T, gauge prometheus.Gauge) float64 {
+	var m dto.Metric
+	err := gauge.Write(&m)
+	assert.Nil(t, err)
+	return m.GetGauge().GetValue()
+}
+
+func TestCheck(t *testing.T) {
+	context := newTestContext(map[string]string{"info replicapool": poolInfo, "status replicapool": poolStatusJSON}, "")
+	c := NewMirroringStatusController(context, pacific)
+	s := newTestMirroringStatus()
+	_, err := context.RookClientset.CephV1().CephMirroringStatuses("ns").Create(s)
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.check(s))
+	s, err = context.RookClientset.CephV1().CephMirroringStatuses("ns").Get("dr", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.True(t, s.Status.Ready)
+	assert.Equal(t, HealthOK, s.Status.Health)
+	assert.NotNil(t, s.Status.LastChecked)
+	assert.Equal(t, float64(1), gaugeValue(t, mirroringReady.WithLabelValues("ns", "dr")))
+	assert.Equal(t, float64(2), gaugeValue(t, mirroringImages.WithLabelValues("ns", "dr", "replicapool", "up+replaying")))
+	assert.Equal(t, float64(3), gaugeValue(t, mirroringEntriesBehind.WithLabelValues("ns", "dr", "replicapool")))
+
+	removeMetrics("ns", "dr", &s.Status)
+	assert.Equal(t, float64(0), gaugeValue(t, mirroringReady.WithLabelValues("ns", "dr")))
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirroring
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+)
+
+var (
+	mirroringReady = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "mirroring_ready",
+			Help:      "Whether the mirroring of all the pools and filesystems of the mirroring status is healthy",
+		},
+		[]string{"namespace", "name"},
+	)
+	mirroringHealth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "mirroring_health",
+			Help:      "Health of the mirroring of a pool or filesystem: 0 for OK, 1 for WARNING and 2 for ERROR",
+		},
+		[]string{"namespace", "name", "type", "resource"},
+	)
+	mirroringImages = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "mirroring_images",
+			Help:      "Number of mirrored images of a pool by mirroring state",
+		},
+		[]string{"namespace", "name", "pool", "state"},
+	)
+	mirroringEntriesBehind = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "mirroring_entries_behind",
+			Help:      "Largest number of journal entries an image of a pool is behind its primary image",
+		},
+		[]string{"namespace", "name", "pool"},
+	)
+	mirroringDirectoryFailures = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "mirroring_directory_failures",
+			Help:      "Number of directories of a filesystem which failed to synchronize to a peer",
+		},
+		[]string{"namespace", "name", "filesystem", "peer"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(mirroringReady, mirroringHealth, mirroringImages, mirroringEntriesBehind, mirroringDirectoryFailures)
+}
+
+// publishMetrics replaces the metrics of the previous health of a mirroring status with those of the new health,
+// so the pools, states and peers which disappeared are not reported anymore
+func publishMetrics(namespace, name string, previous, health *cephv1.MirroringHealth) {
+	removeMetrics(namespace, name, previous)
+
+	ready := 0.0
+	if health.Ready {
+		ready = 1
+	}
+	mirroringReady.WithLabelValues(namespace, name).Set(ready)
+	for _, pool := range health.Pools {
+		mirroringHealth.WithLabelValues(namespace, name, "pool", pool.Name).Set(float64(healthSeverity[pool.Health]))
+		mirroringEntriesBehind.WithLabelValues(namespace, name, pool.Name).Set(float64(pool.MaxEntriesBehind))
+		for state, count := range pool.States {
+			mirroringImages.WithLabelValues(namespace, name, pool.Name, state).Set(float64(count))
+		}
+	}
+	for _, fs := range health.Filesystems {
+		mirroringHealth.WithLabelValues(namespace, name, "filesystem", fs.Name).Set(float64(healthSeverity[fs.Health]))
+		for _, peer := range fs.Peers {
+			mirroringDirectoryFailures.WithLabelValues(namespace, name, fs.Name, peer.UUID).Set(float64(peer.FailureCount))
+		}
+	}
+}
+
+// removeMetrics removes the metrics of the health of a mirroring status
+func removeMetrics(namespace, name string, health *cephv1.MirroringHealth) {
+	mirroringReady.DeleteLabelValues(namespace, name)
+	for _, pool := range health.Pools {
+		mirroringHealth.DeleteLabelValues(namespace, name, "pool", pool.Name)
+		mirroringEntriesBehind.DeleteLabelValues(namespace, name, pool.Name)
+		for state := range pool.States {
+			mirroringImages.DeleteLabelValues(namespace, name, pool.Name, state)
+		}
+	}
+	for _, fs := range health.Filesystems {
+		mirroringHealth.DeleteLabelValues(namespace, name, "filesystem", fs.Name)
+		for _, peer := range fs.Peers {
+			mirroringDirectoryFailures.DeleteLabelValues(namespace, name, fs.Name, peer.UUID)
+		}
+	}
+}
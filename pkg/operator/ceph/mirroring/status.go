@@ -0,0 +1,299 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirroring
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	HealthOK      = "OK"
+	HealthWarning = "WARNING"
+	HealthError   = "ERROR"
+
+	// the number of unhealthy images reported in the status of a pool
+	maxReportedImages = 20
+	// the format of the last update of the mirroring status of an image
+	lastUpdateLayout = "2006-01-02 15:04:05"
+)
+
+var (
+	healthSeverity      = map[string]int{HealthOK: 0, HealthWarning: 1, HealthError: 2}
+	entriesBehindRegexp = regexp.MustCompile(`entries_behind_master=(\d+)`)
+)
+
+// collect returns the mirroring health of the pools and filesystems of a mirroring status resource. The filesystems
+// are mirrored by the cephfs-mirror daemons of pacific, they are not queried on the older versions.
+func collect(context *clusterd.Context, s *cephv1.CephMirroringStatus, cephVersion cephv1.CephVersionSpec, now time.Time) *cephv1.MirroringHealth {
+	checked := metav1.NewTime(now)
+	health := &cephv1.MirroringHealth{Health: HealthOK, LastChecked: &checked}
+
+	pools, err := mirroredPools(context, s)
+	if err != nil {
+		logger.Warningf("failed to list the pools of %s. %+v", s.Namespace, err)
+		health.Health = HealthError
+	}
+	for _, pool := range pools {
+		status := poolStatus(context, s, pool, now)
+		health.Pools = append(health.Pools, status)
+		health.Health = worst(health.Health, status.Health)
+	}
+
+	if len(s.Spec.Filesystems) > 0 && !cephv1.VersionAtLeast(cephVersion.Name, cephv1.Pacific) {
+		for _, fs := range s.Spec.Filesystems {
+			status := cephv1.FilesystemMirroringStatus{Name: fs, Health: HealthError, Message: "the mirroring of the filesystems requires pacific"}
+			health.Filesystems = append(health.Filesystems, status)
+			health.Health = worst(health.Health, status.Health)
+		}
+	} else if len(s.Spec.Filesystems) > 0 {
+		daemons, err := client.GetFilesystemMirrorDaemonStatus(context, s.Namespace)
+		for _, fs := range s.Spec.Filesystems {
+			status := cephv1.FilesystemMirroringStatus{Name: fs, Health: HealthError}
+			if err != nil {
+				status.Message = err.Error()
+			} else {
+				status = filesystemStatus(fs, daemons)
+			}
+			health.Filesystems = append(health.Filesystems, status)
+			health.Health = worst(health.Health, status.Health)
+		}
+	}
+
+	if len(health.Pools) == 0 && len(health.Filesystems) == 0 {
+		// nothing is mirrored
+		health.Health = worst(health.Health, HealthWarning)
+	}
+	health.Ready = health.Health == HealthOK
+	return health
+}
+
+// mirroredPools returns the pools of the spec, or the block pools of the namespace with mirroring enabled
+func mirroredPools(context *clusterd.Context, s *cephv1.CephMirroringStatus) ([]string, error) {
+	if len(s.Spec.Pools) > 0 {
+		return s.Spec.Pools, nil
+	}
+
+	pools, err := context.RookClientset.CephV1().CephBlockPools(s.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, pool := range pools.Items {
+		info, err := client.GetPoolMirroringInfo(context, s.Namespace, pool.Name)
+		if err != nil {
+			logger.Warningf("failed to get the mirroring mode of pool %s. %+v", pool.Name, err)
+			continue
+		}
+		if info.Mode != "" && info.Mode != "disabled" {
+			names = append(names, pool.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// poolStatus returns the mirroring status of a pool. The pool is healthy if it has a peer and all its images are
+// replaying, or stopped for the primary images, without lagging.
+func poolStatus(context *clusterd.Context, s *cephv1.CephMirroringStatus, pool string, now time.Time) cephv1.PoolMirroringStatus {
+	status := cephv1.PoolMirroringStatus{Name: pool, Health: HealthError}
+	info, err := client.GetPoolMirroringInfo(context, s.Namespace, pool)
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+	status.Mode = info.Mode
+	if info.Mode == "" || info.Mode == "disabled" {
+		status.Message = "mirroring is not enabled"
+		return status
+	}
+	for _, peer := range info.Peers {
+		status.Peers = append(status.Peers, cephv1.MirroringPeerStatus{
+			UUID:        peer.UUID,
+			SiteName:    peer.SiteName,
+			ClusterName: peer.ClusterName,
+			ClientName:  peer.ClientName,
+		})
+	}
+
+	mirrorStatus, err := client.GetPoolMirroringStatus(context, s.Namespace, pool)
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+
+	status.Health = summaryHealth(mirrorStatus.Summary.Health)
+	var messages []string
+	if len(status.Peers) == 0 {
+		status.Health = worst(status.Health, HealthWarning)
+		messages = append(messages, "no peer cluster")
+	}
+
+	status.States = map[string]int{}
+	unhealthy := 0
+	for _, image := range mirrorStatus.Images {
+		status.States[image.State]++
+		entriesBehind := parseEntriesBehind(image.Description)
+		if entriesBehind > status.MaxEntriesBehind {
+			status.MaxEntriesBehind = entriesBehind
+		}
+
+		imageHealth := imageStateHealth(image.State)
+		if imageHealth == HealthOK && lagging(s, image, entriesBehind, now) {
+			imageHealth = HealthWarning
+		}
+		if imageHealth == HealthOK {
+			continue
+		}
+		status.Health = worst(status.Health, imageHealth)
+		unhealthy++
+		if len(status.UnhealthyImages) < maxReportedImages {
+			status.UnhealthyImages = append(status.UnhealthyImages, cephv1.ImageMirroringStatus{
+				Name:        image.Name,
+				State:       image.State,
+				Description: image.Description,
+				LastUpdate:  image.LastUpdate,
+			})
+		}
+	}
+	if unhealthy > 0 {
+		messages = append(messages, fmt.Sprintf("%d of %d images are not healthy or lagging", unhealthy, len(mirrorStatus.Images)))
+	}
+	if status.Health != HealthOK && len(messages) == 0 {
+		messages = append(messages, fmt.Sprintf("daemon health %s, image health %s", mirrorStatus.Summary.DaemonHealth, mirrorStatus.Summary.ImageHealth))
+	}
+	status.Message = strings.Join(messages, ". ")
+	return status
+}
+
+// filesystemStatus returns the snapshot mirroring status of a filesystem from the status of the cephfs-mirror daemons
+func filesystemStatus(fs string, daemons []client.FilesystemMirrorDaemonStatus) cephv1.FilesystemMirroringStatus {
+	status := cephv1.FilesystemMirroringStatus{Name: fs, Health: HealthOK}
+	peers := map[string]*cephv1.MirroringPeerStatus{}
+	var uuids []string
+	for _, daemon := range daemons {
+		for _, f := range daemon.Filesystems {
+			if f.Name != fs {
+				continue
+			}
+			status.Daemons++
+			if f.DirectoryCount > status.Directories {
+				status.Directories = f.DirectoryCount
+			}
+			for _, p := range f.Peers {
+				peer, ok := peers[p.UUID]
+				if !ok {
+					peer = &cephv1.MirroringPeerStatus{
+						UUID:        p.UUID,
+						ClusterName: p.Remote.ClusterName,
+						ClientName:  p.Remote.ClientName,
+						Filesystem:  p.Remote.FSName,
+					}
+					peers[p.UUID] = peer
+					uuids = append(uuids, p.UUID)
+				}
+				peer.FailureCount += p.Stats.FailureCount
+				peer.RecoveryCount += p.Stats.RecoveryCount
+			}
+		}
+	}
+
+	if status.Daemons == 0 {
+		status.Health = HealthError
+		status.Message = "no cephfs-mirror daemon mirrors the filesystem"
+		return status
+	}
+	if len(uuids) == 0 {
+		status.Health = HealthWarning
+		status.Message = "no peer cluster"
+		return status
+	}
+	failures := 0
+	for _, uuid := range uuids {
+		status.Peers = append(status.Peers, *peers[uuid])
+		failures += peers[uuid].FailureCount
+	}
+	if failures > 0 {
+		status.Health = HealthWarning
+		status.Message = fmt.Sprintf("%d directories failed to synchronize", failures)
+	}
+	return status
+}
+
+// imageStateHealth returns the health of an image from its mirroring state, such as up+replaying
+func imageStateHealth(state string) string {
+	parts := strings.SplitN(state, "+", 2)
+	if len(parts) != 2 || parts[0] != "up" || parts[1] == "error" {
+		return HealthError
+	}
+	switch parts[1] {
+	case "replaying", "stopped":
+		return HealthOK
+	}
+	// syncing, starting_replay, stopping_replay or unknown
+	return HealthWarning
+}
+
+// lagging returns whether an image is too many journal entries behind its primary image, or whether its status
+// was not updated by rbd-mirror for too long
+func lagging(s *cephv1.CephMirroringStatus, image client.MirrorImageStatus, entriesBehind int64, now time.Time) bool {
+	if s.Spec.MaxEntriesBehind > 0 && entriesBehind > s.Spec.MaxEntriesBehind {
+		return true
+	}
+	if s.Spec.MaxSyncLagSeconds > 0 && image.LastUpdate != "" {
+		lastUpdate, err := time.Parse(lastUpdateLayout, image.LastUpdate)
+		if err != nil {
+			logger.Warningf("failed to parse the last update %q of image %s. %+v", image.LastUpdate, image.Name, err)
+			return false
+		}
+		return now.Sub(lastUpdate) > time.Duration(s.Spec.MaxSyncLagSeconds)*time.Second
+	}
+	return false
+}
+
+func parseEntriesBehind(description string) int64 {
+	match := entriesBehindRegexp.FindStringSubmatch(description)
+	if match == nil {
+		return 0
+	}
+	entries, _ := strconv.ParseInt(match[1], 10, 64)
+	return entries
+}
+
+func summaryHealth(health string) string {
+	switch health {
+	case HealthOK, HealthWarning, HealthError:
+		return health
+	}
+	return HealthWarning
+}
+
+func worst(a, b string) string {
+	if healthSeverity[b] > healthSeverity[a] {
+		return b
+	}
+	return a
+}
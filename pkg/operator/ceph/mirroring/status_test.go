@@ -0,0 +1,191 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mirroring
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	poolInfo       = `{"mode":"pool","peers":[{"uuid":"8b2e","cluster_name":"site-b","client_name":"client.rbd-mirror-peer"}]}`
+	poolStatusJSON = `{"summary":{"health":"OK","daemon_health":"OK","image_health":"OK","states":{"replaying":2}},"images":[
+		{"name":"img1","state":"up+replaying","description":"replaying, entries_behind_master=3","last_update":"2019-03-01 10:00:00"},
+		{"name":"img2","state":"up+replaying","description":"replaying, entries_behind_master=0","last_update":"2019-03-01 10:00:30"}]}`
+	fsDaemonStatus = `[{"daemon_id":4115,"filesystems":[{"filesystem_id":1,"name":"myfs","directory_count":2,
+		"peers":[{"uuid":"c4f1","remote":{"client_name":"client.mirror_remote","cluster_name":"site-b","fs_name":"backupfs"},
+		"stats":{"failure_count":0,"recovery_count":1}}]}]}]`
+)
+
+func newTestContext(rbdOutput map[string]string, fsOutput string) *clusterd.Context {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			// rbd mirror pool info|status <pool>
+			if output, ok := rbdOutput[args[2]+" "+args[3]]; ok {
+				return output, nil
+			}
+			return "", errors.New("mock failure")
+		},
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if fsOutput == "" {
+				return "", errors.New("unknown command")
+			}
+			return fsOutput, nil
+		},
+	}
+	return &clusterd.Context{Executor: executor, RookClientset: rookfake.NewSimpleClientset()}
+}
+
+var pacific = cephv1.CephVersionSpec{Name: cephv1.Pacific}
+
+func newTestMirroringStatus() *cephv1.CephMirroringStatus {
+	return &cephv1.CephMirroringStatus{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr", Namespace: "ns"},
+		Spec:       cephv1.MirroringStatusSpec{Pools: []string{"replicapool"}},
+	}
+}
+
+func TestCollectPools(t *testing.T) {
+	now := time.Date(2019, 3, 1, 10, 1, 0, 0, time.UTC)
+	context := newTestContext(map[string]string{"info replicapool": poolInfo, "status replicapool": poolStatusJSON}, "")
+	s := newTestMirroringStatus()
+
+	health := collect(context, s, pacific, now)
+	assert.Equal(t, HealthOK, health.Health)
+	assert.True(t, health.Ready)
+	assert.Equal(t, 1, len(health.Pools))
+	pool := health.Pools[0]
+	assert.Equal(t, "pool", pool.Mode)
+	assert.Equal(t, "site-b", pool.Peers[0].ClusterName)
+	assert.Equal(t, 2, pool.States["up+replaying"])
+	assert.Equal(t, int64(3), pool.MaxEntriesBehind)
+	assert.Equal(t, 0, len(pool.UnhealthyImages))
+
+	// the images too far behind or not updated for too long are lagging
+	s.Spec.MaxEntriesBehind = 2
+	health = collect(context, s, pacific, now)
+	assert.Equal(t, HealthWarning, health.Health)
+	assert.False(t, health.Ready)
+	assert.Equal(t, "img1", health.Pools[0].UnhealthyImages[0].Name)
+	assert.Equal(t, "1 of 2 images are not healthy or lagging", health.Pools[0].Message)
+
+	s.Spec.MaxEntriesBehind = 0
+	s.Spec.MaxSyncLagSeconds = 45
+	health = collect(context, s, pacific, now)
+	assert.Equal(t, 1, len(health.Pools[0].UnhealthyImages))
+	assert.Equal(t, "img1", health.Pools[0].UnhealthyImages[0].Name)
+
+	// a pool without mirroring is not ready
+	context = newTestContext(map[string]string{"info replicapool": `{"mode":"disabled"}`}, "")
+	health = collect(context, newTestMirroringStatus(), pacific, now)
+	assert.Equal(t, HealthError, health.Health)
+	assert.Equal(t, "mirroring is not enabled", health.Pools[0].Message)
+
+	// the failure of the rbd commands is an error
+	context = newTestContext(map[string]string{}, "")
+	health = collect(context, newTestMirroringStatus(), pacific, now)
+	assert.Equal(t, HealthError, health.Pools[0].Health)
+	assert.NotEmpty(t, health.Pools[0].Message)
+}
+
+func TestCollectAllPools(t *testing.T) {
+	context := newTestContext(map[string]string{
+		"info replicapool": poolInfo, "status replicapool": poolStatusJSON,
+		"info otherpool": `{"mode":"disabled","peers":[]}`,
+	}, "")
+	for _, name := range []string{"replicapool", "otherpool"} {
+		_, err := context.RookClientset.CephV1().CephBlockPools("ns").Create(&cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"}})
+		assert.Nil(t, err)
+	}
+	s := newTestMirroringStatus()
+	s.Spec.Pools = nil
+
+	// only the pools with mirroring enabled are checked
+	health := collect(context, s, pacific, time.Now())
+	assert.Equal(t, 1, len(health.Pools))
+	assert.Equal(t, "replicapool", health.Pools[0].Name)
+
+	// nothing is mirrored
+	health = collect(newTestContext(map[string]string{}, ""), s, pacific, time.Now())
+	assert.Equal(t, HealthWarning, health.Health)
+	assert.False(t, health.Ready)
+}
+
+func TestCollectFilesystems(t *testing.T) {
+	s := newTestMirroringStatus()
+	s.Spec.Pools = []string{"replicapool"}
+	s.Spec.Filesystems = []string{"myfs", "otherfs"}
+	rbdOutput := map[string]string{"info replicapool": poolInfo, "status replicapool": poolStatusJSON}
+
+	health := collect(newTestContext(rbdOutput, fsDaemonStatus), s, pacific, time.Now())
+	assert.Equal(t, HealthError, health.Health)
+	fs := health.Filesystems[0]
+	assert.Equal(t, HealthOK, fs.Health)
+	assert.Equal(t, 1, fs.Daemons)
+	assert.Equal(t, 2, fs.Directories)
+	assert.Equal(t, "backupfs", fs.Peers[0].Filesystem)
+	assert.Equal(t, 1, fs.Peers[0].RecoveryCount)
+	assert.Equal(t, HealthError, health.Filesystems[1].Health)
+	assert.Equal(t, "no cephfs-mirror daemon mirrors the filesystem", health.Filesystems[1].Message)
+
+	// cephfs-mirror is not supported by the cluster
+	s.Spec.Filesystems = []string{"myfs"}
+	health = collect(newTestContext(rbdOutput, ""), s, pacific, time.Now())
+	assert.Equal(t, HealthError, health.Filesystems[0].Health)
+	assert.Contains(t, health.Filesystems[0].Message, "unknown command")
+
+	// the daemons are not queried before pacific
+	health = collect(newTestContext(rbdOutput, fsDaemonStatus), s, cephv1.CephVersionSpec{Name: cephv1.Nautilus}, time.Now())
+	assert.Equal(t, HealthError, health.Filesystems[0].Health)
+	assert.Equal(t, "the mirroring of the filesystems requires pacific", health.Filesystems[0].Message)
+	assert.Equal(t, 0, health.Filesystems[0].Daemons)
+}
+
+func TestFilesystemStatusFailures(t *testing.T) {
+	daemons := []client.FilesystemMirrorDaemonStatus{{Filesystems: []client.FilesystemMirrorStatus{{Name: "myfs", DirectoryCount: 3}}}}
+	status := filesystemStatus("myfs", daemons)
+	assert.Equal(t, HealthWarning, status.Health)
+	assert.Equal(t, "no peer cluster", status.Message)
+
+	peer := client.FilesystemMirrorPeerStatus{UUID: "c4f1"}
+	peer.Stats.FailureCount = 2
+	daemons[0].Filesystems[0].Peers = append(daemons[0].Filesystems[0].Peers, peer)
+	status = filesystemStatus("myfs", daemons)
+	assert.Equal(t, HealthWarning, status.Health)
+	assert.Equal(t, "2 directories failed to synchronize", status.Message)
+}
+
+func TestImageStateHealth(t *testing.T) {
+	assert.Equal(t, HealthOK, imageStateHealth("up+replaying"))
+	assert.Equal(t, HealthOK, imageStateHealth("up+stopped"))
+	assert.Equal(t, HealthWarning, imageStateHealth("up+syncing"))
+	assert.Equal(t, HealthWarning, imageStateHealth("up+starting_replay"))
+	assert.Equal(t, HealthError, imageStateHealth("up+error"))
+	assert.Equal(t, HealthError, imageStateHealth("down+unknown"))
+	assert.Equal(t, HealthError, imageStateHealth("unknown"))
+	assert.Equal(t, int64(12), parseEntriesBehind("replaying, master_position=[object_number=0, tag_tid=1, entry_tid=15], entries_behind_master=12"))
+	assert.Equal(t, int64(0), parseEntriesBehind("local image is primary"))
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	rgwdaemon "github.com/rook/rook/pkg/daemon/ceph/rgw"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	adminOpsUserID = "rook-ceph-admin-ops"
+	accessKeyName  = "AccessKey"
+	secretKeyName  = "SecretKey"
+	// the endpoint of the store is read by the COSI driver from the secret referenced by its bucket classes
+	endpointKeyName = "Endpoint"
+)
+
+// AdminOpsSecretName is the name of the secret with the keys of the admin ops user of the object store
+func AdminOpsSecretName(store string) string {
+	return fmt.Sprintf("%s-%s-admin-ops", appName, store)
+}
+
+// Create the user of the admin ops api of the object store and store its keys in a secret
+func (c *config) createAdminOpsUser() error {
+	endpoint := storeEndpoint(&c.store)
+	existing, err := c.context.Clientset.CoreV1().Secrets(c.store.Namespace).Get(AdminOpsSecretName(c.store.Name), metav1.GetOptions{})
+	if err == nil {
+		logger.Debugf("the admin ops user of object store %s was already created", c.store.Name)
+		if string(existing.Data[endpointKeyName]) == endpoint {
+			return nil
+		}
+		// the secrets created before the endpoint was added, or after a change of the ports of the store
+		if existing.StringData == nil {
+			existing.StringData = map[string]string{}
+		}
+		existing.StringData[endpointKeyName] = endpoint
+		if _, err := c.context.Clientset.CoreV1().Secrets(c.store.Namespace).Update(existing); err != nil {
+			return fmt.Errorf("failed to update the endpoint of the admin ops secret. %+v", err)
+		}
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get the admin ops secret. %+v", err)
+	}
+
+	objContext := rgwdaemon.NewContext(c.context, c.store.Name, c.store.Namespace)
+	user, err := rgwdaemon.GetOrCreateAdminOpsUser(objContext, adminOpsUserID)
+	if err != nil {
+		return err
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AdminOpsSecretName(c.store.Name),
+			Namespace: c.store.Namespace,
+			Labels:    c.getLabels(),
+		},
+		StringData: map[string]string{
+			accessKeyName:   *user.AccessKey,
+			secretKeyName:   *user.SecretKey,
+			endpointKeyName: endpoint,
+		},
+		Type: k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRefs(c.context.Clientset, c.store.Namespace, &secret.ObjectMeta, c.ownerRefs)
+	if _, err := c.context.Clientset.CoreV1().Secrets(c.store.Namespace).Create(secret); err != nil {
+		return fmt.Errorf("failed to save the admin ops secret. %+v", err)
+	}
+	logger.Infof("created admin ops user of object store %s", c.store.Name)
+	return nil
+}
+
+// GetStoreEndpoint returns the url of the rgw service of the object store, on its http port if it has one
+func GetStoreEndpoint(context *clusterd.Context, namespace, store string) (string, error) {
+	s, err := context.RookClientset.CephV1().CephObjectStores(namespace).Get(store, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get object store %s. %+v", store, err)
+	}
+	return storeEndpoint(s), nil
+}
+
+// GetStoreCACert returns the certificates of the ssl certificate of the object store, without its private key, so the
+// clients of the https endpoint can trust a self-signed certificate. It is empty if the store has no ssl certificate.
+func GetStoreCACert(context *clusterd.Context, namespace, store string) (string, error) {
+	s, err := context.RookClientset.CephV1().CephObjectStores(namespace).Get(store, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get object store %s. %+v", store, err)
+	}
+	if s.Spec.Gateway.SSLCertificateRef == "" {
+		return "", nil
+	}
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(s.Spec.Gateway.SSLCertificateRef, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the ssl certificate of object store %s. %+v", store, err)
+	}
+
+	var certs []byte
+	rest := secret.Data[certKeyName]
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			certs = append(certs, pem.EncodeToMemory(block)...)
+		}
+	}
+	return string(certs), nil
+}
+
+func storeEndpoint(s *cephv1.CephObjectStore) string {
+	host := fmt.Sprintf("%s-%s.%s", appName, s.Name, s.Namespace)
+	if s.Spec.Gateway.Port != 0 {
+		return fmt.Sprintf("http://%s:%d", host, s.Spec.Gateway.Port)
+	}
+	return fmt.Sprintf("https://%s:%d", host, s.Spec.Gateway.SecurePort)
+}
+
+// NewAdminOpsClient creates a client of the admin ops api of the object store at the endpoint with the keys of the admin
+// ops user of the store
+func NewAdminOpsClient(context *clusterd.Context, namespace, store, endpoint string) (*rgwdaemon.AdminOpsClient, error) {
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(AdminOpsSecretName(store), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the keys of the admin ops user of object store %s. %+v", store, err)
+	}
+
+	// trust the ssl certificate of the store when its admin ops api is only reachable on the https port
+	var caCert string
+	if strings.HasPrefix(endpoint, "https://") {
+		caCert, err = GetStoreCACert(context, namespace, store)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rgwdaemon.NewAdminOpsClient(string(secret.Data[accessKeyName]), string(secret.Data[secretKeyName]), endpoint, []byte(caCert))
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package objectbucket to manage the buckets of a rook object store.
+package objectbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coreos/pkg/capnslog"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephrgw "github.com/rook/rook/pkg/daemon/ceph/rgw"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-object-bucket")
+
+// ObjectBucketResource represents the object bucket custom resource
+var ObjectBucketResource = opkit.CustomResource{
+	Name:    "cephobjectbucket",
+	Plural:  "cephobjectbuckets",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephObjectBucket{}).Name(),
+}
+
+// ObjectBucketController represents a controller object for object bucket custom resources
+type ObjectBucketController struct {
+	context *clusterd.Context
+	// the url of the rgw service of an object store
+	endpoint func(namespace, store string) (string, error)
+}
+
+// NewObjectBucketController create controller for watching object bucket custom resources created
+func NewObjectBucketController(context *clusterd.Context) *ObjectBucketController {
+	return &ObjectBucketController{
+		context: context,
+		endpoint: func(namespace, store string) (string, error) {
+			return object.GetStoreEndpoint(context, namespace, store)
+		},
+	}
+}
+
+// StartWatch watches for instances of ObjectBucket custom resources and acts on them
+func (c *ObjectBucketController) StartWatch(namespace string, stopCh chan struct{}) error {
+
+	// the resources are not reconciled while their cluster is in maintenance mode
+	resourceHandlerFuncs := maintenance.PausableHandlers(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	})
+
+	logger.Infof("start watching object bucket resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(ObjectBucketResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephObjectBucket{}, stopCh)
+
+	return nil
+}
+
+func (c *ObjectBucketController) onAdd(obj interface{}) {
+	bucket, err := getObjectBucketObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get object bucket object: %+v", err)
+		return
+	}
+
+	if err := c.createBucket(bucket); err != nil {
+		logger.Errorf("failed to create object bucket %s. %+v", bucket.Name, err)
+	}
+}
+
+func (c *ObjectBucketController) onUpdate(oldObj, newObj interface{}) {
+	oldBucket, err := getObjectBucketObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old object bucket object: %+v", err)
+		return
+	}
+	bucket, err := getObjectBucketObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new object bucket object: %+v", err)
+		return
+	}
+
+	if reflect.DeepEqual(oldBucket.Spec, bucket.Spec) {
+		logger.Debugf("object bucket %s not changed", bucket.Name)
+		return
+	}
+
+	logger.Infof("updating object bucket %s", bucket.Name)
+	if err := c.createBucket(bucket); err != nil {
+		logger.Errorf("failed to update object bucket %s. %+v", bucket.Name, err)
+	}
+}
+
+func (c *ObjectBucketController) onDelete(obj interface{}) {
+	bucket, err := getObjectBucketObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get object bucket object: %+v", err)
+		return
+	}
+
+	// the objects of the bucket are not deleted with the resource
+	logger.Infof("object bucket %s deleted. the bucket %s is kept in object store %s", bucket.Name, bucketName(bucket), bucket.Spec.Store)
+}
+
+func getObjectBucketObject(obj interface{}) (*cephv1.CephObjectBucket, error) {
+	bucket, ok := obj.(*cephv1.CephObjectBucket)
+	if ok {
+		return bucket.DeepCopy(), nil
+	}
+	return nil, fmt.Errorf("not a known object bucket object: %+v", obj)
+}
+
+// Create the bucket with the credentials of its owner and apply its settings. The settings are applied again when they change.
+func (c *ObjectBucketController) createBucket(b *cephv1.CephObjectBucket) error {
+	if err := ValidateBucket(b); err != nil {
+		return fmt.Errorf("invalid object bucket %s arguments. %+v", b.Name, err)
+	}
+
+	agent, err := c.ownerAgent(b)
+	if err != nil {
+		return err
+	}
+
+	name := bucketName(b)
+	if err := agent.CreateBucket(name); err != nil {
+		return err
+	}
+
+	if err := agent.PutBucketLifecycle(name, lifecycleRules(b.Spec.Lifecycle)); err != nil {
+		return err
+	}
+	logger.Infof("applied %d lifecycle rules to bucket %s", len(b.Spec.Lifecycle), name)
+
+	if err := agent.PutBucketPolicy(name, b.Spec.Policy); err != nil {
+		return err
+	}
+
+	// the quota is only set by the admin of the object store
+	endpoint, err := c.endpoint(b.Namespace, b.Spec.Store)
+	if err != nil {
+		return err
+	}
+	adminOps, err := object.NewAdminOpsClient(c.context, b.Namespace, b.Spec.Store, endpoint)
+	if err != nil {
+		return err
+	}
+	maxObjects, maxSize := quotaLimits(b.Spec.Quota)
+	return adminOps.SetBucketQuota(b.Spec.Owner, name, maxObjects, maxSize)
+}
+
+// The limits of the quota of the bucket for rgw, where a negative value has no limit
+func quotaLimits(quota cephv1.BucketQuotaSpec) (int64, int64) {
+	maxObjects := int64(-1)
+	if quota.MaxObjects > 0 {
+		maxObjects = quota.MaxObjects
+	}
+	maxSize := int64(-1)
+	if quota.MaxSize != "" {
+		// the size was validated with the bucket
+		size := resource.MustParse(quota.MaxSize)
+		maxSize = size.Value()
+	}
+	return maxObjects, maxSize
+}
+
+// Create an s3 agent with the keys of the owner of the bucket
+func (c *ObjectBucketController) ownerAgent(b *cephv1.CephObjectBucket) (*cephrgw.S3Agent, error) {
+	secretName := fmt.Sprintf("rook-ceph-object-user-%s-%s", b.Spec.Store, b.Spec.Owner)
+	secret, err := c.context.Clientset.CoreV1().Secrets(b.Namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the keys of owner %s. %+v", b.Spec.Owner, err)
+	}
+
+	endpoint, err := c.endpoint(b.Namespace, b.Spec.Store)
+	if err != nil {
+		return nil, err
+	}
+	return cephrgw.NewS3Agent(string(secret.Data["AccessKey"]), string(secret.Data["SecretKey"]), endpoint)
+}
+
+func bucketName(b *cephv1.CephObjectBucket) string {
+	if b.Spec.BucketName != "" {
+		return b.Spec.BucketName
+	}
+	return b.Name
+}
+
+// Convert the lifecycle rules of the bucket to the rules of the s3 lifecycle configuration
+func lifecycleRules(rules []cephv1.BucketLifecycleRule) []*s3.LifecycleRule {
+	result := []*s3.LifecycleRule{}
+	for _, r := range rules {
+		rule := &s3.LifecycleRule{
+			ID:     aws.String(r.ID),
+			Filter: &s3.LifecycleRuleFilter{Prefix: aws.String(r.Prefix)},
+			Status: aws.String(s3.ExpirationStatusEnabled),
+		}
+		if r.Disabled {
+			rule.Status = aws.String(s3.ExpirationStatusDisabled)
+		}
+		if r.ExpirationDays > 0 {
+			rule.Expiration = &s3.LifecycleExpiration{Days: aws.Int64(int64(r.ExpirationDays))}
+		}
+		if r.NoncurrentVersionExpirationDays > 0 {
+			rule.NoncurrentVersionExpiration = &s3.NoncurrentVersionExpiration{NoncurrentDays: aws.Int64(int64(r.NoncurrentVersionExpirationDays))}
+		}
+		if r.AbortIncompleteMultipartUploadDays > 0 {
+			rule.AbortIncompleteMultipartUpload = &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int64(int64(r.AbortIncompleteMultipartUploadDays))}
+		}
+		for _, t := range r.Transitions {
+			rule.Transitions = append(rule.Transitions, &s3.Transition{Days: aws.Int64(int64(t.Days)), StorageClass: aws.String(t.StorageClass)})
+		}
+		result = append(result, rule)
+	}
+	return result
+}
+
+// ValidateBucket validates the bucket arguments
+func ValidateBucket(b *cephv1.CephObjectBucket) error {
+	if b.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if b.Namespace == "" {
+		return fmt.Errorf("missing namespace")
+	}
+	if b.Spec.Store == "" {
+		return fmt.Errorf("missing store")
+	}
+	if b.Spec.Owner == "" {
+		return fmt.Errorf("missing owner")
+	}
+	name := bucketName(b)
+	if len(name) < 3 || len(name) > 63 {
+		return fmt.Errorf("bucket name %s must have between 3 and 63 characters", name)
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid bucket name %s. %v", name, errs)
+	}
+
+	ids := map[string]bool{}
+	for _, r := range b.Spec.Lifecycle {
+		if r.ID == "" {
+			return fmt.Errorf("missing id of a lifecycle rule")
+		}
+		if ids[r.ID] {
+			return fmt.Errorf("duplicate lifecycle rule %s", r.ID)
+		}
+		ids[r.ID] = true
+		if r.ExpirationDays == 0 && r.NoncurrentVersionExpirationDays == 0 && r.AbortIncompleteMultipartUploadDays == 0 && len(r.Transitions) == 0 {
+			return fmt.Errorf("lifecycle rule %s has no expiration or transition", r.ID)
+		}
+		for _, t := range r.Transitions {
+			if t.Days <= 0 || t.StorageClass == "" {
+				return fmt.Errorf("lifecycle rule %s has a transition without days or storage class", r.ID)
+			}
+		}
+	}
+
+	if b.Spec.Quota.MaxObjects < 0 {
+		return fmt.Errorf("invalid max objects %d", b.Spec.Quota.MaxObjects)
+	}
+	if b.Spec.Quota.MaxSize != "" {
+		size, err := resource.ParseQuantity(b.Spec.Quota.MaxSize)
+		if err != nil {
+			return fmt.Errorf("invalid max size %s. %+v", b.Spec.Quota.MaxSize, err)
+		}
+		if size.Sign() <= 0 {
+			return fmt.Errorf("invalid max size %s", b.Spec.Quota.MaxSize)
+		}
+	}
+	if b.Spec.Policy != "" && !json.Valid([]byte(b.Spec.Policy)) {
+		return fmt.Errorf("the policy is not a valid json document")
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectbucket
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// an s3 endpoint recording the requests it receives
+type s3Server struct {
+	requests []string
+	bodies   []string
+}
+
+func (s *s3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+	s.bodies = append(s.bodies, string(body))
+	if r.Method == "DELETE" {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func simpleBucket() *cephv1.CephObjectBucket {
+	return &cephv1.CephObjectBucket{
+		ObjectMeta: metav1.ObjectMeta{Name: "mybucket", Namespace: "mycluster"},
+		Spec: cephv1.ObjectBucketSpec{
+			Store: "mystore",
+			Owner: "myuser",
+			Lifecycle: []cephv1.BucketLifecycleRule{
+				{ID: "logs", Prefix: "logs/", ExpirationDays: 30, Transitions: []cephv1.BucketLifecycleTransition{{Days: 7, StorageClass: "COLD"}}},
+			},
+		},
+	}
+}
+
+func TestCreateBucket(t *testing.T) {
+	server := &s3Server{}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-object-user-mystore-myuser", Namespace: "mycluster"},
+		Data:       map[string][]byte{"AccessKey": []byte("myaccesskey"), "SecretKey": []byte("mysecretkey")},
+	}
+	adminSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-rgw-mystore-admin-ops", Namespace: "mycluster"},
+		Data:       map[string][]byte{"AccessKey": []byte("adminaccesskey"), "SecretKey": []byte("adminsecretkey")},
+	}
+	c := NewObjectBucketController(&clusterd.Context{Clientset: fake.NewSimpleClientset(secret, adminSecret)})
+	c.endpoint = func(namespace, store string) (string, error) {
+		return httpServer.URL, nil
+	}
+
+	b := simpleBucket()
+	err := c.createBucket(b)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"PUT /mybucket?", "PUT /mybucket?lifecycle=", "DELETE /mybucket?policy=",
+		"PUT /admin/bucket?bucket=mybucket&quota=&uid=myuser"}, server.requests)
+	// the bucket has no quota
+	assert.Equal(t, `{"enabled":false,"max_objects":-1,"max_size_kb":-1}`, server.bodies[3])
+	lifecycle := server.bodies[1]
+	assert.True(t, strings.Contains(lifecycle, "<ID>logs</ID>"), lifecycle)
+	assert.True(t, strings.Contains(lifecycle, "<Prefix>logs/</Prefix>"), lifecycle)
+	assert.True(t, strings.Contains(lifecycle, "<Status>Enabled</Status>"), lifecycle)
+	assert.True(t, strings.Contains(lifecycle, "<Expiration><Days>30</Days></Expiration>"), lifecycle)
+	assert.True(t, strings.Contains(lifecycle, "<Transition>"), lifecycle)
+	assert.True(t, strings.Contains(lifecycle, "<StorageClass>COLD</StorageClass>"), lifecycle)
+
+	// the lifecycle is removed with the last rule
+	server.requests = nil
+	b.Spec.Lifecycle = nil
+	err = c.createBucket(b)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"PUT /mybucket?", "DELETE /mybucket?lifecycle=", "DELETE /mybucket?policy=",
+		"PUT /admin/bucket?bucket=mybucket&quota=&uid=myuser"}, server.requests)
+
+	// the policy and the quota are applied
+	server.requests = nil
+	server.bodies = nil
+	b.Spec.Policy = `{"Version":"2012-10-17","Statement":[]}`
+	b.Spec.Quota = cephv1.BucketQuotaSpec{MaxObjects: 1000, MaxSize: "1Gi"}
+	err = c.createBucket(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "PUT /mybucket?policy=", server.requests[2])
+	assert.Equal(t, b.Spec.Policy, server.bodies[2])
+	assert.Equal(t, `{"enabled":true,"max_objects":1000,"max_size_kb":1048576}`, server.bodies[3])
+
+	// the owner has no keys
+	b.Spec.Owner = "otheruser"
+	err = c.createBucket(b)
+	assert.NotNil(t, err)
+}
+
+func TestValidateBucket(t *testing.T) {
+	// valid bucket
+	b := simpleBucket()
+	assert.Nil(t, ValidateBucket(b))
+
+	// missing owner
+	b.Spec.Owner = ""
+	assert.NotNil(t, ValidateBucket(b))
+	b.Spec.Owner = "myuser"
+
+	// invalid bucket name
+	b.Spec.BucketName = "My_Bucket"
+	assert.NotNil(t, ValidateBucket(b))
+	b.Spec.BucketName = "ab"
+	assert.NotNil(t, ValidateBucket(b))
+	b.Spec.BucketName = "my.bucket"
+	assert.Nil(t, ValidateBucket(b))
+
+	// duplicate rule
+	b.Spec.Lifecycle = append(b.Spec.Lifecycle, b.Spec.Lifecycle[0])
+	assert.NotNil(t, ValidateBucket(b))
+
+	// rule without action
+	b.Spec.Lifecycle = []cephv1.BucketLifecycleRule{{ID: "empty"}}
+	assert.NotNil(t, ValidateBucket(b))
+
+	// transition without storage class
+	b.Spec.Lifecycle = []cephv1.BucketLifecycleRule{{ID: "cold", Transitions: []cephv1.BucketLifecycleTransition{{Days: 7}}}}
+	assert.NotNil(t, ValidateBucket(b))
+	b.Spec.Lifecycle = nil
+
+	// invalid quota
+	b.Spec.Quota.MaxSize = "10 gigabytes"
+	assert.NotNil(t, ValidateBucket(b))
+	b.Spec.Quota.MaxSize = "10Gi"
+	assert.Nil(t, ValidateBucket(b))
+	b.Spec.Quota.MaxObjects = -1
+	assert.NotNil(t, ValidateBucket(b))
+	b.Spec.Quota.MaxObjects = 0
+
+	// invalid policy
+	b.Spec.Policy = `{"Statement": [`
+	assert.NotNil(t, ValidateBucket(b))
+}
+
+func TestQuotaLimits(t *testing.T) {
+	maxObjects, maxSize := quotaLimits(cephv1.BucketQuotaSpec{})
+	assert.Equal(t, int64(-1), maxObjects)
+	assert.Equal(t, int64(-1), maxSize)
+
+	maxObjects, maxSize = quotaLimits(cephv1.BucketQuotaSpec{MaxObjects: 10, MaxSize: "1Ki"})
+	assert.Equal(t, int64(10), maxObjects)
+	assert.Equal(t, int64(1024), maxSize)
+}
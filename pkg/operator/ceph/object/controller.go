@@ -26,6 +26,8 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	cephbeta "github.com/rook/rook/pkg/apis/ceph.rook.io/v1beta1"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	"github.com/rook/rook/pkg/operator/ceph/object/cosi"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -77,11 +79,12 @@ func NewObjectStoreController(context *clusterd.Context, rookImage string, cephV
 // StartWatch watches for instances of ObjectStore custom resources and acts on them
 func (c *ObjectStoreController) StartWatch(namespace string, stopCh chan struct{}) error {
 
-	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+	// the resources are not reconciled while their cluster is in maintenance mode
+	resourceHandlerFuncs := maintenance.PausableHandlers(cache.ResourceEventHandlerFuncs{
 		AddFunc:    c.onAdd,
 		UpdateFunc: c.onUpdate,
 		DeleteFunc: c.onDelete,
-	}
+	})
 
 	logger.Infof("start watching object store resources in namespace %s", namespace)
 	watcher := opkit.NewWatcher(ObjectStoreResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
@@ -111,6 +114,11 @@ func (c *ObjectStoreController) onAdd(obj interface{}) {
 	if err = cfg.createStore(); err != nil {
 		logger.Errorf("failed to create object store %s. %+v", objectstore.Name, err)
 	}
+
+	// the COSI driver is deployed with the first object store
+	if err = cosi.ReconcileDrivers(c.context, objectstore.Namespace, c.ownerRef); err != nil {
+		logger.Errorf("failed to reconcile the COSI drivers. %+v", err)
+	}
 }
 
 func (c *ObjectStoreController) onUpdate(oldObj, newObj interface{}) {
@@ -161,6 +169,11 @@ func (c *ObjectStoreController) onDelete(obj interface{}) {
 	if err = cfg.deleteStore(); err != nil {
 		logger.Errorf("failed to delete object store %s. %+v", objectstore.Name, err)
 	}
+
+	// the COSI driver is removed with the last object store
+	if err = cosi.ReconcileDrivers(c.context, objectstore.Namespace, c.ownerRef); err != nil {
+		logger.Errorf("failed to reconcile the COSI drivers. %+v", err)
+	}
 }
 
 func (c *ObjectStoreController) storeOwners(store *cephv1.CephObjectStore) []metav1.OwnerReference {
@@ -196,6 +209,18 @@ func storeChanged(oldStore, newStore cephv1.ObjectStoreSpec) bool {
 		logger.Infof("AllNodes changed from %t to %t", oldStore.Gateway.AllNodes, newStore.Gateway.AllNodes)
 		return true
 	}
+	if !reflect.DeepEqual(oldStore.Gateway.PodExtension, newStore.Gateway.PodExtension) {
+		logger.Infof("RGW pod extension changed from %+v to %+v", oldStore.Gateway.PodExtension, newStore.Gateway.PodExtension)
+		return true
+	}
+	if !reflect.DeepEqual(oldStore.Hosting, newStore.Hosting) {
+		logger.Infof("Hosting changed from %+v to %+v", oldStore.Hosting, newStore.Hosting)
+		return true
+	}
+	if !reflect.DeepEqual(oldStore.PlacementTargets, newStore.PlacementTargets) {
+		logger.Infof("placement targets changed from %+v to %+v", oldStore.PlacementTargets, newStore.PlacementTargets)
+		return true
+	}
 	if oldStore.Gateway.SSLCertificateRef != newStore.Gateway.SSLCertificateRef {
 		logger.Infof("SSLCertificateRef changed from %s to %s", oldStore.Gateway.SSLCertificateRef, newStore.Gateway.SSLCertificateRef)
 		return true
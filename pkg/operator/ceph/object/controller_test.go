@@ -54,6 +54,16 @@ func TestObjectStoreChanged(t *testing.T) {
 
 	new = cephv1.ObjectStoreSpec{Gateway: cephv1.GatewaySpec{Port: 80, SecurePort: 443, Instances: 1, AllNodes: false, SSLCertificateRef: "mysecret"}}
 	assert.True(t, storeChanged(old, new))
+
+	new = cephv1.ObjectStoreSpec{Gateway: old.Gateway}
+	new.Gateway.PodExtension.Sidecars = []v1.Container{{Name: "scanner"}}
+	assert.True(t, storeChanged(old, new))
+
+	new = cephv1.ObjectStoreSpec{Gateway: old.Gateway, Hosting: cephv1.HostingSpec{DNSNames: []string{"s3.example.com"}}}
+	assert.True(t, storeChanged(old, new))
+
+	new = cephv1.ObjectStoreSpec{Gateway: old.Gateway, PlacementTargets: []cephv1.PlacementTargetSpec{{Name: "default-placement"}}}
+	assert.True(t, storeChanged(old, new))
 }
 
 func TestGetObjectStoreObject(t *testing.T) {
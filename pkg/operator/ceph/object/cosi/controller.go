@@ -0,0 +1,319 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cosi to deploy the driver of the Container Object Storage Interface for the object stores.
+package cosi
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/coreos/pkg/capnslog"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// DeploymentStrategyAuto deploys the driver when the cluster has an object store
+	DeploymentStrategyAuto = "Auto"
+	// DeploymentStrategyForce always deploys the driver
+	DeploymentStrategyForce = "Force"
+	// DeploymentStrategyNever removes the driver
+	DeploymentStrategyNever = "Never"
+
+	// The images of the ceph COSI driver and of the objectstorage provisioner sidecar
+	DefaultDriverImage            = "quay.io/ceph/cosi:v0.1.1"
+	DefaultObjectProvisionerImage = "gcr.io/k8s-staging-sig-storage/objectstorage-sidecar/objectstorage-sidecar:v20230130-v0.1.0-24-gc0cf995"
+
+	appName            = "ceph-cosi-driver"
+	serviceAccountName = "objectstorage-provisioner"
+	driverPrefix       = "cosi"
+	socketVolumeName   = "socket"
+	socketDir          = "/var/lib/cosi"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-cosi")
+
+// COSIDriverResource represents the COSI driver custom resource
+var COSIDriverResource = opkit.CustomResource{
+	Name:    "cephcosidriver",
+	Plural:  "cephcosidrivers",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephCOSIDriver{}).Name(),
+}
+
+// COSIDriverController represents a controller object for COSI driver custom resources
+type COSIDriverController struct {
+	context  *clusterd.Context
+	ownerRef metav1.OwnerReference
+}
+
+// NewCOSIDriverController create controller for watching COSI driver custom resources created
+func NewCOSIDriverController(context *clusterd.Context, ownerRef metav1.OwnerReference) *COSIDriverController {
+	return &COSIDriverController{
+		context:  context,
+		ownerRef: ownerRef,
+	}
+}
+
+// StartWatch watches for instances of COSIDriver custom resources and acts on them
+func (c *COSIDriverController) StartWatch(namespace string, stopCh chan struct{}) error {
+
+	// the resources are not reconciled while their cluster is in maintenance mode
+	resourceHandlerFuncs := maintenance.PausableHandlers(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	})
+
+	logger.Infof("start watching COSI driver resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(COSIDriverResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephCOSIDriver{}, stopCh)
+
+	return nil
+}
+
+func (c *COSIDriverController) onAdd(obj interface{}) {
+	driver, ok := obj.(*cephv1.CephCOSIDriver)
+	if !ok {
+		logger.Errorf("unexpected COSI driver object %+v", obj)
+		return
+	}
+
+	if err := reconcileDriver(c.context, driver, c.ownerRef); err != nil {
+		logger.Errorf("failed to deploy COSI driver %s. %+v", driver.Name, err)
+	}
+}
+
+func (c *COSIDriverController) onUpdate(oldObj, newObj interface{}) {
+	oldDriver, ok := oldObj.(*cephv1.CephCOSIDriver)
+	if !ok {
+		logger.Errorf("unexpected old COSI driver object %+v", oldObj)
+		return
+	}
+	driver, ok := newObj.(*cephv1.CephCOSIDriver)
+	if !ok {
+		logger.Errorf("unexpected new COSI driver object %+v", newObj)
+		return
+	}
+
+	if reflect.DeepEqual(oldDriver.Spec, driver.Spec) {
+		logger.Debugf("COSI driver %s not changed", driver.Name)
+		return
+	}
+
+	logger.Infof("updating COSI driver %s", driver.Name)
+	if err := reconcileDriver(c.context, driver, c.ownerRef); err != nil {
+		logger.Errorf("failed to update COSI driver %s. %+v", driver.Name, err)
+	}
+}
+
+func (c *COSIDriverController) onDelete(obj interface{}) {
+	driver, ok := obj.(*cephv1.CephCOSIDriver)
+	if !ok {
+		logger.Errorf("unexpected COSI driver object %+v", obj)
+		return
+	}
+
+	if err := deleteDriver(c.context, driver, c.ownerRef); err != nil {
+		logger.Errorf("failed to remove COSI driver %s. %+v", driver.Name, err)
+	}
+}
+
+// deleteDriver removes the deployment of a deleted driver, unless another driver of the namespace deploys it. The
+// next driver of the namespace is then reconciled in its place.
+func deleteDriver(context *clusterd.Context, driver *cephv1.CephCOSIDriver, ownerRef metav1.OwnerReference) error {
+	active, err := activeDriver(context, driver.Namespace)
+	if err != nil {
+		return err
+	}
+	if active != nil && precedes(active, driver) {
+		// the deleted driver was rejected, the deployment belongs to the active driver
+		return nil
+	}
+	if err := k8sutil.DeleteDeployment(context.Clientset, driver.Namespace, appName); err != nil {
+		return err
+	}
+	if active != nil {
+		return reconcileDriver(context, active, ownerRef)
+	}
+	return nil
+}
+
+// activeDriver returns the oldest driver of a namespace, the only one deployed since the name of the deployment and
+// the name of the COSI driver are the same for all the drivers of the namespace
+func activeDriver(context *clusterd.Context, namespace string) (*cephv1.CephCOSIDriver, error) {
+	drivers, err := context.RookClientset.CephV1().CephCOSIDrivers(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list COSI drivers. %+v", err)
+	}
+	var active *cephv1.CephCOSIDriver
+	for i := range drivers.Items {
+		if active == nil || precedes(&drivers.Items[i], active) {
+			active = &drivers.Items[i]
+		}
+	}
+	return active, nil
+}
+
+// precedes returns whether a driver was created before another, by name if they were created at the same time
+func precedes(a, b *cephv1.CephCOSIDriver) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// ReconcileDrivers deploys or removes the COSI drivers with the Auto strategy of a namespace, after an object store
+// was created or deleted
+func ReconcileDrivers(context *clusterd.Context, namespace string, ownerRef metav1.OwnerReference) error {
+	driver, err := activeDriver(context, namespace)
+	if err != nil {
+		return err
+	}
+	if driver == nil || deploymentStrategy(driver) != DeploymentStrategyAuto {
+		return nil
+	}
+	if err := reconcileDriver(context, driver, ownerRef); err != nil {
+		return fmt.Errorf("failed to reconcile COSI driver %s. %+v", driver.Name, err)
+	}
+	return nil
+}
+
+// reconcileDriver creates, updates or removes the deployment of the driver depending on its strategy. Only one driver
+// is allowed per namespace, the drivers created after the first are rejected.
+func reconcileDriver(context *clusterd.Context, driver *cephv1.CephCOSIDriver, ownerRef metav1.OwnerReference) error {
+	active, err := activeDriver(context, driver.Namespace)
+	if err != nil {
+		return err
+	}
+	if active != nil && active.Name != driver.Name {
+		return fmt.Errorf("COSI driver %s already deploys the driver of namespace %s, only one COSI driver is allowed per namespace", active.Name, driver.Namespace)
+	}
+
+	deploy := false
+	switch deploymentStrategy(driver) {
+	case DeploymentStrategyForce:
+		deploy = true
+	case DeploymentStrategyNever:
+	case DeploymentStrategyAuto:
+		stores, err := context.RookClientset.CephV1().CephObjectStores(driver.Namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list object stores. %+v", err)
+		}
+		deploy = len(stores.Items) > 0
+	default:
+		return fmt.Errorf("invalid deployment strategy %s. must be %s, %s or %s", driver.Spec.DeploymentStrategy,
+			DeploymentStrategyAuto, DeploymentStrategyForce, DeploymentStrategyNever)
+	}
+
+	if !deploy {
+		logger.Infof("removing COSI driver %s if it exists", driver.Name)
+		return k8sutil.DeleteDeployment(context.Clientset, driver.Namespace, appName)
+	}
+
+	d := makeDeployment(driver)
+	k8sutil.SetOwnerRef(context.Clientset, driver.Namespace, &d.ObjectMeta, &ownerRef)
+	if _, err := context.Clientset.ExtensionsV1beta1().Deployments(driver.Namespace).Create(d); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create COSI driver deployment. %+v", err)
+		}
+		logger.Infof("COSI driver deployment already exists. updating if needed")
+		if _, err := context.Clientset.ExtensionsV1beta1().Deployments(driver.Namespace).Update(d); err != nil {
+			return fmt.Errorf("failed to update COSI driver deployment. %+v", err)
+		}
+		return nil
+	}
+	logger.Infof("COSI driver %s deployed", driver.Name)
+	return nil
+}
+
+func deploymentStrategy(driver *cephv1.CephCOSIDriver) string {
+	if driver.Spec.DeploymentStrategy == "" {
+		return DeploymentStrategyAuto
+	}
+	return driver.Spec.DeploymentStrategy
+}
+
+// makeDeployment returns the deployment of the driver, serving the COSI requests of the objectstorage provisioner
+// sidecar on a unix socket shared by the two containers
+func makeDeployment(driver *cephv1.CephCOSIDriver) *extensions.Deployment {
+	image := driver.Spec.Image
+	if image == "" {
+		image = DefaultDriverImage
+	}
+	provisionerImage := driver.Spec.ObjectProvisionerImage
+	if provisionerImage == "" {
+		provisionerImage = DefaultObjectProvisionerImage
+	}
+
+	labels := map[string]string{k8sutil.AppAttr: appName}
+	socketMounts := []v1.VolumeMount{{Name: socketVolumeName, MountPath: socketDir}}
+	namespaceEnv := v1.EnvVar{Name: "POD_NAMESPACE", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.namespace"}}}
+	podSpec := v1.PodSpec{
+		ServiceAccountName: serviceAccountName,
+		Containers: []v1.Container{
+			{
+				Name:         appName,
+				Image:        image,
+				Args:         []string{fmt.Sprintf("--driver-prefix=%s", driverPrefix)},
+				Env:          []v1.EnvVar{namespaceEnv},
+				VolumeMounts: socketMounts,
+				Resources:    driver.Spec.Resources,
+			},
+			{
+				Name:         "objectstorage-provisioner-sidecar",
+				Image:        provisionerImage,
+				Args:         []string{"--v=5"},
+				Env:          []v1.EnvVar{namespaceEnv},
+				VolumeMounts: socketMounts,
+				Resources:    driver.Spec.Resources,
+			},
+		},
+		Volumes: []v1.Volume{
+			{Name: socketVolumeName, VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+		},
+	}
+	driver.Spec.Placement.ApplyToPodSpec(&podSpec)
+
+	replicas := int32(1)
+	return &extensions.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: driver.Namespace,
+			Labels:    labels,
+		},
+		Spec: extensions.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Name: appName, Labels: labels},
+				Spec:       podSpec,
+			},
+			Replicas: &replicas,
+		},
+	}
+}
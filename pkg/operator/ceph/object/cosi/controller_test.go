@@ -0,0 +1,144 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosi
+
+import (
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMakeDeployment(t *testing.T) {
+	driver := &cephv1.CephCOSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "ceph-cosi-driver", Namespace: "ns"}}
+	d := makeDeployment(driver)
+	assert.Equal(t, "ceph-cosi-driver", d.Name)
+	assert.Equal(t, "ns", d.Namespace)
+	spec := d.Spec.Template.Spec
+	assert.Equal(t, "objectstorage-provisioner", spec.ServiceAccountName)
+	assert.Equal(t, 2, len(spec.Containers))
+	assert.Equal(t, DefaultDriverImage, spec.Containers[0].Image)
+	assert.Equal(t, []string{"--driver-prefix=cosi"}, spec.Containers[0].Args)
+	assert.Equal(t, DefaultObjectProvisionerImage, spec.Containers[1].Image)
+	// the containers share the socket of the driver
+	assert.Equal(t, "/var/lib/cosi", spec.Containers[0].VolumeMounts[0].MountPath)
+	assert.Equal(t, spec.Containers[0].VolumeMounts, spec.Containers[1].VolumeMounts)
+	assert.NotNil(t, spec.Volumes[0].EmptyDir)
+
+	driver.Spec.Image = "my-cosi:v1"
+	driver.Spec.ObjectProvisionerImage = "my-sidecar:v1"
+	driver.Spec.Resources = v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")}}
+	spec = makeDeployment(driver).Spec.Template.Spec
+	assert.Equal(t, "my-cosi:v1", spec.Containers[0].Image)
+	assert.Equal(t, "my-sidecar:v1", spec.Containers[1].Image)
+	assert.Equal(t, "128Mi", spec.Containers[0].Resources.Limits.Memory().String())
+}
+
+func TestReconcileDriver(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	rookClientset := rookfake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookClientset}
+	driver := &cephv1.CephCOSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "ceph-cosi-driver", Namespace: "ns"}}
+	_, err := rookClientset.CephV1().CephCOSIDrivers("ns").Create(driver)
+	assert.Nil(t, err)
+	deployed := func() bool {
+		_, err := clientset.ExtensionsV1beta1().Deployments("ns").Get(appName, metav1.GetOptions{})
+		return err == nil
+	}
+
+	// the driver is not deployed without object store
+	assert.Nil(t, reconcileDriver(context, driver, metav1.OwnerReference{}))
+	assert.False(t, deployed())
+
+	// the driver is deployed with the first object store
+	_, err = rookClientset.CephV1().CephObjectStores("ns").Create(&cephv1.CephObjectStore{ObjectMeta: metav1.ObjectMeta{Name: "store", Namespace: "ns"}})
+	assert.Nil(t, err)
+	assert.Nil(t, ReconcileDrivers(context, "ns", metav1.OwnerReference{}))
+	assert.True(t, deployed())
+
+	// updating the driver
+	assert.Nil(t, reconcileDriver(context, driver, metav1.OwnerReference{}))
+	assert.True(t, deployed())
+
+	// the driver is removed with the last object store
+	err = rookClientset.CephV1().CephObjectStores("ns").Delete("store", &metav1.DeleteOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, ReconcileDrivers(context, "ns", metav1.OwnerReference{}))
+	assert.False(t, deployed())
+
+	// the driver is always deployed with the Force strategy
+	driver.Spec.DeploymentStrategy = DeploymentStrategyForce
+	assert.Nil(t, reconcileDriver(context, driver, metav1.OwnerReference{}))
+	assert.True(t, deployed())
+
+	// and removed with the Never strategy
+	driver.Spec.DeploymentStrategy = DeploymentStrategyNever
+	assert.Nil(t, reconcileDriver(context, driver, metav1.OwnerReference{}))
+	assert.False(t, deployed())
+
+	driver.Spec.DeploymentStrategy = "Sometimes"
+	assert.NotNil(t, reconcileDriver(context, driver, metav1.OwnerReference{}))
+}
+
+func TestSecondDriver(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	rookClientset := rookfake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookClientset}
+	now := time.Now()
+	first := &cephv1.CephCOSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "second-name", Namespace: "ns", CreationTimestamp: metav1.NewTime(now)},
+		Spec: cephv1.COSIDriverSpec{DeploymentStrategy: DeploymentStrategyForce}}
+	second := &cephv1.CephCOSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "first-name", Namespace: "ns", CreationTimestamp: metav1.NewTime(now.Add(time.Minute))},
+		Spec: cephv1.COSIDriverSpec{DeploymentStrategy: DeploymentStrategyNever}}
+	for _, driver := range []*cephv1.CephCOSIDriver{first, second} {
+		_, err := rookClientset.CephV1().CephCOSIDrivers("ns").Create(driver)
+		assert.Nil(t, err)
+	}
+	deployed := func() bool {
+		_, err := clientset.ExtensionsV1beta1().Deployments("ns").Get(appName, metav1.GetOptions{})
+		return err == nil
+	}
+
+	// the driver created after the first is rejected and does not remove its deployment
+	assert.Nil(t, reconcileDriver(context, first, metav1.OwnerReference{}))
+	assert.True(t, deployed())
+	assert.NotNil(t, reconcileDriver(context, second, metav1.OwnerReference{}))
+	assert.True(t, deployed())
+
+	// deleting the rejected driver keeps the deployment
+	assert.Nil(t, rookClientset.CephV1().CephCOSIDrivers("ns").Delete(second.Name, &metav1.DeleteOptions{}))
+	assert.Nil(t, deleteDriver(context, second, metav1.OwnerReference{}))
+	assert.True(t, deployed())
+
+	// deleting the active driver removes the deployment and the next driver takes over
+	_, err := rookClientset.CephV1().CephCOSIDrivers("ns").Create(second)
+	assert.Nil(t, err)
+	assert.Nil(t, rookClientset.CephV1().CephCOSIDrivers("ns").Delete(first.Name, &metav1.DeleteOptions{}))
+	assert.Nil(t, deleteDriver(context, first, metav1.OwnerReference{}))
+	assert.False(t, deployed())
+	second.Spec.DeploymentStrategy = DeploymentStrategyForce
+	_, err = rookClientset.CephV1().CephCOSIDrivers("ns").Update(second)
+	assert.Nil(t, err)
+	assert.Nil(t, reconcileDriver(context, second, metav1.OwnerReference{}))
+	assert.True(t, deployed())
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"fmt"
+
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/util/version"
+)
+
+// the wildcard hosts of the ingress rules are supported since kubernetes 1.18
+const wildcardHostVersion = "v1.18.0"
+
+// Create or update the ingress of the dns names of the object store, or remove it when the ingress is disabled
+func (c *config) startIngress() error {
+	if !c.store.Spec.Hosting.Ingress.Enabled {
+		return c.deleteIngress()
+	}
+
+	wildcards := c.supportsWildcardHosts()
+	if !wildcards && len(c.store.Spec.Hosting.DNSNames) > 0 {
+		logger.Warningf("the wildcard hosts of the ingress require kubernetes %s. the subdomains of the dns names of object store %s are not routed by the ingress",
+			wildcardHostVersion, c.store.Name)
+	}
+	if err := opspec.CreateOrUpdateIngress(c.context.Clientset, c.makeIngress(wildcards)); err != nil {
+		return fmt.Errorf("failed to create or update rgw ingress. %+v", err)
+	}
+	return nil
+}
+
+func (c *config) deleteIngress() error {
+	if err := opspec.DeleteIngress(c.context.Clientset, c.store.Namespace, c.instanceName()); err != nil {
+		return fmt.Errorf("failed to delete rgw ingress. %+v", err)
+	}
+	return nil
+}
+
+func (c *config) supportsWildcardHosts() bool {
+	kubeVersion, err := k8sutil.GetK8SVersion(c.context.Clientset)
+	if err != nil {
+		logger.Warningf("failed to get the kubernetes version. %+v", err)
+		return false
+	}
+	return kubeVersion.AtLeast(version.MustParseSemantic(wildcardHostVersion))
+}
+
+// makeIngress returns the ingress of the dns names, and of their subdomains if the wildcard hosts are supported
+func (c *config) makeIngress(wildcards bool) *extensions.Ingress {
+	hosting := c.store.Spec.Hosting
+	hosts := []string{}
+	if hosting.Ingress.Host != "" {
+		hosts = append(hosts, hosting.Ingress.Host)
+	}
+	// the buckets are the subdomains of the dns names with the virtual hosted style requests
+	for _, name := range hosting.DNSNames {
+		hosts = append(hosts, name)
+		if wildcards {
+			hosts = append(hosts, "*."+name)
+		}
+	}
+
+	port := c.store.Spec.Gateway.Port
+	if port == 0 {
+		port = c.store.Spec.Gateway.SecurePort
+	}
+
+	ingress := opspec.MakeIngress(c.instanceName(), c.store.Namespace, c.getLabels(), hosts, c.instanceName(), port, hosting.Ingress)
+	k8sutil.SetOwnerRefs(c.context.Clientset, c.store.Namespace, &ingress.ObjectMeta, c.ownerRefs)
+	return ingress
+}
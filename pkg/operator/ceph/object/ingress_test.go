@@ -0,0 +1,101 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStartIngress(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.18.2"}
+	store := simpleStore()
+	store.Spec.Hosting.DNSNames = []string{"s3.example.com"}
+	store.Spec.Hosting.Ingress.Enabled = true
+	store.Spec.Hosting.Ingress.Class = "nginx"
+	store.Spec.Hosting.Ingress.Annotations = map[string]string{"nginx.ingress.kubernetes.io/proxy-body-size": "0"}
+	c := &config{context: &clusterd.Context{Clientset: clientset}, store: store}
+
+	err := c.startIngress()
+	assert.Nil(t, err)
+	ingress, err := clientset.ExtensionsV1beta1().Ingresses(store.Namespace).Get(c.instanceName(), metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx", ingress.Annotations["kubernetes.io/ingress.class"])
+	assert.Equal(t, "0", ingress.Annotations["nginx.ingress.kubernetes.io/proxy-body-size"])
+	assert.Equal(t, 2, len(ingress.Spec.Rules))
+	assert.Equal(t, "s3.example.com", ingress.Spec.Rules[0].Host)
+	assert.Equal(t, "*.s3.example.com", ingress.Spec.Rules[1].Host)
+	backend := ingress.Spec.Rules[1].HTTP.Paths[0].Backend
+	assert.Equal(t, c.instanceName(), backend.ServiceName)
+	assert.Equal(t, 123, backend.ServicePort.IntValue())
+	assert.Equal(t, 0, len(ingress.Spec.TLS))
+
+	// the ingress is updated with the tls secret
+	c.store.Spec.Hosting.Ingress.TLSSecretName = "mycert"
+	err = c.startIngress()
+	assert.Nil(t, err)
+	ingress, err = clientset.ExtensionsV1beta1().Ingresses(store.Namespace).Get(c.instanceName(), metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "mycert", ingress.Spec.TLS[0].SecretName)
+	assert.Equal(t, []string{"s3.example.com", "*.s3.example.com"}, ingress.Spec.TLS[0].Hosts)
+
+	// the ingress is removed when it is disabled
+	c.store.Spec.Hosting.Ingress.Enabled = false
+	err = c.startIngress()
+	assert.Nil(t, err)
+	_, err = clientset.ExtensionsV1beta1().Ingresses(store.Namespace).Get(c.instanceName(), metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// the host of the ingress is routed with the dns names
+	c.store.Spec.Hosting.Ingress.Host = "rgw.example.com"
+	ingress = c.makeIngress(true)
+	assert.Equal(t, 3, len(ingress.Spec.Rules))
+	assert.Equal(t, "rgw.example.com", ingress.Spec.Rules[0].Host)
+
+	// the secure port is the backend without the http port
+	c.store.Spec.Gateway.Port = 0
+	c.store.Spec.Gateway.SecurePort = 443
+	ingress = c.makeIngress(true)
+	assert.Equal(t, 443, ingress.Spec.Rules[0].HTTP.Paths[0].Backend.ServicePort.IntValue())
+}
+
+func TestStartIngressWithoutWildcards(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.17.4+k3s1"}
+	store := simpleStore()
+	store.Spec.Hosting.DNSNames = []string{"s3.example.com"}
+	store.Spec.Hosting.Ingress.Enabled = true
+	store.Spec.Hosting.Ingress.TLSSecretName = "mycert"
+	c := &config{context: &clusterd.Context{Clientset: clientset}, store: store}
+
+	// only the dns names are routed by the ingress
+	err := c.startIngress()
+	assert.Nil(t, err)
+	ingress, err := clientset.ExtensionsV1beta1().Ingresses(store.Namespace).Get(c.instanceName(), metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ingress.Spec.Rules))
+	assert.Equal(t, "s3.example.com", ingress.Spec.Rules[0].Host)
+	assert.Equal(t, []string{"s3.example.com"}, ingress.Spec.TLS[0].Hosts)
+}
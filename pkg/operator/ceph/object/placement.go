@@ -0,0 +1,105 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	rgwdaemon "github.com/rook/rook/pkg/daemon/ceph/rgw"
+	"github.com/rook/rook/pkg/operator/ceph/pool"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var storageClassRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// validatePlacementTargets checks the names and the pools of the placement targets and their storage classes
+func validatePlacementTargets(context *clusterd.Context, s cephv1.CephObjectStore) error {
+	targets := map[string]bool{}
+	for _, target := range s.Spec.PlacementTargets {
+		if errs := validation.IsDNS1123Label(target.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid placement target name %s. %v", target.Name, errs)
+		}
+		// the pool of the STANDARD class of a target named buckets would be the data pool of the object store
+		if target.Name == "buckets" {
+			return fmt.Errorf("the placement target name %s is reserved", target.Name)
+		}
+		if targets[target.Name] {
+			return fmt.Errorf("duplicate placement target %s", target.Name)
+		}
+		targets[target.Name] = true
+
+		if target.Name == rgwdaemon.DefaultPlacement {
+			if target.DataPool != nil {
+				return fmt.Errorf("the data pool of placement target %s is the data pool of the object store", target.Name)
+			}
+		} else {
+			if target.DataPool == nil {
+				return fmt.Errorf("missing data pool of placement target %s", target.Name)
+			}
+			if err := pool.ValidatePoolSpec(context, s.Namespace, target.DataPool); err != nil {
+				return fmt.Errorf("invalid data pool spec of placement target %s. %+v", target.Name, err)
+			}
+		}
+
+		// the names of the pools are the lower case names of the classes
+		classes := map[string]bool{}
+		for _, class := range target.StorageClasses {
+			if !storageClassRegex.MatchString(class.Name) {
+				return fmt.Errorf("invalid storage class name %s of placement target %s", class.Name, target.Name)
+			}
+			if strings.ToUpper(class.Name) == rgwdaemon.StandardStorageClass {
+				return fmt.Errorf("the storage class %s of placement target %s is set by its data pool", class.Name, target.Name)
+			}
+			if classes[strings.ToLower(class.Name)] {
+				return fmt.Errorf("duplicate storage class %s of placement target %s", class.Name, target.Name)
+			}
+			classes[strings.ToLower(class.Name)] = true
+			if err := pool.ValidatePoolSpec(context, s.Namespace, &class.DataPool); err != nil {
+				return fmt.Errorf("invalid data pool spec of storage class %s of placement target %s. %+v", class.Name, target.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validatePlacementVersion checks that the ceph version of the cluster supports the storage classes
+func validatePlacementVersion(spec cephv1.ObjectStoreSpec, cephVersion cephv1.CephVersionSpec) error {
+	if len(spec.PlacementTargets) > 0 && !cephv1.VersionAtLeast(cephVersion.Name, cephv1.Nautilus) {
+		return fmt.Errorf("the placement targets of the object stores require nautilus")
+	}
+	return nil
+}
+
+// placementTargets converts the placement targets of the object store spec to the settings of the rgw daemon
+func placementTargets(spec cephv1.ObjectStoreSpec) []rgwdaemon.PlacementTarget {
+	targets := []rgwdaemon.PlacementTarget{}
+	for _, t := range spec.PlacementTargets {
+		target := rgwdaemon.PlacementTarget{Name: t.Name}
+		if t.DataPool != nil {
+			target.DataPool = t.DataPool.ToModel("")
+		}
+		for _, class := range t.StorageClasses {
+			target.StorageClasses = append(target.StorageClasses, rgwdaemon.StorageClass{Name: class.Name, DataPool: *class.DataPool.ToModel("")})
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePlacementTargets(t *testing.T) {
+	context := &clusterd.Context{Executor: &exectest.MockExecutor{}}
+	replicated := cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}}
+	erasureCoded := cephv1.PoolSpec{ErasureCoded: cephv1.ErasureCodedSpec{CodingChunks: 1, DataChunks: 2}}
+
+	// valid targets
+	s := simpleStore()
+	s.Spec.PlacementTargets = []cephv1.PlacementTargetSpec{
+		{Name: "default-placement", StorageClasses: []cephv1.StorageClassSpec{{Name: "COLD", DataPool: erasureCoded}}},
+		{Name: "fast", DataPool: &replicated, StorageClasses: []cephv1.StorageClassSpec{{Name: "COLD", DataPool: erasureCoded}}},
+	}
+	assert.Nil(t, validateStore(context, s))
+
+	// the default placement is stored in the data pool of the store
+	s.Spec.PlacementTargets[0].DataPool = &replicated
+	assert.NotNil(t, validateStore(context, s))
+	s.Spec.PlacementTargets[0].DataPool = nil
+
+	// the other targets require a data pool
+	s.Spec.PlacementTargets[1].DataPool = nil
+	assert.NotNil(t, validateStore(context, s))
+	s.Spec.PlacementTargets[1].DataPool = &cephv1.PoolSpec{}
+	assert.NotNil(t, validateStore(context, s))
+	s.Spec.PlacementTargets[1].DataPool = &replicated
+
+	// invalid and reserved target names
+	for _, name := range []string{"Fast", "fast.ssd", "buckets", "default-placement"} {
+		s.Spec.PlacementTargets[1].Name = name
+		assert.NotNil(t, validateStore(context, s), name)
+	}
+	s.Spec.PlacementTargets[1].Name = "fast"
+
+	// invalid and duplicate storage classes
+	for _, name := range []string{"", "COLD.HDD", "standard", "cold"} {
+		s.Spec.PlacementTargets[1].StorageClasses = []cephv1.StorageClassSpec{{Name: "COLD", DataPool: erasureCoded}, {Name: name, DataPool: erasureCoded}}
+		assert.NotNil(t, validateStore(context, s), name)
+	}
+	s.Spec.PlacementTargets[1].StorageClasses = []cephv1.StorageClassSpec{{Name: "COLD", DataPool: cephv1.PoolSpec{}}}
+	assert.NotNil(t, validateStore(context, s))
+	s.Spec.PlacementTargets[1].StorageClasses = []cephv1.StorageClassSpec{{Name: "COLD", DataPool: erasureCoded}, {Name: "STANDARD_IA", DataPool: erasureCoded}}
+	assert.Nil(t, validateStore(context, s))
+
+	// the storage classes require nautilus
+	assert.NotNil(t, validatePlacementVersion(s.Spec, cephv1.CephVersionSpec{Name: cephv1.Mimic}))
+	assert.Nil(t, validatePlacementVersion(s.Spec, cephv1.CephVersionSpec{Name: cephv1.Nautilus}))
+	assert.Nil(t, validatePlacementVersion(simpleStore().Spec, cephv1.CephVersionSpec{Name: cephv1.Mimic}))
+}
+
+func TestPlacementTargets(t *testing.T) {
+	s := simpleStore()
+	assert.Equal(t, 0, len(placementTargets(s.Spec)))
+
+	s.Spec.PlacementTargets = []cephv1.PlacementTargetSpec{
+		{Name: "default-placement", StorageClasses: []cephv1.StorageClassSpec{{Name: "COLD", DataPool: cephv1.PoolSpec{ErasureCoded: cephv1.ErasureCodedSpec{CodingChunks: 1, DataChunks: 2}}}}},
+		{Name: "fast", DataPool: &cephv1.PoolSpec{FailureDomain: "host", Replicated: cephv1.ReplicatedSpec{Size: 3}}},
+	}
+	targets := placementTargets(s.Spec)
+	assert.Equal(t, 2, len(targets))
+	assert.Nil(t, targets[0].DataPool)
+	assert.Equal(t, "COLD", targets[0].StorageClasses[0].Name)
+	assert.Equal(t, uint(2), targets[0].StorageClasses[0].DataPool.ErasureCodedConfig.DataChunkCount)
+	assert.Equal(t, "fast", targets[1].Name)
+	assert.Equal(t, "host", targets[1].DataPool.FailureDomain)
+	assert.Equal(t, uint(3), targets[1].DataPool.ReplicatedConfig.Size)
+	assert.Equal(t, 0, len(targets[1].StorageClasses))
+}
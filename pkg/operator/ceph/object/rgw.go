@@ -29,6 +29,7 @@ import (
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
@@ -63,12 +64,19 @@ func (c *config) createOrUpdate(update bool) error {
 	if err := validateStore(c.context, c.store); err != nil {
 		return fmt.Errorf("invalid object store %s arguments. %+v", c.store.Name, err)
 	}
+	if err := validatePlacementVersion(c.store.Spec, c.cephVersion); err != nil {
+		return fmt.Errorf("invalid object store %s arguments. %+v", c.store.Name, err)
+	}
 
 	// check if the object store already exists
 	exists, err := c.storeExists()
 	if err == nil && exists {
 		if !update {
 			logger.Infof("object store %s exists in namespace %s", c.store.Name, c.store.Namespace)
+			// the stores created by a previous version do not have the admin ops user yet
+			if err := c.createAdminOpsUser(); err != nil {
+				return fmt.Errorf("failed to create the admin ops user. %+v", err)
+			}
 			return c.startRGWPods(false)
 		}
 		logger.Infof("object store %s exists in namespace %s. checking for updates", c.store.Name, c.store.Namespace)
@@ -93,6 +101,21 @@ func (c *config) createOrUpdate(update bool) error {
 		return fmt.Errorf("failed to create pools. %+v", err)
 	}
 
+	if err := c.createAdminOpsUser(); err != nil {
+		return fmt.Errorf("failed to create the admin ops user. %+v", err)
+	}
+
+	// the buckets are also served as the subdomains of the dns names of the object store
+	if err := rgwdaemon.SetHostnames(objContext, c.store.Spec.Hosting.DNSNames); err != nil {
+		return fmt.Errorf("failed to set the dns names. %+v", err)
+	}
+	if err := rgwdaemon.ConfigurePlacement(objContext, placementTargets(c.store.Spec)); err != nil {
+		return fmt.Errorf("failed to configure the placement targets. %+v", err)
+	}
+	if err := c.startIngress(); err != nil {
+		return fmt.Errorf("failed to start the ingress. %+v", err)
+	}
+
 	if err := c.startRGWPods(update); err != nil {
 		return fmt.Errorf("failed to start pods. %+v", err)
 	}
@@ -147,6 +170,11 @@ func (c *config) deleteStore() error {
 		logger.Warningf("failed to delete rgw service. %+v", err)
 	}
 
+	// Delete the rgw ingress
+	if err := c.deleteIngress(); err != nil {
+		logger.Warning(err.Error())
+	}
+
 	// Make a best effort to delete the rgw pods
 	err = k8sutil.DeleteDeployment(c.context.Clientset, c.store.Namespace, c.instanceName())
 	if err != nil {
@@ -163,8 +191,17 @@ func (c *config) deleteStore() error {
 		logger.Warningf("failed to delete rgw secret. %+v", err)
 	}
 
+	// Delete the keys of the admin ops user, which is deleted with the pools of the object store
+	err = c.context.Clientset.CoreV1().Secrets(c.store.Namespace).Delete(AdminOpsSecretName(c.store.Name), options)
+	if err != nil && !errors.IsNotFound(err) {
+		logger.Warningf("failed to delete the admin ops secret. %+v", err)
+	}
+
 	// Delete the realm and pools
 	objContext := rgwdaemon.NewContext(c.context, c.store.Name, c.store.Namespace)
+	if err := rgwdaemon.DeletePlacementPools(objContext, placementTargets(c.store.Spec)); err != nil {
+		logger.Warningf("failed to delete the pools of the placement targets. %+v", err)
+	}
 	err = rgwdaemon.DeleteObjectStore(objContext)
 	if err != nil {
 		return fmt.Errorf("failed to delete the realm and pools. %+v", err)
@@ -268,6 +305,17 @@ func validateStore(context *clusterd.Context, s cephv1.CephObjectStore) error {
 	if err := pool.ValidatePoolSpec(context, s.Namespace, &s.Spec.DataPool); err != nil {
 		return fmt.Errorf("invalid data pool spec. %+v", err)
 	}
+	for _, name := range s.Spec.Hosting.DNSNames {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("invalid dns name %s. %v", name, errs)
+		}
+	}
+	if s.Spec.Hosting.Ingress.Enabled && len(s.Spec.Hosting.DNSNames) == 0 && s.Spec.Hosting.Ingress.Host == "" {
+		return fmt.Errorf("the ingress requires a host or dns names")
+	}
+	if err := validatePlacementTargets(context, s); err != nil {
+		return err
+	}
 
 	return nil
 }
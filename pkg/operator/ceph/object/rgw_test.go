@@ -31,6 +31,9 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 )
 
+const adminOpsUserOutput = `{"user_id":"rook-ceph-admin-ops","display_name":"rook-ceph-admin-ops",
+	"keys":[{"user":"rook-ceph-admin-ops","access_key":"myaccesskey","secret_key":"mysecretkey"}]}`
+
 func TestStartRGW(t *testing.T) {
 	clientset := testop.New(3)
 	executor := &exectest.MockExecutor{
@@ -38,6 +41,9 @@ func TestStartRGW(t *testing.T) {
 			return `{"key":"mysecurekey"}`, nil
 		},
 		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if args[0] == "user" {
+				return adminOpsUserOutput, nil
+			}
 			return `{"id":"test-id"}`, nil
 		},
 	}
@@ -88,10 +94,19 @@ func validateStart(t *testing.T, c *config, clientset *fake.Clientset, allNodes
 	assert.Nil(t, err)
 	assert.Equal(t, c.instanceName(), secret.Name)
 	assert.Equal(t, 1, len(secret.StringData))
+
+	secret, err = clientset.CoreV1().Secrets(c.store.Namespace).Get(AdminOpsSecretName(c.store.Name), metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "myaccesskey", secret.StringData["AccessKey"])
+	assert.Equal(t, "mysecretkey", secret.StringData["SecretKey"])
+	assert.Equal(t, storeEndpoint(&c.store), secret.StringData["Endpoint"])
 }
 
 func TestCreateObjectStore(t *testing.T) {
 	commandWithOutputFunc := func(debug bool, actionName, command string, args ...string) (string, error) {
+		if args[0] == "user" {
+			return adminOpsUserOutput, nil
+		}
 		return `{"realms": []}`, nil
 	}
 	executor := &exectest.MockExecutor{
@@ -125,7 +140,7 @@ func simpleStore() cephv1.CephObjectStore {
 	return cephv1.CephObjectStore{
 		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "mycluster"},
 		Spec: cephv1.ObjectStoreSpec{
-			MetadataPool: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1}},
+			MetadataPool: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}},
 			DataPool:     cephv1.PoolSpec{ErasureCoded: cephv1.ErasureCodedSpec{CodingChunks: 1, DataChunks: 2}},
 			Gateway:      cephv1.GatewaySpec{Port: 123},
 		},
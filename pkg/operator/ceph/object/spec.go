@@ -57,6 +57,9 @@ func (c *config) startDeployment() error {
 		logger.Infof("deployment for rgw %s already exists. updating if needed", c.instanceName())
 		// There may be a *lot* of rgws, and they are stateless, so don't bother waiting until the
 		// entire deployment is updated to move on.
+		if original, err := c.context.Clientset.Extensions().Deployments(c.store.Namespace).Get(d.Name, metav1.GetOptions{}); err == nil {
+			k8sutil.KeepRestartedAt(original, d)
+		}
 		_, err := c.context.Clientset.Extensions().Deployments(c.store.Namespace).Update(d)
 		if err != nil {
 			return fmt.Errorf("failed to update rgw deployment %s. %+v", c.instanceName(), err)
@@ -127,6 +130,7 @@ func (c *config) makeRGWPodSpec() v1.PodTemplateSpec {
 	}
 
 	c.store.Spec.Gateway.Placement.ApplyToPodSpec(&podSpec)
+	c.store.Spec.Gateway.PodExtension.ApplyToPodSpec(&podSpec)
 
 	return v1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
@@ -151,7 +155,7 @@ func (c *config) makeConfigInitContainer() v1.Container {
 			fmt.Sprintf("--rgw-secure-port=%d", c.store.Spec.Gateway.SecurePort),
 		},
 		VolumeMounts: opspec.RookVolumeMounts(),
-		Env: []v1.EnvVar{
+		Env: append([]v1.EnvVar{
 			{Name: "ROOK_RGW_KEYRING", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: c.instanceName()}, Key: keyringName}}},
 			k8sutil.PodIPEnvVar(k8sutil.PrivateIPEnvVar),
 			k8sutil.PodIPEnvVar(k8sutil.PublicIPEnvVar),
@@ -159,10 +163,15 @@ func (c *config) makeConfigInitContainer() v1.Container {
 			opmon.EndpointEnvVar(),
 			opmon.SecretEnvVar(),
 			k8sutil.ConfigOverrideEnvVar(),
-		},
+		}, k8sutil.LogFormatEnvVars(true)...),
 		Resources: c.store.Spec.Gateway.Resources,
 	}
 
+	if len(c.store.Spec.Hosting.DNSNames) > 0 {
+		// rgw only accepts a single dns name in its config, the others are the hostnames of the zonegroup
+		container.Args = append(container.Args, fmt.Sprintf("--rgw-dns-name=%s", c.store.Spec.Hosting.DNSNames[0]))
+	}
+
 	if c.store.Spec.Gateway.SSLCertificateRef != "" {
 		// Add a volume mount for the ssl certificate
 		mount := v1.VolumeMount{Name: certVolumeName, MountPath: certMountPath, ReadOnly: true}
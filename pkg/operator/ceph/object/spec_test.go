@@ -138,7 +138,35 @@ func TestValidateSpec(t *testing.T) {
 	s.Spec.MetadataPool.Replicated.Size = 0
 	err = validateStore(context, s)
 	assert.NotNil(t, err)
-	s.Spec.MetadataPool.Replicated.Size = 1
+	s.Spec.MetadataPool.Replicated.Size = 3
 	err = validateStore(context, s)
 	assert.Nil(t, err)
+
+	// invalid dns name
+	s.Spec.Hosting.DNSNames = []string{"s3.example.com", "*.example.com"}
+	err = validateStore(context, s)
+	assert.NotNil(t, err)
+	s.Spec.Hosting.DNSNames = []string{"s3.example.com"}
+	err = validateStore(context, s)
+	assert.Nil(t, err)
+
+	// ingress without dns names
+	s.Spec.Hosting.Ingress.Enabled = true
+	s.Spec.Hosting.DNSNames = nil
+	err = validateStore(context, s)
+	assert.NotNil(t, err)
+	s.Spec.Hosting.Ingress.Host = "rgw.example.com"
+	err = validateStore(context, s)
+	assert.Nil(t, err)
+}
+
+func TestDNSNamesPodSpec(t *testing.T) {
+	store := simpleStore()
+	store.Spec.Hosting.DNSNames = []string{"s3.example.com", "objects.example.com"}
+
+	c := &config{store: store, rookVersion: "v1.0"}
+	s := c.makeRGWPodSpec()
+	cont := s.Spec.InitContainers[0]
+	assert.Equal(t, 7, len(cont.Args))
+	assert.Equal(t, "--rgw-dns-name=s3.example.com", cont.Args[6])
 }
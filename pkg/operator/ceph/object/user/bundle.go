@@ -0,0 +1,154 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectuser
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const (
+	// BundleS3A is the core-site.xml of the S3A file system of Hadoop
+	BundleS3A = "s3a"
+	// BundleVelero is the credentials file and the config of the backup storage location of Velero
+	BundleVelero = "velero"
+	// BundleMinio is the config of the MinIO client
+	BundleMinio = "minio"
+
+	// AccessFull gives the keys of the user to the bundles
+	AccessFull = "full"
+	// AccessRead gives the keys of a subuser that can only read the buckets of the user
+	AccessRead = "read"
+	// AccessWrite gives the keys of a subuser that can only write the buckets of the user
+	AccessWrite = "write"
+	// AccessReadWrite gives the keys of a subuser that can read and write the buckets of the user, but not change
+	// their acls
+	AccessReadWrite = "readwrite"
+	// bundleSubuser is the name of the subuser of the scoped bundles, the id of the subuser is <user>:bundle
+	bundleSubuser = "bundle"
+
+	// DefaultRegion is the region expected by most clients, since the store does not check it
+	DefaultRegion = "us-east-1"
+	// caCertKey is the file of the certificates of the endpoint, in all the bundles
+	caCertKey = "ca.crt"
+)
+
+// Credentials are the keys of a user with the endpoint of its object store
+type Credentials struct {
+	AccessKey string
+	SecretKey string
+	Endpoint  string
+	Region    string
+	// the certificates of the https endpoint, if they are not signed by a known authority
+	CACert string
+}
+
+// validateAccess checks the access of the keys of the bundles
+func validateAccess(access string) error {
+	switch access {
+	case "", AccessFull, AccessRead, AccessWrite, AccessReadWrite:
+		return nil
+	}
+	return fmt.Errorf("invalid credential bundle access %s. must be %s, %s, %s or %s", access, AccessFull, AccessRead, AccessWrite, AccessReadWrite)
+}
+
+// scoped returns whether the bundles have the keys of a subuser with a restricted access
+func scoped(access string) bool {
+	return access != "" && access != AccessFull
+}
+
+// Bundle returns the files configuring a client of the object store with the credentials, keyed by file name
+func Bundle(format string, c Credentials) (map[string]string, error) {
+	if c.Region == "" {
+		c.Region = DefaultRegion
+	}
+
+	var files map[string]string
+	switch format {
+	case BundleS3A:
+		files = map[string]string{"core-site.xml": s3aConfig(c)}
+	case BundleVelero:
+		files = map[string]string{
+			"cloud":              fmt.Sprintf("[default]\naws_access_key_id=%s\naws_secret_access_key=%s\n", c.AccessKey, c.SecretKey),
+			"velero-config.yaml": fmt.Sprintf("region: %s\ns3ForcePathStyle: \"true\"\ns3Url: %s\n", c.Region, c.Endpoint),
+		}
+	case BundleMinio:
+		config, err := minioConfig(c)
+		if err != nil {
+			return nil, err
+		}
+		files = map[string]string{"mc-config.json": config}
+	default:
+		return nil, fmt.Errorf("invalid credential bundle format %s. must be %s, %s or %s", format, BundleS3A, BundleVelero, BundleMinio)
+	}
+
+	if c.CACert != "" {
+		files[caCertKey] = c.CACert
+	}
+	return files, nil
+}
+
+// s3aConfig returns the properties of the S3A file system with path style access, since the buckets are not
+// subdomains of the endpoint by default
+func s3aConfig(c Credentials) string {
+	properties := []struct{ name, value string }{
+		{"fs.s3a.endpoint", c.Endpoint},
+		{"fs.s3a.endpoint.region", c.Region},
+		{"fs.s3a.access.key", c.AccessKey},
+		{"fs.s3a.secret.key", c.SecretKey},
+		{"fs.s3a.path.style.access", "true"},
+		{"fs.s3a.connection.ssl.enabled", fmt.Sprintf("%t", strings.HasPrefix(c.Endpoint, "https://"))},
+	}
+
+	var b bytes.Buffer
+	b.WriteString("<?xml version=\"1.0\"?>\n<configuration>\n")
+	for _, p := range properties {
+		b.WriteString("  <property>\n    <name>")
+		b.WriteString(p.name)
+		b.WriteString("</name>\n    <value>")
+		xml.EscapeText(&b, []byte(p.value))
+		b.WriteString("</value>\n  </property>\n")
+	}
+	b.WriteString("</configuration>\n")
+	return b.String()
+}
+
+// minioConfig returns the config of the MinIO client with an alias of the store
+func minioConfig(c Credentials) (string, error) {
+	type alias struct {
+		URL       string `json:"url"`
+		AccessKey string `json:"accessKey"`
+		SecretKey string `json:"secretKey"`
+		API       string `json:"api"`
+		Path      string `json:"path"`
+	}
+	config := struct {
+		Version string           `json:"version"`
+		Aliases map[string]alias `json:"aliases"`
+	}{
+		Version: "10",
+		Aliases: map[string]alias{"ceph": {URL: c.Endpoint, AccessKey: c.AccessKey, SecretKey: c.SecretKey, API: "s3v4", Path: "auto"}},
+	}
+	b, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to generate the minio client config. %+v", err)
+	}
+	return string(b) + "\n", nil
+}
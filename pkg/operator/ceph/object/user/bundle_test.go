@@ -0,0 +1,51 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectuser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundle(t *testing.T) {
+	c := Credentials{AccessKey: "access", SecretKey: "se<cret", Endpoint: "http://rook-ceph-rgw-my-store:80"}
+
+	files, err := Bundle(BundleS3A, c)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+	assert.Contains(t, files["core-site.xml"], "<name>fs.s3a.secret.key</name>\n    <value>se&lt;cret</value>")
+	assert.Contains(t, files["core-site.xml"], "<name>fs.s3a.endpoint.region</name>\n    <value>us-east-1</value>")
+	assert.Contains(t, files["core-site.xml"], "<name>fs.s3a.connection.ssl.enabled</name>\n    <value>false</value>")
+
+	c.Region = "eu"
+	files, err = Bundle(BundleVelero, c)
+	assert.Nil(t, err)
+	assert.Equal(t, "[default]\naws_access_key_id=access\naws_secret_access_key=se<cret\n", files["cloud"])
+	assert.Equal(t, "region: eu\ns3ForcePathStyle: \"true\"\ns3Url: http://rook-ceph-rgw-my-store:80\n", files["velero-config.yaml"])
+
+	c.Endpoint = "https://s3.example.com"
+	c.CACert = "cert"
+	files, err = Bundle(BundleMinio, c)
+	assert.Nil(t, err)
+	assert.Contains(t, files["mc-config.json"], `"url": "https://s3.example.com"`)
+	assert.Contains(t, files["mc-config.json"], `"accessKey": "access"`)
+	assert.Equal(t, "cert", files["ca.crt"])
+
+	_, err = Bundle("aws", c)
+	assert.NotNil(t, err)
+}
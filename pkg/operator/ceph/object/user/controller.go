@@ -20,12 +20,15 @@ package objectuser
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/coreos/pkg/capnslog"
 	opkit "github.com/rook/operator-kit"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephrgw "github.com/rook/rook/pkg/daemon/ceph/rgw"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	"github.com/rook/rook/pkg/operator/ceph/object"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
@@ -35,6 +38,13 @@ import (
 
 const (
 	AppName = "rook-ceph-rgw"
+
+	accessKeyName = "AccessKey"
+	secretKeyName = "SecretKey"
+	// BundleAccessKeyName and BundleSecretKeyName are the keys of the subuser of the scoped credential bundles,
+	// in the secret of the user
+	BundleAccessKeyName = "BundleAccessKey"
+	BundleSecretKeyName = "BundleSecretKey"
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-object")
@@ -53,6 +63,10 @@ var ObjectStoreUserResource = opkit.CustomResource{
 type ObjectStoreUserController struct {
 	context  *clusterd.Context
 	ownerRef metav1.OwnerReference
+	// the url of the rgw service of an object store
+	endpoint func(namespace, store string) (string, error)
+	// the certificates of the https endpoint of an object store
+	caCert func(namespace, store string) (string, error)
 }
 
 // NewObjectStoreUserController create controller for watching object store user custom resources created
@@ -60,17 +74,24 @@ func NewObjectStoreUserController(context *clusterd.Context, ownerRef metav1.Own
 	return &ObjectStoreUserController{
 		context:  context,
 		ownerRef: ownerRef,
+		endpoint: func(namespace, store string) (string, error) {
+			return object.GetStoreEndpoint(context, namespace, store)
+		},
+		caCert: func(namespace, store string) (string, error) {
+			return object.GetStoreCACert(context, namespace, store)
+		},
 	}
 }
 
 // StartWatch watches for instances of ObjectStoreUser custom resources and acts on them
 func (c *ObjectStoreUserController) StartWatch(namespace string, stopCh chan struct{}) error {
 
-	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+	// the resources are not reconciled while their cluster is in maintenance mode
+	resourceHandlerFuncs := maintenance.PausableHandlers(cache.ResourceEventHandlerFuncs{
 		AddFunc:    c.onAdd,
 		UpdateFunc: c.onUpdate,
 		DeleteFunc: c.onDelete,
-	}
+	})
 
 	logger.Infof("start watching object store user resources in namespace %s", namespace)
 	watcher := opkit.NewWatcher(ObjectStoreUserResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
@@ -92,7 +113,24 @@ func (c *ObjectStoreUserController) onAdd(obj interface{}) {
 }
 
 func (c *ObjectStoreUserController) onUpdate(oldObj, newObj interface{}) {
-	// TODO: Add update code here after features are added which require updates.
+	oldUser, err := getObjectStoreUserObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old objectstoreuser object: %+v", err)
+		return
+	}
+	user, err := getObjectStoreUserObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new objectstoreuser object: %+v", err)
+		return
+	}
+
+	if reflect.DeepEqual(oldUser.Spec.CredentialBundles, user.Spec.CredentialBundles) {
+		logger.Debugf("object store user %s credential bundles not changed", user.Name)
+		return
+	}
+	if err = c.updateCredentialBundles(c.context, user); err != nil {
+		logger.Errorf("failed to update the credential bundles of object store user %s. %+v", user.Name, err)
+	}
 }
 
 func (c *ObjectStoreUserController) onDelete(obj interface{}) {
@@ -102,7 +140,7 @@ func (c *ObjectStoreUserController) onDelete(obj interface{}) {
 		return
 	}
 
-	if err = deleteUser(c.context, user); err != nil {
+	if err = c.deleteUser(c.context, user); err != nil {
 		logger.Errorf("failed to delete object store user %s. %+v", user.Name, err)
 	}
 }
@@ -137,27 +175,31 @@ func (c *ObjectStoreUserController) createUser(context *clusterd.Context, u *cep
 		displayName = u.Name
 	}
 
+	adminOps, err := c.adminOpsClient(context, u)
+	if err != nil {
+		return err
+	}
+
 	// create the user
 	logger.Infof("creating user %s in namespace %s", u.Name, u.Namespace)
 	userConfig := cephrgw.ObjectUser{
 		UserID:      u.Name,
 		DisplayName: &displayName,
 	}
-	objContext := cephrgw.NewContext(context, u.Spec.Store, u.Namespace)
 
-	user, rgwerr, err := cephrgw.CreateUser(objContext, userConfig)
+	user, rgwerr, err := adminOps.CreateUser(userConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create user %s. RadosGW returned error %d: %+v", u.Name, rgwerr, err)
 	}
 
-	// Store the keys in a secret
-	secrets := map[string]string{
-		"AccessKey": *user.AccessKey,
-		"SecretKey": *user.SecretKey,
+	// Store the keys in a secret, with the configuration files of the clients
+	secrets, err := c.credentialBundles(adminOps, u, *user.AccessKey, *user.SecretKey)
+	if err != nil {
+		return err
 	}
 	secret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("rook-ceph-object-user-%s-%s", u.Spec.Store, u.Name),
+			Name:      userSecretName(u),
 			Namespace: u.Namespace,
 			Labels: map[string]string{
 				"app":               AppName,
@@ -179,19 +221,96 @@ func (c *ObjectStoreUserController) createUser(context *clusterd.Context, u *cep
 	return nil
 }
 
+// Update the configuration files of the clients in the secret of the user
+func (c *ObjectStoreUserController) updateCredentialBundles(context *clusterd.Context, u *cephv1.CephObjectStoreUser) error {
+	secret, err := context.Clientset.CoreV1().Secrets(u.Namespace).Get(userSecretName(u), metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get user %s secret. %+v", u.Name, err)
+	}
+	adminOps, err := c.adminOpsClient(context, u)
+	if err != nil {
+		return err
+	}
+	secrets, err := c.credentialBundles(adminOps, u, string(secret.Data[accessKeyName]), string(secret.Data[secretKeyName]))
+	if err != nil {
+		return err
+	}
+	if !scoped(u.Spec.CredentialBundles.Access) && secret.Data[BundleAccessKeyName] != nil {
+		// the keys of the bundles are not restricted anymore
+		if rgwerr, err := adminOps.DeleteSubuser(u.Name, bundleSubuser); err != nil && rgwerr != cephrgw.RGWErrorNotFound {
+			return err
+		}
+	}
+
+	// replace the files of the previous formats
+	secret.Data = nil
+	secret.StringData = secrets
+	if _, err := context.Clientset.CoreV1().Secrets(u.Namespace).Update(secret); err != nil {
+		return fmt.Errorf("failed to update user %s secret. %+v", u.Name, err)
+	}
+	logger.Infof("updated the credential bundles of user %s", u.Name)
+	return nil
+}
+
+// credentialBundles returns the keys of the user with the files of its credential bundles. The bundles with a
+// restricted access have the keys of a subuser, which are also returned.
+func (c *ObjectStoreUserController) credentialBundles(adminOps *cephrgw.AdminOpsClient, u *cephv1.CephObjectStoreUser, accessKey, secretKey string) (map[string]string, error) {
+	secrets := map[string]string{
+		accessKeyName: accessKey,
+		secretKeyName: secretKey,
+	}
+	bundles := u.Spec.CredentialBundles
+	if len(bundles.Formats) == 0 {
+		return secrets, nil
+	}
+
+	creds := Credentials{AccessKey: accessKey, SecretKey: secretKey, Endpoint: bundles.Endpoint, Region: bundles.Region}
+	var err error
+	if scoped(bundles.Access) {
+		if creds.AccessKey, creds.SecretKey, err = adminOps.SetSubuser(u.Name, bundleSubuser, bundles.Access); err != nil {
+			return nil, err
+		}
+		secrets[BundleAccessKeyName] = creds.AccessKey
+		secrets[BundleSecretKeyName] = creds.SecretKey
+	}
+	if creds.Endpoint == "" {
+		if creds.Endpoint, err = c.endpoint(u.Namespace, u.Spec.Store); err != nil {
+			return nil, err
+		}
+	}
+	if strings.HasPrefix(creds.Endpoint, "https://") {
+		if creds.CACert, err = c.caCert(u.Namespace, u.Spec.Store); err != nil {
+			return nil, err
+		}
+	}
+	for _, format := range bundles.Formats {
+		files, err := Bundle(format, creds)
+		if err != nil {
+			return nil, err
+		}
+		for name, content := range files {
+			secrets[name] = content
+		}
+	}
+	return secrets, nil
+}
+
 // Delete the user
-func deleteUser(context *clusterd.Context, u *cephv1.CephObjectStoreUser) error {
-	objContext := cephrgw.NewContext(context, u.Spec.Store, u.Namespace)
-	_, rgwerr, err := cephrgw.DeleteUser(objContext, u.Name)
+func (c *ObjectStoreUserController) deleteUser(context *clusterd.Context, u *cephv1.CephObjectStoreUser) error {
+	adminOps, err := c.adminOpsClient(context, u)
+	if err != nil {
+		return err
+	}
+	rgwerr, err := adminOps.DeleteUser(u.Name)
 	if err != nil {
-		if rgwerr == 3 {
+		if rgwerr == cephrgw.RGWErrorNotFound {
 			logger.Infof("user %s does not exist in store %s", u.Name, u.Spec.Store)
 		} else {
 			return fmt.Errorf("failed to delete user '%s': %+v", u.Name, err)
 		}
 	}
 
-	err = context.Clientset.CoreV1().Secrets(u.Namespace).Delete("rook-ceph-object-user-"+u.Name, &metav1.DeleteOptions{})
+	err = context.Clientset.CoreV1().Secrets(u.Namespace).Delete(userSecretName(u), &metav1.DeleteOptions{})
 	if err != nil {
 		logger.Warningf("failed to delete user %s secret. %+v", u.Name, err)
 	}
@@ -200,6 +319,24 @@ func deleteUser(context *clusterd.Context, u *cephv1.CephObjectStoreUser) error
 	return nil
 }
 
+// Create a client of the admin ops api of the store of the user
+func (c *ObjectStoreUserController) adminOpsClient(context *clusterd.Context, u *cephv1.CephObjectStoreUser) (*cephrgw.AdminOpsClient, error) {
+	endpoint, err := c.endpoint(u.Namespace, u.Spec.Store)
+	if err != nil {
+		return nil, err
+	}
+	return object.NewAdminOpsClient(context, u.Namespace, u.Spec.Store, endpoint)
+}
+
+func userSecretName(u *cephv1.CephObjectStoreUser) string {
+	return SecretName(u.Spec.Store, u.Name)
+}
+
+// SecretName returns the name of the secret with the keys of a user of an object store
+func SecretName(store, user string) string {
+	return fmt.Sprintf("rook-ceph-object-user-%s-%s", store, user)
+}
+
 // Validate the user arguments
 func ValidateUser(context *clusterd.Context, u *cephv1.CephObjectStoreUser) error {
 	if u.Name == "" {
@@ -211,5 +348,13 @@ func ValidateUser(context *clusterd.Context, u *cephv1.CephObjectStoreUser) erro
 	if u.Spec.Store == "" {
 		return fmt.Errorf("missing store")
 	}
+	if err := validateAccess(u.Spec.CredentialBundles.Access); err != nil {
+		return err
+	}
+	for _, format := range u.Spec.CredentialBundles.Formats {
+		if _, err := Bundle(format, Credentials{}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -18,10 +18,17 @@ limitations under the License.
 package objectuser
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestGetObjectStoreUserObject(t *testing.T) {
@@ -35,3 +42,114 @@ func TestGetObjectStoreUserObject(t *testing.T) {
 	assert.Nil(t, objectuser)
 	assert.NotNil(t, err)
 }
+
+func TestCreateUser(t *testing.T) {
+	requests := []string{}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		if r.Method == "PUT" && r.URL.Query().Get("subuser") == "" {
+			w.Write([]byte(`{"user_id":"myuser","display_name":"myuser",
+				"keys":[{"user":"myuser","access_key":"useraccesskey","secret_key":"usersecretkey"}]}`))
+		}
+		if r.Method == "GET" {
+			w.Write([]byte(`{"user_id":"myuser","display_name":"myuser",
+				"keys":[{"user":"myuser","access_key":"useraccesskey","secret_key":"usersecretkey"},
+				{"user":"myuser:bundle","access_key":"subaccesskey","secret_key":"subsecretkey"}]}`))
+		}
+	}))
+	defer httpServer.Close()
+
+	adminSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-rgw-mystore-admin-ops", Namespace: "mycluster"},
+		Data:       map[string][]byte{"AccessKey": []byte("adminaccesskey"), "SecretKey": []byte("adminsecretkey")},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(adminSecret)}
+	c := NewObjectStoreUserController(context, metav1.OwnerReference{})
+	c.endpoint = func(namespace, store string) (string, error) {
+		return httpServer.URL, nil
+	}
+
+	u := &cephv1.CephObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "myuser", Namespace: "mycluster"},
+		Spec:       cephv1.ObjectStoreUserSpec{Store: "mystore"},
+	}
+	err := c.createUser(context, u)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"PUT /admin/user?display-name=myuser&uid=myuser"}, requests)
+	secret, err := context.Clientset.CoreV1().Secrets("mycluster").Get("rook-ceph-object-user-mystore-myuser", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "useraccesskey", secret.StringData["AccessKey"])
+	assert.Equal(t, "usersecretkey", secret.StringData["SecretKey"])
+
+	// the user and its secret are deleted
+	err = c.deleteUser(context, u)
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE /admin/user?uid=myuser", requests[1])
+	_, err = context.Clientset.CoreV1().Secrets("mycluster").Get("rook-ceph-object-user-mystore-myuser", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// the credential bundles are written with the keys
+	u.Spec.CredentialBundles = cephv1.CredentialBundleSpec{Formats: []string{BundleS3A}}
+	err = c.createUser(context, u)
+	assert.Nil(t, err)
+	secret, err = context.Clientset.CoreV1().Secrets("mycluster").Get("rook-ceph-object-user-mystore-myuser", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "useraccesskey", secret.StringData["AccessKey"])
+	assert.Contains(t, secret.StringData["core-site.xml"], "<value>"+httpServer.URL+"</value>")
+	assert.Contains(t, secret.StringData["core-site.xml"], "<value>usersecretkey</value>")
+
+	// the bundles are replaced when the formats are updated
+	secret.Data = map[string][]byte{"AccessKey": []byte("useraccesskey"), "SecretKey": []byte("usersecretkey")}
+	_, err = context.Clientset.CoreV1().Secrets("mycluster").Update(secret)
+	assert.Nil(t, err)
+	u.Spec.CredentialBundles = cephv1.CredentialBundleSpec{Formats: []string{BundleVelero}, Endpoint: "https://s3.example.com"}
+	c.caCert = func(namespace, store string) (string, error) {
+		return "mycert", nil
+	}
+	err = c.updateCredentialBundles(context, u)
+	assert.Nil(t, err)
+	secret, err = context.Clientset.CoreV1().Secrets("mycluster").Get("rook-ceph-object-user-mystore-myuser", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "usersecretkey", secret.StringData["SecretKey"])
+	assert.Equal(t, "mycert", secret.StringData["ca.crt"])
+	assert.Contains(t, secret.StringData["velero-config.yaml"], "s3Url: https://s3.example.com")
+	_, ok := secret.StringData["core-site.xml"]
+	assert.False(t, ok)
+
+	// the scoped bundles have the keys of a subuser
+	secret.Data = map[string][]byte{"AccessKey": []byte("useraccesskey"), "SecretKey": []byte("usersecretkey")}
+	_, err = context.Clientset.CoreV1().Secrets("mycluster").Update(secret)
+	assert.Nil(t, err)
+	u.Spec.CredentialBundles = cephv1.CredentialBundleSpec{Formats: []string{BundleS3A}, Access: AccessRead}
+	err = c.updateCredentialBundles(context, u)
+	assert.Nil(t, err)
+	assert.Equal(t, "POST /admin/user?access=read&subuser=myuser%3Abundle&uid=myuser", requests[len(requests)-1])
+	secret, err = context.Clientset.CoreV1().Secrets("mycluster").Get("rook-ceph-object-user-mystore-myuser", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "usersecretkey", secret.StringData["SecretKey"])
+	assert.Equal(t, "subaccesskey", secret.StringData["BundleAccessKey"])
+	assert.Contains(t, secret.StringData["core-site.xml"], "<value>subsecretkey</value>")
+
+	// the subuser is deleted when the bundles have the keys of the user again
+	secret.Data = map[string][]byte{"AccessKey": []byte("useraccesskey"), "SecretKey": []byte("usersecretkey"), "BundleAccessKey": []byte("subaccesskey")}
+	_, err = context.Clientset.CoreV1().Secrets("mycluster").Update(secret)
+	assert.Nil(t, err)
+	u.Spec.CredentialBundles.Access = AccessFull
+	err = c.updateCredentialBundles(context, u)
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE /admin/user?purge-keys=True&subuser=myuser%3Abundle&uid=myuser", requests[len(requests)-1])
+	secret, err = context.Clientset.CoreV1().Secrets("mycluster").Get("rook-ceph-object-user-mystore-myuser", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Contains(t, secret.StringData["core-site.xml"], "<value>usersecretkey</value>")
+	_, ok = secret.StringData["BundleAccessKey"]
+	assert.False(t, ok)
+
+	// the access is validated
+	u.Spec.CredentialBundles.Access = "admin"
+	assert.NotNil(t, ValidateUser(context, u))
+
+	// the admin ops user of the store was not created
+	u.Spec.Store = "otherstore"
+	err = c.createUser(context, u)
+	assert.NotNil(t, err)
+}
@@ -29,13 +29,17 @@ import (
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
 	"github.com/rook/rook/pkg/operator/ceph/agent"
+	"github.com/rook/rook/pkg/operator/ceph/api"
+	"github.com/rook/rook/pkg/operator/ceph/capacity"
 	"github.com/rook/rook/pkg/operator/ceph/cluster"
 	"github.com/rook/rook/pkg/operator/ceph/file"
+	"github.com/rook/rook/pkg/operator/ceph/logging"
 	"github.com/rook/rook/pkg/operator/ceph/object"
 	"github.com/rook/rook/pkg/operator/ceph/object/user"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
 	"github.com/rook/rook/pkg/operator/ceph/provisioner"
 	"github.com/rook/rook/pkg/operator/ceph/provisioner/controller"
+	"github.com/rook/rook/pkg/operator/ceph/snapshot"
 	"github.com/rook/rook/pkg/operator/discover"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/api/core/v1"
@@ -122,9 +126,43 @@ func (o *Operator) Run() error {
 		logger.Infof("rook-provisioner %s started using %s flex vendor dir", name, vendor)
 	}
 
+	// Detect the images of the provisioners that are not used by any volume anymore
+	janitor, err := provisioner.NewJanitor(o.context, provisioner.OrphanedImagesPolicy)
+	if err != nil {
+		return fmt.Errorf("Error starting orphaned images janitor: %v", err)
+	}
+	go janitor.Start(stopChan)
+
+	// Track the capacity claimed by the namespaces against their storage quota
+	quotaChecker, err := provisioner.NewQuotaChecker(o.context, provisioner.StorageQuotaPolicy)
+	if err != nil {
+		return fmt.Errorf("Error starting storage quota checker: %v", err)
+	}
+	go quotaChecker.Start(stopChan)
+
+	// Check the raw utilization of the clusters before creating the pools, filesystems and volumes
+	if err := capacity.ValidateGuardrail(); err != nil {
+		return fmt.Errorf("Error starting capacity guardrail: %v", err)
+	}
+
+	adminAPI := api.New(o.context, namespace, o.clusterController.StartZapJob)
+	if err := adminAPI.Start(stopChan); err != nil {
+		return fmt.Errorf("Error starting admin api: %v", err)
+	}
+
+	// change the log level of the packages without restarting the operator
+	logLevelController := logging.NewLogLevelController(o.context, namespace)
+	logLevelController.StartWatch(stopChan)
+
 	// watch for changes to the rook clusters
 	o.clusterController.StartWatch(v1.NamespaceAll, stopChan)
 
+	// the backup snapshots are created in the namespaces of the applications
+	snapshotController := snapshot.NewBackupSnapshotController(o.context, namespace)
+	snapshotController.StartWatch(v1.NamespaceAll, stopChan)
+	volumeGroupController := snapshot.NewVolumeGroupController(o.context)
+	volumeGroupController.StartWatch(v1.NamespaceAll, stopChan)
+
 	for {
 		select {
 		case <-signalChan:
@@ -28,6 +28,9 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/daemon/ceph/model"
+	"github.com/rook/rook/pkg/operator/ceph/capacity"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	"k8s.io/api/core/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,8 +41,19 @@ const (
 	replicatedType         = "replicated"
 	erasureCodeType        = "erasure-coded"
 	poolApplicationNameRBD = "rbd"
+	osdFailureDomain       = "osd"
+	safeReplicaSize        = 3
 )
 
+// the techniques supported by the erasure code plugins, the lrc and clay plugins don't have a technique
+var erasureCodeTechniques = map[string][]string{
+	"jerasure": {"reed_sol_van", "reed_sol_r6_op", "cauchy_orig", "cauchy_good", "liberation", "blaum_roth", "liber8tion"},
+	"isa":      {"reed_sol_van", "cauchy"},
+	"lrc":      {},
+	"shec":     {"single", "multiple"},
+	"clay":     {},
+}
+
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-pool")
 
 // PoolResource represents the Pool custom resource object
@@ -63,24 +77,27 @@ var PoolResourceRookLegacy = opkit.CustomResource{
 
 // PoolController represents a controller object for pool custom resources
 type PoolController struct {
-	context *clusterd.Context
+	context     *clusterd.Context
+	cephVersion cephv1.CephVersionSpec
 }
 
 // NewPoolController create controller for watching pool custom resources created
-func NewPoolController(context *clusterd.Context) *PoolController {
+func NewPoolController(context *clusterd.Context, cephVersion cephv1.CephVersionSpec) *PoolController {
 	return &PoolController{
-		context: context,
+		context:     context,
+		cephVersion: cephVersion,
 	}
 }
 
 // Watch watches for instances of Pool custom resources and acts on them
 func (c *PoolController) StartWatch(namespace string, stopCh chan struct{}) error {
 
-	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+	// the resources are not reconciled while their cluster is in maintenance mode
+	resourceHandlerFuncs := maintenance.PausableHandlers(cache.ResourceEventHandlerFuncs{
 		AddFunc:    c.onAdd,
 		UpdateFunc: c.onUpdate,
 		DeleteFunc: c.onDelete,
-	}
+	})
 
 	logger.Infof("start watching pool resources in namespace %s", namespace)
 	watcher := opkit.NewWatcher(PoolResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
@@ -106,10 +123,26 @@ func (c *PoolController) onAdd(obj interface{}) {
 		return
 	}
 
+	if err := validatePoolMirroring(pool.Spec, c.cephVersion); err != nil {
+		logger.Errorf("failed to create pool %s. %+v", pool.Name, err)
+		return
+	}
+	if err := checkPoolCapacity(c.context, pool); err != nil {
+		logger.Errorf("failed to create pool %s. %+v", pool.Name, err)
+		c.updatePoolStatus(pool, err)
+		return
+	}
 	err = createPool(c.context, pool)
 	if err != nil {
 		logger.Errorf("failed to create pool %s. %+v", pool.ObjectMeta.Name, err)
+		return
 	}
+	if pool.Spec.Mirroring != nil {
+		if err := setPoolMirroring(c.context, pool); err != nil {
+			logger.Errorf("failed to set the mirroring of pool %s. %+v", pool.Name, err)
+		}
+	}
+	c.updatePoolStatus(pool, nil)
 }
 
 func (c *PoolController) onUpdate(oldObj, newObj interface{}) {
@@ -135,8 +168,12 @@ func (c *PoolController) onUpdate(oldObj, newObj interface{}) {
 		logger.Errorf("failed to update pool %s. name update not allowed", pool.Name)
 		return
 	}
-	if pool.Spec.ErasureCoded.CodingChunks != 0 && pool.Spec.ErasureCoded.DataChunks != 0 {
-		logger.Errorf("failed to update pool %s. erasurecoded update not allowed", pool.Name)
+	if err := validatePoolUpdate(oldPool.Spec, pool.Spec); err != nil {
+		logger.Errorf("failed to update pool %s. %+v", pool.Name, err)
+		return
+	}
+	if err := validatePoolMirroring(pool.Spec, c.cephVersion); err != nil {
+		logger.Errorf("failed to update pool %s. %+v", pool.Name, err)
 		return
 	}
 	if !poolChanged(oldPool.Spec, pool.Spec) {
@@ -146,9 +183,112 @@ func (c *PoolController) onUpdate(oldObj, newObj interface{}) {
 
 	// if the pool is modified, allow the pool to be created if it wasn't already
 	logger.Infof("updating pool %s", pool.Name)
+	if err := checkPoolCapacity(c.context, pool); err != nil {
+		logger.Errorf("failed to create (modify) pool %s. %+v", pool.Name, err)
+		c.updatePoolStatus(pool, err)
+		return
+	}
 	if err := createPool(c.context, pool); err != nil {
 		logger.Errorf("failed to create (modify) pool %s. %+v", pool.ObjectMeta.Name, err)
+		return
 	}
+
+	if err := resetCrushRule(c.context, oldPool, pool); err != nil {
+		logger.Errorf("failed to reset the crush rule of pool %s. %+v", pool.Name, err)
+	}
+	if pool.Spec.Mirroring != nil && !reflect.DeepEqual(oldPool.Spec.Mirroring, pool.Spec.Mirroring) {
+		if err := setPoolMirroring(c.context, pool); err != nil {
+			logger.Errorf("failed to set the mirroring of pool %s. %+v", pool.Name, err)
+		}
+	}
+	c.updatePoolStatus(pool, nil)
+}
+
+// resetCrushRule moves the pool back to the rule created with the pool when it no longer uses a custom or hybrid
+// rule, and deletes the hybrid rule of the pool once it is not used anymore
+func resetCrushRule(context *clusterd.Context, oldPool, pool *cephv1.CephBlockPool) error {
+	if usesCustomCrushRule(oldPool.Spec) && !usesCustomCrushRule(pool.Spec) {
+		logger.Infof("resetting the crush rule of pool %s", pool.Name)
+		if err := ceph.SetPoolProperty(context, pool.Namespace, pool.Name, "crush_rule", pool.Name); err != nil {
+			return err
+		}
+	}
+	if oldPool.Spec.Replicated.HybridStorage != nil && pool.Spec.Replicated.HybridStorage == nil {
+		rule := ceph.GetHybridCrushRuleForPool(pool.Name)
+		logger.Infof("deleting the hybrid crush rule %s of pool %s", rule, pool.Name)
+		if err := ceph.DeleteCrushRule(context, pool.Namespace, rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func usesCustomCrushRule(spec cephv1.PoolSpec) bool {
+	return spec.CrushRule != "" || spec.Replicated.HybridStorage != nil
+}
+
+// updatePoolStatus sets the conditions that need the attention of the admin in the pool status, with the error of the
+// capacity guardrail if it blocked the creation of the pool
+func (c *PoolController) updatePoolStatus(pool *cephv1.CephBlockPool, capacityErr error) {
+	status := poolStatus(pool.Spec)
+	if capacityErr != nil {
+		status.Conditions = append(status.Conditions, cephv1.PoolCondition{
+			Type:    cephv1.PoolConditionCapacityExceeded,
+			Status:  v1.ConditionTrue,
+			Reason:  "FullnessThresholdExceeded",
+			Message: capacityErr.Error(),
+		})
+	}
+	if reflect.DeepEqual(status.Conditions, pool.Status.Conditions) {
+		return
+	}
+
+	latest, err := c.context.RookClientset.CephV1().CephBlockPools(pool.Namespace).Get(pool.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Errorf("failed to get pool %s prior to updating its status. %+v", pool.Name, err)
+		return
+	}
+	// the usage of the pool is kept, it is updated by the usage collector of the cluster
+	latest.Status.Conditions = status.Conditions
+	if _, err := c.context.RookClientset.CephV1().CephBlockPools(pool.Namespace).Update(latest); err != nil {
+		logger.Errorf("failed to update pool %s status. %+v", pool.Name, err)
+	}
+}
+
+func poolStatus(spec cephv1.PoolSpec) cephv1.PoolStatus {
+	var status cephv1.PoolStatus
+	if r := spec.Replication(); r != nil && r.Size < safeReplicaSize {
+		status.Conditions = append(status.Conditions, cephv1.PoolCondition{
+			Type:    cephv1.PoolConditionUnsafeReplicaSize,
+			Status:  v1.ConditionTrue,
+			Reason:  "RequireSafeReplicaSizeDisabled",
+			Message: fmt.Sprintf("the pool has %d replicas and will lose data when %d osds fail", r.Size, r.Size),
+		})
+	}
+	return status
+}
+
+// validatePoolUpdate blocks the changes that would require to move all the data of the pool. The erasure code profile
+// of a pool cannot be changed after the pool is created.
+func validatePoolUpdate(old, new cephv1.PoolSpec) error {
+	oldEC, newEC := old.ErasureCode(), new.ErasureCode()
+	if (oldEC == nil) != (newEC == nil) {
+		return fmt.Errorf("changing the pool between replicated and erasure coded is not allowed")
+	}
+	if newEC == nil {
+		return nil
+	}
+	if oldEC.DataChunks != newEC.DataChunks || oldEC.CodingChunks != newEC.CodingChunks {
+		return fmt.Errorf("changing the erasure code chunks from k=%d m=%d to k=%d m=%d is not allowed",
+			oldEC.DataChunks, oldEC.CodingChunks, newEC.DataChunks, newEC.CodingChunks)
+	}
+	if oldEC.Plugin != newEC.Plugin || oldEC.Technique != newEC.Technique {
+		return fmt.Errorf("changing the erasure code plugin or technique is not allowed")
+	}
+	if old.FailureDomain != new.FailureDomain || old.CrushRoot != new.CrushRoot {
+		return fmt.Errorf("changing the failure domain or crush root of an erasure coded pool is not allowed")
+	}
+	return nil
 }
 
 func poolChanged(old, new cephv1.PoolSpec) bool {
@@ -156,6 +296,18 @@ func poolChanged(old, new cephv1.PoolSpec) bool {
 		logger.Infof("pool replication changed from %d to %d", old.Replicated.Size, new.Replicated.Size)
 		return true
 	}
+	if old.CrushRule != new.CrushRule {
+		logger.Infof("pool crush rule changed from %q to %q", old.CrushRule, new.CrushRule)
+		return true
+	}
+	if !reflect.DeepEqual(old.Replicated.HybridStorage, new.Replicated.HybridStorage) {
+		logger.Infof("pool hybrid storage changed from %+v to %+v", old.Replicated.HybridStorage, new.Replicated.HybridStorage)
+		return true
+	}
+	if !reflect.DeepEqual(old.Mirroring, new.Mirroring) {
+		logger.Infof("pool mirroring changed from %+v to %+v", old.Mirroring, new.Mirroring)
+		return true
+	}
 	return false
 }
 
@@ -177,6 +329,21 @@ func (c *PoolController) onDelete(obj interface{}) {
 }
 
 // Create the pool
+// checkPoolCapacity checks the raw utilization of the cluster before creating a pool that does not exist yet
+func checkPoolCapacity(context *clusterd.Context, p *cephv1.CephBlockPool) error {
+	if !capacity.Enabled() {
+		return nil
+	}
+	if _, err := ceph.GetPoolDetails(context, p.Namespace, p.Name); err == nil {
+		return nil
+	}
+	rawBytes, err := capacity.SpecRawBytes(p.Spec)
+	if err != nil {
+		return err
+	}
+	return capacity.Check(context, p.Namespace, fmt.Sprintf("pool %s", p.Name), rawBytes)
+}
+
 func createPool(context *clusterd.Context, p *cephv1.CephBlockPool) error {
 	// validate the pool settings
 	if err := ValidatePool(context, p); err != nil {
@@ -223,7 +390,8 @@ func ModelToSpec(pool model.Pool) cephv1.PoolSpec {
 		FailureDomain: pool.FailureDomain,
 		CrushRoot:     pool.CrushRoot,
 		Replicated:    cephv1.ReplicatedSpec{Size: pool.ReplicatedConfig.Size},
-		ErasureCoded:  cephv1.ErasureCodedSpec{CodingChunks: ec.CodingChunkCount, DataChunks: ec.DataChunkCount, Algorithm: ec.Algorithm},
+		ErasureCoded: cephv1.ErasureCodedSpec{CodingChunks: ec.CodingChunkCount, DataChunks: ec.DataChunkCount, Algorithm: ec.Algorithm,
+			Plugin: ec.Plugin, Technique: ec.Technique},
 	}
 }
 
@@ -248,10 +416,41 @@ func ValidatePoolSpec(context *clusterd.Context, namespace string, p *cephv1.Poo
 	if p.Replication() == nil && p.ErasureCode() == nil {
 		return fmt.Errorf("neither replication nor erasure code settings were specified")
 	}
+	if r := p.Replication(); r != nil && r.Size < safeReplicaSize {
+		if r.RequiresSafeReplicaSize() {
+			return fmt.Errorf("a replicated size of %d is not safe, set requireSafeReplicaSize to false to allow it", r.Size)
+		}
+		logger.Warningf("replicated size %d is allowed by requireSafeReplicaSize but the data will be lost when %d osds fail", r.Size, r.Size)
+	}
+
+	if ec := p.ErasureCode(); ec != nil {
+		if err := validateErasureCode(ec); err != nil {
+			return err
+		}
+	}
+	if _, err := capacity.SpecRawBytes(*p); err != nil {
+		return err
+	}
+
+	hybrid := p.Replicated.HybridStorage
+	if hybrid != nil {
+		if p.Replication() == nil {
+			return fmt.Errorf("hybrid storage is only supported for replicated pools")
+		}
+		if p.CrushRule != "" {
+			return fmt.Errorf("both hybrid storage and crush rule settings cannot be specified")
+		}
+		if hybrid.PrimaryDeviceClass == "" || hybrid.SecondaryDeviceClass == "" {
+			return fmt.Errorf("hybrid storage requires both the primary and secondary device classes")
+		}
+		if hybrid.PrimaryDeviceClass == hybrid.SecondaryDeviceClass {
+			return fmt.Errorf("hybrid storage requires different primary and secondary device classes")
+		}
+	}
 
 	var crush ceph.CrushMap
 	var err error
-	if p.FailureDomain != "" || p.CrushRoot != "" {
+	if p.FailureDomain != "" || p.CrushRoot != "" || hybrid != nil {
 		crush, err = ceph.GetCrushMap(context, namespace)
 		if err != nil {
 			return fmt.Errorf("failed to get crush map. %+v", err)
@@ -286,9 +485,106 @@ func ValidatePoolSpec(context *clusterd.Context, namespace string, p *cephv1.Poo
 		}
 	}
 
+	// validate the device classes of the hybrid storage
+	if hybrid != nil {
+		for _, class := range []string{hybrid.PrimaryDeviceClass, hybrid.SecondaryDeviceClass} {
+			found := false
+			for _, d := range crush.Devices {
+				if d.Class == class {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("unrecognized device class %s", class)
+			}
+		}
+	}
+
+	// the chunks of an erasure coded pool must each be placed in a different failure domain
+	if ec := p.ErasureCode(); ec != nil && p.FailureDomain != "" {
+		chunks := int(ec.DataChunks + ec.CodingChunks)
+		available := countFailureDomains(crush, p.CrushRoot, p.FailureDomain)
+		if available < chunks {
+			return fmt.Errorf("erasure code with k=%d m=%d requires %d failure domains of type %s but only %d are available",
+				ec.DataChunks, ec.CodingChunks, chunks, p.FailureDomain, available)
+		}
+	}
+
 	return nil
 }
 
+func validateErasureCode(ec *cephv1.ErasureCodedSpec) error {
+	if ec.DataChunks < 2 {
+		return fmt.Errorf("erasure code requires at least 2 data chunks")
+	}
+	if ec.CodingChunks < 1 {
+		return fmt.Errorf("erasure code requires at least 1 coding chunk")
+	}
+	if ec.Plugin == "" {
+		if ec.Technique != "" {
+			return fmt.Errorf("erasure code technique %s requires a plugin", ec.Technique)
+		}
+		return nil
+	}
+
+	techniques, ok := erasureCodeTechniques[ec.Plugin]
+	if !ok {
+		return fmt.Errorf("unrecognized erasure code plugin %s", ec.Plugin)
+	}
+	if ec.Technique == "" {
+		return nil
+	}
+	for _, t := range techniques {
+		if t == ec.Technique {
+			return nil
+		}
+	}
+	return fmt.Errorf("unrecognized technique %s for erasure code plugin %s", ec.Technique, ec.Plugin)
+}
+
+// countFailureDomains counts the buckets of the failure domain type under the crush root, or the osds if the failure
+// domain is osd
+func countFailureDomains(crush ceph.CrushMap, root, failureDomain string) int {
+	if root == "" {
+		root = "default"
+	}
+
+	buckets := map[int]int{}
+	rootIndex := -1
+	for i, b := range crush.Buckets {
+		buckets[b.ID] = i
+		if b.Name == root {
+			rootIndex = i
+		}
+	}
+	if rootIndex < 0 {
+		return 0
+	}
+
+	// walk the hierarchy from the root, the buckets have negative ids and the osds have positive ids
+	count := 0
+	toVisit := []int{rootIndex}
+	for len(toVisit) > 0 {
+		b := crush.Buckets[toVisit[0]]
+		toVisit = toVisit[1:]
+		if b.TypeName == failureDomain {
+			count++
+			continue
+		}
+		for _, item := range b.Items {
+			if item.ID >= 0 {
+				if failureDomain == osdFailureDomain {
+					count++
+				}
+			} else if i, ok := buckets[item.ID]; ok {
+				toVisit = append(toVisit, i)
+			}
+		}
+	}
+	return count
+}
+
 func (c *PoolController) watchLegacyPools(namespace string, stopCh chan struct{}, resourceHandlerFuncs cache.ResourceEventHandlerFuncs) {
 	// watch for pool.rook.io/v1alpha1 events if the CRD exists
 	if _, err := c.context.RookClientset.CephV1beta1().Pools(namespace).List(metav1.ListOptions{}); err != nil {
@@ -352,11 +648,19 @@ func (c *PoolController) migratePoolObject(poolToMigrate *cephv1.CephBlockPool,
 }
 
 func ConvertRookLegacyPoolSpec(legacySpec cephbeta.PoolSpec) cephv1.PoolSpec {
+	// the legacy pools were allowed with less than 3 replicas, keep allowing them for the existing pools
+	var requireSafeReplicaSize *bool
+	if legacySpec.Replicated.Size > 0 && legacySpec.Replicated.Size < safeReplicaSize {
+		requireSafe := false
+		requireSafeReplicaSize = &requireSafe
+	}
+
 	return cephv1.PoolSpec{
 		FailureDomain: legacySpec.FailureDomain,
 		CrushRoot:     legacySpec.CrushRoot,
 		Replicated: cephv1.ReplicatedSpec{
-			Size: legacySpec.Replicated.Size,
+			Size:                   legacySpec.Replicated.Size,
+			RequireSafeReplicaSize: requireSafeReplicaSize,
 		},
 		ErasureCoded: cephv1.ErasureCodedSpec{
 			DataChunks:   legacySpec.ErasureCoded.DataChunks,
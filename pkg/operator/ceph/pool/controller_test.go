@@ -27,6 +27,7 @@ import (
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -59,6 +60,21 @@ func TestValidatePool(t *testing.T) {
 
 	// succeed with replication settings
 	p = cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
+	p.Spec.Replicated.Size = 3
+	err = ValidatePool(context, &p)
+	assert.Nil(t, err)
+
+	// fail with less than 3 replicas unless the unsafe size is allowed
+	p.Spec.Replicated.Size = 1
+	err = ValidatePool(context, &p)
+	assert.NotNil(t, err)
+	p.Spec.Replicated.Size = 2
+	err = ValidatePool(context, &p)
+	assert.NotNil(t, err)
+	requireSafeReplicaSize := false
+	p.Spec.Replicated.RequireSafeReplicaSize = &requireSafeReplicaSize
+	err = ValidatePool(context, &p)
+	assert.Nil(t, err)
 	p.Spec.Replicated.Size = 1
 	err = ValidatePool(context, &p)
 	assert.Nil(t, err)
@@ -86,7 +102,7 @@ func TestValidateCrushProperties(t *testing.T) {
 	p := &cephv1.CephBlockPool{
 		ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"},
 		Spec: cephv1.PoolSpec{
-			Replicated:    cephv1.ReplicatedSpec{Size: 1},
+			Replicated:    cephv1.ReplicatedSpec{Size: 3},
 			FailureDomain: "osd",
 		},
 	}
@@ -110,6 +126,104 @@ func TestValidateCrushProperties(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestValidateErasureCode(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[1] == "crush" && args[2] == "dump" {
+			return `{"types":[{"type_id": 0,"name": "osd"},{"type_id": 1,"name": "host"},{"type_id": 10,"name": "root"}],
+				"buckets":[
+					{"id": -1,"name":"default","type_name":"root","items":[{"id":-2},{"id":-3},{"id":-4}]},
+					{"id": -2,"name":"node1","type_name":"host","items":[{"id":0},{"id":1}]},
+					{"id": -3,"name":"node2","type_name":"host","items":[{"id":2}]},
+					{"id": -4,"name":"node3","type_name":"host","items":[{"id":3}]},
+					{"id": -5,"name":"other","type_name":"root","items":[{"id":-6}]},
+					{"id": -6,"name":"node4","type_name":"host","items":[{"id":4}]}]}`, nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+
+	p := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"},
+		Spec: cephv1.PoolSpec{
+			FailureDomain: "host",
+			ErasureCoded:  cephv1.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1, Plugin: "jerasure", Technique: "reed_sol_van"},
+		},
+	}
+	assert.Nil(t, ValidatePool(context, p))
+
+	// not enough hosts for the chunks
+	p.Spec.ErasureCoded.CodingChunks = 2
+	assert.NotNil(t, ValidatePool(context, p))
+
+	// enough osds for the chunks
+	p.Spec.FailureDomain = "osd"
+	assert.Nil(t, ValidatePool(context, p))
+
+	// the hosts are only counted under the crush root
+	p.Spec.FailureDomain = "host"
+	p.Spec.CrushRoot = "other"
+	p.Spec.ErasureCoded.CodingChunks = 1
+	assert.NotNil(t, ValidatePool(context, p))
+	p.Spec.CrushRoot = ""
+
+	// unknown plugin or technique
+	p.Spec.ErasureCoded.Plugin = "foo"
+	assert.NotNil(t, ValidatePool(context, p))
+	p.Spec.ErasureCoded.Plugin = "isa"
+	p.Spec.ErasureCoded.Technique = "cauchy_good"
+	assert.NotNil(t, ValidatePool(context, p))
+	p.Spec.ErasureCoded.Technique = "cauchy"
+	assert.Nil(t, ValidatePool(context, p))
+	p.Spec.ErasureCoded.Plugin = ""
+	assert.NotNil(t, ValidatePool(context, p))
+	p.Spec.ErasureCoded.Plugin = "clay"
+	p.Spec.ErasureCoded.Technique = ""
+	assert.Nil(t, ValidatePool(context, p))
+
+	// not enough chunks
+	p.Spec.ErasureCoded.DataChunks = 1
+	assert.NotNil(t, ValidatePool(context, p))
+	p.Spec.ErasureCoded.DataChunks = 2
+	p.Spec.ErasureCoded.CodingChunks = 0
+	assert.NotNil(t, ValidatePool(context, p))
+}
+
+func TestValidateHybridStorage(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[1] == "crush" && args[2] == "dump" {
+			return `{"devices":[{"id":0,"name":"osd.0","class":"ssd"},{"id":1,"name":"osd.1","class":"hdd"}]}`, nil
+		}
+		return "", fmt.Errorf("unexpected ceph command '%v'", args)
+	}
+
+	p := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"},
+		Spec: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3,
+			HybridStorage: &cephv1.HybridStorageSpec{PrimaryDeviceClass: "ssd", SecondaryDeviceClass: "hdd"}}},
+	}
+	assert.Nil(t, ValidatePool(context, p))
+
+	// unknown device class
+	p.Spec.Replicated.HybridStorage.SecondaryDeviceClass = "nvme"
+	assert.NotNil(t, ValidatePool(context, p))
+
+	// the same device class
+	p.Spec.Replicated.HybridStorage.SecondaryDeviceClass = "ssd"
+	assert.NotNil(t, ValidatePool(context, p))
+
+	// missing device class
+	p.Spec.Replicated.HybridStorage.SecondaryDeviceClass = ""
+	assert.NotNil(t, ValidatePool(context, p))
+
+	// not with a crush rule
+	p.Spec.Replicated.HybridStorage.SecondaryDeviceClass = "hdd"
+	p.Spec.CrushRule = "myrule"
+	assert.NotNil(t, ValidatePool(context, p))
+}
+
 func TestCreatePool(t *testing.T) {
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outfile string, args ...string) (string, error) {
@@ -122,7 +236,7 @@ func TestCreatePool(t *testing.T) {
 	context := &clusterd.Context{Executor: executor}
 
 	p := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
-	p.Spec.Replicated.Size = 1
+	p.Spec.Replicated.Size = 3
 
 	exists, err := poolExists(context, p)
 	assert.False(t, exists)
@@ -153,6 +267,89 @@ func TestUpdatePool(t *testing.T) {
 	new = cephv1.PoolSpec{FailureDomain: "osd", Replicated: cephv1.ReplicatedSpec{Size: 2}}
 	changed = poolChanged(old, new)
 	assert.True(t, changed)
+
+	// the pool changed when the hybrid storage changed
+	old = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}}
+	new = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3,
+		HybridStorage: &cephv1.HybridStorageSpec{PrimaryDeviceClass: "ssd", SecondaryDeviceClass: "hdd"}}}
+	assert.True(t, poolChanged(old, new))
+	assert.False(t, poolChanged(new, *new.DeepCopy()))
+	assert.True(t, usesCustomCrushRule(new))
+	assert.False(t, usesCustomCrushRule(old))
+}
+
+func TestValidatePoolUpdate(t *testing.T) {
+	old := cephv1.PoolSpec{FailureDomain: "host", ErasureCoded: cephv1.ErasureCodedSpec{CodingChunks: 1, DataChunks: 2}}
+
+	// the crush rule can be changed
+	new := old
+	new.CrushRule = "myrule"
+	assert.Nil(t, validatePoolUpdate(old, new))
+
+	// the erasure code profile cannot be changed
+	new = old
+	new.ErasureCoded.CodingChunks = 2
+	assert.NotNil(t, validatePoolUpdate(old, new))
+	new = old
+	new.ErasureCoded.Plugin = "isa"
+	assert.NotNil(t, validatePoolUpdate(old, new))
+	new = old
+	new.FailureDomain = "osd"
+	assert.NotNil(t, validatePoolUpdate(old, new))
+
+	// the pool type cannot be changed
+	new = cephv1.PoolSpec{FailureDomain: "host", Replicated: cephv1.ReplicatedSpec{Size: 3}}
+	assert.NotNil(t, validatePoolUpdate(old, new))
+
+	// the failure domain of a replicated pool can be changed
+	old = new
+	new.FailureDomain = "osd"
+	assert.Nil(t, validatePoolUpdate(old, new))
+}
+
+func TestPoolStatus(t *testing.T) {
+	// no condition for a safe pool
+	status := poolStatus(cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}})
+	assert.Equal(t, 0, len(status.Conditions))
+	status = poolStatus(cephv1.PoolSpec{ErasureCoded: cephv1.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}})
+	assert.Equal(t, 0, len(status.Conditions))
+
+	// warn about a pool with less than 3 replicas
+	status = poolStatus(cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 2}})
+	assert.Equal(t, 1, len(status.Conditions))
+	assert.Equal(t, cephv1.PoolConditionUnsafeReplicaSize, status.Conditions[0].Type)
+	assert.Equal(t, v1.ConditionTrue, status.Conditions[0].Status)
+}
+
+func TestUpdatePoolStatus(t *testing.T) {
+	requireSafeReplicaSize := false
+	p := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"},
+		Spec:       cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1, RequireSafeReplicaSize: &requireSafeReplicaSize}},
+	}
+	context := &clusterd.Context{RookClientset: rookfake.NewSimpleClientset(p)}
+	c := NewPoolController(context, cephv1.CephVersionSpec{})
+
+	c.updatePoolStatus(p, nil)
+	pool, err := context.RookClientset.CephV1().CephBlockPools("myns").Get("mypool", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pool.Status.Conditions))
+
+	// the condition is removed when the size is safe
+	pool.Spec.Replicated.Size = 3
+	c.updatePoolStatus(pool, nil)
+	pool, err = context.RookClientset.CephV1().CephBlockPools("myns").Get("mypool", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(pool.Status.Conditions))
+
+	// the pool blocked by the capacity guardrail reports why
+	pool.Spec.Replicated.Size = 3
+	c.updatePoolStatus(pool, fmt.Errorf("creating pool mypool would raise the raw utilization"))
+	pool, err = context.RookClientset.CephV1().CephBlockPools("myns").Get("mypool", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pool.Status.Conditions))
+	assert.Equal(t, cephv1.PoolConditionCapacityExceeded, pool.Status.Conditions[0].Type)
+	assert.Contains(t, pool.Status.Conditions[0].Message, "raw utilization")
 }
 
 func TestDeletePool(t *testing.T) {
@@ -220,7 +417,7 @@ func TestMigratePoolObject(t *testing.T) {
 		Clientset:     clientset,
 		RookClientset: rookfake.NewSimpleClientset(legacyPool),
 	}
-	controller := NewPoolController(context)
+	controller := NewPoolController(context, cephv1.CephVersionSpec{})
 
 	// convert the legacy pool object in memory and assert that a migration is needed
 	convertedPool, migrationNeeded, err := getPoolObject(legacyPool)
@@ -281,3 +478,50 @@ func TestConvertLegacyPool(t *testing.T) {
 
 	assert.Equal(t, expectedPool, *convertRookLegacyPool(&legacyPool))
 }
+
+func TestConvertLegacyPoolUnsafeSize(t *testing.T) {
+	// the legacy pools with less than 3 replicas keep being allowed
+	spec := ConvertRookLegacyPoolSpec(cephbeta.PoolSpec{Replicated: cephbeta.ReplicatedSpec{Size: 2}})
+	assert.False(t, spec.Replicated.RequiresSafeReplicaSize())
+
+	spec = ConvertRookLegacyPoolSpec(cephbeta.PoolSpec{Replicated: cephbeta.ReplicatedSpec{Size: 3}})
+	assert.True(t, spec.Replicated.RequiresSafeReplicaSize())
+	assert.Nil(t, spec.Replicated.RequireSafeReplicaSize)
+}
+
+func TestResetCrushRule(t *testing.T) {
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "pool" && args[2] == "set" {
+				commands = append(commands, "set "+args[4]+" "+args[5])
+			}
+			if args[0] == "osd" && args[1] == "crush" && args[2] == "rule" && args[3] == "rm" {
+				commands = append(commands, "rm "+args[4])
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	hybrid := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"},
+		Spec: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3,
+			HybridStorage: &cephv1.HybridStorageSpec{PrimaryDeviceClass: "ssd", SecondaryDeviceClass: "hdd"}}}}
+	plain := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"},
+		Spec: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}}}
+	custom := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"},
+		Spec: cephv1.PoolSpec{CrushRule: "myrule", Replicated: cephv1.ReplicatedSpec{Size: 3}}}
+
+	// the hybrid rule is deleted once the pool is back on its own rule
+	assert.Nil(t, resetCrushRule(context, hybrid, plain))
+	assert.Equal(t, []string{"set crush_rule mypool", "rm mypool_hybrid"}, commands)
+
+	// the pool already moved to the custom rule
+	commands = nil
+	assert.Nil(t, resetCrushRule(context, hybrid, custom))
+	assert.Equal(t, []string{"rm mypool_hybrid"}, commands)
+
+	// nothing to reset
+	commands = nil
+	assert.Nil(t, resetCrushRule(context, plain, hybrid))
+	assert.Equal(t, 0, len(commands))
+}
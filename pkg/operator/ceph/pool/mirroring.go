@@ -0,0 +1,97 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"fmt"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PeerTokenKey is the key of the bootstrap token in the secrets of the mirroring peers
+const PeerTokenKey = "token"
+
+// validatePoolMirroring checks the mirroring mode of the rbd pools, and that the ceph version of the cluster supports
+// the bootstrap tokens of the peers
+func validatePoolMirroring(spec cephv1.PoolSpec, cephVersion cephv1.CephVersionSpec) error {
+	if spec.Mirroring == nil {
+		return nil
+	}
+	switch spec.Mirroring.Mode {
+	case "", ceph.MirroringModeImage, ceph.MirroringModePool:
+	default:
+		return fmt.Errorf("invalid mirroring mode %q. must be %s or %s", spec.Mirroring.Mode, ceph.MirroringModeImage, ceph.MirroringModePool)
+	}
+	if len(spec.Mirroring.Peers.SecretNames) > 0 && !cephv1.VersionAtLeast(cephVersion.Name, cephv1.Octopus) {
+		return fmt.Errorf("the bootstrap tokens of the mirroring peers require octopus")
+	}
+	return nil
+}
+
+// PeerBootstrapTokens returns the bootstrap tokens of the peer clusters, read from their secrets
+func PeerBootstrapTokens(context *clusterd.Context, namespace string, peers cephv1.MirroringPeerSpec) ([]string, error) {
+	var tokens []string
+	for _, name := range peers.SecretNames {
+		secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the peer secret %s. %+v", name, err)
+		}
+		token, ok := secret.Data[PeerTokenKey]
+		if !ok || len(token) == 0 {
+			return nil, fmt.Errorf("the peer secret %s has no %s", name, PeerTokenKey)
+		}
+		tokens = append(tokens, string(token))
+	}
+	return tokens, nil
+}
+
+// setPoolMirroring enables the mirroring of the images of a pool and imports the bootstrap tokens of its peers. The
+// mirroring is not disabled and the peers are not removed when they are removed from the spec.
+func setPoolMirroring(context *clusterd.Context, p *cephv1.CephBlockPool) error {
+	mode := p.Spec.Mirroring.Mode
+	if mode == "" {
+		mode = ceph.MirroringModeImage
+	}
+	tokens, err := PeerBootstrapTokens(context, p.Namespace, p.Spec.Mirroring.Peers)
+	if err != nil {
+		return err
+	}
+
+	info, err := ceph.GetPoolMirroringInfo(context, p.Namespace, p.Name)
+	if err != nil {
+		return err
+	}
+	if info.Mode != mode {
+		logger.Infof("enabling the %s mirroring of pool %s", mode, p.Name)
+		if err := ceph.EnablePoolMirroring(context, p.Namespace, p.Name, mode); err != nil {
+			return err
+		}
+	}
+
+	for i, token := range tokens {
+		if err := ceph.ImportPoolPeerBootstrapToken(context, p.Namespace, p.Name, token); err != nil {
+			return fmt.Errorf("failed to import the peer of secret %s. %+v", p.Spec.Mirroring.Peers.SecretNames[i], err)
+		}
+	}
+	if len(tokens) > 0 {
+		logger.Infof("imported %d mirroring peers of pool %s", len(tokens), p.Name)
+	}
+	return nil
+}
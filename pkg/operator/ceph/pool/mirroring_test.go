@@ -0,0 +1,109 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidatePoolMirroring(t *testing.T) {
+	nautilus := cephv1.CephVersionSpec{Name: cephv1.Nautilus}
+	octopus := cephv1.CephVersionSpec{Name: cephv1.Octopus}
+	spec := cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}}
+	assert.Nil(t, validatePoolMirroring(spec, nautilus))
+
+	spec.Mirroring = &cephv1.MirroringSpec{}
+	assert.Nil(t, validatePoolMirroring(spec, nautilus))
+	spec.Mirroring.Mode = "pool"
+	assert.Nil(t, validatePoolMirroring(spec, nautilus))
+	spec.Mirroring.Mode = "journal"
+	assert.NotNil(t, validatePoolMirroring(spec, nautilus))
+
+	// the bootstrap tokens of the peers require octopus
+	spec.Mirroring.Mode = "image"
+	spec.Mirroring.Peers.SecretNames = []string{"site-b"}
+	assert.NotNil(t, validatePoolMirroring(spec, nautilus))
+	assert.Nil(t, validatePoolMirroring(spec, octopus))
+}
+
+func TestSetPoolMirroring(t *testing.T) {
+	mode := "disabled"
+	enabled := ""
+	imported := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName, command string, args ...string) (string, error) {
+			assert.Equal(t, "rbd", command)
+			switch args[2] {
+			case "info":
+				assert.Equal(t, "mypool", args[3])
+				return `{"mode":"` + mode + `","peers":[]}`, nil
+			case "enable":
+				assert.Equal(t, "mypool", args[3])
+				enabled = args[4]
+				mode = args[4]
+			case "peer":
+				assert.Equal(t, "import", args[4])
+				assert.Equal(t, "mypool", args[5])
+				imported++
+			}
+			return "", nil
+		},
+	}
+	clientset := testop.New(1)
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+	p := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"},
+		Spec:       cephv1.PoolSpec{Mirroring: &cephv1.MirroringSpec{Peers: cephv1.MirroringPeerSpec{SecretNames: []string{"site-b"}}}},
+	}
+
+	// the secret of the peer is missing
+	assert.NotNil(t, setPoolMirroring(context, p))
+	assert.Equal(t, "", enabled)
+
+	// the secret has no token
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "site-b", Namespace: "myns"}, Data: map[string][]byte{}}
+	_, err := clientset.CoreV1().Secrets("myns").Create(secret)
+	assert.Nil(t, err)
+	assert.NotNil(t, setPoolMirroring(context, p))
+
+	// the mirroring is enabled in the image mode by default and the peer is imported
+	secret.Data[PeerTokenKey] = []byte("eyJmc2lkIjoiYWJjIn0=")
+	_, err = clientset.CoreV1().Secrets("myns").Update(secret)
+	assert.Nil(t, err)
+	assert.Nil(t, setPoolMirroring(context, p))
+	assert.Equal(t, "image", enabled)
+	assert.Equal(t, 1, imported)
+
+	// the mirroring is not enabled again in the same mode
+	enabled = ""
+	assert.Nil(t, setPoolMirroring(context, p))
+	assert.Equal(t, "", enabled)
+	assert.Equal(t, 2, imported)
+
+	// the mode is changed
+	p.Spec.Mirroring.Mode = "pool"
+	assert.Nil(t, setPoolMirroring(context, p))
+	assert.Equal(t, "pool", enabled)
+}
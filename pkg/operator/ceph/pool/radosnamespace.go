@@ -0,0 +1,244 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"fmt"
+	"reflect"
+
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	radosNamespaceAppName = "rook-ceph-rados-namespace"
+	// the key of the user in the secret, which has a single key to be a mountSecret of the storage classes
+	radosNamespaceSecretKey = "key"
+)
+
+// RadosNamespaceResource represents the RADOS namespace custom resource object
+var RadosNamespaceResource = opkit.CustomResource{
+	Name:    "cephblockpoolradosnamespace",
+	Plural:  "cephblockpoolradosnamespaces",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephBlockPoolRadosNamespace{}).Name(),
+}
+
+// RadosNamespaceController represents a controller object for the RADOS namespace custom resources, which isolate
+// the images of the tenants sharing a pool
+type RadosNamespaceController struct {
+	context     *clusterd.Context
+	cephVersion cephv1.CephVersionSpec
+	ownerRef    metav1.OwnerReference
+}
+
+// NewRadosNamespaceController create controller for watching RADOS namespace custom resources created
+func NewRadosNamespaceController(context *clusterd.Context, cephVersion cephv1.CephVersionSpec, ownerRef metav1.OwnerReference) *RadosNamespaceController {
+	return &RadosNamespaceController{
+		context:     context,
+		cephVersion: cephVersion,
+		ownerRef:    ownerRef,
+	}
+}
+
+// StartWatch watches for instances of RADOS namespace custom resources and acts on them
+func (c *RadosNamespaceController) StartWatch(namespace string, stopCh chan struct{}) error {
+	// the resources are not reconciled while their cluster is in maintenance mode
+	resourceHandlerFuncs := maintenance.PausableHandlers(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	})
+
+	logger.Infof("start watching rados namespace resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(RadosNamespaceResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephBlockPoolRadosNamespace{}, stopCh)
+
+	return nil
+}
+
+func (c *RadosNamespaceController) onAdd(obj interface{}) {
+	ns, err := getRadosNamespaceObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get rados namespace object: %+v", err)
+		return
+	}
+
+	status := &cephv1.RadosNamespaceStatus{Phase: cephv1.RadosNamespacePhaseReady}
+	if err := c.createRadosNamespace(ns); err != nil {
+		logger.Errorf("failed to create rados namespace %s. %+v", ns.Name, err)
+		status = &cephv1.RadosNamespaceStatus{Phase: cephv1.RadosNamespacePhaseFailed, Message: err.Error()}
+	} else {
+		status.User = ceph.RadosNamespaceUser(ns.Spec.BlockPoolName, ns.Name)
+		status.SecretName = RadosNamespaceSecretName(ns.Name)
+	}
+	if err := c.updateStatus(ns, status); err != nil {
+		logger.Errorf("failed to update the status of rados namespace %s. %+v", ns.Name, err)
+	}
+}
+
+func (c *RadosNamespaceController) onUpdate(oldObj, newObj interface{}) {
+	oldNs, err := getRadosNamespaceObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old rados namespace object: %+v", err)
+		return
+	}
+	ns, err := getRadosNamespaceObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new rados namespace object: %+v", err)
+		return
+	}
+
+	if reflect.DeepEqual(oldNs.Spec, ns.Spec) {
+		// the status was updated
+		return
+	}
+	if ns.Status == nil || ns.Status.Phase != cephv1.RadosNamespacePhaseReady {
+		// retry with the fixed pool
+		c.onAdd(ns)
+		return
+	}
+	// the images would be left behind in the namespace of the previous pool
+	logger.Errorf("the pool of rados namespace %s cannot be changed from %s to %s", ns.Name, oldNs.Spec.BlockPoolName, ns.Spec.BlockPoolName)
+}
+
+func (c *RadosNamespaceController) onDelete(obj interface{}) {
+	ns, err := getRadosNamespaceObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get rados namespace object: %+v", err)
+		return
+	}
+
+	if err := c.deleteRadosNamespace(ns); err != nil {
+		logger.Errorf("failed to delete rados namespace %s. %+v", ns.Name, err)
+	}
+}
+
+// createRadosNamespace creates the namespace in the pool with the user restricted to it, whose key is stored in a secret
+func (c *RadosNamespaceController) createRadosNamespace(ns *cephv1.CephBlockPoolRadosNamespace) error {
+	if err := ValidateRadosNamespace(c.context, ns, c.cephVersion); err != nil {
+		return fmt.Errorf("invalid rados namespace %s. %+v", ns.Name, err)
+	}
+
+	pool := ns.Spec.BlockPoolName
+	logger.Infof("creating rados namespace %s in pool %s", ns.Name, pool)
+	if err := ceph.CreateRadosNamespace(c.context, ns.Namespace, pool, ns.Name); err != nil {
+		return err
+	}
+
+	user := ceph.RadosNamespaceUser(pool, ns.Name)
+	key, err := ceph.AuthGetOrCreateKey(c.context, ns.Namespace, "client."+user, ceph.RadosNamespaceCaps(pool, ns.Name))
+	if err != nil {
+		return fmt.Errorf("failed to create user %s. %+v", user, err)
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RadosNamespaceSecretName(ns.Name),
+			Namespace: ns.Namespace,
+			Labels: map[string]string{
+				k8sutil.AppAttr:     radosNamespaceAppName,
+				k8sutil.ClusterAttr: ns.Namespace,
+				"rados_namespace":   ns.Name,
+				"pool":              pool,
+			},
+		},
+		StringData: map[string]string{radosNamespaceSecretKey: key},
+		Type:       k8sutil.RookType,
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, ns.Namespace, &secret.ObjectMeta, &c.ownerRef)
+	if _, err := c.context.Clientset.CoreV1().Secrets(ns.Namespace).Create(secret); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to save the key of user %s. %+v", user, err)
+		}
+		if _, err := c.context.Clientset.CoreV1().Secrets(ns.Namespace).Update(secret); err != nil {
+			return fmt.Errorf("failed to update the key of user %s. %+v", user, err)
+		}
+	}
+
+	logger.Infof("created rados namespace %s in pool %s with user %s", ns.Name, pool, user)
+	return nil
+}
+
+// deleteRadosNamespace removes the namespace from the pool. The user and its secret are kept while the namespace has
+// images, since they are still needed to unmap them.
+func (c *RadosNamespaceController) deleteRadosNamespace(ns *cephv1.CephBlockPoolRadosNamespace) error {
+	pool := ns.Spec.BlockPoolName
+	if err := ceph.DeleteRadosNamespace(c.context, ns.Namespace, pool, ns.Name); err != nil {
+		return err
+	}
+
+	user := ceph.RadosNamespaceUser(pool, ns.Name)
+	if err := ceph.AuthDelete(c.context, ns.Namespace, "client."+user); err != nil {
+		logger.Warningf("failed to delete user %s. %+v", user, err)
+	}
+	err := c.context.Clientset.CoreV1().Secrets(ns.Namespace).Delete(RadosNamespaceSecretName(ns.Name), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		logger.Warningf("failed to delete the secret of rados namespace %s. %+v", ns.Name, err)
+	}
+
+	logger.Infof("deleted rados namespace %s from pool %s", ns.Name, pool)
+	return nil
+}
+
+func (c *RadosNamespaceController) updateStatus(ns *cephv1.CephBlockPoolRadosNamespace, status *cephv1.RadosNamespaceStatus) error {
+	latest, err := c.context.RookClientset.CephV1().CephBlockPoolRadosNamespaces(ns.Namespace).Get(ns.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	latest.Status = status
+	_, err = c.context.RookClientset.CephV1().CephBlockPoolRadosNamespaces(ns.Namespace).Update(latest)
+	return err
+}
+
+// ValidateRadosNamespace checks that the namespace is created in an existing pool of a cluster supporting them
+func ValidateRadosNamespace(context *clusterd.Context, ns *cephv1.CephBlockPoolRadosNamespace, cephVersion cephv1.CephVersionSpec) error {
+	if !cephv1.VersionAtLeast(cephVersion.Name, cephv1.Nautilus) {
+		return fmt.Errorf("rados namespaces of the block pools require nautilus")
+	}
+	if ns.Spec.BlockPoolName == "" {
+		return fmt.Errorf("missing blockPoolName")
+	}
+	if _, err := context.RookClientset.CephV1().CephBlockPools(ns.Namespace).Get(ns.Spec.BlockPoolName, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("failed to get pool %s. %+v", ns.Spec.BlockPoolName, err)
+	}
+	return nil
+}
+
+// RadosNamespaceSecretName returns the name of the secret with the key of the user of a RADOS namespace
+func RadosNamespaceSecretName(name string) string {
+	return fmt.Sprintf("%s-%s", radosNamespaceAppName, name)
+}
+
+func getRadosNamespaceObject(obj interface{}) (*cephv1.CephBlockPoolRadosNamespace, error) {
+	ns, ok := obj.(*cephv1.CephBlockPoolRadosNamespace)
+	if ok {
+		return ns.DeepCopy(), nil
+	}
+	return nil, fmt.Errorf("not a known rados namespace object: %+v", obj)
+}
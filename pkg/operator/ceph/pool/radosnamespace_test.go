@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRadosNamespace(t *testing.T) {
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "rook-ceph"}}
+	ns := &cephv1.CephBlockPoolRadosNamespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Namespace: "rook-ceph"},
+		Spec:       cephv1.RadosNamespaceSpec{BlockPoolName: "replicapool"},
+	}
+	var rbdArgs [][]string
+	var caps []string
+	authDeleted := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			rbdArgs = append(rbdArgs, args[:6])
+			return "", nil
+		},
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outFileArg string, args ...string) (string, error) {
+			if args[0] == "auth" && args[1] == "get-or-create-key" {
+				assert.Equal(t, "client.replicapool.tenant-a", args[2])
+				caps = args[3:7]
+				return `{"key":"mykey"}`, nil
+			}
+			if args[0] == "auth" && args[1] == "del" {
+				authDeleted = true
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Executor:      executor,
+		Clientset:     testop.New(1),
+		RookClientset: rookfake.NewSimpleClientset(pool, ns),
+	}
+	c := NewRadosNamespaceController(context, cephv1.CephVersionSpec{Name: cephv1.Nautilus}, metav1.OwnerReference{})
+
+	c.onAdd(ns)
+	assert.Equal(t, []string{"namespace", "create", "--pool", "replicapool", "--namespace", "tenant-a"}, rbdArgs[0])
+	assert.Equal(t, []string{"mon", "profile rbd", "osd", "profile rbd pool=replicapool namespace=tenant-a"}, caps)
+	secret, err := context.Clientset.CoreV1().Secrets("rook-ceph").Get("rook-ceph-rados-namespace-tenant-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"key": "mykey"}, secret.StringData)
+	updated, err := context.RookClientset.CephV1().CephBlockPoolRadosNamespaces("rook-ceph").Get("tenant-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, &cephv1.RadosNamespaceStatus{
+		Phase:      cephv1.RadosNamespacePhaseReady,
+		User:       "replicapool.tenant-a",
+		SecretName: "rook-ceph-rados-namespace-tenant-a",
+	}, updated.Status)
+
+	c.onDelete(ns)
+	assert.Equal(t, []string{"namespace", "remove", "--pool", "replicapool", "--namespace", "tenant-a"}, rbdArgs[1])
+	assert.True(t, authDeleted)
+	_, err = context.Clientset.CoreV1().Secrets("rook-ceph").Get("rook-ceph-rados-namespace-tenant-a", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestRadosNamespaceFailed(t *testing.T) {
+	ns := &cephv1.CephBlockPoolRadosNamespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Namespace: "rook-ceph"},
+		Spec:       cephv1.RadosNamespaceSpec{BlockPoolName: "missing"},
+	}
+	context := &clusterd.Context{
+		Executor:      &exectest.MockExecutor{},
+		Clientset:     testop.New(1),
+		RookClientset: rookfake.NewSimpleClientset(ns),
+	}
+	c := NewRadosNamespaceController(context, cephv1.CephVersionSpec{Name: cephv1.Nautilus}, metav1.OwnerReference{})
+
+	c.onAdd(ns)
+	updated, err := context.RookClientset.CephV1().CephBlockPoolRadosNamespaces("rook-ceph").Get("tenant-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.RadosNamespacePhaseFailed, updated.Status.Phase)
+	assert.Contains(t, updated.Status.Message, "failed to get pool missing")
+}
+
+func TestValidateRadosNamespace(t *testing.T) {
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "rook-ceph"}}
+	context := &clusterd.Context{RookClientset: rookfake.NewSimpleClientset(pool)}
+	ns := &cephv1.CephBlockPoolRadosNamespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Namespace: "rook-ceph"},
+		Spec:       cephv1.RadosNamespaceSpec{BlockPoolName: "replicapool"},
+	}
+	assert.Nil(t, ValidateRadosNamespace(context, ns, cephv1.CephVersionSpec{Name: cephv1.Nautilus}))
+	assert.NotNil(t, ValidateRadosNamespace(context, ns, cephv1.CephVersionSpec{Name: cephv1.Mimic}))
+
+	ns.Spec.BlockPoolName = ""
+	assert.NotNil(t, ValidateRadosNamespace(context, ns, cephv1.CephVersionSpec{Name: cephv1.Nautilus}))
+	ns.Spec.BlockPoolName = "other"
+	assert.NotNil(t, ValidateRadosNamespace(context, ns, cephv1.CephVersionSpec{Name: cephv1.Nautilus}))
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rook/rook/pkg/daemon/ceph/agent/capabilities"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+)
+
+// legacyImageFeatures are the image features the kernel rbd module maps since kernel 4.9. The features mapped by
+// more recent kernels are only enabled when the capabilities of the nodes are known.
+var legacyImageFeatures = map[string]bool{"layering": true, "striping": true, "exclusive-lock": true}
+
+// compatibleImageFeatures returns the requested image features that the kernel of all the nodes can map. The features
+// a node cannot map are dropped with the features depending on them, rather than failing to map the image later. The
+// requested features are returned unchanged when all the nodes can map them, and when no feature is requested the
+// images get the default features of the rook config, layering and striping, which all the kernels map. When no node
+// published its capabilities, only the requested features mapped by the older kernels are kept. An error naming the
+// nodes is returned when they cannot map any of the requested features.
+func compatibleImageFeatures(requested []string, nodes map[string]*capabilities.NodeCapabilities) ([]string, error) {
+	if len(requested) == 0 {
+		return requested, nil
+	}
+	if len(nodes) == 0 {
+		return filterImageFeatures(requested, func(feature string) bool {
+			if !legacyImageFeatures[feature] {
+				logger.Warningf("image feature %s is not enabled since the kernels of the nodes are unknown and older kernels cannot map it", feature)
+				return false
+			}
+			return true
+		}), nil
+	}
+
+	incompatible := map[string]bool{}
+	result := filterImageFeatures(requested, func(feature string) bool {
+		compatible := true
+		for name, node := range nodes {
+			if !node.SupportsRBDFeature(feature) {
+				logger.Warningf("image feature %s is not enabled since node %s with kernel %s cannot map it", feature, name, node.KernelVersion)
+				incompatible[fmt.Sprintf("%s (kernel %s)", name, node.KernelVersion)] = true
+				compatible = false
+			}
+		}
+		return compatible
+	})
+	if len(result) == 0 {
+		var names []string
+		for name := range incompatible {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("nodes %s cannot map the image features %s", strings.Join(names, ", "), strings.Join(requested, ","))
+	}
+	if len(result) == len(requested) {
+		return requested, nil
+	}
+	return result, nil
+}
+
+// filterImageFeatures returns the features that can be mapped, without the features depending on a dropped feature
+func filterImageFeatures(features []string, canMap func(feature string) bool) []string {
+	compatible := map[string]bool{}
+	for _, feature := range features {
+		compatible[feature] = canMap(feature)
+	}
+	for changed := true; changed; {
+		changed = false
+		for feature, dependency := range imageFeatureDependencies {
+			if compatible[feature] && !compatible[dependency] {
+				logger.Warningf("image feature %s is not enabled since it requires image feature %s", feature, dependency)
+				compatible[feature] = false
+				changed = true
+			}
+		}
+	}
+
+	result := []string{}
+	for _, feature := range features {
+		if compatible[feature] {
+			result = append(result, feature)
+		}
+	}
+	return result
+}
+
+// filesystemMounter returns the client mounting the filesystem volumes. ceph-fuse is only used when it is requested:
+// it runs in the agent and its mounts are lost when the agent restarts. An error naming the nodes which published
+// capabilities without the requested client is returned, so the provisioning fails instead of the mount.
+func filesystemMounter(requested string, nodes map[string]*capabilities.NodeCapabilities) (string, error) {
+	var incompatible []string
+	for name, node := range nodes {
+		if requested == flexvolume.MounterFuse && !node.CephFSFuse {
+			incompatible = append(incompatible, name)
+		} else if requested != flexvolume.MounterFuse && !node.CephFSKernel {
+			if node.KernelVersion != "" {
+				name = fmt.Sprintf("%s (kernel %s)", name, node.KernelVersion)
+			}
+			incompatible = append(incompatible, name)
+		}
+	}
+	if len(incompatible) > 0 {
+		sort.Strings(incompatible)
+		if requested == flexvolume.MounterFuse {
+			return "", fmt.Errorf("nodes %s cannot mount the filesystem volume with ceph-fuse", strings.Join(incompatible, ", "))
+		}
+		return "", fmt.Errorf("nodes %s cannot mount the filesystem volume with the kernel client. set the mounter %s in the storage class to mount it with ceph-fuse",
+			strings.Join(incompatible, ", "), flexvolume.MounterFuse)
+	}
+	return requested, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/capabilities"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCompatibleImageFeatures(t *testing.T) {
+	modern := &capabilities.NodeCapabilities{KernelVersion: "5.4.0", RBDFeatures: []string{"layering", "exclusive-lock", "object-map", "fast-diff", "deep-flatten"}}
+	old := &capabilities.NodeCapabilities{KernelVersion: "4.9.0", RBDFeatures: []string{"layering", "striping", "exclusive-lock"}}
+	ancient := &capabilities.NodeCapabilities{KernelVersion: "4.4.0", RBDFeatures: []string{"layering", "striping"}}
+
+	features := func(requested []string, nodes map[string]*capabilities.NodeCapabilities) []string {
+		result, err := compatibleImageFeatures(requested, nodes)
+		assert.Nil(t, err)
+		return result
+	}
+
+	// unchanged when all the nodes support the features
+	assert.Nil(t, features(nil, nil))
+	assert.Equal(t, []string{"layering", "exclusive-lock", "object-map", "fast-diff"}, features([]string{"layering", "exclusive-lock", "object-map", "fast-diff"},
+		map[string]*capabilities.NodeCapabilities{"node1": modern}))
+
+	// the default features of the rook config are mapped by all the kernels
+	assert.Nil(t, features(nil, map[string]*capabilities.NodeCapabilities{"node1": modern, "node2": ancient}))
+
+	// the requested features are restricted to the features of the oldest kernel
+	nodes := map[string]*capabilities.NodeCapabilities{"node1": modern, "node2": old}
+	requested := []string{"layering", "exclusive-lock", "object-map", "fast-diff"}
+	assert.Equal(t, []string{"layering", "exclusive-lock"}, features(requested, nodes))
+	nodes["node3"] = ancient
+	assert.Equal(t, []string{"layering"}, features(requested, nodes))
+
+	// the features depending on a dropped feature are dropped
+	assert.Equal(t, []string{"layering", "striping"}, features([]string{"layering", "striping", "exclusive-lock", "object-map"},
+		map[string]*capabilities.NodeCapabilities{"node1": ancient}))
+
+	// the provisioning fails when no requested feature can be mapped
+	_, err := compatibleImageFeatures([]string{"exclusive-lock", "object-map"}, map[string]*capabilities.NodeCapabilities{"node1": modern, "node2": ancient})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "nodes node2 (kernel 4.4.0) cannot map")
+
+	// without capabilities, the features mapped by the recent kernels only are dropped
+	assert.Equal(t, []string{"layering", "exclusive-lock"}, features([]string{"layering", "exclusive-lock", "object-map", "fast-diff"}, nil))
+	assert.Equal(t, []string{}, features([]string{"deep-flatten"}, nil))
+}
+
+func TestFilesystemMounter(t *testing.T) {
+	kernel := &capabilities.NodeCapabilities{CephFSKernel: true, CephFSFuse: true}
+	fuse := &capabilities.NodeCapabilities{CephFSFuse: true}
+	none := &capabilities.NodeCapabilities{}
+
+	mounter, err := filesystemMounter("", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "", mounter)
+	mounter, err = filesystemMounter("fuse", map[string]*capabilities.NodeCapabilities{"node1": kernel, "node2": fuse})
+	assert.Nil(t, err)
+	assert.Equal(t, "fuse", mounter)
+
+	// the provisioning fails with the names of the nodes which cannot mount the volume
+	_, err = filesystemMounter("", map[string]*capabilities.NodeCapabilities{"node1": kernel, "node2": fuse, "node3": none})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "nodes node2, node3 cannot")
+	_, err = filesystemMounter("kernel", map[string]*capabilities.NodeCapabilities{"node1": fuse})
+	assert.NotNil(t, err)
+	_, err = filesystemMounter("fuse", map[string]*capabilities.NodeCapabilities{"node1": kernel, "node2": none})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "nodes node2 cannot")
+}
+
+func TestProvisionWithNodeCapabilities(t *testing.T) {
+	configDir, _ := ioutil.TempDir("", "")
+	os.Setenv("POD_NAMESPACE", "rook-system")
+	defer os.Setenv("POD_NAMESPACE", "")
+	defer os.RemoveAll(configDir)
+	var createArgs []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if command == "rbd" && args[0] == "create" {
+				createArgs = args
+			}
+			if command == "rbd" && args[0] == "info" {
+				return `{"name":"pvc-uid-1-1","size":1048576,"format":2}`, nil
+			}
+			return "", nil
+		},
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "versions" {
+				return `{"mgr":{"ceph version 14.2.5 (ad5bd132e1492173c85fda2cc863152730b16a92) nautilus (stable)":1}}`, nil
+			}
+			if args[2] == "getpath" {
+				return "/volumes/_nogroup/pvc-uid-1-1", nil
+			}
+			return "", nil
+		},
+	}
+	clientset := test.New(3)
+	node, err := clientset.CoreV1().Nodes().Get("node0", metav1.GetOptions{})
+	assert.Nil(t, err)
+	node.Annotations = map[string]string{capabilities.Annotation: `{"kernelVersion":"4.9.0","rbdFeatures":["layering","striping","exclusive-lock"],"cephfsKernel":false,"cephfsFuse":true}`}
+	_, err = clientset.CoreV1().Nodes().Update(node)
+	assert.Nil(t, err)
+	context := &clusterd.Context{Clientset: clientset, Executor: executor, ConfigDir: configDir}
+	p := New(context, "foo.io")
+
+	// the image is created with the requested features the kernel of the node can map
+	volume := newVolumeOptions(newStorageClass("class-1", "foo.io/block", map[string]string{"blockPool": "testpool", "clusterNamespace": "testCluster", "imageFeatures": "layering,exclusive-lock,object-map"}, v1.PersistentVolumeReclaimDelete), newClaim("claim-1", "uid-1-1", "class-1", "", "class-1", nil), v1.PersistentVolumeReclaimDelete)
+	_, err = p.Provision(volume)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"create", "testpool/pvc-uid-1-1", "--size", "1", "--image-feature", "layering", "--image-feature", "exclusive-lock"}, createArgs[:8])
+
+	// the provisioning fails when the kernel of the node cannot mount the subvolume
+	volume = newVolumeOptions(newStorageClass("class-2", "foo.io/block", map[string]string{"fsName": "myfs", "clusterNamespace": "testCluster"}, v1.PersistentVolumeReclaimDelete), newClaim("claim-2", "uid-1-1", "class-2", "", "class-2", nil), v1.PersistentVolumeReclaimDelete)
+	_, err = p.Provision(volume)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "node0 (kernel 4.9.0)")
+
+	// the subvolume is mounted with ceph-fuse when the storage class requests it
+	volume = newVolumeOptions(newStorageClass("class-3", "foo.io/block", map[string]string{"fsName": "myfs", "clusterNamespace": "testCluster", "mounter": "fuse"}, v1.PersistentVolumeReclaimDelete), newClaim("claim-3", "uid-1-1", "class-3", "", "class-3", nil), v1.PersistentVolumeReclaimDelete)
+	pv, err := p.Provision(volume)
+	assert.Nil(t, err)
+	assert.Equal(t, "fuse", pv.Spec.PersistentVolumeSource.FlexVolume.Options["mounter"])
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+	"github.com/rook/rook/pkg/operator/ceph/capacity"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	reasonCapacityExceeded = "capacity_exceeded"
+)
+
+// checkCapacity returns an error if storing the additional bytes of a volume in the filesystem, or else in the pool,
+// would push the raw utilization of the cluster above the fullness threshold of the capacity guardrail
+func checkCapacity(context *clusterd.Context, clusterNamespace, fsName, poolSpec, volume string, additionalBytes int64) error {
+	if !capacity.Enabled() || additionalBytes <= 0 {
+		return nil
+	}
+
+	var rawBytes uint64
+	var err error
+	if fsName != "" {
+		rawBytes, err = capacity.FilesystemRawBytes(context, clusterNamespace, fsName, additionalBytes)
+	} else {
+		// the images of a RADOS namespace are stored in the pool
+		poolName := strings.SplitN(poolSpec, "/", 2)[0]
+		rawBytes, err = capacity.PoolRawBytes(context, clusterNamespace, poolName, additionalBytes)
+	}
+	if err != nil {
+		// without the replication of the pool, each byte is at least stored once
+		logger.Warningf("failed to get the raw size of volume %s, counting its size only. %+v", volume, err)
+		rawBytes = uint64(additionalBytes)
+	}
+	return capacity.Check(context, clusterNamespace, fmt.Sprintf("volume %s", volume), rawBytes)
+}
+
+// checkVolumeCapacity checks the raw utilization of the cluster before expanding the volume to the requested size
+func checkVolumeCapacity(context *clusterd.Context, volume *v1.PersistentVolume, requestedSize resource.Quantity) error {
+	options := volume.Spec.PersistentVolumeSource.FlexVolume.Options
+	currentSize := volume.Spec.Capacity[v1.ResourceStorage]
+	pool := options[flexvolume.PoolKey]
+	if dataPool := options[flexvolume.DataBlockPoolKey]; dataPool != "" {
+		pool = dataPool
+	}
+	return checkCapacity(context, options[flexvolume.ClusterNamespaceKey], options[flexvolume.FsNameKey], pool,
+		volume.Name, requestedSize.Value()-currentSize.Value())
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/capacity"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestCheckVolumeCapacity(t *testing.T) {
+	pools := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName, command, outFileArg string, args ...string) (string, error) {
+			if args[0] == "df" {
+				return `{"stats":{"total_bytes":10000,"total_used_bytes":5000}}`, nil
+			}
+			if args[0] == "osd" && args[1] == "pool" && args[2] == "get" {
+				pools = append(pools, args[3])
+				return `{"pool":"replicapool","size":3}`, nil
+			}
+			return "", errors.New("unexpected command")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	oldPolicy := capacity.GuardrailPolicy
+	capacity.GuardrailPolicy = capacity.GuardrailBlock
+	defer func() { capacity.GuardrailPolicy = oldPolicy }()
+
+	// the images of the RADOS namespaces are stored in the pool, with 3 replicas
+	pv := newQuotaTestPV("pvc-1", "tenant-a", "1000", map[string]string{"clusterNamespace": "rook-ceph", "pool": "replicapool/tenant-a"})
+	assert.Nil(t, checkVolumeCapacity(context, pv, resource.MustParse("2000")))
+	assert.NotNil(t, checkVolumeCapacity(context, pv, resource.MustParse("2100")))
+	assert.Equal(t, []string{"replicapool", "replicapool"}, pools)
+
+	// shrinking or keeping the size is not checked
+	assert.Nil(t, checkVolumeCapacity(context, pv, resource.MustParse("1000")))
+	assert.Equal(t, 2, len(pools))
+}
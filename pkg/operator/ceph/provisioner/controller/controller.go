@@ -462,6 +462,14 @@ func (ctrl *ProvisionController) addClaim(obj interface{}) {
 				return nil
 			})
 		}
+		return
+	}
+
+	if volume, ok := ctrl.shouldExpand(claim); ok {
+		opName := fmt.Sprintf("expand-%s[%s]", claimToClaimKey(claim), string(claim.UID))
+		ctrl.scheduleOperation(opName, func() error {
+			return ctrl.expandClaimOperation(claim, volume)
+		})
 	}
 }
 
@@ -622,6 +630,54 @@ func (ctrl *ProvisionController) shouldDelete(volume *v1.PersistentVolume) bool
 	return true
 }
 
+// shouldExpand returns whether the bound claim requests more storage than its
+// volume currently has and the volume was provisioned by this controller from
+// a storage class that allows volume expansion.
+func (ctrl *ProvisionController) shouldExpand(claim *v1.PersistentVolumeClaim) (*v1.PersistentVolume, bool) {
+	if _, ok := ctrl.provisioner.(ExpandableProvisioner); !ok {
+		return nil, false
+	}
+
+	if claim.Spec.VolumeName == "" || claim.Status.Phase != v1.ClaimBound {
+		return nil, false
+	}
+
+	requestedSize := claim.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)]
+	currentSize := claim.Status.Capacity[v1.ResourceName(v1.ResourceStorage)]
+	if requestedSize.Cmp(currentSize) <= 0 {
+		return nil, false
+	}
+
+	obj, found, err := ctrl.volumes.GetByKey(claim.Spec.VolumeName)
+	if err != nil || !found {
+		return nil, false
+	}
+	volume, ok := obj.(*v1.PersistentVolume)
+	if !ok {
+		return nil, false
+	}
+	if ann := volume.Annotations[annDynamicallyProvisioned]; ann != ctrl.provisionerName {
+		return nil, false
+	}
+
+	// the volume has already been expanded and is waiting for the filesystem resize on the node
+	volumeSize := volume.Spec.Capacity[v1.ResourceName(v1.ResourceStorage)]
+	if requestedSize.Cmp(volumeSize) <= 0 {
+		return nil, false
+	}
+
+	allowExpansion, err := ctrl.getStorageClassAllowVolumeExpansion(helper.GetPersistentVolumeClaimClass(claim))
+	if err != nil {
+		glog.Errorf("Error getting claim %q's StorageClass's fields: %v", claimToClaimKey(claim), err)
+		return nil, false
+	}
+	if !allowExpansion {
+		return nil, false
+	}
+
+	return volume, true
+}
+
 // lockProvisionClaimOperation wraps provisionClaimOperation. In case other
 // controllers are serving the same claims, to prevent them all from creating
 // volumes for a claim & racing to submit their PV, each controller creates a
@@ -1060,6 +1116,67 @@ func (ctrl *ProvisionController) deleteVolumeOperation(volume *v1.PersistentVolu
 	return nil
 }
 
+// expandClaimOperation grows the storage asset backing the volume bound to the
+// claim, then records the new capacity in the PV and the claim.
+func (ctrl *ProvisionController) expandClaimOperation(claim *v1.PersistentVolumeClaim, volume *v1.PersistentVolume) error {
+	expander := ctrl.provisioner.(ExpandableProvisioner)
+	requestedSize := claim.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)]
+	glog.Infof("expandClaimOperation [%s] started, expanding volume %q to %s", claimToClaimKey(claim), volume.Name, requestedSize.String())
+
+	newSize, err := expander.Expand(volume, requestedSize)
+	if err != nil {
+		strerr := fmt.Sprintf("Failed to expand volume with StorageClass %q: %v", helper.GetPersistentVolumeClaimClass(claim), err)
+		glog.Errorf("Failed to expand volume for claim %q: %v", claimToClaimKey(claim), err)
+		ctrl.eventRecorder.Event(claim, v1.EventTypeWarning, "VolumeResizeFailed", strerr)
+		return err
+	}
+
+	// record the new size in the PV
+	newVolume, err := ctrl.client.CoreV1().PersistentVolumes().Get(volume.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get volume %q after expanding it: %v", volume.Name, err)
+	}
+	newVolume.Spec.Capacity[v1.ResourceName(v1.ResourceStorage)] = newSize
+	if _, err = ctrl.client.CoreV1().PersistentVolumes().Update(newVolume); err != nil {
+		return fmt.Errorf("failed to update capacity of volume %q: %v", volume.Name, err)
+	}
+
+	// either the kubelet still needs to grow the filesystem or the claim can report the new capacity right away
+	newClaim, err := ctrl.client.CoreV1().PersistentVolumeClaims(claim.Namespace).Get(claim.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get claim %q after expanding its volume: %v", claimToClaimKey(claim), err)
+	}
+	if expander.RequiresFSResize(volume) {
+		// a claim expanded again before the kubelet resized its filesystem keeps a single pending condition
+		condition := v1.PersistentVolumeClaimCondition{
+			Type:               v1.PersistentVolumeClaimFileSystemResizePending,
+			Status:             v1.ConditionTrue,
+			LastTransitionTime: metav1.Now(),
+			Message:            "Waiting for user to (re-)start a pod to finish file system resize of volume on node.",
+		}
+		replaced := false
+		for i := range newClaim.Status.Conditions {
+			if newClaim.Status.Conditions[i].Type == condition.Type {
+				newClaim.Status.Conditions[i] = condition
+				replaced = true
+			}
+		}
+		if !replaced {
+			newClaim.Status.Conditions = append(newClaim.Status.Conditions, condition)
+		}
+	} else {
+		newClaim.Status.Capacity[v1.ResourceName(v1.ResourceStorage)] = newSize
+	}
+	if _, err = ctrl.client.CoreV1().PersistentVolumeClaims(claim.Namespace).UpdateStatus(newClaim); err != nil {
+		return fmt.Errorf("failed to update status of claim %q: %v", claimToClaimKey(claim), err)
+	}
+
+	msg := fmt.Sprintf("Successfully expanded volume %s to %s", volume.Name, newSize.String())
+	ctrl.eventRecorder.Event(claim, v1.EventTypeNormal, "VolumeResizeSuccessful", msg)
+	glog.Infof("expandClaimOperation [%s]: success", claimToClaimKey(claim))
+	return nil
+}
+
 // getProvisionedVolumeNameForClaim returns PV.Name for the provisioned volume.
 // The name must be unique.
 func (ctrl *ProvisionController) getProvisionedVolumeNameForClaim(claim *v1.PersistentVolumeClaim) string {
@@ -1102,6 +1219,23 @@ func (ctrl *ProvisionController) getStorageClassFields(name string) (string, *v1
 	return "", nil, nil, fmt.Errorf("Cannot convert object to StorageClass: %+v", classObj)
 }
 
+func (ctrl *ProvisionController) getStorageClassAllowVolumeExpansion(name string) (bool, error) {
+	classObj, found, err := ctrl.classes.GetByKey(name)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return false, fmt.Errorf("StorageClass %q not found", name)
+	}
+	switch class := classObj.(type) {
+	case *storage.StorageClass:
+		return class.AllowVolumeExpansion != nil && *class.AllowVolumeExpansion, nil
+	case *storagebeta.StorageClass:
+		return class.AllowVolumeExpansion != nil && *class.AllowVolumeExpansion, nil
+	}
+	return false, fmt.Errorf("Cannot convert object to StorageClass: %+v", classObj)
+}
+
 func claimToClaimKey(claim *v1.PersistentVolumeClaim) string {
 	return fmt.Sprintf("%s/%s", claim.Namespace, claim.Name)
 }
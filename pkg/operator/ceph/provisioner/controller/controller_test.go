@@ -420,6 +420,135 @@ func TestShouldDelete(t *testing.T) {
 	}
 }
 
+func TestShouldExpand(t *testing.T) {
+	allowExpansion := true
+	expandableClass := newStorageClass("class-1", "foo.bar/baz", v1.PersistentVolumeReclaimDelete)
+	expandableClass.AllowVolumeExpansion = &allowExpansion
+
+	newBoundClaim := func(class, requested string) *v1.PersistentVolumeClaim {
+		claim := newClaim("claim-1", "1-1", class, "volume-1", nil)
+		claim.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)] = resource.MustParse(requested)
+		claim.Status.Phase = v1.ClaimBound
+		claim.Status.Capacity = v1.ResourceList{v1.ResourceName(v1.ResourceStorage): resource.MustParse("1Mi")}
+		return claim
+	}
+
+	tests := []struct {
+		name           string
+		class          *storagebeta.StorageClass
+		claim          *v1.PersistentVolumeClaim
+		volume         *v1.PersistentVolume
+		provisioner    Provisioner
+		expectedShould bool
+	}{
+		{
+			name:           "should expand",
+			class:          expandableClass,
+			claim:          newBoundClaim("class-1", "2Mi"),
+			volume:         newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "foo.bar/baz"}),
+			provisioner:    newTestExpandableProvisioner(),
+			expectedShould: true,
+		},
+		{
+			name:           "size not changed",
+			class:          expandableClass,
+			claim:          newBoundClaim("class-1", "1Mi"),
+			volume:         newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "foo.bar/baz"}),
+			provisioner:    newTestExpandableProvisioner(),
+			expectedShould: false,
+		},
+		{
+			name:           "expansion not allowed by class",
+			class:          newStorageClass("class-1", "foo.bar/baz", v1.PersistentVolumeReclaimDelete),
+			claim:          newBoundClaim("class-1", "2Mi"),
+			volume:         newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "foo.bar/baz"}),
+			provisioner:    newTestExpandableProvisioner(),
+			expectedShould: false,
+		},
+		{
+			name:           "not this provisioner's volume",
+			class:          expandableClass,
+			claim:          newBoundClaim("class-1", "2Mi"),
+			volume:         newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "abc.def/ghi"}),
+			provisioner:    newTestExpandableProvisioner(),
+			expectedShould: false,
+		},
+		{
+			name:           "provisioner cannot expand",
+			class:          expandableClass,
+			claim:          newBoundClaim("class-1", "2Mi"),
+			volume:         newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "foo.bar/baz"}),
+			provisioner:    newTestProvisioner(),
+			expectedShould: false,
+		},
+	}
+	for _, test := range tests {
+		client := fake.NewSimpleClientset(test.claim, test.volume)
+		ctrl := newTestProvisionController(client, "foo.bar/baz", test.provisioner, "v1.5.0")
+
+		if err := ctrl.classes.Add(test.class); err != nil {
+			t.Logf("test case: %s", test.name)
+			t.Errorf("error adding class %v to cache: %v", test.class, err)
+		}
+		if err := ctrl.volumes.Add(test.volume); err != nil {
+			t.Logf("test case: %s", test.name)
+			t.Errorf("error adding volume %v to cache: %v", test.volume, err)
+		}
+
+		_, should := ctrl.shouldExpand(test.claim)
+		if test.expectedShould != should {
+			t.Logf("test case: %s", test.name)
+			t.Errorf("expected should expand %v but got %v\n", test.expectedShould, should)
+		}
+	}
+}
+
+func TestExpandClaimOperation(t *testing.T) {
+	claim := newClaim("claim-1", "1-1", "class-1", "volume-1", nil)
+	claim.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)] = resource.MustParse("2Mi")
+	claim.Status.Phase = v1.ClaimBound
+	claim.Status.Capacity = v1.ResourceList{v1.ResourceName(v1.ResourceStorage): resource.MustParse("1Mi")}
+	volume := newVolume("volume-1", v1.VolumeBound, v1.PersistentVolumeReclaimDelete, map[string]string{annDynamicallyProvisioned: "foo.bar/baz"})
+
+	client := fake.NewSimpleClientset(claim, volume)
+	ctrl := newTestProvisionController(client, "foo.bar/baz", newTestExpandableProvisioner(), "v1.5.0")
+
+	if err := ctrl.expandClaimOperation(claim, volume); err != nil {
+		t.Fatalf("unexpected error expanding claim: %v", err)
+	}
+
+	newVolume, err := client.CoreV1().PersistentVolumes().Get("volume-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get volume: %v", err)
+	}
+	size := newVolume.Spec.Capacity[v1.ResourceName(v1.ResourceStorage)]
+	if size.String() != "2Mi" {
+		t.Errorf("expected volume capacity 2Mi but got %s", size.String())
+	}
+
+	// the filesystem still needs to be resized on the node
+	newClaim, err := client.CoreV1().PersistentVolumeClaims(claim.Namespace).Get("claim-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get claim: %v", err)
+	}
+	if len(newClaim.Status.Conditions) != 1 || newClaim.Status.Conditions[0].Type != v1.PersistentVolumeClaimFileSystemResizePending {
+		t.Errorf("expected claim condition %s but got %+v", v1.PersistentVolumeClaimFileSystemResizePending, newClaim.Status.Conditions)
+	}
+
+	// expanding the claim again before its filesystem is resized keeps a single pending condition
+	newClaim.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)] = resource.MustParse("4Mi")
+	if err := ctrl.expandClaimOperation(newClaim, newVolume); err != nil {
+		t.Fatalf("unexpected error expanding claim again: %v", err)
+	}
+	newClaim, err = client.CoreV1().PersistentVolumeClaims(claim.Namespace).Get("claim-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get claim: %v", err)
+	}
+	if len(newClaim.Status.Conditions) != 1 || newClaim.Status.Conditions[0].Type != v1.PersistentVolumeClaimFileSystemResizePending {
+		t.Errorf("expected a single claim condition %s but got %+v", v1.PersistentVolumeClaimFileSystemResizePending, newClaim.Status.Conditions)
+	}
+}
+
 func TestIsOnlyRecordUpdate(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -627,6 +756,24 @@ func (p *testProvisioner) Delete(volume *v1.PersistentVolume) error {
 	return nil
 }
 
+func newTestExpandableProvisioner() *testExpandableProvisioner {
+	return &testExpandableProvisioner{testProvisioner: newTestProvisioner()}
+}
+
+type testExpandableProvisioner struct {
+	*testProvisioner
+}
+
+var _ ExpandableProvisioner = &testExpandableProvisioner{}
+
+func (p *testExpandableProvisioner) Expand(volume *v1.PersistentVolume, requestedSize resource.Quantity) (resource.Quantity, error) {
+	return requestedSize, nil
+}
+
+func (p *testExpandableProvisioner) RequiresFSResize(volume *v1.PersistentVolume) bool {
+	return true
+}
+
 func newBadTestProvisioner() Provisioner {
 	return &badTestProvisioner{}
 }
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // Provisioner is an interface that creates templates for PersistentVolumes
@@ -38,6 +39,18 @@ type Provisioner interface {
 	Delete(*v1.PersistentVolume) error
 }
 
+// ExpandableProvisioner is an optional interface for a Provisioner that can
+// grow the storage asset backing a PV after it has been provisioned.
+type ExpandableProvisioner interface {
+	Provisioner
+	// Expand grows the storage asset backing the given PV to at least the
+	// requested size and returns the new size of the asset.
+	Expand(volume *v1.PersistentVolume, requestedSize resource.Quantity) (resource.Quantity, error)
+	// RequiresFSResize returns whether the filesystem on the node must also be
+	// grown by the volume plugin after the storage asset has been expanded.
+	RequiresFSResize(volume *v1.PersistentVolume) bool
+}
+
 // IgnoredError is the value for Delete to return to indicate that the call has
 // been ignored and no action taken. In case multiple provisioners are serving
 // the same storage class, provisioners may ignore PVs they are not responsible
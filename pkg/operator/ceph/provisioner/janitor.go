@@ -0,0 +1,293 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// OrphanedImagesIgnore disables the detection of orphaned images
+	OrphanedImagesIgnore = "Ignore"
+	// OrphanedImagesReport lists the orphaned images in a config map in the cluster namespace
+	OrphanedImagesReport = "Report"
+	// OrphanedImagesDelete reports the orphaned images and deletes them after the grace period
+	OrphanedImagesDelete = "Delete"
+
+	// OrphanedImagesConfigMapName is the name of the config map listing the orphaned images of a cluster
+	OrphanedImagesConfigMapName = "rook-ceph-orphaned-images"
+
+	// provisionedImagePrefix is the prefix of the names of the images created by the provisioner
+	provisionedImagePrefix = "pvc-"
+)
+
+var (
+	// OrphanedImagesPolicy is what the janitor does with the images that are not used by any PV
+	OrphanedImagesPolicy = OrphanedImagesReport
+	// OrphanedImagesCheckInterval is the interval between the checks for orphaned images
+	OrphanedImagesCheckInterval = 60 * time.Minute
+	// OrphanedImagesGracePeriod is how long an image must be orphaned before it is deleted
+	OrphanedImagesGracePeriod = 24 * time.Hour
+)
+
+// OrphanedImage is an image created by the provisioner that is not referenced by any PV anymore
+type OrphanedImage struct {
+	Pool      string    `json:"pool"`
+	Image     string    `json:"image"`
+	Size      uint64    `json:"size"`
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// Janitor detects the rbd images created by the provisioner whose PV no longer exists. This happens when a PV
+// with the Retain reclaim policy is deleted or when the operator crashes while provisioning a volume.
+type Janitor struct {
+	context *clusterd.Context
+	policy  string
+}
+
+// NewJanitor creates a janitor for the orphaned images with the given policy
+func NewJanitor(context *clusterd.Context, policy string) (*Janitor, error) {
+	switch policy {
+	case OrphanedImagesIgnore, OrphanedImagesReport, OrphanedImagesDelete:
+	default:
+		return nil, fmt.Errorf("invalid orphaned images policy %q. must be one of %s, %s or %s",
+			policy, OrphanedImagesIgnore, OrphanedImagesReport, OrphanedImagesDelete)
+	}
+	return &Janitor{context: context, policy: policy}, nil
+}
+
+// Start checks for orphaned images and purges the expired images from the trash at the configured interval
+// until the stop channel is closed
+func (j *Janitor) Start(stopCh chan struct{}) {
+	if j.policy == OrphanedImagesIgnore && ImageTrashExpiry <= 0 {
+		logger.Infof("detection of orphaned images is disabled")
+		return
+	}
+
+	logger.Infof("checking for orphaned images every %v with policy %s", OrphanedImagesCheckInterval, j.policy)
+	for {
+		select {
+		case <-time.After(OrphanedImagesCheckInterval):
+			if err := j.checkClusters(); err != nil {
+				logger.Warningf("failed to check for orphaned images. %+v", err)
+			}
+
+		case <-stopCh:
+			logger.Infof("stopping the check for orphaned images")
+			return
+		}
+	}
+}
+
+func (j *Janitor) checkClusters() error {
+	clusters, err := j.context.RookClientset.CephV1().CephClusters(v1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list clusters. %+v", err)
+	}
+
+	for _, c := range clusters.Items {
+		if ImageTrashExpiry > 0 {
+			j.purgeTrash(c.Namespace)
+		}
+
+		if j.policy == OrphanedImagesIgnore {
+			continue
+		}
+		ownerRef := cluster.ClusterOwnerRef(c.Namespace, string(c.UID))
+		if _, err := j.checkCluster(c.Namespace, &ownerRef); err != nil {
+			logger.Warningf("failed to check for orphaned images in cluster %s. %+v", c.Namespace, err)
+		}
+	}
+	return nil
+}
+
+// purgeTrash deletes the expired images from the trash of the block pools of the cluster
+func (j *Janitor) purgeTrash(namespace string) {
+	pools, err := j.context.RookClientset.CephV1().CephBlockPools(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		logger.Warningf("failed to list pools in cluster %s to purge the trash. %+v", namespace, err)
+		return
+	}
+
+	for _, pool := range pools.Items {
+		if err := ceph.PurgeTrash(j.context, namespace, pool.Name); err != nil {
+			logger.Warningf("failed to purge trash in cluster %s. %+v", namespace, err)
+		}
+	}
+}
+
+// checkCluster updates the report of the orphaned images of the cluster and deletes the images that have
+// been orphaned for longer than the grace period if the policy allows it. The remaining orphaned images are returned.
+func (j *Janitor) checkCluster(namespace string, ownerRef *metav1.OwnerReference) ([]OrphanedImage, error) {
+	pools, err := j.context.RookClientset.CephV1().CephBlockPools(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pools. %+v", err)
+	}
+
+	var candidates []OrphanedImage
+	for _, pool := range pools.Items {
+		images, err := ceph.ListImages(j.context, namespace, pool.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range images {
+			if strings.HasPrefix(image.Name, provisionedImagePrefix) {
+				candidates = append(candidates, OrphanedImage{Pool: pool.Name, Image: image.Name, Size: image.Size})
+			}
+		}
+	}
+
+	// the PVs are listed after the images so a volume provisioned in between is not reported
+	used, err := j.usedImages(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := j.loadReport(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var orphaned []OrphanedImage
+	for _, image := range candidates {
+		key := imageKey(image.Pool, image.Image)
+		if used[key] {
+			continue
+		}
+
+		image.FirstSeen = now
+		if p, ok := previous[key]; ok {
+			image.FirstSeen = p.FirstSeen
+		}
+
+		if j.policy == OrphanedImagesDelete && now.Sub(image.FirstSeen) > OrphanedImagesGracePeriod {
+			logger.Infof("deleting image %s/%s in cluster %s. it has been orphaned since %v", image.Pool, image.Image, namespace, image.FirstSeen)
+			if err := removeImage(j.context, namespace, image.Image, image.Pool); err != nil {
+				logger.Errorf("failed to delete orphaned image %s/%s. %+v", image.Pool, image.Image, err)
+			} else {
+				continue
+			}
+		} else if _, ok := previous[key]; !ok {
+			logger.Warningf("image %s/%s in cluster %s is not used by any persistent volume", image.Pool, image.Image, namespace)
+		}
+		orphaned = append(orphaned, image)
+	}
+
+	if err := j.saveReport(namespace, ownerRef, orphaned); err != nil {
+		return nil, err
+	}
+	return orphaned, nil
+}
+
+// usedImages returns the images of the cluster that are referenced by a PV
+func (j *Janitor) usedImages(namespace string) (map[string]bool, error) {
+	pvs, err := j.context.Clientset.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volumes. %+v", err)
+	}
+
+	used := map[string]bool{}
+	for _, pv := range pvs.Items {
+		flex := pv.Spec.PersistentVolumeSource.FlexVolume
+		if flex == nil || flex.Options == nil {
+			continue
+		}
+		clusterNamespace := flex.Options[flexvolume.ClusterNamespaceKey]
+		if clusterNamespace == "" {
+			clusterNamespace = cluster.DefaultClusterName
+		}
+		if clusterNamespace != namespace {
+			continue
+		}
+		pool := flex.Options[flexvolume.PoolKey]
+		if pool == "" {
+			pool = flex.Options[flexvolume.BlockPoolKey]
+		}
+		used[imageKey(pool, flex.Options[flexvolume.ImageKey])] = true
+	}
+	return used, nil
+}
+
+func (j *Janitor) loadReport(namespace string) (map[string]OrphanedImage, error) {
+	report := map[string]OrphanedImage{}
+	cm, err := j.context.Clientset.CoreV1().ConfigMaps(namespace).Get(OrphanedImagesConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return report, nil
+		}
+		return nil, fmt.Errorf("failed to get config map %s. %+v", OrphanedImagesConfigMapName, err)
+	}
+
+	for key, val := range cm.Data {
+		var image OrphanedImage
+		if err := json.Unmarshal([]byte(val), &image); err != nil {
+			logger.Warningf("ignoring invalid orphaned image %s in config map %s. %+v", key, OrphanedImagesConfigMapName, err)
+			continue
+		}
+		report[key] = image
+	}
+	return report, nil
+}
+
+func (j *Janitor) saveReport(namespace string, ownerRef *metav1.OwnerReference, images []OrphanedImage) error {
+	data := map[string]string{}
+	for _, image := range images {
+		val, err := json.Marshal(image)
+		if err != nil {
+			return err
+		}
+		data[imageKey(image.Pool, image.Image)] = string(val)
+	}
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      OrphanedImagesConfigMapName,
+			Namespace: namespace,
+		},
+		Data: data,
+	}
+	k8sutil.SetOwnerRef(j.context.Clientset, namespace, &cm.ObjectMeta, ownerRef)
+
+	_, err := j.context.Clientset.CoreV1().ConfigMaps(namespace).Create(cm)
+	if err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create config map %s. %+v", OrphanedImagesConfigMapName, err)
+		}
+		if _, err := j.context.Clientset.CoreV1().ConfigMaps(namespace).Update(cm); err != nil {
+			return fmt.Errorf("failed to update config map %s. %+v", OrphanedImagesConfigMapName, err)
+		}
+	}
+	return nil
+}
+
+// imageKey is the key of an image in the report config map
+func imageKey(pool, image string) string {
+	return fmt.Sprintf("%s.%s", pool, image)
+}
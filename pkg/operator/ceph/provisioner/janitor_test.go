@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewJanitor(t *testing.T) {
+	_, err := NewJanitor(&clusterd.Context{}, OrphanedImagesDelete)
+	assert.Nil(t, err)
+	_, err = NewJanitor(&clusterd.Context{}, "Destroy")
+	assert.NotNil(t, err)
+}
+
+func TestCheckClusterOrphanedImages(t *testing.T) {
+	deleted := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if command == "rbd" && args[0] == "ls" {
+				return `[{"image":"pvc-used","size":1048576,"format":2},{"image":"pvc-orphan","size":2097152,"format":2},` +
+					`{"image":"pvc-old","size":1048576,"format":2},{"image":"manual","size":1048576,"format":2}]`, nil
+			}
+			if command == "rbd" && args[0] == "rm" {
+				deleted = append(deleted, args[1])
+				return "", nil
+			}
+			return "", fmt.Errorf("unexpected command %s %v", command, args)
+		},
+	}
+
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "pool1", Namespace: "ns"}}
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-used"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				FlexVolume: &v1.FlexPersistentVolumeSource{
+					Driver:  "ceph.rook.io/rook-ceph-system",
+					Options: map[string]string{"pool": "pool1", "image": "pvc-used", "clusterNamespace": "ns"},
+				},
+			},
+		},
+	}
+
+	// pvc-old was already reported two days ago
+	old := OrphanedImage{Pool: "pool1", Image: "pvc-old", Size: 1048576, FirstSeen: time.Now().Add(-48 * time.Hour)}
+	oldVal, _ := json.Marshal(old)
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: OrphanedImagesConfigMapName, Namespace: "ns"},
+		Data:       map[string]string{"pool1.pvc-old": string(oldVal)},
+	}
+
+	context := &clusterd.Context{
+		Clientset:     fake.NewSimpleClientset(pv, cm),
+		RookClientset: rookfake.NewSimpleClientset(pool),
+		Executor:      executor,
+	}
+
+	// the images are only reported
+	janitor, _ := NewJanitor(context, OrphanedImagesReport)
+	orphaned, err := janitor.checkCluster("ns", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(orphaned))
+	assert.Equal(t, "pvc-orphan", orphaned[0].Image)
+	assert.Equal(t, uint64(2097152), orphaned[0].Size)
+	assert.Equal(t, "pvc-old", orphaned[1].Image)
+	assert.True(t, orphaned[1].FirstSeen.Before(time.Now().Add(-47*time.Hour)))
+	assert.Equal(t, 0, len(deleted))
+
+	report, err := janitor.loadReport("ns")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(report))
+	assert.Equal(t, "pvc-orphan", report["pool1.pvc-orphan"].Image)
+
+	// only the image orphaned for longer than the grace period is deleted
+	janitor, _ = NewJanitor(context, OrphanedImagesDelete)
+	orphaned, err = janitor.checkCluster("ns", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pool1/pvc-old"}, deleted)
+	assert.Equal(t, 1, len(orphaned))
+	assert.Equal(t, "pvc-orphan", orphaned[0].Image)
+
+	report, err = janitor.loadReport("ns")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(report))
+}
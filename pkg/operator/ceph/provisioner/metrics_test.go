@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func failureCount(t *testing.T, operation, reason string) float64 {
+	var m dto.Metric
+	err := operationFailures.WithLabelValues(operation, reason).Write(&m)
+	assert.Nil(t, err)
+	return m.GetCounter().GetValue()
+}
+
+func TestOperationFailureMetrics(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			return "mocked error output", fmt.Errorf("mocked rbd error")
+		},
+	}
+	context := &clusterd.Context{
+		Clientset: test.New(3),
+		Executor:  executor,
+	}
+	p := New(context, "foo.io").(*RookVolumeProvisioner)
+
+	pv := &v1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pvc-uid-1-1"}}
+	before := failureCount(t, operationDelete, reasonInvalidVolume)
+	assert.NotNil(t, p.Delete(pv))
+	assert.Equal(t, before+1, failureCount(t, operationDelete, reasonInvalidVolume))
+
+	pv.Spec.PersistentVolumeSource.FlexVolume = &v1.FlexPersistentVolumeSource{
+		Driver:  "foo.io/rook",
+		Options: map[string]string{"pool": "testpool", "image": "pvc-uid-1-1", "clusterNamespace": "testCluster"},
+	}
+	before = failureCount(t, operationDelete, reasonDeleteImage)
+	assert.NotNil(t, p.Delete(pv))
+	assert.Equal(t, before+1, failureCount(t, operationDelete, reasonDeleteImage))
+}
@@ -19,14 +19,19 @@ package provisioner
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/capabilities"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
 	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/cluster"
+	"github.com/rook/rook/pkg/operator/ceph/pool"
 	"github.com/rook/rook/pkg/operator/ceph/provisioner/controller"
+	"github.com/rook/rook/pkg/util/metrics"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -36,10 +41,16 @@ const (
 	attacherImageKey              = "attacherImage"
 	storageClassBetaAnnotationKey = "volume.beta.kubernetes.io/storage-class"
 	sizeMB                        = 1048576 // 1 MB
+	// the fsType of the flex volumes mounting a filesystem instead of an image
+	cephFSType = "ceph"
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-provisioner")
 
+// ImageTrashExpiry is how long the image of a deleted volume is kept in the trash of its pool before it is purged.
+// The image can be restored from the trash until then. The image is deleted immediately if the expiry is not set.
+var ImageTrashExpiry time.Duration
+
 // RookVolumeProvisioner is used to provision Rook volumes on Kubernetes
 type RookVolumeProvisioner struct {
 	context *clusterd.Context
@@ -49,9 +60,20 @@ type RookVolumeProvisioner struct {
 }
 
 type provisionerConfig struct {
-	// Required: The pool name to provision volumes from.
+	// Required: The pool name to provision volumes from, unless the filesystem is given.
 	blockPool string
 
+	// Optional: The RADOS namespace of the pool to provision the images in, to isolate the images of a tenant
+	radosNamespace string
+
+	// Optional: The filesystem to provision volumes from instead of a pool. Each volume is a subvolume of the
+	// filesystem whose quota is the requested size.
+	fsName string
+
+	// Optional: The client mounting the filesystem, kernel or fuse. Default is the kernel client, unless a node
+	// cannot mount the filesystems with it.
+	mounter string
+
 	// Optional: Name of the cluster. Default is `rook`
 	clusterNamespace string
 
@@ -60,6 +82,30 @@ type provisionerConfig struct {
 
 	// Optional: For erasure coded pools the data pool must be given
 	dataBlockPool string
+
+	// Optional: Comma separated list of the rbd image features to enable. Default is the cluster default.
+	imageFeatures []string
+
+	// Optional: Stripe unit in bytes and stripe count of the image. Both must be set to enable striping.
+	stripeUnit  uint64
+	stripeCount uint64
+}
+
+// supportedImageFeatures are the rbd image features that can be requested in the StorageClass. journaling is not
+// supported since the kernel rbd module mapping the volumes cannot map the images with journaling.
+var supportedImageFeatures = map[string]bool{
+	"layering":       true,
+	"striping":       true,
+	"exclusive-lock": true,
+	"object-map":     true,
+	"fast-diff":      true,
+	"deep-flatten":   true,
+}
+
+// imageFeatureDependencies are the rbd image features required by other features
+var imageFeatureDependencies = map[string]string{
+	"object-map": "exclusive-lock",
+	"fast-diff":  "object-map",
 }
 
 // New creates RookVolumeProvisioner
@@ -72,46 +118,114 @@ func New(context *clusterd.Context, flexDriverVendor string) controller.Provisio
 
 // Provision creates a storage asset and returns a PV object representing it.
 func (p *RookVolumeProvisioner) Provision(options controller.VolumeOptions) (*v1.PersistentVolume, error) {
+	trace := metrics.NewOperationTrace(logger, fmt.Sprintf("provision volume %s", options.PVName))
 
 	var err error
 	if options.PVC.Spec.Selector != nil {
-		return nil, fmt.Errorf("claim Selector is not supported")
+		return nil, recordFailure(operationProvision, reasonInvalidParameters, fmt.Errorf("claim Selector is not supported"))
 	}
 
 	cfg, err := parseClassParameters(options.Parameters)
 	if err != nil {
-		return nil, err
+		return nil, recordFailure(operationProvision, reasonInvalidParameters, err)
 	}
 
 	logger.Infof("creating volume with configuration %+v", *cfg)
 
 	capacity := options.PVC.Spec.Resources.Requests[v1.ResourceName(v1.ResourceStorage)]
 	requestBytes := capacity.Value()
+	if err := checkQuota(p.context, options.PVC.Namespace, requestBytes); err != nil {
+		return nil, recordFailure(operationProvision, reasonQuotaExceeded, err)
+	}
+	dataPool := cfg.poolSpec()
+	if cfg.dataBlockPool != "" {
+		dataPool = cfg.dataBlockPool
+	}
+	if err := checkCapacity(p.context, cfg.clusterNamespace, cfg.fsName, dataPool, options.PVName, requestBytes); err != nil {
+		return nil, recordFailure(operationProvision, reasonCapacityExceeded, err)
+	}
 
 	imageName := options.PVName
 
-	storageClass, err := parseStorageClass(options)
+	// the volumes are created with the clients supported by the kernel of the nodes which may mount them
+	nodes, err := capabilities.GetNodeCapabilities(p.context.Clientset)
 	if err != nil {
-		return nil, err
+		logger.Warningf("failed to get the capabilities of the nodes, the volume may not be mountable. %+v", err)
 	}
 
-	blockImage, err := p.createVolume(imageName, cfg.blockPool, cfg.dataBlockPool, cfg.clusterNamespace, requestBytes)
+	storageClass, err := parseStorageClass(options)
 	if err != nil {
-		return nil, err
+		return nil, recordFailure(operationProvision, reasonInvalidParameters, err)
 	}
-
-	// since we can guarantee the size of the volume image generated have to be in `MB` boundary, so we can
-	// convert it to `MB` unit safely here
-	s := fmt.Sprintf("%dMi", blockImage.Size/sizeMB)
-	quantity, err := resource.ParseQuantity(s)
-	if err != nil {
-		return nil, fmt.Errorf("cannot parse '%v': %v", s, err)
+	trace.Step("parse parameters")
+
+	var quantity resource.Quantity
+	var flexOptions map[string]string
+	fstype := cfg.fstype
+	if cfg.fsName != "" {
+		if err := ceph.CheckSubvolumeSupport(p.context, cfg.clusterNamespace); err != nil {
+			return nil, recordFailure(operationProvision, reasonUnsupportedVersion, err)
+		}
+		mounter, err := filesystemMounter(cfg.mounter, nodes)
+		if err != nil {
+			return nil, recordFailure(operationProvision, reasonIncompatibleNodes, err)
+		}
+		path, err := p.createFilesystemVolume(imageName, cfg.fsName, cfg.clusterNamespace, requestBytes)
+		if err != nil {
+			return nil, recordFailure(operationProvision, reasonCreateSubvolume, err)
+		}
+		trace.Step("create subvolume")
+
+		// the quota of the subvolume is exactly the requested size
+		quantity = capacity
+		fstype = cephFSType
+		flexOptions = map[string]string{
+			flexvolume.StorageClassKey:     storageClass,
+			flexvolume.FsNameKey:           cfg.fsName,
+			flexvolume.PathKey:             path,
+			flexvolume.ClusterNamespaceKey: cfg.clusterNamespace,
+		}
+		if mounter != "" {
+			flexOptions[flexvolume.MounterKey] = mounter
+		}
+	} else {
+		imageOptions := cfg.imageOptions()
+		imageOptions.Features, err = compatibleImageFeatures(cfg.imageFeatures, nodes)
+		if err != nil {
+			return nil, recordFailure(operationProvision, reasonIncompatibleNodes, err)
+		}
+		blockImage, err := p.createVolume(imageName, cfg.poolSpec(), cfg.clusterNamespace, requestBytes, imageOptions)
+		if err != nil {
+			return nil, recordFailure(operationProvision, reasonCreateImage, err)
+		}
+		trace.Step("create image")
+
+		// since we can guarantee the size of the volume image generated have to be in `MB` boundary, so we can
+		// convert it to `MB` unit safely here
+		s := fmt.Sprintf("%dMi", blockImage.Size/sizeMB)
+		quantity, err = resource.ParseQuantity(s)
+		if err != nil {
+			return nil, recordFailure(operationProvision, reasonCreateImage, fmt.Errorf("cannot parse '%v': %v", s, err))
+		}
+		flexOptions = map[string]string{
+			flexvolume.StorageClassKey:     storageClass,
+			flexvolume.PoolKey:             cfg.poolSpec(),
+			flexvolume.ImageKey:            imageName,
+			flexvolume.ClusterNamespaceKey: cfg.clusterNamespace,
+			flexvolume.DataBlockPoolKey:    cfg.dataBlockPool,
+		}
+		if cfg.radosNamespace != "" {
+			// the image is mapped with the user restricted to the rados namespace, not the admin
+			flexOptions[flexvolume.MountUserKey] = ceph.RadosNamespaceUser(cfg.blockPool, cfg.radosNamespace)
+			flexOptions[flexvolume.MountSecretKey] = pool.RadosNamespaceSecretName(cfg.radosNamespace)
+		}
 	}
 
 	driverName, err := flexvolume.RookDriverName(p.context)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get driver name. %+v", err)
+		return nil, recordFailure(operationProvision, reasonDriverName, fmt.Errorf("failed to get driver name. %+v", err))
 	}
+	trace.Step("get driver name")
 
 	flexdriver := fmt.Sprintf("%s/%s", p.flexDriverVendor, driverName)
 	pv := &v1.PersistentVolume{
@@ -126,30 +240,42 @@ func (p *RookVolumeProvisioner) Provision(options controller.VolumeOptions) (*v1
 			},
 			PersistentVolumeSource: v1.PersistentVolumeSource{
 				FlexVolume: &v1.FlexPersistentVolumeSource{
-					Driver: flexdriver,
-					FSType: cfg.fstype,
-					Options: map[string]string{
-						flexvolume.StorageClassKey:     storageClass,
-						flexvolume.PoolKey:             cfg.blockPool,
-						flexvolume.ImageKey:            imageName,
-						flexvolume.ClusterNamespaceKey: cfg.clusterNamespace,
-						flexvolume.DataBlockPoolKey:    cfg.dataBlockPool,
-					},
+					Driver:  flexdriver,
+					FSType:  fstype,
+					Options: flexOptions,
 				},
 			},
 		},
 	}
-	logger.Infof("successfully created Rook Block volume %+v", pv.Spec.PersistentVolumeSource.FlexVolume)
+	logger.Infof("successfully created Rook volume %+v", pv.Spec.PersistentVolumeSource.FlexVolume)
+	recordSuccess(operationProvision, trace.Done())
 	return pv, nil
 }
 
+// createFilesystemVolume creates a subvolume of the filesystem limited to the size and returns its path
+func (p *RookVolumeProvisioner) createFilesystemVolume(name, fsName, clusterNamespace string, size int64) (string, error) {
+	if name == "" || fsName == "" || clusterNamespace == "" || size == 0 {
+		return "", fmt.Errorf("subvolume missing required fields (name=%s, fsName=%s, clusterNamespace=%s, size=%d)", name, fsName, clusterNamespace, size)
+	}
+
+	if err := ceph.CreateSubvolume(p.context, clusterNamespace, fsName, name, uint64(size)); err != nil {
+		return "", err
+	}
+	path, err := ceph.GetSubvolumePath(p.context, clusterNamespace, fsName, name)
+	if err != nil {
+		return "", err
+	}
+	logger.Infof("Rook filesystem subvolume created: %s:%s, quota = %d", fsName, path, size)
+	return path, nil
+}
+
 // createVolume creates a rook block volume.
-func (p *RookVolumeProvisioner) createVolume(image, pool, dataPool string, clusterNamespace string, size int64) (*ceph.CephBlockImage, error) {
+func (p *RookVolumeProvisioner) createVolume(image, pool, clusterNamespace string, size int64, opts ceph.ImageOptions) (*ceph.CephBlockImage, error) {
 	if image == "" || pool == "" || clusterNamespace == "" || size == 0 {
 		return nil, fmt.Errorf("image missing required fields (image=%s, pool=%s, clusterNamespace=%s, size=%d)", image, pool, clusterNamespace, size)
 	}
 
-	createdImage, err := ceph.CreateImage(p.context, clusterNamespace, image, pool, dataPool, uint64(size))
+	createdImage, err := ceph.CreateImageWithOptions(p.context, clusterNamespace, image, pool, uint64(size), opts)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create rook block image %s/%s: %v", pool, image, err)
 	}
@@ -162,23 +288,101 @@ func (p *RookVolumeProvisioner) createVolume(image, pool, dataPool string, clust
 // by the given PV.
 func (p *RookVolumeProvisioner) Delete(volume *v1.PersistentVolume) error {
 	logger.Infof("Deleting volume %s", volume.Name)
+	trace := metrics.NewOperationTrace(logger, fmt.Sprintf("delete volume %s", volume.Name))
 	if volume.Spec.PersistentVolumeSource.FlexVolume == nil {
-		return fmt.Errorf("Failed to delete rook block image %s: %v", volume.Name, "PersistentVolume is not a FlexVolume")
+		return recordFailure(operationDelete, reasonInvalidVolume, fmt.Errorf("Failed to delete rook block image %s: %v", volume.Name, "PersistentVolume is not a FlexVolume"))
 	}
 	if volume.Spec.PersistentVolumeSource.FlexVolume.Options == nil {
-		return fmt.Errorf("Failed to delete rook block image %s: %v", volume.Name, "PersistentVolume has no image defined for the FlexVolume")
+		return recordFailure(operationDelete, reasonInvalidVolume, fmt.Errorf("Failed to delete rook block image %s: %v", volume.Name, "PersistentVolume has no image defined for the FlexVolume"))
 	}
-	name := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.ImageKey]
 	clusterns := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.ClusterNamespaceKey]
+	if fsName := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.FsNameKey]; fsName != "" {
+		if err := ceph.DeleteSubvolume(p.context, clusterns, fsName, volume.Name); err != nil {
+			return recordFailure(operationDelete, reasonDeleteSubvolume, fmt.Errorf("Failed to delete rook filesystem subvolume %s/%s: %v", fsName, volume.Name, err))
+		}
+		trace.Step("delete subvolume")
+		logger.Infof("succeeded deleting volume %+v", volume)
+		recordSuccess(operationDelete, trace.Done())
+		return nil
+	}
+
+	name := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.ImageKey]
 	pool := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.PoolKey]
-	err := ceph.DeleteImage(p.context, clusterns, name, pool)
+	err := removeImage(p.context, clusterns, name, pool)
 	if err != nil {
-		return fmt.Errorf("Failed to delete rook block image %s/%s: %v", pool, volume.Name, err)
+		return recordFailure(operationDelete, reasonDeleteImage, fmt.Errorf("Failed to delete rook block image %s/%s: %v", pool, volume.Name, err))
 	}
+	trace.Step("delete image")
 	logger.Infof("succeeded deleting volume %+v", volume)
+	recordSuccess(operationDelete, trace.Done())
 	return nil
 }
 
+// removeImage deletes the image, or moves it to the trash if the trash expiry is set. Moving an image to the trash
+// is immediate while deleting a large image can take a long time. The expired images are purged by the janitor.
+func removeImage(context *clusterd.Context, clusterNamespace, name, pool string) error {
+	if ImageTrashExpiry <= 0 {
+		return ceph.DeleteImage(context, clusterNamespace, name, pool)
+	}
+
+	expiresAt := time.Now().Add(ImageTrashExpiry)
+	logger.Infof("moving image %s/%s to the trash until %v", pool, name, expiresAt)
+	return ceph.MoveImageToTrash(context, clusterNamespace, name, pool, expiresAt)
+}
+
+// Expand grows the rook block image backing the given PV to at least the requested size.
+func (p *RookVolumeProvisioner) Expand(volume *v1.PersistentVolume, requestedSize resource.Quantity) (resource.Quantity, error) {
+	logger.Infof("Expanding volume %s to %s", volume.Name, requestedSize.String())
+	trace := metrics.NewOperationTrace(logger, fmt.Sprintf("expand volume %s", volume.Name))
+	if volume.Spec.PersistentVolumeSource.FlexVolume == nil || volume.Spec.PersistentVolumeSource.FlexVolume.Options == nil {
+		return resource.Quantity{}, recordFailure(operationExpand, reasonInvalidVolume, fmt.Errorf("Failed to expand rook block image %s: %v", volume.Name, "PersistentVolume is not a FlexVolume with an image defined"))
+	}
+	if claim := volume.Spec.ClaimRef; claim != nil {
+		currentSize := volume.Spec.Capacity[v1.ResourceStorage]
+		if err := checkQuota(p.context, claim.Namespace, requestedSize.Value()-currentSize.Value()); err != nil {
+			return resource.Quantity{}, recordFailure(operationExpand, reasonQuotaExceeded, err)
+		}
+	}
+	clusterns := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.ClusterNamespaceKey]
+	if err := checkVolumeCapacity(p.context, volume, requestedSize); err != nil {
+		return resource.Quantity{}, recordFailure(operationExpand, reasonCapacityExceeded, err)
+	}
+	if fsName := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.FsNameKey]; fsName != "" {
+		if err := ceph.ResizeSubvolume(p.context, clusterns, fsName, volume.Name, uint64(requestedSize.Value())); err != nil {
+			return resource.Quantity{}, recordFailure(operationExpand, reasonResizeSubvolume, fmt.Errorf("Failed to expand rook filesystem subvolume %s/%s: %v", fsName, volume.Name, err))
+		}
+		trace.Step("resize subvolume")
+		logger.Infof("succeeded expanding volume %s to %s", volume.Name, requestedSize.String())
+		recordSuccess(operationExpand, trace.Done())
+		return requestedSize, nil
+	}
+
+	name := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.ImageKey]
+	pool := volume.Spec.PersistentVolumeSource.FlexVolume.Options[flexvolume.PoolKey]
+
+	blockImage, err := ceph.ExpandImage(p.context, clusterns, name, pool, uint64(requestedSize.Value()))
+	if err != nil {
+		return resource.Quantity{}, recordFailure(operationExpand, reasonResizeImage, fmt.Errorf("Failed to expand rook block image %s/%s: %v", pool, name, err))
+	}
+	trace.Step("resize image")
+
+	s := fmt.Sprintf("%dMi", blockImage.Size/sizeMB)
+	quantity, err := resource.ParseQuantity(s)
+	if err != nil {
+		return resource.Quantity{}, recordFailure(operationExpand, reasonResizeImage, fmt.Errorf("cannot parse '%v': %v", s, err))
+	}
+	logger.Infof("succeeded expanding volume %s to %s", volume.Name, quantity.String())
+	recordSuccess(operationExpand, trace.Done())
+	return quantity, nil
+}
+
+// RequiresFSResize returns true since the filesystem on a rook block image is grown by the flex driver on the node.
+// The quota of a filesystem subvolume applies immediately.
+func (p *RookVolumeProvisioner) RequiresFSResize(volume *v1.PersistentVolume) bool {
+	flex := volume.Spec.PersistentVolumeSource.FlexVolume
+	return flex == nil || flex.Options[flexvolume.FsNameKey] == ""
+}
+
 func parseStorageClass(options controller.VolumeOptions) (string, error) {
 	if options.PVC.Spec.StorageClassName != nil {
 		return *options.PVC.Spec.StorageClassName, nil
@@ -194,6 +398,7 @@ func parseStorageClass(options controller.VolumeOptions) (string, error) {
 
 func parseClassParameters(params map[string]string) (*provisionerConfig, error) {
 	var cfg provisionerConfig
+	var err error
 
 	for k, v := range params {
 		switch strings.ToLower(k) {
@@ -205,22 +410,111 @@ func parseClassParameters(params map[string]string) (*provisionerConfig, error)
 			cfg.clusterNamespace = v
 		case "clustername":
 			cfg.clusterNamespace = v
+		case "radosnamespace":
+			cfg.radosNamespace = v
+		case "fsname":
+			cfg.fsName = v
+		case "mounter":
+			cfg.mounter = v
 		case "fstype":
 			cfg.fstype = v
 		case "datablockpool":
 			cfg.dataBlockPool = v
+		case "imagefeatures":
+			for _, feature := range strings.Split(v, ",") {
+				if feature = strings.TrimSpace(feature); feature != "" {
+					cfg.imageFeatures = append(cfg.imageFeatures, feature)
+				}
+			}
+		case "stripeunit":
+			if cfg.stripeUnit, err = parseUintParameter(k, v); err != nil {
+				return nil, err
+			}
+		case "stripecount":
+			if cfg.stripeCount, err = parseUintParameter(k, v); err != nil {
+				return nil, err
+			}
 		default:
 			return nil, fmt.Errorf("invalid option %q for volume plugin %s", k, "rookVolumeProvisioner")
 		}
 	}
 
-	if len(cfg.blockPool) == 0 {
-		return nil, fmt.Errorf("StorageClass for provisioner %s must contain 'blockPool' parameter", "rookVolumeProvisioner")
+	if len(cfg.blockPool) == 0 && len(cfg.fsName) == 0 {
+		return nil, fmt.Errorf("StorageClass for provisioner %s must contain 'blockPool' or 'fsName' parameter", "rookVolumeProvisioner")
+	}
+	if len(cfg.blockPool) != 0 && len(cfg.fsName) != 0 {
+		return nil, fmt.Errorf("StorageClass for provisioner %s must not contain both 'blockPool' and 'fsName' parameters", "rookVolumeProvisioner")
+	}
+	if len(cfg.radosNamespace) != 0 && len(cfg.blockPool) == 0 {
+		return nil, fmt.Errorf("StorageClass for provisioner %s with 'radosNamespace' parameter must contain 'blockPool' parameter", "rookVolumeProvisioner")
+	}
+	if len(cfg.fsName) != 0 && (cfg.fstype != "" || cfg.dataBlockPool != "" || len(cfg.imageFeatures) != 0 ||
+		cfg.stripeUnit != 0 || cfg.stripeCount != 0) {
+		return nil, fmt.Errorf("StorageClass for provisioner %s with 'fsName' parameter must not contain block image parameters", "rookVolumeProvisioner")
+	}
+
+	if len(cfg.mounter) != 0 && len(cfg.fsName) == 0 {
+		return nil, fmt.Errorf("StorageClass for provisioner %s with 'mounter' parameter must contain 'fsName' parameter", "rookVolumeProvisioner")
+	}
+	if cfg.mounter != "" && cfg.mounter != flexvolume.MounterKernel && cfg.mounter != flexvolume.MounterFuse {
+		return nil, fmt.Errorf("invalid mounter %q for volume plugin %s, must be %s or %s", cfg.mounter, "rookVolumeProvisioner", flexvolume.MounterKernel, flexvolume.MounterFuse)
 	}
 
 	if len(cfg.clusterNamespace) == 0 {
 		cfg.clusterNamespace = cluster.DefaultClusterName
 	}
 
+	if err := validateImageFeatures(cfg.imageFeatures); err != nil {
+		return nil, err
+	}
+
+	if (cfg.stripeUnit == 0) != (cfg.stripeCount == 0) {
+		return nil, fmt.Errorf("StorageClass for provisioner %s must contain both 'stripeUnit' and 'stripeCount' parameters to enable striping", "rookVolumeProvisioner")
+	}
+
 	return &cfg, nil
 }
+
+// poolSpec returns the pool of the images, with their RADOS namespace if any
+func (c *provisionerConfig) poolSpec() string {
+	return ceph.PoolSpec(c.blockPool, c.radosNamespace)
+}
+
+// imageOptions returns the settings to apply to the rbd image at creation time
+func (c *provisionerConfig) imageOptions() ceph.ImageOptions {
+	return ceph.ImageOptions{
+		DataPoolName: c.dataBlockPool,
+		Features:     c.imageFeatures,
+		StripeUnit:   c.stripeUnit,
+		StripeCount:  c.stripeCount,
+	}
+}
+
+func parseUintParameter(name, value string) (uint64, error) {
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q for option %q of volume plugin %s: %v", value, name, "rookVolumeProvisioner", err)
+	}
+	return v, nil
+}
+
+// validateImageFeatures checks that the features are known and that their dependencies are also requested
+func validateImageFeatures(features []string) error {
+	requested := map[string]bool{}
+	for _, feature := range features {
+		if feature == "journaling" {
+			return fmt.Errorf("image feature %q is not supported, the kernel rbd module cannot map the images with journaling", feature)
+		}
+		if !supportedImageFeatures[feature] {
+			return fmt.Errorf("invalid image feature %q for volume plugin %s", feature, "rookVolumeProvisioner")
+		}
+		requested[feature] = true
+	}
+
+	for feature, dependency := range imageFeatureDependencies {
+		if requested[feature] && !requested[dependency] {
+			return fmt.Errorf("image feature %q requires image feature %q", feature, dependency)
+		}
+	}
+	return nil
+}
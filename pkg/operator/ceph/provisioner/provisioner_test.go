@@ -18,11 +18,13 @@ limitations under the License.
 package provisioner
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/rook/rook/pkg/clusterd"
 	cephtest "github.com/rook/rook/pkg/daemon/ceph/test"
@@ -103,6 +105,41 @@ func TestProvisionImage(t *testing.T) {
 	assert.Equal(t, "iamdatapool", pv.Spec.PersistentVolumeSource.FlexVolume.Options["dataBlockPool"])
 }
 
+func TestProvisionImageInRadosNamespace(t *testing.T) {
+	configDir, _ := ioutil.TempDir("", "")
+	os.Setenv("POD_NAMESPACE", "rook-system")
+	defer os.Setenv("POD_NAMESPACE", "")
+	defer os.RemoveAll(configDir)
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if command == "rbd" && args[0] == "create" {
+				assert.Equal(t, "testpool/tenant-a/pvc-uid-1-1", args[1])
+			}
+			if command == "rbd" && args[0] == "info" {
+				assert.Equal(t, "testpool/tenant-a/pvc-uid-1-1", args[1])
+				return `{"name":"pvc-uid-1-1","size":1048576,"format":2}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset: test.New(3),
+		Executor:  executor,
+		ConfigDir: configDir,
+	}
+
+	provisioner := New(context, "foo.io")
+	volume := newVolumeOptions(newStorageClass("class-1", "foo.io/block", map[string]string{"blockPool": "testpool", "radosNamespace": "tenant-a", "clusterNamespace": "testCluster"}, v1.PersistentVolumeReclaimDelete), newClaim("claim-1", "uid-1-1", "class-1", "", "class-1", nil), v1.PersistentVolumeReclaimDelete)
+
+	pv, err := provisioner.Provision(volume)
+	assert.Nil(t, err)
+	// the attach, expand and delete of the volume use the namespace from the pool of the pv
+	assert.Equal(t, "testpool/tenant-a", pv.Spec.PersistentVolumeSource.FlexVolume.Options["pool"])
+	// the image is mapped with the user of the namespace and the key from its secret
+	assert.Equal(t, "testpool.tenant-a", pv.Spec.PersistentVolumeSource.FlexVolume.Options["mountUser"])
+	assert.Equal(t, "rook-ceph-rados-namespace-tenant-a", pv.Spec.PersistentVolumeSource.FlexVolume.Options["mountSecret"])
+}
+
 func TestReclaimPolicyForProvisionedImages(t *testing.T) {
 	clientset := test.New(3)
 	namespace := "ns"
@@ -145,6 +182,176 @@ func TestReclaimPolicyForProvisionedImages(t *testing.T) {
 	}
 }
 
+func TestExpandImage(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if command == "rbd" && args[0] == "resize" {
+				assert.Equal(t, "testpool/pvc-uid-1-1", args[1])
+				assert.Equal(t, "2048", args[3])
+				return "", nil
+			}
+			if command == "rbd" && args[0] == "info" {
+				return `{"name":"pvc-uid-1-1","size":2147483648,"objects":2048,"order":20,"object_size":1048576,"block_name_prefix":"testpool_data.229226b8b4567",` +
+					`"format":2,"features":["layering"],"op_features":[],"flags":[],"create_timestamp":"Fri Oct  5 19:46:20 2018"}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset: test.New(3),
+		Executor:  executor,
+	}
+
+	p := New(context, "foo.io").(*RookVolumeProvisioner)
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-uid-1-1"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				FlexVolume: &v1.FlexPersistentVolumeSource{
+					Driver: "foo.io/rook",
+					Options: map[string]string{
+						"pool":             "testpool",
+						"image":            "pvc-uid-1-1",
+						"clusterNamespace": "testCluster",
+					},
+				},
+			},
+		},
+	}
+
+	size, err := p.Expand(pv, resource.MustParse("2Gi"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2147483648), size.Value())
+	assert.True(t, p.RequiresFSResize(pv))
+
+	// a volume that was not provisioned as a flex volume cannot be expanded
+	pv.Spec.PersistentVolumeSource.FlexVolume = nil
+	_, err = p.Expand(pv, resource.MustParse("2Gi"))
+	assert.NotNil(t, err)
+}
+
+func TestDeleteImageToTrash(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			commands = append(commands, args[:3])
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset: test.New(3),
+		Executor:  executor,
+	}
+	p := New(context, "foo.io").(*RookVolumeProvisioner)
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-uid-1-1"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				FlexVolume: &v1.FlexPersistentVolumeSource{
+					Driver:  "foo.io/rook",
+					Options: map[string]string{"pool": "testpool", "image": "pvc-uid-1-1", "clusterNamespace": "testCluster"},
+				},
+			},
+		},
+	}
+
+	// the image is deleted immediately by default
+	assert.Nil(t, p.Delete(pv))
+	assert.Equal(t, []string{"rm", "testpool/pvc-uid-1-1", "--cluster=testCluster"}, commands[0])
+
+	// the image is moved to the trash when an expiry is set
+	ImageTrashExpiry = 24 * time.Hour
+	defer func() { ImageTrashExpiry = 0 }()
+	assert.Nil(t, p.Delete(pv))
+	assert.Equal(t, []string{"trash", "mv", "testpool/pvc-uid-1-1"}, commands[1])
+}
+
+func TestFilesystemVolume(t *testing.T) {
+	os.Setenv("POD_NAMESPACE", "rook-system")
+	defer os.Setenv("POD_NAMESPACE", "")
+	var commands [][]string
+	mgrVersion := "14.2.5"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "versions" {
+				return fmt.Sprintf(`{"mgr":{"ceph version %s (ad5bd132e1492173c85fda2cc863152730b16a92) nautilus (stable)":1}}`, mgrVersion), nil
+			}
+			commands = append(commands, args[:6])
+			if args[2] == "getpath" {
+				return "/volumes/_nogroup/pvc-uid-1-1", nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset: test.New(3),
+		Executor:  executor,
+	}
+	p := New(context, "foo.io").(*RookVolumeProvisioner)
+
+	// the volume is a subvolume of the filesystem whose quota is the requested size
+	volume := newVolumeOptions(newStorageClass("class-1", "foo.io/block", map[string]string{"fsName": "myfs", "clusterNamespace": "testCluster"}, v1.PersistentVolumeReclaimDelete), newClaim("claim-1", "uid-1-1", "class-1", "", "class-1", nil), v1.PersistentVolumeReclaimDelete)
+	pv, err := p.Provision(volume)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"fs", "subvolume", "create", "myfs", "pvc-uid-1-1", "--size"}, commands[0])
+	capacity := pv.Spec.Capacity[v1.ResourceStorage]
+	assert.Equal(t, "1Mi", capacity.String())
+	assert.Equal(t, "foo.io/rook", pv.Spec.PersistentVolumeSource.FlexVolume.Driver)
+	assert.Equal(t, "ceph", pv.Spec.PersistentVolumeSource.FlexVolume.FSType)
+	assert.Equal(t, map[string]string{"storageClass": "class-1", "fsName": "myfs", "path": "/volumes/_nogroup/pvc-uid-1-1", "clusterNamespace": "testCluster"},
+		pv.Spec.PersistentVolumeSource.FlexVolume.Options)
+
+	// the quota is updated without resizing a filesystem on the node
+	size, err := p.Expand(pv, resource.MustParse("2Gi"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2147483648), size.Value())
+	assert.Equal(t, []string{"fs", "subvolume", "resize", "myfs", "pvc-uid-1-1", "2147483648"}, commands[2])
+	assert.False(t, p.RequiresFSResize(pv))
+
+	assert.Nil(t, p.Delete(pv))
+	assert.Equal(t, []string{"fs", "subvolume", "rm", "myfs", "pvc-uid-1-1", "--cluster=testCluster"}, commands[3])
+
+	// the mgrs before nautilus do not manage the subvolumes
+	mgrVersion = "13.2.2"
+	_, err = p.Provision(volume)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "nautilus")
+	assert.Equal(t, 4, len(commands))
+}
+
+func TestParseClassParametersFilesystem(t *testing.T) {
+	cfg := map[string]string{"fsName": "myfs"}
+	provConfig, err := parseClassParameters(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, "myfs", provConfig.fsName)
+	assert.Equal(t, "", provConfig.blockPool)
+
+	// the volumes are either images or subvolumes
+	cfg["blockPool"] = "testPool"
+	_, err = parseClassParameters(cfg)
+	assert.NotNil(t, err)
+
+	// the image options do not apply to the subvolumes
+	delete(cfg, "blockPool")
+	cfg["fstype"] = "xfs"
+	_, err = parseClassParameters(cfg)
+	assert.NotNil(t, err)
+
+	// the mounter of the subvolumes is kernel or fuse
+	delete(cfg, "fstype")
+	cfg["mounter"] = "fuse"
+	provConfig, err = parseClassParameters(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, "fuse", provConfig.mounter)
+	cfg["mounter"] = "nfs"
+	_, err = parseClassParameters(cfg)
+	assert.NotNil(t, err)
+
+	// the mounter does not apply to the images
+	_, err = parseClassParameters(map[string]string{"blockPool": "testPool", "mounter": "kernel"})
+	assert.NotNil(t, err)
+}
+
 func TestParseClassParameters(t *testing.T) {
 	cfg := make(map[string]string)
 	cfg["pool"] = "testPool"
@@ -171,12 +378,67 @@ func TestParseClassParametersDefault(t *testing.T) {
 	assert.Equal(t, "", provConfig.fstype)
 }
 
+func TestParseClassParametersRadosNamespace(t *testing.T) {
+	cfg := map[string]string{"blockPool": "testPool", "radosNamespace": "tenant-a"}
+	provConfig, err := parseClassParameters(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, "tenant-a", provConfig.radosNamespace)
+	assert.Equal(t, "testPool/tenant-a", provConfig.poolSpec())
+
+	// the namespace is in a block pool
+	cfg = map[string]string{"fsName": "myfs", "radosNamespace": "tenant-a"}
+	_, err = parseClassParameters(cfg)
+	assert.NotNil(t, err)
+}
+
+func TestParseClassParametersImageOptions(t *testing.T) {
+	cfg := make(map[string]string)
+	cfg["blockPool"] = "testPool"
+	cfg["imageFeatures"] = "layering, exclusive-lock,object-map,fast-diff"
+	cfg["stripeUnit"] = "65536"
+	cfg["stripeCount"] = "8"
+
+	provConfig, err := parseClassParameters(cfg)
+	assert.Nil(t, err)
+
+	opts := provConfig.imageOptions()
+	assert.Equal(t, []string{"layering", "exclusive-lock", "object-map", "fast-diff"}, opts.Features)
+	assert.Equal(t, uint64(65536), opts.StripeUnit)
+	assert.Equal(t, uint64(8), opts.StripeCount)
+
+	// fast-diff without object-map
+	cfg["imageFeatures"] = "layering,fast-diff"
+	_, err = parseClassParameters(cfg)
+	assert.EqualError(t, err, "image feature \"fast-diff\" requires image feature \"object-map\"")
+
+	// the kernel rbd module cannot map the images with journaling
+	cfg["imageFeatures"] = "layering,exclusive-lock,journaling"
+	_, err = parseClassParameters(cfg)
+	assert.EqualError(t, err, "image feature \"journaling\" is not supported, the kernel rbd module cannot map the images with journaling")
+
+	// unknown feature
+	cfg["imageFeatures"] = "turbo"
+	_, err = parseClassParameters(cfg)
+	assert.EqualError(t, err, "invalid image feature \"turbo\" for volume plugin rookVolumeProvisioner")
+
+	// striping requires both the unit and the count
+	cfg["imageFeatures"] = "layering"
+	delete(cfg, "stripeCount")
+	_, err = parseClassParameters(cfg)
+	assert.NotNil(t, err)
+
+	// stripe settings must be numbers
+	cfg["stripeCount"] = "many"
+	_, err = parseClassParameters(cfg)
+	assert.NotNil(t, err)
+}
+
 func TestParseClassParametersNoPool(t *testing.T) {
 	cfg := make(map[string]string)
 	cfg["clustername"] = "myname"
 
 	_, err := parseClassParameters(cfg)
-	assert.EqualError(t, err, "StorageClass for provisioner rookVolumeProvisioner must contain 'blockPool' parameter")
+	assert.EqualError(t, err, "StorageClass for provisioner rookVolumeProvisioner must contain 'blockPool' or 'fsName' parameter")
 
 }
 
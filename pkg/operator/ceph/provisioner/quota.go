@@ -0,0 +1,240 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// StorageQuotaIgnore disables the storage quotas of the namespaces
+	StorageQuotaIgnore = "Ignore"
+	// StorageQuotaAlert publishes the allocation of the namespaces against their quota and warns when it is exceeded
+	StorageQuotaAlert = "Alert"
+	// StorageQuotaBlock also refuses to provision or expand the volumes of a namespace beyond its quota
+	StorageQuotaBlock = "Block"
+
+	// StorageQuotaAnnotation is the annotation of a namespace with the capacity of the ceph volumes it can claim
+	StorageQuotaAnnotation = "ceph.rook.io/storage-quota"
+
+	reasonQuotaExceeded = "quota_exceeded"
+	// the reason of the event of a namespace exceeding its quota
+	eventQuotaExceeded = "StorageQuotaExceeded"
+)
+
+var (
+	// StorageQuotaPolicy is what is done when a namespace claims more ceph volumes than its quota
+	StorageQuotaPolicy = StorageQuotaIgnore
+	// StorageQuotaCheckInterval is the interval between the updates of the allocations of the namespaces
+	StorageQuotaCheckInterval = 5 * time.Minute
+
+	namespaceQuotaBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "namespace_quota_bytes",
+			Help:      "Capacity of the ceph volumes the namespace can claim, from its storage quota annotation",
+		},
+		[]string{"namespace"},
+	)
+	namespaceAllocatedBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "rook",
+			Subsystem: "ceph",
+			Name:      "namespace_allocated_bytes",
+			Help:      "Capacity of the ceph volumes claimed by the namespace",
+		},
+		[]string{"namespace"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(namespaceQuotaBytes, namespaceAllocatedBytes)
+}
+
+// QuotaChecker tracks the capacity of the ceph volumes claimed by the namespaces with a storage quota, for the
+// platforms charging the tenants back for their storage
+type QuotaChecker struct {
+	context *clusterd.Context
+	policy  string
+	// the namespaces beyond their quota at the last check, to record a single event when they exceed it
+	exceeded map[string]bool
+}
+
+// NewQuotaChecker creates a checker of the storage quotas of the namespaces with the given policy
+func NewQuotaChecker(context *clusterd.Context, policy string) (*QuotaChecker, error) {
+	switch policy {
+	case StorageQuotaIgnore, StorageQuotaAlert, StorageQuotaBlock:
+	default:
+		return nil, fmt.Errorf("invalid storage quota policy %q. must be one of %s, %s or %s",
+			policy, StorageQuotaIgnore, StorageQuotaAlert, StorageQuotaBlock)
+	}
+	return &QuotaChecker{context: context, policy: policy, exceeded: map[string]bool{}}, nil
+}
+
+// Start updates the allocations of the namespaces at the configured interval until the stop channel is closed
+func (q *QuotaChecker) Start(stopCh chan struct{}) {
+	if q.policy == StorageQuotaIgnore {
+		logger.Infof("storage quotas of the namespaces are disabled")
+		return
+	}
+
+	logger.Infof("checking the storage quotas of the namespaces every %v with policy %s", StorageQuotaCheckInterval, q.policy)
+	for {
+		select {
+		case <-time.After(StorageQuotaCheckInterval):
+			if err := q.check(); err != nil {
+				logger.Warningf("failed to check the storage quotas. %+v", err)
+			}
+
+		case <-stopCh:
+			logger.Infof("stopping the check of the storage quotas")
+			return
+		}
+	}
+}
+
+func (q *QuotaChecker) check() error {
+	namespaces, err := q.context.Clientset.CoreV1().Namespaces().List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces. %+v", err)
+	}
+	allocations, err := allocatedBytes(q.context)
+	if err != nil {
+		return err
+	}
+
+	namespaceQuotaBytes.Reset()
+	namespaceAllocatedBytes.Reset()
+	exceeded := map[string]bool{}
+	for _, ns := range namespaces.Items {
+		quota, ok := namespaceQuota(&ns)
+		if !ok {
+			continue
+		}
+		allocated := allocations[ns.Name]
+		namespaceQuotaBytes.WithLabelValues(ns.Name).Set(float64(quota))
+		namespaceAllocatedBytes.WithLabelValues(ns.Name).Set(float64(allocated))
+		if allocated <= quota {
+			continue
+		}
+		exceeded[ns.Name] = true
+		message := fmt.Sprintf("namespace %s claimed %d bytes of ceph volumes, beyond its quota of %d bytes", ns.Name, allocated, quota)
+		logger.Warning(message)
+		if q.exceeded[ns.Name] {
+			continue
+		}
+		if err := q.recordNamespaceEvent(&ns, message); err != nil {
+			logger.Warningf("failed to record the quota event of namespace %s. %+v", ns.Name, err)
+		}
+	}
+	q.exceeded = exceeded
+	return nil
+}
+
+// recordNamespaceEvent creates a warning event for a namespace which exceeded its quota
+func (q *QuotaChecker) recordNamespaceEvent(ns *v1.Namespace, message string) error {
+	now := metav1.Now()
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", ns.Name, now.UnixNano()),
+			Namespace: ns.Name,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+			Name:       ns.Name,
+			UID:        ns.UID,
+		},
+		Reason:         eventQuotaExceeded,
+		Message:        message,
+		Type:           v1.EventTypeWarning,
+		Source:         v1.EventSource{Component: "rook-ceph-operator"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	_, err := q.context.Clientset.CoreV1().Events(ns.Name).Create(event)
+	return err
+}
+
+// checkQuota returns an error if the namespace would exceed its storage quota by claiming the additional bytes. The
+// volumes claimed at the same time are not counted, so the quota can be slightly exceeded by concurrent claims.
+func checkQuota(context *clusterd.Context, namespace string, additionalBytes int64) error {
+	if StorageQuotaPolicy != StorageQuotaBlock || additionalBytes <= 0 {
+		return nil
+	}
+	ns, err := context.Clientset.CoreV1().Namespaces().Get(namespace, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get namespace %s. %+v", namespace, err)
+	}
+	quota, ok := namespaceQuota(ns)
+	if !ok {
+		return nil
+	}
+	allocations, err := allocatedBytes(context)
+	if err != nil {
+		return err
+	}
+	if allocated := allocations[namespace]; allocated+additionalBytes > quota {
+		return fmt.Errorf("namespace %s claimed %d bytes of ceph volumes and cannot claim %d more bytes beyond its quota of %d bytes",
+			namespace, allocated, additionalBytes, quota)
+	}
+	return nil
+}
+
+// namespaceQuota returns the storage quota of the namespace in bytes, if it has a valid one
+func namespaceQuota(ns *v1.Namespace) (int64, bool) {
+	value, ok := ns.Annotations[StorageQuotaAnnotation]
+	if !ok {
+		return 0, false
+	}
+	quota, err := resource.ParseQuantity(value)
+	if err != nil {
+		logger.Warningf("ignoring invalid storage quota %q of namespace %s. %+v", value, ns.Name, err)
+		return 0, false
+	}
+	return quota.Value(), true
+}
+
+// allocatedBytes returns the capacity of the ceph volumes claimed by each namespace. The released volumes are counted
+// until they are deleted since they still use the capacity of the cluster.
+func allocatedBytes(context *clusterd.Context) (map[string]int64, error) {
+	pvs, err := context.Clientset.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volumes. %+v", err)
+	}
+
+	allocations := map[string]int64{}
+	for _, pv := range pvs.Items {
+		flex := pv.Spec.PersistentVolumeSource.FlexVolume
+		if flex == nil || flex.Options[flexvolume.ClusterNamespaceKey] == "" || pv.Spec.ClaimRef == nil {
+			continue
+		}
+		capacity := pv.Spec.Capacity[v1.ResourceStorage]
+		allocations[pv.Spec.ClaimRef.Namespace] += capacity.Value()
+	}
+	return allocations, nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newQuotaTestPV(name, namespace, size string, flexOptions map[string]string) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse(size)},
+			PersistentVolumeSource: v1.PersistentVolumeSource{
+				FlexVolume: &v1.FlexPersistentVolumeSource{Driver: "ceph.rook.io/rook-ceph-system", Options: flexOptions},
+			},
+			ClaimRef: &v1.ObjectReference{Namespace: namespace, Name: name},
+		},
+	}
+}
+
+func newQuotaTestContext() *clusterd.Context {
+	tenant := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Annotations: map[string]string{StorageQuotaAnnotation: "10Gi"}}}
+	other := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b"}}
+	ceph := map[string]string{"clusterNamespace": "rook-ceph", "pool": "replicapool", "image": "pvc-1"}
+	pv1 := newQuotaTestPV("pvc-1", "tenant-a", "4Gi", ceph)
+	pv2 := newQuotaTestPV("pvc-2", "tenant-a", "4Gi", ceph)
+	pv3 := newQuotaTestPV("pvc-3", "tenant-b", "100Gi", ceph)
+	// the volumes of the other drivers are not counted
+	nfs := newQuotaTestPV("pvc-4", "tenant-a", "100Gi", nil)
+	nfs.Spec.PersistentVolumeSource = v1.PersistentVolumeSource{NFS: &v1.NFSVolumeSource{Server: "nfs", Path: "/"}}
+	return &clusterd.Context{Clientset: fake.NewSimpleClientset(tenant, other, pv1, pv2, pv3, nfs)}
+}
+
+func TestNewQuotaChecker(t *testing.T) {
+	_, err := NewQuotaChecker(&clusterd.Context{}, StorageQuotaBlock)
+	assert.Nil(t, err)
+	_, err = NewQuotaChecker(&clusterd.Context{}, "Deny")
+	assert.NotNil(t, err)
+}
+
+func TestAllocatedBytes(t *testing.T) {
+	allocations, err := allocatedBytes(newQuotaTestContext())
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int64{"tenant-a": 8 << 30, "tenant-b": 100 << 30}, allocations)
+}
+
+func TestNamespaceQuota(t *testing.T) {
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a"}}
+	_, ok := namespaceQuota(ns)
+	assert.False(t, ok)
+
+	ns.Annotations = map[string]string{StorageQuotaAnnotation: "1Ti"}
+	quota, ok := namespaceQuota(ns)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1<<40), quota)
+
+	ns.Annotations[StorageQuotaAnnotation] = "lots"
+	_, ok = namespaceQuota(ns)
+	assert.False(t, ok)
+}
+
+func TestCheckQuota(t *testing.T) {
+	context := newQuotaTestContext()
+	defer func() { StorageQuotaPolicy = StorageQuotaIgnore }()
+
+	// the claims are only blocked by the Block policy
+	StorageQuotaPolicy = StorageQuotaAlert
+	assert.Nil(t, checkQuota(context, "tenant-a", 4<<30))
+
+	StorageQuotaPolicy = StorageQuotaBlock
+	assert.Nil(t, checkQuota(context, "tenant-a", 2<<30))
+	assert.NotNil(t, checkQuota(context, "tenant-a", 3<<30))
+	// a namespace without quota is not limited
+	assert.Nil(t, checkQuota(context, "tenant-b", 1<<40))
+	// shrinking or keeping the size of a volume is always allowed
+	assert.Nil(t, checkQuota(context, "tenant-a", 0))
+
+	checker, _ := NewQuotaChecker(context, StorageQuotaAlert)
+	assert.Nil(t, checker.check())
+}
+
+func TestQuotaExceededEvent(t *testing.T) {
+	context := newQuotaTestContext()
+	checker, _ := NewQuotaChecker(context, StorageQuotaAlert)
+	assert.Nil(t, checker.check())
+	events, err := context.Clientset.CoreV1().Events("tenant-a").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(events.Items))
+
+	// a single event is recorded while the namespace stays beyond its quota
+	ns, _ := context.Clientset.CoreV1().Namespaces().Get("tenant-a", metav1.GetOptions{})
+	ns.Annotations[StorageQuotaAnnotation] = "6Gi"
+	_, err = context.Clientset.CoreV1().Namespaces().Update(ns)
+	assert.Nil(t, err)
+	assert.Nil(t, checker.check())
+	assert.Nil(t, checker.check())
+	events, err = context.Clientset.CoreV1().Events("tenant-a").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Equal(t, "StorageQuotaExceeded", events.Items[0].Reason)
+	assert.Equal(t, v1.EventTypeWarning, events.Items[0].Type)
+	assert.Equal(t, "Namespace", events.Items[0].InvolvedObject.Kind)
+}
+
+func TestProvisionBeyondQuota(t *testing.T) {
+	context := newQuotaTestContext()
+	StorageQuotaPolicy = StorageQuotaBlock
+	defer func() { StorageQuotaPolicy = StorageQuotaIgnore }()
+
+	provisioner := New(context, "foo.io")
+	claim := newClaim("claim-1", "uid-1-1", "class-1", "", "class-1", nil)
+	claim.Namespace = "tenant-a"
+	claim.Spec.Resources.Requests = v1.ResourceList{v1.ResourceStorage: resource.MustParse("3Gi")}
+	volume := newVolumeOptions(newStorageClass("class-1", "foo.io/block", map[string]string{"blockPool": "replicapool"}, v1.PersistentVolumeReclaimDelete), claim, v1.PersistentVolumeReclaimDelete)
+
+	_, err := provisioner.Provision(volume)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "beyond its quota")
+
+	// the expansion of a volume counts the additional capacity only
+	pv := newQuotaTestPV("pvc-1", "tenant-a", "4Gi", map[string]string{"clusterNamespace": "rook-ceph", "pool": "replicapool", "image": "pvc-1"})
+	_, err = provisioner.(*RookVolumeProvisioner).Expand(pv, resource.MustParse("7Gi"))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "beyond its quota")
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smb
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+)
+
+const (
+	configFileName = "smb.conf"
+
+	defaultWorkgroup  = "WORKGROUP"
+	defaultIDMapRange = "10000-999999"
+	// the ids of the local and builtin accounts when the domain users are mapped with rid
+	defaultLocalIDMapRange = "3000-7999"
+)
+
+// generateConfig returns the smb.conf of a gateway, exporting each share with the ceph vfs module so the gateway
+// does not need to mount the filesystems
+func generateConfig(gateway *cephv1.CephSMBGateway) string {
+	security := gateway.Spec.Security
+	workgroup := security.Workgroup
+	if workgroup == "" {
+		workgroup = defaultWorkgroup
+	}
+
+	var b strings.Builder
+	section := func(name string) { fmt.Fprintf(&b, "[%s]\n", name) }
+	param := func(key, value string) { fmt.Fprintf(&b, "\t%s = %s\n", key, value) }
+
+	section("global")
+	param("netbios name", strings.ToUpper(netbiosName(gateway)))
+	param("workgroup", strings.ToUpper(workgroup))
+	param("security", securityMode(gateway))
+	if securityMode(gateway) == SecurityModeADS {
+		idmapRange := security.IDMapRange
+		if idmapRange == "" {
+			idmapRange = defaultIDMapRange
+		}
+		param("realm", strings.ToUpper(security.Realm))
+		param("idmap config * : backend", "tdb")
+		param("idmap config * : range", defaultLocalIDMapRange)
+		param(fmt.Sprintf("idmap config %s : backend", strings.ToUpper(workgroup)), "rid")
+		param(fmt.Sprintf("idmap config %s : range", strings.ToUpper(workgroup)), idmapRange)
+		param("winbind use default domain", "yes")
+	} else {
+		param("server role", "standalone server")
+		param("map to guest", "never")
+	}
+	param("load printers", "no")
+	param("printing", "bsd")
+	param("printcap name", "/dev/null")
+	param("disable spoolss", "yes")
+
+	for _, share := range gateway.Spec.Shares {
+		sharePath := share.Path
+		if sharePath == "" {
+			sharePath = "/"
+		}
+		b.WriteString("\n")
+		section(share.Name)
+		param("path", path.Clean("/"+sharePath))
+		param("vfs objects", "ceph")
+		param("ceph:config_file", cephconfig.DefaultConfigFilePath())
+		param("ceph:user_id", cephUserID(gateway))
+		param("ceph:filesystem", share.Filesystem)
+		// the ceph vfs module cannot take the kernel locks
+		param("kernel share modes", "no")
+		param("read only", yesNo(share.ReadOnly))
+		param("browseable", "yes")
+		if len(share.ValidUsers) > 0 {
+			param("valid users", strings.Join(share.ValidUsers, ", "))
+		}
+	}
+	return b.String()
+}
+
+// cephCaps returns the caps of the ceph user of a gateway, which can only access the data of the filesystems of
+// its shares
+func cephCaps(gateway *cephv1.CephSMBGateway) []string {
+	filesystems := map[string]bool{}
+	for _, share := range gateway.Spec.Shares {
+		filesystems[share.Filesystem] = true
+	}
+	var osdCaps []string
+	for fs := range filesystems {
+		osdCaps = append(osdCaps, fmt.Sprintf("allow rw tag cephfs data=%s", fs))
+	}
+	sort.Strings(osdCaps)
+	return []string{"mon", "allow r", "mds", "allow rw", "osd", strings.Join(osdCaps, ", ")}
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
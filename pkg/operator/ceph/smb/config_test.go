@@ -0,0 +1,54 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smb
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateConfig(t *testing.T) {
+	gateway := newTestGateway()
+	gateway.Spec.Shares = append(gateway.Spec.Shares, cephv1.SMBShareSpec{Name: "docs", Filesystem: "otherfs", ReadOnly: true, ValidUsers: []string{"alice", "@admins"}})
+	config := generateConfig(gateway)
+
+	assert.True(t, strings.HasPrefix(config, "[global]\n\tnetbios name = MY-SMB\n\tworkgroup = WORKGROUP\n\tsecurity = user\n"))
+	assert.Contains(t, config, "\n[share]\n\tpath = /data\n\tvfs objects = ceph\n\tceph:config_file = /etc/ceph/ceph.conf\n\tceph:user_id = smb.my-smb\n\tceph:filesystem = myfs\n")
+	assert.Contains(t, config, "\n[docs]\n\tpath = /\n")
+	assert.Contains(t, config, "\tceph:filesystem = otherfs\n\tkernel share modes = no\n\tread only = yes\n\tbrowseable = yes\n\tvalid users = alice, @admins\n")
+	assert.NotContains(t, config, "realm")
+
+	gateway.Spec.NetbiosName = "mysmb"
+	gateway.Spec.Security = cephv1.SMBSecuritySpec{Mode: SecurityModeADS, Workgroup: "example", Realm: "example.com", JoinSecretName: "smb-join"}
+	config = generateConfig(gateway)
+	assert.Contains(t, config, "\tnetbios name = MYSMB\n\tworkgroup = EXAMPLE\n\tsecurity = ads\n\trealm = EXAMPLE.COM\n")
+	assert.Contains(t, config, "\tidmap config EXAMPLE : backend = rid\n\tidmap config EXAMPLE : range = 10000-999999\n")
+
+	gateway.Spec.Security.IDMapRange = "20000-29999"
+	assert.Contains(t, generateConfig(gateway), "\tidmap config EXAMPLE : range = 20000-29999\n")
+}
+
+func TestCephCaps(t *testing.T) {
+	gateway := newTestGateway()
+	gateway.Spec.Shares = append(gateway.Spec.Shares,
+		cephv1.SMBShareSpec{Name: "b", Filesystem: "afs"},
+		cephv1.SMBShareSpec{Name: "c", Filesystem: "myfs"})
+	assert.Equal(t, []string{"mon", "allow r", "mds", "allow rw", "osd", "allow rw tag cephfs data=afs, allow rw tag cephfs data=myfs"}, cephCaps(gateway))
+}
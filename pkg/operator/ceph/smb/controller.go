@@ -0,0 +1,411 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package smb to deploy the samba gateways exporting the filesystems to the windows clients.
+package smb
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/maintenance"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// SecurityModeUser authenticates the local users of the gateway
+	SecurityModeUser = "user"
+	// SecurityModeADS joins the gateway to an Active Directory domain
+	SecurityModeADS = "ads"
+
+	appName           = "rook-ceph-smb"
+	maxNetbiosNameLen = 15
+	// the characters ending a line or starting a section of the smb.conf
+	invalidConfigChars = "\r\n[]"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-smb")
+
+// SMBGatewayResource represents the samba gateway custom resource
+var SMBGatewayResource = opkit.CustomResource{
+	Name:    "cephsmbgateway",
+	Plural:  "cephsmbgateways",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephSMBGateway{}).Name(),
+}
+
+// SMBGatewayController represents a controller object for samba gateway custom resources
+type SMBGatewayController struct {
+	context     *clusterd.Context
+	rookVersion string
+	ownerRef    metav1.OwnerReference
+}
+
+// NewSMBGatewayController create controller for watching samba gateway custom resources created
+func NewSMBGatewayController(context *clusterd.Context, rookVersion string, ownerRef metav1.OwnerReference) *SMBGatewayController {
+	return &SMBGatewayController{
+		context:     context,
+		rookVersion: rookVersion,
+		ownerRef:    ownerRef,
+	}
+}
+
+// StartWatch watches for instances of SMBGateway custom resources and acts on them
+func (c *SMBGatewayController) StartWatch(namespace string, stopCh chan struct{}) error {
+
+	// the resources are not reconciled while their cluster is in maintenance mode
+	resourceHandlerFuncs := maintenance.PausableHandlers(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	})
+
+	logger.Infof("start watching samba gateway resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(SMBGatewayResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephSMBGateway{}, stopCh)
+
+	return nil
+}
+
+func (c *SMBGatewayController) onAdd(obj interface{}) {
+	gateway, ok := obj.(*cephv1.CephSMBGateway)
+	if !ok {
+		logger.Errorf("unexpected samba gateway object %+v", obj)
+		return
+	}
+
+	if err := c.reconcileGateway(gateway); err != nil {
+		logger.Errorf("failed to create samba gateway %s. %+v", gateway.Name, err)
+	}
+}
+
+func (c *SMBGatewayController) onUpdate(oldObj, newObj interface{}) {
+	oldGateway, ok := oldObj.(*cephv1.CephSMBGateway)
+	if !ok {
+		logger.Errorf("unexpected old samba gateway object %+v", oldObj)
+		return
+	}
+	gateway, ok := newObj.(*cephv1.CephSMBGateway)
+	if !ok {
+		logger.Errorf("unexpected new samba gateway object %+v", newObj)
+		return
+	}
+
+	if reflect.DeepEqual(oldGateway.Spec, gateway.Spec) {
+		logger.Debugf("samba gateway %s not changed", gateway.Name)
+		return
+	}
+
+	logger.Infof("updating samba gateway %s", gateway.Name)
+	if err := c.reconcileGateway(gateway); err != nil {
+		logger.Errorf("failed to update samba gateway %s. %+v", gateway.Name, err)
+	}
+}
+
+func (c *SMBGatewayController) onDelete(obj interface{}) {
+	gateway, ok := obj.(*cephv1.CephSMBGateway)
+	if !ok {
+		logger.Errorf("unexpected samba gateway object %+v", obj)
+		return
+	}
+
+	if err := c.deleteGateway(gateway); err != nil {
+		logger.Errorf("failed to delete samba gateway %s. %+v", gateway.Name, err)
+	}
+}
+
+// reconcileGateway creates or updates the keyring, the config, the deployment and the service of a gateway
+func (c *SMBGatewayController) reconcileGateway(gateway *cephv1.CephSMBGateway) error {
+	if err := validateGateway(c.context, gateway); err != nil {
+		return fmt.Errorf("invalid samba gateway %s. %+v", gateway.Name, err)
+	}
+
+	if err := c.createKeyring(gateway); err != nil {
+		return err
+	}
+
+	config := generateConfig(gateway)
+	if err := c.createOrUpdateConfigMap(gateway, config); err != nil {
+		return err
+	}
+
+	d := makeDeployment(gateway, c.rookVersion, k8sutil.Hash(config))
+	k8sutil.SetOwnerRef(c.context.Clientset, gateway.Namespace, &d.ObjectMeta, &c.ownerRef)
+	if _, err := c.context.Clientset.ExtensionsV1beta1().Deployments(gateway.Namespace).Create(d); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create samba gateway deployment. %+v", err)
+		}
+		logger.Infof("samba gateway deployment %s already exists. updating if needed", d.Name)
+		if _, err := c.context.Clientset.ExtensionsV1beta1().Deployments(gateway.Namespace).Update(d); err != nil {
+			return fmt.Errorf("failed to update samba gateway deployment. %+v", err)
+		}
+	}
+
+	if err := c.createOrUpdateService(gateway); err != nil {
+		return err
+	}
+	logger.Infof("samba gateway %s exporting %d shares", gateway.Name, len(gateway.Spec.Shares))
+	return nil
+}
+
+// createKeyring creates the ceph user of the gateway, then updates its caps since the filesystems of the shares may
+// have changed
+func (c *SMBGatewayController) createKeyring(gateway *cephv1.CephSMBGateway) error {
+	caps := cephCaps(gateway)
+	config := opspec.KeyringConfig{
+		Namespace:    gateway.Namespace,
+		ResourceName: instanceName(gateway),
+		DaemonName:   gateway.Name,
+		OwnerRef:     c.ownerRef,
+		Username:     cephUsername(gateway),
+		Access:       caps,
+	}
+	if err := opspec.CreateKeyring(c.context, config); err != nil {
+		return fmt.Errorf("failed to create the keyring of samba gateway %s. %+v", gateway.Name, err)
+	}
+	return client.AuthUpdateCaps(c.context, gateway.Namespace, cephUsername(gateway), caps)
+}
+
+func (c *SMBGatewayController) createOrUpdateConfigMap(gateway *cephv1.CephSMBGateway, config string) error {
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instanceName(gateway),
+			Namespace: gateway.Namespace,
+			Labels:    opspec.AppLabels(appName, gateway.Namespace),
+		},
+		Data: map[string]string{configFileName: config},
+	}
+	k8sutil.SetOwnerRef(c.context.Clientset, gateway.Namespace, &configMap.ObjectMeta, &c.ownerRef)
+	if _, err := c.context.Clientset.CoreV1().ConfigMaps(gateway.Namespace).Create(configMap); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create samba gateway config map. %+v", err)
+		}
+		if _, err := c.context.Clientset.CoreV1().ConfigMaps(gateway.Namespace).Update(configMap); err != nil {
+			return fmt.Errorf("failed to update samba gateway config map. %+v", err)
+		}
+	}
+	return nil
+}
+
+func (c *SMBGatewayController) createOrUpdateService(gateway *cephv1.CephSMBGateway) error {
+	s := makeService(gateway)
+	k8sutil.SetOwnerRef(c.context.Clientset, gateway.Namespace, &s.ObjectMeta, &c.ownerRef)
+	existing, err := c.context.Clientset.CoreV1().Services(gateway.Namespace).Get(s.Name, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get samba gateway service. %+v", err)
+		}
+		if _, err := c.context.Clientset.CoreV1().Services(gateway.Namespace).Create(s); err != nil {
+			return fmt.Errorf("failed to create samba gateway service. %+v", err)
+		}
+		return nil
+	}
+
+	// the cluster ip of a service is immutable
+	existing.Spec.Type = s.Spec.Type
+	existing.Spec.Ports = s.Spec.Ports
+	existing.Spec.Selector = s.Spec.Selector
+	if _, err := c.context.Clientset.CoreV1().Services(gateway.Namespace).Update(existing); err != nil {
+		return fmt.Errorf("failed to update samba gateway service. %+v", err)
+	}
+	return nil
+}
+
+// deleteGateway removes the resources of a gateway and its ceph user
+func (c *SMBGatewayController) deleteGateway(gateway *cephv1.CephSMBGateway) error {
+	name := instanceName(gateway)
+	if err := k8sutil.DeleteDeployment(c.context.Clientset, gateway.Namespace, name); err != nil {
+		return err
+	}
+	options := &metav1.DeleteOptions{}
+	if err := c.context.Clientset.CoreV1().Services(gateway.Namespace).Delete(name, options); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete samba gateway service. %+v", err)
+	}
+	if err := c.context.Clientset.CoreV1().ConfigMaps(gateway.Namespace).Delete(name, options); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete samba gateway config map. %+v", err)
+	}
+	if err := c.context.Clientset.CoreV1().Secrets(gateway.Namespace).Delete(name, options); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete samba gateway keyring. %+v", err)
+	}
+	if err := client.AuthDelete(c.context, gateway.Namespace, cephUsername(gateway)); err != nil {
+		return err
+	}
+	logger.Infof("samba gateway %s deleted", gateway.Name)
+	return nil
+}
+
+// validateGateway checks the settings of a gateway and that the filesystems of its shares exist
+func validateGateway(context *clusterd.Context, gateway *cephv1.CephSMBGateway) error {
+	spec := gateway.Spec
+	if spec.Image == "" {
+		return fmt.Errorf("the samba image is required")
+	}
+	if len(netbiosName(gateway)) > maxNetbiosNameLen {
+		return fmt.Errorf("the netbios name %s is longer than %d characters", netbiosName(gateway), maxNetbiosNameLen)
+	}
+	if err := validateConfigValue("netbios name", netbiosName(gateway)); err != nil {
+		return err
+	}
+
+	if err := validateConfigValue("workgroup", spec.Security.Workgroup); err != nil {
+		return err
+	}
+	if err := validateConfigValue("realm", spec.Security.Realm); err != nil {
+		return err
+	}
+	if spec.Security.IDMapRange != "" {
+		if err := validateIDMapRange(spec.Security.IDMapRange); err != nil {
+			return err
+		}
+	}
+
+	if len(spec.Shares) == 0 {
+		return fmt.Errorf("at least one share is required")
+	}
+	names := map[string]bool{}
+	for _, share := range spec.Shares {
+		if share.Name == "" {
+			return fmt.Errorf("the name of a share is required")
+		}
+		if err := validateConfigValue("share name", share.Name); err != nil {
+			return err
+		}
+		if strings.EqualFold(share.Name, "global") {
+			return fmt.Errorf("the share name %s is reserved", share.Name)
+		}
+		if names[share.Name] {
+			return fmt.Errorf("duplicate share %s", share.Name)
+		}
+		names[share.Name] = true
+		if err := validateConfigValue("path of share "+share.Name, share.Path); err != nil {
+			return err
+		}
+		for _, user := range share.ValidUsers {
+			if err := validateConfigValue("valid user of share "+share.Name, user); err != nil {
+				return err
+			}
+		}
+		if share.Filesystem == "" {
+			return fmt.Errorf("the filesystem of share %s is required", share.Name)
+		}
+		if _, err := context.RookClientset.CephV1().CephFilesystems(gateway.Namespace).Get(share.Filesystem, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("failed to get the filesystem %s of share %s. %+v", share.Filesystem, share.Name, err)
+		}
+	}
+
+	switch securityMode(gateway) {
+	case SecurityModeUser:
+		if spec.Security.UsersSecretName == "" {
+			return fmt.Errorf("usersSecretName is required with the %s security mode", SecurityModeUser)
+		}
+	case SecurityModeADS:
+		if spec.Security.Realm == "" || spec.Security.JoinSecretName == "" {
+			return fmt.Errorf("realm and joinSecretName are required with the %s security mode", SecurityModeADS)
+		}
+	default:
+		return fmt.Errorf("invalid security mode %s. must be %s or %s", spec.Security.Mode, SecurityModeUser, SecurityModeADS)
+	}
+
+	switch spec.ServiceType {
+	case "", v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer:
+	default:
+		return fmt.Errorf("invalid service type %s", spec.ServiceType)
+	}
+	return nil
+}
+
+// validateConfigValue rejects the values that would end their line or start a new section of the smb.conf
+func validateConfigValue(field, value string) error {
+	if strings.ContainsAny(value, invalidConfigChars) {
+		return fmt.Errorf("invalid %s %q. must not contain newlines or brackets", field, value)
+	}
+	return nil
+}
+
+// validateIDMapRange checks that the idmap range of the domain is a <low>-<high> range of unix ids that does not overlap
+// the range of the local ids of the default tdb backend
+func validateIDMapRange(idmapRange string) error {
+	low, high, err := parseIDMapRange(idmapRange)
+	if err != nil {
+		return fmt.Errorf("invalid idmap range %q. %+v", idmapRange, err)
+	}
+	localLow, localHigh, _ := parseIDMapRange(defaultLocalIDMapRange)
+	if low <= localHigh && high >= localLow {
+		return fmt.Errorf("the idmap range %s overlaps the range %s of the local ids", idmapRange, defaultLocalIDMapRange)
+	}
+	return nil
+}
+
+func parseIDMapRange(idmapRange string) (uint32, uint32, error) {
+	bounds := strings.Split(idmapRange, "-")
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("must be <low>-<high>")
+	}
+	low, err := strconv.ParseUint(bounds[0], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid low id. %+v", err)
+	}
+	high, err := strconv.ParseUint(bounds[1], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid high id. %+v", err)
+	}
+	if low > high {
+		return 0, 0, fmt.Errorf("the low id is above the high id")
+	}
+	return uint32(low), uint32(high), nil
+}
+
+func instanceName(gateway *cephv1.CephSMBGateway) string {
+	return fmt.Sprintf("%s-%s", appName, gateway.Name)
+}
+
+// cephUserID is the id of the ceph user of the gateway, without the client. prefix as expected by vfs_ceph
+func cephUserID(gateway *cephv1.CephSMBGateway) string {
+	return fmt.Sprintf("smb.%s", gateway.Name)
+}
+
+func cephUsername(gateway *cephv1.CephSMBGateway) string {
+	return "client." + cephUserID(gateway)
+}
+
+func securityMode(gateway *cephv1.CephSMBGateway) string {
+	if gateway.Spec.Security.Mode == "" {
+		return SecurityModeUser
+	}
+	return gateway.Spec.Security.Mode
+}
+
+func netbiosName(gateway *cephv1.CephSMBGateway) string {
+	if gateway.Spec.NetbiosName == "" {
+		return gateway.Name
+	}
+	return gateway.Spec.NetbiosName
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smb
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	opmon "github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestGateway() *cephv1.CephSMBGateway {
+	return &cephv1.CephSMBGateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-smb", Namespace: "ns"},
+		Spec: cephv1.SMBGatewaySpec{
+			Image:    "samba:v1",
+			Shares:   []cephv1.SMBShareSpec{{Name: "share", Filesystem: "myfs", Path: "/data"}},
+			Security: cephv1.SMBSecuritySpec{UsersSecretName: "smb-users"},
+		},
+	}
+}
+
+func TestValidateGateway(t *testing.T) {
+	rookClientset := rookfake.NewSimpleClientset()
+	context := &clusterd.Context{RookClientset: rookClientset}
+	gateway := newTestGateway()
+
+	// the filesystem of the share must exist
+	assert.NotNil(t, validateGateway(context, gateway))
+	_, err := rookClientset.CephV1().CephFilesystems("ns").Create(&cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"}})
+	assert.Nil(t, err)
+	assert.Nil(t, validateGateway(context, gateway))
+
+	invalid := []func(g *cephv1.CephSMBGateway){
+		func(g *cephv1.CephSMBGateway) { g.Spec.Image = "" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.NetbiosName = "a-very-long-netbios-name" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Shares = nil },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Shares = append(g.Spec.Shares, g.Spec.Shares[0]) },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Shares[0].Filesystem = "" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Security.UsersSecretName = "" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Security.Mode = SecurityModeADS },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Security.Mode = "domain" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.ServiceType = v1.ServiceTypeExternalName },
+		// the values must not inject lines or sections in the smb.conf
+		func(g *cephv1.CephSMBGateway) { g.Spec.Shares[0].Name = "share]\n[global" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Shares[0].Name = "Global" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Shares[0].Path = "/a\n\tpath = /" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Shares[0].ValidUsers = []string{"alice\nadmin users = bob"} },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Security.Workgroup = "WG\r\n[x]" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Security.Realm = "EXAMPLE.COM\n" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Security.IDMapRange = "20000-29999\n\tadmin users = bob" },
+		// the idmap range must be a range of ids outside of the local ids
+		func(g *cephv1.CephSMBGateway) { g.Spec.Security.IDMapRange = "20000" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Security.IDMapRange = "29999-20000" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Security.IDMapRange = "-1-20000" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Security.IDMapRange = "20000-99999999999" },
+		func(g *cephv1.CephSMBGateway) { g.Spec.Security.IDMapRange = "5000-29999" },
+	}
+	for i, change := range invalid {
+		g := newTestGateway()
+		change(g)
+		assert.NotNil(t, validateGateway(context, g), "case %d", i)
+	}
+
+	gateway.Spec.Security = cephv1.SMBSecuritySpec{Mode: SecurityModeADS, Realm: "EXAMPLE.COM", JoinSecretName: "smb-join"}
+	assert.Nil(t, validateGateway(context, gateway))
+	gateway.Spec.Security.IDMapRange = "20000-29999"
+	assert.Nil(t, validateGateway(context, gateway))
+}
+
+func TestReconcileGateway(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	rookClientset := rookfake.NewSimpleClientset()
+	var capsUpdated []string
+	deleted := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "auth" && args[1] == "get-or-create-key" {
+				return `{"key":"mysecurekey"}`, nil
+			}
+			if args[0] == "auth" && args[1] == "caps" {
+				assert.Equal(t, "client.smb.my-smb", args[2])
+				capsUpdated = args[3:9]
+			}
+			if args[0] == "auth" && args[1] == "del" {
+				deleted = true
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookClientset, Executor: executor}
+	_, err := rookClientset.CephV1().CephFilesystems("ns").Create(&cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"}})
+	assert.Nil(t, err)
+	c := NewSMBGatewayController(context, "rook/rook:myversion", metav1.OwnerReference{})
+	gateway := newTestGateway()
+
+	assert.Nil(t, c.reconcileGateway(gateway))
+	assert.Equal(t, []string{"mon", "allow r", "mds", "allow rw", "osd", "allow rw tag cephfs data=myfs"}, capsUpdated)
+	secret, err := clientset.CoreV1().Secrets("ns").Get("rook-ceph-smb-my-smb", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "mysecurekey", secret.StringData["keyring"])
+	configMap, err := clientset.CoreV1().ConfigMaps("ns").Get("rook-ceph-smb-my-smb", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Contains(t, configMap.Data["smb.conf"], "[share]")
+
+	d, err := clientset.ExtensionsV1beta1().Deployments("ns").Get("rook-ceph-smb-my-smb", metav1.GetOptions{})
+	assert.Nil(t, err)
+	spec := d.Spec.Template.Spec
+	assert.Equal(t, "config-init", spec.InitContainers[0].Name)
+	assert.Equal(t, "rook/rook:myversion", spec.InitContainers[0].Image)
+	// the gateway finds the mons from their SRV records
+	assert.Contains(t, spec.InitContainers[0].Env, opmon.DNSSRVNameEnvVar())
+	assert.Equal(t, "samba:v1", spec.Containers[0].Image)
+	assert.Equal(t, int32(445), spec.Containers[0].Ports[0].ContainerPort)
+	assert.Equal(t, "smb-users", spec.Volumes[len(spec.Volumes)-1].Secret.SecretName)
+	hash := d.Spec.Template.Annotations[configHashAnnotation]
+	assert.NotEmpty(t, hash)
+
+	s, err := clientset.CoreV1().Services("ns").Get("rook-ceph-smb-my-smb", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, v1.ServiceTypeClusterIP, s.Spec.Type)
+	s.Spec.ClusterIP = "10.0.0.1"
+	_, err = clientset.CoreV1().Services("ns").Update(s)
+	assert.Nil(t, err)
+
+	// updating the shares and the service rolls the pod and keeps the cluster ip
+	gateway.Spec.Shares[0].ReadOnly = true
+	gateway.Spec.ServiceType = v1.ServiceTypeLoadBalancer
+	assert.Nil(t, c.reconcileGateway(gateway))
+	d, err = clientset.ExtensionsV1beta1().Deployments("ns").Get("rook-ceph-smb-my-smb", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, hash, d.Spec.Template.Annotations[configHashAnnotation])
+	s, err = clientset.CoreV1().Services("ns").Get("rook-ceph-smb-my-smb", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, v1.ServiceTypeLoadBalancer, s.Spec.Type)
+	assert.Equal(t, "10.0.0.1", s.Spec.ClusterIP)
+
+	// an invalid gateway is not deployed
+	invalid := newTestGateway()
+	invalid.Name = "other"
+	invalid.Spec.Shares[0].Filesystem = "missing"
+	assert.NotNil(t, c.reconcileGateway(invalid))
+	_, err = clientset.ExtensionsV1beta1().Deployments("ns").Get("rook-ceph-smb-other", metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	assert.Nil(t, c.deleteGateway(gateway))
+	assert.True(t, deleted)
+	_, err = clientset.ExtensionsV1beta1().Deployments("ns").Get("rook-ceph-smb-my-smb", metav1.GetOptions{})
+	assert.NotNil(t, err)
+	_, err = clientset.CoreV1().Services("ns").Get("rook-ceph-smb-my-smb", metav1.GetOptions{})
+	assert.NotNil(t, err)
+	_, err = clientset.CoreV1().Secrets("ns").Get("rook-ceph-smb-my-smb", metav1.GetOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestMakeDeploymentADS(t *testing.T) {
+	gateway := newTestGateway()
+	gateway.Spec.Security = cephv1.SMBSecuritySpec{Mode: SecurityModeADS, Realm: "EXAMPLE.COM", JoinSecretName: "smb-join"}
+	spec := makeDeployment(gateway, "rook/rook:myversion", "hash").Spec.Template.Spec
+
+	container := spec.Containers[0]
+	assert.Equal(t, "ads", container.Env[0].Value)
+	assert.Equal(t, "SMB_JOIN_PASSWORD", container.Env[2].Name)
+	assert.Equal(t, "smb-join", container.Env[2].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "password", container.Env[2].ValueFrom.SecretKeyRef.Key)
+	// the join password is not in the arguments of net
+	assert.Contains(t, container.Command[2], `| net ads join -U "$SMB_JOIN_USERNAME"`)
+	assert.NotContains(t, container.Command[2], "%$SMB_JOIN_PASSWORD")
+	for _, volume := range spec.Volumes {
+		assert.NotEqual(t, usersVolumeName, volume.Name)
+	}
+}
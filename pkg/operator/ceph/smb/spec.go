@@ -0,0 +1,191 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smb
+
+import (
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opmon "github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opspec "github.com/rook/rook/pkg/operator/ceph/spec"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	smbPort = 445
+
+	// the keys of the secret of the account joining the domain
+	JoinUsernameKey = "username"
+	JoinPasswordKey = "password"
+
+	configVolumeName     = "smb-config"
+	usersVolumeName      = "smb-users"
+	configMountPath      = "/etc/samba/smb.conf"
+	usersMountPath       = "/etc/samba/users"
+	configHashAnnotation = "ceph.rook.io/smb-config-hash"
+)
+
+// startScript joins the domain or adds the local users from the files of the users secret before starting smbd. The
+// join password is passed on stdin so it is not in the arguments of the process.
+var startScript = `set -e
+if [ "$SMB_SECURITY" = "ads" ]; then
+  printf '%s\n' "$SMB_JOIN_PASSWORD" | net ads join -U "$SMB_JOIN_USERNAME"
+  winbindd -D
+else
+  for f in ` + usersMountPath + `/*; do
+    [ -f "$f" ] || continue
+    u=$(basename "$f")
+    id "$u" >/dev/null 2>&1 || useradd -M -s /sbin/nologin "$u"
+    p=$(cat "$f")
+    printf '%s\n%s\n' "$p" "$p" | smbpasswd -a -s "$u"
+  done
+fi
+exec smbd --foreground --no-process-group --debug-stdout
+`
+
+// makeDeployment returns the deployment of a gateway. The hash of the config rolls the pod when the config changes.
+func makeDeployment(gateway *cephv1.CephSMBGateway, rookVersion, configHash string) *extensions.Deployment {
+	name := instanceName(gateway)
+	labels := opspec.PodLabels(appName, gateway.Namespace, "smb", gateway.Name)
+
+	volumes := append(opspec.PodVolumes(""), v1.Volume{
+		Name: configVolumeName,
+		VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+			LocalObjectReference: v1.LocalObjectReference{Name: name},
+		}},
+	})
+	if securityMode(gateway) == SecurityModeUser {
+		volumes = append(volumes, v1.Volume{
+			Name:         usersVolumeName,
+			VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: gateway.Spec.Security.UsersSecretName}},
+		})
+	}
+
+	podSpec := v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: map[string]string{configHashAnnotation: configHash},
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{
+				makeConfigInitContainer(gateway, rookVersion),
+			},
+			Containers: []v1.Container{
+				makeSambaContainer(gateway),
+			},
+			RestartPolicy: v1.RestartPolicyAlways,
+			Volumes:       volumes,
+		},
+	}
+	gateway.Spec.Placement.ApplyToPodSpec(&podSpec.Spec)
+	gateway.Spec.PodExtension.ApplyToPodSpec(&podSpec.Spec)
+
+	// a single instance of the gateway since the clients keep their sessions and locks on the server
+	replicas := int32(1)
+	return &extensions.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: gateway.Namespace,
+			Labels:    labels,
+		},
+		Spec: extensions.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: podSpec,
+			Replicas: &replicas,
+			Strategy: extensions.DeploymentStrategy{Type: extensions.RecreateDeploymentStrategyType},
+		},
+	}
+}
+
+func makeConfigInitContainer(gateway *cephv1.CephSMBGateway, rookVersion string) v1.Container {
+	return v1.Container{
+		Name: opspec.ConfigInitContainerName,
+		Args: []string{
+			"ceph",
+			"config-init",
+		},
+		Image: k8sutil.MakeRookImage(rookVersion),
+		Env: append([]v1.EnvVar{
+			{Name: "ROOK_USERNAME", Value: cephUsername(gateway)},
+			{Name: "ROOK_KEYRING",
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{Name: instanceName(gateway)},
+						Key:                  opspec.KeyringSecretKeyName,
+					}}},
+			k8sutil.PodIPEnvVar(k8sutil.PrivateIPEnvVar),
+			k8sutil.PodIPEnvVar(k8sutil.PublicIPEnvVar),
+			opmon.EndpointEnvVar(),
+			opmon.DNSSRVNameEnvVar(),
+			k8sutil.ConfigOverrideEnvVar(),
+		}, k8sutil.LogFormatEnvVars(true)...),
+		VolumeMounts: opspec.RookVolumeMounts(),
+	}
+}
+
+func makeSambaContainer(gateway *cephv1.CephSMBGateway) v1.Container {
+	env := []v1.EnvVar{{Name: "SMB_SECURITY", Value: securityMode(gateway)}}
+	mounts := append(opspec.CephVolumeMounts(), v1.VolumeMount{Name: configVolumeName, MountPath: configMountPath, SubPath: configFileName})
+	if securityMode(gateway) == SecurityModeADS {
+		env = append(env,
+			secretKeyEnvVar("SMB_JOIN_USERNAME", gateway.Spec.Security.JoinSecretName, JoinUsernameKey),
+			secretKeyEnvVar("SMB_JOIN_PASSWORD", gateway.Spec.Security.JoinSecretName, JoinPasswordKey))
+	} else {
+		mounts = append(mounts, v1.VolumeMount{Name: usersVolumeName, MountPath: usersMountPath, ReadOnly: true})
+	}
+
+	return v1.Container{
+		Name:         "smbd",
+		Image:        gateway.Spec.Image,
+		Command:      []string{"/bin/sh", "-c", startScript},
+		Env:          env,
+		VolumeMounts: mounts,
+		Ports:        []v1.ContainerPort{{Name: "smb", ContainerPort: smbPort, Protocol: v1.ProtocolTCP}},
+		Resources:    gateway.Spec.Resources,
+	}
+}
+
+func makeService(gateway *cephv1.CephSMBGateway) *v1.Service {
+	serviceType := gateway.Spec.ServiceType
+	if serviceType == "" {
+		serviceType = v1.ServiceTypeClusterIP
+	}
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instanceName(gateway),
+			Namespace: gateway.Namespace,
+			Labels:    opspec.AppLabels(appName, gateway.Namespace),
+		},
+		Spec: v1.ServiceSpec{
+			Type:     serviceType,
+			Selector: opspec.PodLabels(appName, gateway.Namespace, "smb", gateway.Name),
+			Ports: []v1.ServicePort{
+				{Name: "smb", Port: smbPort, TargetPort: intstr.FromInt(smbPort), Protocol: v1.ProtocolTCP},
+			},
+		},
+	}
+}
+
+func secretKeyEnvVar(name, secretName, key string) v1.EnvVar {
+	return v1.EnvVar{Name: name, ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+		LocalObjectReference: v1.LocalObjectReference{Name: secretName},
+		Key:                  key,
+	}}}
+}
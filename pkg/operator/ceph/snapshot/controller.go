@@ -0,0 +1,427 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package snapshot to take the backup snapshots and manage the rbd groups of the block volumes of the applications.
+package snapshot
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// PhasePending is the phase of a snapshot not processed yet
+	PhasePending = ""
+	// PhaseFreezing is the phase of a snapshot waiting for the agents to freeze the filesystems of the volumes
+	PhaseFreezing = "Freezing"
+	// PhaseSnapshotting is the phase of a snapshot while the images are snapshotted
+	PhaseSnapshotting = "Snapshotting"
+	// PhaseThawing is the phase of a snapshot waiting for the agents to thaw the filesystems of the volumes
+	PhaseThawing = "Thawing"
+	// PhaseCompleted is the phase of a snapshot with the snapshots of all the images
+	PhaseCompleted = "Completed"
+	// PhaseFailed is the phase of a snapshot that failed. The snapshots already taken are deleted.
+	PhaseFailed = "Failed"
+
+	// DefaultFreezeTimeout is the maximum duration the filesystems are frozen if the timeout is not set
+	DefaultFreezeTimeout = 30 * time.Second
+
+	pollInterval        = time.Second
+	updateStatusRetries = 5
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-snapshot")
+
+// BackupSnapshotResource represents the BackupSnapshot custom resource object
+var BackupSnapshotResource = opkit.CustomResource{
+	Name:    "cephbackupsnapshot",
+	Plural:  "cephbackupsnapshots",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephBackupSnapshot{}).Name(),
+}
+
+// BackupSnapshotController represents a controller object for backup snapshot custom resources
+type BackupSnapshotController struct {
+	context *clusterd.Context
+	// the namespace of the volume attachments of the agents
+	agentNamespace string
+	pollInterval   time.Duration
+}
+
+// NewBackupSnapshotController create controller for watching backup snapshot custom resources created
+func NewBackupSnapshotController(context *clusterd.Context, agentNamespace string) *BackupSnapshotController {
+	return &BackupSnapshotController{
+		context:        context,
+		agentNamespace: agentNamespace,
+		pollInterval:   pollInterval,
+	}
+}
+
+// StartWatch watches for instances of BackupSnapshot custom resources and acts on them
+func (c *BackupSnapshotController) StartWatch(namespace string, stopCh chan struct{}) error {
+
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching backup snapshot resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(BackupSnapshotResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephBackupSnapshot{}, stopCh)
+
+	return nil
+}
+
+func (c *BackupSnapshotController) onAdd(obj interface{}) {
+	s, err := getBackupSnapshotObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get backup snapshot object: %+v", err)
+		return
+	}
+
+	switch s.Status.Phase {
+	case PhasePending:
+		if err := c.takeSnapshot(s); err != nil {
+			logger.Errorf("failed to take backup snapshot %s/%s. %+v", s.Namespace, s.Name, err)
+		}
+	case PhaseFreezing, PhaseSnapshotting, PhaseThawing:
+		// the agents thaw the filesystems when the snapshot fails
+		c.fail(s, fmt.Errorf("the snapshot was interrupted by a restart of the operator"))
+	}
+}
+
+func (c *BackupSnapshotController) onUpdate(oldObj, newObj interface{}) {
+	oldSnapshot, err := getBackupSnapshotObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old backup snapshot object: %+v", err)
+		return
+	}
+	s, err := getBackupSnapshotObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new backup snapshot object: %+v", err)
+		return
+	}
+
+	if !reflect.DeepEqual(oldSnapshot.Spec, s.Spec) {
+		logger.Warningf("backup snapshot %s/%s cannot be modified. create a new backup snapshot instead", s.Namespace, s.Name)
+	}
+}
+
+func (c *BackupSnapshotController) onDelete(obj interface{}) {
+	s, err := getBackupSnapshotObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get backup snapshot object: %+v", err)
+		return
+	}
+
+	if err := deleteImageSnapshots(c.context, s); err != nil {
+		logger.Errorf("failed to delete backup snapshot %s/%s. %+v", s.Namespace, s.Name, err)
+	}
+}
+
+// takeSnapshot snapshots the images of the volumes, while their filesystems are frozen by the agents if the freeze
+// is enabled. The images are snapshotted one after the other, so the snapshots are consistent with each other
+// only if the filesystems are frozen.
+func (c *BackupSnapshotController) takeSnapshot(s *cephv1.CephBackupSnapshot) error {
+	volumes, err := getVolumes(c.context, c.agentNamespace, s.Namespace, s.Spec.PVCs, s.Spec.Selector, s.Spec.Freeze)
+	if err != nil {
+		c.fail(s, err)
+		return err
+	}
+
+	freezeTimeout := FreezeTimeout(s)
+	frozen := s.Spec.Freeze && hasMounts(volumes)
+	phase := PhaseSnapshotting
+	if frozen {
+		phase = PhaseFreezing
+	}
+	logger.Infof("taking backup snapshot %s/%s of %d volumes", s.Namespace, s.Name, len(volumes))
+	start := time.Now()
+	s, err = UpdateStatus(c.context, s, func(latest *cephv1.CephBackupSnapshot) {
+		latest.Status = cephv1.BackupSnapshotStatus{Phase: phase, Volumes: volumes}
+	})
+	if err != nil {
+		return err
+	}
+
+	if frozen {
+		// leave the time to snapshot the images before the agents thaw the filesystems
+		if s, err = c.waitForMounts(s, true, freezeTimeout/2); err != nil {
+			c.fail(s, fmt.Errorf("the filesystems were not frozen. %+v", err))
+			return err
+		}
+		if s, err = UpdateStatus(c.context, s, func(latest *cephv1.CephBackupSnapshot) {
+			latest.Status.Phase = PhaseSnapshotting
+		}); err != nil {
+			return err
+		}
+	}
+
+	for i, v := range s.Status.Volumes {
+		if err := ceph.CreateImageSnapshot(c.context, v.ClusterNamespace, v.Image, v.Pool, s.Name); err != nil {
+			c.fail(s, err)
+			return err
+		}
+		s.Status.Volumes[i].Snapshot = s.Name
+		// record the snapshot right away so it is deleted if the next ones fail
+		if s, err = UpdateStatus(c.context, s, func(latest *cephv1.CephBackupSnapshot) {
+			latest.Status.Volumes[i].Snapshot = latest.Name
+		}); err != nil {
+			c.fail(s, err)
+			return err
+		}
+	}
+	if frozen && time.Since(start) > freezeTimeout {
+		err := fmt.Errorf("the images were snapshotted after the freeze timeout %s", freezeTimeout)
+		c.fail(s, err)
+		return err
+	}
+
+	now := metav1.Now()
+	phase = PhaseCompleted
+	if frozen {
+		phase = PhaseThawing
+	}
+	if s, err = UpdateStatus(c.context, s, func(latest *cephv1.CephBackupSnapshot) {
+		latest.Status.Phase = phase
+		latest.Status.SnapshotTime = &now
+	}); err != nil {
+		return err
+	}
+
+	if frozen {
+		var message string
+		if s, err = c.waitForMounts(s, false, freezeTimeout); err != nil {
+			// the snapshots are fine, the agents thaw the filesystems after the freeze timeout anyway
+			message = fmt.Sprintf("the filesystems were not thawed. %+v", err)
+			logger.Warningf("backup snapshot %s/%s: %s", s.Namespace, s.Name, message)
+		}
+		if _, err = UpdateStatus(c.context, s, func(latest *cephv1.CephBackupSnapshot) {
+			latest.Status.Phase = PhaseCompleted
+			latest.Status.Message = message
+		}); err != nil {
+			return err
+		}
+	}
+	logger.Infof("completed backup snapshot %s/%s", s.Namespace, s.Name)
+	return nil
+}
+
+// getVolumes returns the images of the pvcs listed by name or selected by labels, with the mounts of the volumes
+// recorded by the agents if requested
+func getVolumes(context *clusterd.Context, agentNamespace, namespace string, pvcNames []string, labelSelector *metav1.LabelSelector,
+	withMounts bool) ([]cephv1.BackupSnapshotVolume, error) {
+	names := map[string]bool{}
+	for _, name := range pvcNames {
+		names[name] = true
+	}
+	if labelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector. %+v", err)
+		}
+		pvcs, err := context.Clientset.CoreV1().PersistentVolumeClaims(namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the pvcs. %+v", err)
+		}
+		for _, pvc := range pvcs.Items {
+			names[pvc.Name] = true
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no pvc to snapshot")
+	}
+
+	volumes := []cephv1.BackupSnapshotVolume{}
+	for name := range names {
+		v, err := getVolume(context, agentNamespace, namespace, name, withMounts)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, *v)
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].PVC < volumes[j].PVC })
+	return volumes, nil
+}
+
+// getVolume returns the image of a pvc from the options of its flex volume
+func getVolume(context *clusterd.Context, agentNamespace, namespace, name string, withMounts bool) (*cephv1.BackupSnapshotVolume, error) {
+	pvc, err := context.Clientset.CoreV1().PersistentVolumeClaims(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pvc %s. %+v", name, err)
+	}
+	if pvc.Spec.VolumeName == "" {
+		return nil, fmt.Errorf("pvc %s is not bound", name)
+	}
+	pv, err := context.Clientset.CoreV1().PersistentVolumes().Get(pvc.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pv %s of pvc %s. %+v", pvc.Spec.VolumeName, name, err)
+	}
+	flex := pv.Spec.PersistentVolumeSource.FlexVolume
+	if flex == nil || flex.Options[flexvolume.ImageKey] == "" {
+		return nil, fmt.Errorf("pvc %s is not a rook block volume", name)
+	}
+
+	v := &cephv1.BackupSnapshotVolume{
+		PVC:              name,
+		ClusterNamespace: flex.Options[flexvolume.ClusterNamespaceKey],
+		Pool:             flex.Options[flexvolume.PoolKey],
+		Image:            flex.Options[flexvolume.ImageKey],
+	}
+	if !withMounts {
+		return v, nil
+	}
+
+	// the volume attachment records the mounts of the volume by the pods
+	attachment, err := context.RookClientset.RookV1alpha2().Volumes(agentNamespace).Get(pv.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return v, nil
+		}
+		return nil, fmt.Errorf("failed to get the attachment of pv %s. %+v", pv.Name, err)
+	}
+	for _, a := range attachment.Attachments {
+		// the read only mounts are not written
+		if !a.ReadOnly {
+			v.Mounts = append(v.Mounts, cephv1.BackupSnapshotMount{Node: a.Node, MountDir: a.MountDir})
+		}
+	}
+	return v, nil
+}
+
+// waitForMounts waits for the agents to freeze or thaw all the mounts of the volumes
+func (c *BackupSnapshotController) waitForMounts(s *cephv1.CephBackupSnapshot, frozen bool, timeout time.Duration) (*cephv1.CephBackupSnapshot, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		latest, err := c.context.RookClientset.CephV1().CephBackupSnapshots(s.Namespace).Get(s.Name, metav1.GetOptions{})
+		if err != nil {
+			return s, fmt.Errorf("failed to get backup snapshot. %+v", err)
+		}
+		s = latest
+		pending := 0
+		for _, v := range s.Status.Volumes {
+			for _, m := range v.Mounts {
+				if m.Frozen != frozen {
+					pending++
+				}
+			}
+		}
+		if pending == 0 {
+			return s, nil
+		}
+		if time.Now().After(deadline) {
+			return s, fmt.Errorf("timed out waiting for %d mounts after %s", pending, timeout)
+		}
+		logger.Debugf("waiting for %d mounts of backup snapshot %s/%s", pending, s.Namespace, s.Name)
+		time.Sleep(c.pollInterval)
+	}
+}
+
+// fail records the failure of the snapshot and deletes the snapshots already taken
+func (c *BackupSnapshotController) fail(s *cephv1.CephBackupSnapshot, cause error) {
+	logger.Errorf("backup snapshot %s/%s failed. %+v", s.Namespace, s.Name, cause)
+	if err := deleteImageSnapshots(c.context, s); err != nil {
+		logger.Warningf("failed to delete the snapshots of backup snapshot %s/%s. %+v", s.Namespace, s.Name, err)
+	}
+	if _, err := UpdateStatus(c.context, s, func(latest *cephv1.CephBackupSnapshot) {
+		latest.Status.Phase = PhaseFailed
+		latest.Status.Message = cause.Error()
+		for i := range latest.Status.Volumes {
+			latest.Status.Volumes[i].Snapshot = ""
+		}
+	}); err != nil {
+		logger.Errorf("failed to update the status of backup snapshot %s/%s. %+v", s.Namespace, s.Name, err)
+	}
+}
+
+// deleteImageSnapshots deletes the snapshots of the images taken for the backup snapshot
+func deleteImageSnapshots(context *clusterd.Context, s *cephv1.CephBackupSnapshot) error {
+	var lastErr error
+	for _, v := range s.Status.Volumes {
+		if v.Snapshot == "" {
+			continue
+		}
+		if err := ceph.DeleteImageSnapshot(context, v.ClusterNamespace, v.Image, v.Pool, v.Snapshot); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// UpdateStatus applies a change to the latest status of a backup snapshot, which is updated both by the operator
+// and the agents, retrying if the snapshot was modified concurrently
+func UpdateStatus(context *clusterd.Context, s *cephv1.CephBackupSnapshot, update func(*cephv1.CephBackupSnapshot)) (*cephv1.CephBackupSnapshot, error) {
+	client := context.RookClientset.CephV1().CephBackupSnapshots(s.Namespace)
+	var err error
+	for i := 0; i < updateStatusRetries; i++ {
+		var latest *cephv1.CephBackupSnapshot
+		latest, err = client.Get(s.Name, metav1.GetOptions{})
+		if err != nil {
+			return s, fmt.Errorf("failed to get backup snapshot %s/%s. %+v", s.Namespace, s.Name, err)
+		}
+		update(latest)
+		var updated *cephv1.CephBackupSnapshot
+		updated, err = client.Update(latest)
+		if err == nil {
+			return updated, nil
+		}
+		if !errors.IsConflict(err) {
+			break
+		}
+	}
+	return s, fmt.Errorf("failed to update backup snapshot %s/%s. %+v", s.Namespace, s.Name, err)
+}
+
+// FreezeTimeout returns the maximum duration the filesystems of the volumes of a snapshot are frozen
+func FreezeTimeout(s *cephv1.CephBackupSnapshot) time.Duration {
+	if s.Spec.FreezeTimeoutSeconds <= 0 {
+		return DefaultFreezeTimeout
+	}
+	return time.Duration(s.Spec.FreezeTimeoutSeconds) * time.Second
+}
+
+func hasMounts(volumes []cephv1.BackupSnapshotVolume) bool {
+	for _, v := range volumes {
+		if len(v.Mounts) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func getBackupSnapshotObject(obj interface{}) (*cephv1.CephBackupSnapshot, error) {
+	s, ok := obj.(*cephv1.CephBackupSnapshot)
+	if ok {
+		return s.DeepCopy(), nil
+	}
+	return nil, fmt.Errorf("not a known backup snapshot object: %+v", obj)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestVolume(name string) (*v1.PersistentVolumeClaim, *v1.PersistentVolume) {
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "app", Labels: map[string]string{"app": "db"}},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pvc-" + name},
+	}
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-" + name},
+		Spec: v1.PersistentVolumeSpec{PersistentVolumeSource: v1.PersistentVolumeSource{FlexVolume: &v1.FlexPersistentVolumeSource{
+			Options: map[string]string{"clusterNamespace": "rook-ceph", "pool": "replicapool", "image": "pvc-" + name},
+		}}},
+	}
+	return pvc, pv
+}
+
+func newTestController(snapshots *[]string, s *cephv1.CephBackupSnapshot) *BackupSnapshotController {
+	data, dataPV := newTestVolume("data")
+	wal, walPV := newTestVolume("wal")
+	attachment := &rookalpha.Volume{
+		ObjectMeta:  metav1.ObjectMeta{Name: "pvc-data", Namespace: "rook-ceph-system"},
+		Attachments: []rookalpha.Attachment{{Node: "node1", MountDir: "/var/lib/kubelet/pods/123/volumes/ceph.rook.io~rook-ceph-system/pvc-data"}},
+	}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			if args[0] == "snap" && args[1] == "create" {
+				*snapshots = append(*snapshots, args[2])
+				return "", nil
+			}
+			return "", fmt.Errorf("unexpected rbd command '%v'", args)
+		},
+	}
+	context := &clusterd.Context{
+		Clientset:     fake.NewSimpleClientset(data, dataPV, wal, walPV),
+		RookClientset: rookfake.NewSimpleClientset(attachment, s),
+		Executor:      executor,
+	}
+	c := NewBackupSnapshotController(context, "rook-ceph-system")
+	c.pollInterval = time.Millisecond
+	return c
+}
+
+func TestTakeSnapshot(t *testing.T) {
+	s := &cephv1.CephBackupSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "app"},
+		Spec:       cephv1.BackupSnapshotSpec{PVCs: []string{"wal"}, Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}},
+	}
+	snapshots := []string{}
+	c := newTestController(&snapshots, s)
+
+	// the images are snapshotted without freezing the filesystems
+	assert.Nil(t, c.takeSnapshot(s))
+	assert.Equal(t, []string{"replicapool/pvc-data@backup1", "replicapool/pvc-wal@backup1"}, snapshots)
+	s, err := c.context.RookClientset.CephV1().CephBackupSnapshots("app").Get("backup1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, PhaseCompleted, s.Status.Phase)
+	assert.NotNil(t, s.Status.SnapshotTime)
+	assert.Equal(t, 2, len(s.Status.Volumes))
+	assert.Equal(t, "backup1", s.Status.Volumes[0].Snapshot)
+	assert.Equal(t, 0, len(s.Status.Volumes[0].Mounts))
+
+	// a pvc that is not a rook volume fails the snapshot
+	s = &cephv1.CephBackupSnapshot{ObjectMeta: metav1.ObjectMeta{Name: "backup2", Namespace: "app"}, Spec: cephv1.BackupSnapshotSpec{PVCs: []string{"other"}}}
+	c = newTestController(&snapshots, s)
+	assert.NotNil(t, c.takeSnapshot(s))
+	s, err = c.context.RookClientset.CephV1().CephBackupSnapshots("app").Get("backup2", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, PhaseFailed, s.Status.Phase)
+}
+
+func TestTakeSnapshotFrozen(t *testing.T) {
+	s := &cephv1.CephBackupSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup1", Namespace: "app"},
+		Spec:       cephv1.BackupSnapshotSpec{PVCs: []string{"data", "wal"}, Freeze: true, FreezeTimeoutSeconds: 5},
+	}
+	snapshots := []string{}
+	c := newTestController(&snapshots, s)
+
+	// the agent freezes and thaws the mount of the data volume
+	phases := []string{}
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Millisecond):
+				latest, _ := c.context.RookClientset.CephV1().CephBackupSnapshots("app").Get("backup1", metav1.GetOptions{})
+				if latest == nil || len(latest.Status.Volumes) == 0 {
+					continue
+				}
+				frozen := latest.Status.Phase == PhaseFreezing
+				if latest.Status.Phase == PhaseSnapshotting || latest.Status.Volumes[0].Mounts[0].Frozen == frozen {
+					continue
+				}
+				phases = append(phases, latest.Status.Phase)
+				UpdateStatus(c.context, latest, func(s *cephv1.CephBackupSnapshot) { s.Status.Volumes[0].Mounts[0].Frozen = frozen })
+			}
+		}
+	}()
+	err := c.takeSnapshot(s)
+	close(stop)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{PhaseFreezing, PhaseThawing}, phases)
+	assert.Equal(t, []string{"replicapool/pvc-data@backup1", "replicapool/pvc-wal@backup1"}, snapshots)
+
+	s, err = c.context.RookClientset.CephV1().CephBackupSnapshots("app").Get("backup1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, PhaseCompleted, s.Status.Phase)
+	assert.Equal(t, []cephv1.BackupSnapshotMount{{Node: "node1", MountDir: "/var/lib/kubelet/pods/123/volumes/ceph.rook.io~rook-ceph-system/pvc-data"}},
+		s.Status.Volumes[0].Mounts)
+}
@@ -0,0 +1,382 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"fmt"
+	"reflect"
+
+	opkit "github.com/rook/operator-kit"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+	"k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// the label of the pvcs cloned from a volume group, with the name of the clone
+	volumeGroupCloneLabel = "ceph.rook.io/volume-group-clone"
+	provisionedByKey      = "pv.kubernetes.io/provisioned-by"
+)
+
+// VolumeGroupResource represents the VolumeGroup custom resource object
+var VolumeGroupResource = opkit.CustomResource{
+	Name:    "cephvolumegroup",
+	Plural:  "cephvolumegroups",
+	Group:   cephv1.CustomResourceGroup,
+	Version: cephv1.Version,
+	Scope:   apiextensionsv1beta1.NamespaceScoped,
+	Kind:    reflect.TypeOf(cephv1.CephVolumeGroup{}).Name(),
+}
+
+// VolumeGroupController represents a controller object for volume group custom resources
+type VolumeGroupController struct {
+	context *clusterd.Context
+}
+
+// NewVolumeGroupController create controller for watching volume group custom resources created
+func NewVolumeGroupController(context *clusterd.Context) *VolumeGroupController {
+	return &VolumeGroupController{
+		context: context,
+	}
+}
+
+// StartWatch watches for instances of VolumeGroup custom resources and acts on them
+func (c *VolumeGroupController) StartWatch(namespace string, stopCh chan struct{}) error {
+
+	resourceHandlerFuncs := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.onAdd,
+		UpdateFunc: c.onUpdate,
+		DeleteFunc: c.onDelete,
+	}
+
+	logger.Infof("start watching volume group resources in namespace %s", namespace)
+	watcher := opkit.NewWatcher(VolumeGroupResource, namespace, resourceHandlerFuncs, c.context.RookClientset.CephV1().RESTClient())
+	go watcher.Watch(&cephv1.CephVolumeGroup{}, stopCh)
+
+	return nil
+}
+
+func (c *VolumeGroupController) onAdd(obj interface{}) {
+	g, err := getVolumeGroupObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get volume group object: %+v", err)
+		return
+	}
+
+	if err := c.reconcile(g); err != nil {
+		logger.Errorf("failed to reconcile volume group %s/%s. %+v", g.Namespace, g.Name, err)
+	}
+}
+
+func (c *VolumeGroupController) onUpdate(oldObj, newObj interface{}) {
+	oldGroup, err := getVolumeGroupObject(oldObj)
+	if err != nil {
+		logger.Errorf("failed to get old volume group object: %+v", err)
+		return
+	}
+	g, err := getVolumeGroupObject(newObj)
+	if err != nil {
+		logger.Errorf("failed to get new volume group object: %+v", err)
+		return
+	}
+
+	// the status updates of the operator are ignored
+	if reflect.DeepEqual(oldGroup.Spec, g.Spec) {
+		logger.Debugf("volume group %s/%s not changed", g.Namespace, g.Name)
+		return
+	}
+	if err := c.reconcile(g); err != nil {
+		logger.Errorf("failed to reconcile volume group %s/%s. %+v", g.Namespace, g.Name, err)
+	}
+}
+
+func (c *VolumeGroupController) onDelete(obj interface{}) {
+	g, err := getVolumeGroupObject(obj)
+	if err != nil {
+		logger.Errorf("failed to get volume group object: %+v", err)
+		return
+	}
+	if g.Status.Pool == "" {
+		return
+	}
+
+	// the snapshots of the group are deleted with the group, the volumes and their clones are kept
+	if err := ceph.DeleteImageGroup(c.context, g.Status.ClusterNamespace, g.Status.Pool, groupName(g)); err != nil {
+		logger.Errorf("failed to delete volume group %s/%s. %+v", g.Namespace, g.Name, err)
+	}
+}
+
+// reconcile creates the rbd group of the volumes, then takes or deletes its snapshots, clones its volumes, and
+// rolls back its volumes as requested by the spec. The progress is recorded in the status.
+func (c *VolumeGroupController) reconcile(g *cephv1.CephVolumeGroup) error {
+	err := c.reconcileGroup(g)
+	g.Status.Message = ""
+	if err != nil {
+		g.Status.Message = err.Error()
+	}
+	if updateErr := c.updateStatus(g); updateErr != nil {
+		logger.Errorf("failed to update the status of volume group %s/%s. %+v", g.Namespace, g.Name, updateErr)
+	}
+	return err
+}
+
+func (c *VolumeGroupController) reconcileGroup(g *cephv1.CephVolumeGroup) error {
+	if err := c.syncImages(g); err != nil {
+		return err
+	}
+	if err := c.syncSnapshots(g); err != nil {
+		return err
+	}
+	for _, clone := range g.Spec.Clones {
+		if containsString(g.Status.Clones, clone.Name) {
+			continue
+		}
+		if err := c.cloneVolumes(g, clone); err != nil {
+			return fmt.Errorf("failed to create clone %s. %+v", clone.Name, err)
+		}
+		g.Status.Clones = append(g.Status.Clones, clone.Name)
+	}
+	if g.Spec.RestoreSnapshot != g.Status.RestoredSnapshot {
+		if err := c.restoreSnapshot(g); err != nil {
+			return fmt.Errorf("failed to restore snapshot %s. %+v", g.Spec.RestoreSnapshot, err)
+		}
+		g.Status.RestoredSnapshot = g.Spec.RestoreSnapshot
+	}
+	return nil
+}
+
+// syncImages creates the rbd group in the pool of the first volume, and adds or removes the images of the group
+// to match the pvcs of the spec
+func (c *VolumeGroupController) syncImages(g *cephv1.CephVolumeGroup) error {
+	volumes, err := getVolumes(c.context, "", g.Namespace, g.Spec.PVCs, g.Spec.Selector, false)
+	if err != nil {
+		return err
+	}
+	if g.Status.Pool == "" {
+		g.Status.ClusterNamespace = volumes[0].ClusterNamespace
+		g.Status.Pool = volumes[0].Pool
+	}
+	clusterNamespace, pool, name := g.Status.ClusterNamespace, g.Status.Pool, groupName(g)
+	for _, v := range volumes {
+		if v.ClusterNamespace != clusterNamespace {
+			return fmt.Errorf("pvc %s is in cluster %s. all the volumes of the group must be in cluster %s", v.PVC, v.ClusterNamespace, clusterNamespace)
+		}
+	}
+
+	groups, err := ceph.ListImageGroups(c.context, clusterNamespace, pool)
+	if err != nil {
+		return err
+	}
+	if !containsString(groups, name) {
+		logger.Infof("creating rbd group %s/%s", pool, name)
+		if err := ceph.CreateImageGroup(c.context, clusterNamespace, pool, name); err != nil {
+			return err
+		}
+	}
+
+	images, err := ceph.ListGroupImages(c.context, clusterNamespace, pool, name)
+	if err != nil {
+		return err
+	}
+	inGroup := map[string]bool{}
+	for _, image := range images {
+		inGroup[image.Pool+"/"+image.Image] = true
+	}
+	g.Status.Volumes = []cephv1.VolumeGroupImage{}
+	for _, v := range volumes {
+		spec := v.Pool + "/" + v.Image
+		if !inGroup[spec] {
+			logger.Infof("adding image %s of pvc %s to rbd group %s/%s", spec, v.PVC, pool, name)
+			if err := ceph.AddImageToGroup(c.context, clusterNamespace, pool, name, v.Pool, v.Image); err != nil {
+				return err
+			}
+		}
+		delete(inGroup, spec)
+		g.Status.Volumes = append(g.Status.Volumes, cephv1.VolumeGroupImage{PVC: v.PVC, Pool: v.Pool, Image: v.Image})
+	}
+	for _, image := range images {
+		if inGroup[image.Pool+"/"+image.Image] {
+			logger.Infof("removing image %s/%s from rbd group %s/%s", image.Pool, image.Image, pool, name)
+			if err := ceph.RemoveImageFromGroup(c.context, clusterNamespace, pool, name, image.Pool, image.Image); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// syncSnapshots takes the snapshots added to the spec and deletes the snapshots removed from the spec
+func (c *VolumeGroupController) syncSnapshots(g *cephv1.CephVolumeGroup) error {
+	clusterNamespace, pool, name := g.Status.ClusterNamespace, g.Status.Pool, groupName(g)
+	snapshots := []cephv1.VolumeGroupSnapshot{}
+	for _, s := range g.Status.Snapshots {
+		if containsString(g.Spec.Snapshots, s.Name) {
+			snapshots = append(snapshots, s)
+			continue
+		}
+		logger.Infof("deleting snapshot %s of rbd group %s/%s", s.Name, pool, name)
+		if err := ceph.DeleteGroupSnapshot(c.context, clusterNamespace, pool, name, s.Name); err != nil {
+			return err
+		}
+	}
+	g.Status.Snapshots = snapshots
+
+	for _, s := range g.Spec.Snapshots {
+		if hasGroupSnapshot(g, s) {
+			continue
+		}
+		logger.Infof("taking snapshot %s of rbd group %s/%s", s, pool, name)
+		if err := ceph.CreateGroupSnapshot(c.context, clusterNamespace, pool, name, s); err != nil {
+			return err
+		}
+		g.Status.Snapshots = append(g.Status.Snapshots, cephv1.VolumeGroupSnapshot{Name: s, Time: metav1.Now()})
+	}
+	return nil
+}
+
+// cloneVolumes clones the images of the group from a snapshot of the group, and creates the pvcs of the clones
+// bound to new pvs of the same storage class as the volumes of the group
+func (c *VolumeGroupController) cloneVolumes(g *cephv1.CephVolumeGroup, clone cephv1.VolumeGroupClone) error {
+	if !hasGroupSnapshot(g, clone.Snapshot) {
+		return fmt.Errorf("snapshot %s not found", clone.Snapshot)
+	}
+
+	for _, v := range g.Status.Volumes {
+		claimName := fmt.Sprintf("%s-%s", v.PVC, clone.Name)
+		if _, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(g.Namespace).Get(claimName, metav1.GetOptions{}); err == nil {
+			logger.Infof("pvc %s of clone %s already exists", claimName, clone.Name)
+			continue
+		}
+		pvc, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(g.Namespace).Get(v.PVC, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get pvc %s. %+v", v.PVC, err)
+		}
+		pv, err := c.context.Clientset.CoreV1().PersistentVolumes().Get(pvc.Spec.VolumeName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get pv %s. %+v", pvc.Spec.VolumeName, err)
+		}
+
+		// the snapshots of the images taken by a group snapshot are only found by id
+		snapID, err := ceph.GetGroupSnapshotID(c.context, g.Status.ClusterNamespace, v.Pool, v.Image, groupName(g), clone.Snapshot)
+		if err != nil {
+			return err
+		}
+		imageName := fmt.Sprintf("%s-%s", v.Image, clone.Name)
+		logger.Infof("cloning image %s/%s to %s for pvc %s", v.Pool, v.Image, imageName, claimName)
+		if err := ceph.CloneImageFromSnapshotID(c.context, g.Status.ClusterNamespace, v.Pool, v.Image, snapID, v.Pool, imageName); err != nil {
+			return err
+		}
+
+		clonePV := &v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: imageName, Labels: map[string]string{volumeGroupCloneLabel: clone.Name}},
+			Spec:       *pv.Spec.DeepCopy(),
+		}
+		// the clone is deleted with its pv by the provisioner of the volume
+		if provisioner, ok := pv.Annotations[provisionedByKey]; ok {
+			clonePV.Annotations = map[string]string{provisionedByKey: provisioner}
+		}
+		clonePV.Spec.FlexVolume.Options[flexvolume.ImageKey] = imageName
+		clonePV.Spec.ClaimRef = &v1.ObjectReference{Namespace: g.Namespace, Name: claimName}
+		if _, err := c.context.Clientset.CoreV1().PersistentVolumes().Create(clonePV); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create pv %s. %+v", imageName, err)
+		}
+
+		clonePVC := &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: claimName, Namespace: g.Namespace, Labels: map[string]string{volumeGroupCloneLabel: clone.Name}},
+			Spec: v1.PersistentVolumeClaimSpec{
+				AccessModes:      pvc.Spec.AccessModes,
+				Resources:        pvc.Spec.Resources,
+				StorageClassName: pvc.Spec.StorageClassName,
+				VolumeName:       imageName,
+			},
+		}
+		if _, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(g.Namespace).Create(clonePVC); err != nil {
+			return fmt.Errorf("failed to create pvc %s. %+v", claimName, err)
+		}
+	}
+	return nil
+}
+
+// restoreSnapshot rolls back all the images of the group to a snapshot of the group. The images must not be mapped
+// since the filesystems of the volumes would be corrupted.
+func (c *VolumeGroupController) restoreSnapshot(g *cephv1.CephVolumeGroup) error {
+	if g.Spec.RestoreSnapshot == "" {
+		return nil
+	}
+	if !hasGroupSnapshot(g, g.Spec.RestoreSnapshot) {
+		return fmt.Errorf("snapshot not found")
+	}
+	for _, v := range g.Status.Volumes {
+		watchers, err := ceph.GetImageWatchers(c.context, g.Status.ClusterNamespace, v.Pool, v.Image)
+		if err != nil {
+			return err
+		}
+		if len(watchers) > 0 {
+			return fmt.Errorf("pvc %s is in use by %v. stop the pods using the volumes of the group first", v.PVC, watchers)
+		}
+	}
+
+	logger.Infof("rolling back rbd group %s/%s to snapshot %s", g.Status.Pool, groupName(g), g.Spec.RestoreSnapshot)
+	return ceph.RollbackGroupSnapshot(c.context, g.Status.ClusterNamespace, g.Status.Pool, groupName(g), g.Spec.RestoreSnapshot)
+}
+
+func (c *VolumeGroupController) updateStatus(g *cephv1.CephVolumeGroup) error {
+	latest, err := c.context.RookClientset.CephV1().CephVolumeGroups(g.Namespace).Get(g.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	latest.Status = g.Status
+	_, err = c.context.RookClientset.CephV1().CephVolumeGroups(g.Namespace).Update(latest)
+	return err
+}
+
+// groupName returns the name of the rbd group of a volume group, unique in the kubernetes cluster
+func groupName(g *cephv1.CephVolumeGroup) string {
+	return fmt.Sprintf("%s.%s", g.Namespace, g.Name)
+}
+
+func hasGroupSnapshot(g *cephv1.CephVolumeGroup, name string) bool {
+	for _, s := range g.Status.Snapshots {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func getVolumeGroupObject(obj interface{}) (*cephv1.CephVolumeGroup, error) {
+	g, ok := obj.(*cephv1.CephVolumeGroup)
+	if ok {
+		return g.DeepCopy(), nil
+	}
+	return nil, fmt.Errorf("not a known volume group object: %+v", obj)
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReconcileVolumeGroup(t *testing.T) {
+	data, dataPV := newTestVolume("data")
+	wal, walPV := newTestVolume("wal")
+	dataPV.Annotations = map[string]string{provisionedByKey: "ceph.rook.io/block"}
+	g := &cephv1.CephVolumeGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "app"},
+		Spec:       cephv1.VolumeGroupSpec{PVCs: []string{"data", "wal"}, Snapshots: []string{"snap1"}},
+	}
+
+	commands := []string{}
+	groups := "[]"
+	groupImages := "[]"
+	watchers := `{"watchers": []}`
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(debug bool, actionName string, command string, args ...string) (string, error) {
+			// the args end with the config args of the cluster, and the output format
+			args = args[:len(args)-3]
+			if args[len(args)-1] == "json" {
+				args = args[:len(args)-2]
+			}
+			cmd := strings.Join(args, " ")
+			switch {
+			case strings.HasPrefix(cmd, "group ls"):
+				return groups, nil
+			case strings.HasPrefix(cmd, "group image list"):
+				return groupImages, nil
+			case strings.HasPrefix(cmd, "snap ls --all replicapool/pvc-data"):
+				return `[{"id": 4, "name": ".group.1_abc_def", "namespace": {"type": "group", "pool": "replicapool", "group": "app.db", "group snap": "snap1"}}]`, nil
+			case strings.HasPrefix(cmd, "status"):
+				return watchers, nil
+			}
+			commands = append(commands, cmd)
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Clientset:     fake.NewSimpleClientset(data, dataPV, wal, walPV),
+		RookClientset: rookfake.NewSimpleClientset(g),
+		Executor:      executor,
+	}
+	c := NewVolumeGroupController(context)
+
+	// the group is created with the images and snapshotted
+	assert.Nil(t, c.reconcile(g))
+	assert.Equal(t, []string{
+		"group create replicapool/app.db",
+		"group image add replicapool/app.db replicapool/pvc-data",
+		"group image add replicapool/app.db replicapool/pvc-wal",
+		"group snap create replicapool/app.db@snap1",
+	}, commands)
+	g, err := context.RookClientset.CephV1().CephVolumeGroups("app").Get("db", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "rook-ceph", g.Status.ClusterNamespace)
+	assert.Equal(t, "replicapool", g.Status.Pool)
+	assert.Equal(t, []cephv1.VolumeGroupImage{{PVC: "data", Pool: "replicapool", Image: "pvc-data"}, {PVC: "wal", Pool: "replicapool", Image: "pvc-wal"}},
+		g.Status.Volumes)
+	assert.Equal(t, 1, len(g.Status.Snapshots))
+
+	// the wal is removed from the group and the data is cloned
+	groups = `["app.db"]`
+	groupImages = `[{"image": "pvc-data", "pool": "replicapool"}, {"image": "pvc-wal", "pool": "replicapool"}]`
+	commands = []string{}
+	g.Spec.PVCs = []string{"data"}
+	g.Spec.Clones = []cephv1.VolumeGroupClone{{Name: "test", Snapshot: "snap1"}}
+	assert.Nil(t, c.reconcile(g))
+	assert.Equal(t, []string{
+		"group image rm replicapool/app.db replicapool/pvc-wal",
+		"clone --snap-id 4 --rbd-default-clone-format 2 replicapool/pvc-data replicapool/pvc-data-test",
+	}, commands)
+	assert.Equal(t, []string{"test"}, g.Status.Clones)
+	pvc, err := context.Clientset.CoreV1().PersistentVolumeClaims("app").Get("data-test", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "pvc-data-test", pvc.Spec.VolumeName)
+	pv, err := context.Clientset.CoreV1().PersistentVolumes().Get("pvc-data-test", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "pvc-data-test", pv.Spec.FlexVolume.Options["image"])
+	assert.Equal(t, "data-test", pv.Spec.ClaimRef.Name)
+	assert.Equal(t, "ceph.rook.io/block", pv.Annotations[provisionedByKey])
+	// the image of the source volume is not modified
+	dataPV, err = context.Clientset.CoreV1().PersistentVolumes().Get("pvc-data", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "pvc-data", dataPV.Spec.FlexVolume.Options["image"])
+
+	// the volumes are not rolled back while they are mapped
+	groupImages = `[{"image": "pvc-data", "pool": "replicapool"}]`
+	commands = []string{}
+	watchers = `{"watchers": [{"address": "10.0.0.1:0/1234"}]}`
+	g.Spec.RestoreSnapshot = "snap1"
+	assert.NotNil(t, c.reconcile(g))
+	assert.Equal(t, 0, len(commands))
+	assert.Equal(t, "", g.Status.RestoredSnapshot)
+	assert.Contains(t, g.Status.Message, "in use")
+
+	watchers = `{"watchers": []}`
+	assert.Nil(t, c.reconcile(g))
+	assert.Equal(t, []string{"group snap rollback replicapool/app.db@snap1"}, commands)
+	assert.Equal(t, "snap1", g.Status.RestoredSnapshot)
+	assert.Equal(t, "", g.Status.Message)
+
+	// the snapshot removed from the spec is deleted
+	commands = []string{}
+	g.Spec.Snapshots = nil
+	assert.Nil(t, c.reconcile(g))
+	assert.Equal(t, []string{"group snap rm replicapool/app.db@snap1"}, commands)
+	assert.Equal(t, 0, len(g.Status.Snapshots))
+
+	// the volumes of a group must be in the same cluster
+	walPV.Spec.FlexVolume.Options["clusterNamespace"] = "other"
+	_, err = context.Clientset.CoreV1().PersistentVolumes().Update(walPV)
+	assert.Nil(t, err)
+	g.Spec.PVCs = []string{"data", "wal"}
+	err = c.reconcile(g)
+	assert.NotNil(t, err)
+	assert.Equal(t, fmt.Sprintf("%+v", err), g.Status.Message)
+}
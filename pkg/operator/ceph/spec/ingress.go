@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// IngressClassAnnotation is the annotation selecting the ingress controller of an ingress
+	IngressClassAnnotation = "kubernetes.io/ingress.class"
+)
+
+// MakeIngress creates an ingress routing the hosts to the port of a service with the settings of the ingress spec.
+// Without hosts, the ingress routes all the requests it receives to the service.
+func MakeIngress(name, namespace string, labels map[string]string, hosts []string, serviceName string, servicePort int32,
+	spec cephv1.IngressSpec) *extensions.Ingress {
+
+	annotations := map[string]string{}
+	for key, value := range spec.Annotations {
+		annotations[key] = value
+	}
+	if spec.Class != "" {
+		annotations[IngressClassAnnotation] = spec.Class
+	}
+
+	ingress := &extensions.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+
+	backend := extensions.IngressBackend{
+		ServiceName: serviceName,
+		ServicePort: intstr.FromInt(int(servicePort)),
+	}
+	rule := func(host string) extensions.IngressRule {
+		return extensions.IngressRule{
+			Host: host,
+			IngressRuleValue: extensions.IngressRuleValue{
+				HTTP: &extensions.HTTPIngressRuleValue{
+					Paths: []extensions.HTTPIngressPath{{Path: "/", Backend: backend}},
+				},
+			},
+		}
+	}
+	if len(hosts) == 0 {
+		ingress.Spec.Rules = []extensions.IngressRule{rule("")}
+	}
+	for _, host := range hosts {
+		ingress.Spec.Rules = append(ingress.Spec.Rules, rule(host))
+	}
+
+	if spec.TLSSecretName != "" {
+		ingress.Spec.TLS = []extensions.IngressTLS{{Hosts: hosts, SecretName: spec.TLSSecretName}}
+	}
+	return ingress
+}
+
+// CreateOrUpdateIngress creates the ingress, or updates it if it already exists
+func CreateOrUpdateIngress(clientset kubernetes.Interface, ingress *extensions.Ingress) error {
+	_, err := clientset.ExtensionsV1beta1().Ingresses(ingress.Namespace).Create(ingress)
+	if err == nil {
+		logger.Infof("ingress %s created", ingress.Name)
+		return nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	// keep the status of the existing ingress with the address of the ingress controller
+	existing, err := clientset.ExtensionsV1beta1().Ingresses(ingress.Namespace).Get(ingress.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	existing.Labels = ingress.Labels
+	existing.Annotations = ingress.Annotations
+	existing.OwnerReferences = ingress.OwnerReferences
+	existing.Spec = ingress.Spec
+	if _, err := clientset.ExtensionsV1beta1().Ingresses(ingress.Namespace).Update(existing); err != nil {
+		return err
+	}
+	logger.Infof("ingress %s updated", ingress.Name)
+	return nil
+}
+
+// DeleteIngress deletes the ingress if it exists
+func DeleteIngress(clientset kubernetes.Interface, namespace, name string) error {
+	err := clientset.ExtensionsV1beta1().Ingresses(namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
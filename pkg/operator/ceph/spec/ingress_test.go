@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMakeIngress(t *testing.T) {
+	spec := cephv1.IngressSpec{Enabled: true, Class: "nginx", Annotations: map[string]string{"a": "b"}}
+	ingress := MakeIngress("myingress", "ns", map[string]string{"app": "myapp"}, []string{}, "mysvc", 80, spec)
+	assert.Equal(t, map[string]string{"a": "b", IngressClassAnnotation: "nginx"}, ingress.Annotations)
+	assert.Equal(t, map[string]string{"a": "b"}, spec.Annotations)
+	assert.Equal(t, "myapp", ingress.Labels["app"])
+	// all the requests are routed to the service without hosts
+	assert.Equal(t, 1, len(ingress.Spec.Rules))
+	assert.Equal(t, "", ingress.Spec.Rules[0].Host)
+	assert.Equal(t, "mysvc", ingress.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName)
+	assert.Equal(t, 80, ingress.Spec.Rules[0].HTTP.Paths[0].Backend.ServicePort.IntValue())
+	assert.Nil(t, ingress.Spec.TLS)
+
+	spec.TLSSecretName = "mycert"
+	ingress = MakeIngress("myingress", "ns", nil, []string{"a.example.com", "b.example.com"}, "mysvc", 80, spec)
+	assert.Equal(t, 2, len(ingress.Spec.Rules))
+	assert.Equal(t, "b.example.com", ingress.Spec.Rules[1].Host)
+	assert.Equal(t, []extensions.IngressTLS{{Hosts: []string{"a.example.com", "b.example.com"}, SecretName: "mycert"}}, ingress.Spec.TLS)
+}
+
+func TestCreateOrUpdateIngress(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	ingress := MakeIngress("myingress", "ns", nil, []string{"a.example.com"}, "mysvc", 80, cephv1.IngressSpec{})
+	err := CreateOrUpdateIngress(clientset, ingress)
+	assert.Nil(t, err)
+
+	// the existing ingress is updated
+	ingress = MakeIngress("myingress", "ns", nil, []string{"b.example.com"}, "mysvc", 80, cephv1.IngressSpec{})
+	err = CreateOrUpdateIngress(clientset, ingress)
+	assert.Nil(t, err)
+	existing, err := clientset.ExtensionsV1beta1().Ingresses("ns").Get("myingress", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "b.example.com", existing.Spec.Rules[0].Host)
+
+	err = DeleteIngress(clientset, "ns", "myingress")
+	assert.Nil(t, err)
+	// deleting a missing ingress is not an error
+	err = DeleteIngress(clientset, "ns", "myingress")
+	assert.Nil(t, err)
+}
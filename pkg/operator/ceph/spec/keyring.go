@@ -26,6 +26,7 @@ import (
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -78,3 +79,60 @@ func CreateKeyring(context *clusterd.Context, config KeyringConfig) error {
 
 	return nil
 }
+
+// StaleKeyrings returns the keyring secrets created by CreateKeyring or by the daemons which are not used by any
+// deployment or daemonset anymore, since their daemon was removed. A keyring deleted too early is created again with the
+// same key when its daemon is started.
+func StaleKeyrings(clientset kubernetes.Interface, namespace string) ([]v1.Secret, error) {
+	used := map[string]bool{}
+	deployments, err := clientset.ExtensionsV1beta1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments. %+v", err)
+	}
+	for _, d := range deployments.Items {
+		addUsedSecrets(used, d.Spec.Template.Spec)
+	}
+	daemonsets, err := clientset.ExtensionsV1beta1().DaemonSets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets. %+v", err)
+	}
+	for _, d := range daemonsets.Items {
+		addUsedSecrets(used, d.Spec.Template.Spec)
+	}
+
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets. %+v", err)
+	}
+	stale := []v1.Secret{}
+	for _, secret := range secrets.Items {
+		if _, ok := secret.Data[KeyringSecretKeyName]; !ok || len(secret.Data) != 1 || secret.Type != k8sutil.RookType {
+			continue
+		}
+		if !used[secret.Name] {
+			stale = append(stale, secret)
+		}
+	}
+	return stale, nil
+}
+
+// addUsedSecrets adds the secrets of the env vars and of the volumes of a pod spec
+func addUsedSecrets(used map[string]bool, spec v1.PodSpec) {
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil {
+			used[volume.Secret.SecretName] = true
+		}
+	}
+	for _, container := range append(spec.InitContainers, spec.Containers...) {
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				used[env.ValueFrom.SecretKeyRef.Name] = true
+			}
+		}
+		for _, env := range container.EnvFrom {
+			if env.SecretRef != nil {
+				used[env.SecretRef.Name] = true
+			}
+		}
+	}
+}
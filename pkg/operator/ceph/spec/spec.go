@@ -18,7 +18,10 @@ limitations under the License.
 package spec
 
 import (
+	"os"
+
 	"github.com/coreos/pkg/capnslog"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"k8s.io/api/core/v1"
@@ -28,6 +31,12 @@ const (
 	// ConfigInitContainerName is the name which is given to the config initialization container
 	// in all Ceph pods.
 	ConfigInitContainerName = "config-init"
+
+	defaultProbeInitialDelaySeconds    = 10
+	defaultOSDProbeInitialDelaySeconds = 60
+	defaultProbePeriodSeconds          = 10
+	defaultProbeTimeoutSeconds         = 5
+	defaultProbeFailureThreshold       = 3
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "ceph-spec")
@@ -45,6 +54,19 @@ func PodVolumes(dataDirHostPath string) []v1.Volume {
 	}
 }
 
+// HostPathRequiresPrivileged returns whether the hosts require privileged containers to write to the dataDirHostPath,
+// such as the SELinux hosts where the path is not labeled for the containers
+func HostPathRequiresPrivileged() bool {
+	return os.Getenv("ROOK_HOSTPATH_REQUIRES_PRIVILEGED") == "true"
+}
+
+// HostPathSecurityContext returns the security context of the containers mounting the dataDirHostPath. The containers
+// are privileged if the hosts require it to write to the host path.
+func HostPathSecurityContext() *v1.SecurityContext {
+	privileged := HostPathRequiresPrivileged()
+	return &v1.SecurityContext{Privileged: &privileged}
+}
+
 // CephVolumeMounts returns the common list of Kubernetes volume mounts for Ceph containers.
 func CephVolumeMounts() []v1.VolumeMount {
 	return []v1.VolumeMount{
@@ -82,3 +104,53 @@ func PodLabels(appName, namespace, daemonType, daemonID string) map[string]strin
 	labels[daemonType] = daemonID
 	return labels
 }
+
+// LivenessProbe returns a liveness probe checking that the daemon answers a status command on its admin socket, or nil
+// if the probe is disabled. The ceph args must point to the same config and cluster name as the daemon so the ceph
+// tool finds the admin socket of the daemon.
+// Daemon type is the Ceph daemon type: "mon", "mgr", "osd", "mds"
+// Daemon ID is the ID portion of the Ceph daemon name: "a" for "mon.a"; "c" for "mds.c"
+func LivenessProbe(daemonType, daemonID string, probe cephv1.ProbeSpec, cephArgs ...string) *v1.Probe {
+	if probe.Disabled {
+		return nil
+	}
+
+	command := append([]string{"ceph"}, cephArgs...)
+	command = append(command, "daemon", daemonType+"."+daemonID, adminSocketStatusCommand(daemonType))
+
+	initialDelay := int32(defaultProbeInitialDelaySeconds)
+	if daemonType == "osd" {
+		// the osds may take a long time to start after a dirty shutdown
+		initialDelay = defaultOSDProbeInitialDelaySeconds
+	}
+
+	return &v1.Probe{
+		Handler: v1.Handler{
+			Exec: &v1.ExecAction{Command: command},
+		},
+		InitialDelaySeconds: valueOrDefault(probe.InitialDelaySeconds, initialDelay),
+		PeriodSeconds:       valueOrDefault(probe.PeriodSeconds, defaultProbePeriodSeconds),
+		TimeoutSeconds:      valueOrDefault(probe.TimeoutSeconds, defaultProbeTimeoutSeconds),
+		FailureThreshold:    valueOrDefault(probe.FailureThreshold, defaultProbeFailureThreshold),
+	}
+}
+
+// the admin socket command answered by the daemon when it is alive
+func adminSocketStatusCommand(daemonType string) string {
+	switch daemonType {
+	case "mon":
+		return "mon_status"
+	case "mgr":
+		// the mgr does not have a status command on its admin socket
+		return "version"
+	default:
+		return "status"
+	}
+}
+
+func valueOrDefault(value, defaultValue int32) int32 {
+	if value > 0 {
+		return value
+	}
+	return defaultValue
+}
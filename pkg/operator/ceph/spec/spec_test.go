@@ -17,10 +17,13 @@ limitations under the License.
 package spec
 
 import (
+	"os"
 	"testing"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPodVolumes(t *testing.T) {
@@ -42,3 +45,43 @@ func TestMountsMatchVolumes(t *testing.T) {
 	}
 	volsMountsTestDef.TestMountsMatchVolumes(t)
 }
+
+func TestLivenessProbe(t *testing.T) {
+	// the default probe of a mon checks the mon status
+	probe := LivenessProbe("mon", "a", cephv1.ProbeSpec{})
+	assert.Equal(t, []string{"ceph", "daemon", "mon.a", "mon_status"}, probe.Exec.Command)
+	assert.Equal(t, int32(10), probe.InitialDelaySeconds)
+	assert.Equal(t, int32(10), probe.PeriodSeconds)
+	assert.Equal(t, int32(5), probe.TimeoutSeconds)
+	assert.Equal(t, int32(3), probe.FailureThreshold)
+
+	// the osds have a longer delay by default and pass the args to find their admin socket
+	probe = LivenessProbe("osd", "0", cephv1.ProbeSpec{}, "--conf", "/var/lib/rook/osd0/rook-ceph.config", "--cluster", "rook-ceph")
+	assert.Equal(t, []string{"ceph", "--conf", "/var/lib/rook/osd0/rook-ceph.config", "--cluster", "rook-ceph", "daemon", "osd.0", "status"},
+		probe.Exec.Command)
+	assert.Equal(t, int32(60), probe.InitialDelaySeconds)
+
+	// the settings override the defaults
+	probe = LivenessProbe("osd", "0", cephv1.ProbeSpec{InitialDelaySeconds: 600, PeriodSeconds: 30, TimeoutSeconds: 20, FailureThreshold: 10})
+	assert.Equal(t, int32(600), probe.InitialDelaySeconds)
+	assert.Equal(t, int32(30), probe.PeriodSeconds)
+	assert.Equal(t, int32(20), probe.TimeoutSeconds)
+	assert.Equal(t, int32(10), probe.FailureThreshold)
+
+	probe = LivenessProbe("mgr", "a", cephv1.ProbeSpec{})
+	assert.Equal(t, []string{"ceph", "daemon", "mgr.a", "version"}, probe.Exec.Command)
+
+	// no probe when disabled
+	assert.Nil(t, LivenessProbe("mds", "myfs-a", cephv1.ProbeSpec{Disabled: true}))
+}
+
+func TestHostPathSecurityContext(t *testing.T) {
+	assert.False(t, HostPathRequiresPrivileged())
+	assert.False(t, *HostPathSecurityContext().Privileged)
+
+	// the hosts require privileged containers to write to the host path
+	os.Setenv("ROOK_HOSTPATH_REQUIRES_PRIVILEGED", "true")
+	defer os.Unsetenv("ROOK_HOSTPATH_REQUIRES_PRIVILEGED")
+	assert.True(t, HostPathRequiresPrivileged())
+	assert.True(t, *HostPathSecurityContext().Privileged)
+}
@@ -115,10 +115,10 @@ func (d *Discover) createDiscoverDaemonSet(namespace, discoverImage, securityAcc
 									ReadOnly:  true,
 								},
 							},
-							Env: []v1.EnvVar{
+							Env: append([]v1.EnvVar{
 								k8sutil.NamespaceEnvVar(),
 								k8sutil.NodeEnvVar(),
-							},
+							}, k8sutil.LogFormatEnvVars(false)...),
 						},
 					},
 					Volumes: []v1.Volume{
@@ -25,6 +25,12 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+const (
+	// RestartedAtAnnotation is the annotation of the pod template of a deployment with the time of its last rolling
+	// restart, whose change restarts the pods. The operator does not generate it, so it is kept by the updates.
+	RestartedAtAnnotation = "ceph.rook.io/restartedAt"
+)
+
 // GetDeploymentImage returns the version of the image running in the pod spec for the desired container
 func GetDeploymentImage(clientset kubernetes.Interface, namespace, name, container string) (string, error) {
 	d, err := clientset.Extensions().Deployments(namespace).Get(name, metav1.GetOptions{})
@@ -86,6 +92,7 @@ func UpdateDeploymentAndWait(context *clusterd.Context, deployment *extensions.D
 		return fmt.Errorf("failed to get deployment %s. %+v", deployment.Name, err)
 	}
 
+	KeepRestartedAt(original, deployment)
 	logger.Infof("updating deployment %s", deployment.Name)
 	if _, err := context.Clientset.Extensions().Deployments(namespace).Update(deployment); err != nil {
 		return fmt.Errorf("failed to update deployment %s. %+v", deployment.Name, err)
@@ -127,3 +134,19 @@ func GetDeployments(clientset kubernetes.Interface, namespace, labelSelector str
 	}
 	return deployments, nil
 }
+
+// KeepRestartedAt copies the time of the last rolling restart of the pods of the original deployment to the updated
+// deployment, unless the update restarts the pods again, so that regenerating the spec does not restart the pods
+func KeepRestartedAt(original, deployment *extensions.Deployment) {
+	restartedAt, ok := original.Spec.Template.Annotations[RestartedAtAnnotation]
+	if !ok {
+		return
+	}
+	if _, ok := deployment.Spec.Template.Annotations[RestartedAtAnnotation]; ok {
+		return
+	}
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations[RestartedAtAnnotation] = restartedAt
+}
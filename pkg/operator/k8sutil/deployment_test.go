@@ -0,0 +1,44 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	extensions "k8s.io/api/extensions/v1beta1"
+)
+
+func TestKeepRestartedAt(t *testing.T) {
+	original := &extensions.Deployment{}
+	original.Spec.Template.Annotations = map[string]string{RestartedAtAnnotation: "2018-11-20T10:00:00Z", "other": "value"}
+
+	// the regenerated spec keeps the time of the last restart
+	d := &extensions.Deployment{}
+	KeepRestartedAt(original, d)
+	assert.Equal(t, map[string]string{RestartedAtAnnotation: "2018-11-20T10:00:00Z"}, d.Spec.Template.Annotations)
+
+	// a new restart is not reverted
+	d.Spec.Template.Annotations[RestartedAtAnnotation] = "2018-11-21T10:00:00Z"
+	KeepRestartedAt(original, d)
+	assert.Equal(t, "2018-11-21T10:00:00Z", d.Spec.Template.Annotations[RestartedAtAnnotation])
+
+	// the deployments never restarted are unchanged
+	d = &extensions.Deployment{}
+	KeepRestartedAt(&extensions.Deployment{}, d)
+	assert.Nil(t, d.Spec.Template.Annotations)
+}
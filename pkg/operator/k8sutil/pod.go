@@ -41,6 +41,8 @@ const (
 	PublicIPEnvVar = "ROOK_PUBLIC_IP"
 	// PrivateIPEnvVar pod IP env var
 	PrivateIPEnvVar = "ROOK_PRIVATE_IP"
+	// LogFormatEnvVar log format env var
+	LogFormatEnvVar = "ROOK_LOG_FORMAT"
 
 	// DefaultRepoPrefix repo prefix
 	DefaultRepoPrefix = "rook"
@@ -90,6 +92,20 @@ func NodeEnvVar() v1.EnvVar {
 	return v1.EnvVar{Name: NodeNameEnvVar, ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "spec.nodeName"}}}
 }
 
+// LogFormatEnvVars passes the log format of the operator to the rook daemons. The node env var is also added
+// for the structured logs unless the pod already has it.
+func LogFormatEnvVars(withNode bool) []v1.EnvVar {
+	format := os.Getenv(LogFormatEnvVar)
+	if format == "" {
+		return nil
+	}
+	envVars := []v1.EnvVar{{Name: LogFormatEnvVar, Value: format}}
+	if withNode {
+		envVars = append(envVars, NodeEnvVar())
+	}
+	return envVars
+}
+
 // ConfigDirEnvVar config dir env var
 func ConfigDirEnvVar(dataDir string) v1.EnvVar {
 	return v1.EnvVar{Name: "ROOK_CONFIG_DIR", Value: dataDir}
@@ -16,6 +16,7 @@ limitations under the License.
 package k8sutil
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -80,3 +81,18 @@ func TestGetPodPhaseMap(t *testing.T) {
 	// list of failed pods should have 1 entry
 	assert.Equal(t, 1, len(podPhaseMap[v1.PodFailed]))
 }
+
+func TestLogFormatEnvVars(t *testing.T) {
+	os.Unsetenv(LogFormatEnvVar)
+	assert.Nil(t, LogFormatEnvVars(true))
+
+	os.Setenv(LogFormatEnvVar, "json")
+	defer os.Unsetenv(LogFormatEnvVar)
+	envVars := LogFormatEnvVars(false)
+	assert.Equal(t, []v1.EnvVar{{Name: LogFormatEnvVar, Value: "json"}}, envVars)
+
+	envVars = LogFormatEnvVars(true)
+	assert.Equal(t, 2, len(envVars))
+	assert.Equal(t, NodeNameEnvVar, envVars[1].Name)
+	assert.Equal(t, "spec.nodeName", envVars[1].ValueFrom.FieldRef.FieldPath)
+}
@@ -37,8 +37,17 @@ func (e *CommandError) Error() string {
 }
 
 func (e *CommandError) ExitStatus() int {
+	return ExitStatus(e.Err)
+}
+
+// ExitStatus returns the exit status of a failed command, or -1 if the command did not exit. The error is either a
+// CommandError or the ExitError returned as is by ExecuteCommandWithOutputFile.
+func ExitStatus(err error) int {
+	if cmdErr, ok := err.(*CommandError); ok {
+		err = cmdErr.Err
+	}
 	exitStatus := -1
-	exitErr, ok := e.Err.(*exec.ExitError)
+	exitErr, ok := err.(*exec.ExitError)
 	if ok {
 		waitStatus, ok := exitErr.ProcessState.Sys().(syscall.WaitStatus)
 		if ok {
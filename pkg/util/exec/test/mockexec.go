@@ -16,11 +16,17 @@ limitations under the License.
 package test
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"time"
 )
 
+// ExitError returns the error of a process that exited with the status, as returned by the executor
+func ExitError(status int) error {
+	return exec.Command("sh", "-c", fmt.Sprintf("exit %d", status)).Run()
+}
+
 // ******************** MockExecutor ********************
 type MockExecutor struct {
 	MockExecuteCommand                   func(debug bool, actionName string, command string, arg ...string) error
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exposes the prometheus metrics of the rook daemons and traces slow operations.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "metrics")
+
+var (
+	// SlowOperationThreshold is the duration after which an operation is reported as slow
+	SlowOperationThreshold = 30 * time.Second
+
+	// DurationBuckets are the histogram buckets for the duration of volume operations, from 100ms to ~7min
+	DurationBuckets = prometheus.ExponentialBuckets(0.1, 2, 13)
+)
+
+// StartServer serves the registered prometheus metrics at /metrics on the given port in the background.
+// The server is not started if the port is not set.
+func StartServer(port int) {
+	if port <= 0 {
+		logger.Infof("metrics server is disabled")
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		logger.Infof("serving metrics on port %d", port)
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", port), mux); err != nil {
+			logger.Errorf("metrics server stopped. %+v", err)
+		}
+	}()
+}
+
+// OperationTrace times the steps of an operation. The steps are logged at the TRACE level and the whole
+// operation is logged as a warning if it takes longer than the SlowOperationThreshold.
+type OperationTrace struct {
+	logger *capnslog.PackageLogger
+	name   string
+	start  time.Time
+	last   time.Time
+	steps  []string
+}
+
+// NewOperationTrace starts the trace of the named operation
+func NewOperationTrace(logger *capnslog.PackageLogger, name string) *OperationTrace {
+	now := time.Now()
+	return &OperationTrace{logger: logger, name: name, start: now, last: now}
+}
+
+// Step records that a step of the operation has completed
+func (t *OperationTrace) Step(step string) {
+	now := time.Now()
+	elapsed := now.Sub(t.last)
+	t.last = now
+	t.steps = append(t.steps, fmt.Sprintf("%s=%v", step, elapsed))
+	t.logger.Tracef("%s: %s completed in %v", t.name, step, elapsed)
+}
+
+// Done completes the trace and returns the total duration of the operation
+func (t *OperationTrace) Done() time.Duration {
+	total := time.Since(t.start)
+	t.logger.Tracef("%s: completed in %v", t.name, total)
+	if total > SlowOperationThreshold {
+		t.logger.Warningf("%s: slow operation took %v. steps: %v", t.name, total, t.steps)
+	}
+	return total
+}
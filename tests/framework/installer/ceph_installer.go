@@ -375,6 +375,15 @@ func (h *CephInstaller) UninstallRookFromMultipleNS(helmInstalled bool, systemNa
 		"cephobjectstores.ceph.rook.io",
 		"cephobjectstoreusers.ceph.rook.io",
 		"cephfilesystems.ceph.rook.io",
+		"cephobjectbuckets.ceph.rook.io",
+		"cephcosidrivers.ceph.rook.io",
+		"cephmetadatabackups.ceph.rook.io",
+		"cephsmbgateways.ceph.rook.io",
+		"cephmirroringstatuses.ceph.rook.io",
+		"cephblockpoolradosnamespaces.ceph.rook.io",
+		"cephbackupsnapshots.ceph.rook.io",
+		"cephvolumegroups.ceph.rook.io",
+		"cephcrushrules.ceph.rook.io",
 		"volumes.rook.io")
 	checkError(h.T(), err, "cannot delete CRDs")
 
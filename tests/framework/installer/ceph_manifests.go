@@ -212,6 +212,132 @@ spec:
 ---
 apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
+metadata:
+  name: cephobjectbuckets.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephObjectBucket
+    listKind: CephObjectBucketList
+    plural: cephobjectbuckets
+    singular: cephobjectbucket
+  scope: Namespaced
+  version: v1
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephcosidrivers.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephCOSIDriver
+    listKind: CephCOSIDriverList
+    plural: cephcosidrivers
+    singular: cephcosidriver
+  scope: Namespaced
+  version: v1
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephbackupsnapshots.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephBackupSnapshot
+    listKind: CephBackupSnapshotList
+    plural: cephbackupsnapshots
+    singular: cephbackupsnapshot
+  scope: Namespaced
+  version: v1
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephvolumegroups.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephVolumeGroup
+    listKind: CephVolumeGroupList
+    plural: cephvolumegroups
+    singular: cephvolumegroup
+  scope: Namespaced
+  version: v1
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephmetadatabackups.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephMetadataBackup
+    listKind: CephMetadataBackupList
+    plural: cephmetadatabackups
+    singular: cephmetadatabackup
+  scope: Namespaced
+  version: v1
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephsmbgateways.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephSMBGateway
+    listKind: CephSMBGatewayList
+    plural: cephsmbgateways
+    singular: cephsmbgateway
+  scope: Namespaced
+  version: v1
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephmirroringstatuses.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephMirroringStatus
+    listKind: CephMirroringStatusList
+    plural: cephmirroringstatuses
+    singular: cephmirroringstatus
+  scope: Namespaced
+  version: v1
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephblockpoolradosnamespaces.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephBlockPoolRadosNamespace
+    listKind: CephBlockPoolRadosNamespaceList
+    plural: cephblockpoolradosnamespaces
+    singular: cephblockpoolradosnamespace
+  scope: Namespaced
+  version: v1
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephcrushrules.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephCrushRule
+    listKind: CephCrushRuleList
+    plural: cephcrushrules
+    singular: cephcrushrule
+  scope: Namespaced
+  version: v1
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
 metadata:
   name: volumes.rook.io
 spec:
@@ -281,6 +407,7 @@ rules:
   - pods
   - pods/log
   - services
+  - endpoints
   - configmaps
   verbs:
   - get
@@ -318,10 +445,17 @@ rules:
   - pods
   - nodes
   - nodes/proxy
+  - namespaces
   verbs:
   - get
   - list
   - watch
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  verbs:
+  - patch
 - apiGroups:
   - ""
   resources:
@@ -758,7 +892,8 @@ metadata:
   namespace: ` + namespace + `
 spec:
   replicated:
-    size: ` + replicaSize
+    size: ` + replicaSize + `
+    requireSafeReplicaSize: false`
 }
 
 func (m *CephManifestsMaster) GetBlockStorageClassDef(poolName string, storageClassName string, reclaimPolicy string, namespace string, varClusterName bool) string {
@@ -812,9 +947,11 @@ spec:
   metadataPool:
     replicated:
       size: 1
+      requireSafeReplicaSize: false
   dataPools:
   - replicated:
       size: 1
+      requireSafeReplicaSize: false
   metadataServer:
     activeCount: ` + strconv.Itoa(activeCount) + `
     activeStandby: true`
@@ -830,9 +967,11 @@ spec:
   metadataPool:
     replicated:
       size: 1
+      requireSafeReplicaSize: false
   dataPool:
     replicated:
       size: 1
+      requireSafeReplicaSize: false
   gateway:
     type: s3
     sslCertificateRef:
@@ -34,7 +34,7 @@ function purge_rook_pods {
   # versions > 0.8 use resource type "cephcluster".
   kubectl delete -n rook-ceph cluster rook-ceph || true
   kubectl delete -n rook-ceph cephcluster rook-ceph || true
-  kubectl delete crd cephclusters.ceph.rook.io cephblockpools.ceph.rook.io cephobjectstores.ceph.rook.io cephobjectstoreusers.ceph.rook.io cephfilesystems.ceph.rook.io volumes.rook.io || true
+  kubectl delete crd cephclusters.ceph.rook.io cephblockpools.ceph.rook.io cephobjectstores.ceph.rook.io cephobjectstoreusers.ceph.rook.io cephfilesystems.ceph.rook.io cephcrushrules.ceph.rook.io cephobjectbuckets.ceph.rook.io cephcosidrivers.ceph.rook.io cephbackupsnapshots.ceph.rook.io cephvolumegroups.ceph.rook.io cephmetadatabackups.ceph.rook.io cephsmbgateways.ceph.rook.io cephmirroringstatuses.ceph.rook.io cephblockpoolradosnamespaces.ceph.rook.io volumes.rook.io || true
   kubectl delete -n rook-ceph-system daemonset rook-ceph-agent || true
   kubectl delete -f operator.yaml || true
   kubectl delete clusterroles rook-ceph-agent || true